@@ -0,0 +1,644 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/cmd/devp2p/internal/ethtest"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/rlpx"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Database layout of the census.
+var (
+	censusNodePrefix    = []byte("n") // censusNodePrefix + id -> censusNode
+	censusHistoryPrefix = []byte("h") // censusHistoryPrefix + id + time -> censusEvent
+	censusStatsPrefix   = []byte("s") // censusStatsPrefix + time -> censusStats
+)
+
+const (
+	censusDialTimeout      = 10 * time.Second
+	censusHandshakeTimeout = 10 * time.Second
+	censusRevalidateTick   = time.Minute
+)
+
+var errNoTCPEndpoint = errors.New("node has no TCP endpoint")
+
+// censusNode is the state of a node in the census.
+type censusNode struct {
+	N       *enode.Node `json:"record"`
+	Sources []string    `json:"sources,omitempty"`
+
+	// These track when the node was found by discovery.
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+	// These track the RLPx handshakes with the node.
+	LastCheck    time.Time `json:"lastCheck,omitempty"`
+	LastResponse time.Time `json:"lastResponse,omitempty"`
+	Error        string    `json:"error,omitempty"`
+
+	// Information learned through the handshake.
+	Client    string        `json:"client,omitempty"`
+	Caps      []string      `json:"caps,omitempty"`
+	NetworkID uint64        `json:"networkID,omitempty"`
+	ForkID    *censusForkID `json:"forkID,omitempty"`
+}
+
+// censusForkID is the JSON representation of a fork identifier.
+type censusForkID struct {
+	Hash hexutil.Bytes `json:"hash"`
+	Next uint64        `json:"next"`
+}
+
+func newCensusForkID(id forkid.ID) *censusForkID {
+	return &censusForkID{Hash: id.Hash[:], Next: id.Next}
+}
+
+func (id *censusForkID) forkID() (fid forkid.ID) {
+	copy(fid.Hash[:], id.Hash)
+	fid.Next = id.Next
+	return fid
+}
+
+func (id *censusForkID) String() string {
+	if id == nil {
+		return ""
+	}
+	return fmt.Sprintf("%#x/%d", []byte(id.Hash), id.Next)
+}
+
+// censusEvent is a history entry, recorded whenever the handshake
+// result of a node changes.
+type censusEvent struct {
+	Time      time.Time     `json:"time"`
+	Reachable bool          `json:"reachable"`
+	Client    string        `json:"client,omitempty"`
+	ForkID    *censusForkID `json:"forkID,omitempty"`
+}
+
+// censusStats are the aggregate statistics over all active nodes.
+type censusStats struct {
+	Time      time.Time               `json:"time"`
+	Nodes     int                     `json:"nodes"`
+	Reachable int                     `json:"reachable"`
+	Clients   map[string]*clientStats `json:"clients"`
+	ForkIDs   map[string]int          `json:"forkIDs"`
+	Readiness *forkReadiness          `json:"readiness"`
+}
+
+type clientStats struct {
+	Count    int            `json:"count"`
+	Versions map[string]int `json:"versions"`
+}
+
+// forkReadiness counts the nodes by their fork identifier relative to the
+// local one of the crawled network.
+type forkReadiness struct {
+	ForkID       *censusForkID `json:"forkID"`
+	Ready        int           `json:"ready"`        // same fork, and next fork scheduled
+	NotReady     int           `json:"notReady"`     // same fork, but next fork not scheduled
+	Other        int           `json:"other"`        // compatible, but on a different fork
+	Incompatible int           `json:"incompatible"` // rejected by the fork filter
+	Unknown      int           `json:"unknown"`      // no fork identifier known
+}
+
+// handshakeResult is the information learned about a node through RLPx.
+type handshakeResult struct {
+	Client    string
+	Caps      []string
+	NetworkID uint64
+	ForkID    *forkid.ID
+}
+
+// census crawls the network continuously and tracks what it finds.
+type census struct {
+	db        ethdb.KeyValueStore
+	key       *ecdsa.PrivateKey
+	resolvers map[string]resolver
+	config    *params.ChainConfig
+	genesis   *types.Block
+	filter    forkid.Filter
+
+	revalidateInterval time.Duration
+	activeWindow       time.Duration
+
+	// hooks for testing
+	now       func() time.Time
+	handshake func(*enode.Node) (*handshakeResult, error)
+
+	mu    sync.RWMutex
+	nodes map[enode.ID]*censusNode
+}
+
+// censusInput is a node found by one of the census sources.
+type censusInput struct {
+	node   *enode.Node
+	source string
+}
+
+func newCensus(db ethdb.KeyValueStore, key *ecdsa.PrivateKey, config *params.ChainConfig, genesis *types.Block) (*census, error) {
+	c := &census{
+		db:                 db,
+		key:                key,
+		resolvers:          make(map[string]resolver),
+		config:             config,
+		genesis:            genesis,
+		filter:             forkid.NewStaticFilter(config, genesis),
+		revalidateInterval: 30 * time.Minute,
+		activeWindow:       24 * time.Hour,
+		now:                time.Now,
+		nodes:              make(map[enode.ID]*censusNode),
+	}
+	c.handshake = c.rlpxHandshake
+
+	it := db.NewIterator(censusNodePrefix, nil)
+	defer it.Release()
+	for it.Next() {
+		var n censusNode
+		if err := json.Unmarshal(it.Value(), &n); err != nil {
+			return nil, fmt.Errorf("invalid census entry %x: %v", it.Key(), err)
+		}
+		c.nodes[n.N.ID()] = &n
+	}
+	log.Info("Loaded census database", "nodes", len(c.nodes))
+	return c, it.Error()
+}
+
+// run crawls the given sources until the context is canceled. Known nodes are
+// revalidated periodically, and aggregate statistics are stored at the snapshot interval.
+func (c *census) run(ctx context.Context, sources map[string]enode.Iterator, nthreads int, snapshotInterval time.Duration) {
+	var (
+		inputs = make(chan censusInput)
+		wg     sync.WaitGroup
+	)
+	for name, it := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer it.Close()
+			c.runIterator(ctx, inputs, it, name)
+		}()
+	}
+	go func() {
+		<-ctx.Done()
+		for _, it := range sources {
+			it.Close()
+		}
+	}()
+	for i := 0; i < max(nthreads, 1); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case in := <-inputs:
+					c.update(in.node, in.source)
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	revalidate := time.NewTicker(censusRevalidateTick)
+	defer revalidate.Stop()
+	snapshot := time.NewTicker(snapshotInterval)
+	defer snapshot.Stop()
+	for {
+		select {
+		case <-revalidate.C:
+			for _, n := range c.revalidationCandidates() {
+				select {
+				case inputs <- censusInput{node: n}:
+				case <-ctx.Done():
+				}
+			}
+		case <-snapshot.C:
+			c.storeStats()
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+	}
+}
+
+func (c *census) runIterator(ctx context.Context, inputs chan<- censusInput, it enode.Iterator, source string) {
+	for it.Next() {
+		select {
+		case inputs <- censusInput{node: it.Node(), source: source}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// revalidationCandidates returns the active nodes which are due for a check.
+func (c *census) revalidationCandidates() []*enode.Node {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var (
+		now    = c.now()
+		result []*enode.Node
+	)
+	for _, n := range c.nodes {
+		if c.isActive(n, now) && now.Sub(n.LastCheck) >= c.revalidateInterval {
+			result = append(result, n.N)
+		}
+	}
+	return result
+}
+
+// update processes a node found by a source, or due for revalidation if source is empty.
+func (c *census) update(n *enode.Node, source string) {
+	now := c.now().UTC().Truncate(time.Second)
+
+	c.mu.Lock()
+	node := c.nodes[n.ID()]
+	if node == nil {
+		node = &censusNode{N: n, FirstSeen: now}
+		c.nodes[n.ID()] = node
+	}
+	if source != "" {
+		node.LastSeen = now
+		if !slices.Contains(node.Sources, source) {
+			node.Sources = append(node.Sources, source)
+			slices.Sort(node.Sources)
+		}
+	}
+	if now.Sub(node.LastCheck) < c.revalidateInterval {
+		c.mu.Unlock()
+		return
+	}
+	firstCheck := node.LastCheck.IsZero()
+	node.LastCheck = now
+	c.mu.Unlock()
+
+	// Get the latest record.
+	if r := c.resolver(source, node.Sources); r != nil {
+		if nn, err := r.RequestENR(n); err == nil {
+			n = nn
+		}
+	}
+	res, err := c.handshake(n)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if node.N.Seq() <= n.Seq() {
+		node.N = n
+	}
+	prev := censusEvent{Reachable: !node.LastResponse.IsZero() && node.Error == "", Client: node.Client, ForkID: node.ForkID}
+	if err != nil {
+		log.Debug("Census handshake failed", "id", n.ID(), "err", err)
+		node.Error = err.Error()
+	} else {
+		node.Error = ""
+		node.LastResponse = now
+		node.Client = res.Client
+		node.Caps = res.Caps
+		if res.ForkID != nil {
+			node.NetworkID = res.NetworkID
+			node.ForkID = newCensusForkID(*res.ForkID)
+		}
+	}
+	// Fall back to the fork identifier announced in the record.
+	if node.ForkID == nil {
+		if id, ok := enrForkID(node.N); ok {
+			node.ForkID = newCensusForkID(id)
+		}
+	}
+	ev := censusEvent{Time: now, Reachable: err == nil, Client: node.Client, ForkID: node.ForkID}
+	if firstCheck || ev.Reachable != prev.Reachable || ev.Client != prev.Client || ev.ForkID.String() != prev.ForkID.String() {
+		c.storeEvent(n.ID(), &ev)
+	}
+	c.storeNode(node)
+}
+
+// enrForkID returns the fork identifier in the `eth` entry of a record.
+func enrForkID(n *enode.Node) (forkid.ID, bool) {
+	var entry ethENREntry
+	if err := n.Load(&entry); err != nil {
+		return forkid.ID{}, false
+	}
+	return entry.ForkID, true
+}
+
+// ethENREntry is the `eth` entry of a node record.
+type ethENREntry struct {
+	ForkID forkid.ID
+
+	// Ignore additional fields (for forward compatibility).
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// ENRKey implements enr.Entry.
+func (e ethENREntry) ENRKey() string {
+	return "eth"
+}
+
+// resolver returns the discovery resolver for the given source. For revalidation,
+// any of the sources the node was found by is used.
+func (c *census) resolver(source string, known []string) resolver {
+	if source != "" {
+		return c.resolvers[source]
+	}
+	for _, s := range known {
+		if r := c.resolvers[s]; r != nil {
+			return r
+		}
+	}
+	return nil
+}
+
+func (c *census) isActive(n *censusNode, now time.Time) bool {
+	return now.Sub(n.LastSeen) < c.activeWindow || now.Sub(n.LastResponse) < c.activeWindow
+}
+
+func (c *census) storeNode(n *censusNode) {
+	enc, err := json.Marshal(n)
+	if err != nil {
+		panic(err)
+	}
+	id := n.N.ID()
+	if err := c.db.Put(append(censusNodePrefix, id[:]...), enc); err != nil {
+		log.Error("Failed to store census node", "id", id, "err", err)
+	}
+}
+
+func (c *census) storeEvent(id enode.ID, ev *censusEvent) {
+	enc, _ := json.Marshal(ev)
+	key := append(append(slices.Clone(censusHistoryPrefix), id[:]...), encodeCensusTime(ev.Time)...)
+	if err := c.db.Put(key, enc); err != nil {
+		log.Error("Failed to store census event", "id", id, "err", err)
+	}
+}
+
+// history returns the recorded events of a node.
+func (c *census) history(id enode.ID) []*censusEvent {
+	it := c.db.NewIterator(append(slices.Clone(censusHistoryPrefix), id[:]...), nil)
+	defer it.Release()
+
+	events := []*censusEvent{}
+	for it.Next() {
+		var ev censusEvent
+		if err := json.Unmarshal(it.Value(), &ev); err == nil {
+			events = append(events, &ev)
+		}
+	}
+	return events
+}
+
+// storeStats records the current aggregate statistics.
+func (c *census) storeStats() {
+	stats := c.stats()
+	enc, _ := json.Marshal(stats)
+	key := append(slices.Clone(censusStatsPrefix), encodeCensusTime(stats.Time)...)
+	if err := c.db.Put(key, enc); err != nil {
+		log.Error("Failed to store census stats", "err", err)
+	}
+	log.Info("Census snapshot", "nodes", stats.Nodes, "reachable", stats.Reachable, "clients", len(stats.Clients))
+}
+
+// statsHistory returns the most recent stored statistics, oldest first.
+func (c *census) statsHistory(limit int) []*censusStats {
+	it := c.db.NewIterator(censusStatsPrefix, nil)
+	defer it.Release()
+
+	result := []*censusStats{}
+	for it.Next() {
+		var s censusStats
+		if err := json.Unmarshal(it.Value(), &s); err == nil {
+			result = append(result, &s)
+		}
+	}
+	if len(result) > limit {
+		result = result[len(result)-limit:]
+	}
+	return result
+}
+
+func encodeCensusTime(t time.Time) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(t.Unix()))
+}
+
+// stats computes aggregate statistics over the active nodes.
+func (c *census) stats() *censusStats {
+	now := c.now()
+	local := forkid.NewID(c.config, c.genesis, math.MaxUint64, uint64(now.Unix()))
+	stats := &censusStats{
+		Time:      now.UTC().Truncate(time.Second),
+		Clients:   make(map[string]*clientStats),
+		ForkIDs:   make(map[string]int),
+		Readiness: &forkReadiness{ForkID: newCensusForkID(local)},
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, n := range c.nodes {
+		if !c.isActive(n, now) {
+			continue
+		}
+		stats.Nodes++
+		if n.Error == "" && now.Sub(n.LastResponse) < c.activeWindow {
+			stats.Reachable++
+		}
+		if n.Client != "" {
+			name, version := parseClientName(n.Client)
+			cs := stats.Clients[name]
+			if cs == nil {
+				cs = &clientStats{Versions: make(map[string]int)}
+				stats.Clients[name] = cs
+			}
+			cs.Count++
+			cs.Versions[version]++
+		}
+		if n.ForkID == nil {
+			stats.Readiness.Unknown++
+			continue
+		}
+		stats.ForkIDs[n.ForkID.String()]++
+		id := n.ForkID.forkID()
+		switch {
+		case c.filter(id) != nil:
+			stats.Readiness.Incompatible++
+		case id.Hash != local.Hash:
+			stats.Readiness.Other++
+		case id.Next == local.Next:
+			stats.Readiness.Ready++
+		default:
+			stats.Readiness.NotReady++
+		}
+	}
+	return stats
+}
+
+// parseClientName splits a client identifier like "Geth/v1.15.0-stable/linux-amd64/go1.24"
+// into the client name and version.
+func parseClientName(s string) (name, version string) {
+	parts := strings.Split(s, "/")
+	name = parts[0]
+	for _, p := range parts[1:] {
+		// Some clients put an instance name before the version.
+		if strings.HasPrefix(p, "v") && len(p) > 1 && p[1] >= '0' && p[1] <= '9' {
+			version = p
+			break
+		}
+	}
+	if version == "" {
+		version = "unknown"
+	}
+	return name, version
+}
+
+// rlpxHandshake connects to the node and performs the RLPx and devp2p handshakes.
+// It also waits for the eth status message if the node supports eth.
+func (c *census) rlpxHandshake(n *enode.Node) (*handshakeResult, error) {
+	addr, ok := n.TCPEndpoint()
+	if !ok {
+		return nil, errNoTCPEndpoint
+	}
+	fd, err := net.DialTimeout("tcp", addr.String(), censusDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn := rlpx.NewConn(fd, n.Pubkey())
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(censusHandshakeTimeout))
+
+	if _, err := conn.Handshake(c.key); err != nil {
+		return nil, err
+	}
+	ourHello := &ethtest.Hello{
+		Version: 5,
+		Name:    "devp2p-crawler",
+		ID:      crypto.FromECDSAPub(&c.key.PublicKey)[1:],
+	}
+	for _, v := range eth.ProtocolVersions {
+		ourHello.Caps = append(ourHello.Caps, p2p.Cap{Name: eth.ProtocolName, Version: v})
+	}
+	enc, _ := rlp.EncodeToBytes(ourHello)
+	if _, err := conn.Write(0, enc); err != nil {
+		return nil, err
+	}
+	hello, err := readCensusHello(conn)
+	if err != nil {
+		return nil, err
+	}
+	if hello.Version >= 5 {
+		conn.SetSnappy(true)
+	}
+	// Say goodbye when done.
+	defer func() {
+		enc, _ := rlp.EncodeToBytes([]any{p2p.DiscRequested})
+		conn.Write(1, enc)
+	}()
+
+	res := &handshakeResult{Client: hello.Name}
+	supportsEth := false
+	for _, cap := range hello.Caps {
+		res.Caps = append(res.Caps, cap.String())
+		supportsEth = supportsEth || (cap.Name == eth.ProtocolName && slices.Contains(eth.ProtocolVersions, cap.Version))
+	}
+	if !supportsEth {
+		return res, nil
+	}
+	// Wait for the status. Errors are not reported here because the
+	// client information is valid even if the node refuses to talk eth.
+	for {
+		code, data, _, err := conn.Read()
+		if err != nil {
+			return res, nil
+		}
+		switch code {
+		case 1: // disconnect
+			return res, nil
+		case 2: // ping
+			conn.Write(3, []byte{0xc0})
+		case 16: // eth status
+			res.NetworkID, res.ForkID = decodeCensusStatus(data)
+			return res, nil
+		}
+	}
+}
+
+func readCensusHello(conn *rlpx.Conn) (*ethtest.Hello, error) {
+	code, data, _, err := conn.Read()
+	if err != nil {
+		return nil, err
+	}
+	switch code {
+	case 0:
+		var h ethtest.Hello
+		if err := rlp.DecodeBytes(data, &h); err != nil {
+			return nil, fmt.Errorf("invalid handshake: %v", err)
+		}
+		return &h, nil
+	case 1:
+		var msg []p2p.DiscReason
+		if rlp.DecodeBytes(data, &msg); len(msg) == 0 {
+			return nil, errors.New("invalid disconnect message")
+		}
+		return nil, fmt.Errorf("received disconnect message: %v", msg[0])
+	default:
+		return nil, fmt.Errorf("invalid message code %d, expected handshake", code)
+	}
+}
+
+// decodeCensusStatus extracts the network and fork identifiers from an eth status.
+func decodeCensusStatus(data []byte) (uint64, *forkid.ID) {
+	var version struct {
+		ProtocolVersion uint32
+		Rest            []rlp.RawValue `rlp:"tail"`
+	}
+	if err := rlp.DecodeBytes(data, &version); err != nil {
+		return 0, nil
+	}
+	if version.ProtocolVersion >= eth.ETH69 {
+		var status eth.StatusPacket69
+		if err := rlp.DecodeBytes(data, &status); err != nil {
+			return 0, nil
+		}
+		return status.NetworkID, &status.ForkID
+	}
+	var status eth.StatusPacket68
+	if err := rlp.DecodeBytes(data, &status); err != nil {
+		return 0, nil
+	}
+	return status.NetworkID, &status.ForkID
+}
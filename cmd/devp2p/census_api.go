@@ -0,0 +1,125 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+const defaultStatsHistory = 100
+
+// newCensusAPI creates the HTTP handler serving the census. The endpoints are:
+//
+//	GET /api/stats          aggregate statistics of the active nodes
+//	GET /api/history        stored statistics snapshots (?limit=N)
+//	GET /api/nodes          active nodes (?client=NAME filters by client)
+//	GET /api/nodes/{id}     a single node and its handshake history
+func newCensusAPI(c *census) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/stats", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONResponse(w, c.stats())
+	})
+	mux.HandleFunc("GET /api/history", func(w http.ResponseWriter, r *http.Request) {
+		limit := defaultStatsHistory
+		if s := r.URL.Query().Get("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n <= 0 {
+				http.Error(w, "invalid limit", http.StatusBadRequest)
+				return
+			}
+			limit = n
+		}
+		writeJSONResponse(w, c.statsHistory(limit))
+	})
+	mux.HandleFunc("GET /api/nodes", func(w http.ResponseWriter, r *http.Request) {
+		writeJSONResponse(w, c.activeNodes(r.URL.Query().Get("client")))
+	})
+	mux.HandleFunc("GET /api/nodes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := enode.ParseID(r.PathValue("id"))
+		if err != nil {
+			http.Error(w, "invalid node ID", http.StatusBadRequest)
+			return
+		}
+		n := c.node(id)
+		if n == nil {
+			http.Error(w, "node not found", http.StatusNotFound)
+			return
+		}
+		writeJSONResponse(w, struct {
+			*censusNode
+			History []*censusEvent `json:"history"`
+		}{n, c.history(id)})
+	})
+	return mux
+}
+
+// activeNodes returns copies of the active nodes, optionally filtered by client name.
+func (c *census) activeNodes(client string) []*censusNode {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	now := c.now()
+	result := []*censusNode{}
+	for _, n := range c.nodes {
+		if !c.isActive(n, now) {
+			continue
+		}
+		if client != "" {
+			if name, _ := parseClientName(n.Client); !strings.EqualFold(name, client) {
+				continue
+			}
+		}
+		result = append(result, n.copy())
+	}
+	slices.SortFunc(result, func(a, b *censusNode) int {
+		ida, idb := a.N.ID(), b.N.ID()
+		return strings.Compare(string(ida[:]), string(idb[:]))
+	})
+	return result
+}
+
+// node returns a copy of the census entry of a node.
+func (c *census) node(id enode.ID) *censusNode {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	n := c.nodes[id]
+	if n == nil {
+		return nil
+	}
+	return n.copy()
+}
+
+func (n *censusNode) copy() *censusNode {
+	cpy := *n
+	cpy.Sources = slices.Clone(n.Sources)
+	return &cpy
+}
+
+func writeJSONResponse(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Debug("Failed to write census response", "err", err)
+	}
+}
@@ -0,0 +1,358 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func TestParseClientName(t *testing.T) {
+	tests := []struct {
+		input, name, version string
+	}{
+		{"Geth/v1.15.0-stable-756cca7c/linux-amd64/go1.24.1", "Geth", "v1.15.0-stable-756cca7c"},
+		{"Geth/mynode/v1.14.12-stable/linux-amd64/go1.23.4", "Geth", "v1.14.12-stable"},
+		{"Nethermind/v1.31.0+2ed7f6d4/linux-x64/dotnet9.0.2", "Nethermind", "v1.31.0+2ed7f6d4"},
+		{"reth/v1.2.0-1a2b3c4/x86_64-unknown-linux-gnu", "reth", "v1.2.0-1a2b3c4"},
+		{"erigon/3.0.0/linux-amd64/go1.23.5", "erigon", "unknown"},
+		{"besu", "besu", "unknown"},
+	}
+	for _, test := range tests {
+		name, version := parseClientName(test.input)
+		if name != test.name || version != test.version {
+			t.Errorf("%q: got (%q, %q), want (%q, %q)", test.input, name, version, test.name, test.version)
+		}
+	}
+}
+
+// censusTest is a census with a fake clock and handshake.
+type censusTest struct {
+	*census
+	time    time.Time
+	results map[enode.ID]*handshakeResult
+}
+
+func newCensusTest(t *testing.T, db ethdb.KeyValueStore) *censusTest {
+	genesis := core.DefaultGenesisBlock().ToBlock()
+	c, err := newCensus(db, nil, params.MainnetChainConfig, genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ct := &censusTest{
+		census: c,
+		// Shanghai is active, Cancun is scheduled.
+		time:    time.Unix(int64(*params.MainnetChainConfig.CancunTime), 0).Add(-30 * 24 * time.Hour),
+		results: make(map[enode.ID]*handshakeResult),
+	}
+	c.now = func() time.Time { return ct.time }
+	c.handshake = func(n *enode.Node) (*handshakeResult, error) {
+		if res := ct.results[n.ID()]; res != nil {
+			return res, nil
+		}
+		return nil, errors.New("connection refused")
+	}
+	return ct
+}
+
+func (ct *censusTest) localForkID() forkid.ID {
+	return forkid.NewID(ct.config, ct.genesis, math.MaxUint64, uint64(ct.time.Unix()))
+}
+
+func newCensusTestNode(t *testing.T) *enode.Node {
+	key, _ := crypto.GenerateKey()
+	var r enr.Record
+	r.Set(enr.IPv4{127, 0, 0, 1})
+	r.Set(enr.TCP(30303))
+	if err := enode.SignV4(&r, key); err != nil {
+		t.Fatal(err)
+	}
+	n, err := enode.New(enode.ValidSchemes, &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestCensusStats(t *testing.T) {
+	ct := newCensusTest(t, memorydb.New())
+	local := ct.localForkID()
+	previous := forkid.NewID(ct.config, ct.genesis, math.MaxUint64, *ct.config.ShanghaiTime-1)
+
+	results := []*handshakeResult{
+		{Client: "Geth/v1.15.0-stable/linux-amd64/go1.24", ForkID: &local},
+		{Client: "Geth/v1.15.0-stable/linux-amd64/go1.24", ForkID: &local},
+		{Client: "Geth/v1.14.0-stable/linux-amd64/go1.23", ForkID: &forkid.ID{Hash: local.Hash}},
+		{Client: "Nethermind/v1.31.0/linux-x64/dotnet9.0.2", ForkID: &previous},
+		{Client: "Other/v0.1.0", ForkID: &forkid.ID{Hash: [4]byte{0xde, 0xad, 0xbe, 0xef}}},
+		{Client: "Other/v0.2.0"},
+		nil, // unreachable
+	}
+	for _, res := range results {
+		n := newCensusTestNode(t)
+		ct.results[n.ID()] = res
+		ct.update(n, "discv4")
+	}
+	stale := newCensusTestNode(t)
+	ct.update(stale, "discv5")
+
+	// Check again after the stale node has left the active window.
+	ct.time = ct.time.Add(ct.activeWindow)
+	for id := range ct.results {
+		ct.update(ct.node(id).N, "discv4")
+	}
+	stats := ct.stats()
+	if stats.Nodes != len(results) {
+		t.Errorf("wrong node count %d, want %d", stats.Nodes, len(results))
+	}
+	if stats.Reachable != len(results)-1 {
+		t.Errorf("wrong reachable count %d, want %d", stats.Reachable, len(results)-1)
+	}
+	if geth := stats.Clients["Geth"]; geth == nil || geth.Count != 3 || geth.Versions["v1.15.0-stable"] != 2 {
+		t.Errorf("wrong Geth stats: %+v", geth)
+	}
+	if other := stats.Clients["Other"]; other == nil || other.Count != 2 {
+		t.Errorf("wrong Other stats: %+v", other)
+	}
+	want := forkReadiness{Ready: 2, NotReady: 1, Other: 1, Incompatible: 1, Unknown: 2}
+	got := *stats.Readiness
+	got.ForkID = nil
+	if got != want {
+		t.Errorf("wrong readiness: %+v, want %+v", got, want)
+	}
+	if stats.Readiness.ForkID.forkID() != local {
+		t.Errorf("wrong local fork ID %v", stats.Readiness.ForkID)
+	}
+}
+
+func TestCensusPersistence(t *testing.T) {
+	var (
+		db = memorydb.New()
+		ct = newCensusTest(t, db)
+		n  = newCensusTestNode(t)
+	)
+	local := ct.localForkID()
+
+	// The node is first unreachable, then comes online, then changes version.
+	ct.update(n, "discv4")
+	ct.time = ct.time.Add(ct.revalidateInterval)
+	ct.results[n.ID()] = &handshakeResult{Client: "Geth/v1.14.0", Caps: []string{"eth/68"}, NetworkID: 1, ForkID: &local}
+	ct.update(n, "discv5")
+	ct.time = ct.time.Add(ct.revalidateInterval)
+	ct.update(n, "discv4") // unchanged
+	ct.time = ct.time.Add(ct.revalidateInterval)
+	ct.results[n.ID()] = &handshakeResult{Client: "Geth/v1.15.0", Caps: []string{"eth/69"}, NetworkID: 1, ForkID: &local}
+	ct.update(n, "")
+	ct.storeStats()
+
+	// Reload and check.
+	ct2 := newCensusTest(t, db)
+	ct2.time = ct.time
+	node := ct2.node(n.ID())
+	if node == nil {
+		t.Fatal("node not loaded")
+	}
+	if node.Client != "Geth/v1.15.0" || node.NetworkID != 1 || node.ForkID.forkID() != local {
+		t.Errorf("wrong node state: %+v", node)
+	}
+	if len(node.Sources) != 2 {
+		t.Errorf("wrong sources %v", node.Sources)
+	}
+	history := ct2.history(n.ID())
+	wantClients := []string{"", "Geth/v1.14.0", "Geth/v1.15.0"}
+	if len(history) != len(wantClients) {
+		t.Fatalf("wrong history length %d, want %d", len(history), len(wantClients))
+	}
+	for i, ev := range history {
+		if ev.Client != wantClients[i] || ev.Reachable != (i > 0) {
+			t.Errorf("wrong history event %d: %+v", i, ev)
+		}
+	}
+	if s := ct2.statsHistory(10); len(s) != 1 || s[0].Nodes != 1 {
+		t.Errorf("wrong stats history: %v", s)
+	}
+}
+
+func TestCensusRevalidation(t *testing.T) {
+	var (
+		ct    = newCensusTest(t, memorydb.New())
+		n     = newCensusTestNode(t)
+		calls int
+	)
+	ct.handshake = func(*enode.Node) (*handshakeResult, error) {
+		calls++
+		return &handshakeResult{Client: "Geth/v1.15.0"}, nil
+	}
+	ct.update(n, "discv4")
+	ct.update(n, "discv5")
+	if calls != 1 {
+		t.Fatalf("node checked %d times, want 1", calls)
+	}
+	if c := ct.revalidationCandidates(); len(c) != 0 {
+		t.Fatalf("revalidation candidates before interval: %v", c)
+	}
+	ct.time = ct.time.Add(ct.revalidateInterval)
+	if c := ct.revalidationCandidates(); len(c) != 1 {
+		t.Fatalf("wrong revalidation candidates %v", c)
+	}
+	ct.update(n, "")
+	if calls != 2 {
+		t.Fatalf("node checked %d times, want 2", calls)
+	}
+}
+
+func TestCensusAPI(t *testing.T) {
+	ct := newCensusTest(t, memorydb.New())
+	local := ct.localForkID()
+	var nodes []*enode.Node
+	for _, client := range []string{"Geth/v1.15.0", "Nethermind/v1.31.0"} {
+		n := newCensusTestNode(t)
+		ct.results[n.ID()] = &handshakeResult{Client: client, ForkID: &local}
+		ct.update(n, "discv4")
+		nodes = append(nodes, n)
+	}
+	ct.storeStats()
+
+	srv := httptest.NewServer(newCensusAPI(ct.census))
+	defer srv.Close()
+	get := func(path string, wantStatus int, result any) {
+		t.Helper()
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != wantStatus {
+			t.Fatalf("%s: wrong status %d, want %d", path, resp.StatusCode, wantStatus)
+		}
+		if result != nil {
+			if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+		}
+	}
+
+	var stats censusStats
+	get("/api/stats", http.StatusOK, &stats)
+	if stats.Nodes != 2 || stats.Readiness.Ready != 2 || stats.Clients["Geth"].Count != 1 {
+		t.Errorf("wrong stats: %+v", stats)
+	}
+	var list []*censusNode
+	get("/api/nodes", http.StatusOK, &list)
+	if len(list) != 2 {
+		t.Errorf("wrong node count %d", len(list))
+	}
+	get("/api/nodes?client=geth", http.StatusOK, &list)
+	if len(list) != 1 || list[0].N.ID() != nodes[0].ID() {
+		t.Errorf("wrong filtered nodes %v", list)
+	}
+	var node struct {
+		Client  string         `json:"client"`
+		History []*censusEvent `json:"history"`
+	}
+	get("/api/nodes/"+nodes[1].ID().String(), http.StatusOK, &node)
+	if node.Client != "Nethermind/v1.31.0" || len(node.History) != 1 {
+		t.Errorf("wrong node response: %+v", node)
+	}
+	var history []*censusStats
+	get("/api/history", http.StatusOK, &history)
+	if len(history) != 1 {
+		t.Errorf("wrong history length %d", len(history))
+	}
+	get("/api/nodes/"+enode.ID{}.String(), http.StatusNotFound, nil)
+	get("/api/nodes/xyz", http.StatusBadRequest, nil)
+	get("/api/history?limit=-1", http.StatusBadRequest, nil)
+}
+
+// This test checks the RLPx handshake against a p2p server running eth.
+func TestCensusHandshake(t *testing.T) {
+	genesis := core.DefaultGenesisBlock().ToBlock()
+	status := &eth.StatusPacket69{
+		ProtocolVersion: eth.ETH69,
+		NetworkID:       1,
+		Genesis:         genesis.Hash(),
+		ForkID:          forkid.NewID(params.MainnetChainConfig, genesis, 0, 0),
+	}
+	srv := &p2p.Server{
+		Config: p2p.Config{
+			PrivateKey:  newTestKey(t),
+			MaxPeers:    10,
+			ListenAddr:  "127.0.0.1:0",
+			NoDiscovery: true,
+			Name:        "Geth/v1.15.0-test",
+			Protocols: []p2p.Protocol{{
+				Name:    eth.ProtocolName,
+				Version: eth.ETH69,
+				Length:  18,
+				Run: func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+					if err := p2p.Send(rw, eth.StatusMsg, status); err != nil {
+						return err
+					}
+					_, err := rw.ReadMsg()
+					return err
+				},
+			}},
+		},
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	c, err := newCensus(memorydb.New(), newTestKey(t), params.MainnetChainConfig, genesis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := c.rlpxHandshake(srv.Self())
+	if err != nil {
+		t.Fatal("handshake failed:", err)
+	}
+	if res.Client != "Geth/v1.15.0-test" {
+		t.Errorf("wrong client %q", res.Client)
+	}
+	if len(res.Caps) != 1 || res.Caps[0] != "eth/69" {
+		t.Errorf("wrong caps %v", res.Caps)
+	}
+	if res.NetworkID != 1 || res.ForkID == nil || *res.ForkID != status.ForkID {
+		t.Errorf("wrong status info: network %d, fork ID %v", res.NetworkID, res.ForkID)
+	}
+}
+
+func newTestKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
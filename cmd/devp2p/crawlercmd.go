@@ -0,0 +1,207 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/discover"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	crawlerCommand = &cli.Command{
+		Name:  "crawler",
+		Usage: "Network crawler",
+		Subcommands: []*cli.Command{
+			crawlerServeCommand,
+		},
+	}
+	crawlerServeCommand = &cli.Command{
+		Name:   "serve",
+		Usage:  "Continuously crawls discv4 and discv5, and serves the network census over HTTP",
+		Action: crawlerServe,
+		Flags: slices.Concat(discoveryNodeFlags, []cli.Flag{
+			crawlerNetworkFlag,
+			crawlerDBFlag,
+			crawlerHTTPAddrFlag,
+			crawlParallelismFlag,
+			crawlerRevalidateFlag,
+			crawlerSnapshotFlag,
+		}),
+	}
+)
+
+var (
+	crawlerNetworkFlag = &cli.StringFlag{
+		Name:  "network",
+		Usage: "Network to crawl (mainnet, sepolia, holesky, hoodi)",
+		Value: "mainnet",
+	}
+	crawlerDBFlag = &cli.StringFlag{
+		Name:  "db",
+		Usage: "Census database location (in-memory if empty)",
+	}
+	crawlerHTTPAddrFlag = &cli.StringFlag{
+		Name:  "http.addr",
+		Usage: "HTTP API listening address",
+		Value: "127.0.0.1:8080",
+	}
+	crawlerRevalidateFlag = &cli.DurationFlag{
+		Name:  "revalidate",
+		Usage: "Time between handshakes with the same node",
+		Value: 30 * time.Minute,
+	}
+	crawlerSnapshotFlag = &cli.DurationFlag{
+		Name:  "snapshot",
+		Usage: "Time between stored statistics snapshots",
+		Value: time.Hour,
+	}
+)
+
+// crawlerNetwork is the configuration of a crawlable network.
+type crawlerNetwork struct {
+	genesis   *core.Genesis
+	bootnodes []string
+}
+
+var crawlerNetworks = map[string]crawlerNetwork{
+	"mainnet": {core.DefaultGenesisBlock(), params.MainnetBootnodes},
+	"sepolia": {core.DefaultSepoliaGenesisBlock(), params.SepoliaBootnodes},
+	"holesky": {core.DefaultHoleskyGenesisBlock(), params.HoleskyBootnodes},
+	"hoodi":   {core.DefaultHoodiGenesisBlock(), params.HoodiBootnodes},
+}
+
+func crawlerServe(ctx *cli.Context) error {
+	network, ok := crawlerNetworks[ctx.String(crawlerNetworkFlag.Name)]
+	if !ok {
+		return fmt.Errorf("-%s: unknown network %q", crawlerNetworkFlag.Name, ctx.String(crawlerNetworkFlag.Name))
+	}
+	db, err := openCensusDB(ctx.String(crawlerDBFlag.Name))
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Start discovery. Both protocols share the same socket, discv5
+	// receives the packets which discv4 cannot handle.
+	ln, config := makeDiscoveryConfig(ctx)
+	if !ctx.IsSet(bootnodesFlag.Name) {
+		config.Bootnodes = make([]*enode.Node, len(network.bootnodes))
+		for i, url := range network.bootnodes {
+			config.Bootnodes[i] = enode.MustParse(url)
+		}
+	}
+	socket := listen(ctx, ln)
+	unhandled := make(chan discover.ReadPacket, 100)
+	v4config := config
+	v4config.Unhandled = unhandled
+	disc4, err := discover.ListenV4(socket, ln, v4config)
+	if err != nil {
+		return err
+	}
+	disc5, err := discover.ListenV5(&sharedUDPConn{socket, unhandled}, ln, config)
+	if err != nil {
+		disc4.Close()
+		return err
+	}
+	// discv4 must be closed first, it ends the read loop of discv5.
+	defer func() {
+		disc4.Close()
+		disc5.Close()
+	}()
+
+	genesis := network.genesis.ToBlock()
+	c, err := newCensus(db, config.PrivateKey, network.genesis.Config, genesis)
+	if err != nil {
+		return err
+	}
+	c.revalidateInterval = ctx.Duration(crawlerRevalidateFlag.Name)
+	c.resolvers["discv4"] = disc4
+	c.resolvers["discv5"] = disc5
+
+	// Start the API server.
+	httpAddr := ctx.String(crawlerHTTPAddrFlag.Name)
+	listener, err := net.Listen("tcp", httpAddr)
+	if err != nil {
+		return err
+	}
+	httpsrv := &http.Server{Handler: newCensusAPI(c), ReadHeaderTimeout: 5 * time.Second}
+	go httpsrv.Serve(listener)
+	defer httpsrv.Close()
+	log.Info("Started census API server", "addr", listener.Addr())
+
+	runctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	sources := map[string]enode.Iterator{
+		"discv4": disc4.RandomNodes(),
+		"discv5": disc5.RandomNodes(),
+	}
+	c.run(runctx, sources, ctx.Int(crawlParallelismFlag.Name), ctx.Duration(crawlerSnapshotFlag.Name))
+	c.storeStats()
+	return nil
+}
+
+func openCensusDB(path string) (ethdb.KeyValueStore, error) {
+	if path == "" {
+		return memorydb.New(), nil
+	}
+	db, err := leveldb.New(path, 16, 16, "", false)
+	if err != nil {
+		return nil, fmt.Errorf("can't open census database: %v", err)
+	}
+	return db, nil
+}
+
+// sharedUDPConn implements a shared connection. Write sends messages to the underlying
+// connection while read returns the messages which were not handled by discv4.
+type sharedUDPConn struct {
+	*net.UDPConn
+	unhandled chan discover.ReadPacket
+}
+
+// ReadFromUDPAddrPort implements discover.UDPConn.
+func (s *sharedUDPConn) ReadFromUDPAddrPort(b []byte) (n int, addr netip.AddrPort, err error) {
+	packet, ok := <-s.unhandled
+	if !ok {
+		return 0, netip.AddrPort{}, errors.New("connection was closed")
+	}
+	n = copy(b, packet.Data)
+	return n, packet.Addr, nil
+}
+
+// Close implements discover.UDPConn.
+func (s *sharedUDPConn) Close() error {
+	return nil
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package ethtest
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/beacon"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/txpool"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// replaySentinelID is the request ID of the header request sent after each
+// recorded message. Its response signals that the message has been handled.
+const replaySentinelID = 0x7265706c6179 // "replay"
+
+// NewBlockChain creates an in-memory blockchain containing all blocks of the chain.
+func (c *Chain) NewBlockChain() (*core.BlockChain, error) {
+	engine := beacon.New(ethash.NewFaker())
+	bc, err := core.NewBlockChain(rawdb.NewMemoryDatabase(), &c.genesis, engine, nil)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := bc.InsertChain(c.blocks[1:]); err != nil {
+		bc.Stop()
+		return nil, err
+	}
+	return bc, nil
+}
+
+// Replay feeds the inbound `eth` messages of a recording into the `eth` protocol
+// message handler, serving the given chain. Only the protocol-level handling is
+// replayed, the decoded packets aren't processed any further. The recording should contain the messages
+// of a single peer. If it starts with the status message, the handshake is run
+// as well. A description of every message and the responses of the handler is
+// written to out.
+//
+// Messages are processed one by one, so the result is deterministic. The error
+// returned by the handler, if any, is returned.
+func Replay(bc *core.BlockChain, records []*p2p.MsgRecord, out io.Writer) error {
+	var inbound []*p2p.MsgRecord
+	for _, rec := range records {
+		if rec.Protocol == eth.ProtocolName && rec.Inbound {
+			inbound = append(inbound, rec)
+		}
+	}
+	if len(inbound) == 0 {
+		return errors.New("recording contains no inbound eth messages")
+	}
+	// Only replay the first connection if the peer has reconnected.
+	for i := 1; i < len(inbound); i++ {
+		if inbound[i].Code == eth.StatusMsg {
+			inbound = inbound[:i]
+			break
+		}
+	}
+	var (
+		version    = inbound[0].Version
+		local, net = p2p.MsgPipe()
+		caps       = []p2p.Cap{{Name: eth.ProtocolName, Version: version}}
+		peer       = eth.NewPeer(version, p2p.NewPeerPipe(inbound[0].Peer, "replay", caps, local), local, replayTxPool{})
+		drain      = newReplayDrain(net)
+		backend    = &replayBackend{chain: bc, drain: drain}
+	)
+	defer peer.Close()
+	defer net.Close()
+
+	sentinel := &eth.GetBlockHeadersPacket{
+		RequestId:              replaySentinelID,
+		GetBlockHeadersRequest: &eth.GetBlockHeadersRequest{Amount: 1},
+	}
+	fmt.Fprintf(out, "Replaying %d messages of peer %v on eth/%d\n", len(inbound), inbound[0].Peer, version)
+	if inbound[0].Code != eth.StatusMsg {
+		fmt.Fprintln(out, "Recording does not start with status, skipping handshake")
+	}
+
+	// Run the handler.
+	errc := make(chan error, 1)
+	go func() {
+		var err error
+		if inbound[0].Code == eth.StatusMsg {
+			head := bc.CurrentHeader()
+			rangeMsg := eth.BlockRangeUpdatePacket{LatestBlock: head.Number.Uint64(), LatestBlockHash: head.Hash()}
+			if err = peer.Handshake(bc.Config().ChainID.Uint64(), bc, rangeMsg); err != nil {
+				err = fmt.Errorf("handshake failed: %w", err)
+			}
+		}
+		if err == nil {
+			err = eth.Handle(backend, peer)
+		}
+		local.Close()
+		errc <- err
+	}()
+	go drain.loop()
+
+	// Feed the messages. After each message, a header request is sent and its
+	// response awaited. Since the handler processes messages in order, this
+	// ensures all output of the message is collected before moving on.
+	for i, rec := range inbound {
+		fmt.Fprintf(out, "#%-5d in  code %#02x, %d bytes\n", i, rec.Code, len(rec.Payload))
+		err := net.WriteMsg(rec.Msg())
+		if err == nil {
+			err = p2p.Send(net, eth.GetBlockHeadersMsg, sentinel)
+		}
+		if err == nil {
+			select {
+			case <-drain.synced:
+			case err = <-errc:
+			}
+		} else {
+			err = <-errc
+		}
+		drain.report(out)
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Fprintln(out, "All messages handled")
+	return nil
+}
+
+// replayDrain reads the responses of the handler, and collects them along with
+// the packets delivered to the backend.
+type replayDrain struct {
+	rw     p2p.MsgReader
+	synced chan struct{}
+
+	mu     sync.Mutex
+	events []string
+}
+
+func newReplayDrain(rw p2p.MsgReader) *replayDrain {
+	return &replayDrain{rw: rw, synced: make(chan struct{}, 1)}
+}
+
+func (d *replayDrain) loop() {
+	for {
+		msg, err := d.rw.ReadMsg()
+		if err != nil {
+			return
+		}
+		payload, _ := io.ReadAll(msg.Payload)
+		switch {
+		case msg.Code == eth.BlockHeadersMsg && isReplaySentinel(payload):
+			d.synced <- struct{}{}
+		case msg.Code == eth.StatusMsg:
+			// Our status is not reported since it's sent concurrently
+			// with reading the remote one.
+		default:
+			d.add(fmt.Sprintf("out code %#02x, %d bytes", msg.Code, msg.Size))
+		}
+	}
+}
+
+func isReplaySentinel(payload []byte) bool {
+	var resp struct {
+		RequestId uint64
+		Rest      []rlp.RawValue `rlp:"tail"`
+	}
+	return rlp.DecodeBytes(payload, &resp) == nil && resp.RequestId == replaySentinelID
+}
+
+func (d *replayDrain) add(event string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.events = append(d.events, event)
+}
+
+// report writes out the events collected so far.
+func (d *replayDrain) report(out io.Writer) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, ev := range d.events {
+		fmt.Fprintf(out, "       %s\n", ev)
+	}
+	d.events = d.events[:0]
+}
+
+// replayBackend is the eth.Backend used for replaying. It serves data from the
+// chain and reports the packets delivered by the handler, without processing them
+// like the node's backend would.
+type replayBackend struct {
+	chain *core.BlockChain
+	drain *replayDrain
+}
+
+func (b *replayBackend) Chain() *core.BlockChain              { return b.chain }
+func (b *replayBackend) TxPool() eth.TxPool                   { return replayTxPool{} }
+func (b *replayBackend) AcceptTxs() bool                      { return true }
+func (b *replayBackend) RunPeer(*eth.Peer, eth.Handler) error { return errors.New("not supported") }
+func (b *replayBackend) PeerInfo(enode.ID) interface{}        { return nil }
+func (b *replayBackend) Handle(peer *eth.Peer, packet eth.Packet) error {
+	b.drain.add("delivered " + packet.Name())
+	return nil
+}
+
+// replayTxPool is an empty transaction pool.
+type replayTxPool struct{}
+
+func (replayTxPool) Get(common.Hash) *types.Transaction         { return nil }
+func (replayTxPool) GetRLP(common.Hash) []byte                  { return nil }
+func (replayTxPool) GetMetadata(common.Hash) *txpool.TxMetadata { return nil }
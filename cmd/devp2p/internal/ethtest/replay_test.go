@@ -0,0 +1,103 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package ethtest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestReplay(t *testing.T) {
+	chain, err := NewChain("./testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc, err := chain.NewBlockChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer bc.Stop()
+
+	var (
+		peer   = enode.ID{1}
+		record = func(code uint64, msg any) *p2p.MsgRecord {
+			payload, err := rlp.EncodeToBytes(msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return &p2p.MsgRecord{Peer: peer, Inbound: true, Protocol: eth.ProtocolName, Version: eth.ETH69, Code: code, Payload: payload}
+		}
+		head   = chain.Head()
+		status = record(eth.StatusMsg, &eth.StatusPacket69{
+			ProtocolVersion: eth.ETH69,
+			NetworkID:       chain.config.ChainID.Uint64(),
+			Genesis:         chain.blocks[0].Hash(),
+			ForkID:          chain.ForkID(),
+			LatestBlock:     head.NumberU64(),
+			LatestBlockHash: head.Hash(),
+		})
+		request = record(eth.GetBlockHeadersMsg, &eth.GetBlockHeadersPacket{
+			RequestId:              1,
+			GetBlockHeadersRequest: &eth.GetBlockHeadersRequest{Origin: eth.HashOrNumber{Number: 1}, Amount: 2},
+		})
+		invalid = &p2p.MsgRecord{Peer: peer, Inbound: true, Protocol: eth.ProtocolName, Version: eth.ETH69, Code: eth.GetBlockBodiesMsg, Payload: []byte{0xc1, 0xc0}}
+	)
+	// Outbound messages and messages of other protocols must be ignored.
+	outbound := *request
+	outbound.Inbound = false
+	snap := *request
+	snap.Protocol = "snap"
+
+	t.Run("ok", func(t *testing.T) {
+		var out bytes.Buffer
+		err := Replay(bc, []*p2p.MsgRecord{status, &outbound, request, &snap, request}, &out)
+		if err != nil {
+			t.Fatalf("replay failed: %v\n%s", err, out.String())
+		}
+		if n := strings.Count(out.String(), "out code 0x04"); n != 2 {
+			t.Errorf("got %d header responses, want 2\n%s", n, out.String())
+		}
+	})
+	t.Run("invalid", func(t *testing.T) {
+		var out bytes.Buffer
+		err := Replay(bc, []*p2p.MsgRecord{status, request, invalid, request}, &out)
+		if err == nil {
+			t.Fatalf("replay of invalid message succeeded\n%s", out.String())
+		}
+		if !strings.Contains(out.String(), "out code 0x04") {
+			t.Errorf("response before invalid message missing\n%s", out.String())
+		}
+	})
+	t.Run("wrong-genesis", func(t *testing.T) {
+		wrong := record(eth.StatusMsg, &eth.StatusPacket69{
+			ProtocolVersion: eth.ETH69,
+			NetworkID:       chain.config.ChainID.Uint64(),
+			ForkID:          chain.ForkID(),
+		})
+		var out bytes.Buffer
+		err := Replay(bc, []*p2p.MsgRecord{wrong, request}, &out)
+		if err == nil || !strings.Contains(err.Error(), "handshake") {
+			t.Fatalf("wrong error %v\n%s", err, out.String())
+		}
+	})
+}
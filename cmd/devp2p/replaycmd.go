@@ -0,0 +1,155 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"github.com/ethereum/go-ethereum/cmd/devp2p/internal/ethtest"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/urfave/cli/v2"
+)
+
+var (
+	replayCommand = &cli.Command{
+		Name:      "replay",
+		Usage:     "Replays recorded eth messages of a peer against the eth protocol handler",
+		ArgsUsage: "<recording file or directory>...",
+		Action:    replay,
+		Description: `Feeds the recorded eth messages of a peer into the eth protocol-level message handler,
+serving requests from a local chain. Announcements, broadcasts and responses are decoded and
+reported, but not processed any further: the sync, block fetching and transaction pool logic of
+a full node is not exercised.`,
+		Flags: []cli.Flag{
+			testChainDirFlag,
+			replayPeerFlag,
+		},
+	}
+	replayPeerFlag = &cli.StringFlag{
+		Name:  "peer",
+		Usage: "Node ID of the peer to replay (required if the recording contains multiple peers)",
+	}
+)
+
+func replay(ctx *cli.Context) error {
+	if ctx.NArg() == 0 {
+		return errors.New("need recording files as arguments")
+	}
+	var files []string
+	for _, arg := range ctx.Args().Slice() {
+		info, err := os.Stat(arg)
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		dirFiles, err := p2p.RecordFiles(arg)
+		if err != nil {
+			return err
+		}
+		files = append(files, dirFiles...)
+	}
+	records, err := loadRecords(files)
+	if err != nil {
+		return err
+	}
+	records, err = selectPeerRecords(records, ctx.String(replayPeerFlag.Name))
+	if err != nil {
+		return err
+	}
+
+	chain, err := ethtest.NewChain(ctx.Path(testChainDirFlag.Name))
+	if err != nil {
+		return err
+	}
+	bc, err := chain.NewBlockChain()
+	if err != nil {
+		return fmt.Errorf("can't import chain: %v", err)
+	}
+	defer bc.Stop()
+	if err := ethtest.Replay(bc, records, os.Stdout); err != nil {
+		return fmt.Errorf("handler failed: %w", err)
+	}
+	return nil
+}
+
+// loadRecords reads all records of the given recording files.
+func loadRecords(files []string) ([]*p2p.MsgRecord, error) {
+	var records []*p2p.MsgRecord
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		r := p2p.NewMsgRecordReader(f)
+		for {
+			rec, err := r.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				f.Close()
+				return nil, fmt.Errorf("%s: %v", file, err)
+			}
+			records = append(records, rec)
+		}
+		f.Close()
+	}
+	return records, nil
+}
+
+// selectPeerRecords returns the eth records of a single peer. If id is empty,
+// the recording must contain exactly one peer using eth.
+func selectPeerRecords(records []*p2p.MsgRecord, id string) ([]*p2p.MsgRecord, error) {
+	var peers []enode.ID
+	for _, rec := range records {
+		if rec.Protocol == eth.ProtocolName && !slices.Contains(peers, rec.Peer) {
+			peers = append(peers, rec.Peer)
+		}
+	}
+	var selected enode.ID
+	switch {
+	case id != "":
+		var err error
+		if selected, err = enode.ParseID(id); err != nil {
+			return nil, fmt.Errorf("-%s: %v", replayPeerFlag.Name, err)
+		}
+		if !slices.Contains(peers, selected) {
+			return nil, fmt.Errorf("no eth messages of peer %v in recording", selected)
+		}
+	case len(peers) == 0:
+		return nil, errors.New("no eth messages in recording")
+	case len(peers) > 1:
+		return nil, fmt.Errorf("recording contains %d peers, select one using -%s", len(peers), replayPeerFlag.Name)
+	default:
+		selected = peers[0]
+	}
+	var result []*p2p.MsgRecord
+	for _, rec := range records {
+		if rec.Peer == selected && rec.Protocol == eth.ProtocolName {
+			result = append(result, rec)
+		}
+	}
+	return result, nil
+}
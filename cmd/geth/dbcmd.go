@@ -41,6 +41,7 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
 	"github.com/urfave/cli/v2"
 )
 
@@ -71,6 +72,7 @@ Remove blockchain and state databases`,
 		Subcommands: []*cli.Command{
 			dbInspectCmd,
 			dbStatCmd,
+			dbTrafficCmd,
 			dbCompactCmd,
 			dbGetCmd,
 			dbDeleteCmd,
@@ -82,6 +84,9 @@ Remove blockchain and state databases`,
 			dbMetadataCmd,
 			dbCheckStateContentCmd,
 			dbInspectHistoryCmd,
+			dbMigrateSchemeCmd,
+			dbConvertCmd,
+			dbRecompressFreezerCmd,
 		},
 	}
 	dbInspectCmd = &cli.Command{
@@ -108,6 +113,16 @@ a data corruption.`,
 		Usage:  "Print leveldb statistics",
 		Flags:  slices.Concat(utils.NetworkFlags, utils.DatabaseFlags),
 	}
+	dbTrafficCmd = &cli.Command{
+		Action: dbTraffic,
+		Name:   "traffic",
+		Usage:  "Print the write and delete counters tracked for each type of data",
+		Flags:  slices.Concat(utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `This command prints the number of writes, written bytes, deletions and range deletions per type
+of data, as continuously tracked by the node since the tracking started. These are cumulative counters
+rather than the current storage size: data that was overwritten or deleted is still counted. Use
+'geth db inspect' for the current sizes.`,
+	}
 	dbCompactCmd = &cli.Command{
 		Action: dbCompact,
 		Name:   "compact",
@@ -206,6 +221,52 @@ WARNING: This is a low-level operation which may cause database corruption!`,
 		}, utils.NetworkFlags, utils.DatabaseFlags),
 		Description: "This command queries the history of the account or storage slot within the specified block range",
 	}
+	dbMigrateSchemeCmd = &cli.Command{
+		Action: migrateScheme,
+		Name:   "migrate-scheme",
+		Usage:  "Migrate the state from the hash scheme to the path scheme in place",
+		Flags:  slices.Concat(utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `This command converts the head state of a hash-based database into the path-based
+layout without resyncing. The trie nodes of the head state and the flat state are copied
+first, after which the legacy trie nodes are deleted from the database. Historical states
+are not retained. The migration can be interrupted at any time and resumed by running the
+command again. The node must have been shut down cleanly before migrating.`,
+	}
+	dbConvertCmd = &cli.Command{
+		Action: dbConvert,
+		Name:   "convert",
+		Usage:  "Convert the key-value database to another storage engine",
+		Flags: slices.Concat([]cli.Flag{
+			&cli.StringFlag{
+				Name:  "to",
+				Usage: "Storage engine to convert the database to (pebble or leveldb)",
+				Value: rawdb.DBPebble,
+			},
+			utils.CacheFlag,
+			utils.CacheDatabaseFlag,
+		}, utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `This command copies every entry of the key-value database into a new database
+of the requested storage engine, built next to the existing one. Pebble databases are
+populated by ingesting sorted tables directly. After the number of entries under each key
+prefix is verified, the directories are swapped and the old database is kept with an
+'.old' suffix until removed manually. The freezer is not modified.`,
+	}
+	dbRecompressFreezerCmd = &cli.Command{
+		Action:    recompressFreezer,
+		Name:      "recompress-freezer",
+		Usage:     "Recompress a freezer table with zstd and a trained dictionary",
+		ArgsUsage: "<freezer-type> <table-type>",
+		Flags:     slices.Concat(utils.NetworkFlags, utils.DatabaseFlags),
+		Description: `This command converts a snappy-compressed freezer table to zstd compression, using
+a dictionary trained on the table content. The table is rebuilt next to the original one
+and swapped in atomically once complete, the original table is left untouched if the
+command is interrupted. Recompressed tables can't be opened by earlier releases.
+The compression ratio of each table is reported by 'geth db inspect'.
+
+The command needs exclusive access to the freezer, so the node must be stopped while it
+runs. Alternatively, a node started with --datadir.ancient.zstd recompresses its chain
+tables in the background, and creates any new ones with zstd right away.`,
+	}
 )
 
 func removeDB(ctx *cli.Context) error {
@@ -331,6 +392,16 @@ func inspect(ctx *cli.Context) error {
 	return rawdb.InspectDatabase(db, prefix, start)
 }
 
+func dbTraffic(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	db := utils.MakeChainDatabase(ctx, stack, true)
+	defer db.Close()
+
+	return rawdb.PrintDatabaseStats(db)
+}
+
 func checkStateContent(ctx *cli.Context) error {
 	var (
 		prefix []byte
@@ -639,6 +710,81 @@ func importLDBdata(ctx *cli.Context) error {
 	return utils.ImportLDBData(db, fName, int64(start), stop)
 }
 
+func migrateScheme(ctx *cli.Context) error {
+	var (
+		stack, _  = makeConfigNode(ctx)
+		interrupt = make(chan os.Signal, 1)
+		stop      = make(chan struct{})
+	)
+	defer stack.Close()
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during scheme migration, saving progress")
+		}
+		close(stop)
+	}()
+	db := utils.MakeChainDatabase(ctx, stack, false)
+	defer db.Close()
+
+	config := *pathdb.Defaults
+	config.JournalDirectory = stack.ResolvePath("triedb")
+	return utils.MigrateStateScheme(db, &config, stop)
+}
+
+func recompressFreezer(ctx *cli.Context) error {
+	if ctx.NArg() < 2 {
+		return fmt.Errorf("required arguments: %v", ctx.Command.ArgsUsage)
+	}
+	var (
+		freezer   = ctx.Args().Get(0)
+		table     = ctx.Args().Get(1)
+		interrupt = make(chan os.Signal, 1)
+		stop      = make(chan struct{})
+	)
+	stack, _ := makeConfigNode(ctx)
+	ancient := stack.ResolveAncient("chaindata", ctx.String(utils.AncientFlag.Name))
+	stack.Close()
+
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during recompression, discarding progress")
+		}
+		close(stop)
+	}()
+	return rawdb.RecompressFreezerTable(ancient, freezer, table, stop)
+}
+
+func dbConvert(ctx *cli.Context) error {
+	var (
+		stack, _  = makeConfigNode(ctx)
+		interrupt = make(chan os.Signal, 1)
+		stop      = make(chan struct{})
+	)
+	defer stack.Close()
+	signal.Notify(interrupt, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(interrupt)
+	defer close(interrupt)
+	go func() {
+		if _, ok := <-interrupt; ok {
+			log.Info("Interrupted during database conversion, discarding progress")
+		}
+		close(stop)
+	}()
+	var (
+		dir     = stack.ResolvePath("chaindata")
+		ancient = stack.ResolveAncient("chaindata", ctx.String(utils.AncientFlag.Name))
+		cache   = ctx.Int(utils.CacheFlag.Name) * ctx.Int(utils.CacheDatabaseFlag.Name) / 100
+		handles = utils.MakeDatabaseHandles(ctx.Int(utils.FDLimitFlag.Name))
+	)
+	return utils.ConvertDatabaseEngine(dir, ancient, ctx.String("to"), cache, handles, stop)
+}
+
 type preimageIterator struct {
 	iter ethdb.Iterator
 }
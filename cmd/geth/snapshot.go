@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"os"
 	"slices"
+	"strconv"
 	"time"
 
 	"github.com/ethereum/go-ethereum/cmd/utils"
@@ -33,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/pebble"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
@@ -159,6 +161,54 @@ block is used.
 				Description: `
 The export-preimages command exports hash preimages to a flat file, in exactly
 the expected order for the overlay tree migration.
+`,
+			},
+			{
+				Action:    convertBinaryTrie,
+				Name:      "convert-bintrie",
+				Usage:     "Convert the state into a binary trie for benchmarking",
+				ArgsUsage: "<outdir> [<root>]",
+				Flags:     slices.Concat(utils.NetworkFlags, utils.DatabaseFlags),
+				Description: `
+geth snapshot convert-bintrie <outdir> [<root>]
+will build the binary trie (EIP-7864) of the state with the given root from the
+flat state, and store its nodes by path in a new database in the given output
+directory. The preimages of all the account and storage slot hashes need to be
+present in the database. The root of the binary trie and the statistics of its
+stems and leaves are reported afterwards. The default state is the one of the
+HEAD block.
+`,
+			},
+			{
+				Action:    exportCheckpoint,
+				Name:      "export-checkpoint",
+				Usage:     "Export the state at a block as a portable checkpoint",
+				ArgsUsage: "<file> [<blockHash> | <blockNum>]",
+				Flags:     slices.Concat(utils.NetworkFlags, utils.DatabaseFlags),
+				Description: `
+geth snapshot export-checkpoint <file> [<blockHash> | <blockNum>]
+will export the flat state (accounts, storage slots and contract codes) of the
+given block into a compact, chunked and checksummed binary file, which can be
+loaded into another node with import-checkpoint. The chain leading to the block
+is exported too: the most recent 128 blocks in full with their receipts, the
+older ones as headers only. If the file name ends with .gz, the output is
+gzipped. The default block is the HEAD block.
+`,
+			},
+			{
+				Action:    importCheckpoint,
+				Name:      "import-checkpoint",
+				Usage:     "Import a state checkpoint and mark the node as synced",
+				ArgsUsage: "<file>",
+				Flags:     slices.Concat(utils.NetworkFlags, utils.DatabaseFlags),
+				Description: `
+geth snapshot import-checkpoint <file>
+will rebuild the state tries from the flat state contained in the checkpoint,
+verify the resulting state root against the checkpoint block and set the block
+as the head of the chain, as if it was the pivot of a completed snap sync. The
+node must be freshly initialized with the same genesis. As with snap sync, the
+bodies and receipts of the blocks older than the most recent 128 ones are not
+available afterwards.
 `,
 			},
 		},
@@ -690,3 +740,131 @@ func checkAccount(ctx *cli.Context) error {
 	log.Info("Checked the snapshot journalled storage", "time", common.PrettyDuration(time.Since(start)))
 	return nil
 }
+
+// exportCheckpoint exports the state at the given block as a checkpoint file.
+func exportCheckpoint(ctx *cli.Context) error {
+	if ctx.NArg() < 1 || ctx.NArg() > 2 {
+		utils.Fatalf("This command requires one or two arguments.")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chaindb := utils.MakeChainDatabase(ctx, stack, true)
+	defer chaindb.Close()
+
+	var block *types.Block
+	if ctx.NArg() == 2 {
+		arg := ctx.Args().Get(1)
+		if hashish(arg) {
+			hash := common.HexToHash(arg)
+			if number, ok := rawdb.ReadHeaderNumber(chaindb, hash); ok {
+				block = rawdb.ReadBlock(chaindb, hash, number)
+			}
+		} else {
+			number, err := strconv.ParseUint(arg, 10, 64)
+			if err != nil {
+				return err
+			}
+			block = rawdb.ReadBlock(chaindb, rawdb.ReadCanonicalHash(chaindb, number), number)
+		}
+	} else {
+		block = rawdb.ReadHeadBlock(chaindb)
+	}
+	if block == nil {
+		log.Error("Failed to load checkpoint block")
+		return errors.New("no checkpoint block")
+	}
+	triedb := utils.MakeTrieDatabase(ctx, stack, chaindb, false, true, false)
+	defer triedb.Close()
+
+	stateIt, err := utils.NewStateIterator(triedb, chaindb, block.Root())
+	if err != nil {
+		return err
+	}
+	genesis := rawdb.ReadCanonicalHash(chaindb, 0)
+	return utils.ExportCheckpoint(chaindb, stateIt, genesis, block, ctx.Args().First())
+}
+
+// importCheckpoint imports a checkpoint file and sets its block as the head.
+func importCheckpoint(ctx *cli.Context) error {
+	if ctx.NArg() != 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chain, db := utils.MakeChain(ctx, stack, false)
+	defer db.Close()
+	defer chain.Stop()
+
+	start := time.Now()
+	if err := utils.ImportCheckpoint(chain, db, ctx.Args().First()); err != nil {
+		utils.Fatalf("Import error: %v\n", err)
+	}
+	fmt.Printf("Import done in %v\n", time.Since(start))
+	return nil
+}
+
+// convertBinaryTrie converts the state into a binary trie, stored in a separate
+// database.
+func convertBinaryTrie(ctx *cli.Context) error {
+	if ctx.NArg() < 1 || ctx.NArg() > 2 {
+		utils.Fatalf("This command requires one or two arguments.")
+	}
+	outdir := ctx.Args().First()
+	if common.FileExist(outdir) && common.IsNonEmptyDir(outdir) {
+		return fmt.Errorf("output directory %s is not empty", outdir)
+	}
+	stack, _ := makeConfigNode(ctx)
+	defer stack.Close()
+
+	chaindb := utils.MakeChainDatabase(ctx, stack, true)
+	defer chaindb.Close()
+
+	var root common.Hash
+	if ctx.NArg() > 1 {
+		var err error
+		if root, err = parseRoot(ctx.Args().Get(1)); err != nil {
+			return err
+		}
+	} else {
+		headBlock := rawdb.ReadHeadBlock(chaindb)
+		if headBlock == nil {
+			log.Error("Failed to load head block")
+			return errors.New("no head block")
+		}
+		root = headBlock.Root()
+	}
+	triedb := utils.MakeTrieDatabase(ctx, stack, chaindb, false, true, false)
+	defer triedb.Close()
+
+	stateIt, err := utils.NewStateIterator(triedb, chaindb, root)
+	if err != nil {
+		return err
+	}
+	outdb, err := pebble.New(outdir, 512, 512, "", false)
+	if err != nil {
+		return err
+	}
+	defer outdb.Close()
+
+	stats, err := utils.ConvertBinaryTrie(chaindb, stateIt, root, outdb)
+	if err != nil {
+		return err
+	}
+	var avgDepth float64
+	if stats.Stems > 0 {
+		avgDepth = float64(stats.Depths) / float64(stats.Stems)
+	}
+	fmt.Printf("State root:        %v\n", root)
+	fmt.Printf("Binary trie root:  %v\n", stats.Root)
+	fmt.Printf("Accounts:          %d\n", stats.Accounts)
+	fmt.Printf("Storage slots:     %d\n", stats.Slots)
+	fmt.Printf("Contract codes:    %d\n", stats.Codes)
+	fmt.Printf("Internal nodes:    %d\n", stats.Internal)
+	fmt.Printf("Stem nodes:        %d\n", stats.Stems)
+	fmt.Printf("Leaves:            %d\n", stats.Leaves)
+	fmt.Printf("Stem depth:        avg %.2f, max %d\n", avgDepth, stats.MaxDepth)
+	fmt.Printf("Total size:        %v\n", stats.Size)
+	return nil
+}
@@ -0,0 +1,228 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie/bintrie"
+	"github.com/ethereum/go-ethereum/triedb/database"
+)
+
+// binaryTrieFlushItems is the number of accounts and storage slots inserted
+// into the binary trie before its nodes are flushed to disk.
+var binaryTrieFlushItems = 250_000
+
+// BinaryTrieStats contains the statistics of a converted binary trie.
+type BinaryTrieStats struct {
+	Root     common.Hash        // Root hash of the binary trie
+	Accounts uint64             // Number of accounts converted
+	Slots    uint64             // Number of storage slots converted
+	Codes    uint64             // Number of contract codes converted
+	Internal uint64             // Number of internal nodes
+	Stems    uint64             // Number of stem nodes
+	Leaves   uint64             // Number of leaf values in the stem nodes
+	MaxDepth int                // Depth of the deepest stem node
+	Depths   uint64             // Sum of the stem node depths
+	Size     common.StorageSize // Total size of the serialized nodes
+}
+
+// binaryNodeDatabase is a node database serving the binary trie nodes stored
+// by path, regardless of the requested state.
+type binaryNodeDatabase struct {
+	db ethdb.KeyValueReader
+}
+
+func (db binaryNodeDatabase) NodeReader(common.Hash) (database.NodeReader, error) {
+	return db, nil
+}
+
+func (db binaryNodeDatabase) Node(owner common.Hash, path []byte, hash common.Hash) ([]byte, error) {
+	return rawdb.ReadAccountTrieNode(db.db, path), nil
+}
+
+// ConvertBinaryTrie builds the binary trie of the state with the given root
+// from the flat state, writing the trie nodes by path into the output store.
+// The preimages of the account and storage slot hashes are required. The trie
+// is periodically committed and flushed to keep the memory usage bounded.
+func ConvertBinaryTrie(chaindb ethdb.Database, stateIt *StateIterator, root common.Hash, outdb ethdb.KeyValueStore) (*BinaryTrieStats, error) {
+	var (
+		start   = time.Now()
+		logged  = time.Now()
+		stats   = new(BinaryTrieStats)
+		pending int
+		nodedb  = binaryNodeDatabase{db: outdb}
+	)
+	bt, err := bintrie.NewBinaryTrie(types.EmptyBinaryHash, nodedb)
+	if err != nil {
+		return nil, err
+	}
+	flush := func() error {
+		hash, nodes := bt.Commit(false)
+		batch := outdb.NewBatch()
+		for path, n := range nodes.Nodes {
+			if n.IsDeleted() {
+				rawdb.DeleteAccountTrieNode(batch, []byte(path))
+			} else {
+				rawdb.WriteAccountTrieNode(batch, []byte(path), n.Blob)
+			}
+			if batch.ValueSize() >= ethdb.IdealBatchSize {
+				if err := batch.Write(); err != nil {
+					return err
+				}
+				batch.Reset()
+			}
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		// Reopen the trie, dropping the resolved nodes from memory
+		bt, err = bintrie.NewBinaryTrie(hash, nodedb)
+		if err != nil {
+			return err
+		}
+		stats.Root, pending = hash, 0
+		return nil
+	}
+	accIt, err := stateIt.AccountIterator(root, common.Hash{})
+	if err != nil {
+		return nil, err
+	}
+	defer accIt.Release()
+
+	for accIt.Next() {
+		acc, err := types.FullAccount(accIt.Account())
+		if err != nil {
+			return nil, err
+		}
+		preimage := rawdb.ReadPreimage(chaindb, accIt.Hash())
+		if len(preimage) != common.AddressLength {
+			return nil, fmt.Errorf("missing preimage for account %v", accIt.Hash())
+		}
+		addr := common.BytesToAddress(preimage)
+
+		var code []byte
+		if !bytes.Equal(acc.CodeHash, types.EmptyCodeHash.Bytes()) {
+			code = rawdb.ReadCode(chaindb, common.BytesToHash(acc.CodeHash))
+			if len(code) == 0 {
+				return nil, fmt.Errorf("missing code %x of account %v", acc.CodeHash, addr)
+			}
+		}
+		if err := bt.UpdateAccount(addr, acc, len(code)); err != nil {
+			return nil, err
+		}
+		if len(code) > 0 {
+			if err := bt.UpdateContractCode(addr, common.BytesToHash(acc.CodeHash), code); err != nil {
+				return nil, err
+			}
+			stats.Codes++
+		}
+		stats.Accounts++
+		pending++
+
+		if acc.Root != types.EmptyRootHash {
+			stIt, err := stateIt.StorageIterator(root, accIt.Hash(), common.Hash{})
+			if err != nil {
+				return nil, err
+			}
+			for stIt.Next() {
+				key := rawdb.ReadPreimage(chaindb, stIt.Hash())
+				if len(key) != common.HashLength {
+					stIt.Release()
+					return nil, fmt.Errorf("missing preimage for storage slot %v of account %v", stIt.Hash(), addr)
+				}
+				_, value, _, err := rlp.Split(stIt.Slot())
+				if err != nil {
+					stIt.Release()
+					return nil, err
+				}
+				if err := bt.UpdateStorage(addr, key, value); err != nil {
+					stIt.Release()
+					return nil, err
+				}
+				stats.Slots++
+				pending++
+
+				if pending >= binaryTrieFlushItems {
+					if err := flush(); err != nil {
+						stIt.Release()
+						return nil, err
+					}
+				}
+			}
+			err = stIt.Error()
+			stIt.Release()
+			if err != nil {
+				return nil, err
+			}
+		}
+		if pending >= binaryTrieFlushItems {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Converting state to binary trie", "at", accIt.Hash(), "accounts", stats.Accounts,
+				"slots", stats.Slots, "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := accIt.Error(); err != nil {
+		return nil, err
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	log.Info("Converted state to binary trie", "root", stats.Root, "accounts", stats.Accounts,
+		"slots", stats.Slots, "codes", stats.Codes, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	// Gather the statistics of the resulting trie
+	it := outdb.NewIterator(rawdb.TrieNodeAccountPrefix, nil)
+	defer it.Release()
+
+	for it.Next() {
+		path := it.Key()[len(rawdb.TrieNodeAccountPrefix):]
+		node, err := bintrie.DeserializeNode(it.Value(), len(path))
+		if err != nil {
+			return nil, fmt.Errorf("invalid node at path %x: %v", path, err)
+		}
+		stats.Size += common.StorageSize(len(it.Key()) + len(it.Value()))
+
+		switch n := node.(type) {
+		case *bintrie.InternalNode:
+			stats.Internal++
+		case *bintrie.StemNode:
+			stats.Stems++
+			stats.Depths += uint64(len(path))
+			stats.MaxDepth = max(stats.MaxDepth, len(path))
+			for _, value := range n.Values {
+				if value != nil {
+					stats.Leaves++
+				}
+			}
+		}
+	}
+	return stats, it.Error()
+}
@@ -0,0 +1,153 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/trie/bintrie"
+)
+
+func TestConvertBinaryTrie(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.Address{0xcc}
+		code     = common.FromHex("0x3460005500") // sstore(0, callvalue)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				address:  {Balance: big.NewInt(params.Ether)},
+				contract: {Code: code, Storage: map[common.Hash]common.Hash{{1}: {1}}},
+			},
+		}
+		signer = types.LatestSigner(genesis.Config)
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 8, func(i int, g *core.BlockGen) {
+		g.AddTx(types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   genesis.Config.ChainID,
+			Nonce:     g.TxNonce(address),
+			GasFeeCap: g.BaseFee(),
+			Gas:       50000,
+			To:        &contract,
+			Value:     big.NewInt(int64(i + 1)),
+		}))
+		g.AddTx(types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   genesis.Config.ChainID,
+			Nonce:     g.TxNonce(address),
+			GasFeeCap: g.BaseFee(),
+			Gas:       params.TxGas,
+			To:        &common.Address{byte(i + 1)},
+			Value:     big.NewInt(1),
+		}))
+	})
+	head := blocks[len(blocks)-1]
+
+	db := newCheckpointTestDB(t)
+	chain, err := core.NewBlockChain(db, genesis, ethash.NewFaker(), core.DefaultConfig().WithStateScheme(rawdb.PathScheme))
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	stateIt, err := NewStateIterator(chain.TrieDB(), db, head.Root())
+	if err != nil {
+		t.Fatalf("failed to create state iterator: %v", err)
+	}
+	// The conversion fails without the preimages
+	if _, err := ConvertBinaryTrie(db, stateIt, head.Root(), memorydb.New()); err == nil {
+		t.Fatal("conversion succeeded without preimages")
+	}
+	preimages := make(map[common.Hash][]byte)
+	for _, addr := range []common.Address{address, contract, {}} {
+		preimages[crypto.Keccak256Hash(addr.Bytes())] = addr.Bytes()
+	}
+	for i := range blocks {
+		addr := common.Address{byte(i + 1)}
+		preimages[crypto.Keccak256Hash(addr.Bytes())] = addr.Bytes()
+	}
+	for _, slot := range []common.Hash{{}, {1}} {
+		preimages[crypto.Keccak256Hash(slot.Bytes())] = slot.Bytes()
+	}
+	rawdb.WritePreimages(db, preimages)
+
+	// Convert the state in one go and with flushing after every item, the
+	// resulting tries must be identical.
+	outdb := memorydb.New()
+	stats, err := ConvertBinaryTrie(db, stateIt, head.Root(), outdb)
+	if err != nil {
+		t.Fatalf("failed to convert state: %v", err)
+	}
+	defer func(items int) { binaryTrieFlushItems = items }(binaryTrieFlushItems)
+	binaryTrieFlushItems = 1
+
+	flushed, err := ConvertBinaryTrie(db, stateIt, head.Root(), memorydb.New())
+	if err != nil {
+		t.Fatalf("failed to convert state with flushing: %v", err)
+	}
+	if stats.Root == (common.Hash{}) || stats.Root != flushed.Root {
+		t.Fatalf("binary trie root mismatch: %v != %v", stats.Root, flushed.Root)
+	}
+	if *stats != *flushed {
+		t.Fatalf("binary trie statistics mismatch: %+v != %+v", stats, flushed)
+	}
+	if stats.Accounts != uint64(3+len(blocks)) || stats.Slots != 2 || stats.Codes != 1 {
+		t.Fatalf("unexpected conversion statistics: %+v", stats)
+	}
+	if stats.Stems == 0 || stats.Leaves < 2*stats.Accounts {
+		t.Fatalf("unexpected trie statistics: %+v", stats)
+	}
+	// Verify the converted state against the original one
+	statedb, err := chain.StateAt(head.Root())
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	bt, err := bintrie.NewBinaryTrie(stats.Root, binaryNodeDatabase{db: outdb})
+	if err != nil {
+		t.Fatalf("failed to open binary trie: %v", err)
+	}
+	for _, addr := range []common.Address{address, contract} {
+		acc, err := bt.GetAccount(addr)
+		if err != nil {
+			t.Fatalf("failed to read account %v: %v", addr, err)
+		}
+		if acc.Nonce != statedb.GetNonce(addr) || acc.Balance.Cmp(statedb.GetBalance(addr)) != 0 {
+			t.Fatalf("account %v mismatch: have %d/%v, want %d/%v", addr, acc.Nonce, acc.Balance, statedb.GetNonce(addr), statedb.GetBalance(addr))
+		}
+	}
+	for _, slot := range []common.Hash{{}, {1}} {
+		have, err := bt.GetWithHashedKey(bintrie.GetBinaryTreeKeyStorageSlot(contract, slot.Bytes()))
+		if err != nil {
+			t.Fatalf("failed to read slot %v: %v", slot, err)
+		}
+		if want := statedb.GetState(contract, slot); !bytes.Equal(have, want.Bytes()) {
+			t.Fatalf("slot %v mismatch: have %x, want %x", slot, have, want)
+		}
+	}
+}
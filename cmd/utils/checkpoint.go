@@ -0,0 +1,692 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// The state checkpoint is a portable binary encoding of the flat state at a given
+// block, which can be imported into an empty node to bootstrap it without snap
+// sync. The file starts with the checkpoint magic and version, followed by a
+// sequence of chunks:
+//
+//	kind (1 byte) | size (4 bytes) | payload (size bytes) | crc32c (4 bytes)
+//
+// The checksum covers the kind, size and payload of the chunk. The payload is
+// RLP encoded, depending on the kind of the chunk. The first chunk contains the
+// metadata, the last one the total number of exported items.
+//
+// The metadata is followed by the chain segment leading to the checkpoint: the
+// headers of the old blocks, then the full blocks along with their receipts of
+// the most recent ones. The state follows afterwards, the accounts are exported
+// in the order of their hashes, the storage slots of an account follow right
+// after the accounts chunk which ends with the account itself.
+const (
+	checkpointMagic   = "gethckpt"
+	checkpointVersion = 0
+
+	checkpointChunkSize    = 1024 * 1024              // Target payload size of a chunk
+	checkpointMaxChunkSize = 16 * checkpointChunkSize // Maximum accepted payload size of a chunk
+
+	// checkpointRecentBlocks is the number of recent blocks exported with their
+	// bodies and receipts, older blocks are only exported as headers.
+	checkpointRecentBlocks = state.TriesInMemory
+)
+
+const (
+	checkpointChunkMeta byte = iota
+	checkpointChunkHeaders
+	checkpointChunkBlocks
+	checkpointChunkAccounts
+	checkpointChunkStorage
+	checkpointChunkCode
+	checkpointChunkEnd
+)
+
+var checkpointCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// checkpointMeta describes the chain position of a checkpoint.
+type checkpointMeta struct {
+	Genesis  common.Hash
+	Head     *types.Header
+	UnixTime uint64
+}
+
+// checkpointBlock is a recent block of the chain along with its receipts.
+type checkpointBlock struct {
+	Block    *types.Block
+	Receipts rlp.RawValue
+}
+
+// checkpointAccount is an account entry, in slim RLP encoding.
+type checkpointAccount struct {
+	Hash common.Hash
+	Body []byte
+}
+
+// checkpointSlot is a storage slot entry, in RLP encoding.
+type checkpointSlot struct {
+	Hash  common.Hash
+	Value []byte
+}
+
+// checkpointStorage is a batch of consecutive storage slots of an account.
+type checkpointStorage struct {
+	Account common.Hash
+	Slots   []checkpointSlot
+}
+
+// checkpointEnd terminates the checkpoint with the number of exported items.
+type checkpointEnd struct {
+	Headers  uint64
+	Blocks   uint64
+	Accounts uint64
+	Slots    uint64
+	Codes    uint64
+}
+
+// checkpointWriter writes checksummed chunks into the checkpoint file.
+type checkpointWriter struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+func (cw *checkpointWriter) writeChunk(kind byte, payload interface{}) error {
+	blob, err := rlp.EncodeToBytes(payload)
+	if err != nil {
+		return err
+	}
+	cw.buf.Reset()
+	cw.buf.WriteByte(kind)
+	cw.buf.Write(binary.BigEndian.AppendUint32(nil, uint32(len(blob))))
+	cw.buf.Write(blob)
+	cw.buf.Write(binary.BigEndian.AppendUint32(nil, crc32.Checksum(cw.buf.Bytes(), checkpointCRCTable)))
+	_, err = cw.w.Write(cw.buf.Bytes())
+	return err
+}
+
+// checkpointReader reads and verifies the chunks of the checkpoint file.
+type checkpointReader struct {
+	r      io.Reader
+	chunks uint64
+}
+
+func (cr *checkpointReader) readChunk() (byte, []byte, error) {
+	var head [5]byte
+	if _, err := io.ReadFull(cr.r, head[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, err
+	}
+	size := binary.BigEndian.Uint32(head[1:])
+	if size > checkpointMaxChunkSize {
+		return 0, nil, fmt.Errorf("chunk %d too large: %d bytes", cr.chunks, size)
+	}
+	blob := make([]byte, size+4)
+	if _, err := io.ReadFull(cr.r, blob); err != nil {
+		return 0, nil, err
+	}
+	payload := blob[:size]
+	crc := crc32.Update(crc32.Checksum(head[:], checkpointCRCTable), checkpointCRCTable, payload)
+	if crc != binary.BigEndian.Uint32(blob[size:]) {
+		return 0, nil, fmt.Errorf("checksum mismatch in chunk %d", cr.chunks)
+	}
+	cr.chunks++
+	return head[0], payload, nil
+}
+
+// exportCheckpointChain writes the chain segment up to the given head into the
+// checkpoint.
+func exportCheckpointChain(chaindb ethdb.Database, cw *checkpointWriter, head *types.Header, end *checkpointEnd) error {
+	var (
+		recent  uint64
+		headers []*types.Header
+		blocks  []checkpointBlock
+		size    int
+		number  = head.Number.Uint64()
+	)
+	if number > checkpointRecentBlocks {
+		recent = number - checkpointRecentBlocks
+	}
+	for n := uint64(1); n <= number; n++ {
+		hash := rawdb.ReadCanonicalHash(chaindb, n)
+		if n <= recent {
+			header := rawdb.ReadHeader(chaindb, hash, n)
+			if header == nil {
+				return fmt.Errorf("missing header %d", n)
+			}
+			headers = append(headers, header)
+			size += int(header.Size())
+			end.Headers++
+		} else {
+			block := rawdb.ReadBlock(chaindb, hash, n)
+			if block == nil {
+				return fmt.Errorf("missing block %d", n)
+			}
+			receipts := rawdb.ReadReceiptsRLP(chaindb, hash, n)
+			if receipts == nil {
+				return fmt.Errorf("missing receipts of block %d", n)
+			}
+			blocks = append(blocks, checkpointBlock{Block: block, Receipts: receipts})
+			size += int(block.Size()) + len(receipts)
+			end.Blocks++
+		}
+		if size >= checkpointChunkSize || n == recent || n == number {
+			var err error
+			if len(headers) > 0 {
+				err = cw.writeChunk(checkpointChunkHeaders, headers)
+			} else if len(blocks) > 0 {
+				err = cw.writeChunk(checkpointChunkBlocks, blocks)
+			}
+			if err != nil {
+				return err
+			}
+			headers, blocks, size = headers[:0], blocks[:0], 0
+		}
+	}
+	if hash := rawdb.ReadCanonicalHash(chaindb, number); hash != head.Hash() {
+		return fmt.Errorf("checkpoint block %d is not canonical", number)
+	}
+	return nil
+}
+
+// ExportCheckpoint writes the state of the given block as a checkpoint into the
+// file, along with the chain segment leading to it. If the file name ends with
+// .gz, the output is gzipped.
+func ExportCheckpoint(chaindb ethdb.Database, stateIt *StateIterator, genesis common.Hash, block *types.Block, fn string) error {
+	log.Info("Exporting state checkpoint", "file", fn, "number", block.Number(), "root", block.Root())
+
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer fh.Close() // only for the error paths, closed explicitly on success
+
+	var (
+		writer io.Writer = fh
+		gz     *gzip.Writer
+	)
+	if strings.HasSuffix(fn, ".gz") {
+		gz = gzip.NewWriter(writer)
+		writer = gz
+	}
+	buf := bufio.NewWriter(writer)
+
+	if _, err := buf.WriteString(checkpointMagic); err != nil {
+		return err
+	}
+	if err := buf.WriteByte(checkpointVersion); err != nil {
+		return err
+	}
+	cw := &checkpointWriter{w: buf}
+	if err := cw.writeChunk(checkpointChunkMeta, &checkpointMeta{
+		Genesis:  genesis,
+		Head:     block.Header(),
+		UnixTime: uint64(time.Now().Unix()),
+	}); err != nil {
+		return err
+	}
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		end    checkpointEnd
+	)
+	if err := exportCheckpointChain(chaindb, cw, block.Header(), &end); err != nil {
+		return err
+	}
+	log.Info("Exported checkpoint chain segment", "headers", end.Headers, "blocks", end.Blocks, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	var (
+		accounts     []checkpointAccount
+		accountsSize int
+		codes        [][]byte
+		codesSize    int
+		seenCodes    = make(map[common.Hash]struct{})
+	)
+	flushAccounts := func() error {
+		if len(accounts) == 0 {
+			return nil
+		}
+		err := cw.writeChunk(checkpointChunkAccounts, accounts)
+		accounts, accountsSize = accounts[:0], 0
+		return err
+	}
+	flushCodes := func() error {
+		if len(codes) == 0 {
+			return nil
+		}
+		err := cw.writeChunk(checkpointChunkCode, codes)
+		codes, codesSize = codes[:0], 0
+		return err
+	}
+	accIt, err := stateIt.AccountIterator(block.Root(), common.Hash{})
+	if err != nil {
+		return err
+	}
+	defer accIt.Release()
+
+	for accIt.Next() {
+		account, err := types.FullAccount(accIt.Account())
+		if err != nil {
+			return err
+		}
+		accounts = append(accounts, checkpointAccount{Hash: accIt.Hash(), Body: common.CopyBytes(accIt.Account())})
+		accountsSize += common.HashLength + len(accIt.Account())
+		end.Accounts++
+
+		// Export the contract code if it's not yet exported
+		if codeHash := common.BytesToHash(account.CodeHash); codeHash != types.EmptyCodeHash {
+			if _, ok := seenCodes[codeHash]; !ok {
+				code := rawdb.ReadCode(chaindb, codeHash)
+				if len(code) == 0 {
+					return fmt.Errorf("missing code %x of account %x", codeHash, accIt.Hash())
+				}
+				seenCodes[codeHash] = struct{}{}
+				codes = append(codes, code)
+				codesSize += len(code)
+				end.Codes++
+			}
+			if codesSize >= checkpointChunkSize {
+				if err := flushCodes(); err != nil {
+					return err
+				}
+			}
+		}
+		// Export the storage right after the account chunk ending with its owner
+		if account.Root != types.EmptyRootHash {
+			if err := flushAccounts(); err != nil {
+				return err
+			}
+			stIt, err := stateIt.StorageIterator(block.Root(), accIt.Hash(), common.Hash{})
+			if err != nil {
+				return err
+			}
+			var (
+				slots []checkpointSlot
+				size  int
+			)
+			for stIt.Next() {
+				slots = append(slots, checkpointSlot{Hash: stIt.Hash(), Value: common.CopyBytes(stIt.Slot())})
+				size += common.HashLength + len(stIt.Slot())
+				end.Slots++
+
+				if size >= checkpointChunkSize {
+					if err := cw.writeChunk(checkpointChunkStorage, &checkpointStorage{Account: accIt.Hash(), Slots: slots}); err != nil {
+						stIt.Release()
+						return err
+					}
+					slots, size = slots[:0], 0
+				}
+			}
+			err = stIt.Error()
+			stIt.Release()
+			if err != nil {
+				return err
+			}
+			if len(slots) > 0 {
+				if err := cw.writeChunk(checkpointChunkStorage, &checkpointStorage{Account: accIt.Hash(), Slots: slots}); err != nil {
+					return err
+				}
+			}
+		}
+		if accountsSize >= checkpointChunkSize {
+			if err := flushAccounts(); err != nil {
+				return err
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Exporting state checkpoint", "at", accIt.Hash(), "accounts", end.Accounts, "slots", end.Slots, "codes", end.Codes,
+				"elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := accIt.Error(); err != nil {
+		return err
+	}
+	if err := flushAccounts(); err != nil {
+		return err
+	}
+	if err := flushCodes(); err != nil {
+		return err
+	}
+	if err := cw.writeChunk(checkpointChunkEnd, &end); err != nil {
+		return err
+	}
+	// Flush all the layers of buffering, failing if any data didn't make it to disk
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			return err
+		}
+	}
+	if err := fh.Close(); err != nil {
+		return err
+	}
+	log.Info("Exported state checkpoint", "file", fn, "blocks", end.Headers+end.Blocks, "accounts", end.Accounts, "slots", end.Slots, "codes", end.Codes,
+		"elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// checkpointImporter rebuilds the state tries from the flat state contained in
+// a checkpoint.
+type checkpointImporter struct {
+	db     ethdb.Database
+	batch  ethdb.Batch
+	scheme string
+
+	accountTrie *trie.StackTrie
+	account     *checkpointAccount // Last imported account
+	accountRoot common.Hash        // Storage root of the last imported account
+	storageTrie *trie.StackTrie    // Storage trie of the last imported account
+
+	imported checkpointEnd
+}
+
+func newCheckpointImporter(db ethdb.Database, scheme string) *checkpointImporter {
+	imp := &checkpointImporter{
+		db:     db,
+		batch:  db.NewBatch(),
+		scheme: scheme,
+	}
+	imp.accountTrie = trie.NewStackTrie(func(path []byte, hash common.Hash, blob []byte) {
+		rawdb.WriteTrieNode(imp.batch, common.Hash{}, path, hash, blob, scheme)
+	})
+	return imp
+}
+
+// flush writes the batch into the database once it grows large enough.
+func (imp *checkpointImporter) flush(force bool) error {
+	if !force && imp.batch.ValueSize() < ethdb.IdealBatchSize {
+		return nil
+	}
+	if err := imp.batch.Write(); err != nil {
+		return err
+	}
+	imp.batch.Reset()
+	return nil
+}
+
+// finishAccount verifies the storage trie of the last imported account.
+func (imp *checkpointImporter) finishAccount() error {
+	if imp.account == nil {
+		return nil
+	}
+	root := types.EmptyRootHash
+	if imp.storageTrie != nil {
+		root = imp.storageTrie.Hash()
+	}
+	if root != imp.accountRoot {
+		return fmt.Errorf("storage root mismatch for account %x: have %x, want %x", imp.account.Hash, root, imp.accountRoot)
+	}
+	imp.account, imp.storageTrie = nil, nil
+	return nil
+}
+
+func (imp *checkpointImporter) importAccounts(accounts []checkpointAccount) error {
+	for i := range accounts {
+		if err := imp.finishAccount(); err != nil {
+			return err
+		}
+		account, err := types.FullAccount(accounts[i].Body)
+		if err != nil {
+			return fmt.Errorf("invalid account %x: %v", accounts[i].Hash, err)
+		}
+		full, err := types.FullAccountRLP(accounts[i].Body)
+		if err != nil {
+			return err
+		}
+		if err := imp.accountTrie.Update(accounts[i].Hash.Bytes(), full); err != nil {
+			return err
+		}
+		rawdb.WriteAccountSnapshot(imp.batch, accounts[i].Hash, accounts[i].Body)
+		imp.account, imp.accountRoot = &accounts[i], account.Root
+		imp.imported.Accounts++
+	}
+	return imp.flush(false)
+}
+
+func (imp *checkpointImporter) importStorage(storage *checkpointStorage) error {
+	if imp.account == nil || imp.account.Hash != storage.Account {
+		return fmt.Errorf("unexpected storage of account %x", storage.Account)
+	}
+	if imp.storageTrie == nil {
+		owner := storage.Account
+		imp.storageTrie = trie.NewStackTrie(func(path []byte, hash common.Hash, blob []byte) {
+			rawdb.WriteTrieNode(imp.batch, owner, path, hash, blob, imp.scheme)
+		})
+	}
+	for _, slot := range storage.Slots {
+		if err := imp.storageTrie.Update(slot.Hash.Bytes(), slot.Value); err != nil {
+			return err
+		}
+		rawdb.WriteStorageSnapshot(imp.batch, storage.Account, slot.Hash, slot.Value)
+		imp.imported.Slots++
+	}
+	return imp.flush(false)
+}
+
+func (imp *checkpointImporter) importCodes(codes [][]byte) error {
+	for _, code := range codes {
+		rawdb.WriteCode(imp.batch, crypto.Keccak256Hash(code), code)
+		imp.imported.Codes++
+	}
+	return imp.flush(false)
+}
+
+// wipeState deletes the flat state and the path-based trie nodes present in the
+// database, which would otherwise dangle next to the imported state.
+func wipeState(db ethdb.Database, scheme string) error {
+	prefixes := [][]byte{rawdb.SnapshotAccountPrefix, rawdb.SnapshotStoragePrefix}
+	if scheme == rawdb.PathScheme {
+		prefixes = append(prefixes, rawdb.TrieNodeAccountPrefix, rawdb.TrieNodeStoragePrefix)
+	}
+	for _, prefix := range prefixes {
+		end := []byte{prefix[0] + 1}
+		if err := rawdb.SafeDeleteRange(db, prefix, end, scheme == rawdb.HashScheme, func(bool) bool { return false }); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportCheckpoint imports the state checkpoint from the given file into a fresh
+// chain, rebuilding the state tries and verifying them against the checkpoint
+// block. Afterwards the checkpoint block is set as the head of the chain, as if
+// it was the pivot of a completed snap sync.
+func ImportCheckpoint(chain *core.BlockChain, db ethdb.Database, fn string) error {
+	log.Info("Importing state checkpoint", "file", fn)
+
+	fh, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var reader io.Reader = bufio.NewReader(fh)
+	if strings.HasSuffix(fn, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return err
+		}
+	}
+	magic := make([]byte, len(checkpointMagic)+1)
+	if _, err := io.ReadFull(reader, magic); err != nil {
+		return fmt.Errorf("could not read checkpoint header: %v", err)
+	}
+	if string(magic[:len(checkpointMagic)]) != checkpointMagic {
+		return errors.New("incompatible data, wrong magic")
+	}
+	if version := magic[len(checkpointMagic)]; version != checkpointVersion {
+		return fmt.Errorf("incompatible version %d, (support only %d)", version, checkpointVersion)
+	}
+	cr := &checkpointReader{r: reader}
+
+	// Read the metadata and ensure the checkpoint belongs to the local chain
+	kind, payload, err := cr.readChunk()
+	if err != nil {
+		return err
+	}
+	if kind != checkpointChunkMeta {
+		return fmt.Errorf("unexpected first chunk kind %d", kind)
+	}
+	var meta checkpointMeta
+	if err := rlp.DecodeBytes(payload, &meta); err != nil {
+		return fmt.Errorf("could not decode metadata: %v", err)
+	}
+	if genesis := chain.Genesis().Hash(); meta.Genesis != genesis {
+		return fmt.Errorf("checkpoint of a different network, genesis %x (local %x)", meta.Genesis, genesis)
+	}
+	if meta.Head == nil || meta.Head.Number.Sign() == 0 {
+		return errors.New("invalid checkpoint head")
+	}
+	if head := chain.CurrentHeader(); head.Number.Sign() != 0 {
+		return fmt.Errorf("local chain is not empty, head at block %d", head.Number)
+	}
+	head := meta.Head
+	log.Info("Importing state checkpoint", "number", head.Number, "hash", head.Hash(), "root", head.Root,
+		"age", common.PrettyDuration(time.Since(time.Unix(int64(meta.UnixTime), 0))))
+
+	// Pause the state maintenance and drop the flat state of the current head,
+	// just like snap sync does it.
+	if err := chain.SnapSyncStart(); err != nil {
+		return err
+	}
+	scheme := chain.TrieDB().Scheme()
+	if err := wipeState(db, scheme); err != nil {
+		return err
+	}
+	var (
+		imp    = newCheckpointImporter(db, scheme)
+		start  = time.Now()
+		logged = time.Now()
+	)
+	for {
+		kind, payload, err := cr.readChunk()
+		if err != nil {
+			return err
+		}
+		if kind == checkpointChunkEnd {
+			var end checkpointEnd
+			if err := rlp.DecodeBytes(payload, &end); err != nil {
+				return fmt.Errorf("could not decode end of checkpoint: %v", err)
+			}
+			if end != imp.imported {
+				return fmt.Errorf("incomplete checkpoint: imported %+v, want %+v", imp.imported, end)
+			}
+			break
+		}
+		switch kind {
+		case checkpointChunkHeaders:
+			var headers []*types.Header
+			if err := rlp.DecodeBytes(payload, &headers); err != nil {
+				return fmt.Errorf("could not decode headers: %v", err)
+			}
+			if _, err = chain.InsertHeadersBeforeCutoff(headers); err == nil {
+				imp.imported.Headers += uint64(len(headers))
+			}
+		case checkpointChunkBlocks:
+			var entries []checkpointBlock
+			if err := rlp.DecodeBytes(payload, &entries); err != nil {
+				return fmt.Errorf("could not decode blocks: %v", err)
+			}
+			var (
+				blocks   = make(types.Blocks, len(entries))
+				receipts = make([]rlp.RawValue, len(entries))
+			)
+			for i, entry := range entries {
+				blocks[i], receipts[i] = entry.Block, entry.Receipts
+			}
+			if _, err = chain.InsertReceiptChain(blocks, receipts, 0); err == nil {
+				imp.imported.Blocks += uint64(len(blocks))
+			}
+		case checkpointChunkAccounts:
+			var accounts []checkpointAccount
+			if err := rlp.DecodeBytes(payload, &accounts); err != nil {
+				return fmt.Errorf("could not decode accounts: %v", err)
+			}
+			err = imp.importAccounts(accounts)
+		case checkpointChunkStorage:
+			var storage checkpointStorage
+			if err := rlp.DecodeBytes(payload, &storage); err != nil {
+				return fmt.Errorf("could not decode storage: %v", err)
+			}
+			err = imp.importStorage(&storage)
+		case checkpointChunkCode:
+			var codes [][]byte
+			if err := rlp.DecodeBytes(payload, &codes); err != nil {
+				return fmt.Errorf("could not decode codes: %v", err)
+			}
+			err = imp.importCodes(codes)
+		default:
+			err = fmt.Errorf("unknown chunk kind %d", kind)
+		}
+		if err != nil {
+			return err
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Importing state checkpoint", "accounts", imp.imported.Accounts, "slots", imp.imported.Slots, "codes", imp.imported.Codes,
+				"elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if err := imp.finishAccount(); err != nil {
+		return err
+	}
+	if root := imp.accountTrie.Hash(); root != head.Root {
+		return fmt.Errorf("state root mismatch: have %x, want %x", root, head.Root)
+	}
+	if snap := chain.CurrentSnapBlock(); snap.Hash() != head.Hash() {
+		return fmt.Errorf("chain segment mismatch: have head %d [%x], want %d [%x]", snap.Number, snap.Hash(), head.Number, head.Hash())
+	}
+	// The state is verified, mark the checkpoint block as the head
+	rawdb.WriteSnapSyncStatusFlag(imp.batch, rawdb.StateSyncFinished)
+	if err := imp.flush(true); err != nil {
+		return err
+	}
+	if err := chain.SnapSyncComplete(head.Hash()); err != nil {
+		return err
+	}
+	rawdb.WriteHeadBlockHash(db, head.Hash())
+
+	log.Info("Imported state checkpoint", "file", fn, "number", head.Number, "accounts", imp.imported.Accounts,
+		"slots", imp.imported.Slots, "codes", imp.imported.Codes, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
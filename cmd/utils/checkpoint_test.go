@@ -0,0 +1,183 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+)
+
+func TestCheckpointHash(t *testing.T) { testCheckpoint(t, rawdb.HashScheme, "checkpoint") }
+func TestCheckpointPath(t *testing.T) { testCheckpoint(t, rawdb.PathScheme, "checkpoint.gz") }
+
+func testCheckpoint(t *testing.T, scheme string, name string) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.Address{0xcc}
+		code     = common.FromHex("0x3460005500") // sstore(0, callvalue)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				address:  {Balance: big.NewInt(params.Ether)},
+				contract: {Code: code, Storage: map[common.Hash]common.Hash{{1}: {1}}},
+			},
+		}
+		signer = types.LatestSigner(genesis.Config)
+		config = core.DefaultConfig().WithStateScheme(scheme)
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), checkpointRecentBlocks+12, func(i int, g *core.BlockGen) {
+		g.AddTx(types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   genesis.Config.ChainID,
+			Nonce:     g.TxNonce(address),
+			GasFeeCap: g.BaseFee(),
+			Gas:       50000,
+			To:        &contract,
+			Value:     big.NewInt(int64(i + 1)),
+		}))
+		g.AddTx(types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   genesis.Config.ChainID,
+			Nonce:     g.TxNonce(address),
+			GasFeeCap: g.BaseFee(),
+			Gas:       params.TxGas,
+			To:        &common.Address{byte(i + 1)},
+			Value:     big.NewInt(1),
+		}))
+	})
+	head := blocks[len(blocks)-1]
+
+	// Export the checkpoint from a node synced to the head
+	srcdb := newCheckpointTestDB(t)
+	src, err := core.NewBlockChain(srcdb, genesis, ethash.NewFaker(), config)
+	if err != nil {
+		t.Fatalf("failed to create source chain: %v", err)
+	}
+	if _, err := src.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	// The path-based state can be iterated on the live trie database, while the
+	// hash-based one is only available after journaling the snapshot.
+	tdb := src.TrieDB()
+	if scheme == rawdb.HashScheme {
+		src.Stop()
+		tdb = triedb.NewDatabase(srcdb, &triedb.Config{HashDB: hashdb.Defaults})
+		defer tdb.Close()
+	} else {
+		defer src.Stop()
+	}
+	stateIt, err := NewStateIterator(tdb, srcdb, head.Root())
+	if err != nil {
+		t.Fatalf("failed to create state iterator: %v", err)
+	}
+	fn := filepath.Join(t.TempDir(), name)
+	if err := ExportCheckpoint(srcdb, stateIt, src.Genesis().Hash(), head, fn); err != nil {
+		t.Fatalf("failed to export checkpoint: %v", err)
+	}
+
+	// Corrupted checkpoints are rejected
+	if !strings.HasSuffix(fn, ".gz") {
+		blob, err := os.ReadFile(fn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blob[len(blob)/2] ^= 0xff
+		corrupted := fn + ".corrupted"
+		if err := os.WriteFile(corrupted, blob, 0644); err != nil {
+			t.Fatal(err)
+		}
+		db := newCheckpointTestDB(t)
+		chain, err := core.NewBlockChain(db, genesis, ethash.NewFaker(), config)
+		if err != nil {
+			t.Fatalf("failed to create chain: %v", err)
+		}
+		if err := ImportCheckpoint(chain, db, corrupted); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+			t.Fatalf("expected checksum error, got %v", err)
+		}
+		chain.Stop()
+	}
+	// Import the checkpoint into a fresh node
+	db := newCheckpointTestDB(t)
+	chain, err := core.NewBlockChain(db, genesis, ethash.NewFaker(), config)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if err := ImportCheckpoint(chain, db, fn); err != nil {
+		t.Fatalf("failed to import checkpoint: %v", err)
+	}
+	if have := chain.CurrentBlock().Hash(); have != head.Hash() {
+		t.Fatalf("unexpected head: have %x, want %x", have, head.Hash())
+	}
+	if have := rawdb.ReadHeadBlockHash(db); have != head.Hash() {
+		t.Fatalf("unexpected persisted head: have %x, want %x", have, head.Hash())
+	}
+	for _, block := range blocks {
+		if header := chain.GetHeaderByNumber(block.NumberU64()); header == nil || header.Hash() != block.Hash() {
+			t.Fatalf("missing header %d", block.NumberU64())
+		}
+	}
+	if receipts := chain.GetReceiptsByHash(head.Hash()); len(receipts) != 2 {
+		t.Fatalf("unexpected receipts of head: %d", len(receipts))
+	}
+	statedb, err := chain.StateAt(head.Root())
+	if err != nil {
+		t.Fatalf("failed to open imported state: %v", err)
+	}
+	if have := statedb.GetState(contract, common.Hash{}); have != common.BigToHash(big.NewInt(int64(len(blocks)))) {
+		t.Errorf("unexpected slot 0: %x", have)
+	}
+	if have := statedb.GetState(contract, common.Hash{1}); have != (common.Hash{1}) {
+		t.Errorf("unexpected slot 1: %x", have)
+	}
+	if have := statedb.GetCode(contract); string(have) != string(code) {
+		t.Errorf("unexpected code: %x", have)
+	}
+	if have := statedb.GetNonce(address); have != uint64(2*len(blocks)) {
+		t.Errorf("unexpected nonce: %d", have)
+	}
+	if have := statedb.GetBalance(common.Address{8}); have.Uint64() != 1 {
+		t.Errorf("unexpected balance: %d", have)
+	}
+	// A node already at the checkpoint refuses to import it again
+	if err := ImportCheckpoint(chain, db, fn); err == nil {
+		t.Fatal("expected error for re-importing checkpoint")
+	}
+}
+
+func newCheckpointTestDB(t *testing.T) ethdb.Database {
+	db, err := rawdb.Open(memorydb.New(), rawdb.OpenOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
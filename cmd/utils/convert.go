@@ -0,0 +1,231 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+	"github.com/ethereum/go-ethereum/ethdb/pebble"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// errConversionInterrupted is returned if the database conversion is interrupted
+// by the user. The partially converted database is discarded.
+var errConversionInterrupted = errors.New("database conversion interrupted")
+
+// convertIngestSize is the amount of data accumulated in sstables before they
+// are ingested into a pebble database in one go.
+const convertIngestSize = 1024 * 1024 * 1024
+
+// openKeyValueStore opens the key-value store in the given directory with the
+// specified storage engine.
+func openKeyValueStore(dir string, engine string, cache int, handles int, readonly bool) (ethdb.KeyValueStore, error) {
+	switch engine {
+	case rawdb.DBLeveldb:
+		return leveldb.New(dir, cache, handles, "", readonly)
+	case rawdb.DBPebble:
+		return pebble.New(dir, cache, handles, "", readonly)
+	default:
+		return nil, fmt.Errorf("unknown db.engine %v", engine)
+	}
+}
+
+// ConvertDatabase copies every entry of the source key-value store into the
+// destination in key order. Pebble destinations are populated by ingesting the
+// sstables built in the tmpdir, any other store is written in batches. The copy
+// is verified by comparing the number of entries under every single-byte key
+// prefix in the two stores.
+func ConvertDatabase(src ethdb.KeyValueStore, dst ethdb.KeyValueStore, tmpdir string, interrupt <-chan struct{}) error {
+	var (
+		counts   [256]uint64
+		count    uint64
+		size     common.StorageSize
+		pending  common.StorageSize
+		start    = time.Now()
+		logged   = time.Now()
+		put      func(key, value []byte) error
+		flush    func() error
+		ingester *pebble.Ingester
+	)
+	if db, ok := dst.(*pebble.Database); ok {
+		var err error
+		if ingester, err = db.NewIngester(tmpdir); err != nil {
+			return err
+		}
+		defer ingester.Close()
+		put, flush = ingester.Put, ingester.Flush
+	} else {
+		batch := dst.NewBatch()
+		put = batch.Put
+		flush = func() error {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+			return nil
+		}
+	}
+	it := src.NewIterator(nil, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key, value := it.Key(), it.Value()
+		if err := put(key, value); err != nil {
+			return err
+		}
+		if len(key) > 0 {
+			counts[key[0]]++
+		}
+		count++
+		size += common.StorageSize(len(key) + len(value))
+		pending += common.StorageSize(len(key) + len(value))
+
+		if (ingester != nil && pending > convertIngestSize) || (ingester == nil && pending > ethdb.IdealBatchSize) {
+			if err := flush(); err != nil {
+				return err
+			}
+			pending = 0
+		}
+		if count%1000 == 0 {
+			select {
+			case <-interrupt:
+				return errConversionInterrupted
+			default:
+			}
+			if time.Since(logged) > 8*time.Second {
+				log.Info("Converting database", "count", count, "size", size, "at", fmt.Sprintf("%x", key[:min(len(key), 8)]),
+					"elapsed", common.PrettyDuration(time.Since(start)))
+				logged = time.Now()
+			}
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	log.Info("Copied database entries", "count", count, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+
+	// Verify the number of entries under every prefix in the new database
+	var have [256]uint64
+	vit := dst.NewIterator(nil, nil)
+	defer vit.Release()
+	for vit.Next() {
+		if key := vit.Key(); len(key) > 0 {
+			have[key[0]]++
+		}
+	}
+	if err := vit.Error(); err != nil {
+		return err
+	}
+	var mismatches []string
+	for prefix := range counts {
+		if counts[prefix] != have[prefix] {
+			mismatches = append(mismatches, fmt.Sprintf("%q: have %d want %d", byte(prefix), have[prefix], counts[prefix]))
+		}
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("converted database mismatch: %s", strings.Join(mismatches, ", "))
+	}
+	log.Info("Verified converted database", "count", count, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// ConvertDatabaseEngine converts the key-value store in dir into the given storage
+// engine. The new database is built next to the old one and the directories are
+// swapped only after the conversion is verified, with the old database retained
+// as a backup. The freezer is moved over as is if it resides in the database
+// directory, otherwise it's left alone.
+func ConvertDatabaseEngine(dir string, ancient string, engine string, cache int, handles int, interrupt <-chan struct{}) error {
+	var (
+		converted = dir + ".converted"
+		backup    = dir + ".old"
+		freezer   string
+	)
+	if rel, err := filepath.Rel(dir, ancient); err == nil && rel != "." && !strings.HasPrefix(rel, "..") {
+		freezer = strings.Split(rel, string(filepath.Separator))[0]
+	}
+	// Finish the directory swap of an interrupted conversion first
+	if !common.FileExist(dir) && common.FileExist(converted) && common.FileExist(backup) {
+		log.Warn("Resuming interrupted database swap", "dir", dir)
+		return swapDatabase(dir, converted, backup, freezer)
+	}
+	from := rawdb.PreexistingDatabase(dir)
+	switch {
+	case from == "":
+		return fmt.Errorf("no database found in %s", dir)
+	case from == engine:
+		return fmt.Errorf("database is already %s", engine)
+	case common.FileExist(backup):
+		return fmt.Errorf("backup of a previous conversion exists at %s, remove it first", backup)
+	}
+	if err := os.RemoveAll(converted); err != nil {
+		return err
+	}
+	log.Info("Converting database", "dir", dir, "from", from, "to", engine)
+
+	src, err := openKeyValueStore(dir, from, cache/2, handles/2, true)
+	if err != nil {
+		return err
+	}
+	dst, err := openKeyValueStore(converted, engine, cache/2, handles/2, false)
+	if err != nil {
+		src.Close()
+		return err
+	}
+	err = ConvertDatabase(src, dst, converted+".ingest", interrupt)
+	src.Close()
+	if cerr := dst.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.RemoveAll(converted)
+		return err
+	}
+	if err := swapDatabase(dir, converted, backup, freezer); err != nil {
+		return err
+	}
+	log.Info("Converted database", "dir", dir, "engine", engine, "backup", backup)
+	return nil
+}
+
+// swapDatabase replaces the database directory with the converted one, moving
+// the freezer along if it resides within. Every step is a single rename and the
+// swap can be resumed if it's interrupted.
+func swapDatabase(dir string, converted string, backup string, freezer string) error {
+	if common.FileExist(dir) {
+		if err := os.Rename(dir, backup); err != nil {
+			return err
+		}
+	}
+	if freezer != "" && common.FileExist(filepath.Join(backup, freezer)) && !common.FileExist(filepath.Join(converted, freezer)) {
+		if err := os.Rename(filepath.Join(backup, freezer), filepath.Join(converted, freezer)); err != nil {
+			return err
+		}
+	}
+	return os.Rename(converted, dir)
+}
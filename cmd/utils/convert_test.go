@@ -0,0 +1,107 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb/leveldb"
+	"github.com/ethereum/go-ethereum/ethdb/pebble"
+)
+
+func TestConvertDatabaseEngine(t *testing.T) {
+	var (
+		dir     = filepath.Join(t.TempDir(), "chaindata")
+		ancient = filepath.Join(dir, "ancient", "chain")
+		keys    [][]byte
+	)
+	db, err := leveldb.New(dir, 16, 16, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2000; i++ {
+		key := []byte(fmt.Sprintf("%c-%d", "ahoA"[i%4], i))
+		if err := db.Put(key, key); err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+	db.Close()
+
+	if err := os.MkdirAll(ancient, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ancient, "headers.cidx"), []byte("freezer"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConvertDatabaseEngine(dir, ancient, rawdb.DBPebble, 16, 32, nil); err != nil {
+		t.Fatalf("failed to convert database: %v", err)
+	}
+	if engine := rawdb.PreexistingDatabase(dir); engine != rawdb.DBPebble {
+		t.Fatalf("unexpected engine after conversion: %s", engine)
+	}
+	if engine := rawdb.PreexistingDatabase(dir + ".old"); engine != rawdb.DBLeveldb {
+		t.Fatalf("unexpected engine of backup: %s", engine)
+	}
+	if common.FileExist(dir + ".converted") {
+		t.Fatal("converted directory left behind")
+	}
+	// The freezer is moved along untouched
+	if blob, err := os.ReadFile(filepath.Join(ancient, "headers.cidx")); err != nil || string(blob) != "freezer" {
+		t.Fatalf("freezer not retained: %v", err)
+	}
+	converted, err := pebble.New(dir, 16, 16, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		if value, err := converted.Get(key); err != nil || !bytes.Equal(value, key) {
+			t.Fatalf("missing key %s: %v", key, err)
+		}
+	}
+	converted.Close()
+
+	// A second conversion is refused while the backup is around
+	if err := ConvertDatabaseEngine(dir, ancient, rawdb.DBLeveldb, 16, 32, nil); err == nil {
+		t.Fatal("expected error with existing backup")
+	}
+}
+
+func TestConvertDatabaseSwapResume(t *testing.T) {
+	var (
+		dir     = filepath.Join(t.TempDir(), "chaindata")
+		ancient = filepath.Join(dir, "ancient")
+	)
+	// Simulate a crash right after the old database was moved away
+	for _, path := range []string{dir + ".old/ancient", dir + ".converted"} {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ConvertDatabaseEngine(dir, ancient, rawdb.DBPebble, 16, 32, nil); err != nil {
+		t.Fatalf("failed to resume swap: %v", err)
+	}
+	if !common.FileExist(ancient) || common.FileExist(dir+".converted") || common.FileExist(dir+".old/ancient") {
+		t.Fatal("swap not completed")
+	}
+}
@@ -52,6 +52,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/eth/syncer"
 	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/eth/tracers/wasm"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/ethdb/remotedb"
 	"github.com/ethereum/go-ethereum/ethstats"
@@ -114,6 +115,22 @@ var (
 		Usage:    "Root directory for era1 history (default = inside ancient/chain)",
 		Category: flags.EthCategory,
 	}
+	AncientRemoteFlag = &cli.StringFlag{
+		Name:     "datadir.ancient.remote",
+		Usage:    "Object store for the sealed ancient chain files (file:///dir or s3://bucket/prefix?region=..&endpoint=..)",
+		Category: flags.EthCategory,
+	}
+	AncientRemoteCacheFlag = &cli.IntFlag{
+		Name:     "datadir.ancient.remote.cache",
+		Usage:    "Megabytes of memory allocated to caching ancient chain data read from the object store",
+		Value:    ethconfig.Defaults.DatabaseFreezerRemoteCache,
+		Category: flags.EthCategory,
+	}
+	AncientZstdFlag = &cli.BoolFlag{
+		Name:     "datadir.ancient.zstd",
+		Usage:    "Compress new ancient chain tables with zstd and recompress the existing ones in the background",
+		Category: flags.EthCategory,
+	}
 	MinFreeDiskSpaceFlag = &cli.IntFlag{
 		Name:     "datadir.minfreedisk",
 		Usage:    "Minimum free disk space in MB, once reached triggers auto shut down (default = --cache.gc converted to MB, 0 = disabled)",
@@ -307,6 +324,11 @@ var (
 		Value:    uint(ethconfig.Defaults.NodeFullValueCheckpoint),
 		Category: flags.StateCategory,
 	}
+	StatePruneCommitsFlag = &cli.IntFlag{
+		Name:     "state.prune.commits",
+		Usage:    "Number of recent state commits to disk (not blocks) to retain with online state pruning, only relevant in state.scheme=hash and gcmode=full (0 = disabled, minimum 3)",
+		Category: flags.StateCategory,
+	}
 	TransactionHistoryFlag = &cli.Uint64Flag{
 		Name:     "history.transactions",
 		Usage:    "Number of recent blocks to maintain transactions index for (default = about one year, 0 = entire chain)",
@@ -477,6 +499,29 @@ var (
 		Value:    ethconfig.Defaults.BlobPool.PriceBump,
 		Category: flags.BlobPoolCategory,
 	}
+	// Transaction propagation settings
+	TxPropagationNoLocalsFlag = &cli.BoolFlag{
+		Name:     "txpropagation.nolocals",
+		Usage:    "Withholds locally submitted transactions from all peers (private mempool)",
+		Category: flags.TxPoolCategory,
+	}
+	TxPropagationTrustedOnlyFlag = &cli.BoolFlag{
+		Name:     "txpropagation.trustedonly",
+		Usage:    "Propagates transactions to trusted peers only",
+		Category: flags.TxPoolCategory,
+	}
+	TxPropagationAnnounceRateFlag = &cli.Uint64Flag{
+		Name:     "txpropagation.announcerate",
+		Usage:    "Maximum number of transactions announced to a peer per second (0 = unlimited)",
+		Value:    ethconfig.Defaults.TxPropagation.AnnounceRate,
+		Category: flags.TxPoolCategory,
+	}
+	TxPropagationBlobTxsFlag = &cli.StringFlag{
+		Name:     "txpropagation.blobtxs",
+		Usage:    `Propagation mode of blob transactions ("announce", "trusted" or "none")`,
+		Value:    ethconfig.Defaults.TxPropagation.BlobTxs,
+		Category: flags.BlobPoolCategory,
+	}
 	// Performance tuning settings
 	CacheFlag = &cli.IntFlag{
 		Name:     "cache",
@@ -611,6 +656,49 @@ var (
 		Usage:    "Generate execution witnesses and self-check against them (testing purpose)",
 		Category: flags.VMCategory,
 	}
+	WitnessServeFlag = &cli.BoolFlag{
+		Name:     "witness.serve",
+		Usage:    "Generate execution witnesses of new blocks and serve them to peers over the wit protocol",
+		Category: flags.VMCategory,
+	}
+	WitnessStatelessFlag = &cli.BoolFlag{
+		Name:     "witness.stateless",
+		Usage:    "Validate payloads without local state using execution witnesses fetched from peers",
+		Category: flags.VMCategory,
+	}
+	TraceStoreFlag = &cli.BoolFlag{
+		Name:     "tracestore",
+		Usage:    "Persist block traces of finalized blocks for serving repeated trace requests",
+		Category: flags.VMCategory,
+	}
+	TraceStoreSizeFlag = &cli.Uint64Flag{
+		Name:     "tracestore.size",
+		Usage:    "Maximum size of the trace store in megabytes",
+		Value:    ethconfig.Defaults.TraceStoreSize,
+		Category: flags.VMCategory,
+	}
+	WasmTracerDirFlag = &cli.StringFlag{
+		Name:     "tracers.wasmdir",
+		Usage:    "Directory of WebAssembly tracers (*.wasm) to make available by file name",
+		Category: flags.VMCategory,
+	}
+	WasmTracerGasLimitFlag = &cli.Uint64Flag{
+		Name:     "tracers.wasm.gaslimit",
+		Usage:    "Maximum gas a single trace of a WebAssembly tracer may consume, metering both guest instructions and host calls (0=infinite)",
+		Value:    ethconfig.Defaults.WasmTracerGasLimit,
+		Category: flags.VMCategory,
+	}
+	WasmTracerMemoryFlag = &cli.UintFlag{
+		Name:     "tracers.wasm.memory",
+		Usage:    "Maximum memory of a WebAssembly tracer instance in megabytes",
+		Value:    uint(ethconfig.Defaults.WasmTracerMemory),
+		Category: flags.VMCategory,
+	}
+	TraceStoreTracersFlag = &cli.StringSliceFlag{
+		Name:     "tracestore.tracers",
+		Usage:    "Comma separated list of tracers to run on newly finalized blocks (e.g. callTracer,structLogger)",
+		Category: flags.VMCategory,
+	}
 	// API options.
 	RPCGlobalGasCapFlag = &cli.Uint64Flag{
 		Name:     "rpc.gascap",
@@ -630,6 +718,11 @@ var (
 		Value:    ethconfig.Defaults.RPCTxFeeCap,
 		Category: flags.APICategory,
 	}
+	RPCAncientWritesFlag = &cli.BoolFlag{
+		Name:     "rpc.ancientwrites",
+		Usage:    "Allow the debug_db* methods on the IPC endpoint to modify the ancient store (dangerous)",
+		Category: flags.APICategory,
+	}
 	RPCGlobalLogQueryLimit = &cli.IntFlag{
 		Name:     "rpc.logquerylimit",
 		Usage:    "Maximum number of alternative addresses or topics allowed per search position in eth_getLogs filter criteria (0 = no cap)",
@@ -913,6 +1006,21 @@ var (
 		Value:    30303,
 		Category: flags.NetworkingCategory,
 	}
+	QUICPortFlag = &cli.IntFlag{
+		Name:     "quic.port",
+		Usage:    "Accept and dial peer connections over QUIC using this UDP port (experimental)",
+		Category: flags.NetworkingCategory,
+	}
+	P2PRecordDirFlag = &cli.StringFlag{
+		Name:     "p2p.record",
+		Usage:    "Directory to record the protocol messages exchanged with peers (for debugging)",
+		Category: flags.NetworkingCategory,
+	}
+	P2PRecordPeersFlag = &cli.StringFlag{
+		Name:     "p2p.record.peers",
+		Usage:    "Comma separated node IDs of the peers to record (default = all peers)",
+		Category: flags.NetworkingCategory,
+	}
 
 	// Console
 	JSpathFlag = &flags.DirectoryFlag{
@@ -1059,6 +1167,9 @@ var (
 		DataDirFlag,
 		AncientFlag,
 		EraFlag,
+		AncientRemoteFlag,
+		AncientRemoteCacheFlag,
+		AncientZstdFlag,
 		RemoteDBFlag,
 		DBEngineFlag,
 		StateSchemeFlag,
@@ -1201,6 +1312,9 @@ func setListenAddress(ctx *cli.Context, cfg *p2p.Config) {
 	if ctx.IsSet(DiscoveryPortFlag.Name) {
 		cfg.DiscAddr = fmt.Sprintf(":%d", ctx.Int(DiscoveryPortFlag.Name))
 	}
+	if ctx.IsSet(QUICPortFlag.Name) {
+		cfg.QUICAddr = fmt.Sprintf(":%d", ctx.Int(QUICPortFlag.Name))
+	}
 }
 
 // setNAT creates a port mapper from command line flags.
@@ -1412,6 +1526,20 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 		cfg.NetRestrict = list
 	}
 
+	if ctx.IsSet(P2PRecordDirFlag.Name) {
+		cfg.RecordDir = ctx.String(P2PRecordDirFlag.Name)
+	}
+	if peers := ctx.String(P2PRecordPeersFlag.Name); peers != "" {
+		cfg.RecordPeers = nil
+		for _, s := range SplitAndTrim(peers) {
+			id, err := enode.ParseID(s)
+			if err != nil {
+				Fatalf("Option %q: %v", P2PRecordPeersFlag.Name, err)
+			}
+			cfg.RecordPeers = append(cfg.RecordPeers, id)
+		}
+	}
+
 	if ctx.Bool(DeveloperFlag.Name) {
 		// --dev mode can't use p2p networking.
 		cfg.MaxPeers = 0
@@ -1584,6 +1712,24 @@ func setBlobPool(ctx *cli.Context, cfg *blobpool.Config) {
 	}
 }
 
+func setTxPropagation(ctx *cli.Context, cfg *ethconfig.TxPropagationConfig) {
+	if ctx.IsSet(TxPropagationNoLocalsFlag.Name) {
+		cfg.NoLocals = ctx.Bool(TxPropagationNoLocalsFlag.Name)
+	}
+	if ctx.IsSet(TxPropagationTrustedOnlyFlag.Name) {
+		cfg.TrustedOnly = ctx.Bool(TxPropagationTrustedOnlyFlag.Name)
+	}
+	if ctx.IsSet(TxPropagationAnnounceRateFlag.Name) {
+		cfg.AnnounceRate = ctx.Uint64(TxPropagationAnnounceRateFlag.Name)
+	}
+	if ctx.IsSet(TxPropagationBlobTxsFlag.Name) {
+		cfg.BlobTxs = ctx.String(TxPropagationBlobTxsFlag.Name)
+	}
+	if err := cfg.Validate(); err != nil {
+		Fatalf("Invalid transaction propagation policy: %v", err)
+	}
+}
+
 func setMiner(ctx *cli.Context, cfg *miner.Config) {
 	if ctx.Bool(MiningEnabledFlag.Name) {
 		log.Warn("The flag --mine is deprecated and will be removed")
@@ -1648,6 +1794,7 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	setGPO(ctx, &cfg.GPO)
 	setTxPool(ctx, &cfg.TxPool)
 	setBlobPool(ctx, &cfg.BlobPool)
+	setTxPropagation(ctx, &cfg.TxPropagation)
 	setMiner(ctx, &cfg.Miner)
 	setRequiredBlocks(ctx, cfg)
 
@@ -1697,6 +1844,15 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(EraFlag.Name) {
 		cfg.DatabaseEra = ctx.String(EraFlag.Name)
 	}
+	if ctx.IsSet(AncientRemoteFlag.Name) {
+		cfg.DatabaseFreezerRemote = ctx.String(AncientRemoteFlag.Name)
+	}
+	if ctx.IsSet(AncientRemoteCacheFlag.Name) {
+		cfg.DatabaseFreezerRemoteCache = ctx.Int(AncientRemoteCacheFlag.Name)
+	}
+	if ctx.IsSet(AncientZstdFlag.Name) {
+		cfg.DatabaseFreezerZstd = ctx.Bool(AncientZstdFlag.Name)
+	}
 
 	if gcmode := ctx.String(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
 		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
@@ -1726,6 +1882,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(StateSchemeFlag.Name) {
 		cfg.StateScheme = ctx.String(StateSchemeFlag.Name)
 	}
+	if ctx.IsSet(StatePruneCommitsFlag.Name) {
+		if cfg.NoPruning {
+			Fatalf("--%s is not compatible with --%s=archive", StatePruneCommitsFlag.Name, GCModeFlag.Name)
+		}
+		cfg.StatePruneRetention = ctx.Int(StatePruneCommitsFlag.Name)
+	}
 	// Parse transaction history flag, if user is still using legacy config
 	// file with 'TxLookupLimit' configured, copy the value to 'TransactionHistory'.
 	if cfg.TransactionHistory == ethconfig.Defaults.TransactionHistory && cfg.TxLookupLimit != ethconfig.Defaults.TxLookupLimit {
@@ -1808,6 +1970,12 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.Bool(VMWitnessStatsFlag.Name) {
 		cfg.StatelessSelfValidation = true
 	}
+	if ctx.IsSet(WitnessServeFlag.Name) {
+		cfg.WitnessServe = ctx.Bool(WitnessServeFlag.Name)
+	}
+	if ctx.IsSet(WitnessStatelessFlag.Name) {
+		cfg.WitnessStateless = ctx.Bool(WitnessStatelessFlag.Name)
+	}
 
 	if ctx.IsSet(RPCGlobalGasCapFlag.Name) {
 		cfg.RPCGasCap = ctx.Uint64(RPCGlobalGasCapFlag.Name)
@@ -1820,6 +1988,9 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 	if ctx.IsSet(RPCGlobalEVMTimeoutFlag.Name) {
 		cfg.RPCEVMTimeout = ctx.Duration(RPCGlobalEVMTimeoutFlag.Name)
 	}
+	if ctx.IsSet(RPCAncientWritesFlag.Name) {
+		cfg.RPCAncientWrites = ctx.Bool(RPCAncientWritesFlag.Name)
+	}
 	if ctx.IsSet(RPCGlobalTxFeeCapFlag.Name) {
 		cfg.RPCTxFeeCap = ctx.Float64(RPCGlobalTxFeeCapFlag.Name)
 	}
@@ -1982,6 +2153,26 @@ func SetEthConfig(ctx *cli.Context, stack *node.Node, cfg *ethconfig.Config) {
 			cfg.VMTraceJsonConfig = ctx.String(VMTraceJsonConfigFlag.Name)
 		}
 	}
+	// Trace store config.
+	if ctx.IsSet(TraceStoreFlag.Name) {
+		cfg.TraceStore = ctx.Bool(TraceStoreFlag.Name)
+	}
+	if ctx.IsSet(TraceStoreSizeFlag.Name) {
+		cfg.TraceStoreSize = ctx.Uint64(TraceStoreSizeFlag.Name)
+	}
+	if ctx.IsSet(TraceStoreTracersFlag.Name) {
+		cfg.TraceStoreTracers = ctx.StringSlice(TraceStoreTracersFlag.Name)
+	}
+	// WASM tracer config.
+	if ctx.IsSet(WasmTracerDirFlag.Name) {
+		cfg.WasmTracerDir = ctx.String(WasmTracerDirFlag.Name)
+	}
+	if ctx.IsSet(WasmTracerGasLimitFlag.Name) {
+		cfg.WasmTracerGasLimit = ctx.Uint64(WasmTracerGasLimitFlag.Name)
+	}
+	if ctx.IsSet(WasmTracerMemoryFlag.Name) {
+		cfg.WasmTracerMemory = uint32(ctx.Uint(WasmTracerMemoryFlag.Name))
+	}
 }
 
 // MakeBeaconLightConfig constructs a beacon light client config based on the
@@ -2100,10 +2291,43 @@ func RegisterEthService(stack *node.Node, cfg *ethconfig.Config) (*eth.EthAPIBac
 	if err != nil {
 		Fatalf("Failed to register the Ethereum service: %v", err)
 	}
-	stack.RegisterAPIs(tracers.APIs(backend.APIBackend))
+	if cfg.WasmTracerDir != "" {
+		_, err := wasm.LoadDirectory(cfg.WasmTracerDir, wasm.Config{
+			GasLimit:    cfg.WasmTracerGasLimit,
+			MemoryLimit: cfg.WasmTracerMemory,
+		})
+		if err != nil {
+			Fatalf("Failed to load WASM tracers: %v", err)
+		}
+	}
+	if cfg.TraceStore {
+		registerTraceStore(stack, backend.APIBackend, cfg)
+	} else {
+		stack.RegisterAPIs(tracers.APIs(backend.APIBackend))
+	}
 	return backend.APIBackend, backend
 }
 
+// registerTraceStore opens the persistent trace store and registers the tracing
+// APIs backed by it, along with the pretracer of finalized blocks.
+func registerTraceStore(stack *node.Node, backend *eth.EthAPIBackend, cfg *ethconfig.Config) {
+	db, err := stack.OpenDatabaseWithOptions("tracestore", node.DatabaseOptions{
+		Cache:            16,
+		Handles:          16,
+		MetricsNamespace: "eth/db/tracestore/",
+	})
+	if err != nil {
+		Fatalf("Failed to open the trace store: %v", err)
+	}
+	store, err := tracers.NewTraceStore(db, cfg.TraceStoreSize*1024*1024)
+	if err != nil {
+		Fatalf("Failed to load the trace store: %v", err)
+	}
+	stack.RegisterAPIs(tracers.APIsWithStore(backend, store))
+	stack.RegisterLifecycle(tracers.NewPretracer(backend, store, cfg.TraceStoreTracers))
+	log.Info("Enabled trace store", "limit", common.StorageSize(cfg.TraceStoreSize*1024*1024), "tracers", cfg.TraceStoreTracers)
+}
+
 // RegisterEthStatsService configures the Ethereum Stats daemon and adds it to the node.
 func RegisterEthStatsService(stack *node.Node, backend *eth.EthAPIBackend, url string) {
 	if err := ethstats.New(stack, backend, backend.Engine(), url); err != nil {
@@ -2233,6 +2457,13 @@ func MakeChainDatabase(ctx *cli.Context, stack *node.Node, readonly bool) ethdb.
 			AncientsDirectory: ctx.String(AncientFlag.Name),
 			MetricsNamespace:  "eth/db/chaindata/",
 			EraDirectory:      ctx.String(EraFlag.Name),
+			AncientZstd:       ctx.Bool(AncientZstdFlag.Name),
+		}
+		if ctx.IsSet(AncientRemoteFlag.Name) {
+			options.AncientRemote, err = rawdb.OpenFreezerRemote(ctx.String(AncientRemoteFlag.Name), ctx.Int(AncientRemoteCacheFlag.Name)*1024*1024)
+			if err != nil {
+				break
+			}
 		}
 		chainDb, err = stack.OpenDatabaseWithOptions("chaindata", options)
 	}
@@ -2338,6 +2569,7 @@ func MakeChain(ctx *cli.Context, stack *node.Node, readonly bool) (*core.BlockCh
 		StateHistory:            ctx.Uint64(StateHistoryFlag.Name),
 		TrienodeHistory:         ctx.Int64(TrienodeHistoryFlag.Name),
 		NodeFullValueCheckpoint: uint32(ctx.Uint(TrienodeHistoryFullValueCheckpointFlag.Name)),
+		StatePruneRetention:     ctx.Int(StatePruneCommitsFlag.Name),
 
 		// Disable transaction indexing/unindexing.
 		TxLookupLimit: -1,
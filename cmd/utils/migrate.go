@@ -0,0 +1,398 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/ethereum/go-ethereum/triedb/hashdb"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+)
+
+// errSchemeMigrationInterrupted is returned if the state scheme migration is
+// interrupted by the user. The progress is saved and picked up by the next run.
+var errSchemeMigrationInterrupted = errors.New("state scheme migration interrupted, run it again to resume")
+
+const (
+	migrateStatePhase  = iota // The head state is copied into the path-based layout
+	migrateLegacyPhase        // The legacy trie nodes are deleted
+)
+
+// schemeMigration is the persisted progress of an in-place migration from the
+// hash scheme to the path scheme.
+type schemeMigration struct {
+	Root   common.Hash // State root being migrated
+	Phase  uint8       // Current phase of the migration
+	Marker []byte      // Account hash (state phase) or database key (legacy phase) to resume from
+
+	Nodes    uint64 // Number of trie nodes copied
+	Accounts uint64 // Number of accounts copied
+	Slots    uint64 // Number of storage slots copied
+	Deleted  uint64 // Number of legacy trie nodes deleted
+}
+
+// loadSchemeMigration retrieves the progress of a previously interrupted state
+// scheme migration, or nil if there is none.
+func loadSchemeMigration(db ethdb.KeyValueReader) *schemeMigration {
+	blob := rawdb.ReadStateSchemeMigration(db)
+	if len(blob) == 0 {
+		return nil
+	}
+	var progress schemeMigration
+	if err := rlp.DecodeBytes(blob, &progress); err != nil {
+		log.Warn("Failed to decode scheme migration progress", "err", err)
+		return nil
+	}
+	return &progress
+}
+
+// writeSchemeMigration stores the progress of the state scheme migration.
+func writeSchemeMigration(db ethdb.KeyValueWriter, progress *schemeMigration) {
+	blob, err := rlp.EncodeToBytes(progress)
+	if err != nil {
+		panic(err) // Cannot happen, here to catch dev errors
+	}
+	rawdb.WriteStateSchemeMigration(db, blob)
+}
+
+// MigrateStateScheme converts the head state of a hash-based database into the
+// path-based layout in place. The trie nodes and the flat state are copied over
+// first, after which the path database is activated and the legacy trie nodes
+// are deleted. The migration can be interrupted by closing the interrupt channel
+// at any time and is resumed from the last saved progress on the next call.
+func MigrateStateScheme(db ethdb.Database, config *pathdb.Config, interrupt <-chan struct{}) error {
+	progress := loadSchemeMigration(db)
+	if progress == nil || progress.Phase == migrateStatePhase {
+		head := rawdb.ReadHeadBlock(db)
+		if head == nil {
+			return errors.New("no head block")
+		}
+		root := head.Root()
+		if progress == nil || progress.Root != root {
+			if scheme := rawdb.ReadStateScheme(db); scheme != rawdb.HashScheme {
+				return fmt.Errorf("state is not stored in hash scheme (%q)", scheme)
+			}
+			if !rawdb.HasLegacyTrieNode(db, root) {
+				return fmt.Errorf("head state %x is missing, restart and shut down the node cleanly first", root)
+			}
+			if progress != nil {
+				log.Warn("Head state changed, restarting scheme migration", "old", progress.Root, "new", root)
+			}
+			if err := wipeMigrationTarget(db, interrupt); err != nil {
+				return err
+			}
+			progress = &schemeMigration{Root: root}
+			writeSchemeMigration(db, progress)
+		}
+		if err := migrateState(db, progress, interrupt); err != nil {
+			return err
+		}
+	}
+	// The path database is activated in the same way as after a snap sync, which
+	// resets the state id and schedules the verification of the flat state.
+	if rawdb.ReadSnapSyncStatusFlag(db) == rawdb.StateSyncRunning {
+		tdb := triedb.NewDatabase(db, &triedb.Config{PathDB: config})
+		if err := tdb.Enable(progress.Root); err != nil {
+			tdb.Close()
+			return err
+		}
+		if err := tdb.Close(); err != nil {
+			return err
+		}
+	}
+	if err := deleteLegacyNodes(db, progress, interrupt); err != nil {
+		return err
+	}
+	rawdb.DeleteStateSchemeMigration(db)
+	log.Info("Migrated state to path scheme", "root", progress.Root, "nodes", progress.Nodes,
+		"accounts", progress.Accounts, "slots", progress.Slots, "deleted", progress.Deleted)
+	return nil
+}
+
+// wipeMigrationTarget deletes any leftover flat state and path-based trie nodes,
+// along with the metadata of the legacy state snapshot, before the migration
+// starts afresh. The legacy trie nodes sharing the key ranges are retained.
+func wipeMigrationTarget(db ethdb.Database, interrupt <-chan struct{}) error {
+	prefixes := [][]byte{
+		rawdb.SnapshotAccountPrefix, rawdb.SnapshotStoragePrefix,
+		rawdb.TrieNodeAccountPrefix, rawdb.TrieNodeStoragePrefix,
+	}
+	for _, prefix := range prefixes {
+		err := rawdb.SafeDeleteRange(db, prefix, []byte{prefix[0] + 1}, true, func(bool) bool {
+			select {
+			case <-interrupt:
+				return true
+			default:
+				return false
+			}
+		})
+		if errors.Is(err, rawdb.ErrDeleteRangeInterrupted) {
+			return errSchemeMigrationInterrupted
+		}
+		if err != nil {
+			return err
+		}
+	}
+	batch := db.NewBatch()
+	rawdb.DeleteSnapshotRoot(batch)
+	rawdb.DeleteSnapshotJournal(batch)
+	rawdb.DeleteSnapshotGenerator(batch)
+	rawdb.DeleteSnapshotRecoveryNumber(batch)
+	rawdb.DeleteSnapshotDisabled(batch)
+	return batch.Write()
+}
+
+// migrateState walks the hash-based head state and writes every trie node in
+// the path-based layout, along with the flat state and the contract codes. The
+// root node is written last, as its presence switches the database over to the
+// path scheme.
+func migrateState(db ethdb.Database, progress *schemeMigration, interrupt <-chan struct{}) error {
+	tdb := triedb.NewDatabase(db, &triedb.Config{HashDB: hashdb.Defaults})
+	defer tdb.Close()
+
+	t, err := trie.NewStateTrie(trie.StateTrieID(progress.Root), tdb)
+	if err != nil {
+		return err
+	}
+	accIter, err := t.NodeIterator(progress.Marker)
+	if err != nil {
+		return err
+	}
+	var (
+		batch  = db.NewBatch()
+		start  = time.Now()
+		logged = time.Now()
+		root   []byte
+	)
+	// flush writes out the batch along with the progress, which always points
+	// to an account whose storage is not yet fully written.
+	flush := func() error {
+		writeSchemeMigration(batch, progress)
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+		return nil
+	}
+	for accIter.Next(true) {
+		if accIter.Hash() != (common.Hash{}) {
+			if len(accIter.Path()) == 0 {
+				root = common.CopyBytes(accIter.NodeBlob())
+			} else {
+				rawdb.WriteAccountTrieNode(batch, accIter.Path(), accIter.NodeBlob())
+			}
+			progress.Nodes++
+		}
+		if !accIter.Leaf() {
+			continue
+		}
+		// Check interruption emitted by ctrl+c before touching the next account
+		// and persist the progress if so.
+		accHash := common.BytesToHash(accIter.LeafKey())
+		progress.Marker = accHash.Bytes()
+
+		select {
+		case <-interrupt:
+			if err := flush(); err != nil {
+				return err
+			}
+			log.Info("State scheme migration interrupted", "accounts", progress.Accounts, "slots", progress.Slots, "at", accHash)
+			return errSchemeMigrationInterrupted
+		default:
+		}
+		var acc types.StateAccount
+		if err := rlp.DecodeBytes(accIter.LeafBlob(), &acc); err != nil {
+			return fmt.Errorf("invalid account %x: %v", accHash, err)
+		}
+		rawdb.WriteAccountSnapshot(batch, accHash, types.SlimAccountRLP(acc))
+		progress.Accounts++
+
+		if !bytes.Equal(acc.CodeHash, types.EmptyCodeHash.Bytes()) {
+			codeHash := common.BytesToHash(acc.CodeHash)
+			if !rawdb.HasCodeWithPrefix(db, codeHash) {
+				code := rawdb.ReadCode(db, codeHash)
+				if len(code) == 0 {
+					return fmt.Errorf("missing code %x of account %x", codeHash, accHash)
+				}
+				rawdb.WriteCode(batch, codeHash, code)
+			}
+		}
+		if acc.Root != types.EmptyRootHash {
+			id := trie.StorageTrieID(progress.Root, accHash, acc.Root)
+			storageTrie, err := trie.NewStateTrie(id, tdb)
+			if err != nil {
+				return err
+			}
+			storageIter, err := storageTrie.NodeIterator(nil)
+			if err != nil {
+				return err
+			}
+			for storageIter.Next(true) {
+				if storageIter.Hash() != (common.Hash{}) {
+					rawdb.WriteStorageTrieNode(batch, accHash, storageIter.Path(), storageIter.NodeBlob())
+					progress.Nodes++
+				}
+				if storageIter.Leaf() {
+					rawdb.WriteStorageSnapshot(batch, accHash, common.BytesToHash(storageIter.LeafKey()), storageIter.LeafBlob())
+					progress.Slots++
+				}
+				if batch.ValueSize() > ethdb.IdealBatchSize {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			}
+			if storageIter.Error() != nil {
+				return storageIter.Error()
+			}
+		}
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Migrating state to path scheme", "accounts", progress.Accounts, "slots", progress.Slots,
+				"nodes", progress.Nodes, "progress", migrationProgress(accHash.Bytes()), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+	}
+	if accIter.Error() != nil {
+		return accIter.Error()
+	}
+	// The root node is only visited if the iteration started from scratch,
+	// resolve it from the legacy store otherwise.
+	if root == nil {
+		root = rawdb.ReadLegacyTrieNode(db, progress.Root)
+	}
+	if len(root) == 0 {
+		return fmt.Errorf("missing root node %x", progress.Root)
+	}
+	// Write the root node and mark the state as being synced in one go, the path
+	// database is activated after this and the legacy nodes can be deleted.
+	rawdb.WriteAccountTrieNode(batch, nil, root)
+	rawdb.WriteSnapSyncStatusFlag(batch, rawdb.StateSyncRunning)
+	progress.Phase, progress.Marker = migrateLegacyPhase, nil
+	if err := flush(); err != nil {
+		return err
+	}
+	log.Info("Copied state to path scheme", "accounts", progress.Accounts, "slots", progress.Slots,
+		"nodes", progress.Nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// deleteLegacyNodes iterates the entire key-value store and deletes the legacy
+// trie nodes (and the legacy contract codes) keyed by their hash. Contiguous
+// runs of them are deleted as ranges, so that the store can drop them cheaply.
+func deleteLegacyNodes(db ethdb.Database, progress *schemeMigration, interrupt <-chan struct{}) error {
+	var (
+		batch    = db.NewBatch()
+		it       = db.NewIterator(nil, progress.Marker)
+		start    = time.Now()
+		logged   = time.Now()
+		scanned  int
+		runStart []byte // First key of the current run of legacy nodes
+		runEnd   []byte // Last key of the current run of legacy nodes
+	)
+	defer func() { it.Release() }()
+
+	// closeRun schedules the deletion of the current run of legacy nodes. The
+	// range is exclusive, appending a zero gives the next possible key.
+	closeRun := func() error {
+		if runStart == nil {
+			return nil
+		}
+		err := batch.DeleteRange(runStart, append(runEnd, 0))
+		runStart, runEnd = nil, nil
+		return err
+	}
+	for it.Next() {
+		key := it.Key()
+		if len(key) == common.HashLength && crypto.Keccak256Hash(it.Value()) == common.BytesToHash(key) {
+			if runStart == nil {
+				runStart = common.CopyBytes(key)
+			}
+			runEnd = common.CopyBytes(key)
+			progress.Deleted++
+		} else if err := closeRun(); err != nil {
+			return err
+		}
+		scanned++
+		if scanned%10000 != 0 {
+			continue
+		}
+		// Flush the deletions along with the position to resume from, and renew
+		// the iterator to release the underlying snapshot of the store.
+		if err := closeRun(); err != nil {
+			return err
+		}
+		progress.Marker = append(common.CopyBytes(key), 0)
+		writeSchemeMigration(batch, progress)
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch.Reset()
+
+		select {
+		case <-interrupt:
+			log.Info("Legacy trie node deletion interrupted", "deleted", progress.Deleted, "at", common.Bytes2Hex(key))
+			return errSchemeMigrationInterrupted
+		default:
+		}
+		if time.Since(logged) > 8*time.Second {
+			log.Info("Deleting legacy trie nodes", "deleted", progress.Deleted, "scanned", scanned,
+				"at", common.Bytes2Hex(key), "elapsed", common.PrettyDuration(time.Since(start)))
+			logged = time.Now()
+		}
+		it.Release()
+		it = db.NewIterator(nil, progress.Marker)
+	}
+	if it.Error() != nil {
+		return it.Error()
+	}
+	if err := closeRun(); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Deleted legacy trie nodes", "deleted", progress.Deleted, "scanned", scanned,
+		"elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// migrationProgress estimates the migration progress from the position in the
+// account hash space.
+func migrationProgress(marker []byte) string {
+	if len(marker) < 8 {
+		return "0.00%"
+	}
+	return fmt.Sprintf("%.2f%%", float64(binary.BigEndian.Uint64(marker))/float64(^uint64(0))*100)
+}
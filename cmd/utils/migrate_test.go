@@ -0,0 +1,141 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/triedb/pathdb"
+)
+
+func TestMigrateStateScheme(t *testing.T) {
+	var (
+		key, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		address  = crypto.PubkeyToAddress(key.PublicKey)
+		contract = common.Address{0xcc}
+		code     = common.FromHex("0x3460005500") // sstore(0, callvalue)
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				address:  {Balance: big.NewInt(params.Ether)},
+				contract: {Code: code, Storage: map[common.Hash]common.Hash{{1}: {1}}},
+			},
+		}
+		signer = types.LatestSigner(genesis.Config)
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(genesis, ethash.NewFaker(), 17, func(i int, g *core.BlockGen) {
+		g.AddTx(types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   genesis.Config.ChainID,
+			Nonce:     g.TxNonce(address),
+			GasFeeCap: g.BaseFee(),
+			Gas:       50000,
+			To:        &contract,
+			Value:     big.NewInt(int64(i + 1)),
+		}))
+		g.AddTx(types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+			ChainID:   genesis.Config.ChainID,
+			Nonce:     g.TxNonce(address),
+			GasFeeCap: g.BaseFee(),
+			Gas:       params.TxGas,
+			To:        &common.Address{byte(i + 1)},
+			Value:     big.NewInt(1),
+		}))
+	})
+	blocks, more := blocks[:16], blocks[16:]
+	head := blocks[len(blocks)-1]
+
+	db := newCheckpointTestDB(t)
+	chain, err := core.NewBlockChain(db, genesis, ethash.NewFaker(), core.DefaultConfig().WithStateScheme(rawdb.HashScheme))
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if _, err := chain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	chain.Stop()
+
+	// An interrupted migration leaves the database in hash scheme
+	config := *pathdb.Defaults
+	interrupt := make(chan struct{})
+	close(interrupt)
+	if err := MigrateStateScheme(db, &config, interrupt); !errors.Is(err, errSchemeMigrationInterrupted) {
+		t.Fatalf("expected interruption, got %v", err)
+	}
+	if progress := loadSchemeMigration(db); progress == nil || progress.Root != head.Root() || progress.Phase != migrateStatePhase {
+		t.Fatalf("unexpected progress: %+v", progress)
+	}
+	if scheme := rawdb.ReadStateScheme(db); scheme != rawdb.HashScheme {
+		t.Fatalf("unexpected scheme after interruption: %s", scheme)
+	}
+	// Resume the migration and run it to completion
+	if err := MigrateStateScheme(db, &config, nil); err != nil {
+		t.Fatalf("failed to migrate state: %v", err)
+	}
+	if blob := rawdb.ReadStateSchemeMigration(db); len(blob) != 0 {
+		t.Fatal("migration progress not deleted")
+	}
+	if scheme := rawdb.ReadStateScheme(db); scheme != rawdb.PathScheme {
+		t.Fatalf("unexpected scheme after migration: %s", scheme)
+	}
+	it := db.NewIterator(nil, nil)
+	for it.Next() {
+		if len(it.Key()) == common.HashLength && crypto.Keccak256Hash(it.Value()) == common.BytesToHash(it.Key()) {
+			t.Fatalf("legacy trie node %x left behind", it.Key())
+		}
+	}
+	it.Release()
+
+	// The migrated state is usable by a path-based chain
+	chain, err = core.NewBlockChain(db, genesis, ethash.NewFaker(), core.DefaultConfig().WithStateScheme(rawdb.PathScheme))
+	if err != nil {
+		t.Fatalf("failed to reopen chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if have := chain.CurrentBlock().Hash(); have != head.Hash() {
+		t.Fatalf("unexpected head: have %x, want %x", have, head.Hash())
+	}
+	statedb, err := chain.StateAt(head.Root())
+	if err != nil {
+		t.Fatalf("failed to open migrated state: %v", err)
+	}
+	if have := statedb.GetState(contract, common.Hash{}); have != common.BigToHash(big.NewInt(int64(len(blocks)))) {
+		t.Errorf("unexpected slot 0: %x", have)
+	}
+	if have := statedb.GetState(contract, common.Hash{1}); have != (common.Hash{1}) {
+		t.Errorf("unexpected slot 1: %x", have)
+	}
+	if have := statedb.GetCode(contract); string(have) != string(code) {
+		t.Errorf("unexpected code: %x", have)
+	}
+	if have := statedb.GetNonce(address); have != uint64(2*len(blocks)) {
+		t.Errorf("unexpected nonce: %d", have)
+	}
+	// The chain keeps progressing on top of the migrated state
+	if _, err := chain.InsertChain(more); err != nil {
+		t.Fatalf("failed to extend migrated chain: %v", err)
+	}
+}
@@ -189,6 +189,12 @@ type BlockChainConfig struct {
 	// are stored in diff mode for storage compression.
 	NodeFullValueCheckpoint uint32
 
+	// Number of recently committed states retained on disk by the online state
+	// pruner. States are committed when the dirty trie cache is flushed, which is
+	// far less frequent than blocks in full mode. It's only relevant in hash mode,
+	// if set to 0 the pruning is disabled.
+	StatePruneRetention int
+
 	// State snapshot related options
 	SnapshotLimit   int  // Memory allowance (MB) to use for caching snapshot entries in memory
 	SnapshotNoBuild bool // Whether the background generation is allowed
@@ -263,6 +269,11 @@ func (cfg *BlockChainConfig) triedbConfig(isVerkle bool) *triedb.Config {
 		config.HashDB = &hashdb.Config{
 			CleanCacheSize: cfg.TrieCleanLimit * 1024 * 1024,
 		}
+		// The chain commits up to three states on shutdown, all of which must be
+		// retained by the pruner to restart from the head.
+		if !cfg.ArchiveMode && cfg.StatePruneRetention > 0 {
+			config.HashDB.PruneRetention = max(cfg.StatePruneRetention, 3)
+		}
 	}
 	if cfg.StateScheme == rawdb.PathScheme {
 		config.PathDB = &pathdb.Config{
@@ -1139,10 +1150,14 @@ func (bc *BlockChain) SnapSyncStart() error {
 	// trie database unusable until the state is fully synced. To prevent any
 	// subsequent state reads, explicitly disable the trie database and state
 	// syncer is responsible to address and correct any state missing.
-	if bc.TrieDB().Scheme() == rawdb.PathScheme {
-		if err := bc.TrieDB().Disable(); err != nil {
-			return err
-		}
+	//
+	// In hash mode, the synced nodes are written without reference counting, so
+	// the counts of the online pruner are dropped: the nodes persisted before the
+	// sync could otherwise be deleted while being shared by the synced state. The
+	// states on disk at this point are never pruned online afterwards, only the
+	// ones committed later, use offline pruning to reclaim their space.
+	if err := bc.TrieDB().Disable(); err != nil {
+		return err
 	}
 	// Snap sync uses the snapshot namespace to store potentially flaky data until
 	// sync completely heals and finishes. Pause snapshot maintenance in the mean-
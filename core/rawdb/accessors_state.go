@@ -150,6 +150,29 @@ func WriteTrieJournal(db ethdb.KeyValueWriter, journal []byte) {
 	}
 }
 
+// ReadStateSchemeMigration retrieves the serialized progress of the in-place
+// state scheme migration.
+func ReadStateSchemeMigration(db ethdb.KeyValueReader) []byte {
+	data, _ := db.Get(stateSchemeMigrationKey)
+	return data
+}
+
+// WriteStateSchemeMigration stores the serialized progress of the in-place state
+// scheme migration.
+func WriteStateSchemeMigration(db ethdb.KeyValueWriter, progress []byte) {
+	if err := db.Put(stateSchemeMigrationKey, progress); err != nil {
+		log.Crit("Failed to store state scheme migration progress", "err", err)
+	}
+}
+
+// DeleteStateSchemeMigration deletes the progress of the in-place state scheme
+// migration.
+func DeleteStateSchemeMigration(db ethdb.KeyValueWriter) {
+	if err := db.Delete(stateSchemeMigrationKey); err != nil {
+		log.Crit("Failed to remove state scheme migration progress", "err", err)
+	}
+}
+
 // ReadStateHistoryMeta retrieves the metadata corresponding to the specified
 // state history. Compute the position of state history in freezer by minus
 // one since the id of first state history starts from one(zero for initial
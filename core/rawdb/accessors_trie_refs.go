@@ -0,0 +1,123 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// ReadTrieNodeRefs retrieves the reference count record of the hash-based trie
+// node with the given hash. Nil is returned if the node is not tracked.
+func ReadTrieNodeRefs(db ethdb.KeyValueReader, hash common.Hash) []byte {
+	data, _ := db.Get(trieNodeRefKey(hash))
+	return data
+}
+
+// WriteTrieNodeRefs stores the reference count record of the trie node.
+func WriteTrieNodeRefs(db ethdb.KeyValueWriter, hash common.Hash, record []byte) {
+	if err := db.Put(trieNodeRefKey(hash), record); err != nil {
+		log.Crit("Failed to store trie node references", "err", err)
+	}
+}
+
+// DeleteTrieNodeRefs deletes the reference count record of the trie node.
+func DeleteTrieNodeRefs(db ethdb.KeyValueWriter, hash common.Hash) {
+	if err := db.Delete(trieNodeRefKey(hash)); err != nil {
+		log.Crit("Failed to delete trie node references", "err", err)
+	}
+}
+
+// ReadTrieNodeGarbage retrieves at most limit hashes of the unreferenced trie
+// nodes which are pending deletion.
+func ReadTrieNodeGarbage(db ethdb.Iteratee, limit int) []common.Hash {
+	it := db.NewIterator(TrieNodeGarbagePrefix, nil)
+	defer it.Release()
+
+	var hashes []common.Hash
+	for it.Next() && len(hashes) < limit {
+		// Skip the legacy trie nodes sharing the prefix
+		if len(it.Key()) != len(TrieNodeGarbagePrefix)+common.HashLength {
+			continue
+		}
+		hashes = append(hashes, common.BytesToHash(it.Key()[len(TrieNodeGarbagePrefix):]))
+	}
+	return hashes
+}
+
+// WriteTrieNodeGarbage marks the trie node as unreferenced and pending deletion.
+func WriteTrieNodeGarbage(db ethdb.KeyValueWriter, hash common.Hash) {
+	if err := db.Put(trieNodeGarbageKey(hash), nil); err != nil {
+		log.Crit("Failed to store trie node garbage marker", "err", err)
+	}
+}
+
+// DeleteTrieNodeGarbage removes the pending deletion marker of the trie node.
+func DeleteTrieNodeGarbage(db ethdb.KeyValueWriter, hash common.Hash) {
+	if err := db.Delete(trieNodeGarbageKey(hash)); err != nil {
+		log.Crit("Failed to delete trie node garbage marker", "err", err)
+	}
+}
+
+// ReadTrieRefRoots retrieves the state roots pinned by the online pruner,
+// ordered from the oldest to the newest.
+func ReadTrieRefRoots(db ethdb.KeyValueReader) []common.Hash {
+	blob, err := db.Get(trieRefRootsKey)
+	if err != nil || len(blob) == 0 {
+		return nil
+	}
+	var roots []common.Hash
+	if err := rlp.DecodeBytes(blob, &roots); err != nil {
+		log.Error("Invalid pinned trie roots", "err", err)
+		return nil
+	}
+	return roots
+}
+
+// HasTrieRefRoots reports whether the pinned state roots of the online pruner
+// are present, i.e. whether the trie nodes are reference tracked.
+func HasTrieRefRoots(db ethdb.KeyValueReader) bool {
+	ok, _ := db.Has(trieRefRootsKey)
+	return ok
+}
+
+// WriteTrieRefRoots stores the state roots pinned by the online pruner.
+func WriteTrieRefRoots(db ethdb.KeyValueWriter, roots []common.Hash) {
+	blob, err := rlp.EncodeToBytes(roots)
+	if err != nil {
+		log.Crit("Failed to encode pinned trie roots", "err", err)
+	}
+	if err := db.Put(trieRefRootsKey, blob); err != nil {
+		log.Crit("Failed to store pinned trie roots", "err", err)
+	}
+}
+
+// DeleteTrieRefs removes all the reference counting data of the hash-based trie
+// nodes, turning every node on disk into an untracked, permanent one. The trie
+// nodes themselves sharing the key prefixes are left untouched.
+func DeleteTrieRefs(db ethdb.KeyValueStore) error {
+	noStop := func(bool) bool { return false }
+	if err := deletePrefixRange(db, TrieNodeRefPrefix, true, noStop); err != nil {
+		return err
+	}
+	if err := deletePrefixRange(db, TrieNodeGarbagePrefix, true, noStop); err != nil {
+		return err
+	}
+	return db.Delete(trieRefRootsKey)
+}
@@ -52,6 +52,7 @@ var chainFreezerTableConfigs = map[string]freezerTableConfig{
 type freezerTableConfig struct {
 	noSnappy bool // disables item compression
 	prunable bool // true for tables that can be pruned by TruncateTail
+	zstd     bool // creates the table with zstd compression if it doesn't exist yet
 }
 
 const (
@@ -24,9 +24,29 @@ import (
 	"github.com/ethereum/go-ethereum/ethdb"
 )
 
+// compressionSamples is the number of items sampled from each table for
+// estimating the compression ratio.
+const compressionSamples = 256
+
+// compressionInspector is implemented by the ancient stores which are able to
+// report the compression of their tables.
+type compressionInspector interface {
+	tableCompression(kind string) (string, float64, error)
+}
+
 type tableSize struct {
-	name string
-	size common.StorageSize
+	name        string
+	size        common.StorageSize
+	compression string  // compression of the items, empty if unknown
+	ratio       float64 // estimated compression ratio, zero if unknown
+}
+
+// savings returns the estimated amount of storage saved by the compression.
+func (t tableSize) savings() common.StorageSize {
+	if t.ratio <= 1 {
+		return 0
+	}
+	return common.StorageSize(float64(t.size) * (t.ratio - 1))
 }
 
 // freezerInfo contains the basic information of the freezer.
@@ -54,7 +74,13 @@ func inspect(name string, order map[string]freezerTableConfig, reader ethdb.Anci
 		if err != nil {
 			return freezerInfo{}, err
 		}
-		info.sizes = append(info.sizes, tableSize{name: t, size: common.StorageSize(size)})
+		table := tableSize{name: t, size: common.StorageSize(size)}
+		if inspector, ok := reader.(compressionInspector); ok {
+			if table.compression, table.ratio, err = inspector.tableCompression(t); err != nil {
+				return freezerInfo{}, err
+			}
+		}
+		info.sizes = append(info.sizes, table)
 	}
 	// Retrieve the number of last stored item
 	ancients, err := reader.Ancients()
@@ -154,7 +180,7 @@ func InspectFreezerTable(ancient string, freezerName string, tableName string, s
 	default:
 		return fmt.Errorf("unknown freezer, supported ones: %v", freezers)
 	}
-	noSnappy, exist := tables[tableName]
+	config, exist := tables[tableName]
 	if !exist {
 		var names []string
 		for name := range tables {
@@ -162,10 +188,45 @@ func InspectFreezerTable(ancient string, freezerName string, tableName string, s
 		}
 		return fmt.Errorf("unknown table, supported ones: %v", names)
 	}
-	table, err := newFreezerTable(path, tableName, noSnappy, true)
+	table, err := newFreezerTable(path, tableName, config, true)
 	if err != nil {
 		return err
 	}
 	table.dumpIndexStdout(start, end)
 	return nil
 }
+
+// RecompressFreezerTable converts the given table of a freezer to zstd
+// compression with a trained dictionary. The passed ancient indicates the path
+// of root ancient directory where the freezers can be opened. The freezer must
+// not be in use, opening it fails if a running node holds it.
+func RecompressFreezerTable(ancient string, freezerName string, tableName string, interrupt <-chan struct{}) error {
+	var (
+		path   string
+		tables map[string]freezerTableConfig
+	)
+	switch freezerName {
+	case ChainFreezerName:
+		path, tables = resolveChainFreezerDir(ancient), chainFreezerTableConfigs
+	case MerkleStateFreezerName, VerkleStateFreezerName:
+		path, tables = filepath.Join(ancient, freezerName), stateFreezerTableConfigs
+	case MerkleTrienodeFreezerName, VerkleTrienodeFreezerName:
+		path, tables = filepath.Join(ancient, freezerName), trienodeFreezerTableConfigs
+	default:
+		return fmt.Errorf("unknown freezer, supported ones: %v", freezers)
+	}
+	if _, exist := tables[tableName]; !exist {
+		var names []string
+		for name := range tables {
+			names = append(names, name)
+		}
+		return fmt.Errorf("unknown table, supported ones: %v", names)
+	}
+	f, err := NewFreezer(path, "", false, freezerTableSize, tables)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return f.Recompress(tableName, interrupt)
+}
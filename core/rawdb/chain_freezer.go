@@ -38,8 +38,16 @@ const (
 	// freezerBatchLimit is the maximum number of blocks to freeze in one batch
 	// before doing an fsync and deleting it from the key-value store.
 	freezerBatchLimit = 30000
+
+	// recompactInterval is the time between the attempts to recompress the
+	// chain freezer tables which failed to be converted.
+	recompactInterval = time.Hour
 )
 
+// recompactDelay is the time waited after startup before recompressing the chain
+// freezer tables in the background, variable to allow testing.
+var recompactDelay = time.Minute
+
 // chainFreezer is a wrapper of chain ancient store with additional chain freezing
 // feature. The background thread will keep moving ancient chain segments from
 // key-value database to flat files for saving space on live database.
@@ -59,8 +67,9 @@ type chainFreezer struct {
 //   - if the empty directory is given, initializes the pure in-memory
 //     state freezer (e.g. dev mode).
 //   - if non-empty directory is given, initializes the regular file-based
-//     state freezer.
-func newChainFreezer(datadir string, eraDir string, namespace string, readonly bool) (*chainFreezer, error) {
+//     state freezer, optionally keeping the sealed files in an object store
+//     and creating the compressed tables with zstd.
+func newChainFreezer(datadir string, eraDir string, namespace string, readonly bool, remote *FreezerRemote, zstd bool) (*chainFreezer, error) {
 	if datadir == "" {
 		return &chainFreezer{
 			ancients: NewMemoryFreezer(readonly, chainFreezerTableConfigs),
@@ -68,7 +77,15 @@ func newChainFreezer(datadir string, eraDir string, namespace string, readonly b
 			trigger:  make(chan chan struct{}),
 		}, nil
 	}
-	freezer, err := NewFreezer(datadir, namespace, readonly, freezerTableSize, chainFreezerTableConfigs)
+	tables := chainFreezerTableConfigs
+	if zstd {
+		tables = make(map[string]freezerTableConfig, len(chainFreezerTableConfigs))
+		for kind, config := range chainFreezerTableConfigs {
+			config.zstd = !config.noSnappy
+			tables[kind] = config
+		}
+	}
+	freezer, err := NewFreezerWithRemote(datadir, namespace, readonly, freezerTableSize, tables, remote)
 	if err != nil {
 		return nil, err
 	}
@@ -99,6 +116,40 @@ func (f *chainFreezer) Close() error {
 	return f.ancients.Close()
 }
 
+// recompact converts the snappy compressed tables of the chain freezer to zstd
+// in the background, until all of them are converted or the freezer is closed.
+// Tables failing to be converted, e.g. due to a concurrent head truncation, are
+// retried periodically.
+func (f *chainFreezer) recompact() {
+	freezer, ok := f.ancients.(*Freezer)
+	if !ok {
+		return
+	}
+	timer := time.NewTimer(recompactDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-f.quit:
+			return
+		case <-timer.C:
+		}
+		for _, kind := range freezer.recompressible() {
+			err := freezer.Recompress(kind, f.quit)
+			if errors.Is(err, errRecompressInterrupted) {
+				return
+			}
+			if err != nil {
+				log.Warn("Failed to recompress freezer table", "table", kind, "err", err)
+			}
+		}
+		if len(freezer.recompressible()) == 0 {
+			return
+		}
+		timer.Reset(recompactInterval)
+	}
+}
+
 // readHeadNumber returns the number of chain head block. 0 is returned if the
 // block is unknown or not available yet.
 func (f *chainFreezer) readHeadNumber(db ethdb.KeyValueReader) uint64 {
@@ -399,6 +450,13 @@ func (f *chainFreezer) AncientSize(kind string) (uint64, error) {
 	return f.ancients.AncientSize(kind)
 }
 
+func (f *chainFreezer) tableCompression(kind string) (string, float64, error) {
+	if inspector, ok := f.ancients.(compressionInspector); ok {
+		return inspector.tableCompression(kind)
+	}
+	return "", 0, nil // compression unknown, e.g. in-memory freezer
+}
+
 func (f *chainFreezer) AncientRange(kind string, start, count, maxBytes uint64) ([][]byte, error) {
 	return f.ancients.AncientRange(kind, start, count, maxBytes)
 }
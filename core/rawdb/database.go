@@ -222,6 +222,14 @@ type OpenOptions struct {
 	Era              string // era files directory
 	MetricsNamespace string // prefix added to freezer metric names
 	ReadOnly         bool
+
+	// AncientRemote optionally moves the sealed chain freezer files into an
+	// object store, keeping only the head files locally.
+	AncientRemote *FreezerRemote
+
+	// AncientZstd creates the compressed chain freezer tables with zstd, and
+	// recompresses the existing snappy ones in the background.
+	AncientZstd bool
 }
 
 // Open creates a high-level database wrapper for the given key-value store.
@@ -233,7 +241,7 @@ func Open(db ethdb.KeyValueStore, opts OpenOptions) (ethdb.Database, error) {
 	if chainFreezerDir != "" {
 		chainFreezerDir = resolveChainFreezerDir(chainFreezerDir)
 	}
-	frdb, err := newChainFreezer(chainFreezerDir, opts.Era, opts.MetricsNamespace, opts.ReadOnly)
+	frdb, err := newChainFreezer(chainFreezerDir, opts.Era, opts.MetricsNamespace, opts.ReadOnly, opts.AncientRemote, opts.AncientZstd)
 	if err != nil {
 		printChainMetadata(db)
 		return nil, err
@@ -321,18 +329,30 @@ func Open(db ethdb.KeyValueStore, opts OpenOptions) (ethdb.Database, error) {
 			// freezer.
 		}
 	}
-	// Freezer is consistent with the key-value database, permit combining the two
+	// Freezer is consistent with the key-value database, permit combining the two.
+	// Track the writes per data category too, including the deletions done by
+	// the freezer.
+	kvdb := db
 	if !opts.ReadOnly {
+		kvdb = newStatsStore(db, opts.MetricsNamespace)
+
 		frdb.wg.Add(1)
 		go func() {
-			frdb.freeze(db)
+			frdb.freeze(kvdb)
 			frdb.wg.Done()
 		}()
+		if opts.AncientZstd {
+			frdb.wg.Add(1)
+			go func() {
+				frdb.recompact()
+				frdb.wg.Done()
+			}()
+		}
 	}
 	return &freezerdb{
 		readOnly:      opts.ReadOnly,
 		ancientRoot:   opts.Ancient,
-		KeyValueStore: db,
+		KeyValueStore: kvdb,
 		chainFreezer:  frdb,
 	}, nil
 }
@@ -405,40 +425,8 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 		count atomic.Int64
 		total atomic.Uint64
 
-		// Key-value store statistics
-		headers            stat
-		bodies             stat
-		receipts           stat
-		tds                stat
-		numHashPairings    stat
-		hashNumPairings    stat
-		legacyTries        stat
-		stateLookups       stat
-		accountTries       stat
-		storageTries       stat
-		codes              stat
-		txLookups          stat
-		accountSnaps       stat
-		storageSnaps       stat
-		preimages          stat
-		beaconHeaders      stat
-		cliqueSnaps        stat
-		bloomBits          stat
-		filterMapRows      stat
-		filterMapLastBlock stat
-		filterMapBlockLV   stat
-
-		// Path-mode archive data
-		stateIndex    stat
-		trienodeIndex stat
-
-		// Verkle statistics
-		verkleTries        stat
-		verkleStateLookups stat
-
-		// Meta- and unaccounted data
-		metadata    stat
-		unaccounted stat
+		// Key-value store statistics, per data category
+		categories [numCategories]stat
 
 		// This map tracks example keys for unaccounted data.
 		// For each unique two-byte prefix, the first unaccounted key encountered
@@ -470,109 +458,17 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 			total.Add(uint64(size))
 			count.Add(1)
 
-			switch {
-			case bytes.HasPrefix(key, headerPrefix) && len(key) == (len(headerPrefix)+8+common.HashLength):
-				headers.add(size)
-			case bytes.HasPrefix(key, blockBodyPrefix) && len(key) == (len(blockBodyPrefix)+8+common.HashLength):
-				bodies.add(size)
-			case bytes.HasPrefix(key, blockReceiptsPrefix) && len(key) == (len(blockReceiptsPrefix)+8+common.HashLength):
-				receipts.add(size)
-			case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerTDSuffix):
-				tds.add(size)
-			case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerHashSuffix):
-				numHashPairings.add(size)
-			case bytes.HasPrefix(key, headerNumberPrefix) && len(key) == (len(headerNumberPrefix)+common.HashLength):
-				hashNumPairings.add(size)
-			case IsLegacyTrieNode(key, it.Value()):
-				legacyTries.add(size)
-			case bytes.HasPrefix(key, stateIDPrefix) && len(key) == len(stateIDPrefix)+common.HashLength:
-				stateLookups.add(size)
-			case IsAccountTrieNode(key):
-				accountTries.add(size)
-			case IsStorageTrieNode(key):
-				storageTries.add(size)
-			case bytes.HasPrefix(key, CodePrefix) && len(key) == len(CodePrefix)+common.HashLength:
-				codes.add(size)
-			case bytes.HasPrefix(key, txLookupPrefix) && len(key) == (len(txLookupPrefix)+common.HashLength):
-				txLookups.add(size)
-			case bytes.HasPrefix(key, SnapshotAccountPrefix) && len(key) == (len(SnapshotAccountPrefix)+common.HashLength):
-				accountSnaps.add(size)
-			case bytes.HasPrefix(key, SnapshotStoragePrefix) && len(key) == (len(SnapshotStoragePrefix)+2*common.HashLength):
-				storageSnaps.add(size)
-			case bytes.HasPrefix(key, PreimagePrefix) && len(key) == (len(PreimagePrefix)+common.HashLength):
-				preimages.add(size)
-			case bytes.HasPrefix(key, configPrefix) && len(key) == (len(configPrefix)+common.HashLength):
-				metadata.add(size)
-			case bytes.HasPrefix(key, genesisPrefix) && len(key) == (len(genesisPrefix)+common.HashLength):
-				metadata.add(size)
-			case bytes.HasPrefix(key, skeletonHeaderPrefix) && len(key) == (len(skeletonHeaderPrefix)+8):
-				beaconHeaders.add(size)
-			case bytes.HasPrefix(key, CliqueSnapshotPrefix) && len(key) == 7+common.HashLength:
-				cliqueSnaps.add(size)
-
-			// new log index
-			case bytes.HasPrefix(key, filterMapRowPrefix) && len(key) <= len(filterMapRowPrefix)+9:
-				filterMapRows.add(size)
-			case bytes.HasPrefix(key, filterMapLastBlockPrefix) && len(key) == len(filterMapLastBlockPrefix)+4:
-				filterMapLastBlock.add(size)
-			case bytes.HasPrefix(key, filterMapBlockLVPrefix) && len(key) == len(filterMapBlockLVPrefix)+8:
-				filterMapBlockLV.add(size)
-
-			// old log index (deprecated)
-			case bytes.HasPrefix(key, bloomBitsPrefix) && len(key) == (len(bloomBitsPrefix)+10+common.HashLength):
-				bloomBits.add(size)
-			case bytes.HasPrefix(key, bloomBitsMetaPrefix) && len(key) < len(bloomBitsMetaPrefix)+8:
-				bloomBits.add(size)
-
-			// Path-based historic state indexes
-			case bytes.HasPrefix(key, StateHistoryAccountMetadataPrefix) && len(key) == len(StateHistoryAccountMetadataPrefix)+common.HashLength:
-				stateIndex.add(size)
-			case bytes.HasPrefix(key, StateHistoryStorageMetadataPrefix) && len(key) == len(StateHistoryStorageMetadataPrefix)+2*common.HashLength:
-				stateIndex.add(size)
-			case bytes.HasPrefix(key, StateHistoryAccountBlockPrefix) && len(key) == len(StateHistoryAccountBlockPrefix)+common.HashLength+4:
-				stateIndex.add(size)
-			case bytes.HasPrefix(key, StateHistoryStorageBlockPrefix) && len(key) == len(StateHistoryStorageBlockPrefix)+2*common.HashLength+4:
-				stateIndex.add(size)
-
-			case bytes.HasPrefix(key, TrienodeHistoryMetadataPrefix) && len(key) >= len(TrienodeHistoryMetadataPrefix)+common.HashLength:
-				trienodeIndex.add(size)
-			case bytes.HasPrefix(key, TrienodeHistoryBlockPrefix) && len(key) >= len(TrienodeHistoryBlockPrefix)+common.HashLength+4:
-				trienodeIndex.add(size)
-
-			// Verkle trie data is detected, determine the sub-category
-			case bytes.HasPrefix(key, VerklePrefix):
-				remain := key[len(VerklePrefix):]
-				switch {
-				case IsAccountTrieNode(remain):
-					verkleTries.add(size)
-				case bytes.HasPrefix(remain, stateIDPrefix) && len(remain) == len(stateIDPrefix)+common.HashLength:
-					verkleStateLookups.add(size)
-				case bytes.Equal(remain, persistentStateIDKey):
-					metadata.add(size)
-				case bytes.Equal(remain, trieJournalKey):
-					metadata.add(size)
-				case bytes.Equal(remain, snapSyncStatusFlagKey):
-					metadata.add(size)
-				default:
-					unaccounted.add(size)
-				}
+			category := classifyKey(key, it.Value())
+			categories[category].add(size)
 
-			// Metadata keys
-			case slices.ContainsFunc(knownMetadataKeys, func(x []byte) bool { return bytes.Equal(x, key) }):
-				metadata.add(size)
-
-			default:
-				unaccounted.add(size)
-				if len(key) >= 2 {
-					prefix := [2]byte(key[:2])
-					unaccountedMu.Lock()
-					if _, ok := unaccountedKeys[prefix]; !ok {
-						unaccountedKeys[prefix] = bytes.Clone(key)
-					}
-					unaccountedMu.Unlock()
+			if category == categoryUnaccounted && len(key) >= 2 {
+				prefix := [2]byte(key[:2])
+				unaccountedMu.Lock()
+				if _, ok := unaccountedKeys[prefix]; !ok {
+					unaccountedKeys[prefix] = bytes.Clone(key)
 				}
+				unaccountedMu.Unlock()
 			}
-
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
@@ -617,33 +513,9 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 	close(done)
 
 	// Display the database statistic of key-value store.
-	stats := [][]string{
-		{"Key-Value store", "Headers", headers.sizeString(), headers.countString()},
-		{"Key-Value store", "Bodies", bodies.sizeString(), bodies.countString()},
-		{"Key-Value store", "Receipt lists", receipts.sizeString(), receipts.countString()},
-		{"Key-Value store", "Difficulties (deprecated)", tds.sizeString(), tds.countString()},
-		{"Key-Value store", "Block number->hash", numHashPairings.sizeString(), numHashPairings.countString()},
-		{"Key-Value store", "Block hash->number", hashNumPairings.sizeString(), hashNumPairings.countString()},
-		{"Key-Value store", "Transaction index", txLookups.sizeString(), txLookups.countString()},
-		{"Key-Value store", "Log index filter-map rows", filterMapRows.sizeString(), filterMapRows.countString()},
-		{"Key-Value store", "Log index last-block-of-map", filterMapLastBlock.sizeString(), filterMapLastBlock.countString()},
-		{"Key-Value store", "Log index block-lv", filterMapBlockLV.sizeString(), filterMapBlockLV.countString()},
-		{"Key-Value store", "Log bloombits (deprecated)", bloomBits.sizeString(), bloomBits.countString()},
-		{"Key-Value store", "Contract codes", codes.sizeString(), codes.countString()},
-		{"Key-Value store", "Hash trie nodes", legacyTries.sizeString(), legacyTries.countString()},
-		{"Key-Value store", "Path trie state lookups", stateLookups.sizeString(), stateLookups.countString()},
-		{"Key-Value store", "Path trie account nodes", accountTries.sizeString(), accountTries.countString()},
-		{"Key-Value store", "Path trie storage nodes", storageTries.sizeString(), storageTries.countString()},
-		{"Key-Value store", "Verkle trie nodes", verkleTries.sizeString(), verkleTries.countString()},
-		{"Key-Value store", "Verkle trie state lookups", verkleStateLookups.sizeString(), verkleStateLookups.countString()},
-		{"Key-Value store", "Trie preimages", preimages.sizeString(), preimages.countString()},
-		{"Key-Value store", "Account snapshot", accountSnaps.sizeString(), accountSnaps.countString()},
-		{"Key-Value store", "Storage snapshot", storageSnaps.sizeString(), storageSnaps.countString()},
-		{"Key-Value store", "Historical state index", stateIndex.sizeString(), stateIndex.countString()},
-		{"Key-Value store", "Historical trie index", trienodeIndex.sizeString(), trienodeIndex.countString()},
-		{"Key-Value store", "Beacon sync headers", beaconHeaders.sizeString(), beaconHeaders.countString()},
-		{"Key-Value store", "Clique snapshots", cliqueSnaps.sizeString(), cliqueSnaps.countString()},
-		{"Key-Value store", "Singleton metadata", metadata.sizeString(), metadata.countString()},
+	var stats [][]string
+	for category := range categoryUnaccounted {
+		stats = append(stats, []string{"Key-Value store", categoryNames[category], categories[category].sizeString(), categories[category].countString()})
 	}
 
 	// Inspect all registered append-only file store then.
@@ -653,9 +525,13 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 	}
 	for _, ancient := range ancients {
 		for _, table := range ancient.sizes {
+			category := strings.Title(table.name)
+			if table.ratio > 0 && table.compression != "none" {
+				category = fmt.Sprintf("%s (%s %.2fx, saves %v)", category, table.compression, table.ratio, table.savings())
+			}
 			stats = append(stats, []string{
 				fmt.Sprintf("Ancient store (%s)", strings.Title(ancient.name)),
-				strings.Title(table.name),
+				category,
 				table.size.String(),
 				fmt.Sprintf("%d", ancient.count),
 			})
@@ -669,7 +545,7 @@ func InspectDatabase(db ethdb.Database, keyPrefix, keyStart []byte) error {
 	table.AppendBulk(stats)
 	table.Render()
 
-	if !unaccounted.empty() {
+	if unaccounted := &categories[categoryUnaccounted]; !unaccounted.empty() {
 		log.Error("Database contains unaccounted data", "size", unaccounted.sizeString(), "count", unaccounted.countString())
 		for _, e := range slices.SortedFunc(maps.Values(unaccountedKeys), bytes.Compare) {
 			log.Error(fmt.Sprintf("   example key: %x", e))
@@ -686,6 +562,7 @@ var knownMetadataKeys = [][]byte{
 	uncleanShutdownKey, badBlockKey, transitionStatusKey, skeletonSyncStatusKey,
 	persistentStateIDKey, trieJournalKey, snapshotSyncStatusKey, snapSyncStatusFlagKey,
 	filterMapsRangeKey, headStateHistoryIndexKey, headTrienodeHistoryIndexKey, VerkleTransitionStatePrefix,
+	stateSchemeMigrationKey, databaseStatsKey, trieRefRootsKey,
 }
 
 // printChainMetadata prints out chain metadata to stderr.
@@ -0,0 +1,630 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// dbCategory is a category of the data stored in the key-value store, as
+// reported by the database inspection.
+type dbCategory int
+
+const (
+	categoryHeaders dbCategory = iota
+	categoryBodies
+	categoryReceipts
+	categoryTDs
+	categoryNumHashPairings
+	categoryHashNumPairings
+	categoryTxLookups
+	categoryFilterMapRows
+	categoryFilterMapLastBlock
+	categoryFilterMapBlockLV
+	categoryBloomBits
+	categoryCodes
+	categoryLegacyTries
+	categoryTrieRefs
+	categoryStateLookups
+	categoryAccountTries
+	categoryStorageTries
+	categoryVerkleTries
+	categoryVerkleStateLookups
+	categoryPreimages
+	categoryAccountSnaps
+	categoryStorageSnaps
+	categoryStateIndex
+	categoryTrienodeIndex
+	categoryBeaconHeaders
+	categoryCliqueSnaps
+	categoryMetadata
+	categoryUnaccounted
+	numCategories
+)
+
+// categoryNames are the human readable names of the data categories.
+var categoryNames = [numCategories]string{
+	categoryHeaders:            "Headers",
+	categoryBodies:             "Bodies",
+	categoryReceipts:           "Receipt lists",
+	categoryTDs:                "Difficulties (deprecated)",
+	categoryNumHashPairings:    "Block number->hash",
+	categoryHashNumPairings:    "Block hash->number",
+	categoryTxLookups:          "Transaction index",
+	categoryFilterMapRows:      "Log index filter-map rows",
+	categoryFilterMapLastBlock: "Log index last-block-of-map",
+	categoryFilterMapBlockLV:   "Log index block-lv",
+	categoryBloomBits:          "Log bloombits (deprecated)",
+	categoryCodes:              "Contract codes",
+	categoryLegacyTries:        "Hash trie nodes",
+	categoryTrieRefs:           "Hash trie reference counts",
+	categoryStateLookups:       "Path trie state lookups",
+	categoryAccountTries:       "Path trie account nodes",
+	categoryStorageTries:       "Path trie storage nodes",
+	categoryVerkleTries:        "Verkle trie nodes",
+	categoryVerkleStateLookups: "Verkle trie state lookups",
+	categoryPreimages:          "Trie preimages",
+	categoryAccountSnaps:       "Account snapshot",
+	categoryStorageSnaps:       "Storage snapshot",
+	categoryStateIndex:         "Historical state index",
+	categoryTrienodeIndex:      "Historical trie index",
+	categoryBeaconHeaders:      "Beacon sync headers",
+	categoryCliqueSnaps:        "Clique snapshots",
+	categoryMetadata:           "Singleton metadata",
+	categoryUnaccounted:        "Unaccounted",
+}
+
+// categoryIDs are the identifiers of the data categories, used as metric names
+// and for persisting the statistics.
+var categoryIDs = [numCategories]string{
+	categoryHeaders:            "headers",
+	categoryBodies:             "bodies",
+	categoryReceipts:           "receipts",
+	categoryTDs:                "tds",
+	categoryNumHashPairings:    "numhash",
+	categoryHashNumPairings:    "hashnum",
+	categoryTxLookups:          "txlookups",
+	categoryFilterMapRows:      "filtermaprows",
+	categoryFilterMapLastBlock: "filtermaplastblock",
+	categoryFilterMapBlockLV:   "filtermapblocklv",
+	categoryBloomBits:          "bloombits",
+	categoryCodes:              "codes",
+	categoryLegacyTries:        "hashtries",
+	categoryTrieRefs:           "hashtrierefs",
+	categoryStateLookups:       "statelookups",
+	categoryAccountTries:       "accounttries",
+	categoryStorageTries:       "storagetries",
+	categoryVerkleTries:        "verkletries",
+	categoryVerkleStateLookups: "verklestatelookups",
+	categoryPreimages:          "preimages",
+	categoryAccountSnaps:       "accountsnaps",
+	categoryStorageSnaps:       "storagesnaps",
+	categoryStateIndex:         "stateindex",
+	categoryTrienodeIndex:      "trienodeindex",
+	categoryBeaconHeaders:      "beaconheaders",
+	categoryCliqueSnaps:        "cliquesnaps",
+	categoryMetadata:           "metadata",
+	categoryUnaccounted:        "unaccounted",
+}
+
+// classifyKey determines the category of a database entry. If the value is not
+// given, all the keys of hash length are assumed to be legacy trie nodes.
+func classifyKey(key []byte, value []byte) dbCategory {
+	switch {
+	case bytes.HasPrefix(key, headerPrefix) && len(key) == (len(headerPrefix)+8+common.HashLength):
+		return categoryHeaders
+	case bytes.HasPrefix(key, blockBodyPrefix) && len(key) == (len(blockBodyPrefix)+8+common.HashLength):
+		return categoryBodies
+	case bytes.HasPrefix(key, blockReceiptsPrefix) && len(key) == (len(blockReceiptsPrefix)+8+common.HashLength):
+		return categoryReceipts
+	case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerTDSuffix):
+		return categoryTDs
+	case bytes.HasPrefix(key, headerPrefix) && bytes.HasSuffix(key, headerHashSuffix):
+		return categoryNumHashPairings
+	case bytes.HasPrefix(key, headerNumberPrefix) && len(key) == (len(headerNumberPrefix)+common.HashLength):
+		return categoryHashNumPairings
+	case value == nil && len(key) == common.HashLength, value != nil && IsLegacyTrieNode(key, value):
+		return categoryLegacyTries
+	case bytes.HasPrefix(key, TrieNodeRefPrefix) && len(key) == len(TrieNodeRefPrefix)+common.HashLength:
+		return categoryTrieRefs
+	case bytes.HasPrefix(key, TrieNodeGarbagePrefix) && len(key) == len(TrieNodeGarbagePrefix)+common.HashLength:
+		return categoryTrieRefs
+	case bytes.HasPrefix(key, stateIDPrefix) && len(key) == len(stateIDPrefix)+common.HashLength:
+		return categoryStateLookups
+	case IsAccountTrieNode(key):
+		return categoryAccountTries
+	case IsStorageTrieNode(key):
+		return categoryStorageTries
+	case bytes.HasPrefix(key, CodePrefix) && len(key) == len(CodePrefix)+common.HashLength:
+		return categoryCodes
+	case bytes.HasPrefix(key, txLookupPrefix) && len(key) == (len(txLookupPrefix)+common.HashLength):
+		return categoryTxLookups
+	case bytes.HasPrefix(key, SnapshotAccountPrefix) && len(key) == (len(SnapshotAccountPrefix)+common.HashLength):
+		return categoryAccountSnaps
+	case bytes.HasPrefix(key, SnapshotStoragePrefix) && len(key) == (len(SnapshotStoragePrefix)+2*common.HashLength):
+		return categoryStorageSnaps
+	case bytes.HasPrefix(key, PreimagePrefix) && len(key) == (len(PreimagePrefix)+common.HashLength):
+		return categoryPreimages
+	case bytes.HasPrefix(key, configPrefix) && len(key) == (len(configPrefix)+common.HashLength):
+		return categoryMetadata
+	case bytes.HasPrefix(key, genesisPrefix) && len(key) == (len(genesisPrefix)+common.HashLength):
+		return categoryMetadata
+	case bytes.HasPrefix(key, skeletonHeaderPrefix) && len(key) == (len(skeletonHeaderPrefix)+8):
+		return categoryBeaconHeaders
+	case bytes.HasPrefix(key, CliqueSnapshotPrefix) && len(key) == 7+common.HashLength:
+		return categoryCliqueSnaps
+
+	// new log index
+	case bytes.HasPrefix(key, filterMapRowPrefix) && len(key) <= len(filterMapRowPrefix)+9:
+		return categoryFilterMapRows
+	case bytes.HasPrefix(key, filterMapLastBlockPrefix) && len(key) == len(filterMapLastBlockPrefix)+4:
+		return categoryFilterMapLastBlock
+	case bytes.HasPrefix(key, filterMapBlockLVPrefix) && len(key) == len(filterMapBlockLVPrefix)+8:
+		return categoryFilterMapBlockLV
+
+	// old log index (deprecated)
+	case bytes.HasPrefix(key, bloomBitsPrefix) && len(key) == (len(bloomBitsPrefix)+10+common.HashLength):
+		return categoryBloomBits
+	case bytes.HasPrefix(key, bloomBitsMetaPrefix) && len(key) < len(bloomBitsMetaPrefix)+8:
+		return categoryBloomBits
+
+	// Path-based historic state indexes
+	case bytes.HasPrefix(key, StateHistoryAccountMetadataPrefix) && len(key) == len(StateHistoryAccountMetadataPrefix)+common.HashLength:
+		return categoryStateIndex
+	case bytes.HasPrefix(key, StateHistoryStorageMetadataPrefix) && len(key) == len(StateHistoryStorageMetadataPrefix)+2*common.HashLength:
+		return categoryStateIndex
+	case bytes.HasPrefix(key, StateHistoryAccountBlockPrefix) && len(key) == len(StateHistoryAccountBlockPrefix)+common.HashLength+4:
+		return categoryStateIndex
+	case bytes.HasPrefix(key, StateHistoryStorageBlockPrefix) && len(key) == len(StateHistoryStorageBlockPrefix)+2*common.HashLength+4:
+		return categoryStateIndex
+
+	case bytes.HasPrefix(key, TrienodeHistoryMetadataPrefix) && len(key) >= len(TrienodeHistoryMetadataPrefix)+common.HashLength:
+		return categoryTrienodeIndex
+	case bytes.HasPrefix(key, TrienodeHistoryBlockPrefix) && len(key) >= len(TrienodeHistoryBlockPrefix)+common.HashLength+4:
+		return categoryTrienodeIndex
+
+	// Verkle trie data is detected, determine the sub-category
+	case bytes.HasPrefix(key, VerklePrefix):
+		remain := key[len(VerklePrefix):]
+		switch {
+		case IsAccountTrieNode(remain):
+			return categoryVerkleTries
+		case bytes.HasPrefix(remain, stateIDPrefix) && len(remain) == len(stateIDPrefix)+common.HashLength:
+			return categoryVerkleStateLookups
+		case bytes.Equal(remain, persistentStateIDKey):
+			return categoryMetadata
+		case bytes.Equal(remain, trieJournalKey):
+			return categoryMetadata
+		case bytes.Equal(remain, snapSyncStatusFlagKey):
+			return categoryMetadata
+		default:
+			return categoryUnaccounted
+		}
+
+	// Metadata keys
+	case isKnownMetadataKey(key):
+		return categoryMetadata
+
+	default:
+		return categoryUnaccounted
+	}
+}
+
+// knownMetadataKeySet is the set of known metadata keys, for a quick lookup in
+// the write path.
+var knownMetadataKeySet = func() map[string]struct{} {
+	set := make(map[string]struct{}, len(knownMetadataKeys))
+	for _, key := range knownMetadataKeys {
+		set[string(key)] = struct{}{}
+	}
+	return set
+}()
+
+// isKnownMetadataKey reports whether the key is one of the known metadata keys.
+func isKnownMetadataKey(key []byte) bool {
+	_, ok := knownMetadataKeySet[string(key)]
+	return ok
+}
+
+// statsPersistInterval is the minimum time between two persisted snapshots of
+// the database statistics.
+const statsPersistInterval = time.Minute
+
+// DatabaseStat contains the write and delete counters of a data category,
+// accumulated since the tracking started. These are cumulative traffic counters,
+// not the current size of the category. The sizes of the deleted entries are
+// unknown without reading them back, so deletions are only counted. Range
+// deletions are accounted to the category of their start key.
+type DatabaseStat struct {
+	Category     string `json:"category"`
+	Writes       uint64 `json:"writes"`
+	WrittenBytes uint64 `json:"writtenBytes"`
+	Deletes      uint64 `json:"deletes"`
+	RangeDeletes uint64 `json:"rangeDeletes"`
+}
+
+// DatabaseStats contains the write and delete counters of the key-value store.
+type DatabaseStats struct {
+	Since uint64          `json:"since"` // Unix time when the tracking started
+	Stats []*DatabaseStat `json:"stats"`
+}
+
+// categoryStat is the change of the write statistics of a single data category
+// made by a write.
+type categoryStat struct {
+	writes       uint64
+	writtenBytes uint64
+	deletes      uint64
+	rangeDeletes uint64
+}
+
+func (s *categoryStat) put(key []byte, value []byte) {
+	s.writes++
+	s.writtenBytes += uint64(len(key) + len(value))
+}
+
+func (s *categoryStat) delete() {
+	s.deletes++
+}
+
+func (s *categoryStat) deleteRange() {
+	s.rangeDeletes++
+}
+
+// categoryCounters are the live write statistics of a single data category,
+// updated without locking by concurrent writers.
+type categoryCounters struct {
+	writes       atomic.Uint64
+	writtenBytes atomic.Uint64
+	deletes      atomic.Uint64
+	rangeDeletes atomic.Uint64
+}
+
+// storedStat is the persisted form of the statistics of a data category.
+type storedStat struct {
+	ID           string
+	Writes       uint64
+	WrittenBytes uint64
+	Deletes      uint64
+	RangeDeletes uint64
+}
+
+// storedStats is the persisted form of the database statistics.
+type storedStats struct {
+	Since uint64
+	Stats []storedStat
+}
+
+// statsStore is a key-value store wrapper tracking the amount of data written
+// and deleted per data category. The statistics are persisted periodically into
+// the wrapped store, along with the tracked writes.
+//
+// The counters are updated atomically, batches are aggregated before being
+// applied, so writers don't contend on a lock.
+type statsStore struct {
+	ethdb.KeyValueStore
+
+	since       uint64
+	stats       [numCategories]categoryCounters
+	persisted   atomic.Int64 // Time of the last persisted snapshot in unix nanoseconds
+	persistLock sync.Mutex   // Lock serializing the persisting of the statistics
+
+	writeMeters  [numCategories]*metrics.Meter
+	deleteMeters [numCategories]*metrics.Meter
+}
+
+// newStatsStore wraps the key-value store, continuing the tracking from the
+// persisted statistics if there are any.
+func newStatsStore(db ethdb.KeyValueStore, namespace string) *statsStore {
+	s := &statsStore{KeyValueStore: db}
+	s.persisted.Store(time.Now().UnixNano())
+	for i := range numCategories {
+		s.writeMeters[i] = metrics.NewRegisteredMeter(namespace+"stats/"+categoryIDs[i]+"/write", nil)
+		s.deleteMeters[i] = metrics.NewRegisteredMeter(namespace+"stats/"+categoryIDs[i]+"/delete", nil)
+	}
+	stored := readStoredStats(db)
+	if stored == nil {
+		s.since = uint64(time.Now().Unix())
+		return s
+	}
+	s.since = stored.Since
+	for _, stat := range stored.Stats {
+		if i := slices.Index(categoryIDs[:], stat.ID); i >= 0 {
+			s.stats[i].writes.Store(stat.Writes)
+			s.stats[i].writtenBytes.Store(stat.WrittenBytes)
+			s.stats[i].deletes.Store(stat.Deletes)
+			s.stats[i].rangeDeletes.Store(stat.RangeDeletes)
+		}
+	}
+	return s
+}
+
+// Put inserts the given value into the key-value store.
+func (s *statsStore) Put(key []byte, value []byte) error {
+	if err := s.KeyValueStore.Put(key, value); err != nil {
+		return err
+	}
+	var delta categoryStat
+	delta.put(key, value)
+	s.add(classifyKey(key, nil), &delta)
+	s.maybePersist()
+	return nil
+}
+
+// Delete removes the key from the key-value store.
+func (s *statsStore) Delete(key []byte) error {
+	if err := s.KeyValueStore.Delete(key); err != nil {
+		return err
+	}
+	var delta categoryStat
+	delta.delete()
+	s.add(classifyKey(key, nil), &delta)
+	s.maybePersist()
+	return nil
+}
+
+// DeleteRange deletes all of the keys (and values) in the range [start,end).
+// The removal is accounted to the category of the start key, as the number of
+// entries deleted is unknown.
+func (s *statsStore) DeleteRange(start, end []byte) error {
+	if err := s.KeyValueStore.DeleteRange(start, end); err != nil {
+		return err
+	}
+	var delta categoryStat
+	delta.deleteRange()
+	s.add(classifyKey(start, nil), &delta)
+	s.maybePersist()
+	return nil
+}
+
+// NewBatch creates a write-only batch tracking the written data.
+func (s *statsStore) NewBatch() ethdb.Batch {
+	return &statsBatch{Batch: s.KeyValueStore.NewBatch(), store: s}
+}
+
+// NewBatchWithSize creates a write-only batch with a pre-allocated buffer,
+// tracking the written data.
+func (s *statsStore) NewBatchWithSize(size int) ethdb.Batch {
+	return &statsBatch{Batch: s.KeyValueStore.NewBatchWithSize(size), store: s}
+}
+
+// Close persists the statistics and closes the wrapped store.
+func (s *statsStore) Close() error {
+	s.persistLock.Lock()
+	s.persist()
+	s.persistLock.Unlock()
+
+	return s.KeyValueStore.Close()
+}
+
+// add adds the changes made by a write to the statistics of a data category.
+func (s *statsStore) add(category dbCategory, delta *categoryStat) {
+	stat := &s.stats[category]
+	if delta.writes > 0 {
+		stat.writes.Add(delta.writes)
+		stat.writtenBytes.Add(delta.writtenBytes)
+		s.writeMeters[category].Mark(int64(delta.writtenBytes))
+	}
+	if delta.deletes > 0 || delta.rangeDeletes > 0 {
+		stat.deletes.Add(delta.deletes)
+		stat.rangeDeletes.Add(delta.rangeDeletes)
+		s.deleteMeters[category].Mark(int64(delta.deletes + delta.rangeDeletes))
+	}
+}
+
+// apply adds the changes made by a batch to the statistics, persisting them if
+// enough time has passed since the last time.
+func (s *statsStore) apply(delta *[numCategories]categoryStat) {
+	for i := range delta {
+		s.add(dbCategory(i), &delta[i])
+	}
+	s.maybePersist()
+}
+
+// maybePersist persists the statistics if enough time has passed since the last
+// time. It never blocks the caller on another writer persisting them meanwhile.
+func (s *statsStore) maybePersist() {
+	if time.Since(time.Unix(0, s.persisted.Load())) < statsPersistInterval {
+		return
+	}
+	if !s.persistLock.TryLock() {
+		return
+	}
+	defer s.persistLock.Unlock()
+
+	if time.Since(time.Unix(0, s.persisted.Load())) >= statsPersistInterval {
+		s.persist()
+	}
+}
+
+// persist writes the statistics into the wrapped store. It assumes the persist
+// lock is held by the caller.
+func (s *statsStore) persist() {
+	stored := storedStats{Since: s.since}
+	for i := range s.stats {
+		stat := &s.stats[i]
+		stored.Stats = append(stored.Stats, storedStat{
+			ID:           categoryIDs[i],
+			Writes:       stat.writes.Load(),
+			WrittenBytes: stat.writtenBytes.Load(),
+			Deletes:      stat.deletes.Load(),
+			RangeDeletes: stat.rangeDeletes.Load(),
+		})
+	}
+	blob, err := rlp.EncodeToBytes(&stored)
+	if err != nil {
+		log.Crit("Failed to encode database statistics", "err", err)
+	}
+	if err := s.KeyValueStore.Put(databaseStatsKey, blob); err != nil {
+		log.Warn("Failed to store database statistics", "err", err)
+		return
+	}
+	s.persisted.Store(time.Now().UnixNano())
+}
+
+// snapshot returns a copy of the current statistics.
+func (s *statsStore) snapshot() *DatabaseStats {
+	stats := &DatabaseStats{Since: s.since}
+	for i := range s.stats {
+		stat := &s.stats[i]
+		stats.Stats = append(stats.Stats, &DatabaseStat{
+			Category:     categoryNames[i],
+			Writes:       stat.writes.Load(),
+			WrittenBytes: stat.writtenBytes.Load(),
+			Deletes:      stat.deletes.Load(),
+			RangeDeletes: stat.rangeDeletes.Load(),
+		})
+	}
+	return stats
+}
+
+// statsBatch is a batch wrapper collecting the changes made to the statistics,
+// applying them once the batch is written.
+type statsBatch struct {
+	ethdb.Batch
+	store *statsStore
+	delta [numCategories]categoryStat
+}
+
+// Put inserts the given value into the batch.
+func (b *statsBatch) Put(key []byte, value []byte) error {
+	if err := b.Batch.Put(key, value); err != nil {
+		return err
+	}
+	b.delta[classifyKey(key, nil)].put(key, value)
+	return nil
+}
+
+// Delete inserts a key removal into the batch.
+func (b *statsBatch) Delete(key []byte) error {
+	if err := b.Batch.Delete(key); err != nil {
+		return err
+	}
+	b.delta[classifyKey(key, nil)].delete()
+	return nil
+}
+
+// DeleteRange inserts a range removal into the batch.
+func (b *statsBatch) DeleteRange(start, end []byte) error {
+	if err := b.Batch.DeleteRange(start, end); err != nil {
+		return err
+	}
+	b.delta[classifyKey(start, nil)].deleteRange()
+	return nil
+}
+
+// Write flushes the batch into the store and applies the tracked changes.
+func (b *statsBatch) Write() error {
+	if err := b.Batch.Write(); err != nil {
+		return err
+	}
+	b.store.apply(&b.delta)
+	return nil
+}
+
+// Reset resets the batch for reuse.
+func (b *statsBatch) Reset() {
+	b.Batch.Reset()
+	b.delta = [numCategories]categoryStat{}
+}
+
+// readStoredStats retrieves the persisted database statistics.
+func readStoredStats(db ethdb.KeyValueReader) *storedStats {
+	blob, err := db.Get(databaseStatsKey)
+	if err != nil || len(blob) == 0 {
+		return nil
+	}
+	var stored storedStats
+	if err := rlp.DecodeBytes(blob, &stored); err != nil {
+		log.Warn("Failed to decode database statistics", "err", err)
+		return nil
+	}
+	return &stored
+}
+
+// ReadDatabaseStats retrieves the write and delete counters of the key-value store. If
+// the database is tracking them, the live values are returned, otherwise the
+// last persisted ones. Nil is returned if no statistics are available.
+func ReadDatabaseStats(db ethdb.Database) *DatabaseStats {
+	if frdb, ok := db.(*freezerdb); ok {
+		if s, ok := frdb.KeyValueStore.(*statsStore); ok {
+			return s.snapshot()
+		}
+	}
+	stored := readStoredStats(db)
+	if stored == nil {
+		return nil
+	}
+	stats := &DatabaseStats{Since: stored.Since}
+	for i := range numCategories {
+		stat := &DatabaseStat{Category: categoryNames[i]}
+		for _, s := range stored.Stats {
+			if s.ID == categoryIDs[i] {
+				stat.Writes, stat.WrittenBytes = s.Writes, s.WrittenBytes
+				stat.Deletes, stat.RangeDeletes = s.Deletes, s.RangeDeletes
+			}
+		}
+		stats.Stats = append(stats.Stats, stat)
+	}
+	return stats
+}
+
+// PrintDatabaseStats prints the write and delete counters tracked for the
+// key-value store. The counters are cumulative since the tracking started, they
+// don't tell the current size of the data categories.
+func PrintDatabaseStats(db ethdb.Database) error {
+	stats := ReadDatabaseStats(db)
+	if stats == nil {
+		return errors.New("no database statistics tracked yet")
+	}
+	var (
+		rows                          [][]string
+		written                       common.StorageSize
+		writes, deletes, rangeDeletes uint64
+	)
+	for _, stat := range stats.Stats {
+		rows = append(rows, []string{
+			stat.Category,
+			common.StorageSize(stat.WrittenBytes).String(), fmt.Sprintf("%d", stat.Writes),
+			fmt.Sprintf("%d", stat.Deletes), fmt.Sprintf("%d", stat.RangeDeletes),
+		})
+		written += common.StorageSize(stat.WrittenBytes)
+		writes += stat.Writes
+		deletes += stat.Deletes
+		rangeDeletes += stat.RangeDeletes
+	}
+	fmt.Printf("Key-value store write and delete counters since %v\n", time.Unix(int64(stats.Since), 0))
+	table := NewTableWriter(os.Stdout)
+	table.SetHeader([]string{"Category", "Bytes written", "Writes", "Deletes", "Range deletes"})
+	table.SetFooter([]string{"Total", written.String(), fmt.Sprintf("%d", writes), fmt.Sprintf("%d", deletes), fmt.Sprintf("%d", rangeDeletes)})
+	table.AppendBulk(rows)
+	table.Render()
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatabaseStats(t *testing.T) {
+	kvdb := memorydb.New()
+	db, err := Open(kvdb, OpenOptions{})
+	require.NoError(t, err)
+	defer db.Close()
+
+	var (
+		hash  = common.Hash{0x1}
+		code  = []byte{0x60, 0x00}
+		other = []byte("random-key")
+	)
+	// Write entries both directly and through batches
+	WriteCode(db, hash, code)
+	batch := db.NewBatch()
+	WriteCanonicalHash(batch, hash, 1)
+	WriteCanonicalHash(batch, hash, 2)
+	DeleteCanonicalHash(batch, 1)
+	batch.Put(other, []byte{1})
+	batch.DeleteRange(headerHashKey(3), headerHashKey(5))
+	require.NoError(t, batch.Write())
+
+	// Discarded batches are not accounted for
+	batch.Reset()
+	WriteCanonicalHash(batch, hash, 3)
+	batch.Reset()
+	require.NoError(t, batch.Write())
+
+	require.NoError(t, db.Delete(codeKey(hash)))
+	require.NoError(t, db.DeleteRange(codeKey(common.Hash{0x3}), codeKey(common.Hash{0x4})))
+	require.NoError(t, db.Put(headHeaderKey, hash[:]))
+
+	check := func(stats *DatabaseStats) {
+		t.Helper()
+		require.NotNil(t, stats)
+		require.Len(t, stats.Stats, int(numCategories))
+
+		codes := stats.Stats[categoryCodes]
+		require.Equal(t, "Contract codes", codes.Category)
+		require.Equal(t, uint64(1), codes.Writes)
+		require.Equal(t, uint64(len(codeKey(hash))+len(code)), codes.WrittenBytes)
+		require.Equal(t, uint64(1), codes.Deletes)
+		require.Equal(t, uint64(1), codes.RangeDeletes)
+
+		pairings := stats.Stats[categoryNumHashPairings]
+		require.Equal(t, uint64(2), pairings.Writes)
+		require.Equal(t, uint64(2*(len(headerHashKey(1))+common.HashLength)), pairings.WrittenBytes)
+		require.Equal(t, uint64(1), pairings.Deletes)
+		require.Equal(t, uint64(1), pairings.RangeDeletes)
+
+		metadata := stats.Stats[categoryMetadata]
+		require.Equal(t, uint64(1), metadata.Writes)
+
+		unaccounted := stats.Stats[categoryUnaccounted]
+		require.Equal(t, uint64(1), unaccounted.Writes)
+		require.Equal(t, uint64(len(other)+1), unaccounted.WrittenBytes)
+	}
+	check(ReadDatabaseStats(db))
+
+	// Persist the statistics and ensure they're retrievable without tracking
+	store := db.(*freezerdb).KeyValueStore.(*statsStore)
+	store.persistLock.Lock()
+	store.persist()
+	store.persistLock.Unlock()
+
+	since := ReadDatabaseStats(db).Since
+	stats := ReadDatabaseStats(NewDatabase(kvdb))
+	check(stats)
+	require.Equal(t, since, stats.Since)
+
+	// Resume the tracking from the persisted statistics
+	resumed := newStatsStore(kvdb, "")
+	require.NoError(t, resumed.Put(codeKey(common.Hash{0x2}), code))
+	require.Equal(t, uint64(2), resumed.snapshot().Stats[categoryCodes].Writes)
+	require.Equal(t, since, resumed.snapshot().Since)
+}
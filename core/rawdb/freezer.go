@@ -25,6 +25,7 @@ import (
 	"sync"
 	"sync/atomic"
 
+	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
@@ -71,6 +72,10 @@ type Freezer struct {
 	tables       map[string]*freezerTable // Data tables for storing everything
 	instanceLock *flock.Flock             // File-system lock to prevent double opens
 	closeOnce    sync.Once
+
+	remote *FreezerRemote // Object store for the sealed data files, nil if not used
+	quit   chan struct{}
+	wg     sync.WaitGroup
 }
 
 // NewFreezer creates a freezer instance for maintaining immutable ordered
@@ -80,6 +85,13 @@ type Freezer struct {
 // Each value is a freezerTableConfig specifying whether snappy compression is
 // disabled (noSnappy) and whether the table is prunable (prunable).
 func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]freezerTableConfig) (*Freezer, error) {
+	return NewFreezerWithRemote(datadir, namespace, readonly, maxTableSize, tables, nil)
+}
+
+// NewFreezerWithRemote creates a freezer instance which moves the sealed data
+// files of its tables into the given object store, keeping only the head files
+// locally. The remote configuration is optional.
+func NewFreezerWithRemote(datadir string, namespace string, readonly bool, maxTableSize uint32, tables map[string]freezerTableConfig, remote *FreezerRemote) (*Freezer, error) {
 	// Create the initial freezer object
 	var (
 		readMeter  = metrics.NewRegisteredMeter(namespace+"ancient/read", nil)
@@ -119,11 +131,31 @@ func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize ui
 		readonly:     readonly,
 		tables:       make(map[string]*freezerTable),
 		instanceLock: lock,
+		remote:       remote,
+		quit:         make(chan struct{}),
+	}
+	// Set up the read cache shared by the tables if an object store is used
+	var cache *lru.SizeConstrainedCache[remoteBlockKey, []byte]
+	if remote != nil {
+		cache = lru.NewSizeConstrainedCache[remoteBlockKey, []byte](uint64(max(remote.CacheSize, remoteBlockSize)))
 	}
-
 	// Create the tables.
 	for name, config := range tables {
-		table, err := newTable(datadir, name, readMeter, writeMeter, sizeGauge, maxTableSize, config, readonly)
+		var (
+			rt  *remoteTable
+			err error
+		)
+		if remote != nil {
+			rt, err = openRemoteTable(remote, cache, datadir, name)
+			if err != nil {
+				for _, table := range freezer.tables {
+					table.Close()
+				}
+				lock.Unlock()
+				return nil, err
+			}
+		}
+		table, err := newTableWithRemote(datadir, name, readMeter, writeMeter, sizeGauge, maxTableSize, config, readonly, rt)
 		if err != nil {
 			for _, table := range freezer.tables {
 				table.Close()
@@ -153,7 +185,12 @@ func NewFreezer(datadir string, namespace string, readonly bool, maxTableSize ui
 	// Create the write batch.
 	freezer.writeBatch = newFreezerBatch(freezer)
 
-	log.Info("Opened ancient database", "database", datadir, "readonly", readonly)
+	// Start moving the sealed data files into the object store
+	if remote != nil && !readonly {
+		freezer.wg.Add(1)
+		go freezer.offloadLoop()
+	}
+	log.Info("Opened ancient database", "database", datadir, "readonly", readonly, "remote", remote != nil)
 	return freezer, nil
 }
 
@@ -164,6 +201,9 @@ func (f *Freezer) Close() error {
 
 	var errs []error
 	f.closeOnce.Do(func() {
+		close(f.quit)
+		f.wg.Wait()
+
 		for _, table := range f.tables {
 			if err := table.Close(); err != nil {
 				errs = append(errs, err)
@@ -234,6 +274,20 @@ func (f *Freezer) AncientSize(kind string) (uint64, error) {
 	return 0, errUnknownTable
 }
 
+// tableCompression returns the compression of the given table, along with the
+// estimated ratio between the uncompressed and the stored size of the items.
+func (f *Freezer) tableCompression(kind string) (string, float64, error) {
+	table := f.tables[kind]
+	if table == nil {
+		return "", 0, errUnknownTable
+	}
+	ratio, err := table.compressionRatio(compressionSamples)
+	if err != nil {
+		return "", 0, err
+	}
+	return table.compressionName(), ratio, nil
+}
+
 // ReadAncients runs the given read operation while ensuring that no writes take place
 // on the underlying freezer.
 func (f *Freezer) ReadAncients(fn func(ethdb.AncientReaderOp) error) (err error) {
@@ -0,0 +1,144 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// Checkpoint creates a consistent copy of the freezer in the given directory,
+// which must not exist yet. The sealed data files are hard-linked, while the
+// head data files, the index and the metadata files are copied. The writes to
+// the freezer are blocked meanwhile.
+func (f *Freezer) Checkpoint(dir string) error {
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+
+	if common.FileExist(dir) {
+		return fmt.Errorf("checkpoint directory %s already exists", dir)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	for _, table := range f.tables {
+		if err := table.checkpoint(dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkpoint copies the files of the table into the given directory, linking
+// the sealed data files.
+func (t *freezerTable) checkpoint(dir string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.remote != nil {
+		return fmt.Errorf("table %s is kept in an object store", t.name)
+	}
+	// Flush the table first, so that the index doesn't reference any
+	// data not yet on disk.
+	if err := t.doSync(); err != nil {
+		return err
+	}
+	idxExt, _ := t.fileExtensions()
+	files := []string{
+		fmt.Sprintf("%s.meta", t.name),
+		fmt.Sprintf("%s.%s", t.name, idxExt),
+	}
+	if t.compression == compressionZstd && t.metadata.dictID != 0 {
+		files = append(files, fmt.Sprintf("%s.zdict", t.name))
+	}
+	files = append(files, filepath.Base(t.dataFilePath(t.headId)))
+	for _, file := range files {
+		if err := copyFrom(filepath.Join(t.path, file), filepath.Join(dir, file), 0, nil); err != nil {
+			return err
+		}
+	}
+	// The sealed data files are never modified in place, with the exception of
+	// the head truncations which detach the files first.
+	for num := t.tailId; num < t.headId; num++ {
+		file := t.dataFilePath(num)
+		dst := filepath.Join(dir, filepath.Base(file))
+		if err := os.Link(file, dst); err != nil {
+			// Hard links are not possible across devices, fall back to copying
+			if err := copyFrom(file, dst, 0, nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// detachFile replaces a data file with a private copy before it's modified in
+// place, so that the hard links created by checkpoints are left untouched. It
+// assumes that the write-lock is held by the caller.
+func (t *freezerTable) detachFile(num uint32) error {
+	t.releaseFile(num)
+
+	file := t.dataFilePath(num)
+	return copyFrom(file, file, 0, nil)
+}
+
+// Checkpoint creates a consistent copy of the freezer in the given directory.
+func (f *resettableFreezer) Checkpoint(dir string) error {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.freezer.Checkpoint(dir)
+}
+
+// Checkpoint creates a consistent copy of the chain freezer in the given
+// directory. It's not supported by the in-memory freezer.
+func (f *chainFreezer) Checkpoint(dir string) error {
+	cp, ok := f.ancients.(ethdb.Checkpointer)
+	if !ok {
+		return errors.New("ancient store does not support checkpoints")
+	}
+	return cp.Checkpoint(dir)
+}
+
+// Checkpoint creates a consistent copy of the given database in the directory,
+// laid out as a chaindata directory with the chain freezer at the default
+// location. The key-value store is copied first, as the items moved into the
+// freezer meanwhile are kept in both, while the other way around they would
+// be lost.
+func Checkpoint(db ethdb.Database, dir string) error {
+	frdb, ok := db.(*freezerdb)
+	if !ok {
+		return errors.New("database does not support checkpoints")
+	}
+	store := frdb.KeyValueStore
+	if s, ok := store.(*statsStore); ok {
+		store = s.KeyValueStore
+	}
+	kvdb, ok := store.(ethdb.Checkpointer)
+	if !ok {
+		return errors.New("key-value store does not support checkpoints")
+	}
+	if err := kvdb.Checkpoint(dir); err != nil {
+		return err
+	}
+	return frdb.chainFreezer.Checkpoint(filepath.Join(dir, "ancient", ChainFreezerName))
+}
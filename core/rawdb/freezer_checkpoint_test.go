@@ -0,0 +1,95 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreezerCheckpoint(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir    = t.TempDir()
+		cpdir  = filepath.Join(t.TempDir(), "checkpoint")
+		tables = map[string]freezerTableConfig{"a": {noSnappy: true, prunable: true}, "b": {noSnappy: false, prunable: true}}
+	)
+	item := func(i uint64, version byte) []byte {
+		return bytes.Repeat([]byte{byte(i), version}, 50)
+	}
+	write := func(f *Freezer, from, to uint64, version byte) {
+		t.Helper()
+		_, err := f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+			for i := from; i < to; i++ {
+				if err := op.AppendRaw("a", i, item(i, version)); err != nil {
+					return err
+				}
+				if err := op.AppendRaw("b", i, item(i, version)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	check := func(f *Freezer, from, to uint64, version byte) {
+		t.Helper()
+		for i := from; i < to; i++ {
+			for _, kind := range []string{"a", "b"} {
+				blob, err := f.Ancient(kind, i)
+				require.NoError(t, err)
+				require.Equal(t, item(i, version), blob, "table %s item %d", kind, i)
+			}
+		}
+	}
+	// 10 items of 100 bytes per data file
+	f, err := NewFreezer(dir, "", false, 1000, tables)
+	require.NoError(t, err)
+	defer f.Close()
+
+	write(f, 0, 95, 0)
+	_, err = f.TruncateTail(15)
+	require.NoError(t, err)
+
+	require.NoError(t, f.Checkpoint(cpdir))
+	require.Error(t, f.Checkpoint(cpdir), "existing directory overwritten")
+
+	// Rewrite the items of a sealed file and extend the original freezer, the
+	// checkpoint should not be affected.
+	_, err = f.TruncateHead(45)
+	require.NoError(t, err)
+	write(f, 45, 120, 1)
+	check(f, 15, 45, 0)
+	check(f, 45, 120, 1)
+
+	cp, err := NewFreezer(cpdir, "", true, 1000, tables)
+	require.NoError(t, err)
+	defer cp.Close()
+
+	tail, err := cp.Tail()
+	require.NoError(t, err)
+	require.Equal(t, uint64(15), tail)
+	head, err := cp.Ancients()
+	require.NoError(t, err)
+	require.Equal(t, uint64(95), head)
+	check(cp, 15, 95, 0)
+}
@@ -0,0 +1,233 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/dict"
+	"github.com/klauspost/compress/zstd"
+)
+
+// freezerCompression is the compression mode of a freezer table, recorded in
+// the table metadata.
+type freezerCompression uint8
+
+const (
+	// compressionDefault compresses the items with snappy, or leaves them
+	// uncompressed if compression is disabled in the table configuration.
+	compressionDefault freezerCompression = 0
+
+	// compressionZstd compresses the items with zstd, optionally using a
+	// dictionary trained on the table content.
+	compressionZstd freezerCompression = 1
+)
+
+// String implements fmt.Stringer.
+func (c freezerCompression) String() string {
+	switch c {
+	case compressionDefault:
+		return "default"
+	case compressionZstd:
+		return "zstd"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(c))
+	}
+}
+
+const (
+	// zstdDictSize is the maximum size of the dictionaries trained for the
+	// freezer tables.
+	zstdDictSize = 64 * 1024
+
+	// zstdDictSamples is the number of items sampled from a table for training
+	// its dictionary.
+	zstdDictSamples = 4096
+)
+
+// freezerCodec compresses and decompresses the items of a freezer table.
+type freezerCodec interface {
+	// encode compresses the data, the returned slice might reuse the capacity
+	// of dst or alias the data.
+	encode(dst []byte, data []byte) []byte
+
+	// decode decompresses the item.
+	decode(item []byte) ([]byte, error)
+
+	// decodedLen returns the decompressed size of the item.
+	decodedLen(item []byte) (int, error)
+}
+
+// rawCodec is the codec of the tables with compression disabled.
+type rawCodec struct{}
+
+func (rawCodec) encode(dst []byte, data []byte) []byte { return data }
+func (rawCodec) decode(item []byte) ([]byte, error)    { return item, nil }
+func (rawCodec) decodedLen(item []byte) (int, error)   { return len(item), nil }
+
+// snappyCodec is the codec of the tables compressed with snappy in block format.
+type snappyCodec struct{}
+
+func (snappyCodec) encode(dst []byte, data []byte) []byte {
+	// The snappy library does not care what the capacity of the buffer is,
+	// but only checks the length. If the length is too small, it will
+	// allocate a brand new buffer.
+	// To avoid that, we check the required size here, and grow the size of the
+	// buffer to utilize the full capacity.
+	if n := snappy.MaxEncodedLen(len(data)); len(dst) < n {
+		if cap(dst) < n {
+			dst = make([]byte, n)
+		}
+		dst = dst[:n]
+	}
+	return snappy.Encode(dst, data)
+}
+
+func (snappyCodec) decode(item []byte) ([]byte, error)  { return snappy.Decode(nil, item) }
+func (snappyCodec) decodedLen(item []byte) (int, error) { return snappy.DecodedLen(item) }
+
+// zstdCodec compresses and decompresses the items of a zstd freezer table. It
+// is safe for concurrent use, also with closing it: items are decompressed
+// outside of the table lock, so reads might still be in flight when the table
+// is closed.
+type zstdCodec struct {
+	dictID uint32 // ID of the dictionary in use, 0 if none
+	enc    *zstd.Encoder
+	dec    *zstd.Decoder
+
+	lock   sync.RWMutex // Guards the decoder against being closed during use
+	closed bool
+}
+
+// newZstdCodec creates a zstd codec using the given dictionary, which is
+// optional.
+func newZstdCodec(dictionary []byte) (*zstdCodec, error) {
+	var (
+		encOpts = []zstd.EOption{zstd.WithEncoderConcurrency(1), zstd.WithEncoderCRC(false)}
+		decOpts = []zstd.DOption{zstd.WithDecoderConcurrency(0)}
+		dictID  uint32
+	)
+	if len(dictionary) > 0 {
+		d, err := zstd.InspectDictionary(dictionary)
+		if err != nil {
+			return nil, err
+		}
+		dictID = d.ID()
+		encOpts = append(encOpts, zstd.WithEncoderDict(dictionary))
+		decOpts = append(decOpts, zstd.WithDecoderDicts(dictionary))
+	}
+	enc, err := zstd.NewWriter(nil, encOpts...)
+	if err != nil {
+		return nil, err
+	}
+	dec, err := zstd.NewReader(nil, decOpts...)
+	if err != nil {
+		enc.Close()
+		return nil, err
+	}
+	return &zstdCodec{dictID: dictID, enc: enc, dec: dec}, nil
+}
+
+// encode compresses the data into dst, reusing its capacity.
+func (c *zstdCodec) encode(dst []byte, data []byte) []byte {
+	return c.enc.EncodeAll(data, dst[:0])
+}
+
+// decode decompresses the item.
+func (c *zstdCodec) decode(item []byte) ([]byte, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	if c.closed {
+		return nil, errClosed
+	}
+	return c.dec.DecodeAll(item, nil)
+}
+
+// decodedLen returns the decompressed size of the item.
+func (c *zstdCodec) decodedLen(item []byte) (int, error) {
+	if len(item) == 0 {
+		return 0, nil
+	}
+	var header zstd.Header
+	if err := header.Decode(item); err != nil {
+		return 0, err
+	}
+	if !header.HasFCS {
+		data, err := c.decode(item)
+		return len(data), err
+	}
+	return int(header.FrameContentSize), nil
+}
+
+// close releases the resources held by the codec.
+func (c *zstdCodec) close() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.closed = true
+	c.enc.Close()
+	c.dec.Close()
+}
+
+// trainZstdDict trains a zstd dictionary on the given samples of table items.
+func trainZstdDict(samples [][]byte) (dictionary []byte, err error) {
+	// The dictionary builder panics on some degenerate inputs (e.g. highly
+	// repetitive samples), treat those as a failed training.
+	defer func() {
+		if r := recover(); r != nil {
+			dictionary, err = nil, fmt.Errorf("dictionary training failed: %v", r)
+		}
+	}()
+	var size int
+	for _, sample := range samples {
+		size += len(sample)
+	}
+	// Training on too little data yields a useless dictionary, if any at all
+	if size < 8*zstdDictSize {
+		return nil, errors.New("not enough data for training")
+	}
+	return dict.BuildZstdDict(samples, dict.Options{
+		MaxDictSize: zstdDictSize,
+		HashBytes:   6,
+		ZstdLevel:   zstd.SpeedDefault, // match the level of the table encoders
+	})
+}
+
+// loadZstdDict reads the dictionary of a zstd table from the given file and
+// checks it against the ID recorded in the table metadata.
+func loadZstdDict(path string, id uint32) ([]byte, error) {
+	if id == 0 {
+		return nil, nil
+	}
+	blob, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	d, err := zstd.InspectDictionary(blob)
+	if err != nil {
+		return nil, fmt.Errorf("invalid zstd dictionary %s: %v", path, err)
+	}
+	if d.ID() != id {
+		return nil, fmt.Errorf("zstd dictionary mismatch: have %d, want %d", d.ID(), id)
+	}
+	return blob, nil
+}
@@ -29,7 +29,8 @@ import (
 const (
 	freezerTableV1 = 1              // Initial version of metadata struct
 	freezerTableV2 = 2              // Add field: 'flushOffset'
-	freezerVersion = freezerTableV2 // The current used version
+	freezerTableV3 = 3              // Add fields: 'compression', 'dictID'
+	freezerVersion = freezerTableV2 // The current used version of tables with default compression
 )
 
 // freezerTableMeta is a collection of additional properties that describe the
@@ -65,6 +66,15 @@ type freezerTableMeta struct {
 	// The offset could be moved forward by applying sync operation, or be moved
 	// backward in cases of head/tail truncation, etc.
 	flushOffset int64
+
+	// compression is the compression mode of the table items. Tables with the
+	// default compression keep using the v2 format, so that they remain readable
+	// by the older releases. Others are written in the v3 format, which the older
+	// releases refuse to open instead of misinterpreting the data.
+	compression freezerCompression
+
+	// dictID is the ID of the zstd dictionary used by the table, 0 if none.
+	dictID uint32
 }
 
 // decodeV1 attempts to decode the metadata structure in v1 format. If fails or
@@ -123,6 +133,45 @@ func decodeV2(file *os.File) *freezerTableMeta {
 	}
 }
 
+// decodeV3 attempts to decode the metadata structure in v3 format. If fails or
+// the result is incompatible, nil is returned.
+func decodeV3(file *os.File) *freezerTableMeta {
+	_, err := file.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil
+	}
+	type obj struct {
+		Version     uint16
+		Tail        uint64
+		Offset      uint64
+		Compression uint8
+		DictID      uint32
+	}
+	var o obj
+	if err := rlp.Decode(file, &o); err != nil {
+		return nil
+	}
+	if o.Version != freezerTableV3 {
+		return nil
+	}
+	if o.Offset > math.MaxInt64 {
+		log.Error("Invalid flushOffset %d in freezer metadata", o.Offset, "file", file.Name())
+		return nil
+	}
+	if freezerCompression(o.Compression) != compressionZstd {
+		log.Error("Unknown compression in freezer metadata", "compression", o.Compression, "file", file.Name())
+		return nil
+	}
+	return &freezerTableMeta{
+		file:        file,
+		version:     freezerTableV3,
+		virtualTail: o.Tail,
+		flushOffset: int64(o.Offset),
+		compression: freezerCompression(o.Compression),
+		dictID:      o.DictID,
+	}
+}
+
 // newMetadata initializes the metadata object, either by loading it from the file
 // or by constructing a new one from scratch.
 func newMetadata(file *os.File) (*freezerTableMeta, error) {
@@ -142,6 +191,9 @@ func newMetadata(file *os.File) (*freezerTableMeta, error) {
 		}
 		return m, nil
 	}
+	if m := decodeV3(file); m != nil {
+		return m, nil
+	}
 	if m := decodeV2(file); m != nil {
 		return m, nil
 	}
@@ -165,21 +217,11 @@ func (m *freezerTableMeta) setFlushOffset(offset int64, sync bool) error {
 
 // write flushes the content of metadata into file and performs a fsync if required.
 func (m *freezerTableMeta) write(sync bool) error {
-	type obj struct {
-		Version uint16
-		Tail    uint64
-		Offset  uint64
-	}
-	var o obj
-	o.Version = freezerVersion // forcibly use the current version
-	o.Tail = m.virtualTail
-	o.Offset = uint64(m.flushOffset)
-
 	_, err := m.file.Seek(0, io.SeekStart)
 	if err != nil {
 		return err
 	}
-	if err := rlp.Encode(m.file, &o); err != nil {
+	if err := m.encode(m.file); err != nil {
 		return err
 	}
 	if !sync {
@@ -187,3 +229,33 @@ func (m *freezerTableMeta) write(sync bool) error {
 	}
 	return m.file.Sync()
 }
+
+// encode writes the metadata in the format implied by the compression mode.
+func (m *freezerTableMeta) encode(w io.Writer) error {
+	if m.compression == compressionDefault {
+		type obj struct {
+			Version uint16
+			Tail    uint64
+			Offset  uint64
+		}
+		var o obj
+		o.Version = freezerVersion // forcibly use the current version
+		o.Tail = m.virtualTail
+		o.Offset = uint64(m.flushOffset)
+		return rlp.Encode(w, &o)
+	}
+	type obj struct {
+		Version     uint16
+		Tail        uint64
+		Offset      uint64
+		Compression uint8
+		DictID      uint32
+	}
+	var o obj
+	o.Version = freezerTableV3
+	o.Tail = m.virtualTail
+	o.Offset = uint64(m.flushOffset)
+	o.Compression = uint8(m.compression)
+	o.DictID = m.dictID
+	return rlp.Encode(w, &o)
+}
@@ -124,3 +124,37 @@ func TestInvalidMetadata(t *testing.T) {
 		t.Fatal("Unexpected success")
 	}
 }
+
+func TestZstdMetadata(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "*")
+	if err != nil {
+		t.Fatalf("Failed to create file %v", err)
+	}
+	defer f.Close()
+
+	meta, err := newMetadata(f)
+	if err != nil {
+		t.Fatalf("Failed to new metadata %v", err)
+	}
+	meta.compression, meta.dictID = compressionZstd, 1234
+	meta.setVirtualTail(100, false)
+
+	// The compression is retained in the v3 format, which the previous
+	// decoders reject.
+	if decodeV2(f) != nil {
+		t.Fatal("Unexpected success of v2 decoding")
+	}
+	meta, err = newMetadata(f)
+	if err != nil {
+		t.Fatalf("Failed to reload metadata %v", err)
+	}
+	if meta.version != freezerTableV3 {
+		t.Fatalf("Unexpected version field")
+	}
+	if meta.compression != compressionZstd || meta.dictID != 1234 {
+		t.Fatalf("Unexpected compression fields: %v %d", meta.compression, meta.dictID)
+	}
+	if meta.virtualTail != uint64(100) {
+		t.Fatalf("Unexpected virtual tail field")
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+const (
+	// recompressDir is the directory within the freezer in which the tables are
+	// rebuilt during recompression.
+	recompressDir = "recompress"
+
+	// recompressBatchItems and recompressBatchBytes limit the items read from
+	// the original table in one go.
+	recompressBatchItems = 1024
+	recompressBatchBytes = 4 * 1024 * 1024
+)
+
+// errRecompressInterrupted is returned if the table recompression is aborted
+// by the caller. The original table is left untouched.
+var errRecompressInterrupted = errors.New("recompression interrupted")
+
+// Recompress converts the given table to zstd compression, using a dictionary
+// trained on the table content. The items are copied into a new table without
+// blocking the freezer, only catching up with the writes made meanwhile happens
+// with the freezer locked. The new table is swapped in atomically by replacing
+// the metadata of the table.
+//
+// It is used both by the recompress-freezer command of geth and by the chain
+// freezer of a running node converting its tables in the background.
+func (f *Freezer) Recompress(kind string, interrupt <-chan struct{}) error {
+	if f.readonly {
+		return errReadOnly
+	}
+	table := f.tables[kind]
+	if table == nil {
+		return errUnknownTable
+	}
+	if table.config.noSnappy {
+		return fmt.Errorf("compression is disabled for table %s", kind)
+	}
+	if table.remote != nil {
+		return fmt.Errorf("table %s is kept in an object store", kind)
+	}
+	if name := table.compressionName(); name != "snappy" {
+		return fmt.Errorf("table %s is already compressed with %s", kind, name)
+	}
+	f.writeLock.RLock()
+	var (
+		tail        = table.itemHidden.Load()
+		head        = table.items.Load()
+		truncations = table.truncations.Load()
+	)
+	f.writeLock.RUnlock()
+
+	if tail > math.MaxUint32 {
+		return fmt.Errorf("table tail %d out of range", tail)
+	}
+	// Train the dictionary on the items sampled evenly across the table
+	var samples [][]byte
+	if head > tail {
+		step := max((head-tail)/zstdDictSamples, 1)
+		for item := tail; item < head; item += step {
+			blob, err := table.Retrieve(item)
+			if errors.Is(err, errOutOfBounds) {
+				continue // pruned meanwhile
+			}
+			if err != nil {
+				return err
+			}
+			samples = append(samples, blob)
+		}
+	}
+	dictionary, err := trainZstdDict(samples)
+	if err != nil {
+		log.Warn("Failed to train zstd dictionary, compressing without", "table", kind, "err", err)
+		dictionary = nil
+	}
+	// Set up the new table with the dictionary and the metadata in place
+	tmpdir := filepath.Join(f.datadir, recompressDir)
+	if err := os.RemoveAll(tmpdir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmpdir, 0755); err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpdir)
+
+	var dictID uint32
+	if len(dictionary) > 0 {
+		codec, err := newZstdCodec(dictionary)
+		if err != nil {
+			return err
+		}
+		dictID = codec.dictID
+		codec.close()
+
+		if err := os.WriteFile(filepath.Join(tmpdir, fmt.Sprintf("%s.zdict", kind)), dictionary, 0644); err != nil {
+			return err
+		}
+	}
+	if err := writeRecompressMeta(tmpdir, kind, tail, dictID); err != nil {
+		return err
+	}
+	// The first index entry carries the number of deleted items
+	first := indexEntry{filenum: 0, offset: uint32(tail)}
+	if err := os.WriteFile(filepath.Join(tmpdir, fmt.Sprintf("%s.zidx", kind)), first.append(nil), 0644); err != nil {
+		return err
+	}
+	dst, err := newTable(tmpdir, kind, metrics.NewInactiveMeter(), metrics.NewInactiveMeter(), metrics.NewGauge(), table.maxFileSize, table.config, false)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	// Copy the items over without holding the freezer lock
+	var (
+		batch  = dst.newBatch()
+		next   = tail
+		start  = time.Now()
+		logged = time.Now()
+	)
+	copyItems := func(limit uint64, interrupt <-chan struct{}) error {
+		for next < limit {
+			select {
+			case <-interrupt:
+				return errRecompressInterrupted
+			default:
+			}
+			items, err := table.RetrieveItems(next, recompressBatchItems, recompressBatchBytes)
+			if errors.Is(err, errOutOfBounds) && next < table.itemHidden.Load() {
+				// The items are pruned meanwhile, fill in placeholders which
+				// are hidden by the tail truncation in the end.
+				for hidden := min(table.itemHidden.Load(), limit); next < hidden; next++ {
+					if err := batch.AppendRaw(next, nil); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			for _, item := range items {
+				if err := batch.AppendRaw(next, item); err != nil {
+					return err
+				}
+				next++
+			}
+			if time.Since(logged) > 8*time.Second {
+				log.Info("Recompressing freezer table", "table", kind, "item", next, "head", limit, "elapsed", common.PrettyDuration(time.Since(start)))
+				logged = time.Now()
+			}
+		}
+		return nil
+	}
+	if err := copyItems(head, interrupt); err != nil {
+		return err
+	}
+	// Catch up with the freezer and swap the tables
+	f.writeLock.Lock()
+	defer f.writeLock.Unlock()
+
+	if table.truncations.Load() != truncations {
+		return fmt.Errorf("table %s truncated during recompression", kind)
+	}
+	if err := copyItems(table.items.Load(), nil); err != nil {
+		return err
+	}
+	if err := batch.commit(); err != nil {
+		return err
+	}
+	if hidden := table.itemHidden.Load(); hidden > dst.itemHidden.Load() {
+		if err := dst.truncateTail(hidden); err != nil {
+			return err
+		}
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	// Move the new files next to the original ones, replacing the metadata
+	// last as the commit point. The leftovers of either version are cleaned
+	// up when the table is opened.
+	files, err := filepath.Glob(filepath.Join(tmpdir, fmt.Sprintf("%s.*.zdat", kind)))
+	if err != nil {
+		return err
+	}
+	files = append(files, filepath.Join(tmpdir, fmt.Sprintf("%s.zidx", kind)))
+	if dictID != 0 {
+		files = append(files, filepath.Join(tmpdir, fmt.Sprintf("%s.zdict", kind)))
+	}
+	files = append(files, filepath.Join(tmpdir, fmt.Sprintf("%s.meta", kind)))
+	for _, file := range files {
+		if err := os.Rename(file, filepath.Join(f.datadir, filepath.Base(file))); err != nil {
+			return err
+		}
+	}
+	if err := table.reopen(); err != nil {
+		return err
+	}
+	f.writeBatch.tables[kind] = table.newBatch()
+
+	log.Info("Recompressed freezer table", "table", kind, "items", next-tail, "dictionary", dictID != 0,
+		"elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// recompressible returns the tables which can be converted to zstd compression,
+// being compressed with snappy and kept locally.
+func (f *Freezer) recompressible() []string {
+	var kinds []string
+	for kind, table := range f.tables {
+		if table.config.noSnappy || table.remote != nil || table.compressionName() != "snappy" {
+			continue
+		}
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+	return kinds
+}
+
+// writeRecompressMeta creates the metadata of a zstd table with the given tail.
+func writeRecompressMeta(dir string, kind string, tail uint64, dictID uint32) error {
+	file, err := openFreezerFileForAppend(filepath.Join(dir, fmt.Sprintf("%s.meta", kind)))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	meta := &freezerTableMeta{
+		file:        file,
+		version:     freezerTableV3,
+		virtualTail: tail,
+		flushOffset: indexEntrySize,
+		compression: compressionZstd,
+		dictID:      dictID,
+	}
+	return meta.write(true)
+}
@@ -0,0 +1,410 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/ethdb/objstore"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// remoteBlockSize is the granularity of the reads from the object store and
+	// of the local read cache.
+	remoteBlockSize = 256 * 1024
+
+	// remoteRestoreChunk is the size of the chunks a remote data file is read
+	// in when it's moved back locally.
+	remoteRestoreChunk = 64 * 1024 * 1024
+
+	// remoteOffloadInterval is the interval of checking for sealed data files
+	// to be moved into the object store.
+	remoteOffloadInterval = time.Minute
+)
+
+// FreezerRemote configures a freezer to keep its sealed data files in an object
+// store. The data file being appended to, the index and the metadata files are
+// always kept locally.
+type FreezerRemote struct {
+	Store     objstore.Store // Object store holding the sealed data files
+	Prefix    string         // Prefix of the object names, separating multiple freezers
+	CacheSize int            // Size of the local read cache in bytes
+}
+
+// OpenFreezerRemote opens the object store at the given URL for keeping the
+// sealed files of the chain freezer, with a read cache of the given size in
+// bytes. See objstore.Open for the supported URLs.
+func OpenFreezerRemote(rawurl string, cacheSize int) (*FreezerRemote, error) {
+	store, err := objstore.Open(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	return &FreezerRemote{Store: store, Prefix: ChainFreezerName, CacheSize: cacheSize}, nil
+}
+
+// remoteBlockKey identifies a cached block of a remote data file. The cache
+// assumes the content to never change for a given key, so the generation of the
+// file is included for telling apart a file moved back locally, rewritten and
+// moved into the object store again.
+type remoteBlockKey struct {
+	name  string // Object name of the data file
+	gen   uint64 // Number of restores performed on the table
+	block uint32 // Block number within the file
+}
+
+// remoteTable tracks the data files of a freezer table kept in the object store.
+// The sealed files are moved over in ascending order, so all the files below the
+// boundary are stored remotely and all the others locally.
+type remoteTable struct {
+	store    objstore.Store
+	cache    *lru.SizeConstrainedCache[remoteBlockKey, []byte]
+	prefix   string // Prefix of the object names
+	marker   string // Path of the file persisting the boundary
+	boundary uint32 // Number of the first data file kept locally
+	gen      uint64 // Number of files moved back locally, distinguishing the cached blocks
+
+	sizes    map[uint32]int64 // Sizes of the remote files, resolved on demand
+	sizeLock sync.Mutex
+
+	offloadLock sync.Mutex // Lock serializing the uploads of the files
+}
+
+// openRemoteTable loads the remote state of the given table.
+func openRemoteTable(remote *FreezerRemote, cache *lru.SizeConstrainedCache[remoteBlockKey, []byte], dir string, name string) (*remoteTable, error) {
+	rt := &remoteTable{
+		store:  remote.Store,
+		cache:  cache,
+		prefix: remote.Prefix,
+		marker: filepath.Join(dir, fmt.Sprintf("%s.remote", name)),
+		sizes:  make(map[uint32]int64),
+	}
+	blob, err := os.ReadFile(rt.marker)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		// No file offloaded yet
+	case err != nil:
+		return nil, err
+	case len(blob) != 4:
+		return nil, fmt.Errorf("invalid remote marker %s", rt.marker)
+	default:
+		rt.boundary = binary.BigEndian.Uint32(blob)
+	}
+	return rt, nil
+}
+
+// objectName returns the name of the object holding the given data file.
+func (rt *remoteTable) objectName(file string) string {
+	return path.Join(rt.prefix, filepath.Base(file))
+}
+
+// setBoundary persists the new boundary between the remote and local files.
+func (rt *remoteTable) setBoundary(boundary uint32) error {
+	var blob [4]byte
+	binary.BigEndian.PutUint32(blob[:], boundary)
+
+	tmp := rt.marker + ".tmp"
+	if err := os.WriteFile(tmp, blob[:], 0644); err != nil {
+		return err
+	}
+	f, err := os.Open(tmp)
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	f.Close()
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, rt.marker); err != nil {
+		return err
+	}
+	rt.boundary = boundary
+	return nil
+}
+
+// size returns the size of a remote data file.
+func (rt *remoteTable) size(name string, num uint32) (int64, error) {
+	rt.sizeLock.Lock()
+	defer rt.sizeLock.Unlock()
+
+	if size, ok := rt.sizes[num]; ok {
+		return size, nil
+	}
+	size, err := rt.store.Size(name)
+	if err != nil {
+		return 0, err
+	}
+	rt.sizes[num] = size
+	return size, nil
+}
+
+// readAt fills the buffer with the content of a remote data file at the given
+// offset, going through the read cache block by block.
+func (rt *remoteTable) readAt(file string, num uint32, buf []byte, offset int64) error {
+	name := rt.objectName(file)
+	size, err := rt.size(name, num)
+	if err != nil {
+		return err
+	}
+	if offset+int64(len(buf)) > size {
+		return fmt.Errorf("read beyond remote file %s: offset %d, length %d, size %d", name, offset, len(buf), size)
+	}
+	for n := 0; n < len(buf); {
+		var (
+			pos   = offset + int64(n)
+			key   = remoteBlockKey{name: name, gen: rt.gen, block: uint32(pos / remoteBlockSize)}
+			start = int64(key.block) * remoteBlockSize
+		)
+		block, ok := rt.cache.Get(key)
+		if !ok {
+			block, err = rt.store.ReadRange(name, start, min(remoteBlockSize, size-start))
+			if err != nil {
+				return err
+			}
+			rt.cache.Add(key, block)
+		}
+		n += copy(buf[n:], block[pos-start:])
+	}
+	return nil
+}
+
+// forget drops the cached state of a remote data file.
+func (rt *remoteTable) forget(num uint32) {
+	rt.sizeLock.Lock()
+	delete(rt.sizes, num)
+	rt.sizeLock.Unlock()
+}
+
+// dataFilePath returns the local path of the given data file.
+func (t *freezerTable) dataFilePath(num uint32) string {
+	_, datExt := t.fileExtensions()
+	return filepath.Join(t.path, fmt.Sprintf("%s.%04d.%s", t.name, num, datExt))
+}
+
+// isRemote reports whether the given data file is kept in the object store.
+func (t *freezerTable) isRemote(num uint32) bool {
+	return t.remote != nil && num < t.remote.boundary
+}
+
+// readAt reads the content of a data file at the given offset, either from the
+// local file or from the object store. It assumes that the read-lock is held by
+// the caller.
+func (t *freezerTable) readAt(num uint32, buf []byte, offset int64) error {
+	if t.isRemote(num) {
+		return t.remote.readAt(t.dataFilePath(num), num, buf, offset)
+	}
+	dataFile, exist := t.files[num]
+	if !exist {
+		return fmt.Errorf("missing data file %d", num)
+	}
+	_, err := dataFile.ReadAt(buf, offset)
+	return err
+}
+
+// removeOffloadedFiles deletes the local copies of the data files which have
+// already been moved into the object store, left behind by a crash.
+func (t *freezerTable) removeOffloadedFiles() {
+	_, datExt := t.fileExtensions()
+	files, _ := filepath.Glob(filepath.Join(t.path, fmt.Sprintf("%s.*.%s", t.name, datExt)))
+	for _, file := range files {
+		num := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), t.name+"."), "."+datExt)
+		if n, err := strconv.ParseUint(num, 10, 32); err == nil && n < uint64(t.remote.boundary) {
+			t.logger.Info("Removing offloaded freezer file", "file", filepath.Base(file))
+			os.Remove(file)
+		}
+	}
+}
+
+// restoreRemote moves a data file back from the object store, together with
+// dropping all the subsequent remote files. It's needed when the head of the
+// table is truncated into a remote file. It assumes that the write-lock is held
+// by the caller.
+func (t *freezerTable) restoreRemote(num uint32) error {
+	if !t.isRemote(num) {
+		return nil
+	}
+	if t.readonly {
+		return fmt.Errorf("data file %d of table %s is stored remotely", num, t.name)
+	}
+	var (
+		file = t.dataFilePath(num)
+		name = t.remote.objectName(file)
+	)
+	size, err := t.remote.store.Size(name)
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	for offset := int64(0); offset < size && err == nil; offset += remoteRestoreChunk {
+		var blob []byte
+		if blob, err = t.remote.store.ReadRange(name, offset, min(remoteRestoreChunk, size-offset)); err == nil {
+			_, err = f.Write(blob)
+		}
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		return err
+	}
+	boundary := t.remote.boundary
+	if err := t.remote.setBoundary(num); err != nil {
+		return err
+	}
+	t.remote.gen++
+	for i := num; i < boundary; i++ {
+		t.remote.forget(i)
+		if err := t.remote.store.Delete(t.remote.objectName(t.dataFilePath(i))); err != nil {
+			t.logger.Warn("Failed to delete remote freezer file", "file", i, "err", err)
+		}
+	}
+	t.logger.Info("Restored remote freezer file", "file", num)
+	return nil
+}
+
+// deleteRemote deletes the remote data files in the given range after the tail
+// of the table is truncated. It assumes that the write-lock is held by the caller.
+func (t *freezerTable) deleteRemote(from, to uint32) {
+	if t.remote == nil {
+		return
+	}
+	for i := from; i < min(to, t.remote.boundary); i++ {
+		t.remote.forget(i)
+		if err := t.remote.store.Delete(t.remote.objectName(t.dataFilePath(i))); err != nil {
+			t.logger.Warn("Failed to delete remote freezer file", "file", i, "err", err)
+		}
+	}
+}
+
+// offload moves the sealed data files of the table into the object store. The
+// files are uploaded without holding the lock, they are only dropped locally
+// if the table was not truncated meanwhile.
+func (t *freezerTable) offload(abort <-chan struct{}) error {
+	t.remote.offloadLock.Lock()
+	defer t.remote.offloadLock.Unlock()
+
+	for {
+		select {
+		case <-abort:
+			return nil
+		default:
+		}
+		t.lock.RLock()
+		var (
+			num         = max(t.remote.boundary, t.tailId)
+			headId      = t.headId
+			truncations = t.truncations.Load()
+			closed      = t.index == nil
+		)
+		t.lock.RUnlock()
+
+		if closed {
+			return errClosed
+		}
+		if num >= headId {
+			return nil // only the head file is left locally
+		}
+		file := t.dataFilePath(num)
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		stat, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return err
+		}
+		name := t.remote.objectName(file)
+		err = t.remote.store.Put(name, f, stat.Size())
+		f.Close()
+		if err != nil {
+			return err
+		}
+		// Drop the local file if it's still sealed and unchanged
+		t.lock.Lock()
+		if t.truncations.Load() != truncations || t.headId <= num || t.index == nil {
+			t.lock.Unlock()
+			if err := t.remote.store.Delete(name); err != nil {
+				t.logger.Warn("Failed to delete remote freezer file", "file", num, "err", err)
+			}
+			continue
+		}
+		if err := t.remote.setBoundary(num + 1); err != nil {
+			t.lock.Unlock()
+			return err
+		}
+		t.releaseFile(num)
+		if num < t.tailId {
+			// The file was pruned meanwhile
+			t.remote.store.Delete(name)
+		}
+		os.Remove(file)
+		t.lock.Unlock()
+
+		t.logger.Info("Moved freezer file to remote store", "file", num, "size", stat.Size())
+	}
+}
+
+// offloadLoop periodically moves the sealed data files of all the tables into
+// the object store.
+func (f *Freezer) offloadLoop() {
+	defer f.wg.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			f.offload(f.quit)
+			timer.Reset(remoteOffloadInterval)
+		case <-f.quit:
+			return
+		}
+	}
+}
+
+// offload moves the sealed data files of all the tables into the object store.
+func (f *Freezer) offload(abort <-chan struct{}) {
+	for name, table := range f.tables {
+		if err := table.offload(abort); err != nil && !errors.Is(err, errClosed) {
+			log.Warn("Failed to move freezer files to remote store", "table", name, "err", err)
+		}
+	}
+}
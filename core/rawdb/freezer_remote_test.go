@@ -0,0 +1,148 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package rawdb
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/objstore"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFreezerRemote(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dir    = t.TempDir()
+		tables = map[string]freezerTableConfig{"a": {noSnappy: true, prunable: true}, "b": {noSnappy: false, prunable: true}}
+	)
+	store, err := objstore.NewFileStore(t.TempDir())
+	require.NoError(t, err)
+	remote := &FreezerRemote{Store: store, Prefix: "chain", CacheSize: 4096}
+
+	open := func() *Freezer {
+		t.Helper()
+		// 10 items of 100 bytes per data file
+		f, err := NewFreezerWithRemote(dir, "", false, 1000, tables, remote)
+		require.NoError(t, err)
+		return f
+	}
+	item := func(i uint64, version byte) []byte {
+		return bytes.Repeat([]byte{byte(i), version}, 50)
+	}
+	write := func(f *Freezer, from, to uint64, version byte) {
+		t.Helper()
+		_, err := f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+			for i := from; i < to; i++ {
+				if err := op.AppendRaw("a", i, item(i, version)); err != nil {
+					return err
+				}
+				if err := op.AppendRaw("b", i, item(i, version)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	check := func(f *Freezer, from, to uint64, version byte) {
+		t.Helper()
+		for i := from; i < to; i++ {
+			for _, kind := range []string{"a", "b"} {
+				blob, err := f.Ancient(kind, i)
+				require.NoError(t, err)
+				require.Equal(t, item(i, version), blob, "table %s item %d", kind, i)
+
+				part, err := f.AncientBytes(kind, i, 10, 20)
+				require.NoError(t, err)
+				require.Equal(t, item(i, version)[10:30], part)
+			}
+		}
+		blobs, err := f.AncientRange("a", from, to-from, 0)
+		require.NoError(t, err)
+		require.Len(t, blobs, int(to-from))
+		for j, blob := range blobs {
+			require.Equal(t, item(from+uint64(j), version), blob)
+		}
+	}
+	isLocal := func(kind string, num int) bool {
+		ext := "rdat"
+		if kind == "b" {
+			ext = "cdat"
+		}
+		matches, _ := filepath.Glob(filepath.Join(dir, fmt.Sprintf("%s.%04d.%s", kind, num, ext)))
+		return len(matches) > 0
+	}
+	isRemote := func(kind string, num int) bool {
+		ext := "rdat"
+		if kind == "b" {
+			ext = "cdat"
+		}
+		_, err := store.Size(fmt.Sprintf("chain/%s.%04d.%s", kind, num, ext))
+		return err == nil
+	}
+
+	// Fill the tables and move the sealed files into the store
+	f := open()
+	write(f, 0, 95, 0)
+	f.offload(nil)
+
+	for num := 0; num < 9; num++ {
+		require.False(t, isLocal("a", num), "file %d kept locally", num)
+		require.True(t, isRemote("a", num), "file %d not uploaded", num)
+	}
+	require.True(t, isLocal("a", 9), "head file moved")
+	require.False(t, isRemote("a", 9), "head file uploaded")
+	check(f, 0, 95, 0)
+
+	// Reopen the freezer, the remote files should still be served
+	require.NoError(t, f.Close())
+	f = open()
+	check(f, 0, 95, 0)
+
+	// Truncate the head into a remote file, it needs to be moved back
+	_, err = f.TruncateHead(25)
+	require.NoError(t, err)
+	require.True(t, isLocal("a", 2), "truncated head file not restored")
+	for num := 2; num < 9; num++ {
+		require.False(t, isRemote("a", num), "file %d kept remotely", num)
+	}
+	check(f, 0, 25, 0)
+
+	// Rewrite the items with different content and move them over again,
+	// the reads should not be served from stale cache entries
+	write(f, 25, 60, 1)
+	f.offload(nil)
+	require.False(t, isLocal("a", 2))
+	require.True(t, isRemote("a", 4))
+	check(f, 0, 25, 0)
+	check(f, 25, 60, 1)
+
+	// Prune the tail, the remote files should be deleted
+	_, err = f.TruncateTail(30)
+	require.NoError(t, err)
+	for num := 0; num < 3; num++ {
+		require.False(t, isRemote("a", num), "pruned file %d kept", num)
+	}
+	require.True(t, isRemote("a", 3))
+	check(f, 30, 60, 1)
+	require.NoError(t, f.Close())
+}
@@ -159,6 +159,15 @@ func (f *resettableFreezer) AncientSize(kind string) (uint64, error) {
 	return f.freezer.AncientSize(kind)
 }
 
+// tableCompression returns the compression of the given table, along with the
+// estimated ratio between the uncompressed and the stored size of the items.
+func (f *resettableFreezer) tableCompression(kind string) (string, float64, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.freezer.tableCompression(kind)
+}
+
 // ReadAncients runs the given read operation while ensuring that no writes take place
 // on the underlying freezer.
 func (f *resettableFreezer) ReadAncients(fn func(ethdb.AncientReaderOp) error) (err error) {
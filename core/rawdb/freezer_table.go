@@ -25,6 +25,8 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -32,7 +34,6 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
-	"github.com/golang/snappy"
 )
 
 var (
@@ -100,7 +101,14 @@ type freezerTable struct {
 	// should never be lower than itemOffset.
 	itemHidden atomic.Uint64
 
+	// truncations is the number of head truncations applied on the table. It
+	// allows detecting the items being rewritten while the table is recompressed.
+	truncations atomic.Uint64
+
 	config      freezerTableConfig // table configuration (compression, prunability). Note: compression flag does not apply retroactively to existing files
+	compression freezerCompression // compression mode of the items, loaded from the metadata
+	codec       freezerCodec       // codec of the items, matching the compression mode
+	remote      *remoteTable       // object store keeping the sealed data files, nil if all kept locally
 	readonly    bool
 	maxFileSize uint32 // Max file size for data-files
 	name        string
@@ -134,53 +142,17 @@ func newFreezerTable(path, name string, config freezerTableConfig, readonly bool
 // non-existent. Both files are truncated to the shortest common length to ensure
 // they don't go out of sync.
 func newTable(path string, name string, readMeter, writeMeter *metrics.Meter, sizeGauge *metrics.Gauge, maxFilesize uint32, config freezerTableConfig, readonly bool) (*freezerTable, error) {
-	// Ensure the containing directory exists and open the indexEntry file
+	return newTableWithRemote(path, name, readMeter, writeMeter, sizeGauge, maxFilesize, config, readonly, nil)
+}
+
+// newTableWithRemote opens a freezer table whose sealed data files are moved
+// into the given object store, which is optional.
+func newTableWithRemote(path string, name string, readMeter, writeMeter *metrics.Meter, sizeGauge *metrics.Gauge, maxFilesize uint32, config freezerTableConfig, readonly bool, remote *remoteTable) (*freezerTable, error) {
+	// Ensure the containing directory exists
 	if err := os.MkdirAll(path, 0755); err != nil {
 		return nil, err
 	}
-	var idxName string
-	if config.noSnappy {
-		idxName = fmt.Sprintf("%s.ridx", name) // raw index file
-	} else {
-		idxName = fmt.Sprintf("%s.cidx", name) // compressed index file
-	}
-	var (
-		err   error
-		index *os.File
-		meta  *os.File
-	)
-	if readonly {
-		// Will fail if table index file or meta file is not existent
-		index, err = openFreezerFileForReadOnly(filepath.Join(path, idxName))
-		if err != nil {
-			return nil, err
-		}
-		meta, err = openFreezerFileForReadOnly(filepath.Join(path, fmt.Sprintf("%s.meta", name)))
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		index, err = openFreezerFileForAppend(filepath.Join(path, idxName))
-		if err != nil {
-			return nil, err
-		}
-		meta, err = openFreezerFileForAppend(filepath.Join(path, fmt.Sprintf("%s.meta", name)))
-		if err != nil {
-			return nil, err
-		}
-	}
-	// Load metadata from the file. The tag will be true if legacy metadata
-	// is detected.
-	metadata, err := newMetadata(meta)
-	if err != nil {
-		return nil, err
-	}
-	// Create the table and repair any past inconsistency
 	tab := &freezerTable{
-		index:       index,
-		metadata:    metadata,
-		lastSync:    time.Now(),
-		files:       make(map[uint32]*os.File),
 		readMeter:   readMeter,
 		writeMeter:  writeMeter,
 		sizeGauge:   sizeGauge,
@@ -190,9 +162,9 @@ func newTable(path string, name string, readMeter, writeMeter *metrics.Meter, si
 		config:      config,
 		readonly:    readonly,
 		maxFileSize: maxFilesize,
+		remote:      remote,
 	}
-	if err := tab.repair(); err != nil {
-		tab.Close()
+	if err := tab.open(); err != nil {
 		return nil, err
 	}
 	// Initialize the starting size counter
@@ -206,6 +178,171 @@ func newTable(path string, name string, readMeter, writeMeter *metrics.Meter, si
 	return tab, nil
 }
 
+// open loads the metadata, opens the index file in the format implied by the
+// compression mode and repairs any past inconsistency. It assumes that the
+// write-lock is held by the caller, or the table is not yet shared.
+func (t *freezerTable) open() error {
+	opener := openFreezerFileForAppend
+	if t.readonly {
+		// Will fail if table index file or meta file is not existent
+		opener = openFreezerFileForReadOnly
+	}
+	meta, err := opener(filepath.Join(t.path, fmt.Sprintf("%s.meta", t.name)))
+	if err != nil {
+		return err
+	}
+	// Load metadata from the file. The tag will be true if legacy metadata
+	// is detected.
+	stat, err := meta.Stat()
+	if err != nil {
+		meta.Close()
+		return err
+	}
+	metadata, err := newMetadata(meta)
+	if err != nil {
+		meta.Close()
+		return err
+	}
+	// Tables configured for zstd are created as such, without a dictionary as
+	// there's no content to train one on yet. Tables predating the metadata
+	// are left alone, their index is still around.
+	if stat.Size() == 0 && t.config.zstd && !t.config.noSnappy && !t.readonly && !t.hasDefaultIndex() {
+		metadata.version, metadata.compression = freezerTableV3, compressionZstd
+		if err := metadata.write(true); err != nil {
+			meta.Close()
+			return err
+		}
+	}
+	t.metadata = metadata
+	t.compression = metadata.compression
+
+	// Set up the codec of the items
+	switch {
+	case t.compression == compressionZstd:
+		dictionary, err := loadZstdDict(filepath.Join(t.path, fmt.Sprintf("%s.zdict", t.name)), metadata.dictID)
+		if err != nil {
+			meta.Close()
+			return err
+		}
+		if t.codec, err = newZstdCodec(dictionary); err != nil {
+			meta.Close()
+			return err
+		}
+	case t.config.noSnappy:
+		t.codec = rawCodec{}
+	default:
+		t.codec = snappyCodec{}
+	}
+	if !t.readonly {
+		t.removeStaleFiles()
+		if t.remote != nil {
+			t.removeOffloadedFiles()
+		}
+	}
+	idxExt, _ := t.fileExtensions()
+	index, err := opener(filepath.Join(t.path, fmt.Sprintf("%s.%s", t.name, idxExt)))
+	if err != nil {
+		meta.Close()
+		if codec, ok := t.codec.(*zstdCodec); ok {
+			codec.close()
+		}
+		return err
+	}
+	t.index = index
+	t.files = make(map[uint32]*os.File)
+	t.lastSync = time.Now()
+
+	// Repair any past inconsistency
+	if err := t.repair(); err != nil {
+		t.closeFiles()
+		return err
+	}
+	return nil
+}
+
+// reopen closes all the files of the table and opens it again from scratch. It's
+// used for switching the table to the files written in another compression mode.
+func (t *freezerTable) reopen() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	size, err := t.sizeNolock()
+	if err != nil {
+		return err
+	}
+	if err := t.closeFiles(); err != nil {
+		return err
+	}
+	if err := t.open(); err != nil {
+		return err
+	}
+	newSize, err := t.sizeNolock()
+	if err != nil {
+		return err
+	}
+	t.sizeGauge.Inc(int64(newSize) - int64(size))
+	return nil
+}
+
+// fileExtensions returns the extensions of the index and data files of the table,
+// which depend on the compression of the items.
+func (t *freezerTable) fileExtensions() (string, string) {
+	switch {
+	case t.compression == compressionZstd:
+		return "zidx", "zdat" // zstd compressed files
+	case t.config.noSnappy:
+		return "ridx", "rdat" // raw files
+	default:
+		return "cidx", "cdat" // snappy compressed files
+	}
+}
+
+// hasDefaultIndex reports whether the index file of the default compression
+// mode exists.
+func (t *freezerTable) hasDefaultIndex() bool {
+	_, err := os.Stat(filepath.Join(t.path, fmt.Sprintf("%s.cidx", t.name)))
+	return err == nil
+}
+
+// removeStaleFiles deletes the files left behind by an interrupted switch of the
+// table to another compression mode. The switch is committed by replacing the
+// metadata, so the files of the mode not recorded in the metadata are either the
+// remainder of the previous mode or an unfinished conversion.
+func (t *freezerTable) removeStaleFiles() {
+	var (
+		idxExt, datExt string
+		stale          []string
+	)
+	if t.compression == compressionZstd {
+		idxExt, datExt = "cidx", "cdat"
+		if t.config.noSnappy {
+			idxExt, datExt = "ridx", "rdat"
+		}
+	} else {
+		idxExt, datExt = "zidx", "zdat"
+		stale = append(stale, filepath.Join(t.path, fmt.Sprintf("%s.zdict", t.name)))
+	}
+	stale = append(stale, filepath.Join(t.path, fmt.Sprintf("%s.%s", t.name, idxExt)))
+
+	files, _ := filepath.Glob(filepath.Join(t.path, fmt.Sprintf("%s.*.%s", t.name, datExt)))
+	for _, file := range files {
+		// Skip the files of other tables sharing the name prefix
+		num := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), t.name+"."), "."+datExt)
+		if _, err := strconv.ParseUint(num, 10, 32); err == nil {
+			stale = append(stale, file)
+		}
+	}
+	for _, file := range stale {
+		if !common.FileExist(file) {
+			continue
+		}
+		t.logger.Warn("Removing stale freezer file", "file", filepath.Base(file))
+		if err := os.Remove(file); err != nil {
+			t.logger.Error("Failed to remove stale freezer file", "file", filepath.Base(file), "err", err)
+		}
+	}
+}
+
 // repair cross-checks the head and the index file and truncates them to
 // be in sync with each other after a potential crash / data loss.
 func (t *freezerTable) repair() error {
@@ -277,6 +414,11 @@ func (t *freezerTable) repair() error {
 		t.index.ReadAt(buffer, offsetsSize-indexEntrySize)
 		lastIndex.unmarshalBinary(buffer)
 	}
+	// The head file might have been moved into the object store if the index
+	// was truncated without the data, bring it back.
+	if err := t.restoreRemote(lastIndex.filenum); err != nil {
+		return err
+	}
 	if t.readonly {
 		t.head, err = t.openFile(lastIndex.filenum, openFreezerFileForReadOnly)
 	} else {
@@ -339,6 +481,12 @@ func (t *freezerTable) repair() error {
 			if newLastIndex.filenum != lastIndex.filenum {
 				// Release earlier opened file
 				t.releaseFile(lastIndex.filenum)
+				if err := t.restoreRemote(newLastIndex.filenum); err != nil {
+					return err
+				}
+				if err := t.detachFile(newLastIndex.filenum); err != nil {
+					return err
+				}
 				if t.head, err = t.openFile(newLastIndex.filenum, openFreezerFileForAppend); err != nil {
 					return err
 				}
@@ -582,8 +730,11 @@ func (t *freezerTable) preopen() (err error) {
 	// The repair might have already opened (some) files
 	t.releaseFilesAfter(0, false)
 
-	// Open all except head in RDONLY
+	// Open all except head in RDONLY, skipping the files stored remotely
 	for i := t.tailId; i < t.headId; i++ {
+		if t.isRemote(i) {
+			continue
+		}
 		if _, err = t.openFile(i, openFreezerFileForReadOnly); err != nil {
 			return err
 		}
@@ -610,6 +761,8 @@ func (t *freezerTable) truncateHead(items uint64) error {
 	if items < t.itemHidden.Load() {
 		return errors.New("truncation below tail")
 	}
+	t.truncations.Add(1)
+
 	// We need to truncate, save the old size for metrics tracking
 	oldSize, err := t.sizeNolock()
 	if err != nil {
@@ -655,8 +808,15 @@ func (t *freezerTable) truncateHead(items uint64) error {
 	}
 	// We might need to truncate back to older files
 	if expected.filenum != t.headId {
-		// If already open for reading, force-reopen for writing
-		t.releaseFile(expected.filenum)
+		// Move the file back locally if it's stored remotely
+		if err := t.restoreRemote(expected.filenum); err != nil {
+			return err
+		}
+		// If already open for reading, force-reopen for writing. The file
+		// is detached first as it might be linked into a checkpoint.
+		if err := t.detachFile(expected.filenum); err != nil {
+			return err
+		}
 		newHead, err := t.openFile(expected.filenum, openFreezerFileForAppend)
 		if err != nil {
 			return err
@@ -808,9 +968,11 @@ func (t *freezerTable) truncateTail(items uint64) error {
 		return err
 	}
 	// Release any files before the current tail
+	oldTailId := t.tailId
 	t.tailId = newTailId
 	t.itemOffset.Store(newDeleted)
 	t.releaseFilesBefore(t.tailId, true)
+	t.deleteRemote(oldTailId, t.tailId)
 
 	// Move the index flush offset backward due to the deletion of an index segment.
 	// A crash may occur before the offset is updated, leaving a dangling reference
@@ -843,6 +1005,12 @@ func (t *freezerTable) Close() error {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
+	return t.closeFiles()
+}
+
+// closeFiles flushes and closes all the files of the table. It assumes that
+// the write-lock is held by the caller.
+func (t *freezerTable) closeFiles() error {
 	if err := t.doSync(); err != nil {
 		return err
 	}
@@ -861,6 +1029,10 @@ func (t *freezerTable) Close() error {
 	t.head = nil
 	t.metadata.file = nil
 
+	if codec, ok := t.codec.(*zstdCodec); ok {
+		codec.close()
+	}
+
 	if errs != nil {
 		return fmt.Errorf("%v", errs)
 	}
@@ -871,12 +1043,8 @@ func (t *freezerTable) Close() error {
 func (t *freezerTable) openFile(num uint32, opener func(string) (*os.File, error)) (f *os.File, err error) {
 	var exist bool
 	if f, exist = t.files[num]; !exist {
-		var name string
-		if t.config.noSnappy {
-			name = fmt.Sprintf("%s.%04d.rdat", t.name, num)
-		} else {
-			name = fmt.Sprintf("%s.%04d.cdat", t.name, num)
-		}
+		_, datExt := t.fileExtensions()
+		name := fmt.Sprintf("%s.%04d.%s", t.name, num, datExt)
 		f, err = opener(filepath.Join(t.path, name))
 		if err != nil {
 			return nil, err
@@ -974,7 +1142,7 @@ func (t *freezerTable) Retrieve(item uint64) ([]byte, error) {
 // item, it _will_ return one element and possibly overflow the maxBytes.
 func (t *freezerTable) RetrieveItems(start, count, maxBytes uint64) ([][]byte, error) {
 	// First we read the 'raw' data, which might be compressed.
-	diskData, sizes, err := t.retrieveItems(start, count, maxBytes)
+	diskData, sizes, codec, err := t.retrieveItems(start, count, maxBytes)
 	if err != nil {
 		return nil, err
 	}
@@ -987,22 +1155,15 @@ func (t *freezerTable) RetrieveItems(start, count, maxBytes uint64) ([][]byte, e
 	for i, diskSize := range sizes {
 		item := diskData[offset : offset+diskSize]
 		offset += diskSize
-		decompressedSize := diskSize
-		if !t.config.noSnappy {
-			decompressedSize, _ = snappy.DecodedLen(item)
-		}
+		decompressedSize, _ := codec.decodedLen(item)
 		if i > 0 && maxBytes != 0 && uint64(outputSize+decompressedSize) > maxBytes {
 			break
 		}
-		if !t.config.noSnappy {
-			data, err := snappy.Decode(nil, item)
-			if err != nil {
-				return nil, err
-			}
-			output = append(output, data)
-		} else {
-			output = append(output, item)
+		data, err := codec.decode(item)
+		if err != nil {
+			return nil, err
 		}
+		output = append(output, data)
 		outputSize += decompressedSize
 	}
 	return output, nil
@@ -1011,15 +1172,15 @@ func (t *freezerTable) RetrieveItems(start, count, maxBytes uint64) ([][]byte, e
 // retrieveItems reads up to 'count' items from the table. It reads at least
 // one item, but otherwise avoids reading more than maxBytes bytes. Freezer
 // will ignore the size limitation and continuously allocate memory to store
-// data if maxBytes is 0. It returns the (potentially compressed) data, and
-// the sizes.
-func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []int, error) {
+// data if maxBytes is 0. It returns the (potentially compressed) data, the
+// sizes and the codec for decompressing the items.
+func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []int, freezerCodec, error) {
 	t.lock.RLock()
 	defer t.lock.RUnlock()
 
 	// Ensure the table and the item are accessible
 	if t.index == nil || t.head == nil || t.metadata.file == nil {
-		return nil, nil, errClosed
+		return nil, nil, nil, errClosed
 	}
 	var (
 		items  = t.items.Load()      // the total items(head + 1)
@@ -1028,7 +1189,7 @@ func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []i
 	// Ensure the start is written, not deleted from the tail, and that the
 	// caller actually wants something
 	if items <= start || hidden > start || count == 0 {
-		return nil, nil, errOutOfBounds
+		return nil, nil, nil, errOutOfBounds
 	}
 	if start+count > items {
 		count = items - start
@@ -1042,11 +1203,7 @@ func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []i
 	// readData is a helper method to read a single data item from disk.
 	readData := func(fileId, start uint32, length int) error {
 		output = grow(output, length)
-		dataFile, exist := t.files[fileId]
-		if !exist {
-			return fmt.Errorf("missing data file %d", fileId)
-		}
-		if _, err := dataFile.ReadAt(output[len(output)-length:], int64(start)); err != nil {
+		if err := t.readAt(fileId, output[len(output)-length:], int64(start)); err != nil {
 			return fmt.Errorf("%w, fileid: %d, start: %d, length: %d", err, fileId, start, length)
 		}
 		return nil
@@ -1054,7 +1211,7 @@ func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []i
 	// Read all the indexes in one go
 	indices, err := t.getIndices(start, count)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 	var (
 		sizes      []int               // The sizes for each element
@@ -1073,7 +1230,7 @@ func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []i
 			// If we have unread data in the first file, we need to do that read now.
 			if unreadSize > 0 {
 				if err := readData(firstIndex.filenum, readStart, unreadSize); err != nil {
-					return nil, nil, err
+					return nil, nil, nil, err
 				}
 				unreadSize = 0
 			}
@@ -1084,7 +1241,7 @@ func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []i
 			// read this last item, but we need to do the deferred reads now.
 			if unreadSize > 0 {
 				if err := readData(secondIndex.filenum, readStart, unreadSize); err != nil {
-					return nil, nil, err
+					return nil, nil, nil, err
 				}
 			}
 			break
@@ -1096,7 +1253,7 @@ func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []i
 		if i == len(indices)-2 || (uint64(totalSize) > maxBytes && maxBytes != 0) {
 			// Last item, need to do the read now
 			if err := readData(secondIndex.filenum, readStart, unreadSize); err != nil {
-				return nil, nil, err
+				return nil, nil, nil, err
 			}
 			break
 		}
@@ -1104,72 +1261,130 @@ func (t *freezerTable) retrieveItems(start, count, maxBytes uint64) ([]byte, []i
 
 	// Update metrics.
 	t.readMeter.Mark(int64(totalSize))
-	return output, sizes, nil
+	return output, sizes, t.codec, nil
 }
 
 // RetrieveBytes retrieves the value segment of the element specified by the id
 // and value offsets.
 func (t *freezerTable) RetrieveBytes(item, offset, length uint64) ([]byte, error) {
+	buf, codec, err := t.retrieveBytes(item, offset, length)
+	if err != nil || codec == nil {
+		return buf, err
+	}
+	// If compressed, decompress the full item, then slice.
+	data, err := codec.decode(buf)
+	if err != nil {
+		return nil, err
+	}
+	if offset > uint64(len(data)) || offset+length > uint64(len(data)) {
+		return nil, fmt.Errorf("requested range out of bounds: item size %d, offset %d, length %d", len(data), offset, length)
+	}
+	return data[offset : offset+length], nil
+}
+
+// retrieveBytes reads the value segment of the element specified by the id and
+// value offsets if the table is uncompressed. Otherwise the full item is read,
+// returned together with the codec for decompressing it.
+func (t *freezerTable) retrieveBytes(item, offset, length uint64) ([]byte, freezerCodec, error) {
 	t.lock.RLock()
 	defer t.lock.RUnlock()
 
 	if t.index == nil || t.head == nil || t.metadata.file == nil {
-		return nil, errClosed
+		return nil, nil, errClosed
 	}
 	items, hidden := t.items.Load(), t.itemHidden.Load()
 	if items <= item || hidden > item {
-		return nil, errOutOfBounds
+		return nil, nil, errOutOfBounds
 	}
 
 	// Retrieves the index entries for the specified ID and its immediate successor
 	indices, err := t.getIndices(item, 1)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	index0, index1 := indices[0], indices[1]
 
 	itemStart, itemLimit, fileId := index0.bounds(index1)
 	itemSize := itemLimit - itemStart
 
-	dataFile, exist := t.files[fileId]
-	if !exist {
-		return nil, fmt.Errorf("missing data file %d", fileId)
-	}
-
 	// Perform the partial read if no-compression was enabled upon
-	if t.config.noSnappy {
+	codec := t.codec
+	if _, raw := codec.(rawCodec); raw {
 		if offset > uint64(itemSize) || offset+length > uint64(itemSize) {
-			return nil, fmt.Errorf("requested range out of bounds: item size %d, offset %d, length %d", itemSize, offset, length)
+			return nil, nil, fmt.Errorf("requested range out of bounds: item size %d, offset %d, length %d", itemSize, offset, length)
 		}
 		itemStart += uint32(offset)
 
 		buf := make([]byte, length)
-		_, err = dataFile.ReadAt(buf, int64(itemStart))
-		if err != nil {
-			return nil, err
+		if err := t.readAt(fileId, buf, int64(itemStart)); err != nil {
+			return nil, nil, err
 		}
 		t.readMeter.Mark(int64(length))
-		return buf, nil
+		return buf, nil, nil
 	} else {
-		// If compressed, read the full item, decompress, then slice.
+		// If compressed, read the full item to decompress it later.
 		// Unfortunately, in this case, there is no performance gain
 		// by performing the partial read at all.
 		buf := make([]byte, itemSize)
-		_, err = dataFile.ReadAt(buf, int64(itemStart))
-		if err != nil {
-			return nil, err
+		if err := t.readAt(fileId, buf, int64(itemStart)); err != nil {
+			return nil, nil, err
 		}
 		t.readMeter.Mark(int64(itemSize))
+		return buf, codec, nil
+	}
+}
+
+// compressionName returns the human-readable name of the item compression.
+func (t *freezerTable) compressionName() string {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
 
-		data, err := snappy.Decode(nil, buf)
+	switch {
+	case t.compression == compressionZstd:
+		return "zstd"
+	case t.config.noSnappy:
+		return "none"
+	default:
+		return "snappy"
+	}
+}
+
+// compressionRatio estimates the ratio between the uncompressed and the stored
+// size of the items, by sampling the given number of items evenly across the
+// table. Zero is returned if the table is empty.
+func (t *freezerTable) compressionRatio(samples uint64) (float64, error) {
+	var (
+		tail  = t.itemHidden.Load()
+		head  = t.items.Load()
+		step  = uint64(1)
+		raw   uint64
+		store uint64
+	)
+	if head <= tail {
+		return 0, nil
+	}
+	if n := head - tail; n > samples {
+		step = n / samples
+	}
+	for item := tail; item < head; item += step {
+		data, sizes, codec, err := t.retrieveItems(item, 1, 0)
+		if errors.Is(err, errOutOfBounds) {
+			continue // pruned meanwhile
+		}
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
-		if offset > uint64(len(data)) || offset+length > uint64(len(data)) {
-			return nil, fmt.Errorf("requested range out of bounds: item size %d, offset %d, length %d", len(data), offset, length)
+		size, err := codec.decodedLen(data[:sizes[0]])
+		if err != nil {
+			return 0, err
 		}
-		return data[offset : offset+length], nil
+		raw += uint64(size)
+		store += uint64(sizes[0])
+	}
+	if store == 0 {
+		return 0, nil
 	}
+	return float64(raw) / float64(store), nil
 }
 
 // size returns the total data size in the freezer table.
@@ -22,8 +22,11 @@ import (
 	"fmt"
 	"math/big"
 	"math/rand"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/core/rawdb/ancienttest"
 	"github.com/ethereum/go-ethereum/ethdb"
@@ -417,3 +420,244 @@ func TestFreezerSuite(t *testing.T) {
 		return f
 	})
 }
+
+func TestFreezerRecompress(t *testing.T) {
+	t.Parallel()
+
+	tables := map[string]freezerTableConfig{"a": {noSnappy: false, prunable: true}, "b": {noSnappy: true, prunable: true}}
+	f, dir := newFreezerForTesting(t, tables)
+	defer f.Close()
+
+	// Fill the table with compressible items and prune some from the tail
+	item := func(i uint64) []byte {
+		var blob []byte
+		for j := uint64(0); j < 32; j++ {
+			blob = append(blob, fmt.Sprintf("item-%d-log-%d-topic-%x;", i, j, (i*j)%7)...)
+		}
+		return blob
+	}
+	write := func(from, to uint64) {
+		_, err := f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+			for i := from; i < to; i++ {
+				if err := op.AppendRaw("a", i, item(i)); err != nil {
+					return err
+				}
+				if err := op.AppendRaw("b", i, item(i)); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	write(0, 1000)
+	_, err := f.TruncateTail(100)
+	require.NoError(t, err)
+
+	// An interrupted recompression leaves the table untouched
+	interrupt := make(chan struct{})
+	close(interrupt)
+	require.ErrorIs(t, f.Recompress("a", interrupt), errRecompressInterrupted)
+	require.Equal(t, "snappy", f.tables["a"].compressionName())
+	require.NoDirExists(t, filepath.Join(dir, recompressDir))
+
+	// Uncompressed tables are not supported
+	require.Error(t, f.Recompress("b", nil))
+
+	require.NoError(t, f.Recompress("a", nil))
+	require.Equal(t, "zstd", f.tables["a"].compressionName())
+	require.NotZero(t, f.tables["a"].metadata.dictID)
+
+	stale, _ := filepath.Glob(filepath.Join(dir, "a.*cd*"))
+	require.Empty(t, stale, "snappy files left behind")
+
+	check := func(f *Freezer, head uint64) {
+		t.Helper()
+		_, err := f.Ancient("a", 99)
+		require.ErrorIs(t, err, errOutOfBounds)
+		for i := uint64(100); i < head; i++ {
+			blob, err := f.Ancient("a", i)
+			require.NoError(t, err)
+			require.Equal(t, item(i), blob, "item %d", i)
+		}
+		blobs, err := f.AncientRange("a", 100, 10, 0)
+		require.NoError(t, err)
+		require.Len(t, blobs, 10)
+	}
+	check(f, 1000)
+
+	// The recompressed table keeps working and survives a restart
+	write(1000, 1100)
+	check(f, 1100)
+	_, ratio, err := f.tableCompression("a")
+	require.NoError(t, err)
+	require.Greater(t, ratio, 1.0)
+	require.NoError(t, f.Close())
+
+	f, err = NewFreezer(dir, "", false, 2049, tables)
+	require.NoError(t, err)
+	defer f.Close()
+	require.Equal(t, "zstd", f.tables["a"].compressionName())
+	check(f, 1100)
+	require.Error(t, f.Recompress("a", nil))
+}
+
+func TestFreezerZstdNewTables(t *testing.T) {
+	t.Parallel()
+
+	// Tables configured for zstd are only created as such if they don't exist
+	tables := map[string]freezerTableConfig{"a": {noSnappy: false, prunable: true}}
+	f, dir := newFreezerForTesting(t, tables)
+	_, err := f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		return op.AppendRaw("a", 0, []byte{1})
+	})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	tables = map[string]freezerTableConfig{
+		"a": {noSnappy: false, prunable: true, zstd: true},
+		"b": {noSnappy: false, prunable: true, zstd: true},
+		"c": {noSnappy: true, prunable: true, zstd: true},
+	}
+	f, err = NewFreezer(dir, "", false, 2049, tables)
+	require.NoError(t, err)
+	require.Equal(t, "snappy", f.tables["a"].compressionName())
+	require.Equal(t, "zstd", f.tables["b"].compressionName())
+	require.Equal(t, "none", f.tables["c"].compressionName())
+
+	// The new zstd table is fully functional and survives a restart. The tables
+	// are aligned to the empty new ones when opening.
+	_, err = f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for i := uint64(0); i < 100; i++ {
+			for _, kind := range []string{"a", "b", "c"} {
+				if err := op.AppendRaw(kind, i, bytes.Repeat([]byte{byte(i)}, 100)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	f, err = NewFreezer(dir, "", false, 2049, tables)
+	require.NoError(t, err)
+	defer f.Close()
+	require.Equal(t, "zstd", f.tables["b"].compressionName())
+	for i := uint64(0); i < 100; i++ {
+		blob, err := f.Ancient("b", i)
+		require.NoError(t, err)
+		require.Equal(t, bytes.Repeat([]byte{byte(i)}, 100), blob)
+	}
+	require.Equal(t, []string{"a"}, f.recompressible())
+}
+
+func TestChainFreezerRecompact(t *testing.T) {
+	defer func(delay time.Duration) { recompactDelay = delay }(recompactDelay)
+	recompactDelay = 0
+
+	// Fill a snappy compressed chain freezer
+	dir := t.TempDir()
+	cf, err := newChainFreezer(dir, "", "", false, nil, false)
+	require.NoError(t, err)
+	item := func(kind string, i uint64) []byte {
+		return []byte(strings.Repeat(fmt.Sprintf("%s-%d;", kind, i), 64))
+	}
+	_, err = cf.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for i := uint64(0); i < 500; i++ {
+			for kind := range chainFreezerTableConfigs {
+				if err := op.AppendRaw(kind, i, item(kind, i)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, cf.Close())
+
+	// Reopen it with zstd enabled and wait for the tables to be converted
+	cf, err = newChainFreezer(dir, "", "", false, nil, true)
+	require.NoError(t, err)
+	defer cf.Close()
+
+	freezer := cf.ancients.(*Freezer)
+	require.NotEmpty(t, freezer.recompressible())
+
+	cf.wg.Add(1)
+	go func() {
+		cf.recompact()
+		cf.wg.Done()
+	}()
+	require.Eventually(t, func() bool { return len(freezer.recompressible()) == 0 }, 10*time.Second, 10*time.Millisecond)
+
+	for kind, config := range chainFreezerTableConfigs {
+		if !config.noSnappy {
+			require.Equal(t, "zstd", freezer.tables[kind].compressionName(), "table %s", kind)
+		}
+		for i := uint64(0); i < 500; i++ {
+			blob, err := cf.Ancient(kind, i)
+			require.NoError(t, err)
+			require.Equal(t, item(kind, i), blob)
+		}
+	}
+}
+
+func TestFreezerZstdCloseWhileReading(t *testing.T) {
+	t.Parallel()
+
+	tables := map[string]freezerTableConfig{"a": {noSnappy: false, prunable: true}}
+	f, _ := newFreezerForTesting(t, tables)
+	defer f.Close()
+
+	item := func(i uint64) []byte {
+		return bytes.Repeat([]byte(fmt.Sprintf("item-%d;", i)), 4096)
+	}
+	_, err := f.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for i := uint64(0); i < 100; i++ {
+			if err := op.AppendRaw("a", i, item(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.NoError(t, f.Recompress("a", nil))
+
+	// Closing the table must wait for the readers decompressing items
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				blobs, err := f.AncientRange("a", 0, 100, 0)
+				if err != nil {
+					if !errors.Is(err, errClosed) {
+						t.Errorf("unexpected error: %v", err)
+					}
+					return
+				}
+				for i, blob := range blobs {
+					if !bytes.Equal(blob, item(uint64(i))) {
+						t.Errorf("item %d mismatch", i)
+						return
+					}
+				}
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, f.Close())
+	wg.Wait()
+}
+
+func TestTrainZstdDictDegenerate(t *testing.T) {
+	samples := make([][]byte, 100)
+	for i := range samples {
+		samples[i] = bytes.Repeat([]byte{byte(i)}, 64*1024)
+	}
+	// The dictionary builder panics on such input, which must surface as error
+	_, err := trainZstdDict(samples)
+	require.Error(t, err)
+}
@@ -76,6 +76,14 @@ var (
 	// trieJournalKey tracks the in-memory trie node layers across restarts.
 	trieJournalKey = []byte("TrieJournal")
 
+	// stateSchemeMigrationKey tracks the progress of an in-place migration of
+	// the state from the hash scheme to the path scheme.
+	stateSchemeMigrationKey = []byte("StateSchemeMigration")
+
+	// databaseStatsKey tracks the write statistics of the key-value store, per
+	// data category.
+	databaseStatsKey = []byte("DatabaseStats")
+
 	// headStateHistoryIndexKey tracks the ID of the latest state history that has
 	// been indexed.
 	headStateHistoryIndexKey = []byte("LastStateHistoryIndex")
@@ -104,6 +112,9 @@ var (
 	// snapSyncStatusFlagKey flags that status of snap sync.
 	snapSyncStatusFlagKey = []byte("SnapSyncStatus")
 
+	// trieRefRootsKey tracks the state roots pinned by the hash-based online pruner.
+	trieRefRootsKey = []byte("TrieRefRoots")
+
 	// Data item prefixes (use single byte to avoid mixing data types, avoid `i`, used for indexes).
 	headerPrefix       = []byte("h") // headerPrefix + num (uint64 big endian) + hash -> header
 	headerTDSuffix     = []byte("t") // headerPrefix + num (uint64 big endian) + hash + headerTDSuffix -> td (deprecated)
@@ -125,6 +136,10 @@ var (
 	TrieNodeStoragePrefix = []byte("O") // TrieNodeStoragePrefix + accountHash + hexPath -> trie node
 	stateIDPrefix         = []byte("L") // stateIDPrefix + state root -> state id
 
+	// Reference counting of the hash-based trie nodes (online pruning)
+	TrieNodeRefPrefix     = []byte("R")        // TrieNodeRefPrefix + node hash -> reference count record
+	TrieNodeGarbagePrefix = []byte("trie-gc-") // TrieNodeGarbagePrefix + node hash -> empty, unreferenced node pending deletion
+
 	// State history indexing within path-based storage scheme
 	StateHistoryIndexPrefix           = []byte("m")   // The global prefix of state history index data
 	StateHistoryAccountMetadataPrefix = []byte("ma")  // StateHistoryAccountMetadataPrefix + account address hash => account metadata
@@ -277,6 +292,16 @@ func stateIDKey(root common.Hash) []byte {
 	return append(stateIDPrefix, root.Bytes()...)
 }
 
+// trieNodeRefKey = TrieNodeRefPrefix + nodeHash.
+func trieNodeRefKey(hash common.Hash) []byte {
+	return append(TrieNodeRefPrefix, hash.Bytes()...)
+}
+
+// trieNodeGarbageKey = TrieNodeGarbagePrefix + nodeHash.
+func trieNodeGarbageKey(hash common.Hash) []byte {
+	return append(TrieNodeGarbagePrefix, hash.Bytes()...)
+}
+
 // accountTrieNodeKey = TrieNodeAccountPrefix + nodePath.
 func accountTrieNodeKey(path []byte) []byte {
 	return append(TrieNodeAccountPrefix, path...)
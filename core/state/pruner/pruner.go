@@ -125,6 +125,12 @@ func prune(snaptree *snapshot.Tree, root common.Hash, maindb ethdb.Database, sta
 	// that the false-positive is low enough(~0.05%). The probability of the
 	// dangling node is the state root is super low. So the dangling nodes in
 	// theory will never ever be visited again.
+	//
+	// The reference counts of the online pruner are dropped beforehand, as they
+	// are invalidated by deleting the nodes behind its back.
+	if err := rawdb.DeleteTrieRefs(maindb); err != nil {
+		return err
+	}
 	var (
 		skipped, count int
 		size           common.StorageSize
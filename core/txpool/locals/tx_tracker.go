@@ -113,6 +113,38 @@ func (tracker *TxTracker) TrackAll(txs []*types.Transaction) {
 	localGauge.Update(int64(len(tracker.all)))
 }
 
+// Untrack removes a transaction from the tracked set.
+func (tracker *TxTracker) Untrack(hash common.Hash) {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	tx, ok := tracker.all[hash]
+	if !ok {
+		return
+	}
+	delete(tracker.all, hash)
+
+	addr, _ := types.Sender(tracker.signer, tx)
+	if txs := tracker.byAddr[addr]; txs != nil {
+		if cur := txs.Get(tx.Nonce()); cur != nil && cur.Hash() == hash {
+			txs.Remove(tx.Nonce())
+		}
+		if txs.Len() == 0 {
+			delete(tracker.byAddr, addr)
+		}
+	}
+	localGauge.Update(int64(len(tracker.all)))
+}
+
+// Tracked returns whether the given transaction is tracked as local.
+func (tracker *TxTracker) Tracked(hash common.Hash) bool {
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	_, ok := tracker.all[hash]
+	return ok
+}
+
 // recheck checks and returns any transactions that needs to be resubmitted.
 func (tracker *TxTracker) recheck(journalCheck bool) []*types.Transaction {
 	tracker.mu.Lock()
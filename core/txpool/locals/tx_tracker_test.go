@@ -206,3 +206,28 @@ func TestJournal(t *testing.T) {
 		t.Fatalf("Unexpected transactions being tracked, got: %d, want: %d", len(allCopy), len(txs))
 	}
 }
+
+func TestUntrack(t *testing.T) {
+	env := newTestEnv(t, 10, 0, "")
+	defer env.close()
+
+	txs := env.makeTxs(2)
+	env.tracker.TrackAll(txs)
+	for _, tx := range txs {
+		if !env.tracker.Tracked(tx.Hash()) {
+			t.Fatalf("transaction %x not tracked", tx.Hash())
+		}
+	}
+	env.tracker.Untrack(txs[1].Hash())
+	if env.tracker.Tracked(txs[1].Hash()) {
+		t.Fatal("untracked transaction still tracked")
+	}
+	if !env.tracker.Tracked(txs[0].Hash()) {
+		t.Fatal("unrelated transaction untracked")
+	}
+	// The untracked transaction must not be resubmitted anymore
+	resubmits := env.tracker.recheck(false)
+	if len(resubmits) != 1 || resubmits[0].Hash() != txs[0].Hash() {
+		t.Fatalf("unexpected resubmissions: %d", len(resubmits))
+	}
+}
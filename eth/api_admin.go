@@ -22,10 +22,16 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -141,3 +147,74 @@ func (api *AdminAPI) ImportChain(file string) (bool, error) {
 	}
 	return true, nil
 }
+
+// CreateCheckpoint creates a consistent copy of the node's chain database in
+// the given directory, which must not exist yet. The directory is laid out as
+// a datadir and can be used to start another node from. The state of the copy
+// is the last one persisted to disk, the node rewinds the chain to it on start.
+func (api *AdminAPI) CreateCheckpoint(dir string) (bool, error) {
+	if api.eth.chaindata == "" {
+		return false, errors.New("checkpoints are not supported for ephemeral nodes")
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return false, errors.New("location would overwrite an existing directory")
+	}
+	var (
+		start  = time.Now()
+		target = filepath.Join(dir, api.eth.chaindata)
+		triedb = api.eth.BlockChain().TrieDB()
+	)
+	// Write out the buffered trie nodes first, the checkpoint only contains
+	// the persistent state.
+	if err := triedb.Flush(); err != nil {
+		return false, err
+	}
+	if err := rawdb.Checkpoint(api.eth.ChainDb(), target); err != nil {
+		os.RemoveAll(dir)
+		return false, err
+	}
+	if err := triedb.CheckpointHistory(filepath.Join(target, "ancient")); err != nil {
+		os.RemoveAll(dir)
+		return false, err
+	}
+	log.Info("Created database checkpoint", "dir", dir, "elapsed", common.PrettyDuration(time.Since(start)))
+	return true, nil
+}
+
+// TxPropagationPolicyArgs are the transaction propagation policies to change,
+// the omitted ones are left unchanged.
+type TxPropagationPolicyArgs struct {
+	NoLocals     *bool   `json:"noLocals"`
+	TrustedOnly  *bool   `json:"trustedOnly"`
+	AnnounceRate *uint64 `json:"announceRate"`
+	BlobTxs      *string `json:"blobTxs"`
+}
+
+// TxPropagationPolicy returns the current transaction propagation policies.
+func (api *AdminAPI) TxPropagationPolicy() ethconfig.TxPropagationConfig {
+	return api.eth.handler.txPropagationPolicy()
+}
+
+// SetTxPropagationPolicy changes the transaction propagation policies at runtime,
+// returning the resulting policies.
+func (api *AdminAPI) SetTxPropagationPolicy(args TxPropagationPolicyArgs) (ethconfig.TxPropagationConfig, error) {
+	policy := api.eth.handler.txPropagationPolicy()
+	if args.NoLocals != nil {
+		policy.NoLocals = *args.NoLocals
+	}
+	if args.TrustedOnly != nil {
+		policy.TrustedOnly = *args.TrustedOnly
+	}
+	if args.AnnounceRate != nil {
+		policy.AnnounceRate = *args.AnnounceRate
+	}
+	if args.BlobTxs != nil {
+		policy.BlobTxs = *args.BlobTxs
+	}
+	if err := api.eth.handler.setTxPropagationPolicy(policy); err != nil {
+		return ethconfig.TxPropagationConfig{}, err
+	}
+	log.Info("Updated transaction propagation policy", "nolocals", policy.NoLocals, "trustedonly", policy.TrustedOnly,
+		"announcerate", policy.AnnounceRate, "blobtxs", policy.BlobTxs)
+	return policy, nil
+}
@@ -325,24 +325,30 @@ func (b *EthAPIBackend) SubscribeLogsEvent(ch chan<- []*types.Log) event.Subscri
 }
 
 func (b *EthAPIBackend) SendTx(ctx context.Context, signedTx *types.Transaction) error {
-	err := b.eth.txPool.Add([]*types.Transaction{signedTx}, false)[0]
-
 	// If the local transaction tracker is not configured, returns whatever
 	// returned from the txpool.
 	if b.eth.localTxTracker == nil {
-		return err
+		return b.eth.txPool.Add([]*types.Transaction{signedTx}, false)[0]
 	}
+	// Track the transaction before adding it to the pool, so that it is already
+	// known to be local when the pool announces it for propagation.
+	tracked := b.eth.localTxTracker.Tracked(signedTx.Hash())
+	b.eth.localTxTracker.Track(signedTx)
+
 	// If the transaction fails with an error indicating it is invalid, or if there is
 	// very little chance it will be accepted later (e.g., the gas price is below the
 	// configured minimum, or the sender has insufficient funds to cover the cost),
 	// propagate the error to the user.
+	err := b.eth.txPool.Add([]*types.Transaction{signedTx}, false)[0]
 	if err != nil && !locals.IsTemporaryReject(err) {
+		if !tracked {
+			b.eth.localTxTracker.Untrack(signedTx.Hash())
+		}
 		return err
 	}
 	// No error will be returned to user if the transaction fails with a temporary
 	// error and might be accepted later (e.g., the transaction pool is full).
 	// Locally submitted transactions will be resubmitted later via the local tracker.
-	b.eth.localTxTracker.Track(signedTx)
 	return nil
 }
 
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"math"
 	"math/big"
+	"path/filepath"
 	"runtime"
 	"sync"
 	"time"
@@ -35,6 +36,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/filtermaps"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state/pruner"
+	"github.com/ethereum/go-ethereum/core/stateless"
 	"github.com/ethereum/go-ethereum/core/txpool"
 	"github.com/ethereum/go-ethereum/core/txpool/blobpool"
 	"github.com/ethereum/go-ethereum/core/txpool/legacypool"
@@ -46,6 +48,7 @@ import (
 	"github.com/ethereum/go-ethereum/eth/gasprice"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
+	"github.com/ethereum/go-ethereum/eth/protocols/wit"
 	"github.com/ethereum/go-ethereum/eth/tracers"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
@@ -120,6 +123,7 @@ type Ethereum struct {
 	netRPCService *ethapi.NetAPI
 
 	p2pServer *p2p.Server
+	chaindata string // Location of the chain database relative to the datadir
 
 	lock sync.RWMutex // Protects the variadic fields (e.g. gas price and etherbase)
 
@@ -157,6 +161,14 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		AncientsDirectory: config.DatabaseFreezer,
 		EraDirectory:      config.DatabaseEra,
 		MetricsNamespace:  "eth/db/chaindata/",
+		AncientZstd:       config.DatabaseFreezerZstd,
+	}
+	if config.DatabaseFreezerRemote != "" {
+		remote, err := rawdb.OpenFreezerRemote(config.DatabaseFreezerRemote, config.DatabaseFreezerRemoteCache*1024*1024)
+		if err != nil {
+			return nil, err
+		}
+		dbOptions.AncientRemote = remote
 	}
 	chainDb, err := stack.OpenDatabaseWithOptions("chaindata", dbOptions)
 	if err != nil {
@@ -202,6 +214,9 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		discmix:         enode.NewFairMix(discmixTimeout),
 		shutdownTracker: shutdowncheck.NewShutdownTracker(chainDb),
 	}
+	if datadir := stack.DataDir(); datadir != "" {
+		eth.chaindata, _ = filepath.Rel(datadir, stack.ResolvePath("chaindata"))
+	}
 	bcVersion := rawdb.ReadDatabaseVersion(chainDb)
 	var dbVer = "<nil>"
 	if bcVersion != nil {
@@ -232,6 +247,7 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 			StateHistory:            config.StateHistory,
 			TrienodeHistory:         config.TrienodeHistory,
 			NodeFullValueCheckpoint: config.NodeFullValueCheckpoint,
+			StatePruneRetention:     config.StatePruneRetention,
 			StateScheme:             scheme,
 			ChainHistoryMode:        config.HistoryMode,
 			TxLookupLimit:           int64(min(config.TransactionHistory, math.MaxInt64)),
@@ -327,6 +343,13 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		stack.RegisterLifecycle(eth.localTxTracker)
 	}
 
+	// Locally submitted transactions can only be withheld if they are tracked
+	var isLocalTx func(common.Hash) bool
+	if eth.localTxTracker != nil {
+		isLocalTx = eth.localTxTracker.Tracked
+	} else if config.TxPropagation.NoLocals {
+		log.Warn("Local transactions are not tracked, cannot withhold them from peers")
+	}
 	// Permit the downloader to use the trie cache allowance during fast sync
 	cacheLimit := options.TrieCleanLimit + options.TrieDirtyLimit + options.SnapshotLimit
 	if eth.handler, err = newHandler(&handlerConfig{
@@ -339,6 +362,9 @@ func New(stack *node.Node, config *ethconfig.Config) (*Ethereum, error) {
 		BloomCache:     uint64(cacheLimit),
 		EventMux:       eth.eventMux,
 		RequiredBlocks: config.RequiredBlocks,
+		TxPropagation:  config.TxPropagation,
+		IsLocalTx:      isLocalTx,
+		WitnessServe:   config.WitnessServe,
 	}); err != nil {
 		return nil, err
 	}
@@ -405,6 +431,11 @@ func (s *Ethereum) APIs() []rpc.API {
 		}, {
 			Namespace: "debug",
 			Service:   NewDebugAPI(s),
+		}, {
+			// Raw database writes are only served over IPC, authrpc only serves eth and engine
+			Namespace:     "debug",
+			Service:       ethapi.NewDatabaseWriteAPI(s.chainDb, s.config.RPCAncientWrites),
+			Authenticated: true,
 		}, {
 			Namespace: "net",
 			Service:   s.netRPCService,
@@ -430,6 +461,26 @@ func (s *Ethereum) Synced() bool                       { return s.handler.synced
 func (s *Ethereum) SetSynced()                         { s.handler.enableSyncedFeatures() }
 func (s *Ethereum) ArchiveMode() bool                  { return s.config.NoPruning }
 
+// ServesWitnesses reports whether the execution witnesses of newly imported
+// blocks should be generated for serving them over the `wit` protocol.
+func (s *Ethereum) ServesWitnesses() bool { return s.config.WitnessServe }
+
+// StatelessValidation reports whether payloads that can't be imported due to
+// missing local state should be validated with witnesses fetched from peers.
+func (s *Ethereum) StatelessValidation() bool { return s.config.WitnessStateless }
+
+// AddWitness makes the execution witness of a newly imported block available
+// to the `wit` peers. It's a noop if witness serving is disabled.
+func (s *Ethereum) AddWitness(block *types.Block, witness *stateless.Witness) {
+	s.handler.addWitness(block, witness)
+}
+
+// ValidateStateless validates a block by executing it on top of an execution
+// witness retrieved from the `wit` peers, without accessing the local state.
+func (s *Ethereum) ValidateStateless(block *types.Block) error {
+	return s.handler.validateStateless(block)
+}
+
 // Protocols returns all the currently configured
 // network protocols to start.
 func (s *Ethereum) Protocols() []p2p.Protocol {
@@ -437,6 +488,9 @@ func (s *Ethereum) Protocols() []p2p.Protocol {
 	if s.config.SnapshotCache > 0 {
 		protos = append(protos, snap.MakeProtocols((*snapHandler)(s.handler))...)
 	}
+	if s.config.WitnessServe || s.config.WitnessStateless {
+		protos = append(protos, wit.MakeProtocols((*witHandler)(s.handler))...)
+	}
 	return protos
 }
 
@@ -559,9 +613,16 @@ func (s *Ethereum) setupDiscovery() error {
 	}
 
 	// Add DHT nodes from discv5.
-	if s.p2pServer.DiscoveryV5() != nil {
+	if disc := s.p2pServer.DiscoveryV5(); disc != nil {
 		filter := eth.NewNodeFilter(s.blockchain)
-		iter := enode.Filter(s.p2pServer.DiscoveryV5().RandomNodes(), filter)
+		iter := enode.Filter(disc.RandomNodes(), filter)
+		iter = enode.NewBufferIter(iter, discoveryPrefetchBuffer)
+		s.discmix.AddSource(iter)
+
+		// Advertise the chain topic and add nodes found through it.
+		topic := eth.NewTopic(s.blockchain)
+		disc.RegisterTopic(topic)
+		iter = enode.Filter(disc.TopicNodes(topic), filter)
 		iter = enode.NewBufferIter(iter, discoveryPrefetchBuffer)
 		s.discmix.AddSource(iter)
 	}
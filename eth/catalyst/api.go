@@ -30,6 +30,7 @@ import (
 	"github.com/ethereum/go-ethereum/beacon/engine"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -67,6 +68,10 @@ const (
 	// have lead to some bad ancestor block. It's just an OOM protection.
 	invalidTipsetsCap = 512
 
+	// statelessValidCap is the max number of recent payloads tracked that have
+	// been validated statelessly on top of a validated parent.
+	statelessValidCap = 128
+
 	// beaconUpdateStartupTimeout is the time to wait for a beacon client to get
 	// attached before starting to issue warnings.
 	beaconUpdateStartupTimeout = 30 * time.Second
@@ -110,6 +115,11 @@ type ConsensusAPI struct {
 	invalidTipsets    map[common.Hash]*types.Header // Ephemeral cache to track invalid tipsets and their bad ancestor
 	invalidLock       sync.Mutex                    // Protects the invalid maps from concurrent access
 
+	// Payloads validated statelessly on top of a validated parent are valid
+	// themselves, so they can anchor the stateless validation of their own
+	// descendants while the local state is unavailable.
+	statelessValid *lru.Cache[common.Hash, struct{}]
+
 	// Geth can appear to be stuck or do strange things if the beacon client is
 	// offline or is sending us strange data. Stash some update stats away so
 	// that we can warn the user and not have them open issues on our tracker.
@@ -143,6 +153,7 @@ func newConsensusAPIWithoutHeartbeat(eth *eth.Ethereum) *ConsensusAPI {
 		localBlocks:       newPayloadQueue(),
 		invalidBlocksHits: make(map[common.Hash]int),
 		invalidTipsets:    make(map[common.Hash]*types.Header),
+		statelessValid:    lru.NewCache[common.Hash, struct{}](statelessValidCap),
 	}
 	eth.Downloader().SetBadBlockCallback(api.setInvalidAncestor)
 	return api
@@ -785,11 +796,11 @@ func (api *ConsensusAPI) newPayload(params engine.ExecutableData, versionedHashe
 	if !api.eth.BlockChain().HasBlockAndState(block.ParentHash(), block.NumberU64()-1) {
 		api.remoteBlocks.put(block.Hash(), block.Header())
 		log.Warn("State not available, ignoring new payload")
-		return engine.PayloadStatusV1{Status: engine.ACCEPTED}, nil
+		return api.validatePayloadStateless(block, engine.ACCEPTED), nil
 	}
 	log.Trace("Inserting block without sethead", "hash", block.Hash(), "number", block.Number())
 	start := time.Now()
-	proofs, err := api.eth.BlockChain().InsertBlockWithoutSetHead(block, witness)
+	proofs, err := api.eth.BlockChain().InsertBlockWithoutSetHead(block, witness || api.eth.ServesWitnesses())
 	processingTime := time.Since(start)
 	if err != nil {
 		log.Warn("NewPayload: inserting block failed", "error", err)
@@ -810,9 +821,13 @@ func (api *ConsensusAPI) newPayload(params engine.ExecutableData, versionedHashe
 		ProcessingTime: processingTime,
 	})
 
+	// Make the witness available to the network if we're serving them
+	if proofs != nil {
+		api.eth.AddWitness(block, proofs)
+	}
 	// If witness collection was requested, inject that into the result too
 	var ow *hexutil.Bytes
-	if proofs != nil {
+	if witness && proofs != nil {
 		ow = new(hexutil.Bytes)
 		*ow, _ = rlp.EncodeToBytes(proofs)
 	}
@@ -839,7 +854,7 @@ func (api *ConsensusAPI) delayPayloadImport(block *types.Block) engine.PayloadSt
 	err := api.eth.Downloader().BeaconExtend(block.Header())
 	if err == nil {
 		log.Debug("Payload accepted for sync extension", "number", block.NumberU64(), "hash", block.Hash())
-		return engine.PayloadStatusV1{Status: engine.SYNCING}
+		return api.validatePayloadStateless(block, engine.SYNCING)
 	}
 	// Either no beacon sync was started yet, or it rejected the delivered
 	// payload as non-integrate on top of the existing sync. We'll just
@@ -856,7 +871,51 @@ func (api *ConsensusAPI) delayPayloadImport(block *types.Block) engine.PayloadSt
 		// and cannot afford concurrent out-if-band modifications via imports.
 		log.Warn("Ignoring payload while snap syncing", "number", block.NumberU64(), "hash", block.Hash(), "reason", err)
 	}
-	return engine.PayloadStatusV1{Status: engine.SYNCING}
+	return api.validatePayloadStateless(block, engine.SYNCING)
+}
+
+// validatePayloadStateless validates a payload which can't be imported locally
+// by executing it on top of a witness retrieved from the `wit` peers, if that is
+// enabled. A stateless run only proves the transition from the parent's claimed
+// state, so the payload is only reported valid if its parent has been validated
+// locally too. Otherwise, or if the validation fails, the given fallback status
+// is returned. A failure might just as well be caused by the remote peers, so it
+// doesn't prove the payload invalid either.
+func (api *ConsensusAPI) validatePayloadStateless(block *types.Block, fallback string) engine.PayloadStatusV1 {
+	if !api.eth.StatelessValidation() {
+		return engine.PayloadStatusV1{Status: fallback}
+	}
+	start := time.Now()
+	if err := api.eth.ValidateStateless(block); err != nil {
+		statelessFailedCounter.Inc(1)
+		log.Warn("Stateless payload validation failed", "number", block.NumberU64(), "hash", block.Hash(), "err", err)
+		return engine.PayloadStatusV1{Status: fallback}
+	}
+	if !api.statelessAnchored(block) {
+		statelessUnanchoredCounter.Inc(1)
+		log.Info("Validated payload statelessly on unvalidated parent", "number", block.NumberU64(), "hash", block.Hash(), "elapsed", common.PrettyDuration(time.Since(start)))
+		return engine.PayloadStatusV1{Status: fallback}
+	}
+	statelessValidCounter.Inc(1)
+	log.Info("Validated payload statelessly", "number", block.NumberU64(), "hash", block.Hash(), "elapsed", common.PrettyDuration(time.Since(start)))
+
+	hash := block.Hash()
+	api.statelessValid.Add(hash, struct{}{})
+	return engine.PayloadStatusV1{Status: engine.VALID, LatestValidHash: &hash}
+}
+
+// statelessAnchored reports whether the parent of a payload has been validated
+// locally, either by being part of the canonical chain up to the current head
+// or by having been validated statelessly on top of such a block.
+func (api *ConsensusAPI) statelessAnchored(block *types.Block) bool {
+	if api.statelessValid.Contains(block.ParentHash()) {
+		return true
+	}
+	var (
+		chain  = api.eth.BlockChain()
+		number = block.NumberU64() - 1
+	)
+	return number <= chain.CurrentBlock().Number.Uint64() && chain.GetCanonicalHash(number) == block.ParentHash()
 }
 
 // setInvalidAncestor is a callback for the downloader to notify us if a bad block
@@ -1731,6 +1731,37 @@ func TestWitnessCreationAndConsumption(t *testing.T) {
 	}
 }
 
+// Tests that only payloads on top of a locally validated parent are considered
+// anchored for reporting a stateless validation as valid.
+func TestStatelessAnchored(t *testing.T) {
+	genesis, blocks := generateMergeChain(10, false)
+	n, ethservice := startEthService(t, genesis, blocks[:9])
+	defer n.Close()
+
+	api := newConsensusAPIWithoutHeartbeat(ethservice)
+
+	child := func(parent *types.Header) *types.Block {
+		return types.NewBlockWithHeader(&types.Header{ParentHash: parent.Hash(), Number: new(big.Int).Add(parent.Number, common.Big1)})
+	}
+	// Children of canonical blocks up to the head are anchored
+	if !api.statelessAnchored(blocks[9]) || !api.statelessAnchored(blocks[5]) {
+		t.Fatal("payload on canonical parent not anchored")
+	}
+	// Children of unknown blocks are not, unless they were validated statelessly
+	unknown := child(blocks[9].Header())
+	if api.statelessAnchored(unknown) {
+		t.Fatal("payload on unknown parent anchored")
+	}
+	api.statelessValid.Add(blocks[9].Hash(), struct{}{})
+	if !api.statelessAnchored(unknown) {
+		t.Fatal("payload on statelessly validated parent not anchored")
+	}
+	// Stateless validation is disabled, so the fallback status must be returned
+	if res := api.validatePayloadStateless(unknown, engine.ACCEPTED); res.Status != engine.ACCEPTED {
+		t.Fatalf("wrong status with stateless validation disabled: %v", res.Status)
+	}
+}
+
 // TestGetClientVersion verifies the expected version info is returned.
 func TestGetClientVersion(t *testing.T) {
 	genesis, preMergeBlocks := generateMergeChain(10, false)
@@ -34,4 +34,14 @@ var (
 
 	// Number of times getBlobsV3 responded with some, but not all, blobs
 	getBlobsRequestPartialHit = metrics.NewRegisteredCounter("engine/getblobs/partial", nil)
+
+	// Number of payloads validated statelessly on top of a validated parent
+	statelessValidCounter = metrics.NewRegisteredCounter("engine/stateless/valid", nil)
+
+	// Number of payloads validated statelessly on top of an unvalidated parent,
+	// which can't be reported as valid
+	statelessUnanchoredCounter = metrics.NewRegisteredCounter("engine/stateless/unanchored", nil)
+
+	// Number of payloads that couldn't be validated statelessly
+	statelessFailedCounter = metrics.NewRegisteredCounter("engine/stateless/failed", nil)
 )
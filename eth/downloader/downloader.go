@@ -1111,6 +1111,10 @@ func (d *Downloader) DeliverSnapPacket(peer *snap.Peer, packet snap.Packet) erro
 	case *snap.TrieNodesPacket:
 		return d.SnapSyncer.OnTrieNodes(peer, packet.ID, packet.Nodes)
 
+	case *snap.StateDiffPacket:
+		hashes, accounts, slotHashes, slots := packet.Unpack()
+		return d.SnapSyncer.OnStateDiff(peer, packet.ID, hashes, accounts, slotHashes, slots, packet.Complete)
+
 	default:
 		return fmt.Errorf("unexpected snap packet type: %T", packet)
 	}
@@ -366,6 +366,23 @@ func (dlp *downloadTesterPeer) RequestTrieNodes(id uint64, root common.Hash, pat
 	return nil
 }
 
+// RequestStateDiff fetches a batch of the state changes between two state roots,
+// starting with the origin account.
+func (dlp *downloadTesterPeer) RequestStateDiff(id uint64, from, to, origin common.Hash, bytes uint64) error {
+	req := &snap.GetStateDiffPacket{
+		ID:     id,
+		From:   from,
+		To:     to,
+		Origin: origin,
+		Bytes:  bytes,
+	}
+	accounts, complete := snap.ServiceGetStateDiffQuery(dlp.chain, req)
+	res := &snap.StateDiffPacket{ID: id, Accounts: accounts, Complete: complete}
+	hashes, bodies, slotHashes, slots := res.Unpack()
+	go dlp.dl.downloader.SnapSyncer.OnStateDiff(dlp, id, hashes, bodies, slotHashes, slots, complete)
+	return nil
+}
+
 // Log retrieves the peer's own contextual logger.
 func (dlp *downloadTesterPeer) Log() log.Logger {
 	return log.New("peer", dlp.id)
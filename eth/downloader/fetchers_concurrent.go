@@ -264,6 +264,7 @@ func (d *Downloader) concurrentFetch(queue typedQueue) error {
 			// overloading it further.
 			delete(pending, req.Peer)
 			stales[req.Peer] = req
+			req.ReportTimeout()
 
 			timeouts.Pop() // Popping an item will reorder indices in `ordering`, delete after, otherwise will resurrect!
 			if timeouts.Size() > 0 {
@@ -88,15 +88,26 @@ func (q *receiptQueue) request(peer *peerConnection, req *fetchRequest, resCh ch
 // deliver is responsible for taking a generic response packet from the concurrent
 // fetcher, unpacking the receipt data and delivering it to the downloader's queue.
 func (q *receiptQueue) deliver(peer *peerConnection, packet *eth.Response) (int, error) {
-	receipts := *packet.Res.(*eth.ReceiptsRLPResponse)
+	var (
+		receipts   eth.ReceiptsRLPResponse
+		incomplete bool // Whether the block after the delivered ones was partially retrieved
+	)
+	switch res := packet.Res.(type) {
+	case *eth.ReceiptsRLPResponse:
+		receipts = *res
+	case *eth.ReceiptsRLPResponse70:
+		receipts, incomplete = res.ReceiptsRLPResponse, res.LastBlockIncomplete
+	}
 	hashes := packet.Meta.([]common.Hash) // {receipt hashes}
 
-	accepted, err := q.queue.DeliverReceipts(peer.id, receipts, hashes)
+	accepted, err := q.queue.DeliverReceipts(peer.id, receipts, hashes, incomplete)
 	switch {
+	case err == nil && len(receipts) == 0 && incomplete:
+		peer.log.Trace("Delivered partial block receipts")
 	case err == nil && len(receipts) == 0:
 		peer.log.Trace("Requested receipts delivered")
 	case err == nil:
-		peer.log.Trace("Delivered new batch of receipts", "count", len(receipts), "accepted", accepted)
+		peer.log.Trace("Delivered new batch of receipts", "count", len(receipts), "accepted", accepted, "incomplete", incomplete)
 	default:
 		peer.log.Debug("Failed to deliver retrieved receipts", "err", err)
 	}
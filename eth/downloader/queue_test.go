@@ -193,6 +193,38 @@ func TestBasics(t *testing.T) {
 	}
 }
 
+// Tests that a receipt delivery which only partially retrieved the receipts of
+// its first block doesn't mark the peer as lacking the requested blocks.
+func TestPartialReceiptsDelivery(t *testing.T) {
+	q := newQueue(10, 10)
+	q.Prepare(1, SnapSync)
+
+	headers := chain.headers()
+	hashes := make([]common.Hash, len(headers))
+	for i, header := range headers {
+		hashes[i] = header.Hash()
+	}
+	q.Schedule(headers, hashes, 1)
+	pending := q.PendingReceipts()
+
+	for _, incomplete := range []bool{true, false} {
+		peer := dummyPeer("peer")
+		fetchReq, _, _ := q.ReserveReceipts(peer, 50)
+		if fetchReq == nil {
+			t.Fatal("no receipts reserved")
+		}
+		if _, err := q.DeliverReceipts(peer.id, nil, nil, incomplete); err != nil {
+			t.Fatalf("failed to deliver receipts: %v", err)
+		}
+		if have := q.PendingReceipts(); have != pending {
+			t.Errorf("pending receipt mismatch: have %d, want %d", have, pending)
+		}
+		if have := peer.Lacks(fetchReq.Headers[0].Hash()); have == incomplete {
+			t.Errorf("lacking mismatch for incomplete delivery %v: have %v", incomplete, have)
+		}
+	}
+}
+
 func TestEmptyBlocks(t *testing.T) {
 	numOfBlocks := len(emptyChain.blocks)
 
@@ -368,7 +400,7 @@ func XTestDelivery(t *testing.T) {
 				for i, receipt := range rcs {
 					hashes[i] = types.DeriveSha(receipt, hasher)
 				}
-				_, err := q.DeliverReceipts(peer.id, types.EncodeBlockReceiptLists(rcs), hashes)
+				_, err := q.DeliverReceipts(peer.id, types.EncodeBlockReceiptLists(rcs), hashes, false)
 				if err != nil {
 					fmt.Printf("delivered %d receipts %v\n", len(rcs), err)
 				}
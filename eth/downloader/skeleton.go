@@ -842,6 +842,7 @@ func (s *skeleton) executeTask(peer *peerConnection, req *headerRequest) {
 		peer.log.Warn("Header request timed out, dropping peer", "elapsed", ttl)
 		headerTimeoutMeter.Mark(1)
 		s.peers.rates.Update(peer.id, eth.BlockHeadersMsg, 0, 0)
+		netreq.ReportTimeout()
 		s.scheduleRevertRequest(req)
 
 		// At this point we either need to drop the offending peer, or we need a
@@ -17,6 +17,7 @@
 package eth
 
 import (
+	"maps"
 	mrand "math/rand"
 	"slices"
 	"sync"
@@ -40,6 +41,9 @@ const (
 	// dropping when no more peers can be added. Larger numbers result in more
 	// aggressive drop behavior.
 	peerDropThreshold = 0
+	// Peers whose reputation is within this margin of the worst droppable peer
+	// are considered equally bad, one of them being dropped randomly.
+	peerDropScoreMargin = 1.0
 )
 
 var (
@@ -51,8 +55,9 @@ var (
 
 // dropper monitors the state of the peer pool and makes changes as follows:
 //   - during sync the Downloader handles peer connections, so dropper is disabled
-//   - if not syncing and the peer count is close to the limit, it drops peers
-//     randomly every peerDropInterval to make space for new peers
+//   - if not syncing and the peer count is close to the limit, it drops the peer
+//     with the worst reputation every peerDropInterval to make space for new
+//     peers, choosing randomly among equally bad ones
 //   - peers are dropped separately from the inboud pool and from the dialed pool
 type dropper struct {
 	maxDialPeers    int // maximum number of dialed peers
@@ -103,7 +108,8 @@ func (cm *dropper) Stop() {
 	cm.wg.Wait()
 }
 
-// dropRandomPeer selects one of the peers randomly and drops it from the peer pool.
+// dropRandomPeer selects one of the peers with the worst reputation randomly and
+// drops it from the peer pool.
 func (cm *dropper) dropRandomPeer() bool {
 	peers := cm.peersFunc()
 	var numInbound int
@@ -126,9 +132,18 @@ func (cm *dropper) dropRandomPeer() bool {
 
 	droppable := slices.DeleteFunc(peers, selectDoNotDrop)
 	if len(droppable) > 0 {
+		// Narrow the droppable peers down to the ones with the worst reputation
+		scores := make(map[*p2p.Peer]float64, len(droppable))
+		for _, p := range droppable {
+			scores[p] = p.Reputation().Score()
+		}
+		worst := slices.Min(slices.Collect(maps.Values(scores)))
+		droppable = slices.DeleteFunc(droppable, func(p *p2p.Peer) bool {
+			return scores[p] > worst+peerDropScoreMargin
+		})
 		p := droppable[mrand.Intn(len(droppable))]
 		log.Debug("Dropping random peer", "inbound", p.Inbound(),
-			"id", p.ID(), "duration", common.PrettyDuration(p.Lifetime()), "peercountbefore", len(peers))
+			"id", p.ID(), "duration", common.PrettyDuration(p.Lifetime()), "score", scores[p], "peercountbefore", len(peers))
 		p.Disconnect(p2p.DiscUselessPeer)
 		if p.Inbound() {
 			droppedInbound.Mark(1)
@@ -19,6 +19,7 @@ package ethconfig
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -50,33 +51,71 @@ var FullNodeGPO = gasprice.Config{
 
 // Defaults contains default settings for use on the Ethereum main net.
 var Defaults = Config{
-	HistoryMode:             history.KeepAll,
-	SyncMode:                SnapSync,
-	NetworkId:               0, // enable auto configuration of networkID == chainID
-	TxLookupLimit:           2350000,
-	TransactionHistory:      2350000,
-	LogHistory:              2350000,
-	StateHistory:            pathdb.Defaults.StateHistory,
-	TrienodeHistory:         pathdb.Defaults.TrienodeHistory,
-	NodeFullValueCheckpoint: pathdb.Defaults.FullValueCheckpoint,
-	DatabaseCache:           512,
-	TrieCleanCache:          154,
-	TrieDirtyCache:          256,
-	TrieTimeout:             60 * time.Minute,
-	SnapshotCache:           102,
-	FilterLogCacheSize:      32,
-	LogQueryLimit:           1000,
-	Miner:                   miner.DefaultConfig,
-	TxPool:                  legacypool.DefaultConfig,
-	BlobPool:                blobpool.DefaultConfig,
-	RPCGasCap:               50000000,
-	RPCEVMTimeout:           5 * time.Second,
-	GPO:                     FullNodeGPO,
-	RPCTxFeeCap:             1, // 1 ether
-	TxSyncDefaultTimeout:    20 * time.Second,
-	TxSyncMaxTimeout:        1 * time.Minute,
-	SlowBlockThreshold:      time.Second * 2,
-	RangeLimit:              0,
+	HistoryMode:                history.KeepAll,
+	SyncMode:                   SnapSync,
+	NetworkId:                  0, // enable auto configuration of networkID == chainID
+	TxLookupLimit:              2350000,
+	TransactionHistory:         2350000,
+	LogHistory:                 2350000,
+	StateHistory:               pathdb.Defaults.StateHistory,
+	TrienodeHistory:            pathdb.Defaults.TrienodeHistory,
+	NodeFullValueCheckpoint:    pathdb.Defaults.FullValueCheckpoint,
+	DatabaseCache:              512,
+	DatabaseFreezerRemoteCache: 256,
+	TrieCleanCache:             154,
+	TrieDirtyCache:             256,
+	TrieTimeout:                60 * time.Minute,
+	SnapshotCache:              102,
+	FilterLogCacheSize:         32,
+	LogQueryLimit:              1000,
+	Miner:                      miner.DefaultConfig,
+	TxPool:                     legacypool.DefaultConfig,
+	BlobPool:                   blobpool.DefaultConfig,
+	TxPropagation:              DefaultTxPropagationConfig,
+	TraceStoreSize:             1024,
+	WasmTracerGasLimit:         1_000_000_000,
+	WasmTracerMemory:           64,
+	RPCGasCap:                  50000000,
+	RPCEVMTimeout:              5 * time.Second,
+	GPO:                        FullNodeGPO,
+	RPCTxFeeCap:                1, // 1 ether
+	TxSyncDefaultTimeout:       20 * time.Second,
+	TxSyncMaxTimeout:           1 * time.Minute,
+	SlowBlockThreshold:         time.Second * 2,
+	RangeLimit:                 0,
+}
+
+// Propagation modes of blob transactions.
+const (
+	BlobTxsAnnounce = "announce" // Announce blob transactions to all peers
+	BlobTxsTrusted  = "trusted"  // Announce blob transactions to trusted peers only
+	BlobTxsNone     = "none"     // Don't propagate blob transactions at all
+)
+
+// TxPropagationConfig contains the policies for propagating transactions to the
+// connected peers.
+type TxPropagationConfig struct {
+	NoLocals     bool   `json:"noLocals"`     // Whether to withhold locally submitted transactions from all peers (private mempool)
+	TrustedOnly  bool   `json:"trustedOnly"`  // Whether to propagate transactions to trusted peers only
+	AnnounceRate uint64 `json:"announceRate"` // Maximum number of transactions announced to a peer per second (0 = unlimited)
+	BlobTxs      string `json:"blobTxs"`      // Propagation mode of blob transactions (announce, trusted or none)
+}
+
+// DefaultTxPropagationConfig contains the default transaction propagation
+// policies, propagating all transactions to all peers.
+var DefaultTxPropagationConfig = TxPropagationConfig{
+	BlobTxs: BlobTxsAnnounce,
+}
+
+// Validate checks the transaction propagation policies for unsupported values.
+// An empty blob transaction propagation mode is treated as announce.
+func (c *TxPropagationConfig) Validate() error {
+	switch c.BlobTxs {
+	case "", BlobTxsAnnounce, BlobTxsTrusted, BlobTxsNone:
+		return nil
+	default:
+		return fmt.Errorf("unknown blob transaction propagation mode %q", c.BlobTxs)
+	}
 }
 
 //go:generate go run github.com/fjl/gencodec -type Config -formats toml -out gen_config.go
@@ -120,6 +159,11 @@ type Config struct {
 	// are stored in diff mode for storage compression.
 	NodeFullValueCheckpoint uint32 `toml:",omitempty"`
 
+	// Number of recently committed states retained by the online state pruner,
+	// only relevant in hash scheme. States are committed to disk when the trie
+	// cache is flushed, not at every block. If set to 0, online pruning is disabled.
+	StatePruneRetention int `toml:",omitempty"`
+
 	// State scheme represents the scheme used to store ethereum states and trie
 	// nodes on top. It can be 'hash', 'path', or none which means use the scheme
 	// consistent with persistent state.
@@ -141,6 +185,12 @@ type Config struct {
 	DatabaseFreezer    string
 	DatabaseEra        string
 
+	// Object store for the sealed chain history files, and the size of its
+	// local read cache in megabytes.
+	DatabaseFreezerRemote      string `toml:",omitempty"`
+	DatabaseFreezerRemoteCache int    `toml:",omitempty"`
+	DatabaseFreezerZstd        bool   `toml:",omitempty"`
+
 	TrieCleanCache int
 	TrieDirtyCache int
 	TrieTimeout    time.Duration
@@ -161,6 +211,9 @@ type Config struct {
 	TxPool   legacypool.Config
 	BlobPool blobpool.Config
 
+	// Transaction propagation options
+	TxPropagation TxPropagationConfig
+
 	// Gas Price Oracle options
 	GPO gasprice.Config
 
@@ -173,6 +226,10 @@ type Config struct {
 	// Generate execution witnesses and self-check against them (testing purpose)
 	StatelessSelfValidation bool
 
+	// Execution witness sharing options
+	WitnessServe     bool // Serves the witnesses of recently imported blocks over the `wit` protocol
+	WitnessStateless bool // Validates payloads without local state using witnesses fetched from `wit` peers
+
 	// Enables tracking of state size
 	EnableStateSizeTracking bool
 
@@ -180,6 +237,16 @@ type Config struct {
 	VMTrace           string
 	VMTraceJsonConfig string
 
+	// Trace store options
+	TraceStore        bool     // Enables the persistent store of finalized block traces
+	TraceStoreSize    uint64   // Maximum size of the trace store in megabytes
+	TraceStoreTracers []string // Tracers to run on newly finalized blocks
+
+	// WASM tracer options
+	WasmTracerDir      string // Directory of WASM tracers to load into the tracer directory
+	WasmTracerGasLimit uint64 // Maximum gas a single trace of a WASM tracer may consume
+	WasmTracerMemory   uint32 // Maximum linear memory of a WASM tracer instance in megabytes
+
 	// RPCGasCap is the global gas cap for eth-call variants.
 	RPCGasCap uint64
 
@@ -190,6 +257,9 @@ type Config struct {
 	// send-transaction variants. The unit is ether.
 	RPCTxFeeCap float64
 
+	// RPCAncientWrites allows the debug_db* methods to modify the ancient store.
+	RPCAncientWrites bool `toml:",omitempty"`
+
 	// OverrideOsaka (TODO: remove after the fork)
 	OverrideOsaka *uint64 `toml:",omitempty"`
 
@@ -17,57 +17,71 @@ import (
 // MarshalTOML marshals as TOML.
 func (c Config) MarshalTOML() (interface{}, error) {
 	type Config struct {
-		Genesis                 *core.Genesis `toml:",omitempty"`
-		NetworkId               uint64
-		SyncMode                SyncMode
-		HistoryMode             history.HistoryMode
-		EthDiscoveryURLs        []string
-		SnapDiscoveryURLs       []string
-		NoPruning               bool
-		NoPrefetch              bool
-		TxLookupLimit           uint64 `toml:",omitempty"`
-		TransactionHistory      uint64 `toml:",omitempty"`
-		LogHistory              uint64 `toml:",omitempty"`
-		LogNoHistory            bool   `toml:",omitempty"`
-		LogExportCheckpoints    string
-		StateHistory            uint64                 `toml:",omitempty"`
-		TrienodeHistory         int64                  `toml:",omitempty"`
-		NodeFullValueCheckpoint uint32                 `toml:",omitempty"`
-		StateScheme             string                 `toml:",omitempty"`
-		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
-		SlowBlockThreshold      time.Duration          `toml:",omitempty"`
-		SkipBcVersionCheck      bool                   `toml:"-"`
-		DatabaseHandles         int                    `toml:"-"`
-		DatabaseCache           int
-		DatabaseFreezer         string
-		DatabaseEra             string
-		TrieCleanCache          int
-		TrieDirtyCache          int
-		TrieTimeout             time.Duration
-		SnapshotCache           int
-		Preimages               bool
-		FilterLogCacheSize      int
-		LogQueryLimit           int
-		Miner                   miner.Config
-		TxPool                  legacypool.Config
-		BlobPool                blobpool.Config
-		GPO                     gasprice.Config
-		EnablePreimageRecording bool
-		EnableWitnessStats      bool
-		StatelessSelfValidation bool
-		EnableStateSizeTracking bool
-		VMTrace                 string
-		VMTraceJsonConfig       string
-		RPCGasCap               uint64
-		RPCEVMTimeout           time.Duration
-		RPCTxFeeCap             float64
-		OverrideOsaka           *uint64       `toml:",omitempty"`
-		OverrideBPO1            *uint64       `toml:",omitempty"`
-		OverrideBPO2            *uint64       `toml:",omitempty"`
-		OverrideVerkle          *uint64       `toml:",omitempty"`
-		TxSyncDefaultTimeout    time.Duration `toml:",omitempty"`
-		TxSyncMaxTimeout        time.Duration `toml:",omitempty"`
-		RangeLimit              uint64        `toml:",omitempty"`
+		Genesis                    *core.Genesis `toml:",omitempty"`
+		NetworkId                  uint64
+		SyncMode                   SyncMode
+		HistoryMode                history.HistoryMode
+		EthDiscoveryURLs           []string
+		SnapDiscoveryURLs          []string
+		NoPruning                  bool
+		NoPrefetch                 bool
+		TxLookupLimit              uint64 `toml:",omitempty"`
+		TransactionHistory         uint64 `toml:",omitempty"`
+		LogHistory                 uint64 `toml:",omitempty"`
+		LogNoHistory               bool   `toml:",omitempty"`
+		LogExportCheckpoints       string
+		StateHistory               uint64                 `toml:",omitempty"`
+		TrienodeHistory            int64                  `toml:",omitempty"`
+		NodeFullValueCheckpoint    uint32                 `toml:",omitempty"`
+		StatePruneRetention        int                    `toml:",omitempty"`
+		StateScheme                string                 `toml:",omitempty"`
+		RequiredBlocks             map[uint64]common.Hash `toml:"-"`
+		SlowBlockThreshold         time.Duration          `toml:",omitempty"`
+		SkipBcVersionCheck         bool                   `toml:"-"`
+		DatabaseHandles            int                    `toml:"-"`
+		DatabaseCache              int
+		DatabaseFreezer            string
+		DatabaseEra                string
+		DatabaseFreezerRemote      string `toml:",omitempty"`
+		DatabaseFreezerRemoteCache int    `toml:",omitempty"`
+		DatabaseFreezerZstd        bool   `toml:",omitempty"`
+		TrieCleanCache             int
+		TrieDirtyCache             int
+		TrieTimeout                time.Duration
+		SnapshotCache              int
+		Preimages                  bool
+		FilterLogCacheSize         int
+		LogQueryLimit              int
+		Miner                      miner.Config
+		TxPool                     legacypool.Config
+		BlobPool                   blobpool.Config
+		TxPropagation              TxPropagationConfig
+		GPO                        gasprice.Config
+		EnablePreimageRecording    bool
+		EnableWitnessStats         bool
+		StatelessSelfValidation    bool
+		WitnessServe               bool
+		WitnessStateless           bool
+		EnableStateSizeTracking    bool
+		VMTrace                    string
+		VMTraceJsonConfig          string
+		TraceStore                 bool
+		TraceStoreSize             uint64
+		TraceStoreTracers          []string
+		WasmTracerDir              string
+		WasmTracerGasLimit         uint64
+		WasmTracerMemory           uint32
+		RPCGasCap                  uint64
+		RPCEVMTimeout              time.Duration
+		RPCTxFeeCap                float64
+		RPCAncientWrites           bool          `toml:",omitempty"`
+		OverrideOsaka              *uint64       `toml:",omitempty"`
+		OverrideBPO1               *uint64       `toml:",omitempty"`
+		OverrideBPO2               *uint64       `toml:",omitempty"`
+		OverrideVerkle             *uint64       `toml:",omitempty"`
+		TxSyncDefaultTimeout       time.Duration `toml:",omitempty"`
+		TxSyncMaxTimeout           time.Duration `toml:",omitempty"`
+		RangeLimit                 uint64        `toml:",omitempty"`
 	}
 	var enc Config
 	enc.Genesis = c.Genesis
@@ -86,6 +100,7 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.StateHistory = c.StateHistory
 	enc.TrienodeHistory = c.TrienodeHistory
 	enc.NodeFullValueCheckpoint = c.NodeFullValueCheckpoint
+	enc.StatePruneRetention = c.StatePruneRetention
 	enc.StateScheme = c.StateScheme
 	enc.RequiredBlocks = c.RequiredBlocks
 	enc.SlowBlockThreshold = c.SlowBlockThreshold
@@ -94,6 +109,9 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.DatabaseCache = c.DatabaseCache
 	enc.DatabaseFreezer = c.DatabaseFreezer
 	enc.DatabaseEra = c.DatabaseEra
+	enc.DatabaseFreezerRemote = c.DatabaseFreezerRemote
+	enc.DatabaseFreezerRemoteCache = c.DatabaseFreezerRemoteCache
+	enc.DatabaseFreezerZstd = c.DatabaseFreezerZstd
 	enc.TrieCleanCache = c.TrieCleanCache
 	enc.TrieDirtyCache = c.TrieDirtyCache
 	enc.TrieTimeout = c.TrieTimeout
@@ -104,16 +122,26 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.Miner = c.Miner
 	enc.TxPool = c.TxPool
 	enc.BlobPool = c.BlobPool
+	enc.TxPropagation = c.TxPropagation
 	enc.GPO = c.GPO
 	enc.EnablePreimageRecording = c.EnablePreimageRecording
 	enc.EnableWitnessStats = c.EnableWitnessStats
 	enc.StatelessSelfValidation = c.StatelessSelfValidation
+	enc.WitnessServe = c.WitnessServe
+	enc.WitnessStateless = c.WitnessStateless
 	enc.EnableStateSizeTracking = c.EnableStateSizeTracking
 	enc.VMTrace = c.VMTrace
 	enc.VMTraceJsonConfig = c.VMTraceJsonConfig
+	enc.TraceStore = c.TraceStore
+	enc.TraceStoreSize = c.TraceStoreSize
+	enc.TraceStoreTracers = c.TraceStoreTracers
+	enc.WasmTracerDir = c.WasmTracerDir
+	enc.WasmTracerGasLimit = c.WasmTracerGasLimit
+	enc.WasmTracerMemory = c.WasmTracerMemory
 	enc.RPCGasCap = c.RPCGasCap
 	enc.RPCEVMTimeout = c.RPCEVMTimeout
 	enc.RPCTxFeeCap = c.RPCTxFeeCap
+	enc.RPCAncientWrites = c.RPCAncientWrites
 	enc.OverrideOsaka = c.OverrideOsaka
 	enc.OverrideBPO1 = c.OverrideBPO1
 	enc.OverrideBPO2 = c.OverrideBPO2
@@ -127,57 +155,71 @@ func (c Config) MarshalTOML() (interface{}, error) {
 // UnmarshalTOML unmarshals from TOML.
 func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	type Config struct {
-		Genesis                 *core.Genesis `toml:",omitempty"`
-		NetworkId               *uint64
-		SyncMode                *SyncMode
-		HistoryMode             *history.HistoryMode
-		EthDiscoveryURLs        []string
-		SnapDiscoveryURLs       []string
-		NoPruning               *bool
-		NoPrefetch              *bool
-		TxLookupLimit           *uint64 `toml:",omitempty"`
-		TransactionHistory      *uint64 `toml:",omitempty"`
-		LogHistory              *uint64 `toml:",omitempty"`
-		LogNoHistory            *bool   `toml:",omitempty"`
-		LogExportCheckpoints    *string
-		StateHistory            *uint64                `toml:",omitempty"`
-		TrienodeHistory         *int64                 `toml:",omitempty"`
-		NodeFullValueCheckpoint *uint32                `toml:",omitempty"`
-		StateScheme             *string                `toml:",omitempty"`
-		RequiredBlocks          map[uint64]common.Hash `toml:"-"`
-		SlowBlockThreshold      *time.Duration         `toml:",omitempty"`
-		SkipBcVersionCheck      *bool                  `toml:"-"`
-		DatabaseHandles         *int                   `toml:"-"`
-		DatabaseCache           *int
-		DatabaseFreezer         *string
-		DatabaseEra             *string
-		TrieCleanCache          *int
-		TrieDirtyCache          *int
-		TrieTimeout             *time.Duration
-		SnapshotCache           *int
-		Preimages               *bool
-		FilterLogCacheSize      *int
-		LogQueryLimit           *int
-		Miner                   *miner.Config
-		TxPool                  *legacypool.Config
-		BlobPool                *blobpool.Config
-		GPO                     *gasprice.Config
-		EnablePreimageRecording *bool
-		EnableWitnessStats      *bool
-		StatelessSelfValidation *bool
-		EnableStateSizeTracking *bool
-		VMTrace                 *string
-		VMTraceJsonConfig       *string
-		RPCGasCap               *uint64
-		RPCEVMTimeout           *time.Duration
-		RPCTxFeeCap             *float64
-		OverrideOsaka           *uint64        `toml:",omitempty"`
-		OverrideBPO1            *uint64        `toml:",omitempty"`
-		OverrideBPO2            *uint64        `toml:",omitempty"`
-		OverrideVerkle          *uint64        `toml:",omitempty"`
-		TxSyncDefaultTimeout    *time.Duration `toml:",omitempty"`
-		TxSyncMaxTimeout        *time.Duration `toml:",omitempty"`
-		RangeLimit              *uint64        `toml:",omitempty"`
+		Genesis                    *core.Genesis `toml:",omitempty"`
+		NetworkId                  *uint64
+		SyncMode                   *SyncMode
+		HistoryMode                *history.HistoryMode
+		EthDiscoveryURLs           []string
+		SnapDiscoveryURLs          []string
+		NoPruning                  *bool
+		NoPrefetch                 *bool
+		TxLookupLimit              *uint64 `toml:",omitempty"`
+		TransactionHistory         *uint64 `toml:",omitempty"`
+		LogHistory                 *uint64 `toml:",omitempty"`
+		LogNoHistory               *bool   `toml:",omitempty"`
+		LogExportCheckpoints       *string
+		StateHistory               *uint64                `toml:",omitempty"`
+		TrienodeHistory            *int64                 `toml:",omitempty"`
+		NodeFullValueCheckpoint    *uint32                `toml:",omitempty"`
+		StatePruneRetention        *int                   `toml:",omitempty"`
+		StateScheme                *string                `toml:",omitempty"`
+		RequiredBlocks             map[uint64]common.Hash `toml:"-"`
+		SlowBlockThreshold         *time.Duration         `toml:",omitempty"`
+		SkipBcVersionCheck         *bool                  `toml:"-"`
+		DatabaseHandles            *int                   `toml:"-"`
+		DatabaseCache              *int
+		DatabaseFreezer            *string
+		DatabaseEra                *string
+		DatabaseFreezerRemote      *string `toml:",omitempty"`
+		DatabaseFreezerRemoteCache *int    `toml:",omitempty"`
+		DatabaseFreezerZstd        *bool   `toml:",omitempty"`
+		TrieCleanCache             *int
+		TrieDirtyCache             *int
+		TrieTimeout                *time.Duration
+		SnapshotCache              *int
+		Preimages                  *bool
+		FilterLogCacheSize         *int
+		LogQueryLimit              *int
+		Miner                      *miner.Config
+		TxPool                     *legacypool.Config
+		BlobPool                   *blobpool.Config
+		TxPropagation              *TxPropagationConfig
+		GPO                        *gasprice.Config
+		EnablePreimageRecording    *bool
+		EnableWitnessStats         *bool
+		StatelessSelfValidation    *bool
+		WitnessServe               *bool
+		WitnessStateless           *bool
+		EnableStateSizeTracking    *bool
+		VMTrace                    *string
+		VMTraceJsonConfig          *string
+		TraceStore                 *bool
+		TraceStoreSize             *uint64
+		TraceStoreTracers          []string
+		WasmTracerDir              *string
+		WasmTracerGasLimit         *uint64
+		WasmTracerMemory           *uint32
+		RPCGasCap                  *uint64
+		RPCEVMTimeout              *time.Duration
+		RPCTxFeeCap                *float64
+		RPCAncientWrites           *bool          `toml:",omitempty"`
+		OverrideOsaka              *uint64        `toml:",omitempty"`
+		OverrideBPO1               *uint64        `toml:",omitempty"`
+		OverrideBPO2               *uint64        `toml:",omitempty"`
+		OverrideVerkle             *uint64        `toml:",omitempty"`
+		TxSyncDefaultTimeout       *time.Duration `toml:",omitempty"`
+		TxSyncMaxTimeout           *time.Duration `toml:",omitempty"`
+		RangeLimit                 *uint64        `toml:",omitempty"`
 	}
 	var dec Config
 	if err := unmarshal(&dec); err != nil {
@@ -231,6 +273,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.NodeFullValueCheckpoint != nil {
 		c.NodeFullValueCheckpoint = *dec.NodeFullValueCheckpoint
 	}
+	if dec.StatePruneRetention != nil {
+		c.StatePruneRetention = *dec.StatePruneRetention
+	}
 	if dec.StateScheme != nil {
 		c.StateScheme = *dec.StateScheme
 	}
@@ -255,6 +300,15 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.DatabaseEra != nil {
 		c.DatabaseEra = *dec.DatabaseEra
 	}
+	if dec.DatabaseFreezerRemote != nil {
+		c.DatabaseFreezerRemote = *dec.DatabaseFreezerRemote
+	}
+	if dec.DatabaseFreezerRemoteCache != nil {
+		c.DatabaseFreezerRemoteCache = *dec.DatabaseFreezerRemoteCache
+	}
+	if dec.DatabaseFreezerZstd != nil {
+		c.DatabaseFreezerZstd = *dec.DatabaseFreezerZstd
+	}
 	if dec.TrieCleanCache != nil {
 		c.TrieCleanCache = *dec.TrieCleanCache
 	}
@@ -285,6 +339,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.BlobPool != nil {
 		c.BlobPool = *dec.BlobPool
 	}
+	if dec.TxPropagation != nil {
+		c.TxPropagation = *dec.TxPropagation
+	}
 	if dec.GPO != nil {
 		c.GPO = *dec.GPO
 	}
@@ -297,6 +354,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.StatelessSelfValidation != nil {
 		c.StatelessSelfValidation = *dec.StatelessSelfValidation
 	}
+	if dec.WitnessServe != nil {
+		c.WitnessServe = *dec.WitnessServe
+	}
+	if dec.WitnessStateless != nil {
+		c.WitnessStateless = *dec.WitnessStateless
+	}
 	if dec.EnableStateSizeTracking != nil {
 		c.EnableStateSizeTracking = *dec.EnableStateSizeTracking
 	}
@@ -306,6 +369,24 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.VMTraceJsonConfig != nil {
 		c.VMTraceJsonConfig = *dec.VMTraceJsonConfig
 	}
+	if dec.TraceStore != nil {
+		c.TraceStore = *dec.TraceStore
+	}
+	if dec.TraceStoreSize != nil {
+		c.TraceStoreSize = *dec.TraceStoreSize
+	}
+	if dec.TraceStoreTracers != nil {
+		c.TraceStoreTracers = dec.TraceStoreTracers
+	}
+	if dec.WasmTracerDir != nil {
+		c.WasmTracerDir = *dec.WasmTracerDir
+	}
+	if dec.WasmTracerGasLimit != nil {
+		c.WasmTracerGasLimit = *dec.WasmTracerGasLimit
+	}
+	if dec.WasmTracerMemory != nil {
+		c.WasmTracerMemory = *dec.WasmTracerMemory
+	}
 	if dec.RPCGasCap != nil {
 		c.RPCGasCap = *dec.RPCGasCap
 	}
@@ -315,6 +396,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.RPCTxFeeCap != nil {
 		c.RPCTxFeeCap = *dec.RPCTxFeeCap
 	}
+	if dec.RPCAncientWrites != nil {
+		c.RPCAncientWrites = *dec.RPCAncientWrites
+	}
 	if dec.OverrideOsaka != nil {
 		c.OverrideOsaka = dec.OverrideOsaka
 	}
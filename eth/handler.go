@@ -29,6 +29,7 @@ import (
 
 	"github.com/dchest/siphash"
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/txpool"
@@ -38,12 +39,14 @@ import (
 	"github.com/ethereum/go-ethereum/eth/fetcher"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
+	"github.com/ethereum/go-ethereum/eth/protocols/wit"
 	"github.com/ethereum/go-ethereum/ethdb"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 const (
@@ -54,10 +57,22 @@ const (
 	// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
 	chainHeadChanSize = 128
 
+	// headTimesCacheSize is the number of recent head blocks whose local import
+	// time is retained to measure the propagation speed of peers.
+	headTimesCacheSize = 128
+
 	// txMaxBroadcastSize is the max size of a transaction that will be broadcasted.
 	// All transactions with a higher size will be announced and need to be fetched
 	// by the peer.
 	txMaxBroadcastSize = 4096
+
+	// maxQueuedAnnouncements is the maximum number of transaction announcements
+	// held back by the rate limit to queue up per peer before dropping older ones.
+	maxQueuedAnnouncements = 4096
+
+	// annFlushInterval is the interval at which the transaction announcements held
+	// back by the rate limit are released to the peers.
+	annFlushInterval = time.Second
 )
 
 var syncChallengeTimeout = 15 * time.Second // Time allowance for a node to reply to the sync progress challenge
@@ -109,6 +124,11 @@ type handlerConfig struct {
 	BloomCache     uint64                 // Megabytes to alloc for snap sync bloom
 	EventMux       *event.TypeMux         // Legacy event mux, deprecate for `feed`
 	RequiredBlocks map[uint64]common.Hash // Hard coded map of required block hashes for sync challenges
+
+	TxPropagation ethconfig.TxPropagationConfig // Transaction propagation policies
+	IsLocalTx     func(common.Hash) bool        // Reports whether a transaction was submitted locally (optional)
+
+	WitnessServe bool // Whether to keep the witnesses of new blocks for serving
 }
 
 type handler struct {
@@ -125,6 +145,11 @@ type handler struct {
 	txFetcher      *fetcher.TxFetcher
 	peers          *peerSet
 	txBroadcastKey [16]byte
+	txPolicy       atomic.Pointer[ethconfig.TxPropagationConfig]
+	isLocalTx      func(common.Hash) bool
+
+	witFetcher *wit.Fetcher                                         // Retrieves witnesses from `wit` peers
+	witnesses  *lru.SizeConstrainedCache[common.Hash, rlp.RawValue] // Witnesses of recent blocks to serve (nil if disabled)
 
 	eventMux   *event.TypeMux
 	txsCh      chan core.NewTxsEvent
@@ -157,11 +182,19 @@ func newHandler(config *handlerConfig) (*handler, error) {
 		chain:          config.Chain,
 		peers:          newPeerSet(),
 		txBroadcastKey: newBroadcastChoiceKey(),
+		isLocalTx:      config.IsLocalTx,
 		requiredBlocks: config.RequiredBlocks,
+		witFetcher:     wit.NewFetcher(),
 		quitSync:       make(chan struct{}),
 		handlerDoneCh:  make(chan struct{}),
 		handlerStartCh: make(chan struct{}),
 	}
+	if err := h.setTxPropagationPolicy(config.TxPropagation); err != nil {
+		return nil, err
+	}
+	if config.WitnessServe {
+		h.witnesses = lru.NewSizeConstrainedCache[common.Hash, rlp.RawValue](witnessCacheSize)
+	}
 	// Construct the downloader (long sync)
 	h.downloader = downloader.New(config.Database, config.Sync, h.eventMux, h.chain, h.removePeer, h.enableSyncedFeatures)
 
@@ -459,27 +492,88 @@ func (h *handler) Stop() {
 // already have the given transaction.
 func (h *handler) BroadcastTransactions(txs types.Transactions) {
 	var (
-		blobTxs  int // Number of blob transactions to announce only
-		largeTxs int // Number of large transactions to announce only
+		policy = h.txPolicy.Load()
+		dist   = h.distributeTransactions(txs, h.peers.all(), policy)
 
 		directCount int // Number of transactions sent directly to peers (duplicates included)
 		annCount    int // Number of transactions announced across all peers (duplicates included)
+		annDropped  int // Number of announcements dropped due to the rate limit
+	)
+	for peer, hashes := range dist.direct {
+		directCount += len(hashes)
+		peer.AsyncSendTransactions(hashes)
+	}
+	for peer, hashes := range dist.announce {
+		if policy.AnnounceRate > 0 {
+			allowed, dropped := peer.limitAnnouncements(hashes, policy.AnnounceRate)
+			annDropped += dropped
+			if hashes = allowed; len(hashes) == 0 {
+				continue
+			}
+		}
+		annCount += len(hashes)
+		peer.AsyncSendPooledTransactionHashes(hashes)
+	}
+	log.Trace("Distributed transactions", "plaintxs", len(txs)-dist.blobTxs-dist.largeTxs-dist.withheld, "blobtxs", dist.blobTxs,
+		"largetxs", dist.largeTxs, "withheld", dist.withheld, "bcastpeers", len(dist.direct), "bcastcount", directCount,
+		"annpeers", len(dist.announce), "anncount", annCount, "anndropped", annDropped)
+}
+
+// txDistribution is the assignment of transactions to the peers they are to be
+// propagated to.
+type txDistribution struct {
+	direct   map[*ethPeer][]common.Hash // Set peer->hash to transfer directly
+	announce map[*ethPeer][]common.Hash // Set peer->hash to announce
 
-		txset = make(map[*ethPeer][]common.Hash) // Set peer->hash to transfer directly
-		annos = make(map[*ethPeer][]common.Hash) // Set peer->hash to announce
+	blobTxs  int // Number of blob transactions to announce only
+	largeTxs int // Number of large transactions to announce only
+	withheld int // Number of transactions not propagated due to the policies
+}
 
+// distributeTransactions assigns the transactions to the given peers according
+// to the propagation policies. Plain transactions are sent directly to a square
+// root of the peers and announced to the rest, while blob and large transactions
+// are only ever announced.
+func (h *handler) distributeTransactions(txs types.Transactions, peers []*ethPeer, policy *ethconfig.TxPropagationConfig) *txDistribution {
+	var (
+		dist = &txDistribution{
+			direct:   make(map[*ethPeer][]common.Hash),
+			announce: make(map[*ethPeer][]common.Hash),
+		}
 		signer = types.LatestSigner(h.chain.Config())
 		choice = newBroadcastChoice(h.nodeID, h.txBroadcastKey)
-		peers  = h.peers.all()
-	)
 
+		trusted []*ethPeer
+	)
+	for _, peer := range peers {
+		if peer.Trusted() {
+			trusted = append(trusted, peer)
+		}
+	}
+	if policy.TrustedOnly {
+		peers = trusted
+	}
 	for _, tx := range txs {
-		var directSet map[*ethPeer]struct{}
+		if policy.NoLocals && h.isLocalTx != nil && h.isLocalTx(tx.Hash()) {
+			dist.withheld++
+			continue
+		}
+		var (
+			targets   = peers
+			directSet map[*ethPeer]struct{}
+		)
 		switch {
 		case tx.Type() == types.BlobTxType:
-			blobTxs++
+			switch policy.BlobTxs {
+			case ethconfig.BlobTxsNone:
+				dist.withheld++
+				continue
+			case ethconfig.BlobTxsTrusted:
+				targets = trusted
+			}
+			dist.blobTxs++
 		case tx.Size() > txMaxBroadcastSize:
-			largeTxs++
+			dist.largeTxs++
 		default:
 			// Get transaction sender address. Here we can ignore any error
 			// since we're just interested in any value.
@@ -487,39 +581,61 @@ func (h *handler) BroadcastTransactions(txs types.Transactions) {
 			directSet = choice.choosePeers(peers, txSender)
 		}
 
-		for _, peer := range peers {
+		for _, peer := range targets {
 			if peer.KnownTransaction(tx.Hash()) {
 				continue
 			}
 			if _, ok := directSet[peer]; ok {
 				// Send direct.
-				txset[peer] = append(txset[peer], tx.Hash())
+				dist.direct[peer] = append(dist.direct[peer], tx.Hash())
 			} else {
 				// Send announcement.
-				annos[peer] = append(annos[peer], tx.Hash())
+				dist.announce[peer] = append(dist.announce[peer], tx.Hash())
 			}
 		}
 	}
+	return dist
+}
 
-	for peer, hashes := range txset {
-		directCount += len(hashes)
-		peer.AsyncSendTransactions(hashes)
+// txPropagationPolicy returns the current transaction propagation policies.
+func (h *handler) txPropagationPolicy() ethconfig.TxPropagationConfig {
+	return *h.txPolicy.Load()
+}
+
+// setTxPropagationPolicy replaces the transaction propagation policies, taking
+// effect with the next transactions to propagate.
+func (h *handler) setTxPropagationPolicy(policy ethconfig.TxPropagationConfig) error {
+	if err := policy.Validate(); err != nil {
+		return err
 	}
-	for peer, hashes := range annos {
-		annCount += len(hashes)
-		peer.AsyncSendPooledTransactionHashes(hashes)
+	h.txPolicy.Store(&policy)
+	return nil
+}
+
+// flushAnnouncements releases the transaction announcements held back by the
+// rate limit to the peers, as far as the rate limit allows.
+func (h *handler) flushAnnouncements() {
+	limit := h.txPolicy.Load().AnnounceRate
+	for _, peer := range h.peers.all() {
+		if hashes, _ := peer.limitAnnouncements(nil, limit); len(hashes) > 0 {
+			peer.AsyncSendPooledTransactionHashes(hashes)
+		}
 	}
-	log.Trace("Distributed transactions", "plaintxs", len(txs)-blobTxs-largeTxs, "blobtxs", blobTxs, "largetxs", largeTxs,
-		"bcastpeers", len(txset), "bcastcount", directCount, "annpeers", len(annos), "anncount", annCount)
 }
 
 // txBroadcastLoop announces new transactions to connected peers.
 func (h *handler) txBroadcastLoop() {
 	defer h.wg.Done()
+
+	flush := time.NewTicker(annFlushInterval)
+	defer flush.Stop()
+
 	for {
 		select {
 		case event := <-h.txsCh:
 			h.BroadcastTransactions(event.Txs)
+		case <-flush.C:
+			h.flushAnnouncements()
 		case <-h.txsSub.Err():
 			return
 		}
@@ -539,6 +655,8 @@ type blockRangeState struct {
 	headCh  chan core.ChainHeadEvent
 	headSub event.Subscription
 	syncSub *event.TypeMuxSubscription
+
+	headTimes *lru.Cache[common.Hash, time.Time] // Local import times of recent heads
 }
 
 func newBlockRangeState(chain *core.BlockChain, typeMux *event.TypeMux) *blockRangeState {
@@ -546,9 +664,10 @@ func newBlockRangeState(chain *core.BlockChain, typeMux *event.TypeMux) *blockRa
 	headSub := chain.SubscribeChainHeadEvent(headCh)
 	syncSub := typeMux.Subscribe(downloader.StartEvent{}, downloader.DoneEvent{}, downloader.FailedEvent{})
 	st := &blockRangeState{
-		headCh:  headCh,
-		headSub: headSub,
-		syncSub: syncSub,
+		headCh:    headCh,
+		headSub:   headSub,
+		syncSub:   syncSub,
+		headTimes: lru.NewCache[common.Hash, time.Time](headTimesCacheSize),
 	}
 	st.update(chain, chain.CurrentBlock())
 	st.prev = *st.next.Load()
@@ -570,7 +689,8 @@ func (h *handler) blockRangeLoop(st *blockRangeState) {
 			if _, ok := ev.Data.(downloader.StartEvent); ok && h.downloader.ConfigSyncMode() == ethconfig.SnapSync {
 				h.blockRangeWhileSnapSyncing(st)
 			}
-		case <-st.headCh:
+		case ev := <-st.headCh:
+			st.headTimes.Add(ev.Header.Hash(), time.Now())
 			st.update(h.chain, h.chain.CurrentBlock())
 			if st.shouldSend() {
 				h.broadcastBlockRange(st)
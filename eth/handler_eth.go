@@ -19,6 +19,7 @@ package eth
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -58,6 +59,14 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 	// Consume any broadcasts and announces, forwarding the rest to the downloader
 	switch packet := packet.(type) {
 	case *eth.NewPooledTransactionHashesPacket:
+		// Reward the peer for announcing transactions we don't know about yet
+		var unknown int
+		for _, hash := range packet.Hashes {
+			if !h.txpool.Has(hash) {
+				unknown++
+			}
+		}
+		peer.Reputation().Transactions(unknown)
 		return h.txFetcher.Notify(peer.ID(), packet.Types, packet.Sizes, packet.Hashes)
 
 	case *eth.TransactionsPacket:
@@ -84,6 +93,16 @@ func (h *ethHandler) Handle(peer *eth.Peer, packet eth.Packet) error {
 		}
 		return h.txFetcher.Enqueue(peer.ID(), *packet, true)
 
+	case *eth.BlockRangeUpdatePacket:
+		// Peers announce their new head shortly after importing it, reward them
+		// for following the chain as quickly as we do.
+		if h.synced.Load() {
+			if imported, ok := h.blockRange.headTimes.Get(packet.LatestBlockHash); ok {
+				peer.Reputation().BlockPropagation(time.Since(imported))
+			}
+		}
+		return nil
+
 	default:
 		return fmt.Errorf("unexpected eth packet type: %T", packet)
 	}
@@ -46,5 +46,9 @@ func (h *snapHandler) PeerInfo(id enode.ID) interface{} {
 // Handle is invoked from a peer's message handler when it receives a new remote
 // message that the handler couldn't consume and serve itself.
 func (h *snapHandler) Handle(peer *snap.Peer, packet snap.Packet) error {
-	return h.downloader.DeliverSnapPacket(peer, packet)
+	if err := h.downloader.DeliverSnapPacket(peer, packet); err != nil {
+		peer.Reputation().Invalid()
+		return err
+	}
+	return nil
 }
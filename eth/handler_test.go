@@ -274,6 +274,108 @@ func TestBroadcastChoice(t *testing.T) {
 	}
 }
 
+func TestTxPropagationPolicy(t *testing.T) {
+	handler := newTestHandler(ethconfig.FullSync)
+	defer handler.close()
+
+	peers := createTestPeers(rand.New(rand.NewSource(33)), 16)
+	defer closePeers(peers)
+	for i, peer := range peers[:4] {
+		peers[i] = &ethPeer{Peer: eth.NewPeer(eth.ETH69, p2p.NewTrustedPeer(peer.Node().ID(), "test", nil), nil, nil)}
+		peer.Close()
+	}
+	var (
+		local, _ = types.SignTx(types.NewTransaction(0, common.Address{}, big.NewInt(0), 21000, big.NewInt(0), nil), types.HomesteadSigner{}, testKey)
+		remote   = types.NewTransaction(1, common.Address{}, big.NewInt(0), 21000, big.NewInt(0), nil)
+		blob     = types.NewTx(&types.BlobTx{Nonce: 2})
+		txs      = types.Transactions{local, remote, blob}
+	)
+	handler.handler.isLocalTx = func(hash common.Hash) bool { return hash == local.Hash() }
+
+	// recipients counts the peers each transaction is propagated to, checking
+	// that they are all trusted if required.
+	recipients := func(dist *txDistribution, trustedOnly bool) map[common.Hash]int {
+		counts := make(map[common.Hash]int)
+		for _, set := range []map[*ethPeer][]common.Hash{dist.direct, dist.announce} {
+			for peer, hashes := range set {
+				for _, hash := range hashes {
+					if trustedOnly && !peer.Trusted() {
+						t.Errorf("transaction %x propagated to untrusted peer", hash)
+					}
+					counts[hash]++
+				}
+			}
+		}
+		return counts
+	}
+	tests := []struct {
+		policy ethconfig.TxPropagationConfig
+		want   map[common.Hash]int
+	}{
+		{
+			policy: ethconfig.DefaultTxPropagationConfig,
+			want:   map[common.Hash]int{local.Hash(): 16, remote.Hash(): 16, blob.Hash(): 16},
+		},
+		{
+			policy: ethconfig.TxPropagationConfig{NoLocals: true, BlobTxs: ethconfig.BlobTxsNone},
+			want:   map[common.Hash]int{remote.Hash(): 16},
+		},
+		{
+			policy: ethconfig.TxPropagationConfig{BlobTxs: ethconfig.BlobTxsTrusted},
+			want:   map[common.Hash]int{local.Hash(): 16, remote.Hash(): 16, blob.Hash(): 4},
+		},
+		{
+			policy: ethconfig.TxPropagationConfig{TrustedOnly: true},
+			want:   map[common.Hash]int{local.Hash(): 4, remote.Hash(): 4, blob.Hash(): 4},
+		},
+	}
+	for i, test := range tests {
+		if err := handler.handler.setTxPropagationPolicy(test.policy); err != nil {
+			t.Fatalf("test %d: failed to set policy: %v", i, err)
+		}
+		dist := handler.handler.distributeTransactions(txs, peers, handler.handler.txPolicy.Load())
+		if have := recipients(dist, test.policy.TrustedOnly); !maps.Equal(have, test.want) {
+			t.Errorf("test %d: recipients mismatch: have %v, want %v", i, have, test.want)
+		}
+		for peer, hashes := range dist.direct {
+			for _, hash := range hashes {
+				if hash == blob.Hash() {
+					t.Errorf("test %d: blob transaction sent directly to peer %v", i, peer.ID())
+				}
+			}
+		}
+	}
+	if err := handler.handler.setTxPropagationPolicy(ethconfig.TxPropagationConfig{BlobTxs: "foo"}); err == nil {
+		t.Fatal("invalid blob transaction propagation mode accepted")
+	}
+}
+
+func TestLimitAnnouncements(t *testing.T) {
+	peers := createTestPeers(rand.New(rand.NewSource(33)), 1)
+	defer closePeers(peers)
+
+	hashes := make([]common.Hash, 10)
+	if have, _ := peers[0].limitAnnouncements(hashes, 6); len(have) != 6 {
+		t.Fatalf("announcements mismatch: have %d, want 6", len(have))
+	}
+	if have, _ := peers[0].limitAnnouncements(hashes, 6); len(have) != 0 {
+		t.Fatalf("announcements allowed over the rate limit: %d", len(have))
+	}
+	// Changing the rate starts afresh, releasing the queued announcements first
+	if have, _ := peers[0].limitAnnouncements(nil, 20); len(have) != 14 {
+		t.Fatalf("queued announcements mismatch: have %d, want 14", len(have))
+	}
+	// Overflowing the queue drops the oldest announcements
+	have, dropped := peers[0].limitAnnouncements(make([]common.Hash, maxQueuedAnnouncements+16), 20)
+	if len(have) != 6 || dropped != 10 {
+		t.Fatalf("announcements mismatch: have %d sent and %d dropped, want 6 and 10", len(have), dropped)
+	}
+	// Disabling the rate limit releases all the queued announcements
+	if have, _ := peers[0].limitAnnouncements(nil, 0); len(have) != maxQueuedAnnouncements {
+		t.Fatalf("queued announcements mismatch: have %d, want %d", len(have), maxQueuedAnnouncements)
+	}
+}
+
 func BenchmarkBroadcastChoice(b *testing.B) {
 	b.Run("50", func(b *testing.B) {
 		benchmarkBroadcastChoice(b, 50)
@@ -0,0 +1,224 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/eth/protocols/wit"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// witnessCacheSize is the maximum total size of the recent block witnesses
+	// retained for serving remote peers.
+	witnessCacheSize = 256 * 1024 * 1024
+
+	// witnessFetchTimeout is the maximum time spent on retrieving a witness from
+	// the network for stateless validation. It must stay well below the timeout
+	// of engine API calls.
+	witnessFetchTimeout = 4 * time.Second
+)
+
+// errWitWithoutEth is returned if a peer attempts to connect only on the wit
+// protocol without advertising the eth main protocol.
+var errWitWithoutEth = errors.New("peer connected on wit without compatible eth support")
+
+// witHandler implements the wit.Backend interface to handle the various network
+// packets that are sent as replies or broadcasts.
+type witHandler handler
+
+func (h *witHandler) Chain() *core.BlockChain { return h.chain }
+
+// Witness retrieves the RLP-encoded witness of a recent block, if it's retained
+// for serving.
+func (h *witHandler) Witness(hash common.Hash) rlp.RawValue {
+	if h.witnesses == nil {
+		return nil
+	}
+	witness, _ := h.witnesses.Get(hash)
+	return witness
+}
+
+// RunPeer is invoked when a peer joins on the `wit` protocol.
+func (h *witHandler) RunPeer(peer *wit.Peer, hand wit.Handler) error {
+	return (*handler)(h).runWitExtension(peer, hand)
+}
+
+// PeerInfo retrieves all known `wit` information about a peer.
+func (h *witHandler) PeerInfo(id enode.ID) interface{} {
+	if p := h.witFetcher.Peer(id.String()); p != nil {
+		return &witPeerInfo{Version: p.Version()}
+	}
+	return nil
+}
+
+// Handle is invoked from a peer's message handler when it receives a new remote
+// message that the handler couldn't consume and serve itself.
+func (h *witHandler) Handle(peer *wit.Peer, packet wit.Packet) error {
+	switch packet := packet.(type) {
+	case *wit.NewWitnessHashesPacket:
+		// Availability is tracked by the peer itself, nothing else to do
+		return nil
+
+	case *wit.WitnessesPacket:
+		return h.witFetcher.Deliver(peer, packet)
+
+	default:
+		return fmt.Errorf("unexpected wit packet type: %T", packet)
+	}
+}
+
+// runWitExtension registers a `wit` peer as a witness source and starts handling
+// inbound messages. As `wit` is only a satellite protocol to `eth`, peers must
+// run both protocols, but the `wit` connection is managed on its own since no
+// other subsystem depends on it.
+func (h *handler) runWitExtension(peer *wit.Peer, handler wit.Handler) error {
+	if !h.incHandlers() {
+		return p2p.DiscQuitting
+	}
+	defer h.decHandlers()
+
+	// Reject the peer if it advertises `wit` without `eth` as `wit` is only a
+	// satellite protocol meaningful with the chain selection of `eth`
+	var err error
+	if !peer.RunningCap(eth.ProtocolName, eth.ProtocolVersions) {
+		err = fmt.Errorf("%w: have %v", errWitWithoutEth, peer.Caps())
+	} else {
+		err = h.witFetcher.Register(peer)
+	}
+	if err != nil {
+		if metrics.Enabled() {
+			if peer.Inbound() {
+				wit.IngressRegistrationErrorMeter.Mark(1)
+			} else {
+				wit.EgressRegistrationErrorMeter.Mark(1)
+			}
+		}
+		peer.Log().Debug("Witness extension registration failed", "err", err)
+		return err
+	}
+	defer h.witFetcher.Unregister(peer.ID())
+
+	return handler(peer)
+}
+
+// addWitness retains the witness of a newly imported block for serving, and
+// announces its availability to all `wit` peers.
+func (h *handler) addWitness(block *types.Block, witness *stateless.Witness) {
+	if h.witnesses == nil {
+		return
+	}
+	enc, err := rlp.EncodeToBytes(witness)
+	if err != nil {
+		log.Error("Failed to encode witness", "number", block.NumberU64(), "hash", block.Hash(), "err", err)
+		return
+	}
+	hash, number := block.Hash(), block.NumberU64()
+	h.witnesses.Add(hash, enc)
+
+	for _, peer := range h.witFetcher.Peers() {
+		go func() {
+			if err := peer.AnnounceWitness(hash, number); err != nil {
+				peer.Log().Debug("Witness announcement failed", "err", err)
+			}
+		}()
+	}
+}
+
+// validateStateless retrieves the witness of a block from the `wit` peers and
+// validates the block on top of it, without needing any local state. The header
+// is verified against the parent header authenticated by the witness.
+func (h *handler) validateStateless(block *types.Block) error {
+	ctx, cancel := context.WithTimeout(context.Background(), witnessFetchTimeout)
+	defer cancel()
+
+	// Remove the computed fields from the block to force their recalculation
+	context := block.Header()
+	context.Root = common.Hash{}
+	context.ReceiptHash = common.Hash{}
+	task := types.NewBlockWithHeader(context).WithBody(*block.Body())
+
+	_, err := h.witFetcher.Fetch(ctx, block.Hash(), func(witness *stateless.Witness) error {
+		// The pre-state root is taken from the witness, so it must be anchored
+		// to the parent of the block to be meaningful.
+		if len(witness.Headers) == 0 || witness.Headers[0].Hash() != block.ParentHash() {
+			return errors.New("witness not rooted in parent block")
+		}
+		// Execution doesn't check the header fields derived from the parent (gas
+		// limit, base fee, timestamp, blob gas, etc.), so verify them explicitly
+		// against the now authenticated parent header.
+		reader := &witnessHeaderReader{ChainHeaderReader: h.chain, headers: witness.Headers}
+		if err := h.chain.Engine().VerifyHeader(reader, block.Header()); err != nil {
+			return fmt.Errorf("invalid header: %w", err)
+		}
+		stateRoot, receiptRoot, err := core.ExecuteStateless(h.chain.Config(), vm.Config{}, task, witness)
+		if err != nil {
+			return err
+		}
+		if stateRoot != block.Root() {
+			return fmt.Errorf("state root mismatch (stateless: %x block: %x)", stateRoot, block.Root())
+		}
+		if receiptRoot != block.ReceiptHash() {
+			return fmt.Errorf("receipt root mismatch (stateless: %x block: %x)", receiptRoot, block.ReceiptHash())
+		}
+		return nil
+	})
+	return err
+}
+
+// witnessHeaderReader resolves headers from a block witness before falling back
+// to the local chain, allowing a header to be verified against its parent even
+// if the parent is not known locally.
+type witnessHeaderReader struct {
+	consensus.ChainHeaderReader
+	headers []*types.Header
+}
+
+// GetHeader retrieves a header by hash and number, preferring the witness.
+func (r *witnessHeaderReader) GetHeader(hash common.Hash, number uint64) *types.Header {
+	for _, header := range r.headers {
+		if header.Number.Uint64() == number && header.Hash() == hash {
+			return header
+		}
+	}
+	return r.ChainHeaderReader.GetHeader(hash, number)
+}
+
+// GetHeaderByHash retrieves a header by hash, preferring the witness.
+func (r *witnessHeaderReader) GetHeaderByHash(hash common.Hash) *types.Header {
+	for _, header := range r.headers {
+		if header.Hash() == hash {
+			return header
+		}
+	}
+	return r.ChainHeaderReader.GetHeaderByHash(hash)
+}
@@ -0,0 +1,157 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/consensus/ethash"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/ethconfig"
+	"github.com/ethereum/go-ethereum/eth/protocols/eth"
+	"github.com/ethereum/go-ethereum/eth/protocols/wit"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+// Tests that a node validates blocks statelessly using the witnesses served by
+// a connected `wit` peer.
+func TestWitnessStatelessValidation(t *testing.T) {
+	t.Parallel()
+
+	// Create a chain with some transactions, importing the last block the same
+	// way as a payload to generate its witness.
+	var (
+		gspec = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc:  types.GenesisAlloc{testAddr: {Balance: big.NewInt(1_000_000_000_000_000)}},
+		}
+		signer = types.LatestSigner(gspec.Config)
+	)
+	_, blocks, _ := core.GenerateChainWithGenesis(gspec, ethash.NewFaker(), 2, func(i int, gen *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTransaction(gen.TxNonce(testAddr), common.Address{0x01}, big.NewInt(1), params.TxGas, gen.BaseFee(), nil), signer, testKey)
+		gen.AddTx(tx)
+	})
+	db := rawdb.NewMemoryDatabase()
+	chain, _ := core.NewBlockChain(db, gspec, ethash.NewFaker(), nil)
+	defer chain.Stop()
+
+	if _, err := chain.InsertChain(blocks[:1]); err != nil {
+		t.Fatal(err)
+	}
+	witness, err := chain.InsertBlockWithoutSetHead(blocks[1], true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, err := newHandler(&handlerConfig{
+		Database:     db,
+		Chain:        chain,
+		TxPool:       newTestTxPool(),
+		Network:      1,
+		Sync:         ethconfig.FullSync,
+		BloomCache:   1,
+		WitnessServe: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Start(1000)
+	defer server.Stop()
+	server.addWitness(blocks[1], witness)
+
+	// Create a client without any of the blocks and connect the two.
+	client := newTestHandler(ethconfig.FullSync)
+	defer client.close()
+
+	var (
+		caps               = []p2p.Cap{{Name: eth.ProtocolName, Version: eth.ETH68}, {Name: wit.ProtocolName, Version: wit.WIT1}}
+		serverRW, clientRW = p2p.MsgPipe()
+		serverPeer         = wit.NewPeer(wit.WIT1, p2p.NewPeerPipe(enode.ID{1}, "", caps, clientRW), clientRW)
+		clientPeer         = wit.NewPeer(wit.WIT1, p2p.NewPeerPipe(enode.ID{2}, "", caps, serverRW), serverRW)
+	)
+	defer serverRW.Close()
+
+	go server.runWitExtension(clientPeer, func(peer *wit.Peer) error {
+		return wit.Handle((*witHandler)(server), peer)
+	})
+	go client.handler.runWitExtension(serverPeer, func(peer *wit.Peer) error {
+		return wit.Handle((*witHandler)(client.handler), peer)
+	})
+	for client.handler.witFetcher.Peer(serverPeer.ID()) == nil {
+		time.Sleep(time.Millisecond)
+	}
+	if err := client.handler.validateStateless(blocks[1]); err != nil {
+		t.Fatalf("stateless validation failed: %v", err)
+	}
+	// Blocks without an available witness can't be validated.
+	if err := client.handler.validateStateless(blocks[0]); err == nil {
+		t.Fatal("stateless validation succeeded without witness")
+	}
+	// Tampered blocks must be rejected even if the peer serves a witness.
+	header := blocks[1].Header()
+	header.Root = common.Hash{0x01}
+	tampered := types.NewBlockWithHeader(header).WithBody(*blocks[1].Body())
+	enc, _ := server.witnesses.Get(blocks[1].Hash())
+	server.witnesses.Add(tampered.Hash(), enc)
+	if err := client.handler.validateStateless(tampered); err == nil || !strings.Contains(err.Error(), "state root mismatch") {
+		t.Fatalf("wrong error for tampered block: %v", err)
+	}
+	// Blocks with header fields inconsistent with the parent must be rejected,
+	// even though executing them on the witness yields the claimed roots.
+	for _, tamper := range []func(*types.Header){
+		func(h *types.Header) { h.BaseFee = new(big.Int).Add(h.BaseFee, common.Big1) },
+		func(h *types.Header) { h.GasLimit *= 2 },
+	} {
+		header := blocks[1].Header()
+		tamper(header)
+		tampered := types.NewBlockWithHeader(header).WithBody(*blocks[1].Body())
+		server.witnesses.Add(tampered.Hash(), enc)
+		if err := client.handler.validateStateless(tampered); err == nil || !strings.Contains(err.Error(), "invalid header") {
+			t.Fatalf("wrong error for block with invalid header: %v", err)
+		}
+	}
+}
+
+// Tests that peers running `wit` without `eth` are rejected.
+func TestWitnessPeerWithoutEth(t *testing.T) {
+	t.Parallel()
+
+	handler := newTestHandler(ethconfig.FullSync)
+	defer handler.close()
+
+	local, remote := p2p.MsgPipe()
+	defer local.Close()
+	defer remote.Close()
+
+	caps := []p2p.Cap{{Name: wit.ProtocolName, Version: wit.WIT1}}
+	peer := wit.NewPeer(wit.WIT1, p2p.NewPeerPipe(enode.ID{1}, "", caps, local), local)
+	err := handler.handler.runWitExtension(peer, func(*wit.Peer) error { return nil })
+	if !errors.Is(err, errWitWithoutEth) {
+		t.Fatalf("wrong error: %v", err)
+	}
+	if handler.handler.witFetcher.Peer(peer.ID()) != nil {
+		t.Fatal("rejected peer remains registered")
+	}
+}
@@ -17,9 +17,13 @@
 package eth
 
 import (
+	"sync"
+	"time"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/eth/protocols/eth"
 	"github.com/ethereum/go-ethereum/eth/protocols/snap"
+	"golang.org/x/time/rate"
 )
 
 // ethPeerInfo represents a short summary of the `eth` sub-protocol metadata known
@@ -39,6 +43,48 @@ type peerBlockRange struct {
 type ethPeer struct {
 	*eth.Peer
 	snapExt *snapPeer // Satellite `snap` connection
+
+	annLimiter *rate.Limiter // Rate limiter of the transaction announcements
+	annQueue   []common.Hash // Announcements held back by the rate limiter
+	annLock    sync.Mutex    // Lock protecting the announcement rate limiter and queue
+}
+
+// limitAnnouncements caps the transaction announcements to the number the peer
+// is allowed to receive at the given rate per second. The excess is queued up to
+// be released first by subsequent calls, dropping the oldest announcements if the
+// queue overflows. A zero rate releases all the queued announcements.
+//
+// The announcements to send are returned along with the number of dropped ones.
+func (p *ethPeer) limitAnnouncements(hashes []common.Hash, limit uint64) ([]common.Hash, int) {
+	p.annLock.Lock()
+	defer p.annLock.Unlock()
+
+	queue := append(p.annQueue, hashes...)
+	if limit == 0 {
+		p.annQueue = nil
+		return queue, 0
+	}
+	if p.annLimiter == nil || p.annLimiter.Limit() != rate.Limit(limit) {
+		p.annLimiter = rate.NewLimiter(rate.Limit(limit), int(limit))
+	}
+	now := time.Now()
+	allowed := max(0, min(len(queue), int(p.annLimiter.TokensAt(now))))
+	if allowed > 0 {
+		p.annLimiter.AllowN(now, allowed)
+	}
+	var (
+		rest    = queue[allowed:]
+		dropped int
+	)
+	if len(rest) > maxQueuedAnnouncements {
+		dropped = len(rest) - maxQueuedAnnouncements
+		rest = rest[dropped:]
+	}
+	if len(rest) == 0 {
+		rest = nil
+	}
+	p.annQueue = rest
+	return queue[:allowed], dropped
 }
 
 // info gathers and returns some `eth` protocol metadata known about a peer.
@@ -71,3 +117,9 @@ func (p *snapPeer) info() *snapPeerInfo {
 		Version: p.Version(),
 	}
 }
+
+// witPeerInfo represents a short summary of the `wit` sub-protocol metadata known
+// about a connected peer.
+type witPeerInfo struct {
+	Version uint `json:"version"` // Witness protocol version negotiated
+}
@@ -19,6 +19,7 @@ package eth
 import (
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/forkid"
+	"github.com/ethereum/go-ethereum/p2p/discover"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/rlp"
 )
@@ -79,3 +80,10 @@ func NewNodeFilter(chain *core.BlockChain) func(*enode.Node) bool {
 		return err == nil
 	}
 }
+
+// NewTopic returns the discovery v5 topic under which nodes of the given chain
+// advertise the `eth` protocol. The topic is derived from the genesis hash so that
+// it stays stable across forks, compatibility is checked by NewNodeFilter.
+func NewTopic(chain *core.BlockChain) discover.Topic {
+	return discover.NewTopic("eth/" + chain.Genesis().Hash().Hex())
+}
@@ -78,6 +78,15 @@ func (r *Request) Close() error {
 	}
 }
 
+// ReportTimeout penalises the remote peer's reputation for not serving the request
+// in time. It does not cancel the request, a late response may still be delivered.
+func (r *Request) ReportTimeout() {
+	if r.peer == nil { // Tests mock out the dispatcher, skip reporting
+		return
+	}
+	r.peer.Reputation().Timeout()
+}
+
 // request is a wrapper around a client Request that has an error channel to
 // signal on if sending the request already failed on a network level.
 type request struct {
@@ -148,10 +157,17 @@ func (p *Peer) dispatchResponse(res *Response, metadata func() interface{}) erro
 
 	select {
 	case p.resDispatch <- resOp:
-		// Ensure the response is accepted by the dispatcher
+		// Ensure the response is accepted by the dispatcher. Dangling responses
+		// might be late replies to cancelled requests, but responding with the
+		// wrong packet type is a misbehaviour.
 		if err := <-resOp.fail; err != nil {
+			if errors.Is(err, errMismatchingResponseType) {
+				p.Reputation().Invalid()
+			}
 			return nil
 		}
+		p.Reputation().Response(res.code, res.Time)
+
 		// Request was accepted, run any postprocessing step to generate metadata
 		// on the receiver thread, not the sink thread
 		if metadata != nil {
@@ -171,7 +187,12 @@ func (p *Peer) dispatchResponse(res *Response, metadata func() interface{}) erro
 			// for fresh cancellations too
 			select {
 			case res.Req.sink <- res:
-				return <-res.Done // Response delivered, return any errors
+				// Response delivered, return any errors
+				err := <-res.Done
+				if err != nil {
+					p.Reputation().Invalid()
+				}
+				return err
 			case <-res.Req.cancel:
 				return nil // Request cancelled, silently discard response
 			case <-p.term:
@@ -23,6 +23,7 @@ import (
 	"math/big"
 	"math/rand"
 	"os"
+	"reflect"
 	"testing"
 	"time"
 
@@ -550,6 +551,145 @@ func testGetBlockReceipts(t *testing.T, protocol uint) {
 	}
 }
 
+// receiptsTestGenerator creates blocks with three transactions each, so that
+// the receipt retrieval can be resumed mid-block.
+func receiptsTestGenerator(i int, block *core.BlockGen) {
+	signer := types.HomesteadSigner{}
+	for j := 0; j < 3; j++ {
+		tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testAddr), common.Address{byte(j)}, big.NewInt(1000), params.TxGas, block.BaseFee(), nil), signer, testKey)
+		block.AddTx(tx)
+	}
+}
+
+// Tests that the transaction receipts can be retrieved on eth/70 starting at a
+// receipt index within the first requested block.
+func TestGetBlockReceipts70(t *testing.T) {
+	t.Parallel()
+
+	backend := newTestBackendWithGenerator(3, false, false, receiptsTestGenerator)
+	defer backend.close()
+
+	peer, _ := newTestPeer("peer", ETH70, backend)
+	defer peer.close()
+
+	// Collect the hashes to request, and the response to expect
+	var (
+		hashes   []common.Hash
+		receipts []*ReceiptList69
+	)
+	for i := uint64(1); i <= backend.chain.CurrentBlock().Number.Uint64(); i++ {
+		block := backend.chain.GetBlockByNumber(i)
+		hashes = append(hashes, block.Hash())
+		trs := backend.chain.GetReceiptsByHash(block.Hash())
+		if i == 1 {
+			trs = trs[2:]
+		}
+		receipts = append(receipts, NewReceiptList69(trs))
+	}
+	// Send the hash request and verify the response
+	p2p.Send(peer.app, GetReceiptsMsg, &GetReceiptsPacket70{
+		RequestId:              123,
+		FirstBlockReceiptIndex: 2,
+		GetReceiptsRequest:     hashes,
+	})
+	if err := p2p.ExpectMsg(peer.app, ReceiptsMsg, &ReceiptsPacket70{
+		RequestId: 123,
+		List:      receipts,
+	}); err != nil {
+		t.Errorf("receipts mismatch: %v", err)
+	}
+}
+
+// Tests that partially delivered receipts of a block are retained by the peer,
+// and reassembled when their retrieval is resumed.
+func TestPartialReceiptsRetrieval(t *testing.T) {
+	t.Parallel()
+
+	backend := newTestBackendWithGenerator(2, false, false, receiptsTestGenerator)
+	defer backend.close()
+
+	peer, _ := newTestPeer("peer", ETH70, backend)
+	defer peer.close()
+
+	var (
+		block1 = backend.chain.GetBlockByNumber(1)
+		block2 = backend.chain.GetBlockByNumber(2)
+		trs1   = backend.chain.GetReceiptsByHash(block1.Hash())
+		trs2   = backend.chain.GetReceiptsByHash(block2.Hash())
+		sink   = make(chan *Response, 1)
+	)
+	// request sends a receipt query, returning the packet seen by the remote side
+	request := func(hashes []common.Hash) (*Request, *GetReceiptsPacket70) {
+		var (
+			req  *Request
+			errc = make(chan error, 1)
+		)
+		go func() {
+			var err error
+			req, err = peer.RequestReceipts(hashes, sink)
+			errc <- err
+		}()
+		msg, err := peer.app.ReadMsg()
+		if err != nil {
+			t.Fatalf("failed to read request: %v", err)
+		}
+		packet := new(GetReceiptsPacket70)
+		if err := msg.Decode(packet); err != nil {
+			t.Fatalf("failed to decode request: %v", err)
+		}
+		if err := <-errc; err != nil {
+			t.Fatalf("failed to send request: %v", err)
+		}
+		return req, packet
+	}
+	// respond delivers a reply to the local peer, checking the resulting response
+	respond := func(packet *ReceiptsPacket70, want *ReceiptsRLPResponse70, hashes []common.Hash) {
+		go p2p.Send(peer.app, ReceiptsMsg, packet)
+
+		res := <-sink
+		if have := res.Res.(*ReceiptsRLPResponse70); !reflect.DeepEqual(have, want) {
+			t.Errorf("response mismatch: have %v, want %v", have, want)
+		}
+		if have := res.Meta.([]common.Hash); !reflect.DeepEqual(have, hashes) {
+			t.Errorf("receipt root mismatch: have %v, want %v", have, hashes)
+		}
+		res.Done <- nil
+		res.Req.Close()
+	}
+	// Retrieve the receipts of the first block and some of the second
+	_, packet := request([]common.Hash{block1.Hash(), block2.Hash()})
+	if packet.FirstBlockReceiptIndex != 0 {
+		t.Fatalf("unexpected first receipt index: have %d, want 0", packet.FirstBlockReceiptIndex)
+	}
+	respond(&ReceiptsPacket70{
+		RequestId:           packet.RequestId,
+		LastBlockIncomplete: true,
+		List:                []*ReceiptList69{NewReceiptList69(trs1), NewReceiptList69(trs2[:1])},
+	}, &ReceiptsRLPResponse70{
+		ReceiptsRLPResponse: ReceiptsRLPResponse{NewReceiptList69(trs1).EncodeForStorage()},
+		LastBlockIncomplete: true,
+	}, []common.Hash{block1.ReceiptHash()})
+
+	// Resume the retrieval of the second block's receipts
+	_, packet = request([]common.Hash{block2.Hash()})
+	if packet.FirstBlockReceiptIndex != 1 {
+		t.Fatalf("unexpected first receipt index: have %d, want 1", packet.FirstBlockReceiptIndex)
+	}
+	respond(&ReceiptsPacket70{
+		RequestId: packet.RequestId,
+		List:      []*ReceiptList69{NewReceiptList69(trs2[1:])},
+	}, &ReceiptsRLPResponse70{
+		ReceiptsRLPResponse: ReceiptsRLPResponse{NewReceiptList69(trs2).EncodeForStorage()},
+	}, []common.Hash{block2.ReceiptHash()})
+
+	// The partial receipts are consumed, a new query starts from scratch
+	req, packet := request([]common.Hash{block2.Hash()})
+	defer req.Close()
+	if packet.FirstBlockReceiptIndex != 0 {
+		t.Fatalf("unexpected first receipt index: have %d, want 0", packet.FirstBlockReceiptIndex)
+	}
+}
+
 type decoder struct {
 	msg []byte
 }
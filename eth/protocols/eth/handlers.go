@@ -268,6 +268,16 @@ func handleGetReceipts69(backend Backend, msg Decoder, peer *Peer) error {
 	return peer.ReplyReceiptsRLP(query.RequestId, response)
 }
 
+func handleGetReceipts70(backend Backend, msg Decoder, peer *Peer) error {
+	// Decode the block receipts retrieval message
+	var query GetReceiptsPacket70
+	if err := msg.Decode(&query); err != nil {
+		return err
+	}
+	response, incomplete := serviceGetReceiptsQuery70(backend.Chain(), query.GetReceiptsRequest, query.FirstBlockReceiptIndex)
+	return peer.ReplyReceiptsRLP70(query.RequestId, response, incomplete)
+}
+
 // ServiceGetReceiptsQuery68 assembles the response to a receipt query. It is
 // exposed to allow external packages to test protocol behavior.
 func ServiceGetReceiptsQuery68(chain *core.BlockChain, query GetReceiptsRequest) []rlp.RawValue {
@@ -342,6 +352,60 @@ func serviceGetReceiptsQuery69(chain *core.BlockChain, query GetReceiptsRequest)
 	return receipts
 }
 
+// serviceGetReceiptsQuery70 assembles the response to an eth/70 receipt query,
+// omitting the receipts of the first block below the given index. If the size
+// limit is reached within a block, its receipt list is cut short and the
+// response is flagged as incomplete.
+func serviceGetReceiptsQuery70(chain *core.BlockChain, query GetReceiptsRequest, first uint64) ([]rlp.RawValue, bool) {
+	// Gather state data until the fetch or network limits is reached
+	var (
+		bytes    int
+		receipts []rlp.RawValue
+	)
+	for lookups, hash := range query {
+		if bytes >= softResponseLimit || len(receipts) >= maxReceiptsServe ||
+			lookups >= 2*maxReceiptsServe {
+			break
+		}
+		// Retrieve the requested block's receipts
+		results := chain.GetReceiptsRLP(hash)
+		if results == nil {
+			if header := chain.GetHeaderByHash(hash); header == nil || header.ReceiptHash != types.EmptyRootHash {
+				continue
+			}
+		} else {
+			body := chain.GetBodyRLP(hash)
+			if body == nil {
+				continue
+			}
+			var err error
+			results, err = blockReceiptsToNetwork69(results, body)
+			if err != nil {
+				log.Error("Error in block receipts conversion", "hash", hash, "err", err)
+				continue
+			}
+			// Skip the receipts already retrieved by the requester and cut the
+			// list short if it doesn't fit into the response
+			var skip uint64
+			if lookups == 0 {
+				skip = first
+			}
+			var complete bool
+			results, complete, err = sliceReceiptList(results, skip, softResponseLimit-bytes)
+			if err != nil {
+				log.Debug("Invalid receipt query", "hash", hash, "first", skip, "err", err)
+				break
+			}
+			if !complete {
+				return append(receipts, results), true
+			}
+		}
+		receipts = append(receipts, results)
+		bytes += len(results)
+	}
+	return receipts, false
+}
+
 func handleNewBlockhashes(backend Backend, msg Decoder, peer *Peer) error {
 	return errors.New("block announcements disallowed") // We dropped support for non-merge networks
 }
@@ -431,6 +495,67 @@ func handleReceipts[L ReceiptsList](backend Backend, msg Decoder, peer *Peer) er
 	}, metadata)
 }
 
+func handleReceipts70(backend Backend, msg Decoder, peer *Peer) error {
+	// A batch of receipts arrived to one of our previous requests
+	res := new(ReceiptsPacket70)
+	if err := msg.Decode(res); err != nil {
+		return err
+	}
+	// An incomplete block must carry at least some of its receipts, otherwise
+	// its retrieval could never progress
+	if res.LastBlockIncomplete && (len(res.List) == 0 || res.List[len(res.List)-1].Len() == 0) {
+		return errEmptyPartialReceipts
+	}
+	// Assign temporary hashing buffer to each list item, the same buffer is shared
+	// between all receipt list instances.
+	buffers := new(receiptListBuffers)
+	for i := range res.List {
+		res.List[i].setBuffers(buffers)
+	}
+	var (
+		enc      = new(ReceiptsRLPResponse70)
+		response = &Response{
+			id:   res.RequestId,
+			code: ReceiptsMsg,
+			Res:  enc,
+		}
+	)
+	// The partial receipts can only be reassembled once the response is matched
+	// to its request, so the delivered lists are also assembled after that.
+	metadata := func() interface{} {
+		var (
+			req   = response.Req.data.(*GetReceiptsPacket70)
+			lists = res.List
+		)
+		if len(lists) > len(req.GetReceiptsRequest) {
+			lists = lists[:len(req.GetReceiptsRequest)]
+		}
+		// Prepend the previously retrieved receipts of the first block if its
+		// retrieval was resumed mid-block
+		if req.FirstBlockReceiptIndex > 0 && len(lists) > 0 {
+			if partial := peer.takePartialReceipts(req.GetReceiptsRequest[0], req.FirstBlockReceiptIndex); partial != nil {
+				partial.append(lists[0])
+				lists[0] = partial
+			}
+		}
+		// Retain the leading receipts of the last block if it's incomplete
+		if res.LastBlockIncomplete && len(lists) == len(res.List) {
+			last := len(lists) - 1
+			peer.setPartialReceipts(req.GetReceiptsRequest[last], lists[last])
+			lists = lists[:last]
+			enc.LastBlockIncomplete = true
+		}
+		hasher := trie.NewStackTrie(nil)
+		hashes := make([]common.Hash, len(lists))
+		for i := range lists {
+			hashes[i] = types.DeriveSha(lists[i], hasher)
+			enc.ReceiptsRLPResponse = append(enc.ReceiptsRLPResponse, lists[i].EncodeForStorage())
+		}
+		return hashes
+	}
+	return peer.dispatchResponse(response, metadata)
+}
+
 func handleNewPooledTransactionHashes(backend Backend, msg Decoder, peer *Peer) error {
 	// New transaction announcement arrived, make sure we have
 	// a valid and fresh chain to handle them
@@ -548,7 +673,11 @@ func handleBlockRangeUpdate(backend Backend, msg Decoder, peer *Peer) error {
 	if err := update.Validate(); err != nil {
 		return err
 	}
-	// We don't do anything with these messages for now, just store them on the peer.
-	peer.lastRange.Store(&update)
-	return nil
+	// Store the range on the peer, and let the backend measure how quickly the
+	// peer follows the chain if its head advanced.
+	prev := peer.lastRange.Swap(&update)
+	if prev != nil && update.LatestBlock <= prev.LatestBlock {
+		return nil
+	}
+	return backend.Handle(peer, &update)
 }
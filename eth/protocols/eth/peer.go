@@ -18,6 +18,7 @@ package eth
 
 import (
 	"math/rand"
+	"sync"
 	"sync/atomic"
 
 	mapset "github.com/deckarep/golang-set/v2"
@@ -59,6 +60,9 @@ type Peer struct {
 	reqCancel   chan *cancel   // Dispatch channel to cancel pending requests and untrack them
 	resDispatch chan *response // Dispatch channel to fulfil pending requests and untrack them
 
+	partialReceipts *partialReceipts // Leading receipts of a block partially retrieved via eth/70
+	partialLock     sync.Mutex       // Lock protecting the partial receipts
+
 	term chan struct{} // Termination channel to stop the broadcasters
 }
 
@@ -215,6 +219,15 @@ func (p *Peer) ReplyReceiptsRLP(id uint64, receipts []rlp.RawValue) error {
 	})
 }
 
+// ReplyReceiptsRLP70 is the response to GetReceipts on eth/70.
+func (p *Peer) ReplyReceiptsRLP70(id uint64, receipts []rlp.RawValue, lastBlockIncomplete bool) error {
+	return p2p.Send(p.rw, ReceiptsMsg, &ReceiptsRLPPacket70{
+		RequestId:           id,
+		LastBlockIncomplete: lastBlockIncomplete,
+		ReceiptsRLPResponse: receipts,
+	})
+}
+
 // RequestOneHeader is a wrapper around the header query functions to fetch a
 // single header. It is used solely by the fetcher.
 func (p *Peer) RequestOneHeader(hash common.Hash, sink chan *Response) (*Request, error) {
@@ -333,12 +346,67 @@ func (p *Peer) RequestReceipts(hashes []common.Hash, sink chan *Response) (*Requ
 			GetReceiptsRequest: hashes,
 		},
 	}
+	// If the peer supports it, resume the retrieval of the first block's
+	// receipts from where a previous partial response left off.
+	if p.version >= ETH70 {
+		var first uint64
+		if len(hashes) > 0 {
+			first = p.partialReceiptsIndex(hashes[0])
+		}
+		req.data = &GetReceiptsPacket70{
+			RequestId:              id,
+			FirstBlockReceiptIndex: first,
+			GetReceiptsRequest:     hashes,
+		}
+	}
 	if err := p.dispatchRequest(req); err != nil {
 		return nil, err
 	}
 	return req, nil
 }
 
+// partialReceipts is the leading part of a block's receipt list, retrieved by
+// an eth/70 response which reached its size limit mid-block.
+type partialReceipts struct {
+	hash common.Hash    // Hash of the block the receipts belong to
+	list *ReceiptList69 // Receipts retrieved so far
+}
+
+// partialReceiptsIndex returns the number of receipts already retrieved from the
+// peer for the given block.
+func (p *Peer) partialReceiptsIndex(hash common.Hash) uint64 {
+	p.partialLock.Lock()
+	defer p.partialLock.Unlock()
+
+	if p.partialReceipts == nil || p.partialReceipts.hash != hash {
+		return 0
+	}
+	return uint64(p.partialReceipts.list.Len())
+}
+
+// takePartialReceipts returns the receipts already retrieved for the given block
+// if they are exactly the given number, dropping them from the peer.
+func (p *Peer) takePartialReceipts(hash common.Hash, count uint64) *ReceiptList69 {
+	p.partialLock.Lock()
+	defer p.partialLock.Unlock()
+
+	partial := p.partialReceipts
+	if partial == nil || partial.hash != hash || uint64(partial.list.Len()) != count {
+		return nil
+	}
+	p.partialReceipts = nil
+	return partial.list
+}
+
+// setPartialReceipts retains the leading receipts of the given block, replacing
+// any previously retained ones.
+func (p *Peer) setPartialReceipts(hash common.Hash, list *ReceiptList69) {
+	p.partialLock.Lock()
+	defer p.partialLock.Unlock()
+
+	p.partialReceipts = &partialReceipts{hash: hash, list: list}
+}
+
 // RequestTxs fetches a batch of transactions from a remote node.
 func (p *Peer) RequestTxs(hashes []common.Hash) error {
 	p.Log().Trace("Fetching batch of transactions", "count", len(hashes))
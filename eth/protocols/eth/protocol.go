@@ -25,6 +25,7 @@ import (
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/forkid"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -32,6 +33,7 @@ import (
 const (
 	ETH68 = 68
 	ETH69 = 69
+	ETH70 = 70
 )
 
 // ProtocolName is the official short name of the `eth` protocol used during
@@ -40,11 +42,11 @@ const ProtocolName = "eth"
 
 // ProtocolVersions are the supported versions of the `eth` protocol (first
 // is primary).
-var ProtocolVersions = []uint{ETH69, ETH68}
+var ProtocolVersions = []uint{ETH70, ETH69, ETH68}
 
 // protocolLengths are the number of implemented message corresponding to
 // different protocol versions.
-var protocolLengths = map[uint]uint64{ETH68: 17, ETH69: 18}
+var protocolLengths = map[uint]uint64{ETH68: 17, ETH69: 18, ETH70: 18}
 
 // maxMessageSize is the maximum cap on the size of a protocol message.
 const maxMessageSize = 10 * 1024 * 1024
@@ -67,8 +69,8 @@ const (
 )
 
 var (
-	errMsgTooLarge             = errors.New("message too long")
-	errInvalidMsgCode          = errors.New("invalid message code")
+	errMsgTooLarge             = fmt.Errorf("%w: message too long", p2p.ErrMalformedMessage)
+	errInvalidMsgCode          = fmt.Errorf("%w: invalid message code", p2p.ErrMalformedMessage)
 	errProtocolVersionMismatch = errors.New("protocol version mismatch")
 	// handshake errors
 	errNoStatusMsg       = errors.New("no status message")
@@ -76,6 +78,8 @@ var (
 	errGenesisMismatch   = errors.New("genesis mismatch")
 	errForkIDRejected    = errors.New("fork ID rejected")
 	errInvalidBlockRange = errors.New("invalid block range in status")
+
+	errEmptyPartialReceipts = errors.New("empty partial receipt list")
 )
 
 // Packet represents a p2p message in the `eth` protocol.
@@ -264,6 +268,14 @@ type GetReceiptsPacket struct {
 	GetReceiptsRequest
 }
 
+// GetReceiptsPacket70 represents a block receipts query on eth/70, which allows
+// resuming the retrieval of the first block's receipts from the given index.
+type GetReceiptsPacket70 struct {
+	RequestId              uint64
+	FirstBlockReceiptIndex uint64
+	GetReceiptsRequest
+}
+
 // ReceiptsResponse is the network packet for block receipts distribution.
 type ReceiptsResponse []types.Receipts
 
@@ -282,6 +294,15 @@ type ReceiptsPacket[L ReceiptsList] struct {
 	List      []L
 }
 
+// ReceiptsPacket70 is the network packet for block receipts distribution on
+// eth/70. If the response size limit is reached mid-block, the receipt list of
+// the last block is cut short and LastBlockIncomplete is set.
+type ReceiptsPacket70 struct {
+	RequestId           uint64
+	LastBlockIncomplete bool
+	List                []*ReceiptList69
+}
+
 // ReceiptsRLPResponse is used for receipts, when we already have it encoded
 type ReceiptsRLPResponse []rlp.RawValue
 
@@ -291,6 +312,24 @@ type ReceiptsRLPPacket struct {
 	ReceiptsRLPResponse
 }
 
+// ReceiptsRLPPacket70 is ReceiptsRLPResponse with request ID wrapping and the
+// eth/70 incomplete block flag.
+type ReceiptsRLPPacket70 struct {
+	RequestId           uint64
+	LastBlockIncomplete bool
+	ReceiptsRLPResponse
+}
+
+// ReceiptsRLPResponse70 is the eth/70 receipts response delivered to the
+// requester. It holds the complete receipt lists of the leading blocks, and
+// whether the receipts of the block following them were partially retrieved.
+// The partial receipts are retained by the peer, and their retrieval resumes
+// when the block is requested next.
+type ReceiptsRLPResponse70 struct {
+	ReceiptsRLPResponse
+	LastBlockIncomplete bool
+}
+
 // NewPooledTransactionHashesPacket represents a transaction announcement packet on eth/68 and newer.
 type NewPooledTransactionHashesPacket struct {
 	Types  []byte
@@ -379,5 +418,11 @@ func (*ReceiptsResponse) Kind() byte   { return ReceiptsMsg }
 func (*ReceiptsRLPResponse) Name() string { return "Receipts" }
 func (*ReceiptsRLPResponse) Kind() byte   { return ReceiptsMsg }
 
+func (*GetReceiptsPacket70) Name() string { return "GetReceipts" }
+func (*GetReceiptsPacket70) Kind() byte   { return GetReceiptsMsg }
+
+func (*ReceiptsPacket70) Name() string { return "Receipts" }
+func (*ReceiptsPacket70) Kind() byte   { return ReceiptsMsg }
+
 func (*BlockRangeUpdatePacket) Name() string { return "BlockRangeUpdate" }
 func (*BlockRangeUpdatePacket) Kind() byte   { return BlockRangeUpdateMsg }
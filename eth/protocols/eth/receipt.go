@@ -354,6 +354,11 @@ func NewReceiptList69(trs []*types.Receipt) *ReceiptList69 {
 	return rl
 }
 
+// append adds the receipts of the given list to the end of the list.
+func (rl *ReceiptList69) append(other *ReceiptList69) {
+	rl.items = append(rl.items, other.items...)
+}
+
 // setBuffers implements ReceiptsList.
 func (rl *ReceiptList69) setBuffers(buf *receiptListBuffers) {
 	rl.buf = buf
@@ -433,6 +438,48 @@ func blockReceiptsToNetwork69(blockReceipts, blockBody rlp.RawValue) ([]byte, er
 	return out.Bytes(), nil
 }
 
+// sliceReceiptList takes a block's receipt list in network encoding and re-encodes
+// it starting at the given receipt index, stopping once the encoded receipts
+// reach the given size limit. It also reports whether all the receipts of the
+// block were included.
+func sliceReceiptList(list rlp.RawValue, first uint64, limit int) (rlp.RawValue, bool, error) {
+	if first == 0 && len(list) <= limit {
+		return list, true, nil
+	}
+	it, err := rlp.NewListIterator(list)
+	if err != nil {
+		return nil, false, err
+	}
+	var (
+		out      bytes.Buffer
+		enc      = rlp.NewEncoderBuffer(&out)
+		outer    = enc.List()
+		size     int
+		index    uint64
+		complete = true
+	)
+	for ; it.Next(); index++ {
+		if index < first {
+			continue
+		}
+		if size >= limit {
+			complete = false
+			break
+		}
+		enc.Write(it.Value())
+		size += len(it.Value())
+	}
+	if err := it.Err(); err != nil {
+		return nil, false, err
+	}
+	if index < first {
+		return nil, false, fmt.Errorf("receipt index %d out of range (%d receipts)", first, index)
+	}
+	enc.ListEnd(outer)
+	enc.Flush()
+	return out.Bytes(), complete, nil
+}
+
 // txTypesInBody parses the transactions list of an encoded block body, returning just the types.
 func txTypesInBody(body rlp.RawValue) (iter.Seq[byte], error) {
 	bodyFields, _, err := rlp.SplitList(body)
@@ -156,3 +156,40 @@ func TestReceiptList68(t *testing.T) {
 		}
 	}
 }
+
+func TestSliceReceiptList(t *testing.T) {
+	var trs []*types.Receipt
+	for i := range receiptsTests {
+		r := types.Receipt(receiptsTests[i].input[0])
+		trs = append(trs, &r)
+	}
+	list, _ := rlp.EncodeToBytes(NewReceiptList69(trs))
+	encode := func(trs []*types.Receipt) rlp.RawValue {
+		enc, _ := rlp.EncodeToBytes(NewReceiptList69(trs))
+		return enc
+	}
+	tests := []struct {
+		first    uint64
+		limit    int
+		want     rlp.RawValue
+		complete bool
+	}{
+		{first: 0, limit: len(list), want: list, complete: true},
+		{first: 2, limit: len(list), want: encode(trs[2:]), complete: true},
+		{first: uint64(len(trs)), limit: len(list), want: encode(nil), complete: true},
+		{first: 0, limit: 1, want: encode(trs[:1])},
+		{first: 1, limit: len(encode(trs[1:3])) - len(encode(nil)), want: encode(trs[1:3])},
+	}
+	for i, test := range tests {
+		have, complete, err := sliceReceiptList(list, test.first, test.limit)
+		if err != nil {
+			t.Fatalf("test %d: unexpected error: %v", i, err)
+		}
+		if !bytes.Equal(have, test.want) || complete != test.complete {
+			t.Errorf("test %d: slice mismatch: have %x (complete %v), want %x (complete %v)", i, have, complete, test.want, test.complete)
+		}
+	}
+	if _, _, err := sliceReceiptList(list, uint64(len(trs)+1), len(list)); err == nil {
+		t.Error("expected error for out of range receipt index")
+	}
+}
@@ -19,9 +19,12 @@ package snap
 import (
 	"bytes"
 	"fmt"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/rawdb"
 	"github.com/ethereum/go-ethereum/core/state/snapshot"
@@ -57,6 +60,14 @@ const (
 	// If we spend too much time, then it's a fairly high chance of timing out
 	// at the remote side, which means all the work is in vain.
 	maxTrieNodeTimeSpent = 5 * time.Second
+
+	// maxCachedStateDiffs is the number of assembled state diffs to keep around
+	// for serving subsequent pages without assembling them again.
+	maxCachedStateDiffs = 4
+
+	// maxStateDiffSize is the maximum total size of a state diff to serve. This
+	// number is there to limit the memory held by the assembled diffs.
+	maxStateDiffSize = 32 * 1024 * 1024
 )
 
 // Handler is a callback to invoke from an outside runner after the boilerplate
@@ -266,6 +277,43 @@ func HandleMessage(backend Backend, peer *Peer) error {
 
 		return backend.Handle(peer, res)
 
+	case msg.Code == GetStateDiffMsg && peer.version >= SNAP2:
+		// Decode the state diff retrieval request
+		var req GetStateDiffPacket
+		if err := msg.Decode(&req); err != nil {
+			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+		}
+		// Service the request, potentially returning nothing in case of errors
+		accounts, complete := ServiceGetStateDiffQuery(backend.Chain(), &req)
+
+		// Send back anything accumulated (or empty in case of errors)
+		return p2p.Send(peer.rw, StateDiffMsg, &StateDiffPacket{
+			ID:       req.ID,
+			Accounts: accounts,
+			Complete: complete,
+		})
+
+	case msg.Code == StateDiffMsg && peer.version >= SNAP2:
+		// A batch of state changes arrived to one of our previous requests
+		res := new(StateDiffPacket)
+		if err := msg.Decode(res); err != nil {
+			return fmt.Errorf("%w: message %v: %v", errDecode, msg, err)
+		}
+		// Ensure the accounts and slots are monotonically increasing
+		for i, acc := range res.Accounts {
+			if i > 0 && bytes.Compare(res.Accounts[i-1].Hash[:], acc.Hash[:]) >= 0 {
+				return fmt.Errorf("accounts not monotonically increasing: #%d [%x] vs #%d [%x]", i-1, res.Accounts[i-1].Hash[:], i, acc.Hash[:])
+			}
+			for j := 1; j < len(acc.Slots); j++ {
+				if bytes.Compare(acc.Slots[j-1].Hash[:], acc.Slots[j].Hash[:]) >= 0 {
+					return fmt.Errorf("storage slots not monotonically increasing for account #%d: #%d [%x] vs #%d [%x]", i, j-1, acc.Slots[j-1].Hash[:], j, acc.Slots[j].Hash[:])
+				}
+			}
+		}
+		requestTracker.Fulfil(peer.id, peer.version, StateDiffMsg, res.ID)
+
+		return backend.Handle(peer, res)
+
 	default:
 		return fmt.Errorf("%w: %v", errInvalidMsgCode, msg.Code)
 	}
@@ -584,6 +632,113 @@ func ServiceGetTrieNodesQuery(chain *core.BlockChain, req *GetTrieNodesPacket, s
 	return nodes, nil
 }
 
+// stateDiffKey identifies an assembled state diff by its source and target root.
+type stateDiffKey struct {
+	from common.Hash
+	to   common.Hash
+}
+
+// assembledStateDiff is a state diff sorted by account hash for serving it page
+// by page. A diff which is too large to be served is cached with a nil set to
+// avoid assembling it again for every request.
+type assembledStateDiff struct {
+	accounts []*StateDiffAccount
+}
+
+var (
+	stateDiffCache = lru.NewCache[stateDiffKey, *assembledStateDiff](maxCachedStateDiffs)
+	stateDiffLock  sync.Mutex // Serializes diff assembly to avoid duplicate work
+)
+
+// assembleStateDiff retrieves the state diff between the given roots from the
+// cache, or assembles it from the trie database if it's not yet cached. Nil is
+// returned if the diff is not available or too large to be served.
+func assembleStateDiff(chain *core.BlockChain, from, to common.Hash) []*StateDiffAccount {
+	key := stateDiffKey{from: from, to: to}
+	if diff, ok := stateDiffCache.Get(key); ok {
+		return diff.accounts
+	}
+	stateDiffLock.Lock()
+	defer stateDiffLock.Unlock()
+
+	// Another request might have assembled the diff while we were waiting
+	if diff, ok := stateDiffCache.Get(key); ok {
+		return diff.accounts
+	}
+	// Retrieve the requested diff and bail out if it's not available. Failures
+	// are not cached as the states might become available later.
+	accounts, storages, err := chain.TrieDB().StateDiff(from, to)
+	if err != nil {
+		return nil
+	}
+	hashes := make([]common.Hash, 0, len(accounts))
+	for hash := range accounts {
+		hashes = append(hashes, hash)
+	}
+	for hash := range storages {
+		if _, ok := accounts[hash]; !ok {
+			hashes = append(hashes, hash)
+		}
+	}
+	slices.SortFunc(hashes, common.Hash.Cmp)
+
+	// Pile the changed accounts up, keeping the slots of an account together
+	var (
+		diff = make([]*StateDiffAccount, 0, len(hashes))
+		size uint64
+	)
+	for _, hash := range hashes {
+		acc := &StateDiffAccount{
+			Hash: hash,
+			Body: accounts[hash],
+		}
+		entry := uint64(common.HashLength + len(acc.Body))
+		for slot, blob := range storages[hash] {
+			acc.Slots = append(acc.Slots, &StorageData{Hash: slot, Body: blob})
+			entry += uint64(common.HashLength + len(blob))
+		}
+		// Refuse to serve accounts which would not fit into a message, as well
+		// as diffs which are too large to be held in memory
+		size += entry
+		if entry > maxMessageSize/2 || size > maxStateDiffSize {
+			stateDiffCache.Add(key, &assembledStateDiff{})
+			return nil
+		}
+		slices.SortFunc(acc.Slots, func(a, b *StorageData) int { return a.Hash.Cmp(b.Hash) })
+		diff = append(diff, acc)
+	}
+	stateDiffCache.Add(key, &assembledStateDiff{accounts: diff})
+	return diff
+}
+
+// ServiceGetStateDiffQuery assembles the response to a state diff query, also
+// returning whether the response contains the remainder of the diff. It is
+// exposed to allow external packages to test protocol behavior.
+func ServiceGetStateDiffQuery(chain *core.BlockChain, req *GetStateDiffPacket) ([]*StateDiffAccount, bool) {
+	if req.Bytes > softResponseLimit {
+		req.Bytes = softResponseLimit
+	}
+	accounts := assembleStateDiff(chain, req.From, req.To)
+	if accounts == nil {
+		return nil, false
+	}
+	// Serve the page of accounts starting at the requested origin
+	start, _ := slices.BinarySearchFunc(accounts, req.Origin, func(acc *StateDiffAccount, origin common.Hash) int {
+		return acc.Hash.Cmp(origin)
+	})
+	var size uint64
+	for i := start; i < len(accounts); i++ {
+		size += uint64(common.HashLength + len(accounts[i].Body))
+		for _, slot := range accounts[i].Slots {
+			size += uint64(common.HashLength + len(slot.Body))
+		}
+		if size > req.Bytes && i < len(accounts)-1 {
+			return accounts[start : i+1], false
+		}
+	}
+	return accounts[start:], true
+}
+
 // NodeInfo represents a short summary of the `snap` sub-protocol metadata
 // known about the host peer.
 type NodeInfo struct{}
@@ -17,6 +17,8 @@
 package snap
 
 import (
+	"errors"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p"
@@ -131,3 +133,21 @@ func (p *Peer) RequestTrieNodes(id uint64, root common.Hash, paths []TrieNodePat
 		Bytes: bytes,
 	})
 }
+
+// RequestStateDiff fetches a batch of the state changes between two state roots,
+// starting with the origin account. It is only supported from snap/2 onwards.
+func (p *Peer) RequestStateDiff(id uint64, from, to common.Hash, origin common.Hash, bytes uint64) error {
+	if p.version < SNAP2 {
+		return errors.New("state diffs not supported")
+	}
+	p.logger.Trace("Fetching state diff", "reqid", id, "from", from, "to", to, "origin", origin, "bytes", common.StorageSize(bytes))
+
+	requestTracker.Track(p.id, p.version, GetStateDiffMsg, StateDiffMsg, id)
+	return p2p.Send(p.rw, GetStateDiffMsg, &GetStateDiffPacket{
+		ID:     id,
+		From:   from,
+		To:     to,
+		Origin: origin,
+		Bytes:  bytes,
+	})
+}
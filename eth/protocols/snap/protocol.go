@@ -22,12 +22,14 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
 // Constants to match up protocol versions and messages
 const (
 	SNAP1 = 1
+	SNAP2 = 2
 )
 
 // ProtocolName is the official short name of the `snap` protocol used during
@@ -36,11 +38,11 @@ const ProtocolName = "snap"
 
 // ProtocolVersions are the supported versions of the `snap` protocol (first
 // is primary).
-var ProtocolVersions = []uint{SNAP1}
+var ProtocolVersions = []uint{SNAP2, SNAP1}
 
 // protocolLengths are the number of implemented message corresponding to
 // different protocol versions.
-var protocolLengths = map[uint]uint64{SNAP1: 8}
+var protocolLengths = map[uint]uint64{SNAP2: 10, SNAP1: 8}
 
 // maxMessageSize is the maximum cap on the size of a protocol message.
 const maxMessageSize = 10 * 1024 * 1024
@@ -54,12 +56,14 @@ const (
 	ByteCodesMsg        = 0x05
 	GetTrieNodesMsg     = 0x06
 	TrieNodesMsg        = 0x07
+	GetStateDiffMsg     = 0x08
+	StateDiffMsg        = 0x09
 )
 
 var (
-	errMsgTooLarge    = errors.New("message too long")
-	errDecode         = errors.New("invalid message")
-	errInvalidMsgCode = errors.New("invalid message code")
+	errMsgTooLarge    = fmt.Errorf("%w: message too long", p2p.ErrMalformedMessage)
+	errDecode         = fmt.Errorf("%w: invalid message", p2p.ErrMalformedMessage)
+	errInvalidMsgCode = fmt.Errorf("%w: invalid message code", p2p.ErrMalformedMessage)
 	errBadRequest     = errors.New("bad request")
 )
 
@@ -193,6 +197,51 @@ type TrieNodesPacket struct {
 	Nodes [][]byte // Requested state trie nodes
 }
 
+// GetStateDiffPacket represents a query for the flat state changes between two
+// state roots, introduced in snap/2.
+type GetStateDiffPacket struct {
+	ID     uint64      // Request ID to match up responses with
+	From   common.Hash // Root hash of the state to diff from
+	To     common.Hash // Root hash of the state to diff to
+	Origin common.Hash // Hash of the first account to retrieve
+	Bytes  uint64      // Soft limit at which to stop returning data
+}
+
+// StateDiffPacket represents a state diff query response. An empty response
+// which is not complete signals that the peer cannot serve the requested diff.
+type StateDiffPacket struct {
+	ID       uint64              // ID of the request this is a response for
+	Accounts []*StateDiffAccount // List of changed accounts, sorted by hash
+	Complete bool                // Whether the diff has no more accounts after these
+}
+
+// StateDiffAccount represents the changes of a single account in a state diff.
+type StateDiffAccount struct {
+	Hash  common.Hash    // Hash of the account
+	Body  []byte         // Account body in slim format, empty if deleted
+	Slots []*StorageData // Changed storage slots, sorted by hash, empty bodies if deleted
+}
+
+// Unpack retrieves the accounts and storage slots from the state diff packet.
+func (p *StateDiffPacket) Unpack() ([]common.Hash, [][]byte, [][]common.Hash, [][][]byte) {
+	var (
+		hashes     = make([]common.Hash, len(p.Accounts))
+		accounts   = make([][]byte, len(p.Accounts))
+		slotHashes = make([][]common.Hash, len(p.Accounts))
+		slots      = make([][][]byte, len(p.Accounts))
+	)
+	for i, acc := range p.Accounts {
+		hashes[i], accounts[i] = acc.Hash, acc.Body
+		slotHashes[i] = make([]common.Hash, len(acc.Slots))
+		slots[i] = make([][]byte, len(acc.Slots))
+		for j, slot := range acc.Slots {
+			slotHashes[i][j] = slot.Hash
+			slots[i][j] = slot.Body
+		}
+	}
+	return hashes, accounts, slotHashes, slots
+}
+
 func (*GetAccountRangePacket) Name() string { return "GetAccountRange" }
 func (*GetAccountRangePacket) Kind() byte   { return GetAccountRangeMsg }
 
@@ -216,3 +265,9 @@ func (*GetTrieNodesPacket) Kind() byte   { return GetTrieNodesMsg }
 
 func (*TrieNodesPacket) Name() string { return "TrieNodes" }
 func (*TrieNodesPacket) Kind() byte   { return TrieNodesMsg }
+
+func (*GetStateDiffPacket) Name() string { return "GetStateDiff" }
+func (*GetStateDiffPacket) Kind() byte   { return GetStateDiffMsg }
+
+func (*StateDiffPacket) Name() string { return "StateDiff" }
+func (*StateDiffPacket) Kind() byte   { return StateDiffMsg }
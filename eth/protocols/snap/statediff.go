@@ -0,0 +1,529 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/ethereum/go-ethereum/triedb/database"
+)
+
+// stateDiffRequest tracks a pending state diff request to ensure responses are
+// to actual requests and to validate any security constraints.
+//
+// Concurrency note: state diff requests and responses are handled concurrently
+// from the main runloop to allow validation on the peer's thread and to drop on
+// invalid response. The request struct must contain all the data to construct
+// the response without accessing runloop internals (i.e. task). That is only
+// included to allow the runloop to match a response to the task being synced
+// without having yet another set of maps.
+type stateDiffRequest struct {
+	peer string    // Peer to which this request is assigned
+	id   uint64    // Request ID of this request
+	time time.Time // Timestamp when the request was sent
+
+	deliver chan *stateDiffResponse // Channel to deliver successful response on
+	revert  chan *stateDiffRequest  // Channel to deliver request failure on
+	cancel  chan struct{}           // Channel to track sync cancellation
+	timeout *time.Timer             // Timer to track delivery timeout
+	stale   chan struct{}           // Channel to signal the request was dropped
+
+	origin common.Hash    // First account requested to allow continuation checks
+	task   *stateDiffTask // Task which this request is filling (only access fields through the runloop!!)
+}
+
+// stateDiffResponse is an already verified remote response to a state diff request.
+type stateDiffResponse struct {
+	task *stateDiffTask // Task which this request is filling
+	peer string         // Peer which delivered the response
+
+	hashes     []common.Hash   // Account hashes in the returned chunk
+	accounts   [][]byte        // Slim account bodies, empty if deleted
+	slotHashes [][]common.Hash // Storage slot hashes for each account
+	slots      [][][]byte      // Storage slot values for each account, empty if deleted
+	complete   bool            // Whether the chunk finishes the diff
+}
+
+// stateDiffTask represents the retrieval of the state changes between the last
+// fully synced state and the current sync target, allowing the local state to be
+// moved forward at once instead of healing it node by node.
+type stateDiffTask struct {
+	from common.Hash // Root of the fully synced local state
+	next common.Hash // Next account hash to retrieve
+
+	accounts map[common.Hash][]byte                 // Changed accounts retrieved so far
+	storages map[common.Hash]map[common.Hash][]byte // Changed storage slots retrieved so far
+
+	served map[string]struct{} // Peers which delivered parts of the diff
+	failed map[string]struct{} // Peers which failed to deliver the diff
+
+	batch ethdb.Batch // Verified state changes waiting for missing bytecodes
+}
+
+// newStateDiffTask creates a task to retrieve the state diff from the given root.
+func newStateDiffTask(from common.Hash) *stateDiffTask {
+	task := &stateDiffTask{
+		from:   from,
+		failed: make(map[string]struct{}),
+	}
+	task.reset()
+	return task
+}
+
+// reset drops all the retrieved state changes, restarting the retrieval.
+func (task *stateDiffTask) reset() {
+	task.next = common.Hash{}
+	task.accounts = make(map[common.Hash][]byte)
+	task.storages = make(map[common.Hash]map[common.Hash][]byte)
+	task.served = make(map[string]struct{})
+}
+
+// diffNodeDatabase is a wrapper of key-value store and implements database.NodeDatabase,
+// providing access to the persistent trie nodes of the fully synced state.
+type diffNodeDatabase struct {
+	db     ethdb.KeyValueStore
+	scheme string
+}
+
+// NodeReader returns a node reader associated with the specific state.
+func (db *diffNodeDatabase) NodeReader(stateRoot common.Hash) (database.NodeReader, error) {
+	return db, nil
+}
+
+// Node retrieves the trie node blob with the provided trie identifier, node path
+// and the corresponding node hash. No error will be returned if the node is not
+// found, the trie will report it as missing.
+func (db *diffNodeDatabase) Node(owner common.Hash, path []byte, hash common.Hash) ([]byte, error) {
+	return rawdb.ReadTrieNode(db.db, owner, path, hash, db.scheme), nil
+}
+
+// hasCompletedState returns whether the root node of the given state is present
+// in the database, which is a prerequisite for moving it forward by a diff.
+func (s *Syncer) hasCompletedState(root common.Hash) bool {
+	return rawdb.HasTrieNode(s.db, common.Hash{}, nil, root, s.scheme)
+}
+
+// newCodeHealTask creates a healer which only retrieves the given bytecodes,
+// leaving the trie nodes on disk untouched.
+func (s *Syncer) newCodeHealTask(codes []common.Hash) *healTask {
+	scheduler := trie.NewSync(types.EmptyRootHash, s.db, nil, s.scheme)
+	for _, hash := range codes {
+		scheduler.AddCodeEntry(hash, nil, common.Hash{}, nil)
+	}
+	return &healTask{
+		scheduler: scheduler,
+		trieTasks: make(map[string]common.Hash),
+		codeTasks: make(map[common.Hash]struct{}),
+	}
+}
+
+// abandonStateDiff gives up on moving the completed state forward by a state
+// diff and falls back to healing the state node by node. The completed state is
+// invalidated right away, as healing will overwrite parts of it.
+//
+// The lock must be held by the caller.
+func (s *Syncer) abandonStateDiff() {
+	log.Info("Falling back to state healing", "from", s.diff.from, "root", s.root)
+
+	s.diff = nil
+	s.completed = common.Hash{}
+	s.healer = s.newHealTask(s.root)
+	s.saveSyncStatus()
+}
+
+// assignStateDiffTasks attempts to match the state diff task to an idle peer,
+// retrieving the diff chunk by chunk, one request at a time.
+func (s *Syncer) assignStateDiffTasks(success chan *stateDiffResponse, fail chan *stateDiffRequest, cancel chan struct{}) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	task := s.diff
+	if task.batch != nil || len(s.stateDiffReqs) > 0 {
+		return
+	}
+	// Pick the fastest peer which did not yet fail to deliver the diff. If none
+	// of the connected peers can serve it, fall back to healing the state.
+	var (
+		idle    string
+		best    = -1
+		timeout = s.rates.TargetTimeout()
+	)
+	for id := range s.peers {
+		if _, ok := task.failed[id]; ok {
+			continue
+		}
+		if _, ok := s.statelessPeers[id]; ok {
+			continue
+		}
+		if cap := s.rates.Capacity(id, StateDiffMsg, timeout); cap > best {
+			idle, best = id, cap
+		}
+	}
+	if idle == "" {
+		if len(s.peers) > 0 {
+			s.abandonStateDiff()
+		}
+		return
+	}
+	peer := s.peers[idle]
+
+	// Matched the task to an idle peer, allocate a unique request id
+	var reqid uint64
+	for {
+		reqid = uint64(rand.Int63())
+		if reqid == 0 {
+			continue
+		}
+		if _, ok := s.stateDiffReqs[reqid]; ok {
+			continue
+		}
+		break
+	}
+	var (
+		from = task.from
+		root = s.root
+	)
+	req := &stateDiffRequest{
+		peer:    idle,
+		id:      reqid,
+		time:    time.Now(),
+		deliver: success,
+		revert:  fail,
+		cancel:  cancel,
+		stale:   make(chan struct{}),
+		origin:  task.next,
+		task:    task,
+	}
+	req.timeout = time.AfterFunc(s.rates.TargetTimeout(), func() {
+		peer.Log().Debug("State diff request timed out", "reqid", reqid)
+		s.rates.Update(idle, StateDiffMsg, 0, 0)
+		peerReputation(peer).Timeout()
+		s.scheduleRevertStateDiffRequest(req)
+	})
+	s.stateDiffReqs[reqid] = req
+
+	s.pend.Add(1)
+	go func() {
+		defer s.pend.Done()
+
+		// Attempt to send the remote request and revert if it fails
+		if err := peer.RequestStateDiff(reqid, from, root, req.origin, maxRequestSize); err != nil {
+			peer.Log().Debug("Failed to request state diff", "err", err)
+			s.scheduleRevertStateDiffRequest(req)
+		}
+	}()
+}
+
+// scheduleRevertStateDiffRequest asks the event loop to clean up a state diff
+// request and mark the peer as unable to serve the diff.
+func (s *Syncer) scheduleRevertStateDiffRequest(req *stateDiffRequest) {
+	select {
+	case req.revert <- req:
+		// Sync event loop notified
+	case <-req.cancel:
+		// Sync cycle got cancelled
+	case <-req.stale:
+		// Request already reverted
+	}
+}
+
+// revertStateDiffRequest cleans up a state diff request and marks the peer as
+// unable to serve the diff, so the retrieval continues with another one.
+//
+// Note, this needs to run on the event runloop thread to reschedule to idle peers.
+// On peer threads, use scheduleRevertStateDiffRequest.
+func (s *Syncer) revertStateDiffRequest(req *stateDiffRequest) {
+	log.Debug("Reverting state diff request", "peer", req.peer)
+	select {
+	case <-req.stale:
+		log.Trace("State diff request already reverted", "peer", req.peer, "reqid", req.id)
+		return
+	default:
+	}
+	close(req.stale)
+
+	// Remove the request from the tracked set
+	s.lock.Lock()
+	delete(s.stateDiffReqs, req.id)
+	s.lock.Unlock()
+
+	// If there's a timeout timer still running, abort it and exclude the peer
+	// from serving the remainder of the diff
+	req.timeout.Stop()
+	req.task.failed[req.peer] = struct{}{}
+}
+
+// processStateDiffResponse integrates an already validated state diff chunk into
+// the diff task, applying the diff onto the completed state once it's whole.
+func (s *Syncer) processStateDiffResponse(res *stateDiffResponse) {
+	task := res.task
+	task.served[res.peer] = struct{}{}
+
+	for i, hash := range res.hashes {
+		task.accounts[hash] = res.accounts[i]
+		if len(res.slotHashes[i]) == 0 {
+			continue
+		}
+		if task.storages[hash] == nil {
+			task.storages[hash] = make(map[common.Hash][]byte)
+		}
+		for j, slot := range res.slotHashes[i] {
+			task.storages[hash][slot] = res.slots[i][j]
+		}
+	}
+	if !res.complete {
+		if last := res.hashes[len(res.hashes)-1]; last != common.MaxHash {
+			task.next = incHash(last)
+			return
+		}
+	}
+	// The whole diff was retrieved, verify it against the target state root
+	batch, codes, err := s.buildStateDiff(task)
+	if err != nil {
+		var missing *trie.MissingNodeError
+		if errors.As(err, &missing) {
+			log.Warn("Completed state unavailable for state diff", "from", task.from, "err", err)
+			s.lock.Lock()
+			s.abandonStateDiff()
+			s.lock.Unlock()
+			return
+		}
+		log.Warn("Discarding invalid state diff", "from", task.from, "root", s.root, "peers", len(task.served), "err", err)
+		for id := range task.served {
+			task.failed[id] = struct{}{}
+		}
+		task.reset()
+		return
+	}
+	log.Info("Retrieved state diff", "from", task.from, "root", s.root, "accounts", len(task.accounts), "codes", len(codes))
+
+	// Retrieve any missing bytecodes before persisting the changes, keeping
+	// the completed state intact until then
+	s.lock.Lock()
+	task.batch = batch
+	s.healer = s.newCodeHealTask(codes)
+	s.lock.Unlock()
+}
+
+// buildStateDiff applies the retrieved state diff onto the completed state,
+// verifying that it results in the sync target. The resulting trie nodes and
+// flat states are gathered into a batch, along with the list of bytecodes not
+// yet present locally.
+func (s *Syncer) buildStateDiff(task *stateDiffTask) (ethdb.Batch, []common.Hash, error) {
+	nodedb := &diffNodeDatabase{db: s.db, scheme: s.scheme}
+	accTrie, err := trie.New(trie.StateTrieID(task.from), nodedb)
+	if err != nil {
+		return nil, nil, err
+	}
+	for hash := range task.storages {
+		if _, ok := task.accounts[hash]; !ok {
+			return nil, nil, fmt.Errorf("storage changes for unchanged account %x", hash)
+		}
+	}
+	var (
+		nodes = trienode.NewMergedNodeSet()
+		codes = make(map[common.Hash]struct{})
+	)
+	for hash, blob := range task.accounts {
+		// Resolve the storage root of the account before and after the diff
+		var (
+			oldRoot = types.EmptyRootHash
+			newRoot = types.EmptyRootHash
+			full    []byte
+		)
+		prev, err := accTrie.Get(hash[:])
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(prev) > 0 {
+			var account types.StateAccount
+			if err := rlp.DecodeBytes(prev, &account); err != nil {
+				return nil, nil, err
+			}
+			oldRoot = account.Root
+		}
+		if len(blob) > 0 {
+			account, err := types.FullAccount(blob)
+			if err != nil {
+				return nil, nil, err
+			}
+			if full, err = rlp.EncodeToBytes(account); err != nil {
+				return nil, nil, err
+			}
+			newRoot = account.Root
+			if codeHash := common.BytesToHash(account.CodeHash); codeHash != types.EmptyCodeHash && !rawdb.HasCodeWithPrefix(s.db, codeHash) {
+				codes[codeHash] = struct{}{}
+			}
+		}
+		// Apply the storage changes and ensure they match the new storage root
+		if slots := task.storages[hash]; len(slots) > 0 || oldRoot != newRoot {
+			stTrie, err := trie.New(trie.StorageTrieID(task.from, hash, oldRoot), nodedb)
+			if err != nil {
+				return nil, nil, err
+			}
+			for slot, value := range slots {
+				if len(value) == 0 {
+					err = stTrie.Delete(slot[:])
+				} else {
+					err = stTrie.Update(slot[:], value)
+				}
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+			root, set := stTrie.Commit(false)
+			if root != newRoot {
+				return nil, nil, fmt.Errorf("storage root mismatch for %x: have %x, want %x", hash, root, newRoot)
+			}
+			if set != nil {
+				if err := nodes.Merge(set); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+		if full == nil {
+			err = accTrie.Delete(hash[:])
+		} else {
+			err = accTrie.Update(hash[:], full)
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	root, set := accTrie.Commit(false)
+	if root != s.root {
+		return nil, nil, fmt.Errorf("state root mismatch: have %x, want %x", root, s.root)
+	}
+	if set != nil {
+		if err := nodes.Merge(set); err != nil {
+			return nil, nil, err
+		}
+	}
+	// Gather the trie nodes and flat states to be persisted. Stale nodes can only
+	// be removed in path mode, as they might be shared in hash mode.
+	batch := s.db.NewBatch()
+	for owner, set := range nodes.Sets {
+		set.ForEachWithOrder(func(path string, n *trienode.Node) {
+			if !n.IsDeleted() {
+				rawdb.WriteTrieNode(batch, owner, []byte(path), n.Hash, n.Blob, s.scheme)
+			} else if s.scheme == rawdb.PathScheme {
+				rawdb.DeleteTrieNode(batch, owner, []byte(path), common.Hash{}, s.scheme)
+			}
+		})
+	}
+	for hash, blob := range task.accounts {
+		if len(blob) == 0 {
+			rawdb.DeleteAccountSnapshot(batch, hash)
+		} else {
+			rawdb.WriteAccountSnapshot(batch, hash, blob)
+		}
+	}
+	for hash, slots := range task.storages {
+		for slot, value := range slots {
+			if len(value) == 0 {
+				rawdb.DeleteStorageSnapshot(batch, hash, slot)
+			} else {
+				rawdb.WriteStorageSnapshot(batch, hash, slot, value)
+			}
+		}
+	}
+	missing := make([]common.Hash, 0, len(codes))
+	for hash := range codes {
+		missing = append(missing, hash)
+	}
+	slices.SortFunc(missing, common.Hash.Cmp)
+	return batch, missing, nil
+}
+
+// OnStateDiff is a callback method to invoke when a chunk of a state diff is
+// received from a remote peer.
+func (s *Syncer) OnStateDiff(peer SyncPeer, id uint64, hashes []common.Hash, accounts [][]byte, slotHashes [][]common.Hash, slots [][][]byte, complete bool) error {
+	logger := peer.Log().New("reqid", id)
+	logger.Trace("Delivering state diff", "accounts", len(hashes), "complete", complete)
+
+	// Whether or not the response is valid, notify the scheduler to assign a new
+	// task. If the response is invalid, we'll drop the peer in a bit.
+	defer func() {
+		select {
+		case s.update <- struct{}{}:
+		default:
+		}
+	}()
+	s.lock.Lock()
+	// Ensure the response is for a valid request
+	req, ok := s.stateDiffReqs[id]
+	if !ok {
+		// Request stale, perhaps the peer timed out but came through in the end
+		logger.Warn("Unexpected state diff packet")
+		s.lock.Unlock()
+		return nil
+	}
+	delete(s.stateDiffReqs, id)
+	s.rates.Update(peer.ID(), StateDiffMsg, time.Since(req.time), len(hashes))
+	peerReputation(peer).Response(StateDiffMsg, time.Since(req.time))
+
+	// Clean up the request timeout timer, we'll see how to proceed further based
+	// on the actual delivered content
+	if !req.timeout.Stop() {
+		// The timeout is already triggered, and this request will be reverted+rescheduled
+		s.lock.Unlock()
+		return nil
+	}
+	s.lock.Unlock()
+
+	// Response is valid, but check if peer is signalling that it cannot serve the
+	// requested diff, e.g. because its state histories do not reach far enough.
+	if len(hashes) == 0 && !complete {
+		logger.Debug("Peer rejected state diff request")
+		s.scheduleRevertStateDiffRequest(req)
+		return nil
+	}
+	if len(hashes) > 0 && bytes.Compare(hashes[0][:], req.origin[:]) < 0 {
+		logger.Warn("State diff before requested origin", "origin", req.origin, "first", hashes[0])
+		s.scheduleRevertStateDiffRequest(req)
+		return errors.New("state diff before requested origin")
+	}
+	// Response validated, send it to the scheduler for filling
+	response := &stateDiffResponse{
+		task:       req.task,
+		peer:       peer.ID(),
+		hashes:     hashes,
+		accounts:   accounts,
+		slotHashes: slotHashes,
+		slots:      slots,
+		complete:   complete,
+	}
+	select {
+	case req.deliver <- response:
+	case <-req.cancel:
+	case <-req.stale:
+	}
+	return nil
+}
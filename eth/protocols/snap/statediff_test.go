@@ -0,0 +1,393 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snap
+
+import (
+	"bytes"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+	"github.com/ethereum/go-ethereum/triedb"
+	"github.com/holiman/uint256"
+)
+
+// defaultStateDiffHandler is a well-behaving state diff request handler, serving
+// the configured diff in chunks of two accounts, or rejecting if none is set.
+func defaultStateDiffHandler(t *testPeer, id uint64, from, to, origin common.Hash, max uint64) error {
+	hashes, accounts, slotHashes, slots, complete := createStateDiffResponse(t, origin, 2)
+	if err := t.remote.OnStateDiff(t, id, hashes, accounts, slotHashes, slots, complete); err != nil {
+		t.test.Errorf("Remote side rejected our delivery: %v", err)
+		t.term()
+	}
+	return nil
+}
+
+// corruptStateDiffHandler serves the configured diff with all account balances
+// bumped, breaking the resulting state root.
+func corruptStateDiffHandler(t *testPeer, id uint64, from, to, origin common.Hash, max uint64) error {
+	hashes, accounts, slotHashes, slots, complete := createStateDiffResponse(t, origin, 2)
+	for i, blob := range accounts {
+		if len(blob) == 0 {
+			continue
+		}
+		account, _ := types.FullAccount(blob)
+		account.Balance = new(uint256.Int).AddUint64(account.Balance, 1)
+		accounts[i] = types.SlimAccountRLP(*account)
+	}
+	if err := t.remote.OnStateDiff(t, id, hashes, accounts, slotHashes, slots, complete); err != nil {
+		t.test.Errorf("Remote side rejected our delivery: %v", err)
+		t.term()
+	}
+	return nil
+}
+
+func createStateDiffResponse(t *testPeer, origin common.Hash, limit int) ([]common.Hash, [][]byte, [][]common.Hash, [][][]byte, bool) {
+	if t.diffAccounts == nil {
+		return nil, nil, nil, nil, false
+	}
+	var keys []common.Hash
+	for hash := range t.diffAccounts {
+		if bytes.Compare(hash[:], origin[:]) >= 0 {
+			keys = append(keys, hash)
+		}
+	}
+	slices.SortFunc(keys, common.Hash.Cmp)
+
+	complete := len(keys) <= limit
+	if !complete {
+		keys = keys[:limit]
+	}
+	var (
+		accounts   = make([][]byte, len(keys))
+		slotHashes = make([][]common.Hash, len(keys))
+		slots      = make([][][]byte, len(keys))
+	)
+	for i, hash := range keys {
+		accounts[i] = t.diffAccounts[hash]
+		for slot := range t.diffStorages[hash] {
+			slotHashes[i] = append(slotHashes[i], slot)
+		}
+		slices.SortFunc(slotHashes[i], common.Hash.Cmp)
+		for _, slot := range slotHashes[i] {
+			slots[i] = append(slots[i], t.diffStorages[hash][slot])
+		}
+	}
+	return keys, accounts, slotHashes, slots, complete
+}
+
+// stateDiffFixture is a state with storage along with a child state modifying
+// it, and the flat state diff between the two.
+type stateDiffFixture struct {
+	baseTrie      *trie.Trie
+	baseValues    []*kv
+	baseStorages  map[common.Hash]*trie.Trie
+	baseSlots     map[common.Hash][]*kv
+	childTrie     *trie.Trie
+	childStorages map[common.Hash]*trie.Trie
+
+	accounts map[common.Hash][]byte
+	storages map[common.Hash]map[common.Hash][]byte
+}
+
+// makeStateDiffFixture creates a state of four accounts with storage, and a child
+// state which modifies an account, changes the storage of another, deletes a
+// third one and creates a new account with fresh code.
+func makeStateDiffFixture(scheme string) *stateDiffFixture {
+	var (
+		db       = triedb.NewDatabase(rawdb.NewMemoryDatabase(), newDbConfig(scheme))
+		accTrie  = trie.NewEmpty(db)
+		nodes    = trienode.NewMergedNodeSet()
+		accounts = make(map[common.Hash]*types.StateAccount)
+		fixture  = &stateDiffFixture{
+			baseStorages:  make(map[common.Hash]*trie.Trie),
+			baseSlots:     make(map[common.Hash][]*kv),
+			childStorages: make(map[common.Hash]*trie.Trie),
+			accounts:      make(map[common.Hash][]byte),
+			storages:      make(map[common.Hash]map[common.Hash][]byte),
+		}
+	)
+	for i := uint64(1); i <= 4; i++ {
+		key := common.BytesToHash(key32(i))
+		stRoot, stNodes, stEntries := makeStorageTrieWithSeed(key, 10, i, db)
+		nodes.Merge(stNodes)
+
+		accounts[key] = &types.StateAccount{
+			Nonce:    i,
+			Balance:  uint256.NewInt(i),
+			Root:     stRoot,
+			CodeHash: getCodeHash(i),
+		}
+		value, _ := rlp.EncodeToBytes(accounts[key])
+		accTrie.MustUpdate(key[:], value)
+
+		fixture.baseValues = append(fixture.baseValues, &kv{key[:], value})
+		fixture.baseSlots[key] = stEntries
+	}
+	slices.SortFunc(fixture.baseValues, (*kv).cmp)
+
+	baseRoot, set := accTrie.Commit(true)
+	nodes.Merge(set)
+	db.Update(baseRoot, types.EmptyRootHash, 0, nodes, triedb.NewStateSet())
+
+	// Derive the child state from the base one
+	accTrie, _ = trie.New(trie.StateTrieID(baseRoot), db)
+	nodes = trienode.NewMergedNodeSet()
+
+	update := func(key common.Hash, account *types.StateAccount) {
+		if account == nil {
+			accTrie.MustDelete(key[:])
+			fixture.accounts[key] = nil
+			return
+		}
+		value, _ := rlp.EncodeToBytes(account)
+		accTrie.MustUpdate(key[:], value)
+		fixture.accounts[key] = types.SlimAccountRLP(*account)
+	}
+	updateStorage := func(key common.Hash, slots map[common.Hash][]byte) common.Hash {
+		stTrie, _ := trie.New(trie.StorageTrieID(baseRoot, key, accounts[key].Root), db)
+		for slot, value := range slots {
+			if len(value) == 0 {
+				stTrie.MustDelete(slot[:])
+			} else {
+				stTrie.MustUpdate(slot[:], value)
+			}
+		}
+		root, set := stTrie.Commit(false)
+		if set != nil {
+			nodes.Merge(set)
+		}
+		fixture.storages[key] = slots
+		return root
+	}
+	// Account 1: balance change only
+	key := common.BytesToHash(key32(1))
+	accounts[key].Balance = uint256.NewInt(100)
+	update(key, accounts[key])
+
+	// Account 2: one slot updated, one deleted and one created
+	key = common.BytesToHash(key32(2))
+	value, _ := rlp.EncodeToBytes([]byte{0x42})
+	accounts[key].Root = updateStorage(key, map[common.Hash][]byte{
+		common.BytesToHash(fixture.baseSlots[key][0].k): value,
+		common.BytesToHash(fixture.baseSlots[key][1].k): nil,
+		crypto.Keccak256Hash(key32(100)):                value,
+	})
+	update(key, accounts[key])
+
+	// Account 3: deleted along with its storage
+	key = common.BytesToHash(key32(3))
+	slots := make(map[common.Hash][]byte)
+	for _, entry := range fixture.baseSlots[key] {
+		slots[common.BytesToHash(entry.k)] = nil
+	}
+	updateStorage(key, slots)
+	update(key, nil)
+
+	// Account 5: created with code not present in the base state
+	key = common.BytesToHash(key32(5))
+	accounts[key] = &types.StateAccount{
+		Nonce:    1,
+		Balance:  uint256.NewInt(5),
+		Root:     types.EmptyRootHash,
+		CodeHash: getCodeHash(5),
+	}
+	update(key, accounts[key])
+
+	childRoot, set := accTrie.Commit(true)
+	nodes.Merge(set)
+	db.Update(childRoot, baseRoot, 1, nodes, triedb.NewStateSet())
+
+	// Reopen the tries of both states to serve them
+	fixture.baseTrie, _ = trie.New(trie.StateTrieID(baseRoot), db)
+	fixture.childTrie, _ = trie.New(trie.StateTrieID(childRoot), db)
+	for i := uint64(1); i <= 4; i++ {
+		key := common.BytesToHash(key32(i))
+		root := types.EmptyRootHash
+		if blob, _ := fixture.baseTrie.Get(key[:]); len(blob) > 0 {
+			var account types.StateAccount
+			rlp.DecodeBytes(blob, &account)
+			root = account.Root
+		}
+		fixture.baseStorages[key], _ = trie.New(trie.StorageTrieID(baseRoot, key, root), db)
+
+		if account := accounts[key]; i != 3 {
+			fixture.childStorages[key], _ = trie.New(trie.StorageTrieID(childRoot, key, account.Root), db)
+		}
+	}
+	return fixture
+}
+
+// syncStateDiffBase syncs the base state of the fixture with the given peers
+// and switches them over to serving the child state.
+func syncStateDiffBase(t *testing.T, scheme string, fixture *stateDiffFixture, cancel chan struct{}, peers ...*testPeer) *Syncer {
+	t.Helper()
+
+	for _, peer := range peers {
+		peer.accountTrie = fixture.baseTrie.Copy()
+		peer.accountValues = fixture.baseValues
+		peer.setStorageTries(fixture.baseStorages)
+		peer.storageValues = fixture.baseSlots
+	}
+	syncer := setupSyncer(scheme, peers...)
+	if err := syncer.Sync(fixture.baseTrie.Hash(), cancel); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	for _, peer := range peers {
+		peer.accountTrie = fixture.childTrie.Copy()
+		peer.setStorageTries(fixture.childStorages)
+		peer.nTrienodeRequests = 0
+	}
+	return syncer
+}
+
+// TestSyncStateDiff tests that a fully synced state is moved forward to a new
+// root by retrieving the state diff instead of healing it.
+func TestSyncStateDiff(t *testing.T) {
+	t.Parallel()
+
+	testSyncStateDiff(t, rawdb.HashScheme)
+	testSyncStateDiff(t, rawdb.PathScheme)
+}
+
+func testSyncStateDiff(t *testing.T, scheme string) {
+	var (
+		once   sync.Once
+		cancel = make(chan struct{})
+		term   = func() {
+			once.Do(func() {
+				close(cancel)
+			})
+		}
+		fixture = makeStateDiffFixture(scheme)
+		source  = newTestPeer("source", t, term)
+	)
+	syncer := syncStateDiffBase(t, scheme, fixture, cancel, source)
+
+	source.diffAccounts = fixture.accounts
+	source.diffStorages = fixture.storages
+	if err := syncer.Sync(fixture.childTrie.Hash(), cancel); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	verifyTrie(scheme, syncer.db, fixture.childTrie.Hash(), t)
+
+	if source.nTrienodeRequests != 0 {
+		t.Errorf("state healed instead of diffed: %d trie node requests", source.nTrienodeRequests)
+	}
+	if source.nStateDiffRequests != 2 {
+		t.Errorf("state diff request count mismatch: have %d, want 2", source.nStateDiffRequests)
+	}
+	if syncer.completed != fixture.childTrie.Hash() {
+		t.Errorf("completed state mismatch: have %x, want %x", syncer.completed, fixture.childTrie.Hash())
+	}
+	if !rawdb.HasCode(syncer.db, common.BytesToHash(getCodeHash(5))) {
+		t.Error("code of created account missing")
+	}
+	for hash, blob := range fixture.accounts {
+		if have := rawdb.ReadAccountSnapshot(syncer.db, hash); !bytes.Equal(have, blob) {
+			t.Errorf("flat account %x mismatch: have %x, want %x", hash, have, blob)
+		}
+	}
+	// The completed state is persisted across syncer restarts
+	restarted := NewSyncer(syncer.db, scheme)
+	restarted.loadSyncStatus()
+	if restarted.completed != fixture.childTrie.Hash() {
+		t.Errorf("persisted completed state mismatch: have %x, want %x", restarted.completed, fixture.childTrie.Hash())
+	}
+}
+
+// TestSyncStateDiffFallback tests that the sync falls back to healing the state
+// if no peer can deliver a valid state diff.
+func TestSyncStateDiffFallback(t *testing.T) {
+	t.Parallel()
+
+	testSyncStateDiffFallback(t, rawdb.HashScheme)
+	testSyncStateDiffFallback(t, rawdb.PathScheme)
+}
+
+func testSyncStateDiffFallback(t *testing.T, scheme string) {
+	var (
+		once   sync.Once
+		cancel = make(chan struct{})
+		term   = func() {
+			once.Do(func() {
+				close(cancel)
+			})
+		}
+		fixture = makeStateDiffFixture(scheme)
+		corrupt = newTestPeer("corrupt", t, term)
+		useless = newTestPeer("useless", t, term)
+	)
+	syncer := syncStateDiffBase(t, scheme, fixture, cancel, corrupt, useless)
+
+	corrupt.diffAccounts = fixture.accounts
+	corrupt.diffStorages = fixture.storages
+	corrupt.stateDiffHandler = corruptStateDiffHandler
+
+	if err := syncer.Sync(fixture.childTrie.Hash(), cancel); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	verifyTrie(scheme, syncer.db, fixture.childTrie.Hash(), t)
+
+	if corrupt.nStateDiffRequests == 0 || useless.nStateDiffRequests == 0 {
+		t.Errorf("state diff not requested from all peers: %d, %d", corrupt.nStateDiffRequests, useless.nStateDiffRequests)
+	}
+	if corrupt.nTrienodeRequests+useless.nTrienodeRequests == 0 {
+		t.Error("state not healed")
+	}
+	if syncer.completed != fixture.childTrie.Hash() {
+		t.Errorf("completed state mismatch: have %x, want %x", syncer.completed, fixture.childTrie.Hash())
+	}
+}
+
+// TestAssignStateDiffTasksAbandon tests that the state diff is abandoned in
+// favour of healing if none of the connected peers can serve it, even if some
+// of them never failed to deliver it but are known to be stateless.
+func TestAssignStateDiffTasksAbandon(t *testing.T) {
+	t.Parallel()
+
+	var (
+		term    = func() {}
+		failed  = newTestPeer("failed", t, term)
+		missing = newTestPeer("stateless", t, term)
+		syncer  = setupSyncer(rawdb.HashScheme, failed, missing)
+		from    = common.HexToHash("0x01")
+	)
+	syncer.root = common.HexToHash("0x02")
+	syncer.completed = from
+	syncer.statelessPeers = map[string]struct{}{missing.id: {}}
+	syncer.diff = newStateDiffTask(from)
+	syncer.diff.failed[failed.id] = struct{}{}
+
+	syncer.assignStateDiffTasks(make(chan *stateDiffResponse), make(chan *stateDiffRequest), make(chan struct{}))
+	if syncer.diff != nil {
+		t.Fatal("state diff not abandoned")
+	}
+	if syncer.completed != (common.Hash{}) {
+		t.Errorf("completed state not invalidated: %x", syncer.completed)
+	}
+	if len(syncer.stateDiffReqs) != 0 {
+		t.Errorf("state diff requested: %d pending requests", len(syncer.stateDiffReqs))
+	}
+}
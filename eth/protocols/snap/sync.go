@@ -39,6 +39,7 @@ import (
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p/msgrate"
+	"github.com/ethereum/go-ethereum/p2p/reputation"
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/trie"
 	"github.com/ethereum/go-ethereum/trie/trienode"
@@ -379,7 +380,8 @@ type healTask struct {
 // sync. Opposed to full and fast sync, there is no way to restart a suspended
 // snap sync without prior knowledge of the suspension point.
 type SyncProgress struct {
-	Tasks []*accountTask // The suspended account tasks (contract tasks within)
+	Tasks     []*accountTask // The suspended account tasks (contract tasks within)
+	Completed common.Hash    // Root of the last fully synced state, if still intact
 
 	// Status report during syncing phase
 	AccountSynced  uint64             // Number of accounts downloaded
@@ -426,10 +428,23 @@ type SyncPeer interface {
 	// a specific state trie.
 	RequestTrieNodes(id uint64, root common.Hash, paths []TrieNodePathSet, bytes uint64) error
 
+	// RequestStateDiff fetches a batch of the state changes between two state
+	// roots, starting with the origin account.
+	RequestStateDiff(id uint64, from, to, origin common.Hash, bytes uint64) error
+
 	// Log retrieves the peer's own contextual logger.
 	Log() log.Logger
 }
 
+// peerReputation returns the reputation handle of a sync peer backed by a live
+// network connection, or a handle discarding all events for mock peers.
+func peerReputation(peer SyncPeer) reputation.Handle {
+	if p, ok := peer.(interface{ Reputation() reputation.Handle }); ok {
+		return p.Reputation()
+	}
+	return reputation.Handle{}
+}
+
 // Syncer is an Ethereum account and storage trie syncer based on snapshots and
 // the  snap protocol. It's purpose is to download all the accounts and storage
 // slots from remote peers and reassemble chunks of the state trie, on top of
@@ -451,6 +466,9 @@ type Syncer struct {
 	healer  *healTask      // Current state healing task being executed
 	update  chan struct{}  // Notification channel for possible sync progression
 
+	completed common.Hash    // Root of the last fully synced state, if still intact
+	diff      *stateDiffTask // State diff task moving the completed state to the root
+
 	peers    map[string]SyncPeer // Currently active peers to download from
 	peerJoin *event.Feed         // Event feed to react to peers joining
 	peerDrop *event.Feed         // Event feed to react to peers dropping
@@ -481,6 +499,7 @@ type Syncer struct {
 
 	trienodeHealReqs map[uint64]*trienodeHealRequest // Trie node requests currently running
 	bytecodeHealReqs map[uint64]*bytecodeHealRequest // Bytecode requests currently running
+	stateDiffReqs    map[uint64]*stateDiffRequest    // State diff requests currently running
 
 	trienodeHealRate      float64       // Average heal rate for processing trie node data
 	trienodeHealPend      atomic.Uint64 // Number of trie nodes currently pending for processing
@@ -537,6 +556,7 @@ func NewSyncer(db ethdb.KeyValueStore, scheme string) *Syncer {
 
 		trienodeHealReqs:     make(map[uint64]*trienodeHealRequest),
 		bytecodeHealReqs:     make(map[uint64]*bytecodeHealRequest),
+		stateDiffReqs:        make(map[uint64]*stateDiffRequest),
 		trienodeHealThrottle: maxTrienodeHealThrottle, // Tune downward instead of insta-filling with junk
 		stateWriter:          db.NewBatch(),
 
@@ -609,11 +629,8 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 	// any peers and initialize the syncer if it was not yet run
 	s.lock.Lock()
 	s.root = root
-	s.healer = &healTask{
-		scheduler: state.NewStateSync(root, s.db, s.onHealState, s.scheme),
-		trieTasks: make(map[string]common.Hash),
-		codeTasks: make(map[common.Hash]struct{}),
-	}
+	s.healer = s.newHealTask(root)
+	s.diff = nil
 	s.statelessPeers = make(map[string]struct{})
 	s.lock.Unlock()
 
@@ -626,6 +643,20 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 		log.Debug("Snapshot sync already completed")
 		return nil
 	}
+	// If a previous cycle completed the state of an earlier root, try to move it
+	// forward by a state diff instead of healing it node by node
+	if len(s.tasks) == 0 && s.completed != (common.Hash{}) {
+		s.lock.Lock()
+		if s.hasCompletedState(s.completed) {
+			log.Info("Moving synced state forward", "from", s.completed, "root", root)
+			s.diff = newStateDiffTask(s.completed)
+			s.healer = s.newCodeHealTask(nil)
+		} else {
+			s.completed = common.Hash{}
+			s.saveSyncStatus()
+		}
+		s.lock.Unlock()
+	}
 	defer func() { // Persist any progress, independent of failure
 		for _, task := range s.tasks {
 			s.forwardAccountTask(task)
@@ -656,6 +687,7 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 		s.bytecodeReqs = make(map[uint64]*bytecodeRequest)
 		s.trienodeHealReqs = make(map[uint64]*trienodeHealRequest)
 		s.bytecodeHealReqs = make(map[uint64]*bytecodeHealRequest)
+		s.stateDiffReqs = make(map[uint64]*stateDiffRequest)
 		s.lock.Unlock()
 	}()
 	// Keep scheduling sync tasks
@@ -681,12 +713,14 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 		bytecodeHealReqFails = make(chan *bytecodeHealRequest)
 		trienodeHealResps    = make(chan *trienodeHealResponse)
 		bytecodeHealResps    = make(chan *bytecodeHealResponse)
+		stateDiffReqFails    = make(chan *stateDiffRequest)
+		stateDiffResps       = make(chan *stateDiffResponse)
 	)
 	for {
 		// Remove all completed tasks and terminate sync if everything's done
 		s.cleanStorageTasks()
 		s.cleanAccountTasks()
-		if len(s.tasks) == 0 && s.healer.scheduler.Pending() == 0 {
+		if len(s.tasks) == 0 && s.healer.scheduler.Pending() == 0 && (s.diff == nil || s.diff.batch != nil) {
 			// State healing phase completed, record the elapsed time in metrics.
 			// Note: healing may be rerun in subsequent cycles to fill gaps between
 			// pivot states (e.g., if chain sync takes longer).
@@ -695,6 +729,17 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 				log.Info("State healing phase is completed", "elapsed", common.PrettyDuration(time.Since(s.healStartTime)))
 				s.healStartTime = time.Time{}
 			}
+			// If the state was moved forward by a diff, persist the changes now
+			// that all the new bytecodes are available
+			if s.diff != nil {
+				s.commitHealer(true)
+				if err := s.diff.batch.Write(); err != nil {
+					log.Crit("Failed to persist state diff", "err", err)
+				}
+				log.Info("Moved synced state forward", "from", s.diff.from, "root", root)
+				s.diff = nil
+			}
+			s.completed = root
 			return nil
 		}
 		// Assign all the data retrieval tasks to any free peers
@@ -711,11 +756,15 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 				stateSyncTimeGauge.Update(int64(time.Since(s.startTime)))
 				log.Info("State sync phase is completed", "elapsed", common.PrettyDuration(time.Since(s.startTime)))
 			})
-			if s.healStartTime.IsZero() {
-				s.healStartTime = time.Now()
+			if s.diff != nil && s.diff.batch == nil {
+				s.assignStateDiffTasks(stateDiffResps, stateDiffReqFails, cancel)
+			} else {
+				if s.healStartTime.IsZero() && s.diff == nil {
+					s.healStartTime = time.Now()
+				}
+				s.assignTrienodeHealTasks(trienodeHealResps, trienodeHealReqFails, cancel)
+				s.assignBytecodeHealTasks(bytecodeHealResps, bytecodeHealReqFails, cancel)
 			}
-			s.assignTrienodeHealTasks(trienodeHealResps, trienodeHealReqFails, cancel)
-			s.assignBytecodeHealTasks(bytecodeHealResps, bytecodeHealReqFails, cancel)
 		}
 		// Update sync progress
 		s.lock.Lock()
@@ -753,6 +802,8 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 			s.revertTrienodeHealRequest(req)
 		case req := <-bytecodeHealReqFails:
 			s.revertBytecodeHealRequest(req)
+		case req := <-stateDiffReqFails:
+			s.revertStateDiffRequest(req)
 
 		case res := <-accountResps:
 			s.processAccountResponse(res)
@@ -764,12 +815,23 @@ func (s *Syncer) Sync(root common.Hash, cancel chan struct{}) error {
 			s.processTrienodeHealResponse(res)
 		case res := <-bytecodeHealResps:
 			s.processBytecodeHealResponse(res)
+		case res := <-stateDiffResps:
+			s.processStateDiffResponse(res)
 		}
 		// Report stats if something meaningful happened
 		s.report(false)
 	}
 }
 
+// newHealTask creates a healer to fix up the state trie with the given root.
+func (s *Syncer) newHealTask(root common.Hash) *healTask {
+	return &healTask{
+		scheduler: state.NewStateSync(root, s.db, s.onHealState, s.scheme),
+		trieTasks: make(map[string]common.Hash),
+		codeTasks: make(map[common.Hash]struct{}),
+	}
+}
+
 // loadSyncStatus retrieves a previously aborted sync status from the database,
 // or generates a fresh one if none is available.
 func (s *Syncer) loadSyncStatus() {
@@ -826,6 +888,7 @@ func (s *Syncer) loadSyncStatus() {
 			defer s.lock.Unlock()
 
 			s.snapped = len(s.tasks) == 0
+			s.completed = progress.Completed
 
 			s.accountSynced = progress.AccountSynced
 			s.accountBytes = progress.AccountBytes
@@ -845,6 +908,7 @@ func (s *Syncer) loadSyncStatus() {
 	// Start a fresh sync by chunking up the account range and scheduling
 	// them for retrieval.
 	s.tasks = nil
+	s.completed = common.Hash{}
 	s.accountSynced, s.accountBytes = 0, 0
 	s.bytecodeSynced, s.bytecodeBytes = 0, 0
 	s.storageSynced, s.storageBytes = 0, 0
@@ -923,6 +987,7 @@ func (s *Syncer) saveSyncStatus() {
 	// Store the actual progress markers
 	progress := &SyncProgress{
 		Tasks:              s.tasks,
+		Completed:          s.completed,
 		AccountSynced:      s.accountSynced,
 		AccountBytes:       s.accountBytes,
 		BytecodeSynced:     s.bytecodeSynced,
@@ -1086,6 +1151,7 @@ func (s *Syncer) assignAccountTasks(success chan *accountResponse, fail chan *ac
 		req.timeout = time.AfterFunc(s.rates.TargetTimeout(), func() {
 			peer.Log().Debug("Account range request timed out", "reqid", reqid)
 			s.rates.Update(idle, AccountRangeMsg, 0, 0)
+			peerReputation(peer).Timeout()
 			s.scheduleRevertAccountRequest(req)
 		})
 		s.accountReqs[reqid] = req
@@ -1197,6 +1263,7 @@ func (s *Syncer) assignBytecodeTasks(success chan *bytecodeResponse, fail chan *
 		req.timeout = time.AfterFunc(s.rates.TargetTimeout(), func() {
 			peer.Log().Debug("Bytecode request timed out", "reqid", reqid)
 			s.rates.Update(idle, ByteCodesMsg, 0, 0)
+			peerReputation(peer).Timeout()
 			s.scheduleRevertBytecodeRequest(req)
 		})
 		s.bytecodeReqs[reqid] = req
@@ -1345,6 +1412,7 @@ func (s *Syncer) assignStorageTasks(success chan *storageResponse, fail chan *st
 		req.timeout = time.AfterFunc(s.rates.TargetTimeout(), func() {
 			peer.Log().Debug("Storage request timed out", "reqid", reqid)
 			s.rates.Update(idle, StorageRangesMsg, 0, 0)
+			peerReputation(peer).Timeout()
 			s.scheduleRevertStorageRequest(req)
 		})
 		s.storageReqs[reqid] = req
@@ -1482,6 +1550,7 @@ func (s *Syncer) assignTrienodeHealTasks(success chan *trienodeHealResponse, fai
 		req.timeout = time.AfterFunc(s.rates.TargetTimeout(), func() {
 			peer.Log().Debug("Trienode heal request timed out", "reqid", reqid)
 			s.rates.Update(idle, TrieNodesMsg, 0, 0)
+			peerReputation(peer).Timeout()
 			s.scheduleRevertTrienodeHealRequest(req)
 		})
 		s.trienodeHealReqs[reqid] = req
@@ -1598,6 +1667,7 @@ func (s *Syncer) assignBytecodeHealTasks(success chan *bytecodeHealResponse, fai
 		req.timeout = time.AfterFunc(s.rates.TargetTimeout(), func() {
 			peer.Log().Debug("Bytecode heal request timed out", "reqid", reqid)
 			s.rates.Update(idle, ByteCodesMsg, 0, 0)
+			peerReputation(peer).Timeout()
 			s.scheduleRevertBytecodeHealRequest(req)
 		})
 		s.bytecodeHealReqs[reqid] = req
@@ -1651,6 +1721,12 @@ func (s *Syncer) revertRequests(peer string) {
 			bytecodeHealReqs = append(bytecodeHealReqs, req)
 		}
 	}
+	var stateDiffReqs []*stateDiffRequest
+	for _, req := range s.stateDiffReqs {
+		if req.peer == peer {
+			stateDiffReqs = append(stateDiffReqs, req)
+		}
+	}
 	s.lock.Unlock()
 
 	// Revert all the requests matching the peer
@@ -1669,6 +1745,9 @@ func (s *Syncer) revertRequests(peer string) {
 	for _, req := range bytecodeHealReqs {
 		s.revertBytecodeHealRequest(req)
 	}
+	for _, req := range stateDiffReqs {
+		s.revertStateDiffRequest(req)
+	}
 }
 
 // scheduleRevertAccountRequest asks the event loop to clean up an account range
@@ -2537,6 +2616,7 @@ func (s *Syncer) OnAccounts(peer SyncPeer, id uint64, hashes []common.Hash, acco
 	}
 	delete(s.accountReqs, id)
 	s.rates.Update(peer.ID(), AccountRangeMsg, time.Since(req.time), int(size))
+	peerReputation(peer).Response(AccountRangeMsg, time.Since(req.time))
 
 	// Clean up the request timeout timer, we'll see how to proceed further based
 	// on the actual delivered content
@@ -2647,6 +2727,7 @@ func (s *Syncer) onByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) error
 	}
 	delete(s.bytecodeReqs, id)
 	s.rates.Update(peer.ID(), ByteCodesMsg, time.Since(req.time), len(bytecodes))
+	peerReputation(peer).Response(ByteCodesMsg, time.Since(req.time))
 
 	// Clean up the request timeout timer, we'll see how to proceed further based
 	// on the actual delivered content
@@ -2760,6 +2841,7 @@ func (s *Syncer) OnStorage(peer SyncPeer, id uint64, hashes [][]common.Hash, slo
 	}
 	delete(s.storageReqs, id)
 	s.rates.Update(peer.ID(), StorageRangesMsg, time.Since(req.time), int(size))
+	peerReputation(peer).Response(StorageRangesMsg, time.Since(req.time))
 
 	// Clean up the request timeout timer, we'll see how to proceed further based
 	// on the actual delivered content
@@ -2894,6 +2976,7 @@ func (s *Syncer) OnTrieNodes(peer SyncPeer, id uint64, trienodes [][]byte) error
 	}
 	delete(s.trienodeHealReqs, id)
 	s.rates.Update(peer.ID(), TrieNodesMsg, time.Since(req.time), len(trienodes))
+	peerReputation(peer).Response(TrieNodesMsg, time.Since(req.time))
 
 	// Clean up the request timeout timer, we'll see how to proceed further based
 	// on the actual delivered content
@@ -3001,6 +3084,7 @@ func (s *Syncer) onHealByteCodes(peer SyncPeer, id uint64, bytecodes [][]byte) e
 	}
 	delete(s.bytecodeHealReqs, id)
 	s.rates.Update(peer.ID(), ByteCodesMsg, time.Since(req.time), len(bytecodes))
+	peerReputation(peer).Response(ByteCodesMsg, time.Since(req.time))
 
 	// Clean up the request timeout timer, we'll see how to proceed further based
 	// on the actual delivered content
@@ -119,10 +119,11 @@ func BenchmarkHashing(b *testing.B) {
 }
 
 type (
-	accountHandlerFunc func(t *testPeer, requestId uint64, root common.Hash, origin common.Hash, limit common.Hash, cap uint64) error
-	storageHandlerFunc func(t *testPeer, requestId uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, max uint64) error
-	trieHandlerFunc    func(t *testPeer, requestId uint64, root common.Hash, paths []TrieNodePathSet, cap uint64) error
-	codeHandlerFunc    func(t *testPeer, id uint64, hashes []common.Hash, max uint64) error
+	accountHandlerFunc   func(t *testPeer, requestId uint64, root common.Hash, origin common.Hash, limit common.Hash, cap uint64) error
+	storageHandlerFunc   func(t *testPeer, requestId uint64, root common.Hash, accounts []common.Hash, origin, limit []byte, max uint64) error
+	trieHandlerFunc      func(t *testPeer, requestId uint64, root common.Hash, paths []TrieNodePathSet, cap uint64) error
+	codeHandlerFunc      func(t *testPeer, id uint64, hashes []common.Hash, max uint64) error
+	stateDiffHandlerFunc func(t *testPeer, id uint64, from, to, origin common.Hash, max uint64) error
 )
 
 type testPeer struct {
@@ -134,18 +135,22 @@ type testPeer struct {
 	accountValues []*kv
 	storageTries  map[common.Hash]*trie.Trie
 	storageValues map[common.Hash][]*kv
+	diffAccounts  map[common.Hash][]byte
+	diffStorages  map[common.Hash]map[common.Hash][]byte
 
 	accountRequestHandler accountHandlerFunc
 	storageRequestHandler storageHandlerFunc
 	trieRequestHandler    trieHandlerFunc
 	codeRequestHandler    codeHandlerFunc
+	stateDiffHandler      stateDiffHandlerFunc
 	term                  func()
 
 	// counters
-	nAccountRequests  int
-	nStorageRequests  int
-	nBytecodeRequests int
-	nTrienodeRequests int
+	nAccountRequests   int
+	nStorageRequests   int
+	nBytecodeRequests  int
+	nTrienodeRequests  int
+	nStateDiffRequests int
 }
 
 func newTestPeer(id string, t *testing.T, term func()) *testPeer {
@@ -157,6 +162,7 @@ func newTestPeer(id string, t *testing.T, term func()) *testPeer {
 		trieRequestHandler:    defaultTrieRequestHandler,
 		storageRequestHandler: defaultStorageRequestHandler,
 		codeRequestHandler:    defaultCodeRequestHandler,
+		stateDiffHandler:      defaultStateDiffHandler,
 		term:                  term,
 	}
 	//stderrHandler := log.StreamHandler(os.Stderr, log.TerminalFormat(true))
@@ -214,6 +220,13 @@ func (t *testPeer) RequestByteCodes(id uint64, hashes []common.Hash, bytes uint6
 	return nil
 }
 
+func (t *testPeer) RequestStateDiff(id uint64, from, to, origin common.Hash, bytes uint64) error {
+	t.nStateDiffRequests++
+	t.logger.Trace("Fetching state diff", "reqid", id, "from", from, "to", to, "origin", origin, "bytes", common.StorageSize(bytes))
+	go t.stateDiffHandler(t, id, from, to, origin, bytes)
+	return nil
+}
+
 // defaultTrieRequestHandler is a well-behaving handler for trie healing requests
 func defaultTrieRequestHandler(t *testPeer, requestId uint64, root common.Hash, paths []TrieNodePathSet, cap uint64) error {
 	// Pass the response
@@ -0,0 +1,225 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// requestTimeout is the maximum time to wait for a single peer to respond to
+// a witness request before moving on to the next one.
+const requestTimeout = 2 * time.Second
+
+var (
+	errPeerAlreadyRegistered = errors.New("peer already registered")
+	errPeerNotRegistered     = errors.New("peer not registered")
+	errNoWitnessPeers        = errors.New("no witness peers")
+	errPeerDropped           = errors.New("peer dropped")
+	errWitnessUnavailable    = errors.New("witness not served")
+)
+
+// Fetcher keeps track of the connected `wit` peers and retrieves execution
+// witnesses from them.
+type Fetcher struct {
+	peers   map[string]*Peer           // Currently connected `wit` peers
+	pending map[uint64]*witnessRequest // Requests waiting for a response
+	lock    sync.Mutex
+}
+
+// witnessRequest is a pending witness retrieval from a single peer.
+type witnessRequest struct {
+	peer    string
+	deliver chan *WitnessesPacket // Closed if the peer disconnects
+}
+
+// NewFetcher creates a witness fetcher without any peers.
+func NewFetcher() *Fetcher {
+	return &Fetcher{
+		peers:   make(map[string]*Peer),
+		pending: make(map[uint64]*witnessRequest),
+	}
+}
+
+// Register injects a new `wit` peer into the set of retrieval sources.
+func (f *Fetcher) Register(peer *Peer) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.peers[peer.id]; ok {
+		return errPeerAlreadyRegistered
+	}
+	f.peers[peer.id] = peer
+	return nil
+}
+
+// Unregister removes a `wit` peer and aborts all requests pending on it.
+func (f *Fetcher) Unregister(id string) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if _, ok := f.peers[id]; !ok {
+		return errPeerNotRegistered
+	}
+	delete(f.peers, id)
+	for reqid, req := range f.pending {
+		if req.peer == id {
+			close(req.deliver)
+			delete(f.pending, reqid)
+		}
+	}
+	return nil
+}
+
+// Peer retrieves the registered peer with the given id.
+func (f *Fetcher) Peer(id string) *Peer {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	return f.peers[id]
+}
+
+// Peers returns all registered peers.
+func (f *Fetcher) Peers() []*Peer {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	peers := make([]*Peer, 0, len(f.peers))
+	for _, p := range f.peers {
+		peers = append(peers, p)
+	}
+	return peers
+}
+
+// Deliver injects a witness response received from a remote peer. Responses
+// to unknown or timed out requests are dropped.
+func (f *Fetcher) Deliver(peer *Peer, packet *WitnessesPacket) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	req, ok := f.pending[packet.ID]
+	if !ok || req.peer != peer.id {
+		peer.Log().Debug("Unexpected witness response", "reqid", packet.ID)
+		return nil
+	}
+	delete(f.pending, packet.ID)
+	req.deliver <- packet
+	return nil
+}
+
+// Fetch retrieves the execution witness of a block from the connected peers.
+// Peers which announced the witness are asked first, the others afterwards,
+// until a witness is found that passes the given verification or the context
+// is canceled.
+func (f *Fetcher) Fetch(ctx context.Context, hash common.Hash, verify func(*stateless.Witness) error) (*stateless.Witness, error) {
+	defer func(start time.Time) { fetchTimer.UpdateSince(start) }(time.Now())
+
+	peers := f.Peers()
+	if len(peers) == 0 {
+		fetchFailureMeter.Mark(1)
+		return nil, errNoWitnessPeers
+	}
+	rand.Shuffle(len(peers), func(i, j int) { peers[i], peers[j] = peers[j], peers[i] })
+	slices.SortStableFunc(peers, func(a, b *Peer) int {
+		switch ak, bk := a.KnownWitness(hash), b.KnownWitness(hash); {
+		case ak && !bk:
+			return -1
+		case bk && !ak:
+			return 1
+		}
+		return 0
+	})
+	var err error
+	for _, peer := range peers {
+		var witness *stateless.Witness
+		if witness, err = f.fetchFrom(ctx, peer, hash); err == nil {
+			if err = verify(witness); err == nil {
+				fetchSuccessMeter.Mark(1)
+				return witness, nil
+			}
+		}
+		peer.Log().Debug("Witness retrieval failed", "hash", hash, "err", err)
+		if ctx.Err() != nil {
+			err = ctx.Err()
+			break
+		}
+	}
+	fetchFailureMeter.Mark(1)
+	return nil, fmt.Errorf("witness of block %x not retrieved: %w", hash, err)
+}
+
+// fetchFrom requests the witness of a single block from a peer and waits for
+// the response.
+func (f *Fetcher) fetchFrom(ctx context.Context, peer *Peer, hash common.Hash) (*stateless.Witness, error) {
+	req := &witnessRequest{peer: peer.id, deliver: make(chan *WitnessesPacket, 1)}
+
+	f.lock.Lock()
+	if _, ok := f.peers[peer.id]; !ok {
+		f.lock.Unlock()
+		return nil, errPeerDropped
+	}
+	var id uint64
+	for {
+		id = rand.Uint64()
+		if _, ok := f.pending[id]; !ok {
+			break
+		}
+	}
+	f.pending[id] = req
+	f.lock.Unlock()
+
+	defer func() {
+		f.lock.Lock()
+		if f.pending[id] == req {
+			delete(f.pending, id)
+		}
+		f.lock.Unlock()
+	}()
+	if err := peer.RequestWitnesses(id, []common.Hash{hash}); err != nil {
+		return nil, err
+	}
+	timeout := time.NewTimer(requestTimeout)
+	defer timeout.Stop()
+
+	select {
+	case res, ok := <-req.deliver:
+		if !ok {
+			return nil, errPeerDropped
+		}
+		if len(res.Witnesses) == 0 {
+			return nil, errWitnessUnavailable
+		}
+		witness := new(stateless.Witness)
+		if err := rlp.DecodeBytes(res.Witnesses[0], witness); err != nil {
+			return nil, fmt.Errorf("%w: %v", errDecode, err)
+		}
+		return witness, nil
+	case <-timeout.C:
+		return nil, errors.New("request timed out")
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
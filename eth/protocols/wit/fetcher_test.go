@@ -0,0 +1,152 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// fetchBackend is a wit.Backend delivering witness responses to a fetcher.
+type fetchBackend struct {
+	testBackend
+	fetcher *Fetcher
+}
+
+func (b *fetchBackend) Handle(peer *Peer, packet Packet) error {
+	if res, ok := packet.(*WitnessesPacket); ok {
+		return b.fetcher.Deliver(peer, res)
+	}
+	return nil
+}
+
+// startFetchPeer registers a peer in the fetcher, which answers all witness
+// requests with the given witness. If respond is false, requests are ignored.
+func startFetchPeer(t *testing.T, fetcher *Fetcher, id string, witness rlp.RawValue, respond bool) *Peer {
+	t.Helper()
+
+	local, remote := p2p.MsgPipe()
+	t.Cleanup(func() { local.Close() })
+
+	peer := NewFakePeer(WIT1, id, local)
+	if err := fetcher.Register(peer); err != nil {
+		t.Fatal(err)
+	}
+	go Handle(&fetchBackend{fetcher: fetcher}, peer)
+	go func() {
+		for {
+			msg, err := remote.ReadMsg()
+			if err != nil {
+				return
+			}
+			var req GetWitnessesPacket
+			if err := msg.Decode(&req); err != nil {
+				return
+			}
+			if !respond {
+				continue
+			}
+			res := &WitnessesPacket{ID: req.ID}
+			if witness != nil {
+				res.Witnesses = []rlp.RawValue{witness}
+			}
+			if err := p2p.Send(remote, WitnessesMsg, res); err != nil {
+				return
+			}
+		}
+	}()
+	return peer
+}
+
+func TestFetcher(t *testing.T) {
+	t.Parallel()
+
+	var (
+		fetcher = NewFetcher()
+		hash    = common.Hash{1}
+		_, bad  = makeTestWitness(t, 1)
+		_, good = makeTestWitness(t, 2)
+	)
+	if _, err := fetcher.Fetch(context.Background(), hash, nil); !errors.Is(err, errNoWitnessPeers) {
+		t.Fatalf("wrong error without peers: %v", err)
+	}
+	announcer := startFetchPeer(t, fetcher, "aaaaaaaaaaaaaaaa", bad, true)
+	announcer.markWitness(hash)
+	startFetchPeer(t, fetcher, "bbbbbbbbbbbbbbbb", nil, true)
+	startFetchPeer(t, fetcher, "cccccccccccccccc", good, true)
+
+	// The announcing peer must be tried first, and invalid or missing witnesses
+	// must be skipped.
+	var tried []uint64
+	witness, err := fetcher.Fetch(context.Background(), hash, func(w *stateless.Witness) error {
+		tried = append(tried, w.Headers[0].Number.Uint64())
+		if w.Headers[0].Number.Uint64() != 2 {
+			return errors.New("invalid witness")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("fetch failed: %v", err)
+	}
+	if witness.Headers[0].Number.Uint64() != 2 {
+		t.Errorf("wrong witness retrieved: parent %d", witness.Headers[0].Number)
+	}
+	if len(tried) != 2 || tried[0] != 1 {
+		t.Errorf("wrong verification order: %v", tried)
+	}
+}
+
+func TestFetcherPeerDrop(t *testing.T) {
+	t.Parallel()
+
+	fetcher := NewFetcher()
+	startFetchPeer(t, fetcher, "aaaaaaaaaaaaaaaa", nil, false)
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := fetcher.Fetch(context.Background(), common.Hash{1}, func(*stateless.Witness) error { return nil })
+		errc <- err
+	}()
+	// Wait for the request to be sent, then drop the peer.
+	for {
+		fetcher.lock.Lock()
+		pending := len(fetcher.pending)
+		fetcher.lock.Unlock()
+		if pending > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := fetcher.Unregister("aaaaaaaaaaaaaaaa"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-errc:
+		if !errors.Is(err, errPeerDropped) {
+			t.Fatalf("wrong error: %v", err)
+		}
+	case <-time.After(requestTimeout / 2):
+		t.Fatal("fetch not aborted by peer drop")
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wit
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/stateless"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// testBackend is a wit.Backend serving a fixed set of witnesses.
+type testBackend struct {
+	witnesses map[common.Hash]rlp.RawValue
+	delivered []Packet
+}
+
+func (b *testBackend) Chain() *core.BlockChain                   { return nil }
+func (b *testBackend) Witness(hash common.Hash) rlp.RawValue     { return b.witnesses[hash] }
+func (b *testBackend) RunPeer(peer *Peer, handler Handler) error { return handler(peer) }
+func (b *testBackend) PeerInfo(enode.ID) interface{}             { return nil }
+func (b *testBackend) Handle(peer *Peer, packet Packet) error {
+	b.delivered = append(b.delivered, packet)
+	return nil
+}
+
+// makeTestWitness creates an encoded witness whose parent header has the given
+// block number.
+func makeTestWitness(t *testing.T, number int64) (*stateless.Witness, rlp.RawValue) {
+	t.Helper()
+	witness := &stateless.Witness{
+		Headers: []*types.Header{{Number: big.NewInt(number), Difficulty: common.Big0}},
+		Codes:   map[string]struct{}{"code": {}},
+		State:   map[string]struct{}{"node": {}},
+	}
+	enc, err := rlp.EncodeToBytes(witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return witness, enc
+}
+
+func TestServiceGetWitnesses(t *testing.T) {
+	t.Parallel()
+
+	backend := &testBackend{witnesses: make(map[common.Hash]rlp.RawValue)}
+	var hashes []common.Hash
+	for i := 0; i < maxWitnessLookups+2; i++ {
+		hash := common.Hash{byte(i + 1)}
+		_, backend.witnesses[hash] = makeTestWitness(t, int64(i))
+		hashes = append(hashes, hash)
+	}
+	// Responses are capped in count
+	if res := ServiceGetWitnessesQuery(backend, &GetWitnessesPacket{Hashes: hashes}); len(res) != maxWitnessLookups {
+		t.Errorf("wrong number of witnesses served: have %d, want %d", len(res), maxWitnessLookups)
+	}
+	// Responses are cut at the first unavailable witness
+	query := []common.Hash{hashes[0], {0xff}, hashes[1]}
+	if res := ServiceGetWitnessesQuery(backend, &GetWitnessesPacket{Hashes: query}); len(res) != 1 || !bytes.Equal(res[0], backend.witnesses[hashes[0]]) {
+		t.Errorf("wrong witnesses served for partially available query: %d", len(res))
+	}
+	// Responses are capped in size, but at least one witness is served
+	large := common.Hash{0xee}
+	backend.witnesses[large] = make(rlp.RawValue, softResponseLimit+1)
+	if res := ServiceGetWitnessesQuery(backend, &GetWitnessesPacket{Hashes: []common.Hash{large, hashes[0]}}); len(res) != 1 {
+		t.Errorf("wrong number of witnesses served over size limit: have %d, want 1", len(res))
+	}
+}
+
+func TestHandleMessage(t *testing.T) {
+	t.Parallel()
+
+	var (
+		backend    = &testBackend{witnesses: make(map[common.Hash]rlp.RawValue)}
+		local, rem = p2p.MsgPipe()
+		peer       = NewFakePeer(WIT1, "0123456789abcdef", local)
+		hash       = common.Hash{1}
+	)
+	defer local.Close()
+	_, backend.witnesses[hash] = makeTestWitness(t, 1)
+
+	errc := make(chan error, 1)
+	go func() { errc <- Handle(backend, peer) }()
+
+	// Witness requests must be served
+	if err := p2p.Send(rem, GetWitnessesMsg, &GetWitnessesPacket{ID: 7, Hashes: []common.Hash{hash}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p2p.ExpectMsg(rem, WitnessesMsg, &WitnessesPacket{ID: 7, Witnesses: []rlp.RawValue{backend.witnesses[hash]}}); err != nil {
+		t.Fatal(err)
+	}
+	// Announcements must be tracked and delivered to the backend
+	ann := NewWitnessHashesPacket{{Hash: common.Hash{2}, Number: 2}}
+	if err := p2p.Send(rem, NewWitnessHashesMsg, &ann); err != nil {
+		t.Fatal(err)
+	}
+	// Invalid messages must tear down the peer
+	if err := p2p.Send(rem, 0xff, []uint{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; !errors.Is(err, errInvalidMsgCode) {
+		t.Fatalf("wrong handler error: %v", err)
+	}
+	if !peer.KnownWitness(common.Hash{2}) {
+		t.Error("announced witness not marked as known")
+	}
+	if len(backend.delivered) != 1 || backend.delivered[0].Kind() != NewWitnessHashesMsg {
+		t.Errorf("wrong packets delivered to backend: %v", backend.delivered)
+	}
+	// Peers must not be re-announced witnesses they are known to have
+	go func() {
+		errc <- p2p.ExpectMsg(rem, NewWitnessHashesMsg, &NewWitnessHashesPacket{{Hash: common.Hash{3}, Number: 3}})
+	}()
+	if err := peer.AnnounceWitness(common.Hash{2}, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := peer.AnnounceWitness(common.Hash{3}, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wit
+
+import (
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+var (
+	ingressRegistrationErrorName = "eth/protocols/wit/ingress/registration/error"
+	egressRegistrationErrorName  = "eth/protocols/wit/egress/registration/error"
+
+	IngressRegistrationErrorMeter = metrics.NewRegisteredMeter(ingressRegistrationErrorName, nil)
+	EgressRegistrationErrorMeter  = metrics.NewRegisteredMeter(egressRegistrationErrorName, nil)
+
+	// fetchSuccessMeter and fetchFailureMeter track the outcome of witness
+	// retrievals from remote peers.
+	fetchSuccessMeter = metrics.NewRegisteredMeter("eth/protocols/wit/fetch/success", nil)
+	fetchFailureMeter = metrics.NewRegisteredMeter("eth/protocols/wit/fetch/failure", nil)
+
+	// fetchTimer measures the time it takes to retrieve and verify a witness.
+	fetchTimer = metrics.NewRegisteredTimer("eth/protocols/wit/fetch/time", nil)
+)
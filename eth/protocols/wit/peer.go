@@ -0,0 +1,115 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wit
+
+import (
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p"
+)
+
+// maxKnownWitnesses is the maximum number of witness availability announcements
+// to track per peer.
+const maxKnownWitnesses = 1024
+
+// Peer is a collection of relevant information we have about a `wit` peer.
+type Peer struct {
+	id string // Unique ID for the peer, cached
+
+	*p2p.Peer                   // The embedded P2P package peer
+	rw        p2p.MsgReadWriter // Input/output streams for wit
+	version   uint              // Protocol version negotiated
+
+	knownWitnesses *lru.Cache[common.Hash, struct{}] // Block hashes of the witnesses known to the peer
+
+	logger log.Logger // Contextual logger with the peer id injected
+}
+
+// NewPeer creates a wrapper for a network connection and negotiated  protocol
+// version.
+func NewPeer(version uint, p *p2p.Peer, rw p2p.MsgReadWriter) *Peer {
+	id := p.ID().String()
+	return &Peer{
+		id:             id,
+		Peer:           p,
+		rw:             rw,
+		version:        version,
+		knownWitnesses: lru.NewCache[common.Hash, struct{}](maxKnownWitnesses),
+		logger:         log.New("peer", id[:8]),
+	}
+}
+
+// NewFakePeer creates a fake wit peer without a backing p2p peer, for testing purposes.
+func NewFakePeer(version uint, id string, rw p2p.MsgReadWriter) *Peer {
+	return &Peer{
+		id:             id,
+		rw:             rw,
+		version:        version,
+		knownWitnesses: lru.NewCache[common.Hash, struct{}](maxKnownWitnesses),
+		logger:         log.New("peer", id[:8]),
+	}
+}
+
+// ID retrieves the peer's unique identifier.
+func (p *Peer) ID() string {
+	return p.id
+}
+
+// Version retrieves the peer's negotiated `wit` protocol version.
+func (p *Peer) Version() uint {
+	return p.version
+}
+
+// Log overrides the P2P logger with the higher level one containing only the id.
+func (p *Peer) Log() log.Logger {
+	return p.logger
+}
+
+// KnownWitness returns whether the peer is known to have the witness of the
+// given block.
+func (p *Peer) KnownWitness(hash common.Hash) bool {
+	return p.knownWitnesses.Contains(hash)
+}
+
+// markWitness marks the witness of a block as known for the peer, ensuring that
+// it will never be announced to the peer.
+func (p *Peer) markWitness(hash common.Hash) {
+	p.knownWitnesses.Add(hash, struct{}{})
+}
+
+// AnnounceWitness announces the availability of a block's witness to the peer,
+// unless the peer is already known to have it.
+func (p *Peer) AnnounceWitness(hash common.Hash, number uint64) error {
+	if p.KnownWitness(hash) {
+		return nil
+	}
+	p.markWitness(hash)
+
+	return p2p.Send(p.rw, NewWitnessHashesMsg, &NewWitnessHashesPacket{{Hash: hash, Number: number}})
+}
+
+// RequestWitnesses fetches a batch of execution witnesses by block hash.
+func (p *Peer) RequestWitnesses(id uint64, hashes []common.Hash) error {
+	p.logger.Trace("Fetching set of witnesses", "reqid", id, "hashes", len(hashes))
+
+	requestTracker.Track(p.id, p.version, GetWitnessesMsg, WitnessesMsg, id)
+	return p2p.Send(p.rw, GetWitnessesMsg, &GetWitnessesPacket{
+		ID:     id,
+		Hashes: hashes,
+	})
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wit
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/p2p"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	WIT1 = 1
+)
+
+// ProtocolName is the official short name of the `wit` protocol used during
+// devp2p capability negotiation.
+const ProtocolName = "wit"
+
+// ProtocolVersions are the supported versions of the `wit` protocol (first
+// is primary).
+var ProtocolVersions = []uint{WIT1}
+
+// protocolLengths are the number of implemented message corresponding to
+// different protocol versions.
+var protocolLengths = map[uint]uint64{WIT1: 3}
+
+// maxMessageSize is the maximum cap on the size of a protocol message. It is
+// larger than in the other protocols, since a single witness of a full block
+// can span multiple megabytes.
+const maxMessageSize = 16 * 1024 * 1024
+
+const (
+	NewWitnessHashesMsg = 0x00
+	GetWitnessesMsg     = 0x01
+	WitnessesMsg        = 0x02
+)
+
+var (
+	errMsgTooLarge    = fmt.Errorf("%w: message too long", p2p.ErrMalformedMessage)
+	errDecode         = fmt.Errorf("%w: invalid message", p2p.ErrMalformedMessage)
+	errInvalidMsgCode = fmt.Errorf("%w: invalid message code", p2p.ErrMalformedMessage)
+)
+
+// Packet represents a p2p message in the `wit` protocol.
+type Packet interface {
+	Name() string // Name returns a string corresponding to the message type.
+	Kind() byte   // Kind returns the message type.
+}
+
+// NewWitnessHashesPacket is the network packet for the witness availability
+// notification of recent blocks.
+type NewWitnessHashesPacket []struct {
+	Hash   common.Hash // Hash of the block whose witness is available
+	Number uint64      // Number of the block whose witness is available
+}
+
+// Unpack retrieves the block hashes and numbers from the announcement packet
+// and returns them in a split flat format that's more consistent with the
+// internal data structures.
+func (p *NewWitnessHashesPacket) Unpack() ([]common.Hash, []uint64) {
+	var (
+		hashes  = make([]common.Hash, len(*p))
+		numbers = make([]uint64, len(*p))
+	)
+	for i, body := range *p {
+		hashes[i], numbers[i] = body.Hash, body.Number
+	}
+	return hashes, numbers
+}
+
+// GetWitnessesPacket represents a witness query.
+type GetWitnessesPacket struct {
+	ID     uint64        // Request ID to match up responses with
+	Hashes []common.Hash // Hashes of the blocks whose witnesses to retrieve
+}
+
+// WitnessesPacket represents a witness query response. The witnesses are in
+// the order of the request, but the response may be cut short if a witness is
+// not available or the response size limit is reached.
+type WitnessesPacket struct {
+	ID        uint64         // ID of the request this is a response for
+	Witnesses []rlp.RawValue // RLP-encoded execution witnesses of the blocks
+}
+
+func (*NewWitnessHashesPacket) Name() string { return "NewWitnessHashes" }
+func (*NewWitnessHashesPacket) Kind() byte   { return NewWitnessHashesMsg }
+
+func (*GetWitnessesPacket) Name() string { return "GetWitnesses" }
+func (*GetWitnessesPacket) Kind() byte   { return GetWitnessesMsg }
+
+func (*WitnessesPacket) Name() string { return "Witnesses" }
+func (*WitnessesPacket) Kind() byte   { return WitnessesMsg }
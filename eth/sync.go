@@ -24,9 +24,16 @@ import (
 
 // syncTransactions starts sending all currently pending transactions to the given peer.
 func (h *handler) syncTransactions(p *eth.Peer) {
+	policy := h.txPolicy.Load()
+	if policy.TrustedOnly && !p.Trusted() {
+		return
+	}
 	var hashes []common.Hash
 	for _, batch := range h.txpool.Pending(txpool.PendingFilter{BlobTxs: false}) {
 		for _, tx := range batch {
+			if policy.NoLocals && h.isLocalTx != nil && h.isLocalTx(tx.Hash) {
+				continue
+			}
 			hashes = append(hashes, tx.Hash)
 		}
 	}
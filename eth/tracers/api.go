@@ -96,6 +96,7 @@ type Backend interface {
 // API is the collection of tracing APIs exposed over the private debugging endpoint.
 type API struct {
 	backend Backend
+	store   *TraceStore // Optional persistent cache of finalized block traces
 }
 
 // NewAPI creates a new API definition for the tracing methods of the Ethereum service.
@@ -103,6 +104,13 @@ func NewAPI(backend Backend) *API {
 	return &API{backend: backend}
 }
 
+// NewAPIWithStore creates a new API definition for the tracing methods of the
+// Ethereum service, which serves block traces of finalized blocks from the given
+// trace store if available.
+func NewAPIWithStore(backend Backend, store *TraceStore) *API {
+	return &API{backend: backend, store: store}
+}
+
 // chainContext constructs the context reader which is used by the evm for reading
 // the necessary chain context.
 func (api *API) chainContext(ctx context.Context) core.ChainContext {
@@ -501,7 +509,7 @@ func (api *API) StandardTraceBlockToFile(ctx context.Context, hash common.Hash,
 	if err != nil {
 		return nil, err
 	}
-	return api.standardTraceBlockToFile(ctx, block, config)
+	return api.standardTraceBlockToFile(ctx, block, config, nil)
 }
 
 // IntermediateRoots executes a block (bad- or canon- or side-), and returns a list
@@ -576,13 +584,60 @@ func (api *API) StandardTraceBadBlockToFile(ctx context.Context, hash common.Has
 	if block == nil {
 		return nil, fmt.Errorf("bad block %#x not found", hash)
 	}
-	return api.standardTraceBlockToFile(ctx, block, config)
+	return api.standardTraceBlockToFile(ctx, block, config, nil)
+}
+
+// TraceStoreStats returns the statistics of the persistent trace store.
+func (api *API) TraceStoreStats() (*TraceStoreStats, error) {
+	if api.store == nil {
+		return nil, errors.New("trace store is not enabled")
+	}
+	return api.store.Stats(), nil
+}
+
+// isFinalized reports whether the given block is part of the finalized chain.
+func (api *API) isFinalized(ctx context.Context, block *types.Block) bool {
+	final, err := api.backend.HeaderByNumber(ctx, rpc.FinalizedBlockNumber)
+	if err != nil || final == nil || block.NumberU64() > final.Number.Uint64() {
+		return false
+	}
+	header, err := api.backend.HeaderByNumber(ctx, rpc.BlockNumber(block.NumberU64()))
+	return err == nil && header != nil && header.Hash() == block.Hash()
 }
 
 // traceBlock configures a new tracer according to the provided configuration, and
 // executes all the transactions contained within. The return value will be one item
 // per transaction, dependent on the requested tracer.
+//
+// If the trace store is enabled, traces of finalized blocks are served from and
+// persisted into the store.
 func (api *API) traceBlock(ctx context.Context, block *types.Block, config *TraceConfig) ([]*txTraceResult, error) {
+	if api.store == nil || !api.isFinalized(ctx, block) {
+		return api.traceBlockUncached(ctx, block, config)
+	}
+	if results, ok := api.store.get(block.Hash(), config); ok {
+		return results, nil
+	}
+	results, err := api.traceBlockUncached(ctx, block, config)
+	if err != nil {
+		return nil, err
+	}
+	// Only persist complete traces, individual transactions might have failed
+	// due to transient conditions like timeouts.
+	for _, result := range results {
+		if result.Error != "" {
+			return results, nil
+		}
+	}
+	if err := api.store.put(block.Hash(), config, results); err != nil {
+		log.Warn("Failed to persist block trace", "number", block.NumberU64(), "hash", block.Hash(), "err", err)
+	}
+	return results, nil
+}
+
+// traceBlockUncached traces all the transactions of a block, without consulting
+// the trace store.
+func (api *API) traceBlockUncached(ctx context.Context, block *types.Block, config *TraceConfig) ([]*txTraceResult, error) {
 	if block.NumberU64() == 0 {
 		return nil, errors.New("genesis is not traceable")
 	}
@@ -728,8 +783,9 @@ txloop:
 
 // standardTraceBlockToFile configures a new tracer which uses standard JSON output,
 // and traces either a full block or an individual transaction. The return value will
-// be one filename per transaction traced.
-func (api *API) standardTraceBlockToFile(ctx context.Context, block *types.Block, config *StdTraceConfig) ([]string, error) {
+// be one filename per transaction traced. If progress is set, it is invoked after
+// each transaction trace has been written to disk.
+func (api *API) standardTraceBlockToFile(ctx context.Context, block *types.Block, config *StdTraceConfig, progress func(*stdTraceProgress)) ([]string, error) {
 	// If we're tracing a single transaction, make sure it's present
 	if config != nil && config.TxHash != (common.Hash{}) {
 		if !containsTx(block, config.TxHash) {
@@ -787,7 +843,11 @@ func (api *API) standardTraceBlockToFile(ctx context.Context, block *types.Block
 	if chainConfig.IsPrague(block.Number(), block.Time()) {
 		core.ProcessParentBlockHash(block.ParentHash(), evm)
 	}
-	for i, tx := range block.Transactions() {
+	txs := block.Transactions()
+	for i, tx := range txs {
+		if err := ctx.Err(); err != nil {
+			return dumps, err
+		}
 		// Prepare the transaction for un-traced execution
 		msg, _ := core.TransactionToMessage(tx, signer, block.BaseFee())
 		if txHash != (common.Hash{}) && tx.Hash() != txHash {
@@ -838,6 +898,9 @@ func (api *API) standardTraceBlockToFile(ctx context.Context, block *types.Block
 		if err != nil {
 			return dumps, err
 		}
+		if progress != nil {
+			progress(&stdTraceProgress{TxIndex: i, TxHash: tx.Hash(), Total: len(txs), File: dump.Name()})
+		}
 		// Finalize the state so any modifications are written to the trie
 		// Only delete empty objects if EIP158/161 (a.k.a Spurious Dragon) is in effect
 		statedb.Finalise(evm.ChainConfig().IsEIP158(block.Number()))
@@ -864,36 +927,47 @@ func containsTx(block *types.Block, hash common.Hash) bool {
 // TraceTransaction returns the structured logs created during the execution of EVM
 // and returns them as a JSON object.
 func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *TraceConfig) (interface{}, error) {
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	tx, msg, txctx, vmctx, statedb, release, err := api.stateAtCanonicalTransaction(ctx, hash, reexec)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return api.traceTx(ctx, tx, msg, txctx, vmctx, statedb, config, nil)
+}
+
+// stateAtCanonicalTransaction looks up a canonical transaction by hash and
+// returns it along with the message, contexts and state required to trace it.
+func (api *API) stateAtCanonicalTransaction(ctx context.Context, hash common.Hash, reexec uint64) (*types.Transaction, *core.Message, *Context, vm.BlockContext, *state.StateDB, StateReleaseFunc, error) {
 	found, _, blockHash, blockNumber, index := api.backend.GetCanonicalTransaction(hash)
 	if !found {
 		// Warn in case tx indexer is not done.
 		if !api.backend.TxIndexDone() {
-			return nil, ethapi.NewTxIndexingError()
+			return nil, nil, nil, vm.BlockContext{}, nil, nil, ethapi.NewTxIndexingError()
 		}
 		// Only mined txes are supported
-		return nil, errTxNotFound
+		return nil, nil, nil, vm.BlockContext{}, nil, nil, errTxNotFound
 	}
 	// It shouldn't happen in practice.
 	if blockNumber == 0 {
-		return nil, errors.New("genesis is not traceable")
-	}
-	reexec := defaultTraceReexec
-	if config != nil && config.Reexec != nil {
-		reexec = *config.Reexec
+		return nil, nil, nil, vm.BlockContext{}, nil, nil, errors.New("genesis is not traceable")
 	}
 	block, err := api.blockByNumberAndHash(ctx, rpc.BlockNumber(blockNumber), blockHash)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, vm.BlockContext{}, nil, nil, err
 	}
 	tx, vmctx, statedb, release, err := api.backend.StateAtTransaction(ctx, block, int(index), reexec)
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, vm.BlockContext{}, nil, nil, err
 	}
-	defer release()
-
 	msg, err := core.TransactionToMessage(tx, types.MakeSigner(api.backend.ChainConfig(), block.Number(), block.Time()), block.BaseFee())
 	if err != nil {
-		return nil, err
+		release()
+		return nil, nil, nil, vm.BlockContext{}, nil, nil, err
 	}
 	txctx := &Context{
 		BlockHash:   blockHash,
@@ -901,7 +975,7 @@ func (api *API) TraceTransaction(ctx context.Context, hash common.Hash, config *
 		TxIndex:     int(index),
 		TxHash:      hash,
 	}
-	return api.traceTx(ctx, tx, msg, txctx, vmctx, statedb, config, nil)
+	return tx, msg, txctx, vmctx, statedb, release, nil
 }
 
 // TraceCall lets you trace a given eth_call. It collects the structured logs
@@ -1007,11 +1081,20 @@ func (api *API) TraceCall(ctx context.Context, args ethapi.TransactionArgs, bloc
 // executes the given message in the provided environment. The return value will
 // be tracer dependent.
 func (api *API) traceTx(ctx context.Context, tx *types.Transaction, message *core.Message, txctx *Context, vmctx vm.BlockContext, statedb *state.StateDB, config *TraceConfig, precompiles vm.PrecompiledContracts) (interface{}, error) {
+	tracer, timeout, err := api.newTracer(config, txctx)
+	if err != nil {
+		return nil, err
+	}
+	return api.traceTxWithTracer(ctx, tracer, timeout, tx, message, txctx, vmctx, statedb, precompiles)
+}
+
+// newTracer configures a new tracer according to the provided configuration,
+// returning it along with the timeout of a single transaction trace.
+func (api *API) newTracer(config *TraceConfig, txctx *Context) (*Tracer, time.Duration, error) {
 	var (
 		tracer  *Tracer
 		err     error
 		timeout = defaultTraceTimeout
-		usedGas uint64
 	)
 	if config == nil {
 		config = &TraceConfig{}
@@ -1027,21 +1110,29 @@ func (api *API) traceTx(ctx context.Context, tx *types.Transaction, message *cor
 	} else {
 		tracer, err = DefaultDirectory.New(*config.Tracer, txctx, config.TracerConfig, api.backend.ChainConfig())
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 	}
-	tracingStateDB := state.NewHookedState(statedb, tracer.Hooks)
-	evm := vm.NewEVM(vmctx, tracingStateDB, api.backend.ChainConfig(), vm.Config{Tracer: tracer.Hooks, NoBaseFee: true})
-	if precompiles != nil {
-		evm.SetPrecompiles(precompiles)
-	}
-
 	// Define a meaningful timeout of a single transaction trace
 	if config.Timeout != nil {
 		if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 	}
+	return tracer, timeout, nil
+}
+
+// traceTxWithTracer executes the given message in the provided environment with
+// an already configured tracer, aborting it if it runs longer than timeout. The
+// return value will be tracer dependent.
+func (api *API) traceTxWithTracer(ctx context.Context, tracer *Tracer, timeout time.Duration, tx *types.Transaction, message *core.Message, txctx *Context, vmctx vm.BlockContext, statedb *state.StateDB, precompiles vm.PrecompiledContracts) (interface{}, error) {
+	var usedGas uint64
+
+	tracingStateDB := state.NewHookedState(statedb, tracer.Hooks)
+	evm := vm.NewEVM(vmctx, tracingStateDB, api.backend.ChainConfig(), vm.Config{Tracer: tracer.Hooks, NoBaseFee: true})
+	if precompiles != nil {
+		evm.SetPrecompiles(precompiles)
+	}
 	deadlineCtx, cancel := context.WithTimeout(ctx, timeout)
 	go func() {
 		<-deadlineCtx.Done()
@@ -1055,7 +1146,7 @@ func (api *API) traceTx(ctx context.Context, tx *types.Transaction, message *cor
 
 	// Call Prepare to clear out the statedb access list
 	statedb.SetTxContext(txctx.TxHash, txctx.TxIndex)
-	_, err = core.ApplyTransactionWithEVM(message, new(core.GasPool).AddGas(message.GasLimit), statedb, vmctx.BlockNumber, txctx.BlockHash, vmctx.Time, tx, &usedGas, evm)
+	_, err := core.ApplyTransactionWithEVM(message, new(core.GasPool).AddGas(message.GasLimit), statedb, vmctx.BlockNumber, txctx.BlockHash, vmctx.Time, tx, &usedGas, evm)
 	if err != nil {
 		return nil, fmt.Errorf("tracing failed: %w", err)
 	}
@@ -1064,11 +1155,22 @@ func (api *API) traceTx(ctx context.Context, tx *types.Transaction, message *cor
 
 // APIs return the collection of RPC services the tracer package offers.
 func APIs(backend Backend) []rpc.API {
+	return APIsWithStore(backend, nil)
+}
+
+// APIsWithStore return the collection of RPC services the tracer package offers,
+// backed by the given trace store.
+func APIsWithStore(backend Backend, store *TraceStore) []rpc.API {
 	// Append all the local APIs and return
+	api := NewAPIWithStore(backend, store)
 	return []rpc.API{
 		{
 			Namespace: "debug",
-			Service:   NewAPI(backend),
+			Service:   api,
+		},
+		{
+			Namespace: "trace",
+			Service:   NewTraceAPI(api),
 		},
 	}
 }
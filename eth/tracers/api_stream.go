@@ -0,0 +1,245 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// defaultStreamTraceTimeout is the amount of time a single streamed transaction
+	// trace can execute by default before being forcefully aborted. It's higher
+	// than the default timeout as execution is throttled by the subscriber.
+	defaultStreamTraceTimeout = 5 * time.Minute
+
+	// streamTraceBuffer is the number of log entries which are buffered ahead of
+	// the subscriber. Once full, execution is blocked until the subscriber catches
+	// up with the notifications.
+	streamTraceBuffer = 1024
+)
+
+// Log formats supported by the streaming trace.
+const (
+	streamFormatStructLog = "structLog" // Legacy struct log entries, as returned by debug_traceTransaction
+	streamFormatJSON      = "json"      // EIP-3155 entries, as produced by the JSON logger
+)
+
+var errTraceUnsubscribed = errors.New("trace unsubscribed")
+
+// StreamTraceConfig holds extra parameters to the streaming trace functions.
+type StreamTraceConfig struct {
+	logger.Config
+	Format  *string
+	Timeout *string
+	Reexec  *uint64
+}
+
+// traceStreamEvent is a single notification of a streamed transaction trace.
+// Log entries are sent one by one, followed by a final event which carries the
+// execution outcome or the failure of the trace.
+type traceStreamEvent struct {
+	Log    json.RawMessage `json:"log,omitempty"`    // Single log entry produced by the logger
+	Done   bool            `json:"done,omitempty"`   // Whether this is the final event of the trace
+	Result json.RawMessage `json:"result,omitempty"` // Execution outcome, without any struct logs
+	Error  string          `json:"error,omitempty"`  // Trace failure produced by the tracer
+}
+
+// stdTraceProgress is a progress notification of a standard-json block trace,
+// sent after a single transaction trace has been written.
+type stdTraceProgress struct {
+	TxIndex int         `json:"txIndex"`
+	TxHash  common.Hash `json:"txHash"`
+	Total   int         `json:"total"`
+	File    string      `json:"file"`
+}
+
+// stdTraceResult is the final notification of a standard-json block trace.
+type stdTraceResult struct {
+	Done  bool     `json:"done"`
+	Files []string `json:"files"`
+	Error string   `json:"error,omitempty"`
+}
+
+// entryWriter is an io.Writer which hands over every write as a separate log
+// entry. It relies on the JSON logger emitting each entry in a single write.
+type entryWriter struct {
+	emit func(entry json.RawMessage)
+}
+
+func (w *entryWriter) Write(p []byte) (int, error) {
+	w.emit(common.CopyBytes(bytes.TrimSpace(p)))
+	return len(p), nil
+}
+
+// TraceTransactionStream traces a transaction like TraceTransaction does with
+// the struct logger, but instead of buffering the logs in memory, pushes them to
+// the subscriber one by one. Execution is paused whenever the subscriber can't
+// keep up with the notifications.
+func (api *API) TraceTransactionStream(ctx context.Context, hash common.Hash, config *StreamTraceConfig) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if config == nil {
+		config = &StreamTraceConfig{}
+	}
+	format := streamFormatStructLog
+	if config.Format != nil {
+		format = *config.Format
+	}
+	if format != streamFormatStructLog && format != streamFormatJSON {
+		return nil, fmt.Errorf("unknown trace stream format %q", format)
+	}
+	timeout := defaultStreamTraceTimeout
+	if config.Timeout != nil {
+		var err error
+		if timeout, err = time.ParseDuration(*config.Timeout); err != nil {
+			return nil, err
+		}
+	}
+	reexec := defaultTraceReexec
+	if config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	tx, msg, txctx, vmctx, statedb, release, err := api.stateAtCanonicalTransaction(ctx, hash, reexec)
+	if err != nil {
+		return nil, err
+	}
+	sub := notifier.CreateSubscription()
+
+	go func() {
+		defer release()
+
+		// The request context is cancelled as soon as the subscription is
+		// created, tie the lifetime of the trace to the subscription instead.
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var (
+			entries = make(chan json.RawMessage, streamTraceBuffer)
+			done    = make(chan struct{})
+		)
+		emit := func(entry json.RawMessage) {
+			select {
+			case entries <- entry:
+			case <-ctx.Done():
+			}
+		}
+		var tracer *Tracer
+		switch format {
+		case streamFormatStructLog:
+			l := logger.NewEmittingStructLogger(&config.Config, emit)
+			tracer = &Tracer{Hooks: l.Hooks(), GetResult: l.GetResult, Stop: l.Stop}
+		case streamFormatJSON:
+			tracer = &Tracer{
+				Hooks:     logger.NewJSONLogger(&config.Config, &entryWriter{emit: emit}),
+				GetResult: func() (json.RawMessage, error) { return nil, nil },
+				Stop:      func(err error) {},
+			}
+		}
+		// Forward the log entries to the subscriber. Notify blocks until the
+		// entry is written out, which throttles the producer once the buffer
+		// fills up.
+		go func() {
+			defer close(done)
+			for entry := range entries {
+				if err := notifier.Notify(sub.ID, &traceStreamEvent{Log: entry}); err != nil {
+					cancel()
+					return
+				}
+			}
+		}()
+		go func() {
+			select {
+			case <-sub.Err():
+				tracer.Stop(errTraceUnsubscribed)
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		res, err := api.traceTxWithTracer(ctx, tracer, timeout, tx, msg, txctx, vmctx, statedb, nil)
+		close(entries)
+		<-done
+
+		if ctx.Err() != nil {
+			log.Debug("Streamed trace aborted", "hash", hash, "err", err)
+			return
+		}
+		final := &traceStreamEvent{Done: true}
+		if err != nil {
+			final.Error = err.Error()
+		} else if res, ok := res.(json.RawMessage); ok {
+			final.Result = res
+		}
+		notifier.Notify(sub.ID, final)
+	}()
+	return sub, nil
+}
+
+// StandardTraceBlockToFileProgress dumps the structured logs created during the
+// execution of EVM to the local file system like StandardTraceBlockToFile does,
+// but runs in the background and notifies the subscriber whenever a transaction
+// trace has been written. The final notification carries the list of files.
+func (api *API) StandardTraceBlockToFileProgress(ctx context.Context, hash common.Hash, config *StdTraceConfig) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	block, err := api.blockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	sub := notifier.CreateSubscription()
+
+	go func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		go func() {
+			select {
+			case <-sub.Err():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		files, err := api.standardTraceBlockToFile(ctx, block, config, func(progress *stdTraceProgress) {
+			if err := notifier.Notify(sub.ID, progress); err != nil {
+				cancel()
+			}
+		})
+		if ctx.Err() != nil {
+			log.Debug("Standard block trace aborted", "hash", hash, "files", len(files))
+			return
+		}
+		final := &stdTraceResult{Done: true, Files: files}
+		if err != nil {
+			final.Error = err.Error()
+		}
+		notifier.Notify(sub.ID, final)
+	}()
+	return sub, nil
+}
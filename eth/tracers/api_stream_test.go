@@ -0,0 +1,226 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// newStreamTestBackend creates a backend with a single block, containing two
+// transactions into a contract looping until it runs out of gas.
+func newStreamTestBackend(t *testing.T) (*testBackend, []common.Hash) {
+	var (
+		accounts = newAccounts(1)
+		loop     = common.HexToAddress("0x1000")
+		genesis  = &core.Genesis{
+			Config: params.TestChainConfig,
+			Alloc: types.GenesisAlloc{
+				accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+				loop: {
+					Code: []byte{
+						byte(vm.JUMPDEST),
+						byte(vm.PUSH1), 0x00,
+						byte(vm.JUMP),
+					},
+				},
+			},
+		}
+		hashes []common.Hash
+	)
+	backend := newTestBackend(t, 1, genesis, func(i int, b *core.BlockGen) {
+		for nonce := uint64(0); nonce < 2; nonce++ {
+			tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{
+				Nonce:    nonce,
+				To:       &loop,
+				Gas:      40000,
+				GasPrice: b.BaseFee(),
+			}), types.HomesteadSigner{}, accounts[0].key)
+			b.AddTx(tx)
+			hashes = append(hashes, tx.Hash())
+		}
+	})
+	return backend, hashes
+}
+
+func newStreamTestClient(t *testing.T, backend *testBackend) *rpc.Client {
+	server := rpc.NewServer()
+	if err := server.RegisterName("debug", NewAPI(backend)); err != nil {
+		t.Fatalf("failed to register API: %v", err)
+	}
+	client := rpc.DialInProc(server)
+	t.Cleanup(func() {
+		client.Close()
+		server.Stop()
+	})
+	return client
+}
+
+func TestTraceTransactionStream(t *testing.T) {
+	t.Parallel()
+
+	backend, hashes := newStreamTestBackend(t)
+	defer backend.teardown()
+	client := newStreamTestClient(t, backend)
+
+	// Retrieve the buffered trace to compare against
+	res, err := NewAPI(backend).TraceTransaction(context.Background(), hashes[0], nil)
+	if err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	var want logger.ExecutionResult
+	if err := json.Unmarshal(res.(json.RawMessage), &want); err != nil {
+		t.Fatalf("failed to unmarshal result: %v", err)
+	}
+	if len(want.StructLogs) <= streamTraceBuffer {
+		t.Fatalf("trace too short to exercise the stream buffer: %d", len(want.StructLogs))
+	}
+	// Stream the same trace and ensure all entries arrive in order
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	events := make(chan *traceStreamEvent)
+	sub, err := client.Subscribe(ctx, "debug", events, "traceTransactionStream", hashes[0], nil)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	var logs []json.RawMessage
+	for {
+		select {
+		case ev := <-events:
+			if !ev.Done {
+				logs = append(logs, ev.Log)
+				continue
+			}
+			if ev.Error != "" {
+				t.Fatalf("trace failed: %v", ev.Error)
+			}
+			var have logger.ExecutionResult
+			if err := json.Unmarshal(ev.Result, &have); err != nil {
+				t.Fatalf("failed to unmarshal result: %v", err)
+			}
+			if have.Gas != want.Gas || have.Failed != want.Failed {
+				t.Fatalf("result mismatch: have %+v, want %+v", have, want)
+			}
+			if len(have.StructLogs) != 0 {
+				t.Fatalf("unexpected struct logs in final result: %d", len(have.StructLogs))
+			}
+			if len(logs) != len(want.StructLogs) {
+				t.Fatalf("log count mismatch: have %d, want %d", len(logs), len(want.StructLogs))
+			}
+			for i := range logs {
+				if string(logs[i]) != string(want.StructLogs[i]) {
+					t.Fatalf("log %d mismatch: have %s, want %s", i, logs[i], want.StructLogs[i])
+				}
+			}
+			return
+		case err := <-sub.Err():
+			t.Fatalf("subscription failed: %v", err)
+		case <-ctx.Done():
+			t.Fatal("timeout waiting for trace")
+		}
+	}
+}
+
+func TestTraceTransactionStreamFormat(t *testing.T) {
+	t.Parallel()
+
+	backend, hashes := newStreamTestBackend(t)
+	defer backend.teardown()
+	client := newStreamTestClient(t, backend)
+
+	events := make(chan *traceStreamEvent)
+	format := "unknown"
+	if _, err := client.Subscribe(context.Background(), "debug", events, "traceTransactionStream", hashes[0], &StreamTraceConfig{Format: &format}); err == nil {
+		t.Fatal("expected error for unknown format")
+	}
+	format = streamFormatJSON
+	sub, err := client.Subscribe(context.Background(), "debug", events, "traceTransactionStream", hashes[0], &StreamTraceConfig{Format: &format})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	var (
+		count int
+		last  json.RawMessage
+	)
+	for ev := range events {
+		if ev.Done {
+			break
+		}
+		count, last = count+1, ev.Log
+	}
+	// The JSON logger finishes the trace with a summary of the execution
+	var summary struct {
+		GasUsed *string `json:"gasUsed"`
+	}
+	if err := json.Unmarshal(last, &summary); err != nil || summary.GasUsed == nil {
+		t.Fatalf("unexpected final entry %s: %v", last, err)
+	}
+	if count <= streamTraceBuffer {
+		t.Fatalf("too few entries streamed: %d", count)
+	}
+}
+
+func TestStandardTraceBlockToFileProgress(t *testing.T) {
+	t.Parallel()
+
+	backend, hashes := newStreamTestBackend(t)
+	defer backend.teardown()
+	client := newStreamTestClient(t, backend)
+
+	block, _ := backend.BlockByNumber(context.Background(), rpc.LatestBlockNumber)
+	events := make(chan json.RawMessage)
+	sub, err := client.Subscribe(context.Background(), "debug", events, "standardTraceBlockToFileProgress", block.Hash(), nil)
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	for i := range hashes {
+		var progress stdTraceProgress
+		if err := json.Unmarshal(<-events, &progress); err != nil {
+			t.Fatalf("failed to unmarshal progress: %v", err)
+		}
+		if progress.TxIndex != i || progress.TxHash != hashes[i] || progress.Total != len(hashes) {
+			t.Fatalf("unexpected progress %d: %+v", i, progress)
+		}
+		defer os.Remove(progress.File)
+	}
+	var result stdTraceResult
+	if err := json.Unmarshal(<-events, &result); err != nil {
+		t.Fatalf("failed to unmarshal result: %v", err)
+	}
+	if !result.Done || result.Error != "" || len(result.Files) != len(hashes) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+}
@@ -76,6 +76,12 @@ func (d *directory) RegisterJSEval(f jsCtorFn) {
 	d.jsEval = f
 }
 
+// Has reports whether a tracer with the given name is registered.
+func (d *directory) Has(name string) bool {
+	_, ok := d.elems[name]
+	return ok
+}
+
 // New returns a new instance of a tracer, by iterating through the
 // registered lookups. Name is either name of an existing tracer
 // or an arbitrary JS code.
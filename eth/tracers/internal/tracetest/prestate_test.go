@@ -61,6 +61,10 @@ func TestPrestateWithDiffModeTracer(t *testing.T) {
 	testPrestateTracer("prestateTracer", "prestate_tracer_with_diff_mode", t)
 }
 
+func TestStateDiffTracer(t *testing.T) {
+	testPrestateTracer("stateDiffTracer", "state_diff_tracer", t)
+}
+
 func testPrestateTracer(tracerName string, dirPath string, t *testing.T) {
 	files, err := os.ReadDir(filepath.Join("testdata", dirPath))
 	if err != nil {
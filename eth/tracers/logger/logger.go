@@ -216,8 +216,9 @@ type StructLogger struct {
 	err     error
 	usedGas uint64
 
-	writer     io.Writer         // If set, the logger will stream instead of store logs
-	logs       []json.RawMessage // buffer of json-encoded logs
+	writer     io.Writer                   // If set, the logger will stream instead of store logs
+	emit       func(entry json.RawMessage) // If set, json-encoded logs are handed over instead of stored
+	logs       []json.RawMessage           // buffer of json-encoded logs
 	resultSize int
 
 	interrupt atomic.Bool // Atomic flag to signal execution interruption
@@ -232,6 +233,15 @@ func NewStreamingStructLogger(cfg *Config, writer io.Writer) *StructLogger {
 	return l
 }
 
+// NewEmittingStructLogger returns a new logger which hands every json-encoded log
+// entry to the given callback instead of storing it. The result of the logger
+// contains the execution outcome, but no struct logs.
+func NewEmittingStructLogger(cfg *Config, emit func(entry json.RawMessage)) *StructLogger {
+	l := NewStructLogger(cfg)
+	l.emit = emit
+	return l
+}
+
 // NewStructLogger construct a new (non-streaming) struct logger.
 func NewStructLogger(cfg *Config) *StructLogger {
 	logger := &StructLogger{
@@ -318,6 +328,12 @@ func (l *StructLogger) OnOpcode(pc uint64, opcode byte, gas, cost uint64, scope
 	log.Storage = storage
 
 	// create a log
+	if l.emit != nil {
+		entry := log.toLegacyJSON()
+		l.resultSize += len(entry)
+		l.emit(entry)
+		return
+	}
 	if l.writer == nil {
 		entry := log.toLegacyJSON()
 		l.resultSize += len(entry)
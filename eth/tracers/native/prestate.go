@@ -91,14 +91,7 @@ func newPrestateTracer(ctx *tracers.Context, cfg json.RawMessage, chainConfig *p
 	if config.DiffMode && config.IncludeEmpty {
 		return nil, errors.New("cannot use diffMode with includeEmpty")
 	}
-	t := &prestateTracer{
-		pre:         stateMap{},
-		post:        stateMap{},
-		config:      config,
-		chainConfig: chainConfig,
-		created:     make(map[common.Address]bool),
-		deleted:     make(map[common.Address]bool),
-	}
+	t := newPrestateTracerObject(config, chainConfig)
 	return &tracers.Tracer{
 		Hooks: &tracing.Hooks{
 			OnTxStart: t.OnTxStart,
@@ -110,6 +103,17 @@ func newPrestateTracer(ctx *tracers.Context, cfg json.RawMessage, chainConfig *p
 	}, nil
 }
 
+func newPrestateTracerObject(config PrestateTracerConfig, chainConfig *params.ChainConfig) *prestateTracer {
+	return &prestateTracer{
+		pre:         stateMap{},
+		post:        stateMap{},
+		config:      config,
+		chainConfig: chainConfig,
+		created:     make(map[common.Address]bool),
+		deleted:     make(map[common.Address]bool),
+	}
+}
+
 // OnOpcode implements the EVMLogger interface to trace a single step of VM execution.
 func (t *prestateTracer) OnOpcode(pc uint64, opcode byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
 	if err != nil {
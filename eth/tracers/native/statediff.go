@@ -0,0 +1,188 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package native
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+)
+
+func init() {
+	tracers.DefaultDirectory.Register("stateDiffTracer", newStateDiffTracer, false)
+}
+
+// Markers used by the parity stateDiff format to describe how a field changed.
+const (
+	diffSame    = "="
+	diffBorn    = "+"
+	diffDied    = "-"
+	diffAltered = "*"
+)
+
+// diffField is a single field change in the parity stateDiff format. It encodes
+// as "=" if unchanged, {"+": to} if the account was created, {"-": from} if the
+// account was destroyed and {"*": {"from": from, "to": to}} if it was altered.
+type diffField struct {
+	kind string
+	from interface{}
+	to   interface{}
+}
+
+// MarshalJSON implements json.Marshaler.
+func (f diffField) MarshalJSON() ([]byte, error) {
+	switch f.kind {
+	case diffBorn:
+		return json.Marshal(map[string]interface{}{diffBorn: f.to})
+	case diffDied:
+		return json.Marshal(map[string]interface{}{diffDied: f.from})
+	case diffAltered:
+		return json.Marshal(map[string]interface{}{diffAltered: struct {
+			From interface{} `json:"from"`
+			To   interface{} `json:"to"`
+		}{f.from, f.to}})
+	default:
+		return json.Marshal(diffSame)
+	}
+}
+
+// newDiffField compares two values and returns the matching field change.
+func newDiffField(from, to interface{}, same bool) diffField {
+	if same {
+		return diffField{kind: diffSame}
+	}
+	return diffField{kind: diffAltered, from: from, to: to}
+}
+
+// accountDiff is the parity stateDiff representation of a single account.
+type accountDiff struct {
+	Balance diffField                 `json:"balance"`
+	Code    diffField                 `json:"code"`
+	Nonce   diffField                 `json:"nonce"`
+	Storage map[common.Hash]diffField `json:"storage"`
+}
+
+// stateDiffTracer reports the state modifications of a transaction in the
+// stateDiff format of OpenEthereum's trace_replayTransaction. The set of touched
+// accounts and slots is collected with the same rules as the prestateTracer.
+type stateDiffTracer struct {
+	pre  *prestateTracer
+	diff map[common.Address]*accountDiff
+}
+
+// newStateDiffTracer returns a native go tracer which produces the parity
+// stateDiff of a transaction.
+func newStateDiffTracer(ctx *tracers.Context, cfg json.RawMessage, chainConfig *params.ChainConfig) (*tracers.Tracer, error) {
+	// Empty accounts are required to detect account creation, so they are
+	// always retained in the underlying prestate.
+	t := &stateDiffTracer{
+		pre:  newPrestateTracerObject(PrestateTracerConfig{IncludeEmpty: true}, chainConfig),
+		diff: make(map[common.Address]*accountDiff),
+	}
+	return &tracers.Tracer{
+		Hooks: &tracing.Hooks{
+			OnTxStart: t.pre.OnTxStart,
+			OnTxEnd:   t.OnTxEnd,
+			OnOpcode:  t.pre.OnOpcode,
+		},
+		GetResult: t.GetResult,
+		Stop:      t.pre.Stop,
+	}, nil
+}
+
+func (t *stateDiffTracer) OnTxEnd(receipt *types.Receipt, err error) {
+	if err != nil || t.pre.interrupt.Load() {
+		return
+	}
+	statedb := t.pre.env.StateDB
+	for addr, prev := range t.pre.pre {
+		var (
+			balance = statedb.GetBalance(addr).ToBig()
+			nonce   = statedb.GetNonce(addr)
+			code    = statedb.GetCode(addr)
+
+			existed = !prev.empty
+			exists  = !t.pre.deleted[addr] && (nonce > 0 || len(code) > 0 || balance.Sign() != 0)
+		)
+		switch {
+		case !existed && !exists:
+			continue
+
+		case !existed:
+			diff := &accountDiff{
+				Balance: diffField{kind: diffBorn, to: (*hexutil.Big)(balance)},
+				Code:    diffField{kind: diffBorn, to: hexutil.Bytes(code)},
+				Nonce:   diffField{kind: diffBorn, to: hexutil.Uint64(nonce)},
+				Storage: make(map[common.Hash]diffField),
+			}
+			for key := range prev.Storage {
+				if val := statedb.GetState(addr, key); val != (common.Hash{}) {
+					diff.Storage[key] = diffField{kind: diffBorn, to: val}
+				}
+			}
+			t.diff[addr] = diff
+
+		case !exists:
+			diff := &accountDiff{
+				Balance: diffField{kind: diffDied, from: (*hexutil.Big)(prev.Balance)},
+				Code:    diffField{kind: diffDied, from: hexutil.Bytes(prev.Code)},
+				Nonce:   diffField{kind: diffDied, from: hexutil.Uint64(prev.Nonce)},
+				Storage: make(map[common.Hash]diffField),
+			}
+			for key, val := range prev.Storage {
+				if val != (common.Hash{}) {
+					diff.Storage[key] = diffField{kind: diffDied, from: val}
+				}
+			}
+			t.diff[addr] = diff
+
+		default:
+			diff := &accountDiff{
+				Balance: newDiffField((*hexutil.Big)(prev.Balance), (*hexutil.Big)(balance), prev.Balance.Cmp(balance) == 0),
+				Code:    newDiffField(hexutil.Bytes(prev.Code), hexutil.Bytes(code), bytes.Equal(prev.Code, code)),
+				Nonce:   newDiffField(hexutil.Uint64(prev.Nonce), hexutil.Uint64(nonce), prev.Nonce == nonce),
+				Storage: make(map[common.Hash]diffField),
+			}
+			modified := diff.Balance.kind != diffSame || diff.Code.kind != diffSame || diff.Nonce.kind != diffSame
+			for key, val := range prev.Storage {
+				if post := statedb.GetState(addr, key); post != val {
+					diff.Storage[key] = diffField{kind: diffAltered, from: val, to: post}
+					modified = true
+				}
+			}
+			if modified {
+				t.diff[addr] = diff
+			}
+		}
+	}
+}
+
+// GetResult returns the json-encoded state diff, and any error arising from
+// the encoding or forceful termination (via `Stop`).
+func (t *stateDiffTracer) GetResult() (json.RawMessage, error) {
+	res, err := json.Marshal(t.diff)
+	if err != nil {
+		return nil, err
+	}
+	return res, t.pre.reason
+}
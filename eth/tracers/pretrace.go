@@ -0,0 +1,145 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// pretraceInterval is the interval at which the finalized block is polled
+	// for blocks to pre-trace.
+	pretraceInterval = 12 * time.Second
+
+	// maxPretraceBacklog is the maximum number of finalized blocks the pretracer
+	// catches up with. Older blocks are skipped, they are only traced on demand.
+	maxPretraceBacklog = 64
+)
+
+// Pretracer traces newly finalized blocks in the background with a set of
+// configured tracers, so that the results are readily available in the trace
+// store once requested.
+type Pretracer struct {
+	api     *API
+	configs []*TraceConfig
+	last    uint64 // Number of the last pre-traced finalized block
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewPretracer creates a pretracer for the given tracers, persisting the traces
+// into the store. The default struct logger can be selected via "structLogger".
+func NewPretracer(backend Backend, store *TraceStore, tracers []string) *Pretracer {
+	configs := make([]*TraceConfig, 0, len(tracers))
+	for _, name := range tracers {
+		config := new(TraceConfig)
+		if name != defaultTracerName {
+			config.Tracer = &name
+		}
+		configs = append(configs, config)
+	}
+	return &Pretracer{
+		api:     NewAPIWithStore(backend, store),
+		configs: configs,
+		quit:    make(chan struct{}),
+	}
+}
+
+// Start implements node.Lifecycle, starting the background pre-tracing.
+func (p *Pretracer) Start() error {
+	if len(p.configs) == 0 {
+		return nil
+	}
+	p.wg.Add(1)
+	go p.loop()
+	return nil
+}
+
+// Stop implements node.Lifecycle, terminating the background pre-tracing.
+func (p *Pretracer) Stop() error {
+	close(p.quit)
+	p.wg.Wait()
+	return nil
+}
+
+// loop periodically checks for newly finalized blocks and traces them.
+func (p *Pretracer) loop() {
+	defer p.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-p.quit
+		cancel()
+	}()
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			p.pretrace(ctx)
+			timer.Reset(pretraceInterval)
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// pretrace traces all finalized blocks not yet traced.
+func (p *Pretracer) pretrace(ctx context.Context) {
+	final, err := p.api.backend.HeaderByNumber(ctx, rpc.FinalizedBlockNumber)
+	if err != nil || final == nil {
+		return
+	}
+	head := final.Number.Uint64()
+	if head <= p.last {
+		return
+	}
+	start := p.last + 1
+	if p.last == 0 || head-p.last > maxPretraceBacklog {
+		start = head
+	}
+	for number := start; number <= head; number++ {
+		if ctx.Err() != nil {
+			return
+		}
+		block, err := p.api.blockByNumber(ctx, rpc.BlockNumber(number))
+		if err != nil {
+			log.Debug("Failed to retrieve block for pre-tracing", "number", number, "err", err)
+			return
+		}
+		for _, config := range p.configs {
+			if p.api.store.has(block.Hash(), config) {
+				continue
+			}
+			start := time.Now()
+			if _, err := p.api.traceBlock(ctx, block, config); err != nil {
+				log.Warn("Failed to pre-trace block", "number", number, "tracer", config.Tracer, "err", err)
+				continue
+			}
+			log.Debug("Pre-traced finalized block", "number", number, "hash", block.Hash(), "elapsed", time.Since(start))
+		}
+		p.last = number
+	}
+}
@@ -0,0 +1,208 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/internal/ethapi"
+	"github.com/ethereum/go-ethereum/internal/ethapi/override"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// simTraceCall is a call of a simulated block, along with the configuration of
+// the tracer to trace it with.
+type simTraceCall struct {
+	ethapi.TransactionArgs
+	TraceConfig *TraceConfig `json:"traceConfig"`
+}
+
+// simTraceBlock is a batch of calls to be simulated and traced sequentially.
+type simTraceBlock struct {
+	BlockOverrides *override.BlockOverrides
+	StateOverrides *override.StateOverride
+	Calls          []simTraceCall
+}
+
+// simTraceOpts are the inputs to debug_traceSimulateV1, mirroring the payload
+// of eth_simulateV1.
+type simTraceOpts struct {
+	BlockStateCalls []simTraceBlock
+	Validation      bool
+}
+
+// simTraceBlockResult contains the traces of the calls of a simulated block.
+type simTraceBlockResult struct {
+	Number hexutil.Uint64   `json:"number"`
+	Hash   common.Hash      `json:"hash"`
+	Calls  []*txTraceResult `json:"calls"`
+}
+
+// simTrace is the tracer of a single simulated call.
+type simTrace struct {
+	tracer *Tracer
+	timer  *time.Timer
+	txHash common.Hash
+}
+
+// TraceSimulateV1 simulates a series of blocks on top of the provided block, as
+// eth_simulateV1 does, and traces every simulated call. Each call is traced with
+// its own trace config if given, falling back to the config provided for the
+// whole request, and the state changes of every call are visible to all later
+// calls of the simulation.
+func (api *API) TraceSimulateV1(ctx context.Context, opts simTraceOpts, blockNrOrHash *rpc.BlockNumberOrHash, config *TraceConfig) ([]*simTraceBlockResult, error) {
+	if blockNrOrHash == nil {
+		n := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+		blockNrOrHash = &n
+	}
+	// Try to retrieve the specified block
+	var (
+		err   error
+		block *types.Block
+	)
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		block, err = api.blockByHash(ctx, hash)
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		if number == rpc.PendingBlockNumber {
+			return nil, errors.New("tracing on top of pending is not supported")
+		}
+		block, err = api.blockByNumber(ctx, number)
+	} else {
+		return nil, errors.New("invalid arguments; neither block nor hash specified")
+	}
+	if err != nil {
+		return nil, err
+	}
+	// Resolve the trace config of every call, the simulation as a whole is
+	// allowed to run as long as the sum of the individual call timeouts.
+	var (
+		blocks  = make([]ethapi.SimBlock, len(opts.BlockStateCalls))
+		timeout time.Duration
+	)
+	for i, b := range opts.BlockStateCalls {
+		blocks[i] = ethapi.SimBlock{
+			BlockOverrides: b.BlockOverrides,
+			StateOverrides: b.StateOverrides,
+			Calls:          make([]ethapi.TransactionArgs, len(b.Calls)),
+		}
+		for j, call := range b.Calls {
+			blocks[i].Calls[j] = call.TransactionArgs
+			if call.TraceConfig == nil {
+				opts.BlockStateCalls[i].Calls[j].TraceConfig = config
+			}
+			callTimeout := defaultTraceTimeout
+			if cfg := opts.BlockStateCalls[i].Calls[j].TraceConfig; cfg != nil && cfg.Timeout != nil {
+				if callTimeout, err = time.ParseDuration(*cfg.Timeout); err != nil {
+					return nil, fmt.Errorf("invalid timeout of call %d in block %d: %w", j, i, err)
+				}
+			}
+			timeout += callTimeout
+		}
+	}
+	reexec := defaultTraceReexec
+	if config != nil && config.Reexec != nil {
+		reexec = *config.Reexec
+	}
+	statedb, release, err := api.backend.StateAtBlock(ctx, block, reexec, nil, true, false)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Blocks without calls are never traced, the simulated blocks with calls map
+	// to the requested ones in order, skipping the gaps filled by the simulator.
+	var (
+		cursor = -1
+		traces = make(map[uint64][]*simTrace)
+	)
+	defer func() {
+		for _, calls := range traces {
+			for _, trace := range calls {
+				trace.timer.Stop()
+			}
+		}
+	}()
+	callTracer := func(number uint64, index int, tx *types.Transaction) (*tracing.Hooks, error) {
+		if index == 0 {
+			for cursor++; cursor < len(opts.BlockStateCalls) && len(opts.BlockStateCalls[cursor].Calls) == 0; cursor++ {
+			}
+		}
+		if cursor >= len(opts.BlockStateCalls) || index >= len(opts.BlockStateCalls[cursor].Calls) {
+			return nil, fmt.Errorf("unexpected simulated call %d in block %d", index, number)
+		}
+		txctx := &Context{
+			BlockNumber: new(big.Int).SetUint64(number),
+			TxIndex:     index,
+			TxHash:      tx.Hash(),
+		}
+		tracer, timeout, err := api.newTracer(opts.BlockStateCalls[cursor].Calls[index].TraceConfig, txctx)
+		if err != nil {
+			return nil, err
+		}
+		trace := &simTrace{tracer: tracer, txHash: tx.Hash()}
+		trace.timer = time.AfterFunc(timeout, func() {
+			tracer.Stop(errors.New("execution timeout"))
+		})
+		traces[number] = append(traces[number], trace)
+
+		// Disarm the timeout once the call finishes, so that it doesn't interrupt
+		// the tracer while later calls are executing.
+		hooks := *tracer.Hooks
+		hooks.OnTxEnd = func(receipt *types.Receipt, err error) {
+			trace.timer.Stop()
+			if tracer.OnTxEnd != nil {
+				tracer.OnTxEnd(receipt, err)
+			}
+		}
+		return &hooks, nil
+	}
+	simulated, err := ethapi.Simulate(ctx, api.backend, statedb, block.Header(), blocks, ethapi.SimConfig{
+		Validation: opts.Validation,
+		GasCap:     api.backend.RPCGasCap(),
+		Timeout:    timeout,
+		CallTracer: callTracer,
+	})
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*simTraceBlockResult, len(simulated))
+	for i, b := range simulated {
+		result := &simTraceBlockResult{
+			Number: hexutil.Uint64(b.NumberU64()),
+			Hash:   b.Hash(),
+			Calls:  make([]*txTraceResult, 0),
+		}
+		for _, trace := range traces[b.NumberU64()] {
+			res, err := trace.tracer.GetResult()
+			if err != nil {
+				result.Calls = append(result.Calls, &txTraceResult{TxHash: trace.txHash, Error: err.Error()})
+				continue
+			}
+			result.Calls = append(result.Calls, &txTraceResult{TxHash: trace.txHash, Result: res})
+		}
+		results[i] = result
+	}
+	return results, nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func TestTraceSimulate(t *testing.T) {
+	t.Parallel()
+
+	accounts := newAccounts(1)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	backend := newTestBackend(t, 2, genesis, func(i int, b *core.BlockGen) {})
+	defer backend.teardown()
+	api := NewAPI(backend)
+
+	// The contract stores the call value in slot 0 if non-zero, otherwise it
+	// returns the stored value.
+	var (
+		contract = common.HexToAddress("0xc0de")
+		code     = "0x3415600a5734600055005b60005460005260206000f3"
+		payload  = fmt.Sprintf(`{
+			"blockStateCalls": [
+				{
+					"stateOverrides": {"%[1]s": {"code": "%[2]s"}},
+					"calls": [{"from": "%[3]s", "to": "%[1]s", "value": "0x2a"}]
+				},
+				{
+					"calls": []
+				},
+				{
+					"blockOverrides": {"number": "0x7"},
+					"calls": [
+						{"from": "%[3]s", "to": "%[1]s", "traceConfig": {"disableStack": true}},
+						{"from": "%[3]s", "to": "%[1]s", "traceConfig": {"timeout": "bogus"}}
+					]
+				}
+			]
+		}`, contract, code, accounts[0].addr)
+		opts simTraceOpts
+	)
+	if err := json.Unmarshal([]byte(payload), &opts); err != nil {
+		t.Fatalf("failed to decode payload: %v", err)
+	}
+	// Invalid trace configs fail the whole request
+	if _, err := api.TraceSimulateV1(context.Background(), opts, nil, nil); err == nil {
+		t.Fatal("expected error for invalid timeout")
+	}
+	opts.BlockStateCalls[2].Calls = opts.BlockStateCalls[2].Calls[:1]
+
+	latest := rpc.BlockNumberOrHashWithNumber(rpc.LatestBlockNumber)
+	results, err := api.TraceSimulateV1(context.Background(), opts, &latest, nil)
+	if err != nil {
+		t.Fatalf("failed to trace simulation: %v", err)
+	}
+	// Blocks 3 and 4 are simulated, 5 and 6 fill the gap up to 7
+	if len(results) != 5 {
+		t.Fatalf("unexpected number of blocks: have %d want 5", len(results))
+	}
+	for i, result := range results {
+		if have, want := uint64(result.Number), uint64(3+i); have != want {
+			t.Errorf("block %d: unexpected number: have %d want %d", i, have, want)
+		}
+		want := 0
+		if i == 0 || i == 4 {
+			want = 1
+		}
+		if len(result.Calls) != want {
+			t.Fatalf("block %d: unexpected number of calls: have %d want %d", i, len(result.Calls), want)
+		}
+	}
+	decode := func(res *txTraceResult) *logger.ExecutionResult {
+		if res.Error != "" {
+			t.Fatalf("trace failed: %v", res.Error)
+		}
+		var exec logger.ExecutionResult
+		if err := json.Unmarshal(res.Result.(json.RawMessage), &exec); err != nil {
+			t.Fatalf("failed to decode trace: %v", err)
+		}
+		return &exec
+	}
+	// The second call observes the storage written by the first one
+	store := decode(results[0].Calls[0])
+	if store.Failed || len(store.StructLogs) == 0 {
+		t.Fatalf("unexpected trace of store call: %+v", store)
+	}
+	load := decode(results[4].Calls[0])
+	if have, want := common.BytesToHash(load.ReturnValue), common.BigToHash(big.NewInt(42)); have != want {
+		t.Fatalf("unexpected return value: have %x want %x", have, want)
+	}
+	// The per-call trace config is honored
+	var op struct {
+		Stack []string `json:"stack"`
+	}
+	for _, raw := range load.StructLogs {
+		if err := json.Unmarshal(raw, &op); err != nil {
+			t.Fatal(err)
+		}
+		if len(op.Stack) != 0 {
+			t.Fatalf("stack captured despite being disabled")
+		}
+	}
+}
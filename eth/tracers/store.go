@@ -0,0 +1,293 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/snappy"
+)
+
+// The trace store keeps its entries in a dedicated key-value database using the
+// following layout:
+//
+//	traceStoreEntryPrefix + block hash + tracer hash + config hash -> seq + snappy(json(traces))
+//	traceStoreSeqPrefix + seq (uint64 big endian)                  -> entry key
+//	traceStoreMetaKey                                               -> rlp(traceStoreMeta)
+//
+// The sequence index records the insertion order of the entries, which is used
+// to evict the oldest traces once the store grows beyond its size limit.
+var (
+	traceStoreEntryPrefix = []byte("e")
+	traceStoreSeqPrefix   = []byte("s")
+	traceStoreMetaKey     = []byte("meta")
+)
+
+// defaultTracerName is the name used in trace store keys for traces produced by
+// the default struct logger.
+const defaultTracerName = "structLogger"
+
+// traceStoreMeta is the persisted accounting of the trace store.
+type traceStoreMeta struct {
+	Seq   uint64 // Sequence number of the next inserted entry
+	Count uint64 // Number of stored entries
+	Size  uint64 // Total size of the stored entries in bytes
+}
+
+// TraceStoreStats is the statistics of the trace store, as returned by the
+// debug_traceStoreStats RPC.
+type TraceStoreStats struct {
+	Entries   uint64 `json:"entries"`
+	Size      uint64 `json:"size"`
+	Limit     uint64 `json:"limit"`
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+}
+
+// TraceStore is a persistent cache of block traces, keyed by block hash, tracer
+// name and tracer configuration. It's only meant to hold traces of finalized
+// blocks, which are immutable.
+type TraceStore struct {
+	db    ethdb.KeyValueStore
+	limit uint64 // Maximum total size of the stored entries in bytes
+
+	meta      traceStoreMeta
+	hits      uint64
+	misses    uint64
+	evictions uint64
+	lock      sync.Mutex
+}
+
+// NewTraceStore opens a trace store on top of the given database, evicting the
+// oldest entries whenever the total size exceeds limit bytes.
+func NewTraceStore(db ethdb.KeyValueStore, limit uint64) (*TraceStore, error) {
+	s := &TraceStore{db: db, limit: limit}
+	blob, err := db.Get(traceStoreMetaKey)
+	if err == nil && len(blob) > 0 {
+		if err := rlp.DecodeBytes(blob, &s.meta); err != nil {
+			return nil, err
+		}
+	}
+	// Shrink the store in case the limit was lowered since the last run
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.evict(0); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// traceStoreKey derives the key of the traces for the given block and tracer
+// configuration.
+func traceStoreKey(block common.Hash, config *TraceConfig) []byte {
+	var (
+		name         = defaultTracerName
+		loggerConfig logger.Config
+		tracerConfig = []byte("{}")
+	)
+	if config != nil {
+		if config.Tracer != nil {
+			name = *config.Tracer
+		}
+		if config.Config != nil {
+			loggerConfig = *config.Config
+		}
+		if len(config.TracerConfig) > 0 && !bytes.Equal(config.TracerConfig, []byte("null")) {
+			var buf bytes.Buffer
+			if err := json.Compact(&buf, config.TracerConfig); err == nil {
+				tracerConfig = buf.Bytes()
+			} else {
+				tracerConfig = config.TracerConfig
+			}
+		}
+	}
+	// Timeout and reexec don't influence the result of a successful trace,
+	// leave them out of the key.
+	blob, _ := json.Marshal(&loggerConfig)
+	configHash := crypto.Keccak256(blob, tracerConfig)
+
+	key := make([]byte, 0, len(traceStoreEntryPrefix)+3*common.HashLength)
+	key = append(key, traceStoreEntryPrefix...)
+	key = append(key, block.Bytes()...)
+	key = append(key, crypto.Keccak256([]byte(name))...)
+	key = append(key, configHash...)
+	return key
+}
+
+// traceStoreSeqKey returns the key of the insertion index entry with the given
+// sequence number.
+func traceStoreSeqKey(seq uint64) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte{}, traceStoreSeqPrefix...), seq)
+}
+
+// storedTxTrace is the decoded form of a stored transaction trace, with the
+// result kept in its encoded form.
+type storedTxTrace struct {
+	TxHash common.Hash     `json:"txHash"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// has reports whether traces for the given block and configuration are stored.
+func (s *TraceStore) has(block common.Hash, config *TraceConfig) bool {
+	ok, _ := s.db.Has(traceStoreKey(block, config))
+	return ok
+}
+
+// get retrieves the stored traces for the given block and configuration.
+func (s *TraceStore) get(block common.Hash, config *TraceConfig) ([]*txTraceResult, bool) {
+	blob, err := s.db.Get(traceStoreKey(block, config))
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err != nil || len(blob) < 8 {
+		s.misses++
+		return nil, false
+	}
+	data, err := snappy.Decode(nil, blob[8:])
+	if err != nil {
+		log.Error("Corrupted trace store entry", "block", block, "err", err)
+		s.misses++
+		return nil, false
+	}
+	var stored []*storedTxTrace
+	if err := json.Unmarshal(data, &stored); err != nil {
+		log.Error("Corrupted trace store entry", "block", block, "err", err)
+		s.misses++
+		return nil, false
+	}
+	results := make([]*txTraceResult, len(stored))
+	for i, trace := range stored {
+		results[i] = &txTraceResult{TxHash: trace.TxHash, Error: trace.Error}
+		if trace.Result != nil {
+			results[i].Result = trace.Result
+		}
+	}
+	s.hits++
+	return results, true
+}
+
+// put stores the traces for the given block and configuration, evicting the
+// oldest entries if the store grows beyond its limit.
+func (s *TraceStore) put(block common.Hash, config *TraceConfig, results []*txTraceResult) error {
+	data, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	key := traceStoreKey(block, config)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if ok, _ := s.db.Has(key); ok {
+		return nil
+	}
+	var (
+		seq    = s.meta.Seq
+		seqKey = traceStoreSeqKey(seq)
+		value  = binary.BigEndian.AppendUint64(nil, seq)
+	)
+	value = append(value, snappy.Encode(nil, data)...)
+
+	size := uint64(len(key) + len(value) + len(seqKey) + len(key))
+	if size > s.limit {
+		return errors.New("trace too large for the store")
+	}
+	if err := s.evict(size); err != nil {
+		return err
+	}
+	s.meta.Seq++
+	s.meta.Count++
+	s.meta.Size += size
+
+	batch := s.db.NewBatch()
+	batch.Put(key, value)
+	batch.Put(seqKey, key)
+	s.writeMeta(batch)
+	return batch.Write()
+}
+
+// evict deletes the oldest entries until the given number of additional bytes
+// fits into the store. The caller must hold the lock.
+func (s *TraceStore) evict(size uint64) error {
+	if s.meta.Size+size <= s.limit {
+		return nil
+	}
+	var (
+		batch = s.db.NewBatch()
+		it    = s.db.NewIterator(traceStoreSeqPrefix, nil)
+	)
+	defer it.Release()
+
+	for s.meta.Size+size > s.limit && it.Next() {
+		key := it.Value()
+		blob, err := s.db.Get(key)
+		if err == nil {
+			freed := uint64(len(key) + len(blob) + len(it.Key()) + len(key))
+			s.meta.Size -= min(freed, s.meta.Size)
+		}
+		batch.Delete(key)
+		batch.Delete(it.Key())
+		s.meta.Count--
+		s.evictions++
+	}
+	if it.Error() != nil {
+		return it.Error()
+	}
+	// If the index ran empty, the accounting must be off, reset it
+	if s.meta.Size+size > s.limit {
+		s.meta.Size, s.meta.Count = 0, 0
+	}
+	s.writeMeta(batch)
+	return batch.Write()
+}
+
+// writeMeta adds the current accounting of the store to the given batch.
+func (s *TraceStore) writeMeta(batch ethdb.KeyValueWriter) {
+	blob, err := rlp.EncodeToBytes(&s.meta)
+	if err != nil {
+		log.Crit("Failed to encode trace store metadata", "err", err)
+	}
+	batch.Put(traceStoreMetaKey, blob)
+}
+
+// Stats returns the current statistics of the trace store.
+func (s *TraceStore) Stats() *TraceStoreStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return &TraceStoreStats{
+		Entries:   s.meta.Count,
+		Size:      s.meta.Size,
+		Limit:     s.limit,
+		Hits:      s.hits,
+		Misses:    s.misses,
+		Evictions: s.evictions,
+	}
+}
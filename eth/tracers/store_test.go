@@ -0,0 +1,256 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers/logger"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+func makeStoreTraces(n int) []*txTraceResult {
+	results := make([]*txTraceResult, n)
+	for i := range results {
+		results[i] = &txTraceResult{
+			TxHash: common.Hash{byte(i)},
+			Result: json.RawMessage(`{"gas":21000,"failed":false,"returnValue":"0x","structLogs":[]}`),
+		}
+	}
+	return results
+}
+
+func TestTraceStoreKey(t *testing.T) {
+	var (
+		block  = common.Hash{0x01}
+		call   = "callTracer"
+		prest  = "prestateTracer"
+		tmout  = "10s"
+		reexec = uint64(1)
+	)
+	tests := []struct {
+		a, b  *TraceConfig
+		equal bool
+	}{
+		{nil, &TraceConfig{}, true},
+		{&TraceConfig{Tracer: &call}, &TraceConfig{Tracer: &prest}, false},
+		{&TraceConfig{Tracer: &call}, &TraceConfig{Tracer: &call, Timeout: &tmout, Reexec: &reexec}, true},
+		{&TraceConfig{Tracer: &call}, &TraceConfig{Tracer: &call, TracerConfig: json.RawMessage(`{}`)}, true},
+		{&TraceConfig{Tracer: &call, TracerConfig: json.RawMessage(`{"onlyTopCall":true}`)}, &TraceConfig{Tracer: &call, TracerConfig: json.RawMessage(`{ "onlyTopCall": true }`)}, true},
+		{&TraceConfig{Tracer: &call}, &TraceConfig{Tracer: &call, TracerConfig: json.RawMessage(`{"onlyTopCall":true}`)}, false},
+		{&TraceConfig{}, &TraceConfig{Config: &logger.Config{EnableMemory: true}}, false},
+	}
+	for i, tt := range tests {
+		if equal := bytes.Equal(traceStoreKey(block, tt.a), traceStoreKey(block, tt.b)); equal != tt.equal {
+			t.Errorf("test %d: key equality mismatch: have %v, want %v", i, equal, tt.equal)
+		}
+	}
+	if bytes.Equal(traceStoreKey(common.Hash{0x01}, nil), traceStoreKey(common.Hash{0x02}, nil)) {
+		t.Error("keys of different blocks collide")
+	}
+}
+
+func TestTraceStorePutGet(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	store, err := NewTraceStore(db, 1024*1024)
+	if err != nil {
+		t.Fatalf("failed to create trace store: %v", err)
+	}
+	var (
+		block  = common.Hash{0x01}
+		traces = makeStoreTraces(3)
+	)
+	if _, ok := store.get(block, nil); ok {
+		t.Fatal("unexpected traces in empty store")
+	}
+	if err := store.put(block, nil, traces); err != nil {
+		t.Fatalf("failed to store traces: %v", err)
+	}
+	have, ok := store.get(block, nil)
+	if !ok {
+		t.Fatal("stored traces not found")
+	}
+	haveJSON, _ := json.Marshal(have)
+	wantJSON, _ := json.Marshal(traces)
+	if !bytes.Equal(haveJSON, wantJSON) {
+		t.Fatalf("traces mismatch:\nhave %s\nwant %s", haveJSON, wantJSON)
+	}
+	stats := store.Stats()
+	if stats.Entries != 1 || stats.Hits != 1 || stats.Misses != 1 || stats.Size == 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	// Reopen the store and ensure the accounting is retained
+	reopened, err := NewTraceStore(db, 1024*1024)
+	if err != nil {
+		t.Fatalf("failed to reopen trace store: %v", err)
+	}
+	if have := reopened.Stats(); have.Entries != stats.Entries || have.Size != stats.Size {
+		t.Fatalf("stats not persisted: have %+v, want %+v", have, stats)
+	}
+}
+
+func TestTraceStoreEviction(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	store, err := NewTraceStore(db, 1024*1024)
+	if err != nil {
+		t.Fatalf("failed to create trace store: %v", err)
+	}
+	if err := store.put(common.Hash{0x00}, nil, makeStoreTraces(1)); err != nil {
+		t.Fatalf("failed to store traces: %v", err)
+	}
+	entry := store.Stats().Size
+
+	// Limit the store to three entries and insert a few more
+	store, err = NewTraceStore(db, 3*entry)
+	if err != nil {
+		t.Fatalf("failed to reopen trace store: %v", err)
+	}
+	for i := 1; i < 5; i++ {
+		if err := store.put(common.Hash{byte(i)}, nil, makeStoreTraces(1)); err != nil {
+			t.Fatalf("failed to store traces: %v", err)
+		}
+	}
+	if stats := store.Stats(); stats.Entries != 3 || stats.Evictions != 2 || stats.Size > stats.Limit {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	for i := 0; i < 5; i++ {
+		if have, want := store.has(common.Hash{byte(i)}, nil), i >= 2; have != want {
+			t.Errorf("block %d: presence mismatch: have %v, want %v", i, have, want)
+		}
+	}
+	// Lowering the limit on startup should shrink the store
+	store, err = NewTraceStore(db, entry)
+	if err != nil {
+		t.Fatalf("failed to reopen trace store: %v", err)
+	}
+	if stats := store.Stats(); stats.Entries != 1 {
+		t.Fatalf("store not shrunk: %+v", stats)
+	}
+	if !store.has(common.Hash{0x04}, nil) {
+		t.Fatal("newest entry evicted")
+	}
+	// Entries larger than the store are rejected
+	if err := store.put(common.Hash{0x05}, nil, makeStoreTraces(10)); err == nil {
+		t.Fatal("oversized entry accepted")
+	}
+}
+
+func TestTraceBlockStore(t *testing.T) {
+	t.Parallel()
+
+	accounts := newAccounts(2)
+	genesis := &core.Genesis{
+		Config: params.TestChainConfig,
+		Alloc: types.GenesisAlloc{
+			accounts[0].addr: {Balance: big.NewInt(params.Ether)},
+		},
+	}
+	signer := types.HomesteadSigner{}
+	backend := newTestBackend(t, 4, genesis, func(i int, b *core.BlockGen) {
+		tx, _ := types.SignTx(types.NewTx(&types.LegacyTx{
+			Nonce:    uint64(i),
+			To:       &accounts[1].addr,
+			Value:    big.NewInt(1000),
+			Gas:      params.TxGas,
+			GasPrice: b.BaseFee(),
+		}), signer, accounts[0].key)
+		b.AddTx(tx)
+	})
+	defer backend.teardown()
+
+	store, err := NewTraceStore(rawdb.NewMemoryDatabase(), 1024*1024)
+	if err != nil {
+		t.Fatalf("failed to create trace store: %v", err)
+	}
+	api := NewAPIWithStore(backend, store)
+	backend.chain.SetFinalized(backend.chain.GetHeaderByNumber(2))
+
+	// Blocks beyond the finalized one must not be stored
+	if _, err := api.TraceBlockByNumber(context.Background(), rpc.BlockNumber(3), nil); err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if stats := store.Stats(); stats.Entries != 0 {
+		t.Fatalf("unfinalized block stored: %+v", stats)
+	}
+	// Finalized blocks are stored on the first request and served afterwards
+	want, err := api.TraceBlockByNumber(context.Background(), rpc.BlockNumber(2), nil)
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	have, err := api.TraceBlockByNumber(context.Background(), rpc.BlockNumber(2), nil)
+	if err != nil {
+		t.Fatalf("failed to trace block: %v", err)
+	}
+	if stats := store.Stats(); stats.Entries != 1 || stats.Hits != 1 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	haveJSON, _ := json.Marshal(have)
+	wantJSON, _ := json.Marshal(want)
+	if !bytes.Equal(haveJSON, wantJSON) {
+		t.Fatalf("stored trace mismatch:\nhave %s\nwant %s", haveJSON, wantJSON)
+	}
+	stats, err := api.TraceStoreStats()
+	if err != nil {
+		t.Fatalf("failed to retrieve stats: %v", err)
+	}
+	if !reflect.DeepEqual(stats, store.Stats()) {
+		t.Fatalf("stats mismatch: have %+v, want %+v", stats, store.Stats())
+	}
+	if _, err := NewAPI(backend).TraceStoreStats(); err == nil {
+		t.Fatal("expected error without trace store")
+	}
+}
+
+func TestPretracer(t *testing.T) {
+	t.Parallel()
+
+	genesis := &core.Genesis{Config: params.TestChainConfig}
+	backend := newTestBackend(t, 4, genesis, func(i int, b *core.BlockGen) {})
+	defer backend.teardown()
+
+	store, err := NewTraceStore(rawdb.NewMemoryDatabase(), 1024*1024)
+	if err != nil {
+		t.Fatalf("failed to create trace store: %v", err)
+	}
+	p := NewPretracer(backend, store, []string{defaultTracerName})
+
+	backend.chain.SetFinalized(backend.chain.GetHeaderByNumber(2))
+	p.pretrace(context.Background())
+	if !store.has(backend.chain.GetHeaderByNumber(2).Hash(), nil) {
+		t.Fatal("finalized block not pre-traced")
+	}
+	backend.chain.SetFinalized(backend.chain.GetHeaderByNumber(4))
+	p.pretrace(context.Background())
+	for n := uint64(3); n <= 4; n++ {
+		if !store.has(backend.chain.GetHeaderByNumber(n).Hash(), nil) {
+			t.Fatalf("block %d not pre-traced", n)
+		}
+	}
+	if stats := store.Stats(); stats.Entries != 3 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
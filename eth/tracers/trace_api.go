@@ -0,0 +1,183 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+// Trace types accepted by the trace_replay* family of methods.
+const (
+	traceTypeTrace     = "trace"
+	traceTypeStateDiff = "stateDiff"
+	traceTypeVmTrace   = "vmTrace"
+)
+
+// replayTracerConfig runs the flat call tracer and the state diff tracer in a
+// single pass. Errors are converted to the parity format, as expected by
+// trace_ namespace consumers.
+var replayTracerConfig = json.RawMessage(`{"flatCallTracer":{"convertParityErrors":true},"stateDiffTracer":{}}`)
+
+var errVmTraceUnsupported = errors.New("vmTrace is not supported")
+
+// replayResult is the result of replaying a single transaction, in the format
+// of OpenEthereum's trace_replayTransaction.
+type replayResult struct {
+	Output          hexutil.Bytes   `json:"output"`
+	StateDiff       json.RawMessage `json:"stateDiff"`
+	Trace           json.RawMessage `json:"trace"`
+	VmTrace         json.RawMessage `json:"vmTrace"`
+	TransactionHash *common.Hash    `json:"transactionHash,omitempty"`
+}
+
+// replayTypes is the set of requested outputs of a replay.
+type replayTypes struct {
+	trace     bool
+	stateDiff bool
+}
+
+// parseTraceTypes validates the trace types requested by the user.
+func parseTraceTypes(types []string) (replayTypes, error) {
+	var res replayTypes
+	for _, typ := range types {
+		switch typ {
+		case traceTypeTrace:
+			res.trace = true
+		case traceTypeStateDiff:
+			res.stateDiff = true
+		case traceTypeVmTrace:
+			return replayTypes{}, errVmTraceUnsupported
+		default:
+			return replayTypes{}, fmt.Errorf("invalid trace type %q", typ)
+		}
+	}
+	return res, nil
+}
+
+// TraceAPI is the collection of OpenEthereum compatible tracing APIs, built on
+// top of the debug tracing API.
+type TraceAPI struct {
+	api *API
+}
+
+// NewTraceAPI creates a new API definition for the trace_ namespace.
+func NewTraceAPI(api *API) *TraceAPI {
+	return &TraceAPI{api: api}
+}
+
+// replayConfig returns the tracer configuration used for replaying transactions.
+func replayConfig() *TraceConfig {
+	tracer := "muxTracer"
+	return &TraceConfig{Tracer: &tracer, TracerConfig: replayTracerConfig}
+}
+
+// ReplayTransaction replays a transaction, returning the traces configured by
+// traceTypes. Supported types are "trace" and "stateDiff".
+func (api *TraceAPI) ReplayTransaction(ctx context.Context, hash common.Hash, traceTypes []string) (*replayResult, error) {
+	opts, err := parseTraceTypes(traceTypes)
+	if err != nil {
+		return nil, err
+	}
+	res, err := api.api.TraceTransaction(ctx, hash, replayConfig())
+	if err != nil {
+		return nil, err
+	}
+	return newReplayResult(res, opts)
+}
+
+// ReplayBlockTransactions replays all transactions in a block, returning the
+// traces configured by traceTypes for each transaction.
+func (api *TraceAPI) ReplayBlockTransactions(ctx context.Context, blockNrOrHash rpc.BlockNumberOrHash, traceTypes []string) ([]*replayResult, error) {
+	opts, err := parseTraceTypes(traceTypes)
+	if err != nil {
+		return nil, err
+	}
+	var traces []*txTraceResult
+	if hash, ok := blockNrOrHash.Hash(); ok {
+		traces, err = api.api.TraceBlockByHash(ctx, hash, replayConfig())
+	} else if number, ok := blockNrOrHash.Number(); ok {
+		traces, err = api.api.TraceBlockByNumber(ctx, number, replayConfig())
+	} else {
+		return nil, errors.New("invalid arguments; neither block nor hash specified")
+	}
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*replayResult, len(traces))
+	for i, trace := range traces {
+		if trace.Error != "" {
+			return nil, fmt.Errorf("tracing transaction %#x failed: %s", trace.TxHash, trace.Error)
+		}
+		result, err := newReplayResult(trace.Result, opts)
+		if err != nil {
+			return nil, err
+		}
+		txHash := trace.TxHash
+		result.TransactionHash = &txHash
+		results[i] = result
+	}
+	return results, nil
+}
+
+// newReplayResult converts the output of the replay tracer into a replayResult,
+// retaining only the requested trace types.
+func newReplayResult(res interface{}, opts replayTypes) (*replayResult, error) {
+	raw, ok := res.(json.RawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected trace result type %T", res)
+	}
+	var parts struct {
+		Trace     json.RawMessage `json:"flatCallTracer"`
+		StateDiff json.RawMessage `json:"stateDiffTracer"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return nil, err
+	}
+	// The output of the transaction is the output of the top level call, or
+	// the deployed code in case of a contract creation.
+	var frames []struct {
+		Result *struct {
+			Code   hexutil.Bytes `json:"code"`
+			Output hexutil.Bytes `json:"output"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(parts.Trace, &frames); err != nil {
+		return nil, err
+	}
+	result := &replayResult{Output: hexutil.Bytes{}}
+	if len(frames) > 0 && frames[0].Result != nil {
+		if frames[0].Result.Code != nil {
+			result.Output = frames[0].Result.Code
+		} else if frames[0].Result.Output != nil {
+			result.Output = frames[0].Result.Output
+		}
+	}
+	if opts.trace {
+		result.Trace = parts.Trace
+	}
+	if opts.stateDiff {
+		result.StateDiff = parts.StateDiff
+	}
+	return result, nil
+}
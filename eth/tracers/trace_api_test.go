@@ -0,0 +1,81 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package tracers
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestParseTraceTypes(t *testing.T) {
+	var tests = []struct {
+		types []string
+		want  replayTypes
+		err   bool
+	}{
+		{types: nil, want: replayTypes{}},
+		{types: []string{"trace"}, want: replayTypes{trace: true}},
+		{types: []string{"stateDiff", "trace"}, want: replayTypes{trace: true, stateDiff: true}},
+		{types: []string{"vmTrace"}, err: true},
+		{types: []string{"trace", "unknown"}, err: true},
+	}
+	for i, test := range tests {
+		have, err := parseTraceTypes(test.types)
+		if test.err {
+			if err == nil {
+				t.Errorf("test %d: expected error", i)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if have != test.want {
+			t.Errorf("test %d: have %+v, want %+v", i, have, test.want)
+		}
+	}
+	if _, err := parseTraceTypes([]string{"vmTrace"}); !errors.Is(err, errVmTraceUnsupported) {
+		t.Errorf("wrong error for vmTrace: %v", err)
+	}
+}
+
+func TestReplayResult(t *testing.T) {
+	var (
+		trace = `[{"action":{"from":"0x0000000000000000000000000000000000000001"},"result":{"gasUsed":"0x0","output":"0x2a"},"subtraces":0,"traceAddress":[],"type":"call"}]`
+		diff  = `{"0x0000000000000000000000000000000000000001":{"balance":"=","code":"=","nonce":{"*":{"from":"0x0","to":"0x1"}},"storage":{}}}`
+		raw   = json.RawMessage(`{"flatCallTracer":` + trace + `,"stateDiffTracer":` + diff + `}`)
+	)
+	res, err := newReplayResult(raw, replayTypes{stateDiff: true})
+	if err != nil {
+		t.Fatalf("failed to convert result: %v", err)
+	}
+	have, _ := json.Marshal(res)
+	want := `{"output":"0x2a","stateDiff":` + diff + `,"trace":null,"vmTrace":null}`
+	if string(have) != want {
+		t.Fatalf("result mismatch\nhave: %s\nwant: %s", have, want)
+	}
+	res, err = newReplayResult(raw, replayTypes{trace: true})
+	if err != nil {
+		t.Fatalf("failed to convert result: %v", err)
+	}
+	have, _ = json.Marshal(res)
+	want = `{"output":"0x2a","stateDiff":null,"trace":` + trace + `,"vmTrace":null}`
+	if string(have) != want {
+		t.Fatalf("result mismatch\nhave: %s\nwant: %s", have, want)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// hostModule is the name of the module providing the host functions.
+const hostModule = "geth"
+
+// instantiateHost registers the host functions available to tracers in the
+// given runtime.
+func instantiateHost(ctx context.Context, rt wazero.Runtime) error {
+	b := rt.NewHostModuleBuilder(hostModule)
+	export := func(name string, fn interface{}) {
+		b.NewFunctionBuilder().WithFunc(fn).Export(name)
+	}
+	export("abort", hostAbort)
+	export("log", hostLog)
+	export("block_number", hostBlockNumber)
+	export("get_balance", hostGetBalance)
+	export("get_nonce", hostGetNonce)
+	export("get_code_hash", hostGetCodeHash)
+	export("get_code_size", hostGetCodeSize)
+	export("get_code", hostGetCode)
+	export("get_state", hostGetState)
+	export("exists", hostExists)
+	export("stack_len", hostStackLen)
+	export("stack_peek", hostStackPeek)
+	export("memory_len", hostMemoryLen)
+	export("memory_read", hostMemoryRead)
+	export("contract_address", hostContractAddress)
+	export("contract_caller", hostContractCaller)
+
+	_, err := b.Instantiate(ctx)
+	return err
+}
+
+// caller returns the tracer invoking a host function, charging the cost of the
+// call against the gas limit.
+func caller(ctx context.Context, cost uint64) *tracer {
+	t := ctx.Value(tracerKey{}).(*tracer)
+	if !t.charge(hostCost + cost) {
+		t.fail(errOutOfGas)
+	}
+	return t
+}
+
+// state returns the state accessible by the tracer, failing if called outside
+// of a transaction.
+func (t *tracer) state() tracing.StateDB {
+	if t.env == nil || t.env.StateDB == nil {
+		t.fail(errNoState)
+	}
+	return t.env.StateDB
+}
+
+// read copies a chunk of the guest memory.
+func (t *tracer) read(ptr, size uint32) []byte {
+	blob, ok := t.mem.Read(ptr, size)
+	if !ok {
+		t.fail(fmt.Errorf("tracer accessed out of bound memory: offset %d, size %d", ptr, size))
+	}
+	return common.CopyBytes(blob)
+}
+
+// write copies the value into the guest memory.
+func (t *tracer) write(ptr uint32, v []byte) {
+	if !t.mem.Write(ptr, v) {
+		t.fail(fmt.Errorf("tracer accessed out of bound memory: offset %d, size %d", ptr, len(v)))
+	}
+}
+
+// address reads an address from the guest memory.
+func (t *tracer) address(ptr uint32) common.Address {
+	return common.BytesToAddress(t.read(ptr, common.AddressLength))
+}
+
+func hostAbort(ctx context.Context, m api.Module, msg, size uint32) {
+	t := caller(ctx, 0)
+	t.fail(fmt.Errorf("tracer aborted: %s", t.read(msg, size)))
+}
+
+func hostLog(ctx context.Context, m api.Module, msg, size uint32) {
+	t := caller(ctx, (uint64(size)+31)/32)
+	log.Debug("WASM tracer log", "tracer", t.module.name, "msg", string(t.read(msg, size)))
+}
+
+func hostBlockNumber(ctx context.Context, m api.Module) uint64 {
+	t := caller(ctx, 0)
+	if t.env == nil || t.env.BlockNumber == nil {
+		t.fail(errNoState)
+	}
+	return t.env.BlockNumber.Uint64()
+}
+
+func hostGetBalance(ctx context.Context, m api.Module, addr, out uint32) {
+	t := caller(ctx, stateCost)
+	balance := t.state().GetBalance(t.address(addr)).Bytes32()
+	t.write(out, balance[:])
+}
+
+func hostGetNonce(ctx context.Context, m api.Module, addr uint32) uint64 {
+	t := caller(ctx, stateCost)
+	return t.state().GetNonce(t.address(addr))
+}
+
+func hostGetCodeHash(ctx context.Context, m api.Module, addr, out uint32) {
+	t := caller(ctx, stateCost)
+	t.write(out, t.state().GetCodeHash(t.address(addr)).Bytes())
+}
+
+func hostGetCodeSize(ctx context.Context, m api.Module, addr uint32) uint32 {
+	t := caller(ctx, stateCost)
+	return uint32(len(t.state().GetCode(t.address(addr))))
+}
+
+func hostGetCode(ctx context.Context, m api.Module, addr, out, size uint32) uint32 {
+	t := caller(ctx, stateCost+(uint64(size)+31)/32)
+	code := t.state().GetCode(t.address(addr))
+	t.write(out, code[:min(int(size), len(code))])
+	return uint32(len(code))
+}
+
+func hostGetState(ctx context.Context, m api.Module, addr, slot, out uint32) {
+	t := caller(ctx, stateCost)
+	key := common.BytesToHash(t.read(slot, common.HashLength))
+	t.write(out, t.state().GetState(t.address(addr), key).Bytes())
+}
+
+func hostExists(ctx context.Context, m api.Module, addr uint32) uint32 {
+	t := caller(ctx, stateCost)
+	if t.state().Exist(t.address(addr)) {
+		return 1
+	}
+	return 0
+}
+
+func hostStackLen(ctx context.Context, m api.Module) int32 {
+	t := caller(ctx, 0)
+	if t.scope == nil {
+		return -1
+	}
+	return int32(len(t.scope.StackData()))
+}
+
+func hostStackPeek(ctx context.Context, m api.Module, n, out uint32) int32 {
+	t := caller(ctx, 1)
+	if t.scope == nil {
+		return -1
+	}
+	stack := t.scope.StackData()
+	if int(n) >= len(stack) {
+		return -1
+	}
+	item := stack[len(stack)-1-int(n)].Bytes32()
+	t.write(out, item[:])
+	return 0
+}
+
+func hostMemoryLen(ctx context.Context, m api.Module) int32 {
+	t := caller(ctx, 0)
+	if t.scope == nil {
+		return -1
+	}
+	return int32(len(t.scope.MemoryData()))
+}
+
+func hostMemoryRead(ctx context.Context, m api.Module, offset, size, out uint32) int32 {
+	t := caller(ctx, (uint64(size)+31)/32)
+	if t.scope == nil {
+		return -1
+	}
+	memory := t.scope.MemoryData()
+	if uint64(offset)+uint64(size) > uint64(len(memory)) {
+		return -1
+	}
+	t.write(out, memory[offset:offset+size])
+	return 0
+}
+
+func hostContractAddress(ctx context.Context, m api.Module, out uint32) int32 {
+	t := caller(ctx, 0)
+	if t.scope == nil {
+		return -1
+	}
+	t.write(out, t.scope.Address().Bytes())
+	return 0
+}
+
+func hostContractCaller(ctx context.Context, m api.Module, out uint32) int32 {
+	t := caller(ctx, 0)
+	if t.scope == nil {
+		return -1
+	}
+	t.write(out, t.scope.Caller().Bytes())
+	return 0
+}
@@ -0,0 +1,454 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// gasExport is the name under which the injected gas counter is exported.
+const gasExport = "__geth_gas"
+
+// WebAssembly section identifiers used by the instrumentation.
+const (
+	sectionImport = 2
+	sectionGlobal = 6
+	sectionExport = 7
+	sectionCode   = 10
+)
+
+// sectionOrder is the position of every known section in a well formed module,
+// used to find where to insert a missing global section.
+var sectionOrder = map[byte]int{
+	1: 1, 2: 2, 3: 3, 4: 4, 5: 5, 13: 6, 6: 7, 7: 8, 8: 9, 9: 10, 12: 11, 10: 12, 11: 13,
+}
+
+var errMalformedModule = errors.New("malformed module")
+
+// instrument injects gas metering into a module. A mutable i64 global holding
+// the remaining gas is added to the module and exported as gasExport. Every
+// function body and loop iteration is charged the number of instructions up to
+// the next metering point, trapping once the counter drops below zero. Since
+// all unbounded execution has to go through either a loop or a call, this
+// bounds the CPU time spent within the guest.
+//
+// The counter is initialized to the given limit, which thus applies to the start
+// function run during instantiation.
+func instrument(code []byte, limit uint64) ([]byte, error) {
+	if len(code) < 8 || !bytes.Equal(code[:4], []byte("\x00asm")) {
+		return nil, errMalformedModule
+	}
+	var (
+		r        = &reader{buf: code[8:]}
+		sections []rawSection
+	)
+	for !r.done() {
+		id := r.byte()
+		size := r.u32()
+		body := r.bytes(int(size))
+		if r.err != nil {
+			return nil, r.err
+		}
+		sections = append(sections, rawSection{id: id, body: body})
+	}
+	// Count the imported globals, as the index of the injected global follows
+	// both the imported and the defined ones.
+	var globals uint32
+	for _, s := range sections {
+		if s.id == sectionImport {
+			n, err := importedGlobals(s.body)
+			if err != nil {
+				return nil, err
+			}
+			globals += n
+		}
+	}
+	// Append the counter to the global section, creating one if necessary
+	global := appendSleb([]byte{0x7e, 0x01, 0x42}, int64(limit)) // mutable i64, i64.const limit
+	global = append(global, 0x0b)
+
+	var found bool
+	for i, s := range sections {
+		if s.id != sectionGlobal {
+			continue
+		}
+		r := &reader{buf: s.body}
+		n := r.u32()
+		if r.err != nil {
+			return nil, r.err
+		}
+		globals += n
+		sections[i].body = append(append(binary.AppendUvarint(nil, uint64(n)+1), r.buf[r.pos:]...), global...)
+		found = true
+	}
+	if !found {
+		pos := len(sections)
+		for i, s := range sections {
+			if order, ok := sectionOrder[s.id]; ok && order > sectionOrder[sectionGlobal] {
+				pos = i
+				break
+			}
+		}
+		s := rawSection{id: sectionGlobal, body: append(binary.AppendUvarint(nil, 1), global...)}
+		sections = append(sections[:pos], append([]rawSection{s}, sections[pos:]...)...)
+	}
+	// Export the counter and meter all the function bodies
+	var exported, metered bool
+	for i, s := range sections {
+		var err error
+		switch s.id {
+		case sectionExport:
+			sections[i].body, err = appendGasExport(s.body, globals)
+			exported = true
+		case sectionCode:
+			sections[i].body, err = meterCode(s.body, globals)
+			metered = true
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !exported {
+		return nil, errors.New("missing exports")
+	}
+	if !metered {
+		return nil, errors.New("missing code")
+	}
+	out := append([]byte{}, code[:8]...)
+	for _, s := range sections {
+		out = append(out, s.id)
+		out = binary.AppendUvarint(out, uint64(len(s.body)))
+		out = append(out, s.body...)
+	}
+	return out, nil
+}
+
+// rawSection is a raw section of a module.
+type rawSection struct {
+	id   byte
+	body []byte
+}
+
+// importedGlobals returns the number of globals imported by an import section.
+func importedGlobals(body []byte) (uint32, error) {
+	r := &reader{buf: body}
+	var globals uint32
+	for n := r.u32(); n > 0 && r.err == nil; n-- {
+		r.bytes(int(r.u32())) // module
+		r.bytes(int(r.u32())) // name
+		switch r.byte() {
+		case 0x00: // function
+			r.u32()
+		case 0x01: // table
+			r.byte()
+			r.limits()
+		case 0x02: // memory
+			r.limits()
+		case 0x03: // global
+			r.byte()
+			r.byte()
+			globals++
+		case 0x04: // tag
+			r.byte()
+			r.u32()
+		default:
+			return 0, errMalformedModule
+		}
+	}
+	return globals, r.err
+}
+
+// appendGasExport adds the export of the gas counter to an export section.
+func appendGasExport(body []byte, global uint32) ([]byte, error) {
+	r := &reader{buf: body}
+	n := r.u32()
+	start := r.pos
+	for i := uint32(0); i < n && r.err == nil; i++ {
+		if string(r.bytes(int(r.u32()))) == gasExport {
+			return nil, fmt.Errorf("reserved export %q", gasExport)
+		}
+		r.byte()
+		r.u32()
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	out := append(binary.AppendUvarint(nil, uint64(n)+1), body[start:]...)
+	out = binary.AppendUvarint(out, uint64(len(gasExport)))
+	out = append(out, gasExport...)
+	out = append(out, 0x03)
+	return binary.AppendUvarint(out, uint64(global)), nil
+}
+
+// meterCode injects the gas metering into all function bodies of a code section.
+func meterCode(body []byte, global uint32) ([]byte, error) {
+	r := &reader{buf: body}
+	n := r.u32()
+	out := binary.AppendUvarint(nil, uint64(n))
+	for i := uint32(0); i < n; i++ {
+		fn := r.bytes(int(r.u32()))
+		if r.err != nil {
+			return nil, r.err
+		}
+		metered, err := meterFunc(fn, global)
+		if err != nil {
+			return nil, fmt.Errorf("function %d: %w", i, err)
+		}
+		out = binary.AppendUvarint(out, uint64(len(metered)))
+		out = append(out, metered...)
+	}
+	return out, r.err
+}
+
+// meterFunc injects the gas metering into a function body, charging at the
+// start of the function and of every loop iteration.
+func meterFunc(body []byte, global uint32) ([]byte, error) {
+	r := &reader{buf: body}
+	for n := r.u32(); n > 0 && r.err == nil; n-- {
+		r.u32()
+		r.byte()
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	// Split the code at the metering points, counting the instructions in
+	// between them.
+	var (
+		start  = r.pos
+		points = []int{r.pos}
+		costs  = []int64{0}
+	)
+	for !r.done() {
+		op, err := r.instr()
+		if err != nil {
+			return nil, err
+		}
+		costs[len(costs)-1]++
+		if op == 0x03 { // loop
+			points = append(points, r.pos)
+			costs = append(costs, 0)
+		}
+	}
+	out := append([]byte{}, body[:start]...)
+	for i, pos := range points {
+		end := len(body)
+		if i+1 < len(points) {
+			end = points[i+1]
+		}
+		out = appendCharge(out, global, max(costs[i], 1))
+		out = append(out, body[pos:end]...)
+	}
+	return out, nil
+}
+
+// appendCharge appends the code deducting the given cost from the gas counter,
+// trapping if it's exhausted.
+func appendCharge(out []byte, global uint32, cost int64) []byte {
+	out = append(out, 0x23) // global.get
+	out = binary.AppendUvarint(out, uint64(global))
+	out = append(out, 0x42) // i64.const
+	out = appendSleb(out, cost)
+	out = append(out, 0x7d, 0x24) // i64.sub, global.set
+	out = binary.AppendUvarint(out, uint64(global))
+	out = append(out, 0x23) // global.get
+	out = binary.AppendUvarint(out, uint64(global))
+	return append(out, 0x42, 0x00, 0x53, 0x04, 0x40, 0x00, 0x0b) // i64.const 0, i64.lt_s, if, unreachable, end
+}
+
+func appendSleb(out []byte, v int64) []byte {
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+// reader decodes the primitives of the binary format, recording the first error.
+type reader struct {
+	buf []byte
+	pos int
+	err error
+}
+
+func (r *reader) done() bool {
+	return r.err != nil || r.pos >= len(r.buf)
+}
+
+func (r *reader) byte() byte {
+	if r.err != nil {
+		return 0
+	}
+	if r.pos >= len(r.buf) {
+		r.err = errMalformedModule
+		return 0
+	}
+	r.pos++
+	return r.buf[r.pos-1]
+}
+
+func (r *reader) bytes(n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || n > len(r.buf)-r.pos {
+		r.err = errMalformedModule
+		return nil
+	}
+	r.pos += n
+	return r.buf[r.pos-n : r.pos]
+}
+
+func (r *reader) uleb() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		r.err = errMalformedModule
+		return 0
+	}
+	r.pos += n
+	return v
+}
+
+func (r *reader) u32() uint32 {
+	v := r.uleb()
+	if v > 0xffffffff {
+		r.err = errMalformedModule
+	}
+	return uint32(v)
+}
+
+// sleb skips a signed LEB128 value.
+func (r *reader) sleb() {
+	for r.byte()&0x80 != 0 && r.err == nil {
+	}
+}
+
+func (r *reader) limits() {
+	if flags := r.byte(); flags&0x01 != 0 {
+		r.uleb()
+		r.uleb()
+	} else {
+		r.uleb()
+	}
+}
+
+func (r *reader) memarg() {
+	r.u32()
+	r.uleb()
+}
+
+func (r *reader) blocktype() {
+	if r.pos < len(r.buf) {
+		switch r.buf[r.pos] {
+		case 0x40, 0x7f, 0x7e, 0x7d, 0x7c, 0x7b, 0x70, 0x6f:
+			r.pos++
+			return
+		}
+	}
+	r.sleb()
+}
+
+// instr skips a single instruction, returning its opcode.
+func (r *reader) instr() (byte, error) {
+	op := r.byte()
+	switch {
+	case op == 0x02 || op == 0x03 || op == 0x04: // block, loop, if
+		r.blocktype()
+	case op == 0x0c || op == 0x0d || op == 0x10 || op == 0x12: // br, br_if, call, return_call
+		r.u32()
+	case op == 0x0e: // br_table
+		for n := r.u32(); n > 0 && r.err == nil; n-- {
+			r.u32()
+		}
+		r.u32()
+	case op == 0x11 || op == 0x13: // call_indirect, return_call_indirect
+		r.u32()
+		r.u32()
+	case op == 0x1c: // select t*
+		r.bytes(int(r.u32()))
+	case op >= 0x20 && op <= 0x26: // local, global and table access
+		r.u32()
+	case op >= 0x28 && op <= 0x3e: // loads and stores
+		r.memarg()
+	case op == 0x3f || op == 0x40: // memory.size, memory.grow
+		r.u32()
+	case op == 0x41 || op == 0x42: // i32.const, i64.const
+		r.sleb()
+	case op == 0x43: // f32.const
+		r.bytes(4)
+	case op == 0x44: // f64.const
+		r.bytes(8)
+	case op == 0xd0: // ref.null
+		r.byte()
+	case op == 0xd2: // ref.func
+		r.u32()
+	case op == 0xfc:
+		r.miscInstr()
+	case op == 0xfd:
+		r.vectorInstr()
+	case op <= 0x01, op == 0x05, op == 0x0b, op == 0x0f, op == 0x1a, op == 0x1b, // control, parametric
+		op >= 0x45 && op <= 0xc4, op == 0xd1: // numeric, ref.is_null
+	default:
+		return 0, fmt.Errorf("unsupported instruction 0x%02x", op)
+	}
+	return op, r.err
+}
+
+// miscInstr skips the immediates of an instruction with the 0xfc prefix.
+func (r *reader) miscInstr() {
+	switch sub := r.u32(); {
+	case sub <= 7: // saturating truncation
+	case sub == 8: // memory.init
+		r.u32()
+		r.u32()
+	case sub == 9 || sub == 13 || sub == 15 || sub == 16 || sub == 17: // data.drop, elem.drop, table.grow/size/fill
+		r.u32()
+	case sub == 10 || sub == 12 || sub == 14: // memory.copy, table.init, table.copy
+		r.u32()
+		r.u32()
+	case sub == 11: // memory.fill
+		r.u32()
+	default:
+		r.err = fmt.Errorf("unsupported instruction 0xfc %d", sub)
+	}
+}
+
+// vectorInstr skips the immediates of an instruction with the 0xfd prefix.
+func (r *reader) vectorInstr() {
+	switch sub := r.u32(); {
+	case sub <= 11 || sub == 92 || sub == 93: // loads and stores
+		r.memarg()
+	case sub == 12 || sub == 13: // v128.const, i8x16.shuffle
+		r.bytes(16)
+	case sub >= 21 && sub <= 34: // lane extraction and replacement
+		r.byte()
+	case sub >= 84 && sub <= 91: // lane loads and stores
+		r.memarg()
+		r.byte()
+	case sub <= 0xff:
+	default:
+		r.err = fmt.Errorf("unsupported instruction 0xfd %d", sub)
+	}
+}
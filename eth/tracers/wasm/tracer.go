@@ -0,0 +1,429 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/tracing"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// Costs of the interactions between the tracer and the host, charged on top of
+// the instructions executed by the guest.
+const (
+	hookCost  = 1  // Invocation of a guest hook
+	hostCost  = 1  // Invocation of a host function
+	wordCost  = 1  // Copying a 32 byte word between the host and the guest
+	stateCost = 20 // State access through a host function
+)
+
+var (
+	errOutOfGas = errors.New("tracer out of gas")
+	errNoState  = errors.New("tracer accessed state outside of a transaction")
+)
+
+// tracerKey is the context key of the tracer executing a guest call, used by
+// the host functions to find their caller.
+type tracerKey struct{}
+
+// tracer is a single instance of a WASM tracer module, tracing one transaction.
+type tracer struct {
+	module *module
+	inst   api.Module
+	mem    api.Memory
+	funcs  map[string]api.Function
+
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	env   *tracing.VMContext
+	scope tracing.OpContext // Scope of the currently executed opcode, if any
+
+	scratch     uint32 // Pointer to the argument buffer in the guest memory
+	scratchSize uint32 // Size of the argument buffer
+
+	gas     api.MutableGlobal // Gas counter of the metered guest, nil if unmetered
+	running bool              // Whether the guest is executing, holding the gas in its counter
+	used    uint64            // Gas consumed by the trace so far
+	err     error             // First error raised by the guest or the sandbox
+
+	interrupt atomic.Bool // Atomic flag to signal execution interruption
+	reason    error       // Textual reason for the interruption
+}
+
+// newTracer instantiates the module for a new trace.
+func (m *module) newTracer(ctx *tracers.Context, cfg json.RawMessage, chainConfig *params.ChainConfig) (*tracers.Tracer, error) {
+	t := &tracer{module: m, funcs: make(map[string]api.Function)}
+	t.ctx, t.cancel = context.WithCancel(context.WithValue(context.Background(), tracerKey{}, t))
+
+	// The initializer is invoked like any other guest function below, so that
+	// it's metered and interruptible. Only a start function embedded into the
+	// module runs during the instantiation, metered from the initial gas.
+	inst, err := m.runtime.InstantiateModule(t.ctx, m.compiled, wazero.NewModuleConfig().WithName("").WithStartFunctions())
+	if err != nil {
+		t.cancel()
+		return nil, fmt.Errorf("failed to instantiate WASM tracer %q: %w", m.name, err)
+	}
+	t.inst, t.mem = inst, inst.Memory()
+	for name := range m.exports {
+		t.funcs[name] = inst.ExportedFunction(name)
+	}
+	// Traces failing before the result is retrieved would leak the instance,
+	// release it once the tracer is unreachable.
+	runtime.AddCleanup(t, func(inst api.Module) { inst.Close(context.Background()) }, inst)
+
+	if m.config.GasLimit != 0 {
+		t.gas = inst.ExportedGlobal(gasExport).(api.MutableGlobal)
+		t.used += m.config.GasLimit - t.gas.Get()
+	}
+	if fn := inst.ExportedFunction("_initialize"); fn != nil {
+		if t.call(fn); t.err != nil {
+			t.close()
+			return nil, fmt.Errorf("failed to initialize WASM tracer %q: %w", m.name, t.err)
+		}
+	}
+
+	if fn := t.funcs["setup"]; fn != nil {
+		ptrs := t.stage(cfg)
+		res := t.call(fn, uint64(ptrs[0]), uint64(len(cfg)))
+		if t.err == nil && res != nil && res[0] != 0 {
+			t.err = fmt.Errorf("setup failed with code %d", res[0])
+		}
+		if t.err != nil {
+			t.close()
+			return nil, fmt.Errorf("failed to setup WASM tracer %q: %w", m.name, t.err)
+		}
+	}
+	// The transaction start is always tracked, as it provides the state
+	// accessed by the host functions.
+	hooks := &tracing.Hooks{OnTxStart: t.OnTxStart}
+	if t.funcs["on_tx_end"] != nil {
+		hooks.OnTxEnd = t.OnTxEnd
+	}
+	if t.funcs["on_enter"] != nil {
+		hooks.OnEnter = t.OnEnter
+	}
+	if t.funcs["on_exit"] != nil {
+		hooks.OnExit = t.OnExit
+	}
+	if t.funcs["on_opcode"] != nil {
+		hooks.OnOpcode = t.OnOpcode
+	}
+	if t.funcs["on_fault"] != nil {
+		hooks.OnFault = t.OnFault
+	}
+	if t.funcs["on_gas_change"] != nil {
+		hooks.OnGasChange = t.OnGasChange
+	}
+	if t.funcs["on_balance_change"] != nil {
+		hooks.OnBalanceChange = t.OnBalanceChange
+	}
+	if t.funcs["on_nonce_change"] != nil {
+		hooks.OnNonceChange = t.OnNonceChange
+	}
+	if t.funcs["on_code_change"] != nil {
+		hooks.OnCodeChange = t.OnCodeChange
+	}
+	if t.funcs["on_storage_change"] != nil {
+		hooks.OnStorageChange = t.OnStorageChange
+	}
+	if t.funcs["on_log"] != nil {
+		hooks.OnLog = t.OnLog
+	}
+	return &tracers.Tracer{
+		Hooks:     hooks,
+		GetResult: t.GetResult,
+		Stop:      t.Stop,
+	}, nil
+}
+
+// close releases the module instance.
+func (t *tracer) close() {
+	t.inst.Close(context.Background())
+	t.cancel()
+}
+
+// charge consumes the given amount of gas, failing once the gas limit is
+// exceeded. It returns false if the tracer ran out of gas. While the guest is
+// running, the gas is held by its counter, so it's deducted from there.
+func (t *tracer) charge(cost uint64) bool {
+	if t.running {
+		left := t.gas.Get()
+		if left >= cost {
+			t.gas.Set(left - cost)
+			return true
+		}
+		t.gas.Set(0)
+	} else {
+		t.used += cost
+		if limit := t.module.config.GasLimit; limit == 0 || t.used <= limit {
+			return true
+		}
+	}
+	if t.err == nil {
+		t.err = errOutOfGas
+	}
+	return false
+}
+
+// fail records the error and aborts the guest execution. It must only be used
+// from within host functions.
+func (t *tracer) fail(err error) {
+	if t.err == nil {
+		t.err = err
+	}
+	panic(err)
+}
+
+// active reports whether the guest should still be invoked.
+func (t *tracer) active() bool {
+	return t.err == nil && !t.interrupt.Load()
+}
+
+// call invokes a guest function, recording any failure.
+func (t *tracer) call(fn api.Function, params ...uint64) []uint64 {
+	if !t.active() || !t.charge(hookCost) {
+		return nil
+	}
+	if t.gas != nil {
+		t.gas.Set(t.module.config.GasLimit - t.used)
+		t.running = true
+	}
+	res, err := fn.Call(t.ctx, params...)
+	if t.gas != nil {
+		// The metering code traps once the counter turns negative, leaving it
+		// below zero.
+		left := int64(t.gas.Get())
+		if left < 0 {
+			err, left = errOutOfGas, 0
+		}
+		t.used, t.running = t.module.config.GasLimit-uint64(left), false
+	}
+	if err != nil && t.err == nil {
+		t.err = err
+	}
+	return res
+}
+
+// stage copies the given values into the scratch buffer of the guest and
+// returns their pointers. Nil values are passed as null pointers.
+func (t *tracer) stage(values ...[]byte) []uint32 {
+	ptrs := make([]uint32, len(values))
+	if !t.active() {
+		return ptrs
+	}
+	var size uint32
+	for _, v := range values {
+		size += uint32(len(v))
+	}
+	if !t.charge(wordCost * ((uint64(size) + 31) / 32)) {
+		return ptrs
+	}
+	if size > t.scratchSize || t.scratch == 0 {
+		res := t.call(t.funcs["alloc"], uint64(max(size, 1)))
+		if res == nil {
+			return ptrs
+		}
+		t.scratch, t.scratchSize = uint32(res[0]), max(size, 1)
+	}
+	offset := t.scratch
+	for i, v := range values {
+		if v == nil {
+			continue
+		}
+		if !t.mem.Write(offset, v) {
+			t.err = fmt.Errorf("tracer allocated out of bound memory: offset %d, size %d", offset, len(v))
+			return ptrs
+		}
+		ptrs[i] = offset
+		offset += uint32(len(v))
+	}
+	return ptrs
+}
+
+// word encodes a big integer as a 32 byte big endian word.
+func word(v *big.Int) []byte {
+	var w uint256.Int
+	if v != nil {
+		w.SetFromBig(v)
+	}
+	b := w.Bytes32()
+	return b[:]
+}
+
+// errBytes returns the message of an error, or nil if there's none.
+func errBytes(err error) []byte {
+	if err == nil {
+		return nil
+	}
+	return []byte(err.Error())
+}
+
+func boolParam(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (t *tracer) OnTxStart(env *tracing.VMContext, tx *types.Transaction, from common.Address) {
+	t.env = env
+
+	fn := t.funcs["on_tx_start"]
+	if fn == nil {
+		return
+	}
+	var to []byte
+	if tx.To() != nil {
+		to = tx.To().Bytes()
+	}
+	input := tx.Data()
+	if input == nil {
+		input = []byte{}
+	}
+	ptrs := t.stage(from.Bytes(), to, word(tx.Value()), input)
+	t.call(fn, uint64(ptrs[0]), uint64(ptrs[1]), uint64(ptrs[2]), tx.Gas(), uint64(ptrs[3]), uint64(len(input)))
+}
+
+func (t *tracer) OnTxEnd(receipt *types.Receipt, err error) {
+	var (
+		gasUsed uint64
+		status  uint64
+		msg     = errBytes(err)
+	)
+	if receipt != nil {
+		gasUsed, status = receipt.GasUsed, receipt.Status
+	}
+	ptrs := t.stage(msg)
+	t.call(t.funcs["on_tx_end"], gasUsed, status, uint64(ptrs[0]), uint64(len(msg)))
+}
+
+func (t *tracer) OnEnter(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+	if input == nil {
+		input = []byte{}
+	}
+	ptrs := t.stage(from.Bytes(), to.Bytes(), input, word(value))
+	t.call(t.funcs["on_enter"], uint64(depth), uint64(typ), uint64(ptrs[0]), uint64(ptrs[1]), uint64(ptrs[2]), uint64(len(input)), gas, uint64(ptrs[3]))
+}
+
+func (t *tracer) OnExit(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+	if output == nil {
+		output = []byte{}
+	}
+	msg := errBytes(err)
+	ptrs := t.stage(output, msg)
+	t.call(t.funcs["on_exit"], uint64(depth), uint64(ptrs[0]), uint64(len(output)), gasUsed, uint64(ptrs[1]), uint64(len(msg)), boolParam(reverted))
+}
+
+func (t *tracer) OnOpcode(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, rData []byte, depth int, err error) {
+	t.scope = scope
+	t.call(t.funcs["on_opcode"], pc, uint64(op), gas, cost, uint64(depth), boolParam(err != nil))
+	t.scope = nil
+}
+
+func (t *tracer) OnFault(pc uint64, op byte, gas, cost uint64, scope tracing.OpContext, depth int, err error) {
+	msg := errBytes(err)
+	ptrs := t.stage(msg)
+
+	t.scope = scope
+	t.call(t.funcs["on_fault"], pc, uint64(op), gas, cost, uint64(depth), uint64(ptrs[0]), uint64(len(msg)))
+	t.scope = nil
+}
+
+func (t *tracer) OnGasChange(old, new uint64, reason tracing.GasChangeReason) {
+	t.call(t.funcs["on_gas_change"], old, new, uint64(reason))
+}
+
+func (t *tracer) OnBalanceChange(addr common.Address, prev, new *big.Int, reason tracing.BalanceChangeReason) {
+	ptrs := t.stage(addr.Bytes(), word(prev), word(new))
+	t.call(t.funcs["on_balance_change"], uint64(ptrs[0]), uint64(ptrs[1]), uint64(ptrs[2]), uint64(reason))
+}
+
+func (t *tracer) OnNonceChange(addr common.Address, prev, new uint64) {
+	ptrs := t.stage(addr.Bytes())
+	t.call(t.funcs["on_nonce_change"], uint64(ptrs[0]), prev, new)
+}
+
+func (t *tracer) OnCodeChange(addr common.Address, prevCodeHash common.Hash, prevCode []byte, codeHash common.Hash, code []byte) {
+	if code == nil {
+		code = []byte{}
+	}
+	ptrs := t.stage(addr.Bytes(), prevCodeHash.Bytes(), codeHash.Bytes(), code)
+	t.call(t.funcs["on_code_change"], uint64(ptrs[0]), uint64(ptrs[1]), uint64(ptrs[2]), uint64(ptrs[3]), uint64(len(code)))
+}
+
+func (t *tracer) OnStorageChange(addr common.Address, slot common.Hash, prev, new common.Hash) {
+	ptrs := t.stage(addr.Bytes(), slot.Bytes(), prev.Bytes(), new.Bytes())
+	t.call(t.funcs["on_storage_change"], uint64(ptrs[0]), uint64(ptrs[1]), uint64(ptrs[2]), uint64(ptrs[3]))
+}
+
+func (t *tracer) OnLog(log *types.Log) {
+	topics := make([]byte, 0, len(log.Topics)*common.HashLength)
+	for _, topic := range log.Topics {
+		topics = append(topics, topic.Bytes()...)
+	}
+	data := log.Data
+	if data == nil {
+		data = []byte{}
+	}
+	ptrs := t.stage(log.Address.Bytes(), topics, data)
+	t.call(t.funcs["on_log"], uint64(ptrs[0]), uint64(ptrs[1]), uint64(len(log.Topics)), uint64(ptrs[2]), uint64(len(data)))
+}
+
+// GetResult calls the result function of the guest and returns its JSON output,
+// releasing the module instance afterwards.
+func (t *tracer) GetResult() (json.RawMessage, error) {
+	defer t.close()
+
+	if t.interrupt.Load() {
+		return nil, t.reason
+	}
+	res := t.call(t.funcs["result"])
+	if t.err != nil {
+		return nil, t.err
+	}
+	ptr, size := uint32(res[0]>>32), uint32(res[0])
+	blob, ok := t.mem.Read(ptr, size)
+	if !ok {
+		return nil, fmt.Errorf("tracer result out of bound memory: offset %d, size %d", ptr, size)
+	}
+	if !json.Valid(blob) {
+		return nil, errors.New("tracer result is not valid JSON")
+	}
+	return common.CopyBytes(blob), nil
+}
+
+// Stop terminates execution of the tracer at the first opportune moment,
+// interrupting any running guest code.
+func (t *tracer) Stop(err error) {
+	t.reason = err
+	t.interrupt.Store(true)
+	t.cancel()
+}
@@ -0,0 +1,518 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package wasm
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/holiman/uint256"
+)
+
+// The tests below use hand assembled modules, as no WebAssembly toolchain is
+// available in the test environment. The helpers implement the small subset of
+// the binary format needed by them.
+
+const (
+	tI32 = 0x7f
+	tI64 = 0x7e
+)
+
+type wasmImport struct {
+	name            string
+	params, results []byte
+}
+
+type wasmFunc struct {
+	name            string
+	params, results []byte
+	locals          []byte
+	body            []byte
+}
+
+func uleb(v uint64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if v != 0 {
+			out = append(out, b|0x80)
+			continue
+		}
+		return append(out, b)
+	}
+}
+
+func sleb(v int64) []byte {
+	var out []byte
+	for {
+		b := byte(v & 0x7f)
+		v >>= 7
+		if (v == 0 && b&0x40 == 0) || (v == -1 && b&0x40 != 0) {
+			return append(out, b)
+		}
+		out = append(out, b|0x80)
+	}
+}
+
+func vec(items ...[]byte) []byte {
+	out := uleb(uint64(len(items)))
+	for _, item := range items {
+		out = append(out, item...)
+	}
+	return out
+}
+
+func name(s string) []byte {
+	return append(uleb(uint64(len(s))), s...)
+}
+
+func section(id byte, content []byte) []byte {
+	return append(append([]byte{id}, uleb(uint64(len(content)))...), content...)
+}
+
+func cat(parts ...[]byte) []byte {
+	var out []byte
+	for _, p := range parts {
+		out = append(out, p...)
+	}
+	return out
+}
+
+func i32c(v int32) []byte { return append([]byte{0x41}, sleb(int64(v))...) }
+func i64c(v int64) []byte { return append([]byte{0x42}, sleb(v)...) }
+
+// buildModule assembles a module with the given host imports and exported
+// functions, an exported memory of the given number of pages, a mutable i64
+// global and the given data segments.
+func buildModule(imports []wasmImport, funcs []wasmFunc, pages uint32, data map[int32]string) []byte {
+	var types, imps, fns, exports, codes, segments [][]byte
+	for i, imp := range imports {
+		types = append(types, cat([]byte{0x60}, vec(bytesOf(imp.params)...), vec(bytesOf(imp.results)...)))
+		imps = append(imps, cat(name(hostModule), name(imp.name), []byte{0x00}, uleb(uint64(i))))
+	}
+	for i, fn := range funcs {
+		typ := len(imports) + i
+		types = append(types, cat([]byte{0x60}, vec(bytesOf(fn.params)...), vec(bytesOf(fn.results)...)))
+		fns = append(fns, uleb(uint64(typ)))
+		exports = append(exports, cat(name(fn.name), []byte{0x00}, uleb(uint64(typ))))
+
+		var locals [][]byte
+		for _, l := range fn.locals {
+			locals = append(locals, []byte{0x01, l})
+		}
+		body := cat(vec(locals...), fn.body, []byte{0x0b})
+		codes = append(codes, cat(uleb(uint64(len(body))), body))
+	}
+	exports = append(exports, cat(name("memory"), []byte{0x02, 0x00}))
+	for offset, content := range data {
+		segments = append(segments, cat([]byte{0x00}, i32c(offset), []byte{0x0b}, name(content)))
+	}
+	return cat(
+		[]byte{0x00, 0x61, 0x73, 0x6d, 0x01, 0x00, 0x00, 0x00},
+		section(1, vec(types...)),
+		section(2, vec(imps...)),
+		section(3, vec(fns...)),
+		section(5, vec(cat([]byte{0x00}, uleb(uint64(pages))))),
+		section(6, vec(cat([]byte{tI64, 0x01}, i64c(0), []byte{0x0b}))),
+		section(7, vec(exports...)),
+		section(10, vec(codes...)),
+		section(11, vec(segments...)),
+	)
+}
+
+func bytesOf(types []byte) [][]byte {
+	out := make([][]byte, len(types))
+	for i, t := range types {
+		out[i] = []byte{t}
+	}
+	return out
+}
+
+var (
+	// allocFunc returns a fixed scratch buffer.
+	allocFunc = wasmFunc{name: "alloc", params: []byte{tI32}, results: []byte{tI32}, body: i32c(4096)}
+
+	// resultFunc returns the global counter as {"count":N}, formatting the
+	// digits backwards from offset 2000 and prepending the prefix stored at
+	// offset 1000.
+	resultFunc = wasmFunc{
+		name:    "result",
+		results: []byte{tI64},
+		locals:  []byte{tI32, tI64},
+		body: cat(
+			[]byte{0x23, 0x00, 0x21, 0x01}, // n = counter
+			i32c(2000), []byte{0x21, 0x00}, // p = 2000
+			[]byte{0x03, 0x40},                                    // loop
+			[]byte{0x20, 0x00}, i32c(1), []byte{0x6b, 0x22, 0x00}, // p = p - 1
+			[]byte{0x20, 0x01}, i64c(10), []byte{0x82, 0xa7}, i32c('0'), []byte{0x6a}, // '0' + n % 10
+			[]byte{0x3a, 0x00, 0x00},                               // store8
+			[]byte{0x20, 0x01}, i64c(10), []byte{0x80, 0x22, 0x01}, // n = n / 10
+			i64c(0), []byte{0x52, 0x0d, 0x00}, // br_if n != 0
+			[]byte{0x0b},                                          // end
+			[]byte{0x20, 0x00}, i32c(9), []byte{0x6b, 0x22, 0x00}, // p = p - 9
+			i32c(1000), i32c(9), []byte{0xfc, 0x0a, 0x00, 0x00}, // memory.copy prefix
+			i32c(2000), i32c('}'), []byte{0x3a, 0x00, 0x00}, // store8 '}'
+			[]byte{0x20, 0x00, 0xad}, i64c(32), []byte{0x86}, // p << 32
+			i32c(2001), []byte{0x20, 0x00, 0x6b, 0xad, 0x84}, // | (2001 - p)
+		),
+	}
+	resultData = map[int32]string{1000: `{"count":`}
+
+	opcodeParams = []byte{tI64, tI32, tI64, tI64, tI32, tI32}
+)
+
+// counterModule builds a tracer incrementing the counter by the value left on
+// the stack by inc on every opcode.
+func counterModule(inc []byte, extra ...wasmFunc) []byte {
+	onOpcode := wasmFunc{
+		name:   "on_opcode",
+		params: opcodeParams,
+		body:   cat([]byte{0x23, 0x00}, inc, []byte{0x7c, 0x24, 0x00}),
+	}
+	imports := []wasmImport{{name: "stack_len", results: []byte{tI32}}}
+	return buildModule(imports, append([]wasmFunc{allocFunc, onOpcode, resultFunc}, extra...), 1, resultData)
+}
+
+var (
+	countModule = counterModule(i64c(1))
+	stackModule = counterModule([]byte{0x10, 0x00, 0xad}) // stack_len()
+)
+
+func newTestTracer(t *testing.T, code []byte, config Config, cfg string) (*tracers.Tracer, error) {
+	t.Helper()
+	loader, err := NewLoader(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := loader.compile("test", code)
+	if err != nil {
+		t.Fatalf("failed to compile module: %v", err)
+	}
+	return m.newTracer(nil, json.RawMessage(cfg), params.TestChainConfig)
+}
+
+func runTrace(tracer *tracers.Tracer, code []byte) (json.RawMessage, error) {
+	var (
+		statedb, _ = state.New(types.EmptyRootHash, state.NewDatabaseForTesting())
+		blockCtx   = vm.BlockContext{BlockNumber: big.NewInt(1), BaseFee: big.NewInt(0)}
+		evm        = vm.NewEVM(blockCtx, statedb, params.TestChainConfig, vm.Config{Tracer: tracer.Hooks})
+		startGas   = uint64(10000)
+		value      = uint256.NewInt(0)
+		contract   = vm.NewContract(common.Address{}, common.Address{}, value, startGas, nil)
+	)
+	contract.Code = code
+	evm.SetTxContext(vm.TxContext{GasPrice: big.NewInt(1)})
+
+	tracer.OnTxStart(evm.GetVMContext(), types.NewTx(&types.LegacyTx{Gas: 31000, GasPrice: big.NewInt(1)}), contract.Caller())
+	if tracer.OnEnter != nil {
+		tracer.OnEnter(0, byte(vm.CALL), contract.Caller(), contract.Address(), []byte{}, startGas, value.ToBig())
+	}
+	ret, err := evm.Run(contract, []byte{}, false)
+	if tracer.OnExit != nil {
+		tracer.OnExit(0, ret, startGas-contract.Gas, err, true)
+	}
+	if tracer.OnTxEnd != nil {
+		tracer.OnTxEnd(&types.Receipt{GasUsed: 31000 - contract.Gas}, nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tracer.GetResult()
+}
+
+// pushCode pushes three values and stops.
+var pushCode = []byte{byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0x1, byte(vm.PUSH1), 0x1, byte(vm.STOP)}
+
+func TestTracer(t *testing.T) {
+	tests := []struct {
+		code []byte
+		want string
+	}{
+		{countModule, `{"count":4}`},
+		{stackModule, `{"count":6}`}, // 0 + 1 + 2 + 3
+	}
+	for i, tt := range tests {
+		tracer, err := newTestTracer(t, tt.code, DefaultConfig, "{}")
+		if err != nil {
+			t.Fatalf("test %d: failed to create tracer: %v", i, err)
+		}
+		res, err := runTrace(tracer, pushCode)
+		if err != nil {
+			t.Fatalf("test %d: trace failed: %v", i, err)
+		}
+		if string(res) != tt.want {
+			t.Errorf("test %d: result mismatch: have %s, want %s", i, res, tt.want)
+		}
+	}
+}
+
+func TestTracerHooks(t *testing.T) {
+	// Only the exported hooks should be installed, apart from the transaction
+	// start which provides the state to the host functions.
+	tracer, err := newTestTracer(t, countModule, DefaultConfig, "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tracer.OnOpcode == nil || tracer.OnTxStart == nil {
+		t.Fatal("exported hooks not installed")
+	}
+	if tracer.OnEnter != nil || tracer.OnExit != nil || tracer.OnFault != nil || tracer.OnLog != nil {
+		t.Fatal("unexported hooks installed")
+	}
+}
+
+func TestTracerSetup(t *testing.T) {
+	// setup returns the length of the config, failing for anything but "{}"
+	setup := wasmFunc{
+		name:    "setup",
+		params:  []byte{tI32, tI32},
+		results: []byte{tI32},
+		body:    cat([]byte{0x20, 0x01}, i32c(2), []byte{0x6b}),
+	}
+	code := counterModule(i64c(1), setup)
+	if _, err := newTestTracer(t, code, DefaultConfig, "{}"); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if _, err := newTestTracer(t, code, DefaultConfig, `{"a":1}`); err == nil {
+		t.Fatal("expected setup failure")
+	}
+}
+
+func TestTracerGasLimit(t *testing.T) {
+	tracer, err := newTestTracer(t, countModule, Config{GasLimit: 3, MemoryLimit: 1}, "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runTrace(tracer, pushCode); !errors.Is(err, errOutOfGas) {
+		t.Fatalf("unexpected error: have %v, want %v", err, errOutOfGas)
+	}
+}
+
+func TestTracerGasMetering(t *testing.T) {
+	var (
+		// infiniteLoop never terminates unless interrupted
+		infiniteLoop = []byte{0x03, 0x40, 0x0c, 0x00, 0x0b}
+
+		// boundedLoop increments the first local 10000 times
+		boundedLoop = cat(
+			[]byte{0x03, 0x40, 0x20, 0x06}, i32c(1), []byte{0x6a, 0x22, 0x06}, // loop, local = local + 1
+			i32c(10000), []byte{0x49, 0x0d, 0x00, 0x0b}, // br_if local < 10000, end
+		)
+	)
+	onOpcode := func(body []byte) wasmFunc {
+		return wasmFunc{name: "on_opcode", params: opcodeParams, locals: []byte{tI32}, body: body}
+	}
+	config := Config{GasLimit: 1_000_000, MemoryLimit: 1}
+
+	// Guest instructions must be charged, even without any host interaction
+	code := buildModule(nil, []wasmFunc{onOpcode(boundedLoop), resultFunc}, 1, resultData)
+	tracer, err := newTestTracer(t, code, config, "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runTrace(tracer, pushCode); err != nil {
+		t.Fatalf("trace failed: %v", err)
+	}
+	tracer, err = newTestTracer(t, code, Config{GasLimit: 100_000, MemoryLimit: 1}, "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runTrace(tracer, pushCode); !errors.Is(err, errOutOfGas) {
+		t.Fatalf("unexpected error: have %v, want %v", err, errOutOfGas)
+	}
+	// Non-terminating hooks must run out of gas
+	code = buildModule(nil, []wasmFunc{onOpcode(infiniteLoop), resultFunc}, 1, resultData)
+	tracer, err = newTestTracer(t, code, config, "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runTrace(tracer, pushCode); !errors.Is(err, errOutOfGas) {
+		t.Fatalf("unexpected error: have %v, want %v", err, errOutOfGas)
+	}
+	// Non-terminating initializers must run out of gas too
+	initialize := wasmFunc{name: "_initialize", body: infiniteLoop}
+	code = buildModule(nil, []wasmFunc{initialize, onOpcode(nil), resultFunc}, 1, resultData)
+	if _, err := newTestTracer(t, code, config, "{}"); !errors.Is(err, errOutOfGas) {
+		t.Fatalf("unexpected error: have %v, want %v", err, errOutOfGas)
+	}
+}
+
+func TestInstrument(t *testing.T) {
+	// onGasChange exercises the instructions with uncommon immediates
+	onGasChange := wasmFunc{
+		name:   "on_gas_change",
+		params: []byte{tI64, tI64, tI32},
+		body: cat(
+			[]byte{0x02, 0x40, 0x20, 0x02, 0x0e, 0x01, 0x00, 0x00, 0x0b}, // block, br_table, end
+			[]byte{0x20, 0x02, 0x04, 0x40, 0x01, 0x05, 0x01, 0x0b},       // if, nop, else, nop, end
+			[]byte{0x44, 0, 0, 0, 0, 0, 0, 0xf0, 0x3f, 0x1a},             // f64.const 1, drop
+			[]byte{0xfd, 0x0c}, make([]byte, 16), []byte{0x1a}, // v128.const 0, drop
+			i32c(0), i32c(0), i32c(0), []byte{0xfc, 0x0b, 0x00}, // memory.fill
+			[]byte{0x23, 0x00}, i64c(1), []byte{0x7c, 0x24, 0x00}, // counter++
+		),
+	}
+	code := buildModule(nil, []wasmFunc{onGasChange, resultFunc}, 1, resultData)
+	tracer, err := newTestTracer(t, code, DefaultConfig, "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tracer.OnGasChange(0, 1, 0)
+	res, err := tracer.GetResult()
+	if err != nil {
+		t.Fatalf("trace failed: %v", err)
+	}
+	if string(res) != `{"count":1}` {
+		t.Fatalf("result mismatch: have %s", res)
+	}
+	// The name of the injected export is reserved
+	code = buildModule(nil, []wasmFunc{{name: gasExport}, resultFunc}, 1, resultData)
+	loader, err := NewLoader(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loader.compile("test", code); err == nil || !strings.Contains(err.Error(), "reserved export") {
+		t.Fatalf("expected reserved export error, have %v", err)
+	}
+}
+
+func TestTracerMemoryLimit(t *testing.T) {
+	onOpcode := wasmFunc{name: "on_opcode", params: opcodeParams}
+	code := buildModule(nil, []wasmFunc{onOpcode, resultFunc}, 17, resultData)
+
+	loader, err := NewLoader(Config{MemoryLimit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loader.compile("test", code); err == nil {
+		t.Fatal("expected memory limit to be enforced")
+	}
+}
+
+func TestTracerAbort(t *testing.T) {
+	onOpcode := wasmFunc{
+		name:   "on_opcode",
+		params: opcodeParams,
+		body:   cat(i32c(3000), i32c(4), []byte{0x10, 0x00}), // abort("boom")
+	}
+	imports := []wasmImport{{name: "abort", params: []byte{tI32, tI32}}}
+	code := buildModule(imports, []wasmFunc{onOpcode, resultFunc}, 1, map[int32]string{1000: `{"count":`, 3000: "boom"})
+
+	tracer, err := newTestTracer(t, code, DefaultConfig, "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := runTrace(tracer, pushCode); err == nil || err.Error() != "tracer aborted: boom" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestTracerStop(t *testing.T) {
+	onOpcode := wasmFunc{
+		name:   "on_opcode",
+		params: opcodeParams,
+		body:   []byte{0x03, 0x40, 0x0c, 0x00, 0x0b}, // loop br 0 end
+	}
+	code := buildModule(nil, []wasmFunc{onOpcode, resultFunc}, 1, resultData)
+	tracer, err := newTestTracer(t, code, DefaultConfig, "{}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop := errors.New("stop")
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		tracer.Stop(stop)
+	}()
+	if _, err := runTrace(tracer, pushCode); err != stop {
+		t.Fatalf("unexpected error: have %v, want %v", err, stop)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	onOpcode := wasmFunc{name: "on_opcode", params: opcodeParams}
+	onEnter := wasmFunc{name: "on_enter", params: []byte{tI32, tI32, tI32, tI32, tI32, tI32, tI64, tI32}}
+	tests := []struct {
+		code []byte
+		err  string
+	}{
+		{buildModule(nil, []wasmFunc{onOpcode}, 1, nil), "missing result export"},
+		{buildModule(nil, []wasmFunc{onOpcode, {name: "result", results: []byte{tI32}, body: i32c(0)}}, 1, nil), `invalid signature of export "result"`},
+		{buildModule(nil, []wasmFunc{onEnter, resultFunc}, 1, resultData), "missing alloc export"},
+		{buildModule(nil, []wasmFunc{{name: "on_opcode", params: []byte{tI32}}, resultFunc}, 1, resultData), `invalid signature of export "on_opcode"`},
+		{buildModule(nil, []wasmFunc{onEnter, allocFunc, resultFunc}, 1, resultData), ""},
+	}
+	loader, err := NewLoader(DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range tests {
+		_, err := loader.compile("test", tt.code)
+		if tt.err == "" && err != nil {
+			t.Errorf("test %d: unexpected error: %v", i, err)
+		}
+		if tt.err != "" && (err == nil || err.Error() != tt.err) {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+func TestLoadDirectory(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "wasmCountTracer.wasm"), countModule, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("ignored"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	names, err := LoadDirectory(dir, DefaultConfig)
+	if err != nil {
+		t.Fatalf("failed to load tracers: %v", err)
+	}
+	if len(names) != 1 || names[0] != "wasmCountTracer" {
+		t.Fatalf("unexpected tracers loaded: %v", names)
+	}
+	if tracers.DefaultDirectory.IsJS("wasmCountTracer") {
+		t.Fatal("WASM tracer registered as JS")
+	}
+	tracer, err := tracers.DefaultDirectory.New("wasmCountTracer", new(tracers.Context), nil, params.TestChainConfig)
+	if err != nil {
+		t.Fatalf("failed to create tracer: %v", err)
+	}
+	res, err := runTrace(tracer, pushCode)
+	if err != nil {
+		t.Fatalf("trace failed: %v", err)
+	}
+	if string(res) != `{"count":4}` {
+		t.Fatalf("result mismatch: have %s", res)
+	}
+	// Loading again must not override the registered tracer
+	if _, err := LoadDirectory(dir, DefaultConfig); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Fatalf("expected duplicate tracer error, have %v", err)
+	}
+}
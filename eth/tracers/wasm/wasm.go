@@ -0,0 +1,310 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package wasm implements tracers compiled to WebAssembly, which are loaded at
+// runtime into the tracer directory.
+//
+// A tracer module must export its linear memory as "memory" and a function
+// `result() -> i64`, returning the location of the JSON encoded result packed as
+// (ptr << 32 | len). All other exports are optional:
+//
+//	alloc(size i32) -> i32
+//	setup(config i32, config_len i32) -> i32
+//	on_tx_start(from i32, to i32, value i32, gas i64, input i32, input_len i32)
+//	on_tx_end(gas_used i64, status i32, err i32, err_len i32)
+//	on_enter(depth i32, typ i32, from i32, to i32, input i32, input_len i32, gas i64, value i32)
+//	on_exit(depth i32, output i32, output_len i32, gas_used i64, err i32, err_len i32, reverted i32)
+//	on_opcode(pc i64, op i32, gas i64, cost i64, depth i32, failed i32)
+//	on_fault(pc i64, op i32, gas i64, cost i64, depth i32, err i32, err_len i32)
+//	on_gas_change(old i64, new i64, reason i32)
+//	on_balance_change(addr i32, prev i32, new i32, reason i32)
+//	on_nonce_change(addr i32, prev i64, new i64)
+//	on_code_change(addr i32, prev_hash i32, hash i32, code i32, code_len i32)
+//	on_storage_change(addr i32, slot i32, prev i32, new i32)
+//	on_log(addr i32, topics i32, topics_len i32, data i32, data_len i32)
+//
+// Only the hooks exported by the module are installed, so a tracer not exporting
+// on_opcode doesn't pay for the overhead of stepping through the opcodes.
+//
+// Arguments are passed as pointers into a scratch buffer in the guest memory,
+// which is requested from the guest via alloc whenever it is too small to hold
+// the arguments of a call. The buffer is reused by later calls, so the guest has
+// to copy out anything it wants to retain. Addresses are 20 bytes, hashes and
+// 256 bit integers are 32 bytes big endian, topics are laid out consecutively.
+// A pointer of 0 denotes a missing value, e.g. the recipient of a contract
+// creation. The setup function receives the tracer configuration as JSON and
+// signals a failure by returning a non-zero value.
+//
+// The host exposes the following functions to the guest in the "geth" module:
+//
+//	abort(msg i32, msg_len i32)
+//	log(msg i32, msg_len i32)
+//	block_number() -> i64
+//	get_balance(addr i32, out i32)
+//	get_nonce(addr i32) -> i64
+//	get_code_hash(addr i32, out i32)
+//	get_code_size(addr i32) -> i32
+//	get_code(addr i32, out i32, out_len i32) -> i32
+//	get_state(addr i32, slot i32, out i32)
+//	exists(addr i32) -> i32
+//	stack_len() -> i32
+//	stack_peek(n i32, out i32) -> i32
+//	memory_len() -> i32
+//	memory_read(offset i32, len i32, out i32) -> i32
+//	contract_address(out i32) -> i32
+//	contract_caller(out i32) -> i32
+//
+// The stack, memory and contract accessors are only available while executing
+// on_opcode or on_fault, returning -1 otherwise or if the access is out of range.
+// get_code returns the full size of the code, copying at most out_len bytes.
+//
+// Tracers are sandboxed: every module instance is limited in its linear memory
+// and runs on a gas limit shared by the whole trace, covering its instantiation.
+// The module is instrumented on load to charge the executed guest instructions,
+// while every hook invocation and host call is charged on top. Once the gas is
+// exhausted or the guest aborts, the trace fails. Without a gas limit, long
+// running guest code is only interrupted when the trace times out.
+package wasm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/eth/tracers"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+)
+
+// Config contains the resource limits of WASM tracers.
+type Config struct {
+	GasLimit    uint64 // Maximum gas a single trace may consume, 0 if unlimited
+	MemoryLimit uint32 // Maximum linear memory of a tracer instance in megabytes
+}
+
+// DefaultConfig contains the default limits of WASM tracers.
+var DefaultConfig = Config{
+	GasLimit:    1_000_000_000,
+	MemoryLimit: 64,
+}
+
+// pagesPerMegabyte is the number of WebAssembly memory pages in a megabyte.
+const pagesPerMegabyte = 1024 * 1024 / (64 * 1024)
+
+// maxMemoryLimit is the maximum addressable memory of a 32 bit module.
+const maxMemoryLimit = 4096
+
+const (
+	i32 = api.ValueTypeI32
+	i64 = api.ValueTypeI64
+)
+
+// hookSignatures maps the optional exports of a tracer module to their expected
+// parameter types. None of the hooks return a value.
+var hookSignatures = map[string][]api.ValueType{
+	"on_tx_start":       {i32, i32, i32, i64, i32, i32},
+	"on_tx_end":         {i64, i32, i32, i32},
+	"on_enter":          {i32, i32, i32, i32, i32, i32, i64, i32},
+	"on_exit":           {i32, i32, i32, i64, i32, i32, i32},
+	"on_opcode":         {i64, i32, i64, i64, i32, i32},
+	"on_fault":          {i64, i32, i64, i64, i32, i32, i32},
+	"on_gas_change":     {i64, i64, i32},
+	"on_balance_change": {i32, i32, i32, i32},
+	"on_nonce_change":   {i32, i64, i64},
+	"on_code_change":    {i32, i32, i32, i32, i32},
+	"on_storage_change": {i32, i32, i32, i32},
+	"on_log":            {i32, i32, i32, i32, i32},
+}
+
+// scalarHooks are the hooks which don't pass any arguments through the guest
+// memory, thus not requiring the alloc export.
+var scalarHooks = map[string]bool{
+	"on_opcode":     true,
+	"on_gas_change": true,
+}
+
+// module is a compiled tracer module, instantiated for every trace.
+type module struct {
+	name     string
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	config   Config
+	exports  map[string]bool
+}
+
+// Loader compiles WASM tracers with a shared runtime and resource limits.
+type Loader struct {
+	runtime wazero.Runtime
+	config  Config
+}
+
+// NewLoader creates a loader for WASM tracers with the given resource limits.
+func NewLoader(config Config) (*Loader, error) {
+	if config.MemoryLimit == 0 || config.MemoryLimit > maxMemoryLimit {
+		return nil, fmt.Errorf("invalid WASM tracer memory limit %dMB, must be within 1-%d", config.MemoryLimit, maxMemoryLimit)
+	}
+	if config.GasLimit > math.MaxInt64 {
+		return nil, fmt.Errorf("invalid WASM tracer gas limit %d, must be at most %d", config.GasLimit, int64(math.MaxInt64))
+	}
+	ctx := context.Background()
+	rtcfg := wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(config.MemoryLimit * pagesPerMegabyte).
+		WithCloseOnContextDone(true)
+	rt := wazero.NewRuntimeWithConfig(ctx, rtcfg)
+	if err := instantiateHost(ctx, rt); err != nil {
+		rt.Close(ctx)
+		return nil, err
+	}
+	return &Loader{runtime: rt, config: config}, nil
+}
+
+// compile compiles and validates a tracer module, instrumenting it for gas
+// metering if a gas limit is set.
+func (l *Loader) compile(name string, code []byte) (*module, error) {
+	if l.config.GasLimit != 0 {
+		var err error
+		if code, err = instrument(code, l.config.GasLimit); err != nil {
+			return nil, fmt.Errorf("failed to instrument module: %w", err)
+		}
+	}
+	compiled, err := l.runtime.CompileModule(context.Background(), code)
+	if err != nil {
+		return nil, err
+	}
+	m := &module{
+		name:     name,
+		runtime:  l.runtime,
+		compiled: compiled,
+		config:   l.config,
+		exports:  make(map[string]bool),
+	}
+	if err := m.validate(); err != nil {
+		compiled.Close(context.Background())
+		return nil, err
+	}
+	return m, nil
+}
+
+// validate checks that the module implements the tracer ABI.
+func (m *module) validate() error {
+	for _, fn := range m.compiled.ImportedFunctions() {
+		if mod, name, _ := fn.Import(); mod != hostModule {
+			return fmt.Errorf("unsupported import %s.%s", mod, name)
+		}
+	}
+	if _, ok := m.compiled.ExportedMemories()["memory"]; !ok {
+		return errors.New("missing memory export")
+	}
+	exports := m.compiled.ExportedFunctions()
+	check := func(name string, params, results []api.ValueType) error {
+		fn, ok := exports[name]
+		if !ok {
+			return nil
+		}
+		if !equalTypes(fn.ParamTypes(), params) || !equalTypes(fn.ResultTypes(), results) {
+			return fmt.Errorf("invalid signature of export %q", name)
+		}
+		m.exports[name] = true
+		return nil
+	}
+	if err := check("result", nil, []api.ValueType{i64}); err != nil {
+		return err
+	}
+	if !m.exports["result"] {
+		return errors.New("missing result export")
+	}
+	if err := check("alloc", []api.ValueType{i32}, []api.ValueType{i32}); err != nil {
+		return err
+	}
+	if err := check("setup", []api.ValueType{i32, i32}, []api.ValueType{i32}); err != nil {
+		return err
+	}
+	needAlloc := m.exports["setup"]
+	for name, params := range hookSignatures {
+		if err := check(name, params, nil); err != nil {
+			return err
+		}
+		if m.exports[name] && !scalarHooks[name] {
+			needAlloc = true
+		}
+	}
+	if needAlloc && !m.exports["alloc"] {
+		return errors.New("missing alloc export")
+	}
+	return nil
+}
+
+func equalTypes(a, b []api.ValueType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Load compiles the tracer module and registers it in the default tracer
+// directory under the given name.
+func (l *Loader) Load(name string, code []byte) error {
+	if tracers.DefaultDirectory.Has(name) {
+		return fmt.Errorf("tracer %q already exists", name)
+	}
+	m, err := l.compile(name, code)
+	if err != nil {
+		return fmt.Errorf("failed to load WASM tracer %q: %w", name, err)
+	}
+	tracers.DefaultDirectory.Register(name, m.newTracer, false)
+	return nil
+}
+
+// LoadDirectory loads all WASM tracers (*.wasm) from the given directory into
+// the default tracer directory. Each tracer is registered by its file name,
+// without the extension. The names of the loaded tracers are returned.
+func LoadDirectory(dir string, config Config) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*.wasm"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+
+	loader, err := NewLoader(config)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, file := range files {
+		code, err := os.ReadFile(file)
+		if err != nil {
+			return names, err
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".wasm")
+		if err := loader.Load(name, code); err != nil {
+			return names, err
+		}
+		log.Info("Loaded WASM tracer", "name", name, "file", file)
+		names = append(names, name)
+	}
+	return names, nil
+}
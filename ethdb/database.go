@@ -94,6 +94,15 @@ type Compacter interface {
 	Compact(start []byte, limit []byte) error
 }
 
+// Checkpointer wraps the Checkpoint method of a backing data store. It's an
+// optional feature, not supported by all the data stores.
+type Checkpointer interface {
+	// Checkpoint creates a consistent point-in-time copy of the data store in
+	// the given directory, which must not exist yet. The immutable files are
+	// hard-linked instead of copied where possible.
+	Checkpoint(dir string) error
+}
+
 // KeyValueStore contains all the methods required to allow handling different
 // key-value data stores backing the high level database.
 type KeyValueStore interface {
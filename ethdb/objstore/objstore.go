@@ -0,0 +1,82 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package objstore implements access to object stores holding immutable blobs,
+// such as the sealed segments of the freezer. Objects are written once in their
+// entirety and read back in ranges.
+package objstore
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+)
+
+// ErrNotFound is returned if the requested object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// Store is an object store holding immutable named blobs. Object names are
+// slash-separated paths relative to the root of the store.
+type Store interface {
+	// Put stores the size bytes read from r as the named object, replacing any
+	// existing one. The object becomes visible only once fully written.
+	Put(name string, r io.Reader, size int64) error
+
+	// ReadRange reads length bytes of the named object starting at offset.
+	ReadRange(name string, offset int64, length int64) ([]byte, error)
+
+	// Size returns the size of the named object.
+	Size(name string) (int64, error)
+
+	// Delete removes the named object. Deleting a missing object is not an
+	// error.
+	Delete(name string) error
+}
+
+// Open opens the object store at the given URL. The supported schemes are:
+//
+//   - file:///path/to/dir, storing the objects as files in a local directory,
+//     mostly useful for testing or for mounted network filesystems.
+//   - s3://bucket/prefix, storing the objects in an S3-compatible bucket. The
+//     optional 'region' and 'endpoint' query parameters select the region and
+//     the service endpoint, credentials are resolved from the environment.
+func Open(rawurl string) (Store, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "file":
+		if u.Path == "" {
+			return nil, errors.New("missing directory in object store URL")
+		}
+		return NewFileStore(filepath.FromSlash(u.Path))
+	case "s3":
+		if u.Host == "" {
+			return nil, errors.New("missing bucket in object store URL")
+		}
+		return NewS3Store(S3Config{
+			Bucket:   u.Host,
+			Prefix:   u.Path,
+			Region:   u.Query().Get("region"),
+			Endpoint: u.Query().Get("endpoint"),
+		})
+	default:
+		return nil, fmt.Errorf("unsupported object store scheme %q", u.Scheme)
+	}
+}
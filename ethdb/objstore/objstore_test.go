@@ -0,0 +1,175 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package objstore
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+func TestFileStore(t *testing.T) {
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+}
+
+func TestS3Store(t *testing.T) {
+	server := httptest.NewServer(newFakeS3("bucket"))
+	defer server.Close()
+
+	store, err := NewS3Store(S3Config{
+		Bucket:      "bucket",
+		Prefix:      "/history/",
+		Endpoint:    server.URL,
+		Credentials: credentials.NewStaticCredentialsProvider("key", "secret", ""),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testStore(t, store)
+}
+
+func TestOpen(t *testing.T) {
+	for _, url := range []string{"", "http://host/path", "file://", "s3:///prefix"} {
+		if _, err := Open(url); err == nil {
+			t.Errorf("expected error for %q", url)
+		}
+	}
+	store, err := Open("file://" + t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.(*FileStore); !ok {
+		t.Fatalf("unexpected store type %T", store)
+	}
+}
+
+func testStore(t *testing.T, store Store) {
+	if _, err := store.Size("chain/a.0000.cdat"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if _, err := store.ReadRange("chain/a.0000.cdat", 0, 1); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	blob := bytes.Repeat([]byte("0123456789"), 100)
+	if err := store.Put("chain/a.0000.cdat", bytes.NewReader(blob), int64(len(blob))); err != nil {
+		t.Fatalf("failed to put object: %v", err)
+	}
+	if size, err := store.Size("chain/a.0000.cdat"); err != nil || size != int64(len(blob)) {
+		t.Fatalf("unexpected size %d: %v", size, err)
+	}
+	for _, r := range [][2]int64{{0, 10}, {5, 1}, {995, 5}, {100, 900}} {
+		data, err := store.ReadRange("chain/a.0000.cdat", r[0], r[1])
+		if err != nil {
+			t.Fatalf("failed to read range %v: %v", r, err)
+		}
+		if !bytes.Equal(data, blob[r[0]:r[0]+r[1]]) {
+			t.Fatalf("unexpected content of range %v", r)
+		}
+	}
+	if _, err := store.ReadRange("chain/a.0000.cdat", 995, 10); err == nil {
+		t.Fatal("expected error reading beyond the end")
+	}
+	// Short content must not produce an object
+	if err := store.Put("chain/a.0001.cdat", bytes.NewReader(blob[:10]), 20); err == nil {
+		t.Fatal("expected error for short content")
+	}
+	if _, err := store.Size("chain/a.0001.cdat"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+	if err := store.Delete("chain/a.0000.cdat"); err != nil {
+		t.Fatalf("failed to delete: %v", err)
+	}
+	if err := store.Delete("chain/a.0000.cdat"); err != nil {
+		t.Fatalf("failed to delete missing object: %v", err)
+	}
+	if _, err := store.Size("chain/a.0000.cdat"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected not found, got %v", err)
+	}
+}
+
+// fakeS3 is a minimal in-memory implementation of the S3 object API, serving a
+// single bucket with path-style addressing.
+type fakeS3 struct {
+	bucket  string
+	lock    sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeS3(bucket string) *fakeS3 {
+	return &fakeS3{bucket: bucket, objects: make(map[string][]byte)}
+}
+
+func (s *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		http.Error(w, "unsigned request", http.StatusForbidden)
+		return
+	}
+	name, ok := strings.CutPrefix(r.URL.Path, "/"+s.bucket+"/")
+	if !ok {
+		http.Error(w, "no such bucket", http.StatusNotFound)
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		blob, err := io.ReadAll(r.Body)
+		if err != nil || int64(len(blob)) != r.ContentLength {
+			http.Error(w, "incomplete body", http.StatusBadRequest)
+			return
+		}
+		s.objects[name] = blob
+	case http.MethodHead:
+		blob, ok := s.objects[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", fmt.Sprint(len(blob)))
+	case http.MethodGet:
+		blob, ok := s.objects[name]
+		if !ok {
+			http.Error(w, "no such key", http.StatusNotFound)
+			return
+		}
+		var start, end int
+		if _, err := fmt.Sscanf(r.Header.Get("Range"), "bytes=%d-%d", &start, &end); err != nil || start > end || end >= len(blob) {
+			http.Error(w, "invalid range", http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(blob[start : end+1])
+	case http.MethodDelete:
+		delete(s.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+	}
+}
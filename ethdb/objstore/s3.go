@@ -0,0 +1,224 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package objstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/config"
+)
+
+const (
+	// s3DefaultRegion is the region used if none is configured.
+	s3DefaultRegion = "us-east-1"
+
+	// s3RequestTimeout is the timeout of the requests not transferring object
+	// content. Uploads are only bounded by the idle timeouts of the transport.
+	s3RequestTimeout = 30 * time.Second
+
+	// s3UnsignedPayload is the payload hash of requests not signing the content.
+	s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+	// s3EmptyPayload is the payload hash of requests without content.
+	s3EmptyPayload = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+)
+
+// S3Config contains the settings of an S3-compatible object store.
+type S3Config struct {
+	Bucket   string // Name of the bucket
+	Prefix   string // Prefix prepended to the object names
+	Region   string // Region of the bucket, us-east-1 if empty
+	Endpoint string // Service endpoint for S3-compatible stores, AWS if empty
+
+	// Credentials used for signing the requests, resolved from the environment
+	// and the shared configuration files if nil.
+	Credentials aws.CredentialsProvider
+}
+
+// S3Store is an object store backed by an S3-compatible bucket. It talks to the
+// service over its REST interface, using path-style addressing for custom
+// endpoints and virtual-hosted-style addressing for AWS.
+type S3Store struct {
+	base   *url.URL // URL of the bucket, the objects are resolved relative to it
+	prefix string
+	region string
+	creds  aws.CredentialsProvider
+	signer *v4.Signer
+	client *http.Client
+}
+
+// NewS3Store creates an object store backed by the configured bucket.
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("missing bucket")
+	}
+	region := cfg.Region
+	if region == "" {
+		region = s3DefaultRegion
+	}
+	var base *url.URL
+	if cfg.Endpoint == "" {
+		base = &url.URL{Scheme: "https", Host: fmt.Sprintf("%s.s3.%s.amazonaws.com", cfg.Bucket, region), Path: "/"}
+	} else {
+		endpoint, err := url.Parse(cfg.Endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid endpoint: %v", err)
+		}
+		if endpoint.Scheme == "" || endpoint.Host == "" {
+			return nil, fmt.Errorf("invalid endpoint %q", cfg.Endpoint)
+		}
+		base = endpoint.JoinPath(cfg.Bucket)
+		base.Path += "/"
+	}
+	creds := cfg.Credentials
+	if creds == nil {
+		awscfg, err := config.LoadDefaultConfig(context.Background(), config.WithRegion(region))
+		if err != nil {
+			return nil, err
+		}
+		creds = awscfg.Credentials
+	}
+	return &S3Store{
+		base:   base,
+		prefix: strings.Trim(cfg.Prefix, "/"),
+		region: region,
+		creds:  creds,
+		signer: v4.NewSigner(),
+		client: &http.Client{Transport: http.DefaultTransport},
+	}, nil
+}
+
+// objectURL returns the URL of the named object.
+func (s *S3Store) objectURL(name string) string {
+	if s.prefix != "" {
+		name = s.prefix + "/" + name
+	}
+	return s.base.JoinPath(name).String()
+}
+
+// do signs and sends the request, returning the response if its status is one
+// of the expected ones.
+func (s *S3Store) do(ctx context.Context, method string, name string, body io.Reader, size int64, header http.Header, expect ...int) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, s.objectURL(name), body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	payload := s3EmptyPayload
+	if body != nil {
+		req.ContentLength = size
+		payload = s3UnsignedPayload
+	}
+	req.Header.Set("X-Amz-Content-Sha256", payload)
+
+	creds, err := s.creds.Retrieve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.signer.SignHTTP(ctx, creds, req, payload, "s3", s.region, time.Now()); err != nil {
+		return nil, err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	for _, status := range expect {
+		if res.StatusCode == status {
+			return res, nil
+		}
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	return nil, fmt.Errorf("%s %s: %s: %s", method, name, res.Status, strings.TrimSpace(string(msg)))
+}
+
+// Put implements Store.
+func (s *S3Store) Put(name string, r io.Reader, size int64) error {
+	res, err := s.do(context.Background(), http.MethodPut, name, io.NopCloser(io.LimitReader(r, size)), size, nil, http.StatusOK)
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
+
+// ReadRange implements Store.
+func (s *S3Store) ReadRange(name string, offset int64, length int64) ([]byte, error) {
+	if length == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+
+	header := http.Header{"Range": {fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)}}
+	res, err := s.do(ctx, http.MethodGet, name, nil, 0, header, http.StatusPartialContent)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(res.Body, buf); err != nil {
+		return nil, fmt.Errorf("failed to read %s at %d: %v", name, offset, err)
+	}
+	return buf, nil
+}
+
+// Size implements Store.
+func (s *S3Store) Size(name string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+
+	res, err := s.do(ctx, http.MethodHead, name, nil, 0, nil, http.StatusOK)
+	if err != nil {
+		return 0, err
+	}
+	res.Body.Close()
+	if res.ContentLength >= 0 {
+		return res.ContentLength, nil
+	}
+	return strconv.ParseInt(res.Header.Get("Content-Length"), 10, 64)
+}
+
+// Delete implements Store.
+func (s *S3Store) Delete(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s3RequestTimeout)
+	defer cancel()
+
+	res, err := s.do(ctx, http.MethodDelete, name, nil, 0, nil, http.StatusOK, http.StatusNoContent)
+	if errors.Is(err, ErrNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return res.Body.Close()
+}
@@ -0,0 +1,140 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pebble
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/cockroachdb/pebble"
+	"github.com/cockroachdb/pebble/objstorage/objstorageprovider"
+	"github.com/cockroachdb/pebble/sstable"
+	"github.com/cockroachdb/pebble/vfs"
+)
+
+// ingestTableSize is the size of the sstables built by the ingester. It matches
+// the target file size of the bottommost level, where ingested tables land in a
+// database without overlapping data.
+const ingestTableSize = 128 * 1024 * 1024
+
+// Ingester builds sstables out of key-value pairs supplied in strictly ascending
+// order and ingests them into the database in bulk, bypassing the memory tables
+// and the write-ahead-log. It's meant for populating a fresh database, ingesting
+// tables overlapping with existing data forces them into the upper levels.
+type Ingester struct {
+	db     *Database
+	dir    string // Directory to build the sstables in
+	opts   sstable.WriterOptions
+	writer *sstable.Writer // Writer of the table being built, nil if none
+	files  []string        // Finished tables waiting for ingestion
+	last   []byte          // Last key added, for enforcing the ordering
+	seq    int             // Sequence number for naming the tables
+}
+
+// NewIngester creates an ingester for the database, building the sstables in the
+// given directory. The directory should reside on the same filesystem as the
+// database, so that the tables can be hard-linked instead of copied.
+func (d *Database) NewIngester(dir string) (*Ingester, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &Ingester{
+		db:  d,
+		dir: dir,
+		opts: sstable.WriterOptions{
+			TableFormat: d.db.FormatMajorVersion().MaxTableFormat(),
+		},
+	}, nil
+}
+
+// Put adds a key-value pair to the table being built. The key must be larger
+// than all the previously added ones.
+func (in *Ingester) Put(key []byte, value []byte) error {
+	if in.last != nil && bytes.Compare(key, in.last) <= 0 {
+		return fmt.Errorf("key %x not in ascending order", key)
+	}
+	in.last = append(in.last[:0], key...)
+
+	if in.writer == nil {
+		name := filepath.Join(in.dir, fmt.Sprintf("%06d.sst", in.seq))
+		f, err := vfs.Default.Create(name)
+		if err != nil {
+			return err
+		}
+		in.writer = sstable.NewWriter(objstorageprovider.NewFileWritable(f), in.opts)
+		in.files = append(in.files, name)
+		in.seq++
+	}
+	if err := in.writer.Set(key, value); err != nil {
+		return err
+	}
+	if in.writer.EstimatedSize() >= ingestTableSize {
+		return in.finish()
+	}
+	return nil
+}
+
+// finish closes the table being built, if any.
+func (in *Ingester) finish() error {
+	if in.writer == nil {
+		return nil
+	}
+	err := in.writer.Close()
+	in.writer = nil
+	return err
+}
+
+// Flush finishes the table being built and ingests all the built tables into the
+// database atomically.
+func (in *Ingester) Flush() error {
+	if err := in.finish(); err != nil {
+		return err
+	}
+	if len(in.files) == 0 {
+		return nil
+	}
+	in.db.quitLock.RLock()
+	defer in.db.quitLock.RUnlock()
+	if in.db.closed {
+		return pebble.ErrClosed
+	}
+	if err := in.db.db.Ingest(in.files); err != nil {
+		return err
+	}
+	// The tables are linked into the database, drop the originals
+	for _, file := range in.files {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	in.files = in.files[:0]
+	return nil
+}
+
+// Close discards the tables which are not yet ingested and removes the working
+// directory of the ingester.
+func (in *Ingester) Close() error {
+	if in.writer != nil {
+		in.writer.Close()
+		in.writer = nil
+	}
+	in.files = nil
+	return os.RemoveAll(in.dir)
+}
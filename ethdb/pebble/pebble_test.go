@@ -18,6 +18,8 @@ package pebble
 
 import (
 	"errors"
+	"fmt"
+	"path/filepath"
 	"testing"
 
 	"github.com/cockroachdb/pebble"
@@ -78,3 +80,87 @@ func TestPebbleLogData(t *testing.T) {
 		t.Fatal("Unknown database entry")
 	}
 }
+
+func TestPebbleIngester(t *testing.T) {
+	dir := t.TempDir()
+	db, err := New(filepath.Join(dir, "db"), 16, 16, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	in, err := db.NewIngester(filepath.Join(dir, "ingest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+
+	for i := 0; i < 1000; i++ {
+		if err := in.Put([]byte(fmt.Sprintf("key-%04d", i)), []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := in.Put([]byte("key-0000"), nil); err == nil {
+		t.Fatal("expected error for out of order key")
+	}
+	// Nothing is visible before flushing
+	if has, _ := db.Has([]byte("key-0000")); has {
+		t.Fatal("key visible before flush")
+	}
+	if err := in.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	it := db.NewIterator(nil, nil)
+	defer it.Release()
+
+	var count int
+	for it.Next() {
+		if want := fmt.Sprintf("key-%04d", count); string(it.Key()) != want || it.Value()[0] != byte(count) {
+			t.Fatalf("unexpected entry %d: %s %x", count, it.Key(), it.Value())
+		}
+		count++
+	}
+	if count != 1000 {
+		t.Fatalf("unexpected number of entries: have %d want 1000", count)
+	}
+}
+
+func TestPebbleCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	db, err := New(filepath.Join(dir, "db"), 16, 16, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 100; i++ {
+		if err := db.Put([]byte(fmt.Sprintf("key-%04d", i)), []byte{byte(i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Checkpoint(filepath.Join(dir, "checkpoint")); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Checkpoint(filepath.Join(dir, "checkpoint")); err == nil {
+		t.Fatal("expected error for existing checkpoint directory")
+	}
+	// Mutations after the checkpoint are not visible in it
+	if err := db.Delete([]byte("key-0000")); err != nil {
+		t.Fatal(err)
+	}
+	cp, err := New(filepath.Join(dir, "checkpoint"), 16, 16, "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cp.Close()
+
+	for i := 0; i < 100; i++ {
+		val, err := cp.Get([]byte(fmt.Sprintf("key-%04d", i)))
+		if err != nil {
+			t.Fatalf("missing entry %d: %v", i, err)
+		}
+		if val[0] != byte(i) {
+			t.Fatalf("unexpected value for entry %d: %x", i, val)
+		}
+	}
+}
@@ -14,29 +14,38 @@
 // You should have received a copy of the GNU Lesser General Public License
 // along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
 
-// Package remotedb implements the key-value database layer based on a remote geth
-// node. Under the hood, it utilises the `debug_dbGet` method to implement a
-// read-only database.
+// Package remotedb implements the database layer based on a remote geth node.
+// Under the hood, it utilises the `debug_db*` methods to implement the database
+// interface. The writes require the remote node to be accessed over IPC, range
+// deletions need both bounds set, and the ancient store can only be modified if
+// explicitly allowed by the remote node.
 // There really are no guarantees in this database, since the local geth does not
-// exclusive access, but it can be used for basic diagnostics of a remote node.
+// have exclusive access, but it can be used for diagnostics and repairs of a
+// running remote node.
 package remotedb
 
 import (
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
-// Database is a key-value lookup for a remote database via debug_dbGet.
+// iteratorPageSize is the number of entries requested in one go by the iterators.
+const iteratorPageSize = 1024
+
+// Database is a key-value lookup for a remote database via the debug_db* methods.
 type Database struct {
 	remote *rpc.Client
 }
 
 func (db *Database) Has(key []byte) (bool, error) {
-	if _, err := db.Get(key); err != nil {
-		return false, err
-	}
-	return true, nil
+	var resp bool
+	err := db.remote.Call(&resp, "debug_dbHas", hexutil.Bytes(key))
+	return resp, err
 }
 
 func (db *Database) Get(key []byte) ([]byte, error) {
@@ -58,7 +67,16 @@ func (db *Database) Ancient(kind string, number uint64) ([]byte, error) {
 }
 
 func (db *Database) AncientRange(kind string, start, count, maxBytes uint64) ([][]byte, error) {
-	panic("not supported")
+	var resp []hexutil.Bytes
+	err := db.remote.Call(&resp, "debug_dbAncientRange", kind, start, count, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	blobs := make([][]byte, len(resp))
+	for i, blob := range resp {
+		blobs[i] = blob
+	}
+	return blobs, nil
 }
 
 func (db *Database) Ancients() (uint64, error) {
@@ -68,11 +86,15 @@ func (db *Database) Ancients() (uint64, error) {
 }
 
 func (db *Database) Tail() (uint64, error) {
-	panic("not supported")
+	var resp uint64
+	err := db.remote.Call(&resp, "debug_dbTail")
+	return resp, err
 }
 
 func (db *Database) AncientSize(kind string) (uint64, error) {
-	panic("not supported")
+	var resp uint64
+	err := db.remote.Call(&resp, "debug_dbAncientSize", kind)
+	return resp, err
 }
 
 func (db *Database) ReadAncients(fn func(op ethdb.AncientReaderOp) error) (err error) {
@@ -80,43 +102,59 @@ func (db *Database) ReadAncients(fn func(op ethdb.AncientReaderOp) error) (err e
 }
 
 func (db *Database) Put(key []byte, value []byte) error {
-	panic("not supported")
+	return db.remote.Call(nil, "debug_dbPut", hexutil.Bytes(key), hexutil.Bytes(value))
 }
 
 func (db *Database) Delete(key []byte) error {
-	panic("not supported")
+	return db.remote.Call(nil, "debug_dbDelete", hexutil.Bytes(key))
 }
 
 func (db *Database) DeleteRange(start, end []byte) error {
-	panic("not supported")
+	return db.remote.Call(nil, "debug_dbDeleteRange", hexutil.Bytes(start), hexutil.Bytes(end))
 }
 
-func (db *Database) ModifyAncients(f func(ethdb.AncientWriteOp) error) (int64, error) {
-	panic("not supported")
+// ModifyAncients collects the items appended by the given function and sends
+// them to the remote node in one go, where they are written atomically.
+func (db *Database) ModifyAncients(fn func(ethdb.AncientWriteOp) error) (int64, error) {
+	op := new(ancientWriteOp)
+	if err := fn(op); err != nil {
+		return 0, err
+	}
+	var resp int64
+	err := db.remote.Call(&resp, "debug_dbModifyAncients", op.items)
+	return resp, err
 }
 
 func (db *Database) TruncateHead(n uint64) (uint64, error) {
-	panic("not supported")
+	var resp uint64
+	err := db.remote.Call(&resp, "debug_dbTruncateHead", n)
+	return resp, err
 }
 
 func (db *Database) TruncateTail(n uint64) (uint64, error) {
-	panic("not supported")
+	var resp uint64
+	err := db.remote.Call(&resp, "debug_dbTruncateTail", n)
+	return resp, err
 }
 
 func (db *Database) SyncAncient() error {
-	return nil
+	return db.remote.Call(nil, "debug_dbSyncAncient")
 }
 
 func (db *Database) NewBatch() ethdb.Batch {
-	panic("not supported")
+	return &batch{db: db}
 }
 
 func (db *Database) NewBatchWithSize(size int) ethdb.Batch {
-	panic("not supported")
+	return &batch{db: db}
 }
 
 func (db *Database) NewIterator(prefix []byte, start []byte) ethdb.Iterator {
-	panic("not supported")
+	return &iterator{
+		db:     db,
+		prefix: common.CopyBytes(prefix),
+		start:  common.CopyBytes(start),
+	}
 }
 
 func (db *Database) Stat() (string, error) {
@@ -124,7 +162,7 @@ func (db *Database) Stat() (string, error) {
 }
 
 func (db *Database) AncientDatadir() (string, error) {
-	panic("not supported")
+	return "", errors.New("not supported")
 }
 
 func (db *Database) Compact(start []byte, limit []byte) error {
@@ -132,7 +170,7 @@ func (db *Database) Compact(start []byte, limit []byte) error {
 }
 
 func (db *Database) SyncKeyValue() error {
-	return nil
+	return db.remote.Call(nil, "debug_dbSyncKeyValue")
 }
 
 func (db *Database) Close() error {
@@ -141,7 +179,12 @@ func (db *Database) Close() error {
 }
 
 func (db *Database) AncientBytes(kind string, id, offset, length uint64) ([]byte, error) {
-	panic("not supported")
+	var resp hexutil.Bytes
+	err := db.remote.Call(&resp, "debug_dbAncientBytes", kind, id, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
 
 func New(client *rpc.Client) ethdb.Database {
@@ -150,3 +193,182 @@ func New(client *rpc.Client) ethdb.Database {
 	}
 	return &Database{remote: client}
 }
+
+// batchOp is a single operation of a batch, matching the format expected by
+// debug_dbWrite.
+type batchOp struct {
+	Op    string        `json:"op"`
+	Key   hexutil.Bytes `json:"key"`
+	Value hexutil.Bytes `json:"value,omitempty"`
+}
+
+// batch is a write-only batch that commits its changes to the remote database
+// atomically when Write is called.
+type batch struct {
+	db   *Database
+	ops  []batchOp
+	size int
+}
+
+// Put inserts the given value into the batch for later committing.
+func (b *batch) Put(key, value []byte) error {
+	b.ops = append(b.ops, batchOp{Op: "put", Key: common.CopyBytes(key), Value: common.CopyBytes(value)})
+	b.size += len(key) + len(value)
+	return nil
+}
+
+// Delete inserts the key removal into the batch for later committing.
+func (b *batch) Delete(key []byte) error {
+	b.ops = append(b.ops, batchOp{Op: "delete", Key: common.CopyBytes(key)})
+	b.size += len(key)
+	return nil
+}
+
+// DeleteRange removes all keys in the range [start, end) from the batch for
+// later committing.
+func (b *batch) DeleteRange(start, end []byte) error {
+	b.ops = append(b.ops, batchOp{Op: "deleteRange", Key: common.CopyBytes(start), Value: common.CopyBytes(end)})
+	b.size += len(start) + len(end)
+	return nil
+}
+
+// ValueSize retrieves the amount of data queued up for writing.
+func (b *batch) ValueSize() int {
+	return b.size
+}
+
+// Write flushes any accumulated data to the remote database.
+func (b *batch) Write() error {
+	return b.db.remote.Call(nil, "debug_dbWrite", b.ops)
+}
+
+// Reset resets the batch for reuse.
+func (b *batch) Reset() {
+	b.ops = b.ops[:0]
+	b.size = 0
+}
+
+// Replay replays the batch contents.
+func (b *batch) Replay(w ethdb.KeyValueWriter) error {
+	for _, op := range b.ops {
+		switch op.Op {
+		case "put":
+			if err := w.Put(op.Key, op.Value); err != nil {
+				return err
+			}
+		case "delete":
+			if err := w.Delete(op.Key); err != nil {
+				return err
+			}
+		case "deleteRange":
+			rangeDeleter, ok := w.(ethdb.KeyValueRangeDeleter)
+			if !ok {
+				return errors.New("ethdb.KeyValueWriter does not implement DeleteRange")
+			}
+			if err := rangeDeleter.DeleteRange(op.Key, op.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// iteratorPage is a page of entries returned by debug_dbIterate.
+type iteratorPage struct {
+	Keys   []hexutil.Bytes `json:"keys"`
+	Values []hexutil.Bytes `json:"values"`
+	Next   hexutil.Bytes   `json:"next"`
+}
+
+// iterator walks the remote database, fetching the entries page by page.
+type iterator struct {
+	db     *Database
+	prefix []byte
+	start  []byte // Position of the next page to fetch, nil if exhausted
+
+	page *iteratorPage
+	pos  int
+	err  error
+}
+
+// Next moves the iterator to the next key/value pair. It returns whether the
+// iterator is exhausted.
+func (it *iterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.page != nil && it.pos+1 < len(it.page.Keys) {
+		it.pos++
+		return true
+	}
+	// Fetch the next page unless the last one is already consumed
+	if it.page != nil && it.page.Next == nil {
+		it.page, it.pos = &iteratorPage{}, 0
+		return false
+	}
+	page := new(iteratorPage)
+	if err := it.db.remote.Call(page, "debug_dbIterate", hexutil.Bytes(it.prefix), hexutil.Bytes(it.start), iteratorPageSize); err != nil {
+		it.err = err
+		return false
+	}
+	if len(page.Keys) != len(page.Values) {
+		it.err = errors.New("invalid iterator page")
+		return false
+	}
+	it.page, it.pos, it.start = page, 0, page.Next
+	return len(page.Keys) > 0
+}
+
+// Error returns any accumulated error.
+func (it *iterator) Error() error {
+	return it.err
+}
+
+// Key returns the key of the current key/value pair, or nil if done.
+func (it *iterator) Key() []byte {
+	if it.page == nil || it.pos >= len(it.page.Keys) {
+		return nil
+	}
+	return it.page.Keys[it.pos]
+}
+
+// Value returns the value of the current key/value pair, or nil if done.
+func (it *iterator) Value() []byte {
+	if it.page == nil || it.pos >= len(it.page.Values) {
+		return nil
+	}
+	return it.page.Values[it.pos]
+}
+
+// Release releases associated resources.
+func (it *iterator) Release() {
+	it.page = nil
+}
+
+// ancientWriteOp collects the items appended to the ancient store.
+type ancientWriteOp struct {
+	items []ancientItem
+}
+
+// ancientItem is an item appended to the ancient store, matching the format
+// expected by debug_dbModifyAncients.
+type ancientItem struct {
+	Kind   string         `json:"kind"`
+	Number hexutil.Uint64 `json:"number"`
+	Data   hexutil.Bytes  `json:"data"`
+}
+
+// Append adds an RLP-encoded item.
+func (op *ancientWriteOp) Append(kind string, number uint64, item interface{}) error {
+	data, err := rlp.EncodeToBytes(item)
+	if err != nil {
+		return err
+	}
+	return op.AppendRaw(kind, number, data)
+}
+
+// AppendRaw adds an item without RLP-encoding it.
+func (op *ancientWriteOp) AppendRaw(kind string, number uint64, item []byte) error {
+	op.items = append(op.items, ancientItem{Kind: kind, Number: hexutil.Uint64(number), Data: common.CopyBytes(item)})
+	return nil
+}
@@ -825,6 +825,7 @@ func (api *BlockChainAPI) SimulateV1(ctx context.Context, opts simOpts, blockNrO
 		traceTransfers: opts.TraceTransfers,
 		validate:       opts.Validation,
 		fullTx:         opts.ReturnFullTransactions,
+		timeout:        api.b.RPCEVMTimeout(),
 	}
 	return sim.execute(ctx, opts.BlockStateCalls)
 }
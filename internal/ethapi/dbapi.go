@@ -17,10 +17,30 @@
 package ethapi
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+const (
+	// dbIteratePageItems and dbIteratePageBytes limit the size of the pages
+	// returned by DbIterate.
+	dbIteratePageItems = 10000
+	dbIteratePageBytes = 4 * 1024 * 1024
 )
 
+// errAncientWritesDisabled is returned if the ancient store is attempted to be
+// modified without being explicitly allowed.
+var errAncientWritesDisabled = errors.New("ancient store writes are disabled")
+
+// errOpenDeleteRange is returned if a range deletion is missing either bound.
+var errOpenDeleteRange = errors.New("range deletion requires both bounds")
+
 // DbGet returns the raw value of a key stored in the database.
 func (api *DebugAPI) DbGet(key string) (hexutil.Bytes, error) {
 	blob, err := common.ParseHexOrString(key)
@@ -41,3 +61,213 @@ func (api *DebugAPI) DbAncient(kind string, number uint64) (hexutil.Bytes, error
 func (api *DebugAPI) DbAncients() (uint64, error) {
 	return api.b.ChainDb().Ancients()
 }
+
+// DbHas reports whether the given key is present in the database.
+func (api *DebugAPI) DbHas(key hexutil.Bytes) (bool, error) {
+	return api.b.ChainDb().Has(key)
+}
+
+// DbIteratorPage is a page of the key-value pairs returned by DbIterate.
+type DbIteratorPage struct {
+	Keys   []hexutil.Bytes `json:"keys"`
+	Values []hexutil.Bytes `json:"values"`
+	Next   hexutil.Bytes   `json:"next,omitempty"` // Start of the next page (without the prefix), nil if exhausted
+}
+
+// DbIterate returns the key-value pairs with the given prefix, starting at the
+// given position after the prefix. At most limit entries are returned in one
+// page, the iteration can be resumed from the position returned in Next.
+func (api *DebugAPI) DbIterate(prefix hexutil.Bytes, start hexutil.Bytes, limit int) (*DbIteratorPage, error) {
+	if limit <= 0 || limit > dbIteratePageItems {
+		limit = dbIteratePageItems
+	}
+	var (
+		it   = api.b.ChainDb().NewIterator(prefix, start)
+		page = new(DbIteratorPage)
+		size int
+	)
+	defer it.Release()
+
+	for it.Next() {
+		if len(page.Keys) >= limit || size >= dbIteratePageBytes {
+			page.Next = common.CopyBytes(it.Key()[len(prefix):])
+			break
+		}
+		page.Keys = append(page.Keys, common.CopyBytes(it.Key()))
+		page.Values = append(page.Values, common.CopyBytes(it.Value()))
+		size += len(it.Key()) + len(it.Value())
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// DbAncientRange retrieves multiple items in sequence from the ancient store.
+// It is a mapping to the `AncientReaderOp.AncientRange` method
+func (api *DebugAPI) DbAncientRange(kind string, start, count, maxBytes uint64) ([]hexutil.Bytes, error) {
+	blobs, err := api.b.ChainDb().AncientRange(kind, start, count, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]hexutil.Bytes, len(blobs))
+	for i, blob := range blobs {
+		res[i] = blob
+	}
+	return res, nil
+}
+
+// DbAncientBytes retrieves a segment of an item from the ancient store.
+// It is a mapping to the `AncientReaderOp.AncientBytes` method
+func (api *DebugAPI) DbAncientBytes(kind string, id, offset, length uint64) (hexutil.Bytes, error) {
+	return api.b.ChainDb().AncientBytes(kind, id, offset, length)
+}
+
+// DbTail returns the number of the first stored item in the ancient store.
+// It is a mapping to the `AncientReaderOp.Tail` method
+func (api *DebugAPI) DbTail() (uint64, error) {
+	return api.b.ChainDb().Tail()
+}
+
+// DbAncientSize returns the size of the given ancient table.
+// It is a mapping to the `AncientReaderOp.AncientSize` method
+func (api *DebugAPI) DbAncientSize(kind string) (uint64, error) {
+	return api.b.ChainDb().AncientSize(kind)
+}
+
+// DbStats returns the amount of data written into the key-value store and the
+// number of deletions per data category, accumulated since the tracking started.
+// The counters don't tell the current size of the data categories.
+func (api *DebugAPI) DbStats() (*rawdb.DatabaseStats, error) {
+	stats := rawdb.ReadDatabaseStats(api.b.ChainDb())
+	if stats == nil {
+		return nil, errors.New("database statistics not available")
+	}
+	return stats, nil
+}
+
+// DatabaseWriteAPI provides write access to the raw database of a running node,
+// for repair tooling. It's only served over IPC, since careless use can corrupt
+// the database beyond repair.
+type DatabaseWriteAPI struct {
+	db       ethdb.Database
+	ancients bool // Whether modifying the ancient store is allowed
+}
+
+// NewDatabaseWriteAPI creates a new instance of DatabaseWriteAPI. The ancient
+// store can only be modified if explicitly allowed.
+func NewDatabaseWriteAPI(db ethdb.Database, ancients bool) *DatabaseWriteAPI {
+	return &DatabaseWriteAPI{db: db, ancients: ancients}
+}
+
+// DbPut inserts the given value into the database.
+func (api *DatabaseWriteAPI) DbPut(key hexutil.Bytes, value hexutil.Bytes) error {
+	return api.db.Put(key, value)
+}
+
+// DbDelete removes the given key from the database.
+func (api *DatabaseWriteAPI) DbDelete(key hexutil.Bytes) error {
+	return api.db.Delete(key)
+}
+
+// DbDeleteRange deletes all the keys in the range [start, end). Both bounds must
+// be given and ordered, so a malformed request can't wipe the whole database.
+func (api *DatabaseWriteAPI) DbDeleteRange(start hexutil.Bytes, end hexutil.Bytes) error {
+	if err := checkDeleteRange(start, end); err != nil {
+		return err
+	}
+	return api.db.DeleteRange(start, end)
+}
+
+// DbBatchOp is a single operation of a batch written by DbWrite.
+type DbBatchOp struct {
+	Op    string        `json:"op"` // "put", "delete" or "deleteRange"
+	Key   hexutil.Bytes `json:"key"`
+	Value hexutil.Bytes `json:"value,omitempty"` // Value to put, or the end of the range to delete
+}
+
+// DbWrite applies the given operations atomically in a single batch.
+func (api *DatabaseWriteAPI) DbWrite(ops []DbBatchOp) error {
+	batch := api.db.NewBatch()
+	for i, op := range ops {
+		var err error
+		switch op.Op {
+		case "put":
+			err = batch.Put(op.Key, op.Value)
+		case "delete":
+			err = batch.Delete(op.Key)
+		case "deleteRange":
+			if err = checkDeleteRange(op.Key, op.Value); err == nil {
+				err = batch.DeleteRange(op.Key, op.Value)
+			}
+		default:
+			err = fmt.Errorf("unknown operation %q", op.Op)
+		}
+		if err != nil {
+			return fmt.Errorf("operation %d: %w", i, err)
+		}
+	}
+	return batch.Write()
+}
+
+// DbSyncKeyValue flushes the pending key-value writes to disk.
+func (api *DatabaseWriteAPI) DbSyncKeyValue() error {
+	return api.db.SyncKeyValue()
+}
+
+// DbAncientItem is an item appended to the ancient store by DbModifyAncients.
+type DbAncientItem struct {
+	Kind   string         `json:"kind"`
+	Number hexutil.Uint64 `json:"number"`
+	Data   hexutil.Bytes  `json:"data"`
+}
+
+// DbModifyAncients appends the given raw items to the ancient store atomically,
+// returning the number of bytes written.
+func (api *DatabaseWriteAPI) DbModifyAncients(items []DbAncientItem) (int64, error) {
+	if !api.ancients {
+		return 0, errAncientWritesDisabled
+	}
+	return api.db.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for _, item := range items {
+			if err := op.AppendRaw(item.Kind, uint64(item.Number), item.Data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DbTruncateHead discards the ancient items above the given threshold, returning
+// the previous head.
+func (api *DatabaseWriteAPI) DbTruncateHead(n uint64) (uint64, error) {
+	if !api.ancients {
+		return 0, errAncientWritesDisabled
+	}
+	return api.db.TruncateHead(n)
+}
+
+// DbTruncateTail discards the ancient items below the given threshold, returning
+// the previous tail.
+func (api *DatabaseWriteAPI) DbTruncateTail(n uint64) (uint64, error) {
+	if !api.ancients {
+		return 0, errAncientWritesDisabled
+	}
+	return api.db.TruncateTail(n)
+}
+
+// DbSyncAncient flushes the ancient store to disk.
+func (api *DatabaseWriteAPI) DbSyncAncient() error {
+	return api.db.SyncAncient()
+}
+
+// checkDeleteRange ensures a range to delete has both bounds set and ordered.
+func checkDeleteRange(start, end []byte) error {
+	if len(start) == 0 || len(end) == 0 {
+		return errOpenDeleteRange
+	}
+	if bytes.Compare(start, end) >= 0 {
+		return fmt.Errorf("invalid range: start %#x not below end %#x", start, end)
+	}
+	return nil
+}
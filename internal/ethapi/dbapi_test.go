@@ -0,0 +1,183 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethapi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/ethdb/remotedb"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+)
+
+// dbBackend is a backend only serving the database.
+type dbBackend struct {
+	Backend
+	db ethdb.Database
+}
+
+func (b dbBackend) ChainDb() ethdb.Database { return b.db }
+
+// newRemoteDB serves the given database over an in-process RPC server and
+// returns a remote database connected to it.
+func newRemoteDB(t *testing.T, db ethdb.Database, ancients bool) ethdb.Database {
+	server := rpc.NewServer()
+	t.Cleanup(server.Stop)
+
+	require.NoError(t, server.RegisterName("debug", NewDebugAPI(dbBackend{db: db})))
+	require.NoError(t, server.RegisterName("debug", NewDatabaseWriteAPI(db, ancients)))
+	return remotedb.New(rpc.DialInProc(server))
+}
+
+func TestRemoteDatabaseWrites(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	remote := newRemoteDB(t, db, false)
+
+	require.NoError(t, remote.Put([]byte("a"), []byte{1}))
+	require.NoError(t, remote.Put([]byte("b"), []byte{2}))
+	require.NoError(t, remote.Delete([]byte("a")))
+
+	has, err := remote.Has([]byte("a"))
+	require.NoError(t, err)
+	require.False(t, has)
+	val, err := remote.Get([]byte("b"))
+	require.NoError(t, err)
+	require.Equal(t, []byte{2}, val)
+
+	batch := remote.NewBatch()
+	for i := 0; i < 10; i++ {
+		require.NoError(t, batch.Put([]byte(fmt.Sprintf("c%d", i)), []byte{byte(i)}))
+	}
+	require.NoError(t, batch.Delete([]byte("b")))
+	require.NoError(t, batch.DeleteRange([]byte("c3"), []byte("c7")))
+	require.NoError(t, batch.Write())
+
+	for i := 0; i < 10; i++ {
+		has, err := db.Has([]byte(fmt.Sprintf("c%d", i)))
+		require.NoError(t, err)
+		require.Equal(t, i < 3 || i >= 7, has, "key c%d", i)
+	}
+	has, err = db.Has([]byte("b"))
+	require.NoError(t, err)
+	require.False(t, has)
+
+	require.NoError(t, remote.DeleteRange([]byte("c0"), []byte("c5")))
+	has, err = db.Has([]byte("c2"))
+	require.NoError(t, err)
+	require.False(t, has)
+}
+
+func TestRemoteDatabaseDeleteRangeBounds(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	for i := 0; i < 10; i++ {
+		db.Put([]byte{byte(i + 1)}, []byte{byte(i)})
+	}
+	remote := newRemoteDB(t, db, false)
+
+	ranges := [][2][]byte{
+		{nil, nil},
+		{nil, {5}},
+		{{5}, nil},
+		{{5}, {5}},
+		{{9}, {1}},
+	}
+	for _, r := range ranges {
+		require.Error(t, remote.DeleteRange(r[0], r[1]), "range %x-%x", r[0], r[1])
+
+		batch := remote.NewBatch()
+		require.NoError(t, batch.Put([]byte("x"), []byte{1}))
+		require.NoError(t, batch.DeleteRange(r[0], r[1]))
+		require.Error(t, batch.Write(), "batch range %x-%x", r[0], r[1])
+	}
+	// Nothing must have been modified by the rejected requests
+	for i := 0; i < 10; i++ {
+		has, err := db.Has([]byte{byte(i + 1)})
+		require.NoError(t, err)
+		require.True(t, has)
+	}
+	has, err := db.Has([]byte("x"))
+	require.NoError(t, err)
+	require.False(t, has)
+}
+
+func TestRemoteDatabasePagedIterator(t *testing.T) {
+	db := rawdb.NewMemoryDatabase()
+	for i := 0; i < 3000; i++ {
+		db.Put([]byte(fmt.Sprintf("a%05d", i)), []byte{byte(i)})
+	}
+	db.Put([]byte("b"), []byte{1})
+
+	remote := newRemoteDB(t, db, false)
+	it := remote.NewIterator([]byte("a"), []byte("00500"))
+	defer it.Release()
+
+	var n int
+	for ; it.Next(); n++ {
+		require.Equal(t, fmt.Sprintf("a%05d", 500+n), string(it.Key()))
+		require.Equal(t, []byte{byte(500 + n)}, it.Value())
+	}
+	require.NoError(t, it.Error())
+	require.Equal(t, 2500, n)
+}
+
+func TestRemoteDatabaseAncients(t *testing.T) {
+	db, err := rawdb.Open(memorydb.New(), rawdb.OpenOptions{})
+	require.NoError(t, err)
+
+	// Ancient writes are rejected unless allowed
+	remote := newRemoteDB(t, db, false)
+	_, err = remote.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		return op.AppendRaw(rawdb.ChainFreezerHeaderTable, 0, []byte{1})
+	})
+	require.ErrorContains(t, err, errAncientWritesDisabled.Error())
+	_, err = remote.TruncateHead(0)
+	require.ErrorContains(t, err, errAncientWritesDisabled.Error())
+
+	remote = newRemoteDB(t, db, true)
+	_, err = remote.ModifyAncients(func(op ethdb.AncientWriteOp) error {
+		for i := uint64(0); i < 10; i++ {
+			for _, kind := range []string{rawdb.ChainFreezerHeaderTable, rawdb.ChainFreezerHashTable, rawdb.ChainFreezerBodiesTable, rawdb.ChainFreezerReceiptTable} {
+				if err := op.AppendRaw(kind, i, []byte{byte(i), 0xaa, 0xbb}); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+
+	n, err := remote.Ancients()
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), n)
+
+	blobs, err := remote.AncientRange(rawdb.ChainFreezerHeaderTable, 2, 3, 0)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{{2, 0xaa, 0xbb}, {3, 0xaa, 0xbb}, {4, 0xaa, 0xbb}}, blobs)
+
+	_, err = remote.TruncateHead(5)
+	require.NoError(t, err)
+	n, err = remote.Ancients()
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), n)
+
+	_, err = remote.Ancient(rawdb.ChainFreezerHeaderTable, 5)
+	require.Error(t, err)
+}
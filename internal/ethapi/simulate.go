@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"math/big"
 	"time"
 
@@ -31,6 +32,7 @@ import (
 	"github.com/ethereum/go-ethereum/consensus/misc/eip4844"
 	"github.com/ethereum/go-ethereum/core"
 	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/tracing"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/core/vm"
 	"github.com/ethereum/go-ethereum/internal/ethapi/override"
@@ -108,22 +110,73 @@ type simOpts struct {
 	ReturnFullTransactions bool
 }
 
+// SimBlock is a batch of calls to be simulated sequentially, as accepted by
+// eth_simulateV1.
+type SimBlock = simBlock
+
+// SimCallTracer is invoked before every simulated call with the number of the
+// simulated block, the index of the call within it and the transaction of the
+// call. It returns the hooks to trace the call with, or nil to not trace it.
+type SimCallTracer func(number uint64, index int, tx *types.Transaction) (*tracing.Hooks, error)
+
+// SimConfig contains the parameters of a simulation run via Simulate.
+type SimConfig struct {
+	Validation bool          // Whether to validate the calls like transactions
+	GasCap     uint64        // Gas cap shared by all calls, 0 if unlimited
+	Timeout    time.Duration // Timeout of the whole simulation, 0 if unlimited
+	CallTracer SimCallTracer // Tracer of the individual calls, optional
+}
+
+// Simulate executes a series of simulated blocks on top of the given state and
+// base header, as eth_simulateV1 does, returning the simulated blocks. It's meant
+// for other packages building on top of the simulation, such as the tracers.
+func Simulate(ctx context.Context, b ChainContextBackend, state *state.StateDB, base *types.Header, blocks []SimBlock, config SimConfig) ([]*types.Block, error) {
+	if len(blocks) == 0 {
+		return nil, &invalidParamsError{message: "empty input"}
+	} else if len(blocks) > maxSimulateBlocks {
+		return nil, &clientLimitExceededError{message: "too many blocks"}
+	}
+	gasCap := config.GasCap
+	if gasCap == 0 {
+		gasCap = math.MaxUint64
+	}
+	sim := &simulator{
+		b:           b,
+		state:       state,
+		base:        base,
+		chainConfig: b.ChainConfig(),
+		gp:          new(core.GasPool).AddGas(gasCap),
+		validate:    config.Validation,
+		timeout:     config.Timeout,
+		callTracer:  config.CallTracer,
+	}
+	results, err := sim.execute(ctx, blocks)
+	if err != nil {
+		return nil, err
+	}
+	simulated := make([]*types.Block, len(results))
+	for i, result := range results {
+		simulated[i] = result.Block
+	}
+	return simulated, nil
+}
+
 // simChainHeadReader implements ChainHeaderReader which is needed as input for FinalizeAndAssemble.
 type simChainHeadReader struct {
 	context.Context
-	Backend
+	ChainContextBackend
 }
 
 func (m *simChainHeadReader) Config() *params.ChainConfig {
-	return m.Backend.ChainConfig()
+	return m.ChainContextBackend.ChainConfig()
 }
 
 func (m *simChainHeadReader) CurrentHeader() *types.Header {
-	return m.Backend.CurrentHeader()
+	return m.ChainContextBackend.CurrentHeader()
 }
 
 func (m *simChainHeadReader) GetHeader(hash common.Hash, number uint64) *types.Header {
-	header, err := m.Backend.HeaderByNumber(m.Context, rpc.BlockNumber(number))
+	header, err := m.ChainContextBackend.HeaderByNumber(m.Context, rpc.BlockNumber(number))
 	if err != nil || header == nil {
 		return nil
 	}
@@ -134,7 +187,7 @@ func (m *simChainHeadReader) GetHeader(hash common.Hash, number uint64) *types.H
 }
 
 func (m *simChainHeadReader) GetHeaderByNumber(number uint64) *types.Header {
-	header, err := m.Backend.HeaderByNumber(m.Context, rpc.BlockNumber(number))
+	header, err := m.ChainContextBackend.HeaderByNumber(m.Context, rpc.BlockNumber(number))
 	if err != nil {
 		return nil
 	}
@@ -142,7 +195,7 @@ func (m *simChainHeadReader) GetHeaderByNumber(number uint64) *types.Header {
 }
 
 func (m *simChainHeadReader) GetHeaderByHash(hash common.Hash) *types.Header {
-	header, err := m.Backend.HeaderByHash(m.Context, hash)
+	header, err := m.ChainContextBackend.HeaderByHash(m.Context, hash)
 	if err != nil {
 		return nil
 	}
@@ -152,7 +205,7 @@ func (m *simChainHeadReader) GetHeaderByHash(hash common.Hash) *types.Header {
 // simulator is a stateful object that simulates a series of blocks.
 // it is not safe for concurrent use.
 type simulator struct {
-	b              Backend
+	b              ChainContextBackend
 	state          *state.StateDB
 	base           *types.Header
 	chainConfig    *params.ChainConfig
@@ -160,6 +213,8 @@ type simulator struct {
 	traceTransfers bool
 	validate       bool
 	fullTx         bool
+	timeout        time.Duration
+	callTracer     SimCallTracer
 }
 
 // execute runs the simulation of a series of blocks.
@@ -169,7 +224,7 @@ func (sim *simulator) execute(ctx context.Context, blocks []simBlock) ([]*simBlo
 	}
 	var (
 		cancel  context.CancelFunc
-		timeout = sim.b.RPCEVMTimeout()
+		timeout = sim.timeout
 	)
 	if timeout > 0 {
 		ctx, cancel = context.WithTimeout(ctx, timeout)
@@ -286,6 +341,15 @@ func (sim *simulator) processBlock(ctx context.Context, block *simBlock, header,
 		senders[txHash] = call.from()
 		tracer.reset(txHash, uint(i))
 		sim.state.SetTxContext(txHash, i)
+
+		// Attach the call tracer if requested, on top of the log tracer
+		callHooks, err := sim.traceCall(evm, header.Number.Uint64(), i, tx, tracer.Hooks())
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if callHooks != nil && callHooks.OnTxStart != nil {
+			callHooks.OnTxStart(evm.GetVMContext(), tx, call.from())
+		}
 		// EoA check is always skipped, even in validation mode.
 		msg := call.ToMessage(header.BaseFee, !sim.validate)
 		result, err := applyMessageWithEVM(ctx, evm, msg, timeout, sim.gp)
@@ -296,12 +360,18 @@ func (sim *simulator) processBlock(ctx context.Context, block *simBlock, header,
 		// Update the state with pending changes.
 		var root []byte
 		if sim.chainConfig.IsByzantium(blockContext.BlockNumber) {
-			tracingStateDB.Finalise(true)
+			evm.StateDB.Finalise(true)
 		} else {
 			root = sim.state.IntermediateRoot(sim.chainConfig.IsEIP158(blockContext.BlockNumber)).Bytes()
 		}
 		gasUsed += result.UsedGas
 		receipts[i] = core.MakeReceipt(evm, result, sim.state, blockContext.BlockNumber, common.Hash{}, blockContext.Time, tx, gasUsed, root)
+		if callHooks != nil {
+			if callHooks.OnTxEnd != nil {
+				callHooks.OnTxEnd(receipts[i], nil)
+			}
+			evm.Config.Tracer, evm.StateDB = vmConfig.Tracer, tracingStateDB
+		}
 		blobGasUsed += receipts[i].BlobGasUsed
 		logs := tracer.Logs()
 		callRes := simCallResult{ReturnValue: result.Return(), Logs: logs, GasUsed: hexutil.Uint64(result.UsedGas)}
@@ -355,6 +425,51 @@ func (sim *simulator) processBlock(ctx context.Context, block *simBlock, header,
 	return b, callResults, senders, nil
 }
 
+// traceCall installs the hooks of the call tracer, if any, for the execution of
+// the next call in the given EVM, combined with the hooks of the log tracer. The
+// caller is responsible for restoring the original hooks after the call.
+func (sim *simulator) traceCall(evm *vm.EVM, number uint64, index int, tx *types.Transaction, logHooks *tracing.Hooks) (*tracing.Hooks, error) {
+	if sim.callTracer == nil {
+		return nil, nil
+	}
+	hooks, err := sim.callTracer(number, index, tx)
+	if err != nil || hooks == nil {
+		return nil, err
+	}
+	combined := combineHooks(hooks, logHooks)
+	evm.Config.Tracer = combined
+	evm.StateDB = state.NewHookedState(sim.state, combined)
+	return hooks, nil
+}
+
+// combineHooks returns the hooks of the call tracer, extended with the hooks of
+// the log tracer used to collect the logs of the simulated calls.
+func combineHooks(hooks, logHooks *tracing.Hooks) *tracing.Hooks {
+	combined := *hooks
+	if logHooks == nil {
+		return &combined
+	}
+	combined.OnEnter = func(depth int, typ byte, from common.Address, to common.Address, input []byte, gas uint64, value *big.Int) {
+		logHooks.OnEnter(depth, typ, from, to, input, gas, value)
+		if hooks.OnEnter != nil {
+			hooks.OnEnter(depth, typ, from, to, input, gas, value)
+		}
+	}
+	combined.OnExit = func(depth int, output []byte, gasUsed uint64, err error, reverted bool) {
+		logHooks.OnExit(depth, output, gasUsed, err, reverted)
+		if hooks.OnExit != nil {
+			hooks.OnExit(depth, output, gasUsed, err, reverted)
+		}
+	}
+	combined.OnLog = func(log *types.Log) {
+		logHooks.OnLog(log)
+		if hooks.OnLog != nil {
+			hooks.OnLog(log)
+		}
+	}
+	return &combined
+}
+
 // repairLogs updates the block hash in the logs present in the result of
 // a simulated block. This is needed as during execution when logs are collected
 // the block hash is not known.
@@ -27,6 +27,7 @@ var Modules = map[string]string{
 	"rpc":    RpcJs,
 	"txpool": TxpoolJs,
 	"dev":    DevJs,
+	"trace":  TraceJs,
 }
 
 const CliqueJs = `
@@ -110,6 +111,16 @@ web3._extend({
 			call: 'admin_removeTrustedPeer',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'banPeer',
+			call: 'admin_banPeer',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'unban',
+			call: 'admin_unban',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'exportChain',
 			call: 'admin_exportChain',
@@ -121,6 +132,16 @@ web3._extend({
 			call: 'admin_importChain',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'createCheckpoint',
+			call: 'admin_createCheckpoint',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'setTxPropagationPolicy',
+			call: 'admin_setTxPropagationPolicy',
+			params: 1
+		}),
 		new web3._extend.Method({
 			name: 'sleepBlocks',
 			call: 'admin_sleepBlocks',
@@ -168,10 +189,18 @@ web3._extend({
 			name: 'peers',
 			getter: 'admin_peers'
 		}),
+		new web3._extend.Property({
+			name: 'bans',
+			getter: 'admin_listBans'
+		}),
 		new web3._extend.Property({
 			name: 'datadir',
 			getter: 'admin_datadir'
 		}),
+		new web3._extend.Property({
+			name: 'txPropagationPolicy',
+			getter: 'admin_txPropagationPolicy'
+		}),
 	]
 });
 `
@@ -375,6 +404,11 @@ web3._extend({
 			params: 2,
 			inputFormatter: [null, null]
 		}),
+		new web3._extend.Method({
+			name: 'traceStoreStats',
+			call: 'debug_traceStoreStats',
+			params: 0,
+		}),
 		new web3._extend.Method({
 			name: 'traceBlockByNumber',
 			call: 'debug_traceBlockByNumber',
@@ -399,6 +433,12 @@ web3._extend({
 			params: 3,
 			inputFormatter: [null, null, null]
 		}),
+		new web3._extend.Method({
+			name: 'traceSimulateV1',
+			call: 'debug_traceSimulateV1',
+			params: 3,
+			inputFormatter: [null, web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
 		new web3._extend.Method({
 			name: 'preimage',
 			call: 'debug_preimage',
@@ -453,6 +493,86 @@ web3._extend({
 			call: 'debug_dbAncients',
 			params: 0
 		}),
+		new web3._extend.Method({
+			name: 'dbStats',
+			call: 'debug_dbStats',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'dbHas',
+			call: 'debug_dbHas',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'dbIterate',
+			call: 'debug_dbIterate',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'dbAncientRange',
+			call: 'debug_dbAncientRange',
+			params: 4
+		}),
+		new web3._extend.Method({
+			name: 'dbAncientBytes',
+			call: 'debug_dbAncientBytes',
+			params: 4
+		}),
+		new web3._extend.Method({
+			name: 'dbTail',
+			call: 'debug_dbTail',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'dbAncientSize',
+			call: 'debug_dbAncientSize',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'dbPut',
+			call: 'debug_dbPut',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'dbDelete',
+			call: 'debug_dbDelete',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'dbDeleteRange',
+			call: 'debug_dbDeleteRange',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'dbWrite',
+			call: 'debug_dbWrite',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'dbSyncKeyValue',
+			call: 'debug_dbSyncKeyValue',
+			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'dbModifyAncients',
+			call: 'debug_dbModifyAncients',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'dbTruncateHead',
+			call: 'debug_dbTruncateHead',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'dbTruncateTail',
+			call: 'debug_dbTruncateTail',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'dbSyncAncient',
+			call: 'debug_dbSyncAncient',
+			params: 0
+		}),
 		new web3._extend.Method({
 			name: 'setTrieFlushInterval',
 			call: 'debug_setTrieFlushInterval',
@@ -731,3 +851,23 @@ web3._extend({
 	],
 });
 `
+
+const TraceJs = `
+web3._extend({
+	property: 'trace',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'replayTransaction',
+			call: 'trace_replayTransaction',
+			params: 2
+		}),
+		new web3._extend.Method({
+			name: 'replayBlockTransactions',
+			call: 'trace_replayBlockTransactions',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, null]
+		}),
+	],
+});
+`
@@ -19,7 +19,9 @@ package node
 import (
 	"context"
 	"fmt"
+	"math"
 	"strings"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/crypto"
@@ -120,6 +122,43 @@ func (api *adminAPI) RemoveTrustedPeer(url string) (bool, error) {
 	return true, nil
 }
 
+// BanPeer prevents a node or network from connecting for the given number of
+// seconds, or permanently if zero. The target may be an enode URL, an ENR, a node
+// ID, an IP address or a CIDR network.
+func (api *adminAPI) BanPeer(target string, seconds uint64) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	if seconds > math.MaxInt64/uint64(time.Second) {
+		return false, fmt.Errorf("ban duration too long: %d seconds", seconds)
+	}
+	if err := server.BanPeer(target, time.Duration(seconds)*time.Second); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Unban lifts the ban of a node or network, returning whether it was banned.
+func (api *adminAPI) Unban(target string) (bool, error) {
+	// Make sure the server is running, fail otherwise
+	server := api.node.Server()
+	if server == nil {
+		return false, ErrNodeStopped
+	}
+	return server.Unban(target)
+}
+
+// ListBans retrieves all the nodes and networks currently banned.
+func (api *adminAPI) ListBans() ([]p2p.Ban, error) {
+	server := api.node.Server()
+	if server == nil {
+		return nil, ErrNodeStopped
+	}
+	return server.Bans(), nil
+}
+
 // PeerEvents creates an RPC subscription which receives peer events from the
 // node's p2p.Server
 func (api *adminAPI) PeerEvents(ctx context.Context) (*rpc.Subscription, error) {
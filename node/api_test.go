@@ -19,11 +19,13 @@ package node
 import (
 	"bytes"
 	"io"
+	"math"
 	"net"
 	"net/http"
 	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/ethereum/go-ethereum/rpc"
 	"github.com/stretchr/testify/assert"
@@ -298,6 +300,29 @@ func TestStartRPC(t *testing.T) {
 	}
 }
 
+// Tests that admin_banPeer rejects durations which overflow time.Duration.
+func TestBanPeerDuration(t *testing.T) {
+	stack, err := New(testNodeConfig())
+	if err != nil {
+		t.Fatal("can't create node:", err)
+	}
+	defer stack.Close()
+	if err := stack.Start(); err != nil {
+		t.Fatal("can't start node:", err)
+	}
+	api := &adminAPI{stack}
+
+	if _, err := api.BanPeer("10.0.0.0/8", math.MaxUint64); err == nil {
+		t.Fatal("overflowing ban duration accepted")
+	}
+	if _, err := api.BanPeer("10.0.0.0/8", math.MaxInt64/uint64(time.Second)+1); err == nil {
+		t.Fatal("overflowing ban duration accepted")
+	}
+	if ok, err := api.BanPeer("10.0.0.0/8", math.MaxInt64/uint64(time.Second)); !ok || err != nil {
+		t.Fatalf("maximum ban duration rejected: %v", err)
+	}
+}
+
 // checkReachable checks if the TCP endpoint in rawurl is open.
 func checkReachable(rawurl string) bool {
 	u, err := url.Parse(rawurl)
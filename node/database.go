@@ -35,6 +35,13 @@ type DatabaseOptions struct {
 	// ancient/chain or a directory specified via an absolute path.
 	EraDirectory string
 
+	// The optional object store keeping the sealed files of the chain history.
+	AncientRemote *rawdb.FreezerRemote
+
+	// Whether to compress the chain history with zstd, converting the existing
+	// tables in the background.
+	AncientZstd bool
+
 	MetricsNamespace string // the namespace for database relevant metrics
 	Cache            int    // the capacity(in megabytes) of the data caching
 	Handles          int    // number of files to be open simultaneously
@@ -62,6 +69,8 @@ func openDatabase(o internalOpenOptions) (ethdb.Database, error) {
 		Era:              o.EraDirectory,
 		MetricsNamespace: o.MetricsNamespace,
 		ReadOnly:         o.ReadOnly,
+		AncientRemote:    o.AncientRemote,
+		AncientZstd:      o.AncientZstd,
 	}
 	frdb, err := rawdb.Open(kvdb, opts)
 	if err != nil {
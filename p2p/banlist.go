@@ -0,0 +1,341 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// violationBanDuration is the time a peer is banned for after being disconnected
+// because of a protocol violation.
+const violationBanDuration = 10 * time.Minute
+
+var errInvalidBanTarget = errors.New("invalid ban target")
+
+// Ban is a ban of a single node or of a whole network.
+type Ban struct {
+	Target  string     `json:"target"`            // Node ID or network prefix
+	Reason  string     `json:"reason"`            // Reason the ban was added for
+	Expires *time.Time `json:"expires,omitempty"` // Expiry time, nil if the ban is permanent
+}
+
+// banEntry is a single ban in the ban list.
+type banEntry struct {
+	reason string
+	expiry time.Time // Zero if the ban is permanent
+}
+
+// expired returns whether the ban is no longer in effect.
+func (e banEntry) expired(now time.Time) bool {
+	return !e.expiry.IsZero() && !now.Before(e.expiry)
+}
+
+// outlasts returns whether the ban remains in effect at least as long as o.
+func (e banEntry) outlasts(o banEntry) bool {
+	return e.expiry.IsZero() || (!o.expiry.IsZero() && !e.expiry.Before(o.expiry))
+}
+
+// banRecord is the persisted form of a ban entry.
+type banRecord struct {
+	Expiry uint64 // Unix timestamp of the expiry, zero if permanent
+	Reason string
+}
+
+// banList maintains the nodes and networks which are not allowed to connect,
+// persisting them in the node database. All methods are safe to call on a nil
+// ban list, which bans nothing.
+type banList struct {
+	db    *enode.DB
+	now   func() time.Time
+	nodes map[enode.ID]banEntry
+	nets  map[netip.Prefix]banEntry
+	lock  sync.RWMutex
+}
+
+// newBanList creates a ban list, loading the previously persisted bans from the
+// node database. If the database is nil, bans are kept in memory only.
+func newBanList(db *enode.DB, now func() time.Time) *banList {
+	bl := &banList{
+		db:    db,
+		now:   now,
+		nodes: make(map[enode.ID]banEntry),
+		nets:  make(map[netip.Prefix]banEntry),
+	}
+	if db != nil {
+		bl.load()
+	}
+	return bl
+}
+
+// load reads the persisted bans, dropping the ones which expired or cannot
+// be parsed anymore.
+func (bl *banList) load() {
+	var (
+		now   = bl.now()
+		stale []string
+	)
+	bl.db.Bans(func(target string, blob []byte) bool {
+		var rec banRecord
+		if err := rlp.DecodeBytes(blob, &rec); err != nil {
+			log.Debug("Dropping corrupt ban", "target", target, "err", err)
+			stale = append(stale, target)
+			return true
+		}
+		id, prefix, err := parseBanTarget(target)
+		if err != nil {
+			log.Debug("Dropping invalid ban", "target", target, "err", err)
+			stale = append(stale, target)
+			return true
+		}
+		entry := banEntry{reason: rec.Reason}
+		if rec.Expiry != 0 {
+			entry.expiry = time.Unix(int64(rec.Expiry), 0)
+		}
+		if entry.expired(now) {
+			stale = append(stale, target)
+			return true
+		}
+		if prefix.IsValid() {
+			bl.nets[prefix] = entry
+		} else {
+			bl.nodes[id] = entry
+		}
+		return true
+	})
+	for _, target := range stale {
+		bl.db.DeleteBan(target)
+	}
+}
+
+// newEntry creates a ban entry lasting for the given duration, or forever if
+// the duration is zero.
+func (bl *banList) newEntry(duration time.Duration, reason string) banEntry {
+	entry := banEntry{reason: reason}
+	if duration > 0 {
+		entry.expiry = bl.now().Add(duration)
+	}
+	return entry
+}
+
+// ban adds or replaces the ban of the given target, which may be an enode URL,
+// an ENR, a node ID, an IP address or a CIDR network.
+func (bl *banList) ban(target string, duration time.Duration, reason string) error {
+	id, prefix, err := parseBanTarget(target)
+	if err != nil {
+		return err
+	}
+	entry := bl.newEntry(duration, reason)
+
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	if prefix.IsValid() {
+		bl.nets[prefix] = entry
+		bl.store(prefix.String(), entry)
+	} else {
+		bl.nodes[id] = entry
+		bl.store(id.String(), entry)
+	}
+	return nil
+}
+
+// banNode bans the given node for the given duration, unless it is already
+// banned for longer.
+func (bl *banList) banNode(id enode.ID, duration time.Duration, reason string) {
+	if bl == nil {
+		return
+	}
+	entry := bl.newEntry(duration, reason)
+
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	if old, ok := bl.nodes[id]; ok && !old.expired(bl.now()) && old.outlasts(entry) {
+		return
+	}
+	bl.nodes[id] = entry
+	bl.store(id.String(), entry)
+}
+
+// unban removes the ban of the given target, returning whether it was banned.
+func (bl *banList) unban(target string) (bool, error) {
+	id, prefix, err := parseBanTarget(target)
+	if err != nil {
+		return false, err
+	}
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	var (
+		entry banEntry
+		ok    bool
+	)
+	if prefix.IsValid() {
+		entry, ok = bl.nets[prefix]
+		delete(bl.nets, prefix)
+		target = prefix.String()
+	} else {
+		entry, ok = bl.nodes[id]
+		delete(bl.nodes, id)
+		target = id.String()
+	}
+	if bl.db != nil {
+		bl.db.DeleteBan(target)
+	}
+	return ok && !entry.expired(bl.now()), nil
+}
+
+// list returns all the bans in effect, sorted by target. Expired bans are
+// dropped in the process.
+func (bl *banList) list() []Ban {
+	bl.lock.Lock()
+	defer bl.lock.Unlock()
+
+	var (
+		now  = bl.now()
+		bans []Ban
+	)
+	add := func(target string, entry banEntry) bool {
+		if entry.expired(now) {
+			if bl.db != nil {
+				bl.db.DeleteBan(target)
+			}
+			return false
+		}
+		ban := Ban{Target: target, Reason: entry.reason}
+		if !entry.expiry.IsZero() {
+			expiry := entry.expiry
+			ban.Expires = &expiry
+		}
+		bans = append(bans, ban)
+		return true
+	}
+	for id, entry := range bl.nodes {
+		if !add(id.String(), entry) {
+			delete(bl.nodes, id)
+		}
+	}
+	for prefix, entry := range bl.nets {
+		if !add(prefix.String(), entry) {
+			delete(bl.nets, prefix)
+		}
+	}
+	slices.SortFunc(bans, func(a, b Ban) int { return strings.Compare(a.Target, b.Target) })
+	return bans
+}
+
+// bannedIP returns whether the given address is contained in a banned network.
+func (bl *banList) bannedIP(ip netip.Addr) bool {
+	if bl == nil || !ip.IsValid() {
+		return false
+	}
+	ip = ip.Unmap()
+
+	bl.lock.RLock()
+	defer bl.lock.RUnlock()
+
+	now := bl.now()
+	for prefix, entry := range bl.nets {
+		if prefix.Contains(ip) && !entry.expired(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// bannedNode returns whether the given node is banned, either by its identity
+// or by its IP address.
+func (bl *banList) bannedNode(n *enode.Node) bool {
+	if bl == nil {
+		return false
+	}
+	bl.lock.RLock()
+	entry, ok := bl.nodes[n.ID()]
+	bl.lock.RUnlock()
+
+	if ok && !entry.expired(bl.now()) {
+		return true
+	}
+	return bl.bannedIP(n.IPAddr())
+}
+
+// store persists a ban entry. The lock must be held by the caller.
+func (bl *banList) store(target string, entry banEntry) {
+	if bl.db == nil {
+		return
+	}
+	rec := banRecord{Reason: entry.reason}
+	if !entry.expiry.IsZero() {
+		rec.Expiry = uint64(entry.expiry.Unix())
+	}
+	blob, err := rlp.EncodeToBytes(&rec)
+	if err != nil {
+		log.Error("Failed to encode ban", "target", target, "err", err)
+		return
+	}
+	if err := bl.db.UpdateBan(target, blob); err != nil {
+		log.Warn("Failed to store ban", "target", target, "err", err)
+	}
+}
+
+// parseBanTarget parses a ban target, returning either the node ID or the network
+// prefix it refers to. Single IP addresses are converted to full length prefixes.
+func parseBanTarget(target string) (enode.ID, netip.Prefix, error) {
+	target = strings.TrimSpace(target)
+	if strings.HasPrefix(target, "enode://") || strings.HasPrefix(target, "enr:") {
+		n, err := enode.Parse(enode.ValidSchemes, target)
+		if err != nil {
+			return enode.ID{}, netip.Prefix{}, fmt.Errorf("%w: %v", errInvalidBanTarget, err)
+		}
+		return n.ID(), netip.Prefix{}, nil
+	}
+	if prefix, err := netip.ParsePrefix(target); err == nil {
+		if prefix.Addr().Is4In6() {
+			if prefix.Bits() < 96 {
+				return enode.ID{}, netip.Prefix{}, fmt.Errorf("%w: %s", errInvalidBanTarget, target)
+			}
+			prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+		}
+		return enode.ID{}, prefix.Masked(), nil
+	}
+	if ip, err := netip.ParseAddr(target); err == nil {
+		ip = ip.Unmap().WithZone("")
+		return enode.ID{}, netip.PrefixFrom(ip, ip.BitLen()), nil
+	}
+	if id, err := enode.ParseID(target); err == nil {
+		return id, netip.Prefix{}, nil
+	}
+	return enode.ID{}, netip.Prefix{}, fmt.Errorf("%w: %q", errInvalidBanTarget, target)
+}
+
+// isProtocolViolation returns whether a peer disconnection error is caused by
+// the remote side sending a locally detected malformed message. Disconnect
+// reasons sent by the remote side and ordinary protocol failures (e.g. network
+// or fork mismatches) are never treated as violations.
+func isProtocolViolation(err error) bool {
+	return errors.Is(err, ErrMalformedMessage)
+}
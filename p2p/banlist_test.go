@@ -0,0 +1,201 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/internal/testlog"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func TestParseBanTarget(t *testing.T) {
+	node := newNode(uintID(0x01), "127.0.0.1:30303")
+	tests := []struct {
+		target string
+		id     enode.ID
+		prefix string
+		err    bool
+	}{
+		{target: node.ID().String(), id: node.ID()},
+		{target: "10.1.2.3", prefix: "10.1.2.3/32"},
+		{target: "::ffff:10.1.2.3", prefix: "10.1.2.3/32"},
+		{target: "2001:db8::1", prefix: "2001:db8::1/128"},
+		{target: "10.1.2.3/16", prefix: "10.1.0.0/16"},
+		{target: "2001:db8::1/32", prefix: "2001:db8::/32"},
+		{target: "::ffff:10.1.2.3/104", prefix: "10.0.0.0/8"},
+		{target: "enode://1234", err: true},
+		{target: "10.1.2.3/33", err: true},
+		{target: "foobar", err: true},
+	}
+	for _, tt := range tests {
+		id, prefix, err := parseBanTarget(tt.target)
+		switch {
+		case tt.err && err == nil:
+			t.Errorf("%q: expected error", tt.target)
+		case !tt.err && err != nil:
+			t.Errorf("%q: unexpected error: %v", tt.target, err)
+		case tt.prefix != "" && prefix.String() != tt.prefix:
+			t.Errorf("%q: prefix mismatch: have %v, want %v", tt.target, prefix, tt.prefix)
+		case tt.prefix == "" && (id != tt.id || prefix.IsValid()):
+			t.Errorf("%q: node mismatch: have %v %v, want %v", tt.target, id, prefix, tt.id)
+		}
+	}
+}
+
+func TestBanList(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		now   = time.Unix(1_000_000, 0)
+		clock = func() time.Time { return now }
+		bl    = newBanList(db, clock)
+
+		bannedNode = newNode(uintID(0x01), "127.0.0.1:30303")
+		bannedNet  = newNode(uintID(0x02), "10.1.2.3:30303")
+		goodNode   = newNode(uintID(0x03), "127.0.0.3:30303")
+	)
+	if err := bl.ban(bannedNode.ID().String(), time.Hour, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bl.ban("10.1.0.0/16", 0, "test"); err != nil {
+		t.Fatal(err)
+	}
+	// A shorter automatic ban must not override a longer one.
+	bl.banNode(bannedNode.ID(), time.Minute, "violation")
+
+	for _, n := range []*enode.Node{bannedNode, bannedNet} {
+		if !bl.bannedNode(n) {
+			t.Errorf("node %v not banned", n)
+		}
+	}
+	if bl.bannedNode(goodNode) {
+		t.Error("unbanned node reported as banned")
+	}
+	if !bl.bannedIP(netip.MustParseAddr("::ffff:10.1.200.1")) {
+		t.Error("mapped address of banned network not banned")
+	}
+	bans := bl.list()
+	if len(bans) != 2 || bans[1].Target != "10.1.0.0/16" || bans[1].Expires != nil {
+		t.Fatalf("unexpected bans: %+v", bans)
+	}
+	if bans[0].Reason != "test" || !bans[0].Expires.Equal(now.Add(time.Hour)) {
+		t.Fatalf("unexpected node ban: %+v", bans[0])
+	}
+	// Reload the ban list after the node ban expired.
+	now = now.Add(time.Hour)
+	bl = newBanList(db, clock)
+	if bl.bannedNode(bannedNode) {
+		t.Error("expired ban still in effect")
+	}
+	if !bl.bannedNode(bannedNet) {
+		t.Error("permanent ban not persisted")
+	}
+	if ok, err := bl.unban("10.1.2.3/16"); !ok || err != nil {
+		t.Fatalf("failed to unban network: %v %v", ok, err)
+	}
+	if ok, _ := bl.unban("10.1.0.0/16"); ok {
+		t.Fatal("network unbanned twice")
+	}
+	if bans := newBanList(db, clock).list(); len(bans) != 0 {
+		t.Fatalf("bans left after unbanning: %+v", bans)
+	}
+}
+
+func TestBanListNil(t *testing.T) {
+	var bl *banList
+	if bl.bannedNode(newNode(uintID(0x01), "127.0.0.1:30303")) || bl.bannedIP(netip.MustParseAddr("127.0.0.1")) {
+		t.Fatal("nil ban list bans nodes")
+	}
+	bl.banNode(uintID(0x01), time.Minute, "violation")
+}
+
+func TestIsProtocolViolation(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{io.EOF, false},
+		{fmt.Errorf("read: %w", io.ErrUnexpectedEOF), false},
+		{&net.OpError{Op: "read", Err: errors.New("connection reset")}, false},
+		{ErrShuttingDown, false},
+		{DiscUselessPeer, false},
+		{DiscTooManyPeers, false},
+		{DiscProtocolError, false},
+		{DiscSubprotocolError, false},
+		{errors.New("network ID mismatch"), false},
+		{errors.New("invalid block"), false},
+		{newMalformedError(errInvalidMsgCode, "code 99"), true},
+		{newPeerError(errInvalidMsgCode, "not handled"), false},
+		{fmt.Errorf("%w: message too long", ErrMalformedMessage), true},
+	}
+	for _, tt := range tests {
+		if have := isProtocolViolation(tt.err); have != tt.want {
+			t.Errorf("%v: have %v, want %v", tt.err, have, tt.want)
+		}
+	}
+}
+
+func TestServerBans(t *testing.T) {
+	srv := &Server{
+		Config: Config{
+			PrivateKey:  newkey(),
+			MaxPeers:    10,
+			NoDial:      true,
+			NoDiscovery: true,
+			Logger:      testlog.Logger(t, log.LvlTrace),
+		},
+	}
+	if err := srv.BanPeer("10.0.0.0/8", 0); err != errServerStopped {
+		t.Fatalf("ban accepted by stopped server: %v", err)
+	}
+	if err := srv.Start(); err != nil {
+		t.Fatal("can't start: ", err)
+	}
+	defer srv.Stop()
+
+	if err := srv.BanPeer("10.0.0.0/8", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.BanPeer("foobar", time.Minute); !errors.Is(err, errInvalidBanTarget) {
+		t.Fatalf("invalid ban target accepted: %v", err)
+	}
+	if err := srv.checkInboundConn(netip.MustParseAddr("10.1.2.3")); err == nil {
+		t.Error("inbound connection from banned network accepted")
+	}
+	if err := srv.checkInboundConn(netip.MustParseAddr("11.1.2.3")); err != nil {
+		t.Errorf("inbound connection rejected: %v", err)
+	}
+	if bans := srv.Bans(); len(bans) != 1 || bans[0].Target != "10.0.0.0/8" {
+		t.Fatalf("unexpected bans: %+v", bans)
+	}
+	if ok, err := srv.Unban("10.0.0.0/8"); !ok || err != nil {
+		t.Fatalf("failed to unban: %v %v", ok, err)
+	}
+	if err := srv.checkInboundConn(netip.MustParseAddr("10.1.2.3")); err != nil {
+		t.Errorf("inbound connection rejected after unbanning: %v", err)
+	}
+}
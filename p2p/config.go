@@ -105,6 +105,13 @@ type Config struct {
 	// for TCP and DiscAddr for the UDP discovery protocol.
 	DiscAddr string
 
+	// If QUICAddr is set to a non-empty value, the server also accepts connections
+	// over QUIC on this UDP address and announces it in the node record. Nodes which
+	// announce a QUIC port are then dialed using QUIC as well. This is experimental:
+	// the transport is built on golang.org/x/net/quic, whose API is explicitly
+	// unstable and may change between versions of the pinned dependency.
+	QUICAddr string `toml:",omitempty"`
+
 	// If set to a non-nil value, the given NAT port mapper
 	// is used to make the listening port available to the
 	// Internet.
@@ -121,6 +128,12 @@ type Config struct {
 	// whenever a message is sent to or received from a peer
 	EnableMsgEvents bool
 
+	// If RecordDir is set, the decoded protocol messages exchanged with the peers
+	// in RecordPeers are written to rotating files in this directory. All peers
+	// are recorded if RecordPeers is empty.
+	RecordDir   string     `toml:",omitempty"`
+	RecordPeers []enode.ID `toml:",omitempty"`
+
 	// Logger is a custom logger to use with the p2p.Server.
 	Logger log.Logger `toml:"-"`
 
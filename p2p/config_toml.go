@@ -33,10 +33,13 @@ func (c Config) MarshalTOML() (interface{}, error) {
 		Protocols        []Protocol       `toml:"-" json:"-"`
 		ListenAddr       string
 		DiscAddr         string
+		QUICAddr         string        `toml:",omitempty"`
 		NAT              nat.Interface `toml:",omitempty"`
 		Dialer           NodeDialer    `toml:"-"`
 		NoDial           bool          `toml:",omitempty"`
 		EnableMsgEvents  bool
+		RecordDir        string     `toml:",omitempty"`
+		RecordPeers      []enode.ID `toml:",omitempty"`
 		Logger           log.Logger `toml:"-"`
 	}
 	var enc Config
@@ -57,10 +60,13 @@ func (c Config) MarshalTOML() (interface{}, error) {
 	enc.Protocols = c.Protocols
 	enc.ListenAddr = c.ListenAddr
 	enc.DiscAddr = c.DiscAddr
+	enc.QUICAddr = c.QUICAddr
 	enc.NAT = c.NAT
 	enc.Dialer = c.Dialer
 	enc.NoDial = c.NoDial
 	enc.EnableMsgEvents = c.EnableMsgEvents
+	enc.RecordDir = c.RecordDir
+	enc.RecordPeers = c.RecordPeers
 	enc.Logger = c.Logger
 	return &enc, nil
 }
@@ -85,10 +91,13 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 		Protocols        []Protocol       `toml:"-" json:"-"`
 		ListenAddr       *string
 		DiscAddr         *string
+		QUICAddr         *string    `toml:",omitempty"`
 		NAT              *configNAT `toml:",omitempty"`
 		Dialer           NodeDialer `toml:"-"`
 		NoDial           *bool      `toml:",omitempty"`
 		EnableMsgEvents  *bool
+		RecordDir        *string    `toml:",omitempty"`
+		RecordPeers      []enode.ID `toml:",omitempty"`
 		Logger           log.Logger `toml:"-"`
 	}
 	var dec Config
@@ -146,6 +155,9 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.DiscAddr != nil {
 		c.DiscAddr = *dec.DiscAddr
 	}
+	if dec.QUICAddr != nil {
+		c.QUICAddr = *dec.QUICAddr
+	}
 	if dec.NAT != nil {
 		c.NAT = dec.NAT
 	}
@@ -158,6 +170,12 @@ func (c *Config) UnmarshalTOML(unmarshal func(interface{}) error) error {
 	if dec.EnableMsgEvents != nil {
 		c.EnableMsgEvents = *dec.EnableMsgEvents
 	}
+	if dec.RecordDir != nil {
+		c.RecordDir = *dec.RecordDir
+	}
+	if dec.RecordPeers != nil {
+		c.RecordPeers = dec.RecordPeers
+	}
 	if dec.Logger != nil {
 		c.Logger = dec.Logger
 	}
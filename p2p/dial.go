@@ -34,6 +34,7 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/enr"
 	"github.com/ethereum/go-ethereum/p2p/netutil"
+	"github.com/ethereum/go-ethereum/p2p/reputation"
 )
 
 const (
@@ -80,6 +81,8 @@ var (
 	errNetRestrict      = errors.New("not contained in netrestrict list")
 	errNoPort           = errors.New("node does not provide TCP port")
 	errNoResolvedIP     = errors.New("node does not provide a resolved IP")
+	errLowReputation    = errors.New("low reputation")
+	errBanned           = errors.New("banned")
 )
 
 // dialer creates outbound connections and submits them into Server.
@@ -91,6 +94,10 @@ var (
 //   - dynamic dials are created from node discovery results. The dialer
 //     continuously reads candidate nodes from its input iterator and attempts
 //     to create peer connections to nodes arriving through the iterator.
+//
+// If peer reputation tracking is enabled, previously connected nodes with a good
+// reputation are dialed before any discovery results, and discovered nodes with
+// a bad reputation are not dialed at all.
 type dialScheduler struct {
 	dialConfig
 	setupFunc     dialSetupFunc
@@ -139,6 +146,8 @@ type dialConfig struct {
 	log            log.Logger
 	clock          mclock.Clock
 	rand           *mrand.Rand
+	reputation     *reputation.Tracker // peer reputations, disabled if nil
+	banlist        *banList            // banned nodes and networks, disabled if nil
 }
 
 func (cfg dialConfig) withDefaults() dialConfig {
@@ -234,6 +243,7 @@ loop:
 		// Launch new dials if slots are available.
 		slots := d.freeDialSlots()
 		slots -= d.startStaticDials(slots)
+		slots -= d.startReputableDials(slots)
 		if slots > 0 {
 			nodesCh = d.nodesIn
 		} else {
@@ -244,7 +254,7 @@ loop:
 
 		select {
 		case node := <-nodesCh:
-			if err := d.checkDial(node); err != nil {
+			if err := d.checkDynDial(node); err != nil {
 				d.log.Trace("Discarding dial candidate", "id", node.ID(), "ip", node.IPAddr(), "reason", err)
 			} else {
 				d.startDial(newDialTask(node, dynDialedConn))
@@ -393,12 +403,47 @@ func (d *dialScheduler) checkDial(n *enode.Node) error {
 	if d.netRestrict != nil && !d.netRestrict.ContainsAddr(n.IPAddr()) {
 		return errNetRestrict
 	}
+	if d.banlist.bannedNode(n) {
+		return errBanned
+	}
 	if d.history.contains(string(n.ID().Bytes())) {
 		return errRecentlyDialed
 	}
 	return nil
 }
 
+// checkDynDial returns an error if the discovered node n should not be dialed.
+func (d *dialScheduler) checkDynDial(n *enode.Node) error {
+	if err := d.checkDial(n); err != nil {
+		return err
+	}
+	if d.reputation != nil && d.reputation.Score(n.ID()) < reputation.MinDialScore {
+		return errLowReputation
+	}
+	return nil
+}
+
+// startReputableDials starts at most n dial tasks to the disconnected nodes with
+// the best reputation.
+func (d *dialScheduler) startReputableDials(n int) (started int) {
+	if d.reputation == nil || n <= 0 {
+		return 0
+	}
+	for _, node := range d.reputation.Candidates() {
+		if started >= n {
+			break
+		}
+		if _, ok := d.static[node.ID()]; ok {
+			continue
+		}
+		if d.checkDial(node) == nil {
+			d.startDial(newDialTask(node, dynDialedConn))
+			started++
+		}
+	}
+	return started
+}
+
 // startStaticDials starts n static dial tasks.
 func (d *dialScheduler) startStaticDials(n int) (started int) {
 	for started = 0; started < n && len(d.staticPool) > 0; started++ {
@@ -616,7 +661,11 @@ func (t *dialTask) dial(d *dialScheduler, dest *enode.Node) error {
 		dialConnectionError.Mark(1)
 		return &dialError{err}
 	}
-	return d.setupFunc(newMeteredConn(fd), t.flags, dest)
+	// The QUIC transport meters traffic of all its streams itself.
+	if _, ok := fd.(*quicConn); !ok {
+		fd = newMeteredConn(fd)
+	}
+	return d.setupFunc(fd, t.flags, dest)
 }
 
 func (t *dialTask) String() string {
@@ -33,6 +33,7 @@ import (
 	"github.com/ethereum/go-ethereum/log"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/netutil"
+	"github.com/ethereum/go-ethereum/p2p/reputation"
 )
 
 // This test checks that dynamic dials are launched from discovery results.
@@ -359,6 +360,93 @@ func TestDialSchedHistory(t *testing.T) {
 	})
 }
 
+// This test checks that nodes with a good reputation are dialed before discovered
+// nodes, and that nodes with a bad reputation are not dialed.
+func TestDialSchedReputation(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tracker = reputation.New(nil)
+		good    = []*enode.Node{
+			newNode(uintID(0x01), "127.0.0.1:30303"),
+			newNode(uintID(0x02), "127.0.0.2:30303"),
+		}
+		bad = newNode(uintID(0x03), "127.0.0.3:30303")
+	)
+	for _, n := range good {
+		tracker.Connected(n, true)
+		for i := 0; i < 10; i++ {
+			tracker.Peer(n.ID()).BlockPropagation(0)
+		}
+		tracker.Disconnected(n.ID())
+	}
+	tracker.Connected(bad, true)
+	tracker.Peer(bad.ID()).Invalid()
+	tracker.Disconnected(bad.ID())
+
+	config := dialConfig{
+		maxActiveDials: 3,
+		maxDialPeers:   10,
+		reputation:     tracker,
+	}
+	runDialTest(t, config, []dialTestRound{
+		// The good nodes take precedence, the bad one is discarded.
+		{
+			discovered: []*enode.Node{
+				bad,
+				newNode(uintID(0x04), "127.0.0.4:30303"),
+				newNode(uintID(0x05), "127.0.0.5:30303"),
+			},
+			wantNewDials: []*enode.Node{
+				good[0],
+				good[1],
+				newNode(uintID(0x04), "127.0.0.4:30303"),
+			},
+		},
+		// Once connected, the remaining discovered node is dialed.
+		{
+			succeeded: []enode.ID{
+				uintID(0x01),
+				uintID(0x02),
+				uintID(0x04),
+			},
+			wantNewDials: []*enode.Node{
+				newNode(uintID(0x05), "127.0.0.5:30303"),
+			},
+		},
+	})
+}
+
+// This test checks that banned nodes and networks are not dialed.
+func TestDialSchedBanned(t *testing.T) {
+	t.Parallel()
+
+	nodes := []*enode.Node{
+		newNode(uintID(0x01), "127.0.0.1:30303"),
+		newNode(uintID(0x02), "127.0.0.2:30303"),
+		newNode(uintID(0x03), "127.0.2.3:30303"),
+		newNode(uintID(0x04), "127.0.2.4:30303"),
+	}
+	config := dialConfig{
+		banlist:        newBanList(nil, time.Now),
+		maxActiveDials: 10,
+		maxDialPeers:   10,
+	}
+	config.banlist.ban(nodes[0].ID().String(), time.Hour, "test")
+	config.banlist.ban("127.0.2.0/24", 0, "test")
+	runDialTest(t, config, []dialTestRound{
+		{
+			discovered:   nodes,
+			wantNewDials: nodes[1:2],
+		},
+		{
+			succeeded: []enode.ID{
+				nodes[1].ID(),
+			},
+		},
+	})
+}
+
 func TestDialSchedResolve(t *testing.T) {
 	t.Parallel()
 
@@ -336,6 +336,25 @@ func TestTable_addFoundNode(t *testing.T) {
 }
 
 // This test checks that discv4 nodes can update their own endpoint via PING.
+func TestTable_addBannedNode(t *testing.T) {
+	var banned enode.ID
+	tab, db := newTestTable(newPingRecorder(), Config{
+		Banned: func(n *enode.Node) bool { return n.ID() == banned },
+	})
+	<-tab.initDone
+	defer db.Close()
+	defer tab.close()
+
+	// Insert two nodes, one of them banned.
+	n1 := nodeAtDistance(tab.self().ID(), 256, net.IP{88, 77, 66, 1})
+	n2 := nodeAtDistance(tab.self().ID(), 256, net.IP{88, 77, 66, 2})
+	banned = n2.ID()
+	tab.addFoundNode(n1, false)
+	tab.addFoundNode(n2, false)
+	tab.addInboundNode(n2)
+	checkBucketContent(t, tab, []*enode.Node{n1})
+}
+
 func TestTable_addInboundNodeUpdateV4Accept(t *testing.T) {
 	tab, db := newTestTable(newPingRecorder(), Config{})
 	<-tab.initDone
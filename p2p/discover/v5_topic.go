@@ -0,0 +1,515 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"math/rand"
+	"net"
+	"net/netip"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/p2p/netutil"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// Topic advertisement is implemented as a TALKREQ sub-protocol. Nodes which want to be
+// found under a topic register 'ads' with the nodes closest to the topic hash in the
+// DHT. Searchers walk towards the topic hash and ask every node they encounter for the
+// ads it stores. Nodes which don't know about the protocol simply answer with an empty
+// TALKRESP, which is treated as a rejection or an empty result.
+const (
+	topicProtocol = "topic"
+
+	topicAdLifetime       = 15 * time.Minute // how long registrars keep an ad
+	topicRegisterInterval = 5 * time.Minute  // how often ads are refreshed
+	topicRegisterRetry    = 30 * time.Second // retry delay when no registrar accepted
+	topicRegistrarCount   = 8                // number of registrars per topic
+	topicQueryInterval    = 30 * time.Second // min. time between queries to a registrar
+	maxTopicAdsPerTopic   = 100              // ads stored per topic
+	maxTopicAdsTotal      = 5000             // ads stored across all topics
+	maxTopicResponseBytes = 1000             // size limit of records in a query response
+
+	topicIPLimit, topicSubnet = 5, 24 // at most 5 ads per topic from the same /24
+)
+
+// Message kinds of the topic protocol.
+const (
+	topicRegisterMsg = iota
+	topicQueryMsg
+)
+
+var errTopicRejected = errors.New("topic registration rejected")
+
+// Topic identifies a topic under which nodes can be advertised.
+type Topic [32]byte
+
+// NewTopic creates a topic from its name.
+func NewTopic(name string) Topic {
+	return Topic(crypto.Keccak256Hash([]byte(name)))
+}
+
+// String returns the topic in hex.
+func (t Topic) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// topicRequest is the message sent in TALKREQ for the topic protocol.
+type topicRequest struct {
+	Kind  uint
+	Topic Topic
+}
+
+// topicRegisterResponse is the reply to a registration. A zero lifetime
+// signals that the ad was rejected.
+type topicRegisterResponse struct {
+	Lifetime uint64 // in seconds
+}
+
+// topicQueryResponse is the reply to a topic query.
+type topicQueryResponse struct {
+	Nodes []*enr.Record
+}
+
+// topicSystem holds the registrar and registrant side of topic advertisement.
+type topicSystem struct {
+	transport *UDPv5
+	table     *topicTable
+
+	mutex sync.Mutex
+	regs  map[Topic]context.CancelFunc
+}
+
+func newTopicSystem(transport *UDPv5) *topicSystem {
+	return &topicSystem{
+		transport: transport,
+		table:     newTopicTable(transport.clock),
+		regs:      make(map[Topic]context.CancelFunc),
+	}
+}
+
+// handleTalk is the talk handler of the topic protocol.
+func (ts *topicSystem) handleTalk(n *enode.Node, addr *net.UDPAddr, msg []byte) []byte {
+	var req topicRequest
+	if err := rlp.DecodeBytes(msg, &req); err != nil {
+		ts.transport.log.Debug("Invalid topic request", "id", n.ID(), "addr", addr, "err", err)
+		return nil
+	}
+	switch req.Kind {
+	case topicRegisterMsg:
+		var resp topicRegisterResponse
+		if _, ok := n.UDPEndpoint(); ok && ts.table.add(req.Topic, n, netutil.IPToAddr(addr.IP)) {
+			resp.Lifetime = uint64(topicAdLifetime / time.Second)
+		}
+		enc, _ := rlp.EncodeToBytes(&resp)
+		return enc
+	case topicQueryMsg:
+		var resp topicQueryResponse
+		size := uint64(0)
+		for _, ad := range ts.table.nodes(req.Topic) {
+			if ad.ID() == n.ID() {
+				continue
+			}
+			r := ad.Record()
+			if size += r.Size(); size > maxTopicResponseBytes {
+				break
+			}
+			resp.Nodes = append(resp.Nodes, r)
+		}
+		enc, _ := rlp.EncodeToBytes(&resp)
+		return enc
+	default:
+		return nil
+	}
+}
+
+// register starts the registration loop for a topic.
+func (ts *topicSystem) register(topic Topic) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	if _, ok := ts.regs[topic]; ok {
+		return
+	}
+	ctx, cancel := context.WithCancel(ts.transport.closeCtx)
+	ts.regs[topic] = cancel
+	ts.transport.wg.Add(1)
+	go ts.registerLoop(ctx, topic)
+}
+
+// unregister stops the registration loop for a topic. Existing ads are not
+// withdrawn, they simply expire on the registrars.
+func (ts *topicSystem) unregister(topic Topic) {
+	ts.mutex.Lock()
+	defer ts.mutex.Unlock()
+
+	if cancel, ok := ts.regs[topic]; ok {
+		cancel()
+		delete(ts.regs, topic)
+	}
+}
+
+// registerLoop periodically places ads for the local node on the registrars
+// closest to the topic.
+func (ts *topicSystem) registerLoop(ctx context.Context, topic Topic) {
+	defer ts.transport.wg.Done()
+
+	for {
+		delay := topicRegisterInterval
+		if ts.registerOnce(ctx, topic) == 0 {
+			delay = topicRegisterRetry
+		}
+		select {
+		case <-ts.transport.clock.After(delay):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// registerOnce sends the registration to the nodes closest to the topic and
+// returns the number of registrars that accepted it.
+func (ts *topicSystem) registerOnce(ctx context.Context, topic Topic) int {
+	t := ts.transport
+	nodes := t.newLookup(ctx, enode.ID(topic)).run()
+	if len(nodes) > topicRegistrarCount {
+		nodes = nodes[:topicRegistrarCount]
+	}
+	var (
+		wg       sync.WaitGroup
+		accepted atomic.Int32
+	)
+	for _, n := range nodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := t.topicRegister(n, topic); err != nil {
+				t.log.Trace("Topic registration failed", "topic", topic, "id", n.ID(), "err", err)
+				return
+			}
+			accepted.Add(1)
+		}()
+	}
+	wg.Wait()
+	t.log.Debug("Registered topic", "topic", topic, "registrars", len(nodes), "accepted", accepted.Load())
+	return int(accepted.Load())
+}
+
+// RegisterTopic starts advertising the local node under the given topic. Ads are
+// placed on the nodes closest to the topic and refreshed periodically until
+// StopRegisterTopic is called or the transport is closed.
+func (t *UDPv5) RegisterTopic(topic Topic) {
+	t.topics.register(topic)
+}
+
+// StopRegisterTopic stops advertising the local node under the given topic.
+func (t *UDPv5) StopRegisterTopic(topic Topic) {
+	t.topics.unregister(topic)
+}
+
+// TopicNodes returns an iterator that finds nodes advertised under the given topic.
+func (t *UDPv5) TopicNodes(topic Topic) enode.Iterator {
+	lookup := newLookupIterator(t.closeCtx, func(ctx context.Context) *lookup {
+		return t.newLookup(ctx, enode.ID(topic))
+	})
+	return &topicIterator{
+		transport: t,
+		topic:     topic,
+		lookup:    lookup,
+		queried:   make(map[enode.ID]mclock.AbsTime),
+	}
+}
+
+// topicRegister places an ad for the local node on n.
+func (t *UDPv5) topicRegister(n *enode.Node, topic Topic) error {
+	req, _ := rlp.EncodeToBytes(&topicRequest{Kind: topicRegisterMsg, Topic: topic})
+	respMsg, err := t.TalkRequest(n, topicProtocol, req)
+	if err != nil {
+		return err
+	}
+	var resp topicRegisterResponse
+	if len(respMsg) == 0 {
+		return errTopicRejected
+	}
+	if err := rlp.DecodeBytes(respMsg, &resp); err != nil {
+		return err
+	}
+	if resp.Lifetime == 0 {
+		return errTopicRejected
+	}
+	return nil
+}
+
+// topicQuery asks n for the nodes it stores under the given topic.
+func (t *UDPv5) topicQuery(n *enode.Node, topic Topic) ([]*enode.Node, error) {
+	req, _ := rlp.EncodeToBytes(&topicRequest{Kind: topicQueryMsg, Topic: topic})
+	respMsg, err := t.TalkRequest(n, topicProtocol, req)
+	if err != nil || len(respMsg) == 0 {
+		return nil, err
+	}
+	var resp topicQueryResponse
+	if err := rlp.DecodeBytes(respMsg, &resp); err != nil {
+		return nil, err
+	}
+	var (
+		addr, _ = n.UDPEndpoint()
+		c       = &callV5{id: n.ID(), addr: addr, node: n}
+		seen    = make(map[enode.ID]struct{})
+		nodes   []*enode.Node
+	)
+	for _, record := range resp.Nodes {
+		node, err := t.verifyResponseNode(c, record, nil, seen)
+		if err != nil {
+			t.log.Debug("Invalid record in topic response", "id", n.ID(), "err", err)
+			continue
+		}
+		if node.ID() != t.Self().ID() {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+// topicIterator walks towards the topic in the DHT and queries every node it
+// encounters for ads.
+type topicIterator struct {
+	transport *UDPv5
+	topic     Topic
+	lookup    *lookupIterator
+	buffer    []*enode.Node
+	queried   map[enode.ID]mclock.AbsTime
+}
+
+// Node returns the current node.
+func (it *topicIterator) Node() *enode.Node {
+	if len(it.buffer) == 0 {
+		return nil
+	}
+	return it.buffer[0]
+}
+
+// Next moves to the next node.
+func (it *topicIterator) Next() bool {
+	if len(it.buffer) > 0 {
+		it.buffer = it.buffer[1:]
+	}
+	for len(it.buffer) == 0 {
+		if !it.lookup.Next() {
+			it.buffer = nil
+			return false
+		}
+		reg := it.lookup.Node()
+		now := it.transport.clock.Now()
+		if last, ok := it.queried[reg.ID()]; ok && now.Sub(last) < topicQueryInterval {
+			continue
+		}
+		it.expireQueried(now)
+		it.queried[reg.ID()] = now
+		it.buffer, _ = it.transport.topicQuery(reg, it.topic)
+	}
+	return true
+}
+
+// expireQueried removes registrars which can be queried again.
+func (it *topicIterator) expireQueried(now mclock.AbsTime) {
+	for id, last := range it.queried {
+		if now.Sub(last) >= topicQueryInterval {
+			delete(it.queried, id)
+		}
+	}
+}
+
+// Close ends the iterator.
+func (it *topicIterator) Close() {
+	it.lookup.Close()
+}
+
+// topicTable stores the ads of a registrar. The number of ads per topic from the
+// same subnet is limited, and once the table is full, new ads replace the ones
+// registered first, so early registrants can't hold their place by refreshing.
+type topicTable struct {
+	clock mclock.Clock
+
+	mutex  sync.Mutex
+	topics map[Topic]*topicAds
+	count  int
+}
+
+// topicAds are the ads of a single topic.
+type topicAds struct {
+	ads map[enode.ID]*topicAd
+	ips netutil.DistinctNetSet
+}
+
+type topicAd struct {
+	node       *enode.Node
+	ip         netip.Addr
+	registered mclock.AbsTime
+	expires    mclock.AbsTime
+}
+
+func newTopicTable(clock mclock.Clock) *topicTable {
+	return &topicTable{
+		clock:  clock,
+		topics: make(map[Topic]*topicAds),
+	}
+}
+
+// add stores or refreshes an ad for n, registered from the given IP. It returns
+// false if the subnet of the IP already has too many ads for the topic.
+func (tt *topicTable) add(topic Topic, n *enode.Node, ip netip.Addr) bool {
+	tt.mutex.Lock()
+	defer tt.mutex.Unlock()
+
+	now := tt.clock.Now()
+	tt.expireTopic(topic, now)
+	ta := tt.topics[topic]
+	if ta == nil {
+		ta = &topicAds{
+			ads: make(map[enode.ID]*topicAd),
+			ips: netutil.DistinctNetSet{Subnet: topicSubnet, Limit: topicIPLimit},
+		}
+		tt.topics[topic] = ta
+	}
+	if ad := ta.ads[n.ID()]; ad != nil {
+		if ad.ip != ip {
+			ta.removeIP(ad.ip)
+			if !ta.addIP(ip) {
+				tt.remove(topic, n.ID())
+				return false
+			}
+			ad.ip = ip
+		}
+		ad.node = n
+		ad.expires = now.Add(topicAdLifetime)
+		return true
+	}
+	if !ta.addIP(ip) {
+		if len(ta.ads) == 0 {
+			delete(tt.topics, topic)
+		}
+		return false
+	}
+	if tt.count >= maxTopicAdsTotal {
+		for t := range tt.topics {
+			tt.expireTopic(t, now)
+		}
+	}
+	// Make room by evicting the oldest ad of the topic, or of any topic if the
+	// whole table is full.
+	if len(ta.ads) >= maxTopicAdsPerTopic {
+		tt.evictOldest(topic)
+	} else if tt.count >= maxTopicAdsTotal {
+		tt.evictOldest()
+	}
+	tt.topics[topic] = ta // eviction may have removed the topic
+	ta.ads[n.ID()] = &topicAd{node: n, ip: ip, registered: now, expires: now.Add(topicAdLifetime)}
+	tt.count++
+	return true
+}
+
+// evictOldest removes the ad registered first among the ads of the given topics,
+// or of all topics if none are given.
+func (tt *topicTable) evictOldest(topics ...Topic) {
+	if len(topics) == 0 {
+		for t := range tt.topics {
+			topics = append(topics, t)
+		}
+	}
+	var (
+		oldest      *topicAd
+		oldestTopic Topic
+	)
+	for _, t := range topics {
+		for _, ad := range tt.topics[t].ads {
+			if oldest == nil || ad.registered < oldest.registered {
+				oldest, oldestTopic = ad, t
+			}
+		}
+	}
+	if oldest != nil {
+		tt.remove(oldestTopic, oldest.node.ID())
+	}
+}
+
+// remove deletes the ad of a node.
+func (tt *topicTable) remove(topic Topic, id enode.ID) {
+	ta := tt.topics[topic]
+	ta.removeIP(ta.ads[id].ip)
+	delete(ta.ads, id)
+	tt.count--
+	if len(ta.ads) == 0 {
+		delete(tt.topics, topic)
+	}
+}
+
+// nodes returns the live ads for a topic in random order.
+func (tt *topicTable) nodes(topic Topic) []*enode.Node {
+	tt.mutex.Lock()
+	defer tt.mutex.Unlock()
+
+	tt.expireTopic(topic, tt.clock.Now())
+	ta := tt.topics[topic]
+	if ta == nil {
+		return nil
+	}
+	nodes := make([]*enode.Node, 0, len(ta.ads))
+	for _, ad := range ta.ads {
+		nodes = append(nodes, ad.node)
+	}
+	rand.Shuffle(len(nodes), func(i, j int) { nodes[i], nodes[j] = nodes[j], nodes[i] })
+	return nodes
+}
+
+// expireTopic removes expired ads of a topic.
+func (tt *topicTable) expireTopic(topic Topic, now mclock.AbsTime) {
+	ta := tt.topics[topic]
+	if ta == nil {
+		return
+	}
+	for id, ad := range ta.ads {
+		if now >= ad.expires {
+			tt.remove(topic, id)
+		}
+	}
+}
+
+// addIP accounts for an ad registered from ip. LAN addresses are not limited.
+func (ta *topicAds) addIP(ip netip.Addr) bool {
+	if !ip.IsValid() || ip.IsUnspecified() {
+		return false
+	}
+	if netutil.AddrIsLAN(ip) {
+		return true
+	}
+	return ta.ips.AddAddr(ip)
+}
+
+func (ta *topicAds) removeIP(ip netip.Addr) {
+	if netutil.AddrIsLAN(ip) {
+		return
+	}
+	ta.ips.RemoveAddr(ip)
+}
@@ -0,0 +1,226 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package discover
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/mclock"
+	"github.com/ethereum/go-ethereum/p2p/discover/v5wire"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func TestTopicTable(t *testing.T) {
+	var (
+		clock  = new(mclock.Simulated)
+		tab    = newTopicTable(clock)
+		topic  = NewTopic("test")
+		other  = NewTopic("other")
+		first  = nodeAtDistance(enode.ID{}, 256, intIP(1))
+		second = nodeAtDistance(enode.ID{}, 256, intIP(2))
+	)
+	if !tab.add(topic, first, first.IPAddr()) {
+		t.Fatal("first ad rejected")
+	}
+	clock.Run(topicAdLifetime / 2)
+	if !tab.add(topic, second, second.IPAddr()) {
+		t.Fatal("second ad rejected")
+	}
+	if n := len(tab.nodes(topic)); n != 2 {
+		t.Fatalf("wrong number of ads: %d, want 2", n)
+	}
+	if n := len(tab.nodes(other)); n != 0 {
+		t.Fatalf("wrong number of ads for other topic: %d, want 0", n)
+	}
+
+	// Refresh the first ad, the second one should expire before it.
+	clock.Run(topicAdLifetime / 4)
+	if !tab.add(topic, first, first.IPAddr()) {
+		t.Fatal("refresh rejected")
+	}
+	clock.Run(topicAdLifetime * 3 / 4)
+	nodes := tab.nodes(topic)
+	if len(nodes) != 1 || nodes[0].ID() != first.ID() {
+		t.Fatalf("wrong ads after expiry: %v", nodes)
+	}
+	clock.Run(topicAdLifetime)
+	if n := len(tab.nodes(topic)); n != 0 {
+		t.Fatalf("wrong number of ads after expiry: %d, want 0", n)
+	}
+	if tab.count != 0 {
+		t.Fatalf("wrong ad count after expiry: %d", tab.count)
+	}
+
+	// Once the topic is full, new ads replace the one registered first, even if
+	// it was refreshed meanwhile.
+	var ads []*enode.Node
+	for i := 0; i < maxTopicAdsPerTopic; i++ {
+		n := nodeAtDistance(enode.ID{}, 256, intIP(i))
+		if !tab.add(topic, n, n.IPAddr()) {
+			t.Fatalf("ad %d rejected", i)
+		}
+		ads = append(ads, n)
+		clock.Run(time.Second)
+	}
+	if !tab.add(topic, ads[0], ads[0].IPAddr()) {
+		t.Fatal("refresh rejected")
+	}
+	late := nodeAtDistance(enode.ID{}, 256, intIP(maxTopicAdsPerTopic))
+	if !tab.add(topic, late, late.IPAddr()) {
+		t.Fatal("ad rejected beyond per-topic limit")
+	}
+	nodes = tab.nodes(topic)
+	if len(nodes) != maxTopicAdsPerTopic {
+		t.Fatalf("wrong number of ads: %d, want %d", len(nodes), maxTopicAdsPerTopic)
+	}
+	if slices.ContainsFunc(nodes, func(n *enode.Node) bool { return n.ID() == ads[0].ID() }) {
+		t.Fatal("oldest ad not evicted")
+	}
+	if !tab.add(other, first, first.IPAddr()) {
+		t.Fatal("ad for other topic rejected")
+	}
+}
+
+func TestTopicTableSubnetLimit(t *testing.T) {
+	var (
+		tab   = newTopicTable(new(mclock.Simulated))
+		topic = NewTopic("test")
+		other = NewTopic("other")
+	)
+	for i := 0; i < topicIPLimit; i++ {
+		n := nodeAtDistance(enode.ID{}, 256, net.IP{1, 2, 3, byte(i)})
+		if !tab.add(topic, n, n.IPAddr()) {
+			t.Fatalf("ad %d rejected", i)
+		}
+	}
+	n := nodeAtDistance(enode.ID{}, 256, net.IP{1, 2, 3, 100})
+	if tab.add(topic, n, n.IPAddr()) {
+		t.Fatal("ad accepted beyond subnet limit")
+	}
+	if !tab.add(other, n, n.IPAddr()) {
+		t.Fatal("ad for other topic rejected")
+	}
+	// The limit applies to the address the registration came from, not to the
+	// address in the record.
+	if tab.add(topic, n, netip.MustParseAddr("1.2.3.200")) {
+		t.Fatal("ad accepted beyond subnet limit")
+	}
+	if !tab.add(topic, n, netip.MustParseAddr("1.2.4.1")) {
+		t.Fatal("ad from other subnet rejected")
+	}
+}
+
+func TestUDPv5_topicHandling(t *testing.T) {
+	t.Parallel()
+	test := newUDPV5Test(t)
+	defer test.close()
+
+	topic := NewTopic("test")
+	remote := test.getNode(test.remotekey, test.remoteaddr).Node()
+
+	// Register the remote node.
+	req, _ := rlp.EncodeToBytes(&topicRequest{Kind: topicRegisterMsg, Topic: topic})
+	test.packetIn(&v5wire.TalkRequest{ReqID: []byte("reg"), Protocol: topicProtocol, Message: req})
+	test.waitPacketOut(func(p *v5wire.TalkResponse, addr netip.AddrPort, _ v5wire.Nonce) {
+		var resp topicRegisterResponse
+		if err := rlp.DecodeBytes(p.Message, &resp); err != nil {
+			t.Fatal("invalid register response:", err)
+		}
+		if resp.Lifetime != uint64(topicAdLifetime/time.Second) {
+			t.Errorf("wrong lifetime %d", resp.Lifetime)
+		}
+	})
+
+	// The registrant should not get its own ad back.
+	req, _ = rlp.EncodeToBytes(&topicRequest{Kind: topicQueryMsg, Topic: topic})
+	test.packetIn(&v5wire.TalkRequest{ReqID: []byte("q1"), Protocol: topicProtocol, Message: req})
+	test.waitPacketOut(func(p *v5wire.TalkResponse, addr netip.AddrPort, _ v5wire.Nonce) {
+		var resp topicQueryResponse
+		if err := rlp.DecodeBytes(p.Message, &resp); err != nil {
+			t.Fatal("invalid query response:", err)
+		}
+		if len(resp.Nodes) != 0 {
+			t.Errorf("registrant got %d ads, want none", len(resp.Nodes))
+		}
+	})
+
+	// Another node should find it.
+	searchKey := newkey()
+	searchAddr := netip.MustParseAddrPort("10.0.1.100:30303")
+	test.packetInFrom(searchKey, searchAddr, &v5wire.TalkRequest{ReqID: []byte("q2"), Protocol: topicProtocol, Message: req})
+	test.waitPacketOut(func(p *v5wire.TalkResponse, addr netip.AddrPort, _ v5wire.Nonce) {
+		var resp topicQueryResponse
+		if err := rlp.DecodeBytes(p.Message, &resp); err != nil {
+			t.Fatal("invalid query response:", err)
+		}
+		if len(resp.Nodes) != 1 {
+			t.Fatalf("got %d ads, want 1", len(resp.Nodes))
+		}
+		n, err := enode.New(enode.ValidSchemesForTesting, resp.Nodes[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n.ID() != remote.ID() {
+			t.Errorf("wrong node in response: %v", n.ID())
+		}
+	})
+}
+
+func TestUDPv5_topicE2E(t *testing.T) {
+	t.Parallel()
+
+	const N = 5
+	var nodes []*UDPv5
+	for i := 0; i < N; i++ {
+		var cfg Config
+		if len(nodes) > 0 {
+			cfg.Bootnodes = []*enode.Node{nodes[0].Self()}
+		}
+		node := startLocalhostV5(t, cfg)
+		nodes = append(nodes, node)
+		defer node.Close()
+	}
+	var (
+		topic      = NewTopic("test")
+		registrant = nodes[N-1]
+		searcher   = nodes[N-2]
+	)
+	<-registrant.tab.initDone
+	if n := registrant.topics.registerOnce(context.Background(), topic); n == 0 {
+		t.Fatal("no registrar accepted the ad")
+	}
+
+	it := searcher.TopicNodes(topic)
+	defer it.Close()
+	timeout := time.AfterFunc(10*time.Second, it.Close)
+	defer timeout.Stop()
+	for it.Next() {
+		if it.Node().ID() == registrant.Self().ID() {
+			return
+		}
+		if it.Node().ID() == searcher.Self().ID() {
+			t.Fatal("searcher found itself")
+		}
+	}
+	t.Fatal("registrant not found")
+}
@@ -89,6 +89,9 @@ type UDPv5 struct {
 	// talkreq handler registry
 	talk *talkSystem
 
+	// topic advertisement and search
+	topics *topicSystem
+
 	// channels into dispatch
 	packetInCh    chan ReadPacket
 	readNextCh    chan struct{}
@@ -188,6 +191,8 @@ func newUDPv5(conn UDPConn, ln *enode.LocalNode, cfg Config) (*UDPv5, error) {
 		cancelCloseCtx: cancelCloseCtx,
 	}
 	t.talk = newTalkSystem(t)
+	t.topics = newTopicSystem(t)
+	t.talk.register(topicProtocol, t.topics.handleTalk)
 	tab, err := newTable(t, t.db, cfg)
 	if err != nil {
 		return nil, err
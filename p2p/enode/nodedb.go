@@ -40,6 +40,8 @@ import (
 const (
 	dbVersionKey   = "version" // Version of the database to flush if changes
 	dbNodePrefix   = "n:"      // Identifier to prefix node entries with
+	dbRepPrefix    = "rep:"    // Identifier to prefix reputation records with
+	dbBanPrefix    = "ban:"    // Identifier to prefix peer bans with
 	dbLocalPrefix  = "local:"
 	dbDiscoverRoot = "v4"
 	dbDiscv5Root   = "v5"
@@ -194,6 +196,12 @@ func localItemKey(id ID, field string) []byte {
 	return key
 }
 
+// reputationKey returns the key of a node reputation record. These are kept
+// separate from the discovery entries to survive the expiration of the latter.
+func reputationKey(id ID) []byte {
+	return append([]byte(dbRepPrefix), id[:]...)
+}
+
 // fetchInt64 retrieves an integer associated with a particular key.
 func (db *DB) fetchInt64(key []byte) int64 {
 	blob, err := db.lvl.Get(key, nil)
@@ -427,6 +435,66 @@ func (db *DB) UpdateFindFailsV5(id ID, ip netip.Addr, fails int) error {
 	return db.storeInt64(v5Key(id, ip, dbNodeFindFails), int64(fails))
 }
 
+// NodeReputation retrieves the persisted reputation record of a node.
+func (db *DB) NodeReputation(id ID) []byte {
+	blob, err := db.lvl.Get(reputationKey(id), nil)
+	if err != nil {
+		return nil
+	}
+	return blob
+}
+
+// UpdateNodeReputation stores the reputation record of a node.
+func (db *DB) UpdateNodeReputation(id ID, blob []byte) error {
+	return db.lvl.Put(reputationKey(id), blob, nil)
+}
+
+// DeleteNodeReputation deletes the reputation record of a node.
+func (db *DB) DeleteNodeReputation(id ID) {
+	db.lvl.Delete(reputationKey(id), nil)
+}
+
+// NodeReputations iterates over all the persisted reputation records, invoking
+// the callback for each of them until it returns false.
+func (db *DB) NodeReputations(fn func(id ID, blob []byte) bool) {
+	it := db.lvl.NewIterator(util.BytesPrefix([]byte(dbRepPrefix)), nil)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()[len(dbRepPrefix):]
+		if len(key) != len(ID{}) {
+			continue
+		}
+		if !fn(ID(key), bytes.Clone(it.Value())) {
+			return
+		}
+	}
+}
+
+// UpdateBan stores a ban entry of the given target, which may be a node or a
+// network identifier.
+func (db *DB) UpdateBan(target string, blob []byte) error {
+	return db.lvl.Put([]byte(dbBanPrefix+target), blob, nil)
+}
+
+// DeleteBan deletes the ban entry of the given target.
+func (db *DB) DeleteBan(target string) {
+	db.lvl.Delete([]byte(dbBanPrefix+target), nil)
+}
+
+// Bans iterates over all the persisted ban entries, invoking the callback for
+// each of them until it returns false.
+func (db *DB) Bans(fn func(target string, blob []byte) bool) {
+	it := db.lvl.NewIterator(util.BytesPrefix([]byte(dbBanPrefix)), nil)
+	defer it.Release()
+
+	for it.Next() {
+		if !fn(string(it.Key()[len(dbBanPrefix):]), bytes.Clone(it.Value())) {
+			return
+		}
+	}
+}
+
 // localSeq retrieves the local record sequence counter, defaulting to the current
 // timestamp if no previous exists. This ensures that wiping all data associated
 // with a node (apart from its key) will not generate already used sequence nums.
@@ -470,3 +470,68 @@ func TestDBExpireV5(t *testing.T) {
 	db.UpdateFindFailsV5(ID{}, ip, 4)
 	db.expireNodes()
 }
+
+// This test checks that reputation records can be stored, iterated and deleted,
+// and that they are not affected by the expiration of discovery entries.
+func TestDBReputation(t *testing.T) {
+	db, _ := OpenDB("")
+	defer db.Close()
+
+	var (
+		ip   = netip.MustParseAddr("127.0.0.1")
+		ids  = []ID{{0x01}, {0x02}, {0x03}}
+		blob = func(i int) []byte { return []byte{byte(i), 0xff} }
+	)
+	for i, id := range ids {
+		if err := db.UpdateNodeReputation(id, blob(i)); err != nil {
+			t.Fatalf("node %d: failed to store reputation: %v", i, err)
+		}
+		db.UpdateLastPongReceived(id, ip, time.Now().Add(-2*dbNodeExpiration))
+	}
+	db.expireNodes()
+	db.DeleteNodeReputation(ids[1])
+
+	if have := db.NodeReputation(ids[0]); !bytes.Equal(have, blob(0)) {
+		t.Errorf("reputation mismatch: have %x, want %x", have, blob(0))
+	}
+	if have := db.NodeReputation(ids[1]); have != nil {
+		t.Errorf("deleted reputation still present: %x", have)
+	}
+	have := make(map[ID][]byte)
+	db.NodeReputations(func(id ID, blob []byte) bool {
+		have[id] = blob
+		return true
+	})
+	want := map[ID][]byte{ids[0]: blob(0), ids[2]: blob(2)}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("iterated reputations mismatch: have %x, want %x", have, want)
+	}
+}
+
+// This test checks that ban entries can be stored, iterated and deleted.
+func TestDBBans(t *testing.T) {
+	db, _ := OpenDB("")
+	defer db.Close()
+
+	want := map[string][]byte{
+		"10.0.0.0/8":      {0x01},
+		"192.168.1.10/32": {0x02},
+		ID{0x01}.String(): {0x03},
+	}
+	for target, blob := range want {
+		if err := db.UpdateBan(target, blob); err != nil {
+			t.Fatalf("failed to store ban of %s: %v", target, err)
+		}
+	}
+	db.UpdateBan("10.1.0.0/16", []byte{0x04})
+	db.DeleteBan("10.1.0.0/16")
+
+	have := make(map[string][]byte)
+	db.Bans(func(target string, blob []byte) bool {
+		have[target] = blob
+		return true
+	})
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("iterated bans mismatch: have %x, want %x", have, want)
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/p2p/reputation"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
@@ -115,8 +116,13 @@ type Peer struct {
 	pingRecv chan struct{}
 	disc     chan DiscReason
 
+	// reputation tracks the behaviour of the peer if enabled
+	reputation reputation.Handle
+
 	// events receives message send / receive events if set
-	events   *event.Feed
+	events *event.Feed
+	// recorder writes the protocol messages to disk if set
+	recorder *msgRecorder
 	testPipe *MsgPipeRW // for testing
 }
 
@@ -138,6 +144,13 @@ func NewPeer(id enode.ID, name string, caps []Cap) *Peer {
 	return peer
 }
 
+// NewTrustedPeer returns a peer configured as trusted for testing purposes.
+func NewTrustedPeer(id enode.ID, name string, caps []Cap) *Peer {
+	peer := NewPeer(id, name, caps)
+	peer.rw.set(trustedConn, true)
+	return peer
+}
+
 // NewPeerPipe creates a peer for testing purposes.
 // The message pipe given as the last parameter is closed when
 // Disconnect is called on the peer.
@@ -243,6 +256,12 @@ func (p *Peer) StaticDialed() bool {
 	return p.rw.is(staticDialedConn)
 }
 
+// Reputation returns the handle through which protocols can report the behaviour
+// of the peer, and query its reputation score.
+func (p *Peer) Reputation() reputation.Handle {
+	return p.reputation
+}
+
 // Lifetime returns the time since peer creation.
 func (p *Peer) Lifetime() mclock.AbsTime {
 	return mclock.Now() - p.created
@@ -466,6 +485,9 @@ func (p *Peer) startProtocols(writeStart <-chan struct{}, writeErr chan<- error)
 		proto.wstart = writeStart
 		proto.werr = writeErr
 		var rw MsgReadWriter = proto
+		if p.recorder != nil {
+			rw = newMsgRecordRW(rw, p.recorder, p.ID(), proto.cap())
+		}
 		if p.events != nil {
 			rw = newMsgEventer(rw, p.events, p.ID(), proto.Name, p.Info().Network.RemoteAddress, p.Info().Network.LocalAddress)
 		}
@@ -492,7 +514,7 @@ func (p *Peer) getProto(code uint64) (*protoRW, error) {
 			return proto, nil
 		}
 	}
-	return nil, newPeerError(errInvalidMsgCode, "%d", code)
+	return nil, newMalformedError(errInvalidMsgCode, "%d", code)
 }
 
 type protoRW struct {
@@ -554,7 +576,8 @@ type PeerInfo struct {
 		Trusted       bool   `json:"trusted"`
 		Static        bool   `json:"static"`
 	} `json:"network"`
-	Protocols map[string]interface{} `json:"protocols"` // Sub-protocol specific metadata fields
+	Reputation *reputation.Info       `json:"reputation,omitempty"` // Behaviour of the peer as observed by the local node
+	Protocols  map[string]interface{} `json:"protocols"`            // Sub-protocol specific metadata fields
 }
 
 // Info gathers and returns a collection of metadata known about a peer.
@@ -566,11 +589,12 @@ func (p *Peer) Info() *PeerInfo {
 	}
 	// Assemble the generic peer metadata
 	info := &PeerInfo{
-		Enode:     p.Node().URLv4(),
-		ID:        p.ID().String(),
-		Name:      p.Fullname(),
-		Caps:      caps,
-		Protocols: make(map[string]interface{}, len(p.running)),
+		Enode:      p.Node().URLv4(),
+		ID:         p.ID().String(),
+		Name:       p.Fullname(),
+		Caps:       caps,
+		Reputation: p.reputation.Info(),
+		Protocols:  make(map[string]interface{}, len(p.running)),
 	}
 	if p.Node().Seq() > 0 {
 		info.ENR = p.Node().String()
@@ -32,8 +32,9 @@ var errorToString = map[int]string{
 }
 
 type peerError struct {
-	code    int
-	message string
+	code      int
+	message   string
+	malformed bool // raised for a message received from the remote peer
 }
 
 func newPeerError(code int, format string, v ...interface{}) *peerError {
@@ -41,17 +42,41 @@ func newPeerError(code int, format string, v ...interface{}) *peerError {
 	if !ok {
 		panic("invalid error code")
 	}
-	err := &peerError{code, desc}
+	err := &peerError{code: code, message: desc}
 	if format != "" {
 		err.message += ": " + fmt.Sprintf(format, v...)
 	}
 	return err
 }
 
+// newMalformedError creates a peer error for a message received from the remote
+// peer which cannot be handled.
+func newMalformedError(code int, format string, v ...interface{}) *peerError {
+	err := newPeerError(code, format, v...)
+	err.malformed = true
+	return err
+}
+
 func (pe *peerError) Error() string {
 	return pe.message
 }
 
+// Unwrap marks the peer errors raised for messages received from the remote peer
+// as malformed messages. Errors caused locally, like writing a message with an
+// invalid code, are not.
+func (pe *peerError) Unwrap() error {
+	if pe.malformed {
+		return ErrMalformedMessage
+	}
+	return nil
+}
+
+// ErrMalformedMessage is the sentinel for errors caused by the remote peer sending
+// a message which was locally detected to be malformed, such as an unknown message
+// code or an undecodable payload. Protocols should wrap it into such errors, as
+// these are the only ones leading to the remote peer being banned automatically.
+var ErrMalformedMessage = errors.New("malformed message")
+
 var errProtocolReturned = errors.New("protocol returned")
 
 type DiscReason uint8
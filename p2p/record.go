@@ -0,0 +1,253 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	recordFilePattern  = "msgs-*.rlp"
+	recordFileTime     = "20060102-150405.000000000"
+	maxRecordFileSize  = 64 * 1024 * 1024
+	maxRecordFiles     = 16
+	maxRecordReadBytes = 32 * 1024 * 1024 // limit for a single record in a file
+)
+
+// MsgRecord is a protocol message exchanged with a peer, as written by the
+// message recorder.
+type MsgRecord struct {
+	Time     uint64 // unix time in nanoseconds
+	Peer     enode.ID
+	Inbound  bool   // true for messages received from the peer
+	Protocol string // name of the protocol
+	Version  uint   // version of the protocol
+	Code     uint64 // message code relative to the protocol offset
+	Payload  []byte
+}
+
+// Msg returns the recorded message.
+func (r *MsgRecord) Msg() Msg {
+	return Msg{
+		Code:       r.Code,
+		Size:       uint32(len(r.Payload)),
+		Payload:    bytes.NewReader(r.Payload),
+		ReceivedAt: time.Unix(0, int64(r.Time)),
+	}
+}
+
+// MsgRecordReader reads recorded messages from a file.
+type MsgRecordReader struct {
+	s *rlp.Stream
+}
+
+// NewMsgRecordReader creates a reader for the recording in r.
+func NewMsgRecordReader(r io.Reader) *MsgRecordReader {
+	return &MsgRecordReader{rlp.NewStream(r, maxRecordReadBytes)}
+}
+
+// Next returns the next record. It returns io.EOF at the end of the recording.
+func (r *MsgRecordReader) Next() (*MsgRecord, error) {
+	var rec MsgRecord
+	if err := r.s.Decode(&rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// RecordFiles returns the recording files in the given directory, oldest first.
+func RecordFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, recordFilePattern))
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(files)
+	return files, nil
+}
+
+// msgRecorder writes protocol messages to rotating files.
+type msgRecorder struct {
+	dir      string
+	peers    map[enode.ID]bool // nil to record all peers
+	maxSize  int64
+	maxFiles int
+	log      log.Logger
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	failed bool
+}
+
+func newMsgRecorder(dir string, peers []enode.ID, logger log.Logger) (*msgRecorder, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	r := &msgRecorder{
+		dir:      dir,
+		maxSize:  maxRecordFileSize,
+		maxFiles: maxRecordFiles,
+		log:      logger,
+	}
+	if len(peers) > 0 {
+		r.peers = make(map[enode.ID]bool, len(peers))
+		for _, id := range peers {
+			r.peers[id] = true
+		}
+	}
+	return r, nil
+}
+
+// enabled reports whether messages of the given peer are recorded.
+func (r *msgRecorder) enabled(id enode.ID) bool {
+	return r.peers == nil || r.peers[id]
+}
+
+// record writes a message to the current file.
+func (r *msgRecorder) record(rec *MsgRecord) {
+	enc, err := rlp.EncodeToBytes(rec)
+	if err != nil {
+		panic(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		if err := r.rotate(); err != nil {
+			r.fail(err)
+			return
+		}
+	}
+	n, err := r.file.Write(enc)
+	r.size += int64(n)
+	if err != nil {
+		r.fail(err)
+		return
+	}
+	r.failed = false
+	if r.size >= r.maxSize {
+		r.file.Close()
+		r.file = nil
+	}
+}
+
+// fail logs a recording error. Subsequent errors are not logged until
+// a write succeeds again.
+func (r *msgRecorder) fail(err error) {
+	if !r.failed {
+		r.log.Warn("Failed to record p2p message", "err", err)
+	}
+	r.failed = true
+}
+
+// rotate opens a new recording file and removes the oldest ones.
+func (r *msgRecorder) rotate() error {
+	name := filepath.Join(r.dir, fmt.Sprintf("msgs-%s.rlp", time.Now().UTC().Format(recordFileTime)))
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	r.file, r.size = f, 0
+
+	files, err := RecordFiles(r.dir)
+	if err != nil {
+		return err
+	}
+	for len(files) > r.maxFiles {
+		if err := os.Remove(files[0]); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
+}
+
+func (r *msgRecorder) close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+}
+
+// msgRecordRW wraps the MsgReadWriter of a protocol and records all messages.
+type msgRecordRW struct {
+	MsgReadWriter
+
+	rec   *msgRecorder
+	peer  enode.ID
+	proto Cap
+}
+
+func newMsgRecordRW(rw MsgReadWriter, rec *msgRecorder, peer enode.ID, proto Cap) *msgRecordRW {
+	return &msgRecordRW{MsgReadWriter: rw, rec: rec, peer: peer, proto: proto}
+}
+
+// ReadMsg reads a message from the underlying MsgReadWriter and records it.
+func (rw *msgRecordRW) ReadMsg() (Msg, error) {
+	msg, err := rw.MsgReadWriter.ReadMsg()
+	if err != nil {
+		return msg, err
+	}
+	payload, err := io.ReadAll(msg.Payload)
+	if err != nil {
+		return msg, err
+	}
+	msg.Payload = bytes.NewReader(payload)
+	rw.record(true, msg.Code, payload)
+	return msg, nil
+}
+
+// WriteMsg writes a message to the underlying MsgReadWriter and records it.
+func (rw *msgRecordRW) WriteMsg(msg Msg) error {
+	payload, err := io.ReadAll(msg.Payload)
+	if err != nil {
+		return err
+	}
+	msg.Payload = bytes.NewReader(payload)
+	if err := rw.MsgReadWriter.WriteMsg(msg); err != nil {
+		return err
+	}
+	rw.record(false, msg.Code, payload)
+	return nil
+}
+
+func (rw *msgRecordRW) record(inbound bool, code uint64, payload []byte) {
+	rw.rec.record(&MsgRecord{
+		Time:     uint64(time.Now().UnixNano()),
+		Peer:     rw.peer,
+		Inbound:  inbound,
+		Protocol: rw.proto.Name,
+		Version:  rw.proto.Version,
+		Code:     code,
+		Payload:  payload,
+	})
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+// readRecords reads all records in the given directory.
+func readRecords(t *testing.T, dir string) []*MsgRecord {
+	t.Helper()
+	files, err := RecordFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []*MsgRecord
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := NewMsgRecordReader(f)
+		for {
+			rec, err := r.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				t.Fatalf("%s: %v", file, err)
+			}
+			records = append(records, rec)
+		}
+		f.Close()
+	}
+	return records
+}
+
+func TestMsgRecordRW(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := newMsgRecorder(dir, nil, log.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var (
+		id      = uintID(1)
+		cap     = Cap{"test", 2}
+		rw1, p2 = MsgPipe()
+		rw      = newMsgRecordRW(rw1, rec, id, cap)
+	)
+	defer rw1.Close()
+
+	go func() {
+		Send(p2, 1, []uint{1, 2})
+		ExpectMsg(p2, 3, []uint{3})
+	}()
+	if err := ExpectMsg(rw, 1, []uint{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := Send(rw, 3, []uint{3}); err != nil {
+		t.Fatal(err)
+	}
+	rec.close()
+
+	records := readRecords(t, dir)
+	if len(records) != 2 {
+		t.Fatalf("wrong number of records %d", len(records))
+	}
+	for i, want := range []struct {
+		inbound bool
+		code    uint64
+		payload []byte
+	}{
+		{true, 1, []byte{0xc2, 0x01, 0x02}},
+		{false, 3, []byte{0xc1, 0x03}},
+	} {
+		r := records[i]
+		if r.Peer != id || r.Protocol != cap.Name || r.Version != cap.Version {
+			t.Errorf("record %d: wrong peer or protocol: %v %s/%d", i, r.Peer, r.Protocol, r.Version)
+		}
+		if r.Inbound != want.inbound || r.Code != want.code || !bytes.Equal(r.Payload, want.payload) {
+			t.Errorf("record %d: wrong message: inbound=%t code=%d payload=%x", i, r.Inbound, r.Code, r.Payload)
+		}
+		if r.Time == 0 {
+			t.Errorf("record %d: missing timestamp", i)
+		}
+	}
+}
+
+func TestMsgRecorderRotation(t *testing.T) {
+	dir := t.TempDir()
+	rec, err := newMsgRecorder(dir, []enode.ID{uintID(1)}, log.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec.maxSize = 200
+	rec.maxFiles = 3
+
+	if !rec.enabled(uintID(1)) || rec.enabled(uintID(2)) {
+		t.Fatal("wrong peer selection")
+	}
+	const N = 20
+	for i := 0; i < N; i++ {
+		rec.record(&MsgRecord{Time: uint64(i + 1), Peer: uintID(1), Protocol: "test", Code: uint64(i), Payload: make([]byte, 100)})
+	}
+	rec.close()
+
+	files, _ := RecordFiles(dir)
+	if len(files) != rec.maxFiles {
+		t.Fatalf("wrong number of files %d, want %d", len(files), rec.maxFiles)
+	}
+	// The newest records must be retained in order.
+	records := readRecords(t, dir)
+	if len(records) == 0 || records[len(records)-1].Code != N-1 {
+		t.Fatal("newest record missing")
+	}
+	for i := 1; i < len(records); i++ {
+		if records[i].Code != records[i-1].Code+1 {
+			t.Fatalf("records out of order: %d after %d", records[i].Code, records[i-1].Code)
+		}
+	}
+}
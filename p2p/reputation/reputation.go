@@ -0,0 +1,448 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package reputation scores remote nodes based on their observed behaviour.
+//
+// Scores are fed by the protocol handlers running on top of a peer connection:
+// responses are rewarded or penalised based on their latency compared to the
+// median of all connected peers, invalid and timed out responses are penalised,
+// while useful transaction announcements and fast block propagation are rewarded.
+// Scores decay towards zero over time and are persisted in the node database, so
+// that the server can prefer redialing good nodes and avoid bad ones across runs.
+package reputation
+
+import (
+	"bytes"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/p2p/msgrate"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+const (
+	// maxScore and minScore are the bounds of a node's reputation.
+	maxScore = 100
+	minScore = -100
+
+	// scoreHalfLife is the time it takes for a score to decay to half its value,
+	// allowing nodes to recover from past misbehaviour and preventing nodes from
+	// riding on past good behaviour forever.
+	scoreHalfLife = 24 * time.Hour
+
+	// expireScore is the absolute score below which a disconnected node's record
+	// is considered meaningless and is dropped from the database.
+	expireScore = 0.5
+
+	responseReward   = 1.0             // Reward for a response as fast as zero, penalty for one twice the median
+	invalidPenalty   = 20.0            // Penalty for an invalid or unexpected response
+	timeoutPenalty   = 5.0             // Penalty for a request not served in time
+	txReward         = 0.05            // Reward for each useful transaction announced
+	txRewardCap      = 1.0             // Cap on the reward for a single batch of announcements
+	blockReward      = 1.0             // Reward for announcing a new head block as fast as us
+	blockRewardDelay = 4 * time.Second // Propagation delay beyond which no reward is given
+
+	// MinDialScore is the score below which nodes are not dialed anymore.
+	MinDialScore = -10
+
+	// minCandidateScore is the score above which disconnected nodes are preferred
+	// when dialing new peers.
+	minCandidateScore = 5
+
+	// candidateLimit is the maximum number of preferred dial candidates.
+	candidateLimit = 32
+
+	// candidateRefresh is the time after which the dial candidates are recomputed.
+	candidateRefresh = 30 * time.Second
+
+	// persistInterval is the time after which modified scores are flushed to the
+	// database even if the peers remain connected.
+	persistInterval = time.Minute
+)
+
+var (
+	invalidMeter = metrics.NewRegisteredMeter("p2p/reputation/invalid", nil)
+	timeoutMeter = metrics.NewRegisteredMeter("p2p/reputation/timeout", nil)
+)
+
+// Info is a summary of a connected peer's reputation.
+type Info struct {
+	Score        float64 `json:"score"`        // Current reputation score of the node
+	Responses    uint64  `json:"responses"`    // Number of responses served in this session
+	Invalid      uint64  `json:"invalid"`      // Number of invalid responses in this session
+	Timeouts     uint64  `json:"timeouts"`     // Number of timed out requests in this session
+	Transactions uint64  `json:"transactions"` // Number of useful transactions announced in this session
+}
+
+// entry is the reputation of a single node.
+type entry struct {
+	node    *enode.Node // Last known dialable record of the node
+	score   float64     // Score at the time of the last update
+	updated time.Time   // Time of the last score update
+	dirty   bool        // Whether the entry was modified since last persisted
+
+	rates *msgrate.Tracker // Message rate tracker if the node is connected
+	stats Info             // Statistics of the current session
+}
+
+// record is the persisted form of an entry.
+type record struct {
+	Score   uint64 // IEEE 754 representation of the score
+	Updated uint64 // Unix timestamp of the last update
+	Node    string `rlp:"optional"` // Textual representation of the dialable record
+}
+
+// Tracker maintains the reputation of all nodes that were ever connected.
+type Tracker struct {
+	db    *enode.DB
+	rates *msgrate.Trackers
+	now   func() time.Time
+
+	entries map[enode.ID]*entry
+
+	candidates []*enode.Node // Cached preferred dial candidates
+	refreshed  time.Time     // Time the dial candidates were last computed
+	persisted  time.Time     // Time the dirty entries were last flushed
+	closed     bool
+
+	lock sync.Mutex
+}
+
+// New creates a reputation tracker, loading the previously persisted scores from
+// the node database. If the database is nil, scores are kept in memory only.
+func New(db *enode.DB) *Tracker {
+	return newTracker(db, time.Now)
+}
+
+func newTracker(db *enode.DB, now func() time.Time) *Tracker {
+	t := &Tracker{
+		db:      db,
+		rates:   msgrate.NewTrackers(log.New("proto", "reputation")),
+		now:     now,
+		entries: make(map[enode.ID]*entry),
+	}
+	t.persisted = t.now()
+	if db != nil {
+		t.load()
+	}
+	return t
+}
+
+// load reads all the persisted reputation records, dropping the ones that have
+// decayed into irrelevance.
+func (t *Tracker) load() {
+	var stale []enode.ID
+	t.db.NodeReputations(func(id enode.ID, blob []byte) bool {
+		var rec record
+		if err := rlp.DecodeBytes(blob, &rec); err != nil {
+			log.Warn("Invalid node reputation record", "id", id, "err", err)
+			stale = append(stale, id)
+			return true
+		}
+		e := &entry{
+			score:   math.Float64frombits(rec.Score),
+			updated: time.Unix(int64(rec.Updated), 0),
+		}
+		if rec.Node != "" {
+			if n, err := enode.Parse(enode.ValidSchemes, rec.Node); err == nil && n.ID() == id {
+				e.node = n
+			}
+		}
+		if math.Abs(t.decayed(e)) < expireScore {
+			stale = append(stale, id)
+			return true
+		}
+		t.entries[id] = e
+		return true
+	})
+	for _, id := range stale {
+		t.db.DeleteNodeReputation(id)
+	}
+}
+
+// Close flushes all modified scores to the database. Events reported after the
+// tracker is closed are not persisted anymore.
+func (t *Tracker) Close() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.persist()
+	t.closed = true
+}
+
+// Connected starts tracking the behaviour of a newly connected node. If the node
+// was dialed, its record is retained to allow redialing it in the future.
+func (t *Tracker) Connected(node *enode.Node, dialed bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	id := node.ID()
+	e := t.entries[id]
+	if e == nil {
+		e = &entry{updated: t.now()}
+		t.entries[id] = e
+	}
+	if dialed {
+		e.node, e.dirty = node, true
+	}
+	if e.rates == nil {
+		e.rates = msgrate.NewTracker(nil, t.rates.MedianRoundTrip())
+		t.rates.Track(id.String(), e.rates)
+	}
+	e.stats = Info{}
+}
+
+// Disconnected stops tracking the behaviour of a node and persists its score. The
+// nodes which didn't earn a meaningful reputation are forgotten.
+func (t *Tracker) Disconnected(id enode.ID) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	e := t.entries[id]
+	if e == nil || e.rates == nil {
+		return
+	}
+	t.rates.Untrack(id.String())
+	e.rates = nil
+
+	if math.Abs(t.decayed(e)) < expireScore {
+		delete(t.entries, id)
+		if t.db != nil && !t.closed {
+			t.db.DeleteNodeReputation(id)
+		}
+		return
+	}
+	t.store(id, e)
+}
+
+// Peer returns the reputation handle of a node, through which protocol handlers
+// can report its behaviour.
+func (t *Tracker) Peer(id enode.ID) Handle {
+	return Handle{tracker: t, id: id}
+}
+
+// Score returns the current reputation score of a node.
+func (t *Tracker) Score(id enode.ID) float64 {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if e := t.entries[id]; e != nil {
+		return t.decayed(e)
+	}
+	return 0
+}
+
+// Candidates returns the disconnected nodes with the highest reputation, which
+// should be preferred when dialing new peers. The returned slice is cached and
+// must not be modified.
+func (t *Tracker) Candidates() []*enode.Node {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := t.now()
+	if t.candidates != nil && now.Sub(t.refreshed) < candidateRefresh {
+		return t.candidates
+	}
+	type candidate struct {
+		node  *enode.Node
+		score float64
+	}
+	var list []candidate
+	for _, e := range t.entries {
+		if e.node == nil || e.rates != nil {
+			continue
+		}
+		if score := t.decayed(e); score >= minCandidateScore {
+			list = append(list, candidate{e.node, score})
+		}
+	}
+	slices.SortFunc(list, func(a, b candidate) int {
+		switch {
+		case a.score > b.score:
+			return -1
+		case a.score < b.score:
+			return 1
+		}
+		ida, idb := a.node.ID(), b.node.ID()
+		return bytes.Compare(ida[:], idb[:])
+	})
+	t.candidates = make([]*enode.Node, 0, min(len(list), candidateLimit))
+	for i := 0; i < len(list) && i < candidateLimit; i++ {
+		t.candidates = append(t.candidates, list[i].node)
+	}
+	t.refreshed = now
+	return t.candidates
+}
+
+// decayed returns the score of an entry, decayed since its last update.
+func (t *Tracker) decayed(e *entry) float64 {
+	elapsed := t.now().Sub(e.updated)
+	if elapsed <= 0 {
+		return e.score
+	}
+	return e.score * math.Exp2(-float64(elapsed)/float64(scoreHalfLife))
+}
+
+// adjust modifies the score of a connected node, flushing the modified scores to
+// the database if they were not persisted for a while.
+func (t *Tracker) adjust(id enode.ID, delta float64, update func(e *entry)) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	e := t.entries[id]
+	if e == nil || e.rates == nil {
+		return // Late event after the peer disconnected
+	}
+	if update != nil {
+		update(e)
+	}
+	now := t.now()
+	e.score = min(maxScore, max(minScore, t.decayed(e)+delta))
+	e.updated, e.dirty = now, true
+
+	if now.Sub(t.persisted) >= persistInterval {
+		t.persist()
+	}
+}
+
+// persist flushes all the modified entries to the database.
+func (t *Tracker) persist() {
+	for id, e := range t.entries {
+		if e.dirty {
+			t.store(id, e)
+		}
+	}
+	t.persisted = t.now()
+}
+
+// store writes a single entry to the database.
+func (t *Tracker) store(id enode.ID, e *entry) {
+	if t.db == nil || t.closed || !e.dirty {
+		return
+	}
+	rec := record{
+		Score:   math.Float64bits(e.score),
+		Updated: uint64(e.updated.Unix()),
+	}
+	if e.node != nil {
+		rec.Node = e.node.String()
+	}
+	blob, err := rlp.EncodeToBytes(&rec)
+	if err != nil {
+		log.Error("Failed to encode node reputation", "id", id, "err", err)
+		return
+	}
+	if err := t.db.UpdateNodeReputation(id, blob); err != nil {
+		log.Warn("Failed to store node reputation", "id", id, "err", err)
+		return
+	}
+	e.dirty = false
+}
+
+// Handle is the reputation of a single connected peer. The zero value is valid
+// and discards all reported events.
+type Handle struct {
+	tracker *Tracker
+	id      enode.ID
+}
+
+// Response reports a response delivered by the peer after the given time. Fast
+// responses compared to the median of all peers are rewarded, slow ones are
+// penalised.
+func (h Handle) Response(kind uint64, elapsed time.Duration) {
+	if h.tracker == nil {
+		return
+	}
+	median := h.tracker.rates.MedianRoundTrip()
+	h.tracker.adjust(h.id, responseReward*max(-1, 1-float64(elapsed)/float64(median)), func(e *entry) {
+		// Item counts are irrelevant for the reputation, only the round trip
+		// time is of interest to compare the peers with each other.
+		e.rates.Update(kind, elapsed, 1)
+		e.stats.Responses++
+	})
+}
+
+// Invalid reports an invalid or unexpected response from the peer.
+func (h Handle) Invalid() {
+	if h.tracker == nil {
+		return
+	}
+	invalidMeter.Mark(1)
+	h.tracker.adjust(h.id, -invalidPenalty, func(e *entry) {
+		e.stats.Invalid++
+	})
+}
+
+// Timeout reports a request the peer failed to serve in time.
+func (h Handle) Timeout() {
+	if h.tracker == nil {
+		return
+	}
+	timeoutMeter.Mark(1)
+	h.tracker.adjust(h.id, -timeoutPenalty, func(e *entry) {
+		e.stats.Timeouts++
+	})
+}
+
+// Transactions reports a number of previously unknown transactions announced
+// by the peer.
+func (h Handle) Transactions(n int) {
+	if h.tracker == nil || n <= 0 {
+		return
+	}
+	h.tracker.adjust(h.id, min(txRewardCap, float64(n)*txReward), func(e *entry) {
+		e.stats.Transactions += uint64(n)
+	})
+}
+
+// BlockPropagation reports the peer announcing a new head block, the given delay
+// after it was imported locally.
+func (h Handle) BlockPropagation(delay time.Duration) {
+	if h.tracker == nil || delay >= blockRewardDelay {
+		return
+	}
+	h.tracker.adjust(h.id, blockReward*(1-float64(max(0, delay))/float64(blockRewardDelay)), nil)
+}
+
+// Score returns the current reputation score of the peer.
+func (h Handle) Score() float64 {
+	if h.tracker == nil {
+		return 0
+	}
+	return h.tracker.Score(h.id)
+}
+
+// Info returns a summary of the peer's reputation, or nil if it is not tracked.
+func (h Handle) Info() *Info {
+	if h.tracker == nil {
+		return nil
+	}
+	h.tracker.lock.Lock()
+	defer h.tracker.lock.Unlock()
+
+	e := h.tracker.entries[h.id]
+	if e == nil {
+		return nil
+	}
+	info := e.stats
+	info.Score = h.tracker.decayed(e)
+	return &info
+}
@@ -0,0 +1,169 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package reputation
+
+import (
+	"math"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+)
+
+func newTestNode(t *testing.T) *enode.Node {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return enode.NewV4(&key.PublicKey, net.IP{127, 0, 0, 1}, 30303, 30303)
+}
+
+// newTestTracker creates a tracker with a controllable clock.
+func newTestTracker(db *enode.DB, now *time.Time) *Tracker {
+	return newTracker(db, func() time.Time { return *now })
+}
+
+func TestEventScoring(t *testing.T) {
+	var (
+		now     = time.Unix(1_000_000, 0)
+		tracker = newTestTracker(nil, &now)
+	)
+	median := func(h Handle) time.Duration { return h.tracker.rates.MedianRoundTrip() }
+	tests := []struct {
+		report func(h Handle)
+		check  func(score float64) bool
+	}{
+		{func(h Handle) { h.Response(0, 0) }, func(s float64) bool { return s == responseReward }},
+		{func(h Handle) { h.Response(0, median(h)) }, func(s float64) bool { return s == 0 }},
+		{func(h Handle) { h.Response(0, 10*median(h)) }, func(s float64) bool { return s == -responseReward }},
+		{func(h Handle) { h.Invalid() }, func(s float64) bool { return s == -invalidPenalty }},
+		{func(h Handle) { h.Timeout() }, func(s float64) bool { return s == -timeoutPenalty }},
+		{func(h Handle) { h.Transactions(4) }, func(s float64) bool { return s == 4*txReward }},
+		{func(h Handle) { h.Transactions(1000) }, func(s float64) bool { return s == txRewardCap }},
+		{func(h Handle) { h.BlockPropagation(0) }, func(s float64) bool { return s == blockReward }},
+		{func(h Handle) { h.BlockPropagation(blockRewardDelay / 2) }, func(s float64) bool { return s == blockReward/2 }},
+		{func(h Handle) { h.BlockPropagation(time.Minute) }, func(s float64) bool { return s == 0 }},
+	}
+	for i, tt := range tests {
+		node := newTestNode(t)
+		tracker.Connected(node, true)
+		tt.report(tracker.Peer(node.ID()))
+		if score := tracker.Score(node.ID()); !tt.check(score) {
+			t.Errorf("test %d: unexpected score %v", i, score)
+		}
+	}
+}
+
+func TestScoreBoundsAndDecay(t *testing.T) {
+	var (
+		now     = time.Unix(1_000_000, 0)
+		tracker = newTestTracker(nil, &now)
+		node    = newTestNode(t)
+		peer    = tracker.Peer(node.ID())
+	)
+	// Events of disconnected nodes must be discarded
+	peer.Invalid()
+	if score := peer.Score(); score != 0 {
+		t.Fatalf("score changed before connecting: %v", score)
+	}
+	tracker.Connected(node, true)
+	for i := 0; i < 10; i++ {
+		peer.Invalid()
+	}
+	if score := peer.Score(); score != minScore {
+		t.Fatalf("score not capped: have %v, want %v", score, minScore)
+	}
+	now = now.Add(scoreHalfLife)
+	if score := peer.Score(); score != minScore/2 {
+		t.Fatalf("score not decayed: have %v, want %v", score, minScore/2)
+	}
+	peer.Timeout()
+	if score := peer.Score(); score != minScore/2-timeoutPenalty {
+		t.Fatalf("event not applied on decayed score: have %v, want %v", score, minScore/2-timeoutPenalty)
+	}
+	if info := peer.Info(); info.Invalid != 10 || info.Timeouts != 1 || info.Score != peer.Score() {
+		t.Fatalf("unexpected peer info: %+v", info)
+	}
+	tracker.Disconnected(node.ID())
+	peer.Invalid()
+	if info := peer.Info(); info.Invalid != 10 {
+		t.Fatalf("event applied after disconnecting: %+v", info)
+	}
+}
+
+func TestPersistence(t *testing.T) {
+	db, _ := enode.OpenDB("")
+	defer db.Close()
+
+	var (
+		now     = time.Unix(1_000_000, 0)
+		tracker = newTestTracker(db, &now)
+		good    = newTestNode(t)
+		inbound = newTestNode(t)
+		bad     = newTestNode(t)
+		useless = newTestNode(t)
+	)
+	tracker.Connected(good, true)
+	tracker.Connected(inbound, false)
+	tracker.Connected(bad, true)
+	tracker.Connected(useless, true)
+	for i := 0; i < 10; i++ {
+		tracker.Peer(good.ID()).BlockPropagation(0)
+		tracker.Peer(inbound.ID()).BlockPropagation(0)
+	}
+	tracker.Peer(bad.ID()).Invalid()
+
+	// Connected peers must not be offered as dial candidates
+	if candidates := tracker.Candidates(); len(candidates) != 0 {
+		t.Fatalf("connected peers offered for dialing: %v", candidates)
+	}
+	for _, node := range []*enode.Node{good, inbound, bad, useless} {
+		tracker.Disconnected(node.ID())
+	}
+	if blob := db.NodeReputation(useless.ID()); blob != nil {
+		t.Fatal("reputation of useless node persisted")
+	}
+	tracker.Close()
+
+	// Reload the tracker and check the scores and dial candidates, half of
+	// the scores decaying away
+	now = now.Add(scoreHalfLife)
+	tracker = newTestTracker(db, &now)
+	for _, node := range []*enode.Node{good, inbound} {
+		if have, want := tracker.Score(node.ID()), 10*blockReward/2; math.Abs(have-want) > 1e-9 {
+			t.Errorf("score mismatch: have %v, want %v", have, want)
+		}
+	}
+	if have, want := tracker.Score(bad.ID()), -invalidPenalty/2; math.Abs(have-want) > 1e-9 {
+		t.Errorf("score mismatch: have %v, want %v", have, want)
+	}
+	candidates := tracker.Candidates()
+	if len(candidates) != 1 || candidates[0].ID() != good.ID() || candidates[0].TCP() != good.TCP() {
+		t.Fatalf("unexpected dial candidates: %v", candidates)
+	}
+	// Reload the tracker after long enough for the scores to decay, they
+	// should be dropped
+	now = now.Add(10 * scoreHalfLife)
+	newTestTracker(db, &now)
+	for _, node := range []*enode.Node{good, inbound, bad} {
+		if blob := db.NodeReputation(node.ID()); blob != nil {
+			t.Errorf("decayed reputation not dropped: %x", blob)
+		}
+	}
+}
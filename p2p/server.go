@@ -20,6 +20,7 @@ package p2p
 import (
 	"bytes"
 	"cmp"
+	"context"
 	"crypto/ecdsa"
 	"encoding/hex"
 	"errors"
@@ -40,6 +41,8 @@ import (
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/enr"
 	"github.com/ethereum/go-ethereum/p2p/netutil"
+	"github.com/ethereum/go-ethereum/p2p/reputation"
+	"golang.org/x/net/quic"
 )
 
 const (
@@ -85,6 +88,9 @@ type Server struct {
 	running bool
 
 	listener     net.Listener
+	quic         *quic.Endpoint
+	quicConfig   *quic.Config
+	recorder     *msgRecorder
 	ourHandshake *protoHandshake
 	loopWG       sync.WaitGroup // loop, listenLoop
 	peerFeed     event.Feed
@@ -97,6 +103,9 @@ type Server struct {
 	discmix   *enode.FairMix
 	dialsched *dialScheduler
 
+	reputation *reputation.Tracker
+	banlist    *banList
+
 	// This is read by the NAT port mapping loop.
 	portMappingRegister chan *portMapping
 
@@ -111,6 +120,7 @@ type Server struct {
 	checkpointAddPeer       chan *conn
 
 	// State of run loop and listenLoop.
+	inboundLock    sync.Mutex // protects inboundHistory, shared with quicListenLoop
 	inboundHistory expHeap
 }
 
@@ -285,6 +295,53 @@ func (srv *Server) RemoveTrustedPeer(node *enode.Node) {
 	}
 }
 
+// BanPeer prevents the given node or network from connecting for the given duration,
+// or permanently if the duration is zero. The target may be an enode URL, an ENR, a
+// node ID, an IP address or a CIDR network. Connected peers matching the ban are
+// disconnected.
+func (srv *Server) BanPeer(target string, duration time.Duration) error {
+	srv.lock.Lock()
+	running := srv.running
+	srv.lock.Unlock()
+	if !running {
+		return errServerStopped
+	}
+	if err := srv.banlist.ban(target, duration, "banned by admin"); err != nil {
+		return err
+	}
+	srv.doPeerOp(func(peers map[enode.ID]*Peer) {
+		for _, p := range peers {
+			if srv.banlist.bannedNode(p.Node()) {
+				p.Disconnect(DiscUselessPeer)
+			}
+		}
+	})
+	return nil
+}
+
+// Unban removes the ban of the given node or network, returning whether it was
+// banned.
+func (srv *Server) Unban(target string) (bool, error) {
+	srv.lock.Lock()
+	running := srv.running
+	srv.lock.Unlock()
+	if !running {
+		return false, errServerStopped
+	}
+	return srv.banlist.unban(target)
+}
+
+// Bans returns the nodes and networks which are currently banned.
+func (srv *Server) Bans() []Ban {
+	srv.lock.Lock()
+	running := srv.running
+	srv.lock.Unlock()
+	if !running {
+		return nil
+	}
+	return srv.banlist.list()
+}
+
 // SubscribeEvents subscribes the given channel to peer events
 func (srv *Server) SubscribeEvents(ch chan *PeerEvent) event.Subscription {
 	return srv.peerFeed.Subscribe(ch)
@@ -328,6 +385,14 @@ func (srv *Server) Stop() {
 	close(srv.quit)
 	srv.lock.Unlock()
 	srv.loopWG.Wait()
+	if srv.quic != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), discWriteTimeout)
+		srv.quic.Close(ctx)
+		cancel()
+	}
+	if srv.recorder != nil {
+		srv.recorder.close()
+	}
 }
 
 // sharedUDPConn implements a shared connection. Write sends messages to the underlying connection while read returns
@@ -381,7 +446,7 @@ func (srv *Server) Start() (err error) {
 		return errors.New("Server.PrivateKey must be set to a non-nil key")
 	}
 	if srv.newTransport == nil {
-		srv.newTransport = newRLPX
+		srv.newTransport = newConnTransport
 	}
 	if srv.listenFunc == nil {
 		srv.listenFunc = net.Listen
@@ -395,6 +460,11 @@ func (srv *Server) Start() (err error) {
 	srv.peerOp = make(chan peerOpFunc)
 	srv.peerOpDone = make(chan struct{})
 
+	if srv.RecordDir != "" {
+		if srv.recorder, err = newMsgRecorder(srv.RecordDir, srv.RecordPeers, srv.log); err != nil {
+			return err
+		}
+	}
 	if err := srv.setupLocalNode(); err != nil {
 		return err
 	}
@@ -405,6 +475,11 @@ func (srv *Server) Start() (err error) {
 			return err
 		}
 	}
+	if srv.QUICAddr != "" {
+		if err := srv.setupQUICListening(); err != nil {
+			return err
+		}
+	}
 	if err := srv.setupDiscovery(); err != nil {
 		return err
 	}
@@ -430,6 +505,8 @@ func (srv *Server) setupLocalNode() error {
 		return err
 	}
 	srv.nodedb = db
+	srv.reputation = reputation.New(db)
+	srv.banlist = newBanList(db, time.Now)
 	srv.localnode = enode.NewLocalNode(db, srv.PrivateKey)
 	srv.localnode.SetFallbackIP(net.IP{127, 0, 0, 1})
 	// TODO: check conflicts
@@ -471,6 +548,7 @@ func (srv *Server) setupDiscovery() error {
 		cfg := discover.Config{
 			PrivateKey:  srv.PrivateKey,
 			NetRestrict: srv.NetRestrict,
+			Banned:      srv.banlist.bannedNode,
 			Bootnodes:   srv.BootstrapNodes,
 			Unhandled:   unhandled,
 			Log:         srv.log,
@@ -485,6 +563,7 @@ func (srv *Server) setupDiscovery() error {
 		cfg := discover.Config{
 			PrivateKey:  srv.PrivateKey,
 			NetRestrict: srv.NetRestrict,
+			Banned:      srv.banlist.bannedNode,
 			Bootnodes:   srv.BootstrapNodesV5,
 			Log:         srv.log,
 		}
@@ -532,6 +611,8 @@ func (srv *Server) setupDialScheduler() {
 		netRestrict:    srv.NetRestrict,
 		dialer:         srv.Dialer,
 		clock:          srv.clock,
+		reputation:     srv.reputation,
+		banlist:        srv.banlist,
 	}
 	if srv.discv4 != nil {
 		config.resolver = srv.discv4
@@ -539,6 +620,9 @@ func (srv *Server) setupDialScheduler() {
 	if config.dialer == nil {
 		config.dialer = tcpDialer{&net.Dialer{Timeout: defaultDialTimeout}}
 	}
+	if srv.quic != nil {
+		config.dialer = &quicDialer{endpoint: srv.quic, config: srv.quicConfig, fallback: config.dialer, log: srv.log}
+	}
 	srv.dialsched = newDialScheduler(config, srv.discmix, srv.SetupConn)
 	for _, n := range srv.StaticNodes {
 		srv.dialsched.addStatic(n)
@@ -591,6 +675,34 @@ func (srv *Server) setupListening() error {
 	return nil
 }
 
+func (srv *Server) setupQUICListening() error {
+	config, err := newQUICConfig()
+	if err != nil {
+		return err
+	}
+	endpoint, err := quic.Listen("udp", srv.QUICAddr, config)
+	if err != nil {
+		return err
+	}
+	srv.quic, srv.quicConfig = endpoint, config
+
+	// Update the local node record and map the QUIC port if NAT is configured.
+	laddr := endpoint.LocalAddr()
+	srv.localnode.Set(enr.QUIC(laddr.Port()))
+	if !laddr.Addr().IsLoopback() && !laddr.Addr().IsPrivate() {
+		srv.portMappingRegister <- &portMapping{
+			protocol: "UDP",
+			name:     "ethereum p2p quic",
+			port:     int(laddr.Port()),
+			quic:     true,
+		}
+	}
+
+	srv.loopWG.Add(1)
+	go srv.quicListenLoop()
+	return nil
+}
+
 func (srv *Server) setupUDPListening() (*net.UDPConn, error) {
 	listenAddr := srv.ListenAddr
 
@@ -635,6 +747,7 @@ func (srv *Server) run() {
 	srv.log.Info("Started P2P networking", "self", srv.localnode.Node().URLv4())
 	defer srv.loopWG.Done()
 	defer srv.nodedb.Close()
+	defer srv.reputation.Close()
 	defer srv.discmix.Close()
 	defer srv.dialsched.stop()
 
@@ -695,6 +808,7 @@ running:
 			err := srv.addPeerChecks(peers, inboundCount, c)
 			if err == nil {
 				// The handshakes are done and it passed all checks.
+				srv.reputation.Connected(c.node, !c.is(inboundConn))
 				p := srv.launchPeer(c)
 				peers[c.node.ID()] = p
 				srv.log.Debug("Adding p2p peer", "peercount", len(peers), "id", p.ID(), "conn", c.flags, "addr", p.RemoteAddr(), "name", p.Name())
@@ -715,6 +829,11 @@ running:
 			// A peer disconnected.
 			d := common.PrettyDuration(mclock.Now() - pd.created)
 			delete(peers, pd.ID())
+			srv.reputation.Disconnected(pd.ID())
+			if !pd.requested && !pd.rw.is(trustedConn) && !pd.rw.is(staticDialedConn) && isProtocolViolation(pd.err) {
+				srv.log.Debug("Banning misbehaving peer", "id", pd.ID(), "duration", violationBanDuration, "err", pd.err)
+				srv.banlist.banNode(pd.ID(), violationBanDuration, pd.err.Error())
+			}
 			srv.log.Debug("Removing p2p peer", "peercount", len(peers), "id", pd.ID(), "duration", d, "req", pd.requested, "err", pd.err)
 			srv.dialsched.peerRemoved(pd.rw)
 			if pd.Inbound() {
@@ -760,6 +879,8 @@ func (srv *Server) postHandshakeChecks(peers map[enode.ID]*Peer, inboundCount in
 		return DiscAlreadyConnected
 	case c.node.ID() == srv.localnode.ID():
 		return DiscSelf
+	case srv.banlist.bannedNode(c.node):
+		return DiscUselessPeer
 	default:
 		return nil
 	}
@@ -846,6 +967,71 @@ func (srv *Server) listenLoop() {
 	}
 }
 
+// quicListenLoop runs in its own goroutine and accepts
+// inbound QUIC connections.
+func (srv *Server) quicListenLoop() {
+	srv.log.Debug("QUIC listener up", "addr", srv.quic.LocalAddr())
+	defer srv.loopWG.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-srv.quit:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// The slots channel limits accepts of new connections.
+	tokens := defaultMaxPendingPeers
+	if srv.MaxPendingPeers > 0 {
+		tokens = srv.MaxPendingPeers
+	}
+	slots := make(chan struct{}, tokens)
+	for i := 0; i < tokens; i++ {
+		slots <- struct{}{}
+	}
+	defer func() {
+		for i := 0; i < cap(slots); i++ {
+			<-slots
+		}
+	}()
+
+	for {
+		// Wait for a free slot before accepting.
+		<-slots
+
+		conn, err := srv.quic.Accept(ctx)
+		if err != nil {
+			slots <- struct{}{}
+			return
+		}
+		remoteIP := conn.RemoteAddr().Addr().Unmap()
+		if err := srv.checkInboundConn(remoteIP); err != nil {
+			srv.log.Debug("Rejected inbound QUIC connection", "addr", conn.RemoteAddr(), "err", err)
+			conn.Abort(nil)
+			slots <- struct{}{}
+			continue
+		}
+		serveMeter.Mark(1)
+		srv.log.Trace("Accepted QUIC connection", "addr", conn.RemoteAddr())
+		go func() {
+			defer func() { slots <- struct{}{} }()
+
+			// The dialer opens the control stream first.
+			actx, acancel := context.WithTimeout(ctx, handshakeTimeout)
+			control, err := conn.AcceptStream(actx)
+			acancel()
+			if err != nil {
+				conn.Abort(nil)
+				return
+			}
+			srv.SetupConn(newQUICConn(conn, control), inboundConn, nil)
+		}()
+	}
+}
+
 func (srv *Server) checkInboundConn(remoteIP netip.Addr) error {
 	if !remoteIP.IsValid() {
 		// This case happens for internal test connections without remote address.
@@ -855,7 +1041,13 @@ func (srv *Server) checkInboundConn(remoteIP netip.Addr) error {
 	if srv.NetRestrict != nil && !srv.NetRestrict.ContainsAddr(remoteIP) {
 		return errors.New("not in netrestrict list")
 	}
+	// Reject connections from banned networks.
+	if srv.banlist.bannedIP(remoteIP) {
+		return errors.New("banned")
+	}
 	// Reject Internet peers that try too often.
+	srv.inboundLock.Lock()
+	defer srv.inboundLock.Unlock()
 	now := srv.clock.Now()
 	srv.inboundHistory.expire(now, nil)
 	if !netutil.AddrIsLAN(remoteIP) && srv.inboundHistory.contains(remoteIP.String()) {
@@ -948,9 +1140,13 @@ func (srv *Server) setupConn(c *conn, dialDest *enode.Node) error {
 func nodeFromConn(pubkey *ecdsa.PublicKey, conn net.Conn) *enode.Node {
 	var ip net.IP
 	var port int
-	if tcp, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
-		ip = tcp.IP
-		port = tcp.Port
+	switch addr := conn.RemoteAddr().(type) {
+	case *net.TCPAddr:
+		ip = addr.IP
+		port = addr.Port
+	case *net.UDPAddr:
+		// QUIC connection, the remote TCP port is unknown.
+		ip = addr.IP
 	}
 	return enode.NewV4(pubkey, ip, port, port)
 }
@@ -968,11 +1164,15 @@ func (srv *Server) checkpoint(c *conn, stage chan<- *conn) error {
 
 func (srv *Server) launchPeer(c *conn) *Peer {
 	p := newPeer(srv.log, c, srv.Protocols)
+	p.reputation = srv.reputation.Peer(c.node.ID())
 	if srv.EnableMsgEvents {
 		// If message events are enabled, pass the peerFeed
 		// to the peer.
 		p.events = &srv.peerFeed
 	}
+	if srv.recorder != nil && srv.recorder.enabled(c.node.ID()) {
+		p.recorder = srv.recorder
+	}
 	go srv.runPeer(p)
 	return p
 }
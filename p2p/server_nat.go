@@ -34,11 +34,18 @@ const (
 	maxRetries             = 5 // max number of failed attempts to refresh the mapping
 )
 
+// portMappingKey identifies a port mapping by its protocol and internal port.
+type portMappingKey struct {
+	protocol string
+	port     int
+}
+
 type portMapping struct {
 	protocol string
 	name     string
 	port     int
-	retries  int // number of failed attempts to refresh the mapping
+	quic     bool // whether the port is the QUIC listener rather than discovery
+	retries  int  // number of failed attempts to refresh the mapping
 
 	// for use by the portMappingLoop goroutine:
 	extPort  int // the mapped port returned by the NAT interface
@@ -48,11 +55,11 @@ type portMapping struct {
 // setupPortMapping starts the port mapping loop if necessary.
 // Note: this needs to be called after the LocalNode instance has been set on the server.
 func (srv *Server) setupPortMapping() {
-	// portMappingRegister will receive up to two values: one for the TCP port if
-	// listening is enabled, and one more for enabling UDP port mapping if discovery is
-	// enabled. We make it buffered to avoid blocking setup while a mapping request is in
-	// progress.
-	srv.portMappingRegister = make(chan *portMapping, 2)
+	// portMappingRegister will receive up to three values: one for the TCP port if
+	// listening is enabled, one for enabling UDP port mapping if discovery is enabled,
+	// and one more for the QUIC port if QUIC listening is enabled. We make it buffered
+	// to avoid blocking setup while a mapping request is in progress.
+	srv.portMappingRegister = make(chan *portMapping, 3)
 
 	switch srv.NAT.(type) {
 	case nil:
@@ -93,7 +100,7 @@ func (srv *Server) portMappingLoop() {
 	}
 
 	var (
-		mappings  = make(map[string]*portMapping, 2)
+		mappings  = make(map[portMappingKey]*portMapping, 3)
 		refresh   = mclock.NewAlarm(srv.clock)
 		extip     = mclock.NewAlarm(srv.clock)
 		lastExtIP net.IP
@@ -143,7 +150,7 @@ func (srv *Server) portMappingLoop() {
 			if m.protocol != "TCP" && m.protocol != "UDP" {
 				panic("unknown NAT protocol name: " + m.protocol)
 			}
-			mappings[m.protocol] = m
+			mappings[portMappingKey{m.protocol, m.port}] = m
 			m.nextTime = srv.clock.Now()
 
 		case <-refresh.C():
@@ -195,7 +202,11 @@ func (srv *Server) portMappingLoop() {
 					case "TCP":
 						srv.localnode.Set(enr.TCP(m.extPort))
 					case "UDP":
-						srv.localnode.SetFallbackUDP(m.extPort)
+						if m.quic {
+							srv.localnode.Set(enr.QUIC(m.extPort))
+						} else {
+							srv.localnode.SetFallbackUDP(m.extPort)
+						}
 					}
 				}
 				m.nextTime = srv.clock.Now().Add(portMapRefreshInterval)
@@ -26,6 +26,7 @@ import (
 	"github.com/ethereum/go-ethereum/common/mclock"
 	"github.com/ethereum/go-ethereum/internal/testlog"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/p2p/enr"
 )
 
 func TestServerPortMapping(t *testing.T) {
@@ -77,6 +78,46 @@ func TestServerPortMapping(t *testing.T) {
 	}
 }
 
+// TestServerPortMappingQUIC checks that the QUIC port is mapped independently of
+// the discovery port, and that the mapped port ends up in the quic ENR entry.
+func TestServerPortMappingQUIC(t *testing.T) {
+	clock := new(mclock.Simulated)
+	mockNAT := &mockNAT{mappedPort: 30000}
+	srv := Server{
+		Config: Config{
+			PrivateKey: newkey(),
+			NoDial:     true,
+			ListenAddr: ":0",
+			DiscAddr:   ":0",
+			QUICAddr:   ":0",
+			NAT:        mockNAT,
+			Logger:     testlog.Logger(t, log.LvlTrace),
+			clock:      clock,
+		},
+	}
+	err := srv.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Stop()
+
+	deadline := clock.Now().Add(portMapRefreshInterval)
+	for clock.Now() < deadline && mockNAT.mapRequests.Load() < 3 {
+		time.Sleep(10 * time.Millisecond)
+		clock.Run(1 * time.Second)
+	}
+	if reqCount := mockNAT.mapRequests.Load(); reqCount != 3 {
+		t.Error("wrong request count:", reqCount)
+	}
+	var quic enr.QUIC
+	if err := srv.LocalNode().Node().Load(&quic); err != nil {
+		t.Fatal("no QUIC port in ENR:", err)
+	}
+	if quic != 30000 {
+		t.Error("wrong QUIC port in ENR:", quic)
+	}
+}
+
 type mockNAT struct {
 	mappedPort    uint16
 	mapRequests   atomic.Int32
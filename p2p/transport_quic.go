@@ -0,0 +1,572 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	crand "crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/p2p/enode"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/snappy"
+	"golang.org/x/net/quic"
+	"golang.org/x/sync/semaphore"
+)
+
+// The QUIC transport is an experimental alternative to RLPx over TCP. QUIC provides
+// encryption, so devp2p messages are sent as plain length-prefixed frames. The peer
+// identity is authenticated by signing the TLS keying material with the node key.
+//
+// The dialer opens a bidirectional control stream, which carries the identity
+// exchange and all base protocol messages. Each side opens one unidirectional stream
+// per capability for the messages it sends, so packet loss on one subprotocol does
+// not hold up delivery of messages belonging to another.
+const (
+	quicALPN          = "devp2p"
+	quicAuthLabel     = "EXPERIMENTAL devp2p quic auth"
+	quicMaxFrameSize  = 0xffffff
+	quicMaxAuthSize   = 2048                 // limit of the unauthenticated identity frame, same as RLPx
+	quicMaxInflight   = 2 * quicMaxFrameSize // limit of the received frames buffered per connection
+	quicIdleTimeout   = frameReadTimeout
+	quicKeepAlive     = 10 * time.Second
+	quicMaxDataStream = 32
+)
+
+var (
+	errQUICFrameTooLarge = errors.New("QUIC frame too large")
+	errQUICReadTimeout   = errors.New("QUIC read timeout")
+	errQUICClosed        = errors.New("QUIC transport closed")
+)
+
+// newQUICConfig creates the QUIC configuration. The TLS certificate is generated on
+// the fly because it is not used for authentication.
+func newQUICConfig() (*quic.Config, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), crand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(10 * 365 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(crand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS13,
+		NextProtos:         []string{quicALPN},
+		Certificates:       []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		InsecureSkipVerify: true,
+	}
+	return &quic.Config{
+		TLSConfig:           tlsConfig,
+		MaxUniRemoteStreams: quicMaxDataStream,
+		HandshakeTimeout:    handshakeTimeout,
+		MaxIdleTimeout:      quicIdleTimeout,
+		KeepAlivePeriod:     quicKeepAlive,
+	}, nil
+}
+
+// newConnTransport creates the transport for a connection. QUIC connections use the
+// QUIC transport, all others run RLPx.
+func newConnTransport(conn net.Conn, dialDest *ecdsa.PublicKey) transport {
+	if qc, ok := conn.(*quicConn); ok {
+		return newQUICTransport(qc, dialDest)
+	}
+	return newRLPX(conn, dialDest)
+}
+
+// quicConn is a QUIC connection. It implements net.Conn on top of the control stream
+// in order to fit into the connection setup of Server.
+type quicConn struct {
+	conn    *quic.Conn
+	control *quic.Stream
+
+	mu          sync.Mutex
+	cancelRead  context.CancelFunc
+	cancelWrite context.CancelFunc
+	closeOnce   sync.Once
+}
+
+func newQUICConn(conn *quic.Conn, control *quic.Stream) *quicConn {
+	return &quicConn{conn: conn, control: control}
+}
+
+func (c *quicConn) Read(b []byte) (int, error)  { return c.control.Read(b) }
+func (c *quicConn) Write(b []byte) (int, error) { return c.control.Write(b) }
+
+// Close terminates the QUIC connection.
+func (c *quicConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.conn.Abort(nil)
+		c.mu.Lock()
+		if c.cancelRead != nil {
+			c.cancelRead()
+		}
+		if c.cancelWrite != nil {
+			c.cancelWrite()
+		}
+		c.mu.Unlock()
+	})
+	return nil
+}
+
+func (c *quicConn) LocalAddr() net.Addr {
+	return net.UDPAddrFromAddrPort(c.conn.LocalAddr())
+}
+
+func (c *quicConn) RemoteAddr() net.Addr {
+	return net.UDPAddrFromAddrPort(c.conn.RemoteAddr())
+}
+
+func (c *quicConn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+func (c *quicConn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cancelRead = setStreamContext(c.control.SetReadContext, c.cancelRead, t)
+	return nil
+}
+
+func (c *quicConn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cancelWrite = setStreamContext(c.control.SetWriteContext, c.cancelWrite, t)
+	return nil
+}
+
+// setStreamContext applies a deadline to stream operations.
+func setStreamContext(set func(context.Context), cancel context.CancelFunc, t time.Time) context.CancelFunc {
+	if cancel != nil {
+		cancel()
+	}
+	if t.IsZero() {
+		set(context.Background())
+		return nil
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), t)
+	set(ctx)
+	return cancel
+}
+
+// quicDialer dials nodes which advertise a QUIC port using QUIC, and everything
+// else using the fallback dialer.
+type quicDialer struct {
+	endpoint *quic.Endpoint
+	config   *quic.Config
+	fallback NodeDialer
+	log      log.Logger
+}
+
+func (d *quicDialer) Dial(ctx context.Context, dest *enode.Node) (net.Conn, error) {
+	addr, ok := dest.QUICEndpoint()
+	if !ok {
+		return d.fallback.Dial(ctx, dest)
+	}
+	conn, err := d.endpoint.Dial(ctx, "udp", addr.String(), d.config)
+	if err == nil {
+		var control *quic.Stream
+		if control, err = conn.NewStream(ctx); err == nil {
+			return newQUICConn(conn, control), nil
+		}
+		conn.Abort(nil)
+	}
+	d.log.Trace("QUIC dial failed, falling back to TCP", "id", dest.ID(), "addr", addr, "err", err)
+	return d.fallback.Dial(ctx, dest)
+}
+
+// quicAuth is sent on the control stream to prove ownership of the node key.
+type quicAuth struct {
+	Signature []byte
+
+	// Ignore additional fields (for forward compatibility).
+	Rest []rlp.RawValue `rlp:"tail"`
+}
+
+// quicFrame is a frame received on any of the streams.
+type quicFrame struct {
+	data []byte
+	size int // wire size
+}
+
+// quicStreamWriter is an outgoing stream.
+type quicStreamWriter struct {
+	mu     sync.Mutex
+	stream *quic.Stream
+}
+
+// quicTransport implements transport on top of a QUIC connection.
+type quicTransport struct {
+	fd       *quicConn
+	dialDest *ecdsa.PublicKey
+
+	rmu      sync.Mutex
+	snappy   atomic.Bool
+	frames   chan quicFrame
+	errc     chan error
+	inflight *semaphore.Weighted // budget of the frames read but not yet consumed
+
+	smu     sync.Mutex
+	control *quicStreamWriter
+	streams map[string]*quicStreamWriter
+
+	closeOnce sync.Once
+	closed    chan struct{}
+	closeCtx  context.Context // canceled when the transport is closed
+	cancel    context.CancelFunc
+}
+
+func newQUICTransport(fd *quicConn, dialDest *ecdsa.PublicKey) transport {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &quicTransport{
+		fd:       fd,
+		dialDest: dialDest,
+		frames:   make(chan quicFrame),
+		errc:     make(chan error, 1),
+		inflight: semaphore.NewWeighted(quicMaxInflight),
+		control:  &quicStreamWriter{stream: fd.control},
+		streams:  make(map[string]*quicStreamWriter),
+		closed:   make(chan struct{}),
+		closeCtx: ctx,
+		cancel:   cancel,
+	}
+}
+
+func (t *quicTransport) doEncHandshake(prv *ecdsa.PrivateKey) (*ecdsa.PublicKey, error) {
+	t.fd.SetDeadline(time.Now().Add(handshakeTimeout))
+
+	state := t.fd.conn.ConnectionState()
+	ekm, err := state.ExportKeyingMaterial(quicAuthLabel, nil, 32)
+	if err != nil {
+		return nil, err
+	}
+	initiator := t.dialDest != nil
+	sendAuth := func() error {
+		sig, err := crypto.Sign(quicAuthHash(ekm, initiator), prv)
+		if err != nil {
+			return err
+		}
+		enc, _ := rlp.EncodeToBytes(&quicAuth{Signature: sig})
+		_, err = writeQUICFrame(t.fd.control, enc)
+		return err
+	}
+	if initiator {
+		if err := sendAuth(); err != nil {
+			return nil, err
+		}
+	}
+	frame, _, err := readQUICFrame(t.fd.control, quicMaxAuthSize, nil)
+	if err != nil {
+		return nil, err
+	}
+	var auth quicAuth
+	if err := rlp.DecodeBytes(frame, &auth); err != nil {
+		return nil, err
+	}
+	remote, err := crypto.SigToPub(quicAuthHash(ekm, !initiator), auth.Signature)
+	if err != nil {
+		return nil, err
+	}
+	if initiator && !remote.Equal(t.dialDest) {
+		return nil, errors.New("remote identity mismatch")
+	}
+	if !initiator {
+		if err := sendAuth(); err != nil {
+			return nil, err
+		}
+	}
+	t.fd.SetDeadline(time.Time{})
+	go t.readStream(t.fd.control, true)
+	return remote, nil
+}
+
+// quicAuthHash returns the hash signed by either side of the connection.
+func quicAuthHash(ekm []byte, initiator bool) []byte {
+	role := []byte{0}
+	if !initiator {
+		role[0] = 1
+	}
+	return crypto.Keccak256(ekm, role)
+}
+
+func (t *quicTransport) doProtoHandshake(our *protoHandshake) (their *protoHandshake, err error) {
+	werr := make(chan error, 1)
+	go func() { werr <- Send(t, handshakeMsg, our) }()
+	if their, err = readProtocolHandshake(t); err != nil {
+		<-werr // make sure the write terminates too
+		return nil, err
+	}
+	if err := <-werr; err != nil {
+		return nil, fmt.Errorf("write error: %v", err)
+	}
+	t.snappy.Store(their.Version >= snappyProtocolVersion)
+
+	// Subprotocol streams are accepted only after the handshake, so their messages
+	// can't overtake the handshake on the control stream.
+	go t.acceptStreams()
+	return their, nil
+}
+
+// acceptStreams reads from all streams opened by the remote side.
+func (t *quicTransport) acceptStreams() {
+	for {
+		stream, err := t.fd.conn.AcceptStream(t.closeCtx)
+		if err != nil {
+			return
+		}
+		go t.readStream(stream, false)
+	}
+}
+
+// readStream delivers the frames of a stream to ReadMsg. Read errors are reported
+// for the control stream only: if the connection fails, the control stream fails as
+// well, and reporting it there ensures a final disconnect message is delivered first.
+//
+// The frames are only allocated once they fit into the in-flight budget of the
+// connection, so the remote side can't make us buffer a frame on each of its
+// streams at once.
+func (t *quicTransport) readStream(stream *quic.Stream, control bool) {
+	var reserved int64
+	reserve := func(size int) error {
+		if err := t.inflight.Acquire(t.closeCtx, int64(size)); err != nil {
+			return err
+		}
+		reserved = int64(size)
+		return nil
+	}
+	for {
+		reserved = 0
+		data, size, err := readQUICFrame(stream, quicMaxFrameSize, reserve)
+		if err != nil {
+			t.inflight.Release(reserved)
+			if control {
+				t.readFailed(err)
+			}
+			return
+		}
+		select {
+		case t.frames <- quicFrame{data: data, size: size}:
+		case <-t.closed:
+			t.inflight.Release(int64(len(data)))
+			return
+		}
+	}
+}
+
+func (t *quicTransport) readFailed(err error) {
+	select {
+	case t.errc <- err:
+	default:
+	}
+}
+
+func (t *quicTransport) ReadMsg() (Msg, error) {
+	t.rmu.Lock()
+	defer t.rmu.Unlock()
+
+	timeout := time.NewTimer(frameReadTimeout)
+	defer timeout.Stop()
+
+	var frame quicFrame
+	select {
+	case frame = <-t.frames:
+	case err := <-t.errc:
+		return Msg{}, err
+	case <-t.closed:
+		return Msg{}, errQUICClosed
+	case <-timeout.C:
+		return Msg{}, errQUICReadTimeout
+	}
+	t.inflight.Release(int64(len(frame.data)))
+
+	code, data, err := rlp.SplitUint64(frame.data)
+	if err != nil {
+		return Msg{}, fmt.Errorf("invalid message code: %v", err)
+	}
+	if t.snappy.Load() {
+		size, err := snappy.DecodedLen(data)
+		if err != nil {
+			return Msg{}, err
+		}
+		if size > quicMaxFrameSize {
+			return Msg{}, errQUICFrameTooLarge
+		}
+		if data, err = snappy.Decode(nil, data); err != nil {
+			return Msg{}, err
+		}
+	}
+	return Msg{
+		ReceivedAt: time.Now(),
+		Code:       code,
+		Size:       uint32(len(data)),
+		meterSize:  uint32(frame.size),
+		Payload:    bytes.NewReader(data),
+	}, nil
+}
+
+func (t *quicTransport) WriteMsg(msg Msg) error {
+	var buf bytes.Buffer
+	if _, err := io.CopyN(&buf, msg.Payload, int64(msg.Size)); err != nil {
+		return err
+	}
+	frame := t.encodeFrame(msg.Code, buf.Bytes())
+	w, err := t.stream(msg.meterCap.Name)
+	if err != nil {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), frameWriteTimeout)
+	defer cancel()
+	w.stream.SetWriteContext(ctx)
+	size, err := writeQUICFrame(w.stream, frame)
+	if err != nil {
+		return err
+	}
+
+	// Set metrics.
+	msg.meterSize = uint32(size)
+	if metrics.Enabled() && msg.meterCap.Name != "" { // don't meter non-subprotocol messages
+		m := fmt.Sprintf("%s/%s/%d/%#02x", egressMeterName, msg.meterCap.Name, msg.meterCap.Version, msg.meterCode)
+		metrics.GetOrRegisterMeter(m, nil).Mark(int64(msg.meterSize))
+		metrics.GetOrRegisterMeter(m+"/packets", nil).Mark(1)
+	}
+	return nil
+}
+
+// encodeFrame creates the frame of a message.
+func (t *quicTransport) encodeFrame(code uint64, data []byte) []byte {
+	if t.snappy.Load() {
+		data = snappy.Encode(nil, data)
+	}
+	frame := rlp.AppendUint64(nil, code)
+	return append(frame, data...)
+}
+
+// stream returns the outgoing stream of a capability, opening it if necessary.
+// Base protocol messages are sent on the control stream.
+func (t *quicTransport) stream(name string) (*quicStreamWriter, error) {
+	if name == "" {
+		return t.control, nil
+	}
+	t.smu.Lock()
+	defer t.smu.Unlock()
+
+	if w := t.streams[name]; w != nil {
+		return w, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), frameWriteTimeout)
+	defer cancel()
+	stream, err := t.fd.conn.NewSendOnlyStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w := &quicStreamWriter{stream: stream}
+	t.streams[name] = w
+	return w, nil
+}
+
+func (t *quicTransport) close(err error) {
+	t.closeOnce.Do(func() {
+		// Tell the remote end why we're disconnecting if possible.
+		if reason, ok := err.(DiscReason); ok && reason != DiscNetworkError {
+			data, _ := rlp.EncodeToBytes([]any{reason})
+			frame := t.encodeFrame(discMsg, data)
+
+			// Closing the stream waits until the peer has received the message.
+			t.control.mu.Lock()
+			ctx, cancel := context.WithTimeout(context.Background(), discWriteTimeout)
+			t.control.stream.SetWriteContext(ctx)
+			if _, err := writeQUICFrame(t.control.stream, frame); err == nil {
+				t.control.stream.Close()
+			}
+			cancel()
+			t.control.mu.Unlock()
+		}
+		close(t.closed)
+		t.cancel()
+		t.fd.Close()
+	})
+}
+
+// writeQUICFrame writes a length-prefixed frame and flushes the stream.
+func writeQUICFrame(s *quic.Stream, data []byte) (int, error) {
+	if len(data) > quicMaxFrameSize {
+		return 0, errQUICFrameTooLarge
+	}
+	header := []byte{byte(len(data) >> 16), byte(len(data) >> 8), byte(len(data))}
+	if _, err := s.Write(header); err != nil {
+		return 0, err
+	}
+	if _, err := s.Write(data); err != nil {
+		return 0, err
+	}
+	if err := s.Flush(); err != nil {
+		return 0, err
+	}
+	size := len(header) + len(data)
+	egressTrafficMeter.Mark(int64(size))
+	return size, nil
+}
+
+// readQUICFrame reads a length-prefixed frame, rejecting frames larger than the
+// given size before allocating them. If reserve is non-nil, it is called with the
+// frame size before the allocation, and the reservation is held by the returned
+// frame.
+func readQUICFrame(s *quic.Stream, maxSize int, reserve func(int) error) ([]byte, int, error) {
+	var header [3]byte
+	if _, err := io.ReadFull(s, header[:]); err != nil {
+		return nil, 0, err
+	}
+	size := int(header[0])<<16 | int(header[1])<<8 | int(header[2])
+	if size > maxSize {
+		return nil, 0, errQUICFrameTooLarge
+	}
+	if reserve != nil {
+		if err := reserve(size); err != nil {
+			return nil, 0, err
+		}
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(s, data); err != nil {
+		return nil, 0, err
+	}
+	ingressTrafficMeter.Mark(int64(len(header) + size))
+	return data, len(header) + size, nil
+}
@@ -0,0 +1,317 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package p2p
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"io"
+	"net"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"golang.org/x/net/quic"
+)
+
+// newQUICPipe creates a connected pair of QUIC connections.
+func newQUICPipe(t *testing.T) (dialer, listener *quicConn) {
+	t.Helper()
+
+	config, err := newQUICConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var endpoints [2]*quic.Endpoint
+	for i := range endpoints {
+		if endpoints[i], err = quic.Listen("udp", "127.0.0.1:0", config); err != nil {
+			t.Fatal(err)
+		}
+		ep := endpoints[i]
+		t.Cleanup(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			ep.Close(ctx)
+		})
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	conn, err := endpoints[0].Dial(ctx, "udp", endpoints[1].LocalAddr().String(), config)
+	if err != nil {
+		t.Fatal("dial error:", err)
+	}
+	control, err := conn.NewStream(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rconn, err := endpoints[1].Accept(ctx)
+	if err != nil {
+		t.Fatal("accept error:", err)
+	}
+	return newQUICConn(conn, control), newQUICConn(rconn, nil)
+}
+
+func TestQUICTransport(t *testing.T) {
+	var (
+		dialKey, listenKey = newkey(), newkey()
+		dialConn, lconn    = newQUICPipe(t)
+		dialer             = newQUICTransport(dialConn, &listenKey.PublicKey)
+		listener           transport
+	)
+	defer dialer.close(nil)
+
+	// Run the identity exchange. The listener can only accept the control
+	// stream once the dialer has written to it.
+	type result struct {
+		pubkey *ecdsa.PublicKey
+		err    error
+	}
+	dialResult := make(chan result, 1)
+	go func() {
+		pubkey, err := dialer.doEncHandshake(dialKey)
+		dialResult <- result{pubkey, err}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	control, err := lconn.conn.AcceptStream(ctx)
+	if err != nil {
+		t.Fatal("can't accept control stream:", err)
+	}
+	listener = newQUICTransport(newQUICConn(lconn.conn, control), nil)
+	defer listener.close(nil)
+
+	remote, err := listener.doEncHandshake(listenKey)
+	if err != nil {
+		t.Fatal("listener handshake error:", err)
+	}
+	if !remote.Equal(&dialKey.PublicKey) {
+		t.Fatal("listener got wrong remote key")
+	}
+	if res := <-dialResult; res.err != nil {
+		t.Fatal("dialer handshake error:", res.err)
+	}
+
+	// Run the protocol handshake.
+	hs := func(key *ecdsa.PrivateKey) *protoHandshake {
+		return &protoHandshake{Version: baseProtocolVersion, ID: crypto.FromECDSAPub(&key.PublicKey)[1:]}
+	}
+	protoResult := make(chan error, 1)
+	go func() {
+		_, err := dialer.doProtoHandshake(hs(dialKey))
+		protoResult <- err
+	}()
+	if _, err := listener.doProtoHandshake(hs(listenKey)); err != nil {
+		t.Fatal("listener protocol handshake error:", err)
+	}
+	if err := <-protoResult; err != nil {
+		t.Fatal("dialer protocol handshake error:", err)
+	}
+
+	// Send messages of different capabilities. They arrive on different streams,
+	// but the order within each capability must be preserved.
+	msgs := []struct {
+		cap  Cap
+		code uint64
+	}{
+		{Cap{"eth", 68}, 0x10}, {Cap{"snap", 1}, 0x21}, {Cap{"eth", 68}, 0x11},
+		{Cap{}, pingMsg}, {Cap{"snap", 1}, 0x22}, {Cap{"eth", 68}, 0x12},
+	}
+	go func() {
+		for _, m := range msgs {
+			payload := bytes.Repeat([]byte{byte(m.code)}, 2000)
+			msg := Msg{Code: m.code, Size: uint32(len(payload)), Payload: bytes.NewReader(payload), meterCap: m.cap}
+			if err := dialer.WriteMsg(msg); err != nil {
+				t.Error("write error:", err)
+				return
+			}
+		}
+	}()
+	last := make(map[string]uint64)
+	for range msgs {
+		msg, err := listener.ReadMsg()
+		if err != nil {
+			t.Fatal("read error:", err)
+		}
+		payload, _ := io.ReadAll(msg.Payload)
+		if len(payload) != 2000 || payload[0] != byte(msg.Code) {
+			t.Fatalf("wrong payload for message %#x", msg.Code)
+		}
+		var name string
+		switch {
+		case msg.Code >= 0x20:
+			name = "snap"
+		case msg.Code >= 0x10:
+			name = "eth"
+		}
+		if msg.Code <= last[name] && name != "" {
+			t.Fatalf("message %#x of %q arrived out of order", msg.Code, name)
+		}
+		last[name] = msg.Code
+	}
+
+	// Check that the disconnect reason is delivered.
+	dialer.close(DiscTooManyPeers)
+	msg, err := listener.ReadMsg()
+	if err != nil {
+		t.Fatal("read error:", err)
+	}
+	if msg.Code != discMsg {
+		t.Fatalf("expected disconnect, got %#x", msg.Code)
+	}
+	if reason := decodeDisconnectMessage(msg.Payload); reason != DiscTooManyPeers {
+		t.Fatalf("wrong disconnect reason %v", reason)
+	}
+}
+
+func TestQUICTransportWrongIdentity(t *testing.T) {
+	var (
+		dialKey, listenKey = newkey(), newkey()
+		dialConn, lconn    = newQUICPipe(t)
+		dialer             = newQUICTransport(dialConn, &newkey().PublicKey)
+	)
+	defer dialer.close(nil)
+
+	errc := make(chan error, 1)
+	go func() {
+		_, err := dialer.doEncHandshake(dialKey)
+		errc <- err
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	control, err := lconn.conn.AcceptStream(ctx)
+	if err != nil {
+		t.Fatal("can't accept control stream:", err)
+	}
+	listener := newQUICTransport(newQUICConn(lconn.conn, control), nil)
+	defer listener.close(nil)
+	listener.doEncHandshake(listenKey)
+
+	if err := <-errc; err == nil {
+		t.Fatal("dialer accepted wrong identity")
+	}
+}
+
+func TestQUICTransportLargeAuth(t *testing.T) {
+	dialConn, lconn := newQUICPipe(t)
+	defer dialConn.Close()
+
+	// The dialer sends an identity frame exceeding the pre-authentication limit.
+	go writeQUICFrame(dialConn.control, make([]byte, quicMaxAuthSize+1))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	control, err := lconn.conn.AcceptStream(ctx)
+	if err != nil {
+		t.Fatal("can't accept control stream:", err)
+	}
+	listener := newQUICTransport(newQUICConn(lconn.conn, control), nil)
+	defer listener.close(nil)
+
+	if _, err := listener.doEncHandshake(newkey()); err != errQUICFrameTooLarge {
+		t.Fatalf("wrong error for oversized auth frame: %v", err)
+	}
+}
+
+func TestQUICTransportInflightLimit(t *testing.T) {
+	dialConn, lconn := newQUICPipe(t)
+	defer dialConn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	go writeQUICFrame(dialConn.control, []byte{0})
+	control, err := lconn.conn.AcceptStream(ctx)
+	if err != nil {
+		t.Fatal("can't accept control stream:", err)
+	}
+	listener := newQUICTransport(newQUICConn(lconn.conn, control), nil).(*quicTransport)
+	defer listener.close(nil)
+	go listener.acceptStreams()
+
+	// The dialer announces maximum size frames on many streams without sending them.
+	var before runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	header := []byte{0xff, 0xff, 0xff}
+	for range 8 {
+		stream, err := dialConn.conn.NewSendOnlyStream(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream.Write(header)
+		stream.Flush()
+	}
+	// Only the frames fitting into the budget may be allocated.
+	for listener.inflight.TryAcquire(1) {
+		listener.inflight.Release(1)
+		if ctx.Err() != nil {
+			t.Fatal("frames were not reserved")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(200 * time.Millisecond)
+	runtime.GC()
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	if stats.HeapAlloc > before.HeapAlloc+2*quicMaxInflight {
+		t.Fatalf("too much memory allocated for pending frames: %d bytes", stats.HeapAlloc-before.HeapAlloc)
+	}
+}
+
+func TestServerQUIC(t *testing.T) {
+	connected := make(chan *Peer, 2)
+	newServer := func() *Server {
+		config := Config{
+			Name:        "test",
+			MaxPeers:    10,
+			ListenAddr:  "127.0.0.1:0",
+			QUICAddr:    "127.0.0.1:0",
+			NoDiscovery: true,
+			PrivateKey:  newkey(),
+			Logger:      log.New(),
+		}
+		srv := &Server{
+			Config:      config,
+			newPeerHook: func(p *Peer) { connected <- p },
+		}
+		if err := srv.Start(); err != nil {
+			t.Fatalf("could not start: %v", err)
+		}
+		return srv
+	}
+	srv1, srv2 := newServer(), newServer()
+	defer srv1.Stop()
+	defer srv2.Stop()
+
+	if _, ok := srv2.Self().QUICEndpoint(); !ok {
+		t.Fatal("QUIC endpoint not announced")
+	}
+	srv1.AddPeer(srv2.Self())
+	for i := 0; i < 2; i++ {
+		select {
+		case p := <-connected:
+			if _, ok := p.RemoteAddr().(*net.UDPAddr); !ok {
+				t.Fatalf("peer not connected over QUIC: %v", p.RemoteAddr())
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatal("peers not connected")
+		}
+	}
+}
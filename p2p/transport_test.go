@@ -134,7 +134,7 @@ func TestProtocolHandshakeErrors(t *testing.T) {
 		{
 			code: handshakeMsg,
 			msg:  []byte{1, 2, 3},
-			err:  newPeerError(errInvalidMsg, "(code 0) (size 4) rlp: expected input list for p2p.protoHandshake"),
+			err:  newMalformedError(errInvalidMsg, "(code 0) (size 4) rlp: expected input list for p2p.protoHandshake"),
 		},
 		{
 			code: handshakeMsg,
@@ -138,6 +138,17 @@ func (db *Database) HistoricStateReader(root common.Hash) (*pathdb.HistoricalSta
 	return pdb.HistoricReader(root)
 }
 
+// StateDiff returns the flat state changes which bring the state from the given
+// root forward to the given descendant state. It's only supported by path-based
+// database and will return an error for others.
+func (db *Database) StateDiff(from, to common.Hash) (map[common.Hash][]byte, map[common.Hash]map[common.Hash][]byte, error) {
+	pdb, ok := db.backend.(*pathdb.Database)
+	if !ok {
+		return nil, nil, errors.New("not supported")
+	}
+	return pdb.StateDiff(from, to)
+}
+
 // HistoricNodeReader constructs a reader for accessing the historical trie node.
 func (db *Database) HistoricNodeReader(root common.Hash) (*pathdb.HistoricalNodeReader, error) {
 	pdb, ok := db.backend.(*pathdb.Database)
@@ -302,15 +313,20 @@ func (db *Database) Recoverable(root common.Hash) (bool, error) {
 
 // Disable deactivates the database and invalidates all available state layers
 // as stale to prevent access to the persistent state, which is in the syncing
-// stage.
+// stage. For the hash-based database, the reference counts of the online pruner
+// are dropped, as the synced nodes are written bypassing the database.
 //
-// It's only supported by path-based database and will return an error for others.
+// It's only supported by path-based and hash-based database and will return an
+// error for others.
 func (db *Database) Disable() error {
-	pdb, ok := db.backend.(*pathdb.Database)
-	if !ok {
+	switch b := db.backend.(type) {
+	case *pathdb.Database:
+		return b.Disable()
+	case *hashdb.Database:
+		return b.Disable()
+	default:
 		return errors.New("not supported")
 	}
-	return pdb.Disable()
 }
 
 // Enable activates database and resets the state tree with the provided persistent
@@ -335,6 +351,28 @@ func (db *Database) Journal(root common.Hash) error {
 	return pdb.Journal(root)
 }
 
+// Flush persists the state accumulated in the write buffer of the path-based
+// database, keeping the in-memory diff layers. It's a noop for the hash-based
+// database, whose dirty nodes are only persisted by explicit commits.
+func (db *Database) Flush() error {
+	pdb, ok := db.backend.(*pathdb.Database)
+	if !ok {
+		return nil
+	}
+	return pdb.Flush()
+}
+
+// CheckpointHistory creates a consistent copy of the state history freezers in
+// the given ancient directory. It's a noop for the hash-based database, which
+// doesn't keep state history.
+func (db *Database) CheckpointHistory(ancientDir string) error {
+	pdb, ok := db.backend.(*pathdb.Database)
+	if !ok {
+		return nil
+	}
+	return pdb.CheckpointHistory(ancientDir)
+}
+
 // VerifyState traverses the flat states specified by the given state root and
 // ensures they are matched with each other.
 func (db *Database) VerifyState(root common.Hash) error {
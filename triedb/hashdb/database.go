@@ -63,6 +63,7 @@ var (
 // Config contains the settings for database.
 type Config struct {
 	CleanCacheSize int // Maximum memory allowance (in bytes) for caching clean nodes
+	PruneRetention int // Number of committed states retained by the online pruner (0 = disabled)
 }
 
 // Defaults is the default setting for database if it's not specified.
@@ -95,6 +96,9 @@ type Database struct {
 	dirtiesSize  common.StorageSize // Storage size of the dirty node cache (exc. metadata)
 	childrenSize common.StorageSize // Storage size of the external children tracking
 
+	pruner    *pruner // Online pruner of the persisted trie nodes, nil if disabled
+	staleRefs bool    // Flag whether reference counts of a previous pruner are left on disk
+
 	lock sync.RWMutex
 }
 
@@ -132,11 +136,20 @@ func New(diskdb ethdb.Database, config *Config) *Database {
 	if config.CleanCacheSize > 0 {
 		cleans = fastcache.New(config.CleanCacheSize)
 	}
-	return &Database{
+	db := &Database{
 		diskdb:  diskdb,
 		cleans:  cleans,
 		dirties: make(map[common.Hash]*cachedNode),
 	}
+	if config.PruneRetention > 0 {
+		db.pruner = newPruner(db, config.PruneRetention)
+	} else {
+		// The reference counts left by a previously enabled pruner would become
+		// inconsistent once nodes are written without tracking, drop them before
+		// the first write.
+		db.staleRefs = rawdb.HasTrieRefRoots(diskdb)
+	}
+	return db
 }
 
 // insert inserts a trie node into the memory database. All nodes inserted by
@@ -157,6 +170,8 @@ func (db *Database) insert(hash common.Hash, node []byte) {
 	entry.forChildren(func(child common.Hash) {
 		if c := db.dirties[child]; c != nil {
 			c.parents++
+		} else if db.pruner != nil {
+			db.pruner.hold(child)
 		}
 	})
 	db.dirties[hash] = entry
@@ -226,9 +241,13 @@ func (db *Database) Reference(child common.Hash, parent common.Hash) {
 
 // reference is the private locked version of Reference.
 func (db *Database) reference(child common.Hash, parent common.Hash) {
-	// If the node does not exist, it's a node pulled from disk, skip
+	// If the node does not exist, it's a node pulled from disk, skip unless
+	// the references to the persisted nodes are tracked by the pruner.
 	node, ok := db.dirties[child]
 	if !ok {
+		if db.pruner != nil {
+			db.referenceDisk(child, parent)
+		}
 		return
 	}
 	// The reference is for state root, increase the reference counter.
@@ -249,6 +268,25 @@ func (db *Database) reference(child common.Hash, parent common.Hash) {
 	db.childrenSize += common.HashLength
 }
 
+// referenceDisk adds a reference from the memory to a node on disk, recording
+// it as an external child of the parent so that the reference is released or
+// persisted along with the parent.
+func (db *Database) referenceDisk(child common.Hash, parent common.Hash) {
+	if parent == (common.Hash{}) {
+		db.pruner.hold(child)
+		return
+	}
+	if db.dirties[parent].external == nil {
+		db.dirties[parent].external = make(map[common.Hash]struct{})
+	}
+	if _, ok := db.dirties[parent].external[child]; ok {
+		return
+	}
+	db.pruner.hold(child)
+	db.dirties[parent].external[child] = struct{}{}
+	db.childrenSize += common.HashLength
+}
+
 // Dereference removes an existing reference from a root node.
 func (db *Database) Dereference(root common.Hash) {
 	// Sanity check to ensure that the meta-root is not removed
@@ -279,6 +317,9 @@ func (db *Database) dereference(hash common.Hash) {
 	// If the node does not exist, it's a previously committed node.
 	node, ok := db.dirties[hash]
 	if !ok {
+		if db.pruner != nil {
+			db.pruner.release(hash)
+		}
 		return
 	}
 	// If there are no more references to the node, delete it and cascade
@@ -286,8 +327,11 @@ func (db *Database) dereference(hash common.Hash) {
 		// This is a special cornercase where a node loaded from disk (i.e. not in the
 		// memcache any more) gets reinjected as a new node (short node split into full,
 		// then reverted into short), causing a cached node to have no parents. That is
-		// no problem in itself, but don't make maxint parents out of it.
+		// no problem in itself, but don't make maxint parents out of it. The
+		// reference was tracked as a reference to the disk in this case.
 		node.parents--
+	} else if db.pruner != nil {
+		db.pruner.release(hash)
 	}
 	if node.parents == 0 {
 		// Remove the node from the flush-list
@@ -324,6 +368,10 @@ func (db *Database) Cap(limit common.StorageSize) error {
 	db.lock.Lock()
 	defer db.lock.Unlock()
 
+	if err := db.dropStaleRefs(); err != nil {
+		return err
+	}
+
 	// Create a database batch to flush persistent data out. It is important that
 	// outside code doesn't see an inconsistent state (referenced data removed from
 	// memory cache during commit but not yet in persistent storage). This is ensured
@@ -337,12 +385,33 @@ func (db *Database) Cap(limit common.StorageSize) error {
 	size := db.dirtiesSize + common.StorageSize(len(db.dirties)*cachedNodeSize)
 	size += db.childrenSize
 
-	// Keep committing nodes from the flush-list until we're below allowance
+	// Determine the nodes to flush from the flush-list until we're below allowance
+	var flush []common.Hash
+
 	oldest := db.oldest
 	for size > limit && oldest != (common.Hash{}) {
-		// Fetch the oldest referenced node and push into the batch
+		flush = append(flush, oldest)
+
+		// Iterate to the next flush item, or abort if the size cap was achieved. Size
+		// is the total size, including the useful cached data (hash -> blob), the
+		// cache item metadata, as well as external children mappings.
 		node := db.dirties[oldest]
-		rawdb.WriteLegacyTrieNode(batch, oldest, node.node)
+		size -= common.StorageSize(common.HashLength + len(node.node) + cachedNodeSize)
+		if node.external != nil {
+			size -= common.StorageSize(len(node.external) * common.HashLength)
+		}
+		oldest = node.flushNext
+	}
+	// Track the references of the flushed nodes before writing them out
+	if db.pruner != nil && len(flush) > 0 {
+		if err := db.pruner.writeChanges(db.pruner.track(flush), db.pruner.roots); err != nil {
+			log.Error("Failed to write trie node references", "err", err)
+			return err
+		}
+	}
+	// Keep committing nodes from the flush-list
+	for _, hash := range flush {
+		rawdb.WriteLegacyTrieNode(batch, hash, db.dirties[hash].node)
 
 		// If we exceeded the ideal batch size, commit and reset
 		if batch.ValueSize() >= ethdb.IdealBatchSize {
@@ -352,14 +421,6 @@ func (db *Database) Cap(limit common.StorageSize) error {
 			}
 			batch.Reset()
 		}
-		// Iterate to the next flush item, or abort if the size cap was achieved. Size
-		// is the total size, including the useful cached data (hash -> blob), the
-		// cache item metadata, as well as external children mappings.
-		size -= common.StorageSize(common.HashLength + len(node.node) + cachedNodeSize)
-		if node.external != nil {
-			size -= common.StorageSize(len(node.external) * common.HashLength)
-		}
-		oldest = node.flushNext
 	}
 	// Flush out any remainder data from the last batch
 	if err := batch.Write(); err != nil {
@@ -370,6 +431,9 @@ func (db *Database) Cap(limit common.StorageSize) error {
 	for db.oldest != oldest {
 		node := db.dirties[db.oldest]
 		delete(db.dirties, db.oldest)
+		if db.pruner != nil {
+			db.pruner.evict(db.oldest, node)
+		}
 		db.oldest = node.flushNext
 
 		db.dirtiesSize -= common.StorageSize(common.HashLength + len(node.node))
@@ -401,6 +465,23 @@ func (db *Database) Commit(node common.Hash, report bool) error {
 	db.lock.Lock()
 	defer db.lock.Unlock()
 
+	if err := db.dropStaleRefs(); err != nil {
+		return err
+	}
+	// Track the references of the committed nodes and pin the state root before
+	// writing the nodes out, so that an interruption can only leak nodes.
+	var stale []common.Hash
+	if db.pruner != nil {
+		var (
+			nodes []common.Hash
+			err   error
+		)
+		db.gather(node, make(map[common.Hash]struct{}), &nodes)
+		if stale, err = db.pruner.pin(node, nodes); err != nil {
+			log.Error("Failed to write trie node references", "err", err)
+			return err
+		}
+	}
 	// Create a database batch to flush persistent data out. It is important that
 	// outside code doesn't see an inconsistent state (referenced data removed from
 	// memory cache during commit but not yet in persistent storage). This is ensured
@@ -427,6 +508,15 @@ func (db *Database) Commit(node common.Hash, report bool) error {
 	}
 	batch.Reset()
 
+	// Release the states beyond the retention of the pruner now that the new
+	// one is fully persisted
+	if db.pruner != nil {
+		if err := db.pruner.unpin(stale); err != nil {
+			log.Error("Failed to unpin stale tries", "err", err)
+			return err
+		}
+	}
+
 	// Reset the storage counters and bumped metrics
 	memcacheCommitTimeTimer.Update(time.Since(start))
 	memcacheCommitBytesMeter.Mark(int64(storage - db.dirtiesSize))
@@ -479,6 +569,39 @@ func (db *Database) commit(hash common.Hash, batch ethdb.Batch, uncacher *cleane
 	return nil
 }
 
+// gather collects the dirty nodes reachable from the given one, in the order
+// they are committed, i.e. children first.
+func (db *Database) gather(hash common.Hash, seen map[common.Hash]struct{}, nodes *[]common.Hash) {
+	node, ok := db.dirties[hash]
+	if !ok {
+		return
+	}
+	if _, ok := seen[hash]; ok {
+		return
+	}
+	seen[hash] = struct{}{}
+
+	node.forChildren(func(child common.Hash) {
+		db.gather(child, seen, nodes)
+	})
+	*nodes = append(*nodes, hash)
+}
+
+// dropStaleRefs removes the reference counts left on disk by a previously
+// enabled pruner before any node is written without tracking. This function
+// assumes the lock is already held.
+func (db *Database) dropStaleRefs() error {
+	if !db.staleRefs {
+		return nil
+	}
+	log.Warn("Dropping stale trie node references")
+	if err := rawdb.DeleteTrieRefs(db.diskdb); err != nil {
+		return err
+	}
+	db.staleRefs = false
+	return nil
+}
+
 // cleaner is a database batch replayer that takes a batch of write operations
 // and cleans up the trie database from anything written to disk.
 type cleaner struct {
@@ -516,6 +639,9 @@ func (c *cleaner) Put(key []byte, rlp []byte) error {
 	}
 	// Remove the node from the dirty cache
 	delete(c.db.dirties, hash)
+	if c.db.pruner != nil {
+		c.db.pruner.evict(hash, node)
+	}
 	c.db.dirtiesSize -= common.StorageSize(common.HashLength + len(node.node))
 	if node.external != nil {
 		c.db.childrenSize -= common.StorageSize(len(node.external) * common.HashLength)
@@ -601,8 +727,25 @@ func (db *Database) Size() (common.StorageSize, common.StorageSize) {
 	return 0, db.dirtiesSize + db.childrenSize + metadataSize
 }
 
+// Disable drops the reference counts of the persisted trie nodes, as the state
+// sync is about to write nodes bypassing the database. All the nodes on disk
+// are retained permanently afterwards, only the ones written later are pruned.
+// This also discards the pending work of the online pruner.
+func (db *Database) Disable() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	if db.pruner == nil {
+		return db.dropStaleRefs()
+	}
+	return db.pruner.reset()
+}
+
 // Close closes the trie database and releases all held resources.
 func (db *Database) Close() error {
+	if db.pruner != nil {
+		db.pruner.close()
+	}
 	if db.cleans != nil {
 		db.cleans.Reset()
 	}
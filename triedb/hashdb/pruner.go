@@ -0,0 +1,460 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hashdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+var (
+	pruneTimeTimer  = metrics.NewRegisteredResettingTimer("hashdb/prune/time", nil)
+	pruneNodesMeter = metrics.NewRegisteredMeter("hashdb/prune/nodes", nil)
+	pruneBytesMeter = metrics.NewRegisteredMeter("hashdb/prune/bytes", nil)
+)
+
+// pruneBatchNodes is the maximum number of trie nodes deleted from the disk in
+// a single atomic batch, bounding the time the database lock is held.
+const pruneBatchNodes = 10_000
+
+// refRecord is the persisted reference counting metadata of a trie node.
+type refRecord struct {
+	refs      uint64        // Number of references from the nodes on disk and the pinned roots
+	externals []common.Hash // External children (storage roots) not embedded in the node blob
+}
+
+// encode serializes the record as the uvarint reference count followed by the
+// concatenated external children.
+func (r *refRecord) encode() []byte {
+	blob := binary.AppendUvarint(nil, r.refs)
+	for _, hash := range r.externals {
+		blob = append(blob, hash.Bytes()...)
+	}
+	return blob
+}
+
+// decodeRefRecord deserializes the reference counting metadata of a node.
+func decodeRefRecord(blob []byte) (*refRecord, error) {
+	refs, n := binary.Uvarint(blob)
+	if n <= 0 {
+		return nil, errors.New("invalid reference count")
+	}
+	blob = blob[n:]
+	if len(blob)%common.HashLength != 0 {
+		return nil, errors.New("invalid external children")
+	}
+	rec := &refRecord{refs: refs}
+	for len(blob) > 0 {
+		rec.externals = append(rec.externals, common.BytesToHash(blob[:common.HashLength]))
+		blob = blob[common.HashLength:]
+	}
+	return rec, nil
+}
+
+// refChanges is an in-memory overlay of the reference counting metadata, which
+// accumulates the changes made by a single operation to be written atomically.
+//
+// The trie nodes on disk without a record are untracked: they were persisted
+// before the pruner was enabled and are never deleted. Since the children are
+// always persisted before their parents, the untracked nodes only reference the
+// untracked ones.
+type refChanges struct {
+	reader  ethdb.KeyValueReader
+	records map[common.Hash]*refRecord // Loaded records, nil for the untracked nodes
+	dirty   map[common.Hash]struct{}   // Records modified in the overlay
+	garbage map[common.Hash]bool       // Garbage markers to set (true) or clear (false)
+}
+
+func newRefChanges(reader ethdb.KeyValueReader) *refChanges {
+	return &refChanges{
+		reader:  reader,
+		records: make(map[common.Hash]*refRecord),
+		dirty:   make(map[common.Hash]struct{}),
+		garbage: make(map[common.Hash]bool),
+	}
+}
+
+// load retrieves the record of the node, or nil if the node is not tracked.
+func (c *refChanges) load(hash common.Hash) *refRecord {
+	if rec, ok := c.records[hash]; ok {
+		return rec
+	}
+	var rec *refRecord
+	if blob := rawdb.ReadTrieNodeRefs(c.reader, hash); len(blob) > 0 {
+		var err error
+		if rec, err = decodeRefRecord(blob); err != nil {
+			log.Error("Invalid trie node references", "hash", hash, "err", err)
+		}
+	}
+	c.records[hash] = rec
+	return rec
+}
+
+// create starts tracking a node newly written to disk.
+func (c *refChanges) create(hash common.Hash, externals []common.Hash) {
+	c.records[hash] = &refRecord{externals: externals}
+	c.dirty[hash] = struct{}{}
+}
+
+// increase adds a reference to the node, rescuing it from the deletion if it
+// was unreferenced.
+func (c *refChanges) increase(hash common.Hash) {
+	rec := c.load(hash)
+	if rec == nil {
+		return
+	}
+	if rec.refs == 0 {
+		c.garbage[hash] = false
+	}
+	rec.refs++
+	c.dirty[hash] = struct{}{}
+}
+
+// decrease removes a reference from the node, returning whether the node became
+// unreferenced and was marked for deletion.
+func (c *refChanges) decrease(hash common.Hash) bool {
+	rec := c.load(hash)
+	if rec == nil {
+		return false
+	}
+	if rec.refs == 0 {
+		log.Error("Trie node reference underflow", "hash", hash)
+		return false
+	}
+	rec.refs--
+	c.dirty[hash] = struct{}{}
+
+	if rec.refs == 0 {
+		c.garbage[hash] = true
+		return true
+	}
+	return false
+}
+
+// remove stops tracking a node deleted from disk.
+func (c *refChanges) remove(hash common.Hash) {
+	c.records[hash] = nil
+	c.dirty[hash] = struct{}{}
+	c.garbage[hash] = false
+}
+
+// write flushes the accumulated changes into the given writer.
+func (c *refChanges) write(w ethdb.KeyValueWriter) {
+	for hash := range c.dirty {
+		if rec := c.records[hash]; rec != nil {
+			rawdb.WriteTrieNodeRefs(w, hash, rec.encode())
+		} else {
+			rawdb.DeleteTrieNodeRefs(w, hash)
+		}
+	}
+	for hash, mark := range c.garbage {
+		if mark {
+			rawdb.WriteTrieNodeGarbage(w, hash)
+		} else {
+			rawdb.DeleteTrieNodeGarbage(w, hash)
+		}
+	}
+}
+
+// pruner is the online state pruner of the hash-based database. It tracks the
+// reference counts of the trie nodes persisted by the database, pins the most
+// recently committed state roots and garbage collects the nodes no longer
+// referenced in the background.
+//
+// The references of the dirty nodes cached in memory to the nodes on disk are
+// tracked separately, preventing the deletion of the nodes still reachable
+// from the in-memory states which haven't been committed yet.
+//
+// It's crash-safe: the reference counts are always persisted before the nodes
+// themselves, and each garbage collection round is written atomically, so an
+// interruption may only leak some nodes but never delete referenced ones.
+//
+// All the fields are protected by the database lock.
+type pruner struct {
+	db        *Database
+	retention int                    // Number of committed state roots pinned on disk
+	roots     []common.Hash          // Pinned state roots, ordered from oldest to newest
+	diskrefs  map[common.Hash]uint32 // Number of references from memory to nodes on disk
+	released  []common.Hash          // Nodes on disk released from memory, to check for deletion
+
+	wake chan struct{}
+	quit chan struct{}
+	term chan struct{}
+}
+
+// newPruner creates the online pruner and starts garbage collecting any nodes
+// left unreferenced by the previous run.
+func newPruner(db *Database, retention int) *pruner {
+	p := &pruner{
+		db:        db,
+		retention: retention,
+		roots:     rawdb.ReadTrieRefRoots(db.diskdb),
+		diskrefs:  make(map[common.Hash]uint32),
+		wake:      make(chan struct{}, 1),
+		quit:      make(chan struct{}),
+		term:      make(chan struct{}),
+	}
+	// Mark the database as reference tracked, even before the first state
+	// is committed.
+	if !rawdb.HasTrieRefRoots(db.diskdb) {
+		rawdb.WriteTrieRefRoots(db.diskdb, nil)
+	}
+	go p.loop()
+	p.signal()
+	return p
+}
+
+// signal wakes up the background garbage collector.
+func (p *pruner) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// close terminates the background garbage collector.
+func (p *pruner) close() {
+	select {
+	case <-p.quit:
+	default:
+		close(p.quit)
+	}
+	<-p.term
+}
+
+// hold registers a reference from the memory to a node which isn't cached as
+// dirty, i.e. it's either persisted or about to be resolved from disk.
+func (p *pruner) hold(hash common.Hash) {
+	p.diskrefs[hash]++
+}
+
+// release removes a reference from the memory to a node on disk. If it was the
+// last one, the node is queued for the deletion check.
+func (p *pruner) release(hash common.Hash) {
+	refs, ok := p.diskrefs[hash]
+	if !ok {
+		return
+	}
+	if refs > 1 {
+		p.diskrefs[hash] = refs - 1
+		return
+	}
+	delete(p.diskrefs, hash)
+	p.released = append(p.released, hash)
+	p.signal()
+}
+
+// evict is invoked when a dirty node is persisted and removed from the cache.
+// The references it still has from the memory are carried over, whereas the
+// ones it holds to its children are now tracked on disk.
+func (p *pruner) evict(hash common.Hash, node *cachedNode) {
+	if node.parents > 0 {
+		p.diskrefs[hash] += node.parents
+	}
+	node.forChildren(func(child common.Hash) {
+		if _, ok := p.db.dirties[child]; !ok {
+			p.release(child)
+		}
+	})
+}
+
+// track creates the reference counting records of the given dirty nodes about
+// to be persisted, ordered with the children first. The nodes already present
+// on disk are skipped as their children are accounted for already.
+func (p *pruner) track(nodes []common.Hash) *refChanges {
+	changes := newRefChanges(p.db.diskdb)
+	for _, hash := range nodes {
+		if changes.load(hash) != nil || rawdb.HasLegacyTrieNode(p.db.diskdb, hash) {
+			continue
+		}
+		node := p.db.dirties[hash]
+
+		var externals []common.Hash
+		for child := range node.external {
+			externals = append(externals, child)
+		}
+		changes.create(hash, externals)
+		node.forChildren(changes.increase)
+	}
+	return changes
+}
+
+// writeChanges persists the reference counting changes along with the pinned
+// state roots.
+func (p *pruner) writeChanges(changes *refChanges, roots []common.Hash) error {
+	batch := p.db.diskdb.NewBatch()
+	changes.write(batch)
+	rawdb.WriteTrieRefRoots(batch, roots)
+	return batch.Write()
+}
+
+// pin tracks the given nodes about to be committed and pins the state root. It
+// returns the roots to unpin once the state is persisted.
+func (p *pruner) pin(root common.Hash, nodes []common.Hash) ([]common.Hash, error) {
+	changes := p.track(nodes)
+	changes.increase(root)
+
+	roots := append(p.roots, root)
+	if err := p.writeChanges(changes, roots); err != nil {
+		return nil, err
+	}
+	p.roots = roots
+	if len(p.roots) <= p.retention {
+		return nil, nil
+	}
+	return p.roots[:len(p.roots)-p.retention], nil
+}
+
+// unpin releases the stale state roots, marking the nodes no longer referenced
+// for deletion.
+func (p *pruner) unpin(stale []common.Hash) error {
+	if len(stale) == 0 {
+		return nil
+	}
+	changes := newRefChanges(p.db.diskdb)
+	for _, root := range stale {
+		changes.decrease(root)
+	}
+	roots := p.roots[len(stale):]
+	if err := p.writeChanges(changes, roots); err != nil {
+		return err
+	}
+	p.roots = roots
+	p.signal()
+	return nil
+}
+
+// reset drops all the reference counting data, turning every node on disk into
+// an untracked one.
+func (p *pruner) reset() error {
+	if len(p.roots) > 0 || len(p.released) > 0 {
+		log.Warn("Resetting online state pruner, existing trie nodes will not be pruned", "roots", len(p.roots), "released", len(p.released))
+	}
+	p.roots, p.released = nil, nil
+	if err := rawdb.DeleteTrieRefs(p.db.diskdb); err != nil {
+		return err
+	}
+	rawdb.WriteTrieRefRoots(p.db.diskdb, nil)
+	return nil
+}
+
+// loop is the background garbage collector, deleting the unreferenced nodes
+// in batches until there are none left.
+func (p *pruner) loop() {
+	defer close(p.term)
+
+	for {
+		select {
+		case <-p.quit:
+			return
+		case <-p.wake:
+		}
+		for {
+			more, err := p.collect()
+			if err != nil {
+				log.Error("Failed to prune trie nodes", "err", err)
+				break
+			}
+			if !more {
+				break
+			}
+			select {
+			case <-p.quit:
+				return
+			default:
+			}
+		}
+	}
+}
+
+// collect deletes a batch of unreferenced trie nodes, cascading into their
+// children. It returns whether there might be more nodes to delete.
+func (p *pruner) collect() (bool, error) {
+	db := p.db
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	// Gather the nodes released from memory first, then the ones marked as
+	// garbage on disk
+	n := min(len(p.released), pruneBatchNodes)
+	queue := append([]common.Hash{}, p.released[:n]...)
+	p.released = p.released[n:]
+
+	if len(queue) < pruneBatchNodes {
+		queue = append(queue, rawdb.ReadTrieNodeGarbage(db.diskdb, pruneBatchNodes-len(queue))...)
+	}
+	if len(queue) == 0 {
+		return false, nil
+	}
+	var (
+		start   = time.Now()
+		changes = newRefChanges(db.diskdb)
+		batch   = db.diskdb.NewBatch()
+		nodes   int
+		size    common.StorageSize
+	)
+	onChild := func(child common.Hash) {
+		if changes.decrease(child) && len(queue) < 2*pruneBatchNodes {
+			queue = append(queue, child)
+		}
+	}
+	for i := 0; i < len(queue); i++ {
+		hash := queue[i]
+
+		// Skip the nodes still referenced from either the memory or disk
+		changes.garbage[hash] = false
+		if p.diskrefs[hash] > 0 {
+			continue
+		}
+		rec := changes.load(hash)
+		if rec == nil || rec.refs > 0 {
+			continue
+		}
+		changes.remove(hash)
+		if blob := rawdb.ReadLegacyTrieNode(db.diskdb, hash); len(blob) > 0 {
+			rawdb.DeleteLegacyTrieNode(batch, hash)
+			trie.ForGatherChildren(blob, onChild)
+
+			nodes++
+			size += common.StorageSize(common.HashLength + len(blob))
+		}
+		for _, child := range rec.externals {
+			onChild(child)
+		}
+		if db.cleans != nil {
+			db.cleans.Del(hash[:])
+		}
+	}
+	changes.write(batch)
+	if err := batch.Write(); err != nil {
+		return false, err
+	}
+	pruneTimeTimer.UpdateSince(start)
+	pruneNodesMeter.Mark(int64(nodes))
+	pruneBytesMeter.Mark(int64(size))
+
+	log.Debug("Pruned trie nodes from disk", "nodes", nodes, "size", size, "elapsed", common.PrettyDuration(time.Since(start)))
+	return true, nil
+}
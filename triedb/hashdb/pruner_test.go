@@ -0,0 +1,270 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package hashdb
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/ethereum/go-ethereum/trie/trienode"
+)
+
+// pruneTester generates a chain of states with storage tries on top of the
+// database, mimicking the reference handling of the blockchain.
+type pruneTester struct {
+	t     *testing.T
+	db    *Database
+	rand  *rand.Rand
+	root  common.Hash
+	block uint64
+}
+
+func newPruneTester(t *testing.T, db *Database) *pruneTester {
+	return &pruneTester{t: t, db: db, rand: rand.New(rand.NewSource(1)), root: types.EmptyRootHash}
+}
+
+// next generates a new state on top of the current one, inserts it into the
+// database and references its root.
+func (pt *pruneTester) next() common.Hash {
+	accTrie, err := trie.New(trie.StateTrieID(pt.root), pt.db)
+	if err != nil {
+		pt.t.Fatalf("Failed to open account trie: %v", err)
+	}
+	nodes := trienode.NewMergedNodeSet()
+	for _, index := range pt.rand.Perm(16)[:3] {
+		var (
+			owner   = common.Hash{byte(index + 1)}
+			account = types.NewEmptyStateAccount()
+		)
+		if blob := accTrie.MustGet(owner.Bytes()); len(blob) > 0 {
+			if err := rlp.DecodeBytes(blob, account); err != nil {
+				pt.t.Fatalf("Failed to decode account: %v", err)
+			}
+		}
+		stTrie, err := trie.New(trie.StorageTrieID(pt.root, owner, account.Root), pt.db)
+		if err != nil {
+			pt.t.Fatalf("Failed to open storage trie: %v", err)
+		}
+		for j := 0; j < 4; j++ {
+			key, val := make([]byte, 32), make([]byte, 32)
+			key[0] = byte(pt.rand.Intn(64))
+			pt.rand.Read(val)
+			stTrie.MustUpdate(key, val)
+		}
+		root, set := stTrie.Commit(false)
+		if set != nil {
+			if err := nodes.Merge(set); err != nil {
+				pt.t.Fatalf("Failed to merge storage nodes: %v", err)
+			}
+		}
+		account.Root = root
+		account.Nonce = pt.block
+		blob, _ := rlp.EncodeToBytes(account)
+		accTrie.MustUpdate(owner.Bytes(), blob)
+	}
+	root, set := accTrie.Commit(true)
+	if err := nodes.Merge(set); err != nil {
+		pt.t.Fatalf("Failed to merge account nodes: %v", err)
+	}
+	pt.block++
+	if err := pt.db.Update(root, pt.root, pt.block, nodes); err != nil {
+		pt.t.Fatalf("Failed to update database: %v", err)
+	}
+	pt.db.Reference(root, common.Hash{})
+	pt.root = root
+	return root
+}
+
+// drain runs the garbage collection until no unreferenced nodes are left.
+func drain(t *testing.T, db *Database) {
+	for {
+		more, err := db.pruner.collect()
+		if err != nil {
+			t.Fatalf("Failed to prune: %v", err)
+		}
+		if !more {
+			return
+		}
+	}
+}
+
+// reachable collects the hashes of all the nodes reachable from the given state
+// roots, failing if any of them is missing.
+func reachable(t *testing.T, db *Database, roots ...common.Hash) map[common.Hash]struct{} {
+	nodes := make(map[common.Hash]struct{})
+	iterate := func(id *trie.ID) []common.Hash {
+		tr, err := trie.New(id, db)
+		if err != nil {
+			t.Fatalf("Failed to open trie %x: %v", id.Root, err)
+		}
+		var leaves []common.Hash
+		it := tr.MustNodeIterator(nil)
+		for it.Next(true) {
+			if it.Hash() != (common.Hash{}) {
+				nodes[it.Hash()] = struct{}{}
+			}
+			if it.Leaf() && id.Owner == (common.Hash{}) {
+				var account types.StateAccount
+				if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+					t.Fatalf("Failed to decode account: %v", err)
+				}
+				if account.Root != types.EmptyRootHash {
+					leaves = append(leaves, common.BytesToHash(it.LeafKey()), account.Root)
+				}
+			}
+		}
+		if it.Error() != nil {
+			t.Fatalf("Incomplete trie %x: %v", id.Root, it.Error())
+		}
+		return leaves
+	}
+	for _, root := range roots {
+		leaves := iterate(trie.StateTrieID(root))
+		for i := 0; i < len(leaves); i += 2 {
+			iterate(trie.StorageTrieID(root, leaves[i], leaves[i+1]))
+		}
+	}
+	return nodes
+}
+
+// checkPruned verifies that the disk contains exactly the nodes reachable from
+// the given state roots.
+func checkPruned(t *testing.T, disk ethdb.Database, db *Database, roots ...common.Hash) {
+	t.Helper()
+
+	want := reachable(t, db, roots...)
+	have := 0
+	it := disk.NewIterator(nil, nil)
+	defer it.Release()
+	for it.Next() {
+		if len(it.Key()) != common.HashLength {
+			continue
+		}
+		if _, ok := want[common.BytesToHash(it.Key())]; !ok {
+			t.Errorf("Unreferenced node %x left on disk", it.Key())
+		}
+		have++
+	}
+	if have != len(want) {
+		t.Fatalf("Node count mismatch: have %d, want %d", have, len(want))
+	}
+}
+
+func TestPrunerRetention(t *testing.T) {
+	var (
+		disk  = rawdb.NewMemoryDatabase()
+		db    = New(disk, &Config{PruneRetention: 3})
+		pt    = newPruneTester(t, db)
+		roots []common.Hash
+	)
+	defer db.Close()
+
+	for i := 0; i < 20; i++ {
+		roots = append(roots, pt.next())
+		if err := db.Commit(pt.root, false); err != nil {
+			t.Fatalf("Failed to commit: %v", err)
+		}
+		if i > 0 {
+			db.Dereference(roots[i-1])
+		}
+	}
+	db.Dereference(pt.root)
+	drain(t, db)
+
+	checkPruned(t, disk, db, roots[len(roots)-3:]...)
+	if rawdb.HasLegacyTrieNode(disk, roots[len(roots)-4]) {
+		t.Fatal("State beyond retention not pruned")
+	}
+}
+
+func TestPrunerInMemoryStates(t *testing.T) {
+	var (
+		disk  = rawdb.NewMemoryDatabase()
+		db    = New(disk, &Config{PruneRetention: 1})
+		pt    = newPruneTester(t, db)
+		roots []common.Hash
+	)
+	defer db.Close()
+
+	// Keep the latest state in memory, flushing all the dirty nodes every other
+	// block and committing occasionally
+	for i := 0; i < 30; i++ {
+		roots = append(roots, pt.next())
+		if i > 0 {
+			db.Dereference(roots[i-1])
+		}
+		drain(t, db)
+
+		// The state referenced from memory must remain available
+		reachable(t, db, pt.root)
+
+		if i%2 == 1 {
+			if err := db.Cap(0); err != nil {
+				t.Fatalf("Failed to cap: %v", err)
+			}
+		}
+		if i%5 == 4 {
+			if err := db.Commit(pt.root, false); err != nil {
+				t.Fatalf("Failed to commit: %v", err)
+			}
+		}
+	}
+	db.Dereference(pt.root)
+	drain(t, db)
+	checkPruned(t, disk, db, pt.root)
+}
+
+func TestPrunerRestart(t *testing.T) {
+	var (
+		disk  = rawdb.NewMemoryDatabase()
+		db    = New(disk, &Config{PruneRetention: 2})
+		pt    = newPruneTester(t, db)
+		roots []common.Hash
+	)
+	for i := 0; i < 5; i++ {
+		roots = append(roots, pt.next())
+		if err := db.Commit(pt.root, false); err != nil {
+			t.Fatalf("Failed to commit: %v", err)
+		}
+		db.Dereference(pt.root)
+	}
+	db.Close()
+
+	// Reopen the database, the pending garbage must be resumed
+	db = New(disk, &Config{PruneRetention: 2})
+	drain(t, db)
+	checkPruned(t, disk, db, roots[3:]...)
+	db.Close()
+
+	// Reopen the database without pruning, the stale reference counts must be
+	// dropped before writing any node
+	db = New(disk, nil)
+	pt.db = db
+	pt.next()
+	if err := db.Commit(pt.root, false); err != nil {
+		t.Fatalf("Failed to commit: %v", err)
+	}
+	if rawdb.HasTrieRefRoots(disk) || len(rawdb.ReadTrieNodeRefs(disk, roots[4])) != 0 {
+		t.Fatal("Stale reference counts left on disk")
+	}
+}
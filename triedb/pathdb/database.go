@@ -337,6 +337,46 @@ func (db *Database) Commit(root common.Hash, report bool) error {
 	return db.tree.cap(root, 0)
 }
 
+// Flush persists the state accumulated in the write buffer of the disk layer,
+// leaving the diff layers on top untouched.
+func (db *Database) Flush() error {
+	// Hold the lock to prevent concurrent mutations.
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	// Short circuit if the mutation is not allowed.
+	if err := db.modifyAllowed(); err != nil {
+		return err
+	}
+	return db.tree.flush()
+}
+
+// CheckpointHistory creates a consistent copy of the history freezers in the
+// given ancient directory, using the same layout as the original one.
+func (db *Database) CheckpointHistory(ancientDir string) error {
+	// Hold the lock to prevent new histories being written meanwhile.
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	stateName, trienodeName := rawdb.MerkleStateFreezerName, rawdb.MerkleTrienodeFreezerName
+	if db.isVerkle {
+		stateName, trienodeName = rawdb.VerkleStateFreezerName, rawdb.VerkleTrienodeFreezerName
+	}
+	for name, freezer := range map[string]ethdb.ResettableAncientStore{stateName: db.stateFreezer, trienodeName: db.trienodeFreezer} {
+		if freezer == nil {
+			continue
+		}
+		cp, ok := freezer.(ethdb.Checkpointer)
+		if !ok {
+			return fmt.Errorf("%s history does not support checkpoints", name)
+		}
+		if err := cp.Checkpoint(filepath.Join(ancientDir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Disable deactivates the database and invalidates all available state layers
 // as stale to prevent access to the persistent state, which is in the syncing
 // stage.
@@ -777,6 +777,49 @@ func TestCommit(t *testing.T) {
 	}
 }
 
+func TestFlush(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	tester := newTester(t, &testerConfig{layers: 12})
+	defer tester.release()
+
+	var (
+		layers = tester.db.tree.len()
+		bottom = tester.db.tree.bottom()
+	)
+	if bottom.buffer.empty() {
+		t.Fatal("Write buffer is empty before flushing")
+	}
+	if err := tester.db.Flush(); err != nil {
+		t.Fatalf("Failed to flush database, err: %v", err)
+	}
+	// Verify the layer tree structure, the diff layers should be retained
+	if tester.db.tree.len() != layers {
+		t.Fatalf("Layer tree structure is invalid, want %d layers, got %d", layers, tester.db.tree.len())
+	}
+	base := tester.db.tree.bottom()
+	if base.rootHash() != bottom.rootHash() || !base.buffer.empty() {
+		t.Fatal("Disk layer is invalid")
+	}
+	if id := rawdb.ReadPersistentStateID(tester.db.diskdb); id != base.stateID() {
+		t.Fatalf("Unexpected persistent state id, want %d, got %d", base.stateID(), id)
+	}
+	// Verify the states are still accessible and can be extended
+	for i := tester.bottomIndex(); i < len(tester.roots); i++ {
+		if err := tester.verifyState(tester.roots[i]); err != nil {
+			t.Fatalf("State %d is invalid, err: %v", i, err)
+		}
+	}
+	tester.extend(2)
+	if err := tester.verifyState(tester.lastHash()); err != nil {
+		t.Fatalf("State is invalid, err: %v", err)
+	}
+}
+
 func TestJournal(t *testing.T) {
 	testJournal(t, "")
 	testJournal(t, filepath.Join(t.TempDir(), strconv.Itoa(rand.Intn(10000))))
@@ -516,6 +516,56 @@ func (dl *diskLayer) commit(bottom *diffLayer, force bool) (*diskLayer, error) {
 	return ndl, nil
 }
 
+// flushBuffer persists the content of the write buffer and returns a disk layer
+// of the same state with an empty buffer. The current disk layer is tagged as
+// stale if the buffer is not empty, otherwise it's returned as is.
+func (dl *diskLayer) flushBuffer() (*diskLayer, error) {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	if dl.stale {
+		return nil, errSnapshotStale
+	}
+	// Wait until the previous frozen buffer is fully flushed
+	if dl.frozen != nil {
+		if err := dl.frozen.waitFlush(); err != nil {
+			return nil, err
+		}
+		dl.frozen = nil
+	}
+	if dl.buffer.empty() {
+		return dl, nil
+	}
+	dl.stale = true
+
+	// Terminate the background state snapshot generator before flushing
+	// to prevent data race, resuming it afterwards.
+	var (
+		progress []byte
+		gen      = dl.generator
+	)
+	if gen != nil {
+		gen.stop()
+		progress = gen.progressMarker()
+		if progress == nil {
+			dl.setGenerator(nil)
+		}
+	}
+	dl.buffer.flush(dl.root, dl.db.diskdb, []ethdb.AncientWriter{dl.db.stateFreezer, dl.db.trienodeFreezer}, progress, dl.nodes, dl.states, dl.id, func() {
+		if progress != nil {
+			gen.run(dl.root)
+		}
+	})
+	if err := dl.buffer.waitFlush(); err != nil {
+		return nil, err
+	}
+	ndl := newDiskLayer(dl.root, dl.id, dl.db, dl.nodes, dl.states, newBuffer(dl.db.config.WriteBufferSize, nil, nil, 0), nil)
+	if dl.generator != nil {
+		ndl.setGenerator(dl.generator)
+	}
+	return ndl, nil
+}
+
 // revert applies the given state history and return a reverted disk layer.
 func (dl *diskLayer) revert(h *stateHistory) (*diskLayer, error) {
 	start := time.Now()
@@ -0,0 +1,129 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+)
+
+// MaxStateDiffSpan is the maximum number of state transitions a single state
+// diff can span, bounding the work spent on assembling it.
+const MaxStateDiffSpan = 1024
+
+// StateDiff returns the flat state changes which bring the state from the given
+// root forward to the given descendant state. The returned sets are keyed by the
+// account hash and the storage slot hash respectively, holding the values of the
+// target state in the slim format, with nil meaning the entry was deleted.
+//
+// The target state must be available in the layer tree, while the source state
+// can either be an ancestor layer, or a canonical historical state whose state
+// histories are still retained.
+func (db *Database) StateDiff(from, to common.Hash) (map[common.Hash][]byte, map[common.Hash]map[common.Hash][]byte, error) {
+	if db.isVerkle {
+		return nil, nil, errors.New("verkle state diff is not supported")
+	}
+	target := db.tree.get(to)
+	if target == nil {
+		return nil, nil, fmt.Errorf("state %#x is not available", to)
+	}
+	var (
+		accounts = make(map[common.Hash][]byte)
+		storages = make(map[common.Hash]map[common.Hash][]byte)
+	)
+	touch := func(accountSet map[common.Hash][]byte, storageSet map[common.Hash]map[common.Hash][]byte) {
+		for hash := range accountSet {
+			accounts[hash] = nil
+		}
+		for hash, slots := range storageSet {
+			if storages[hash] == nil {
+				storages[hash] = make(map[common.Hash][]byte)
+			}
+			for slot := range slots {
+				storages[hash][slot] = nil
+			}
+		}
+	}
+	// Collect the states mutated by the in-memory layers above the source state,
+	// stopping at the disk layer if the source state is not among them.
+	current := target
+	for current.rootHash() != from {
+		diff, ok := current.(*diffLayer)
+		if !ok {
+			break
+		}
+		if target.stateID()-diff.parentLayer().stateID() > MaxStateDiffSpan {
+			return nil, nil, errors.New("state diff span too large")
+		}
+		touch(diff.states.accountData, diff.states.storageData)
+		current = diff.parentLayer()
+	}
+	// Collect the states mutated by the state histories between the source state
+	// and the disk layer if it's a historical state.
+	if current.rootHash() != from {
+		if db.stateFreezer == nil {
+			return nil, nil, errors.New("state histories are not available")
+		}
+		id := rawdb.ReadStateID(db.diskdb, from)
+		if id == nil || *id >= current.stateID() {
+			return nil, nil, fmt.Errorf("state %#x is not available", from)
+		}
+		if target.stateID()-*id > MaxStateDiffSpan {
+			return nil, nil, errors.New("state diff span too large")
+		}
+		histories, err := readStateHistories(db.stateFreezer, *id+1, current.stateID()-*id)
+		if err != nil {
+			return nil, nil, err
+		}
+		// Ensure the histories link the source state to the disk layer, ruling
+		// out side chain states.
+		root := from
+		for _, h := range histories {
+			h := h.(*stateHistory)
+			if h.meta.parent != root {
+				return nil, nil, errUnexpectedHistory
+			}
+			root = h.meta.root
+			touch(h.stateSet())
+		}
+		if root != current.rootHash() {
+			return nil, nil, errUnexpectedHistory
+		}
+	}
+	// Resolve the values of all the mutated states in the target state
+	reader := &reader{db: db, state: to, layer: target}
+	for hash := range accounts {
+		blob, err := reader.AccountRLP(hash)
+		if err != nil {
+			return nil, nil, err
+		}
+		accounts[hash] = blob
+	}
+	for hash, slots := range storages {
+		for slot := range slots {
+			blob, err := reader.Storage(hash, slot)
+			if err != nil {
+				return nil, nil, err
+			}
+			slots[slot] = blob
+		}
+	}
+	return accounts, storages, nil
+}
@@ -0,0 +1,85 @@
+// Copyright 2025 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pathdb
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func TestStateDiff(t *testing.T) {
+	// Redefine the diff layer depth allowance for faster testing.
+	maxDiffLayers = 4
+	defer func() {
+		maxDiffLayers = 128
+	}()
+
+	tester := newTester(t, &testerConfig{layers: 16})
+	defer tester.release()
+
+	// Bring historical states and in-memory layers forward to the latest state
+	target := tester.lastHash()
+	sources := []common.Hash{types.EmptyRootHash, tester.roots[0], tester.roots[5], tester.roots[12], tester.roots[14]}
+	for _, from := range sources {
+		accounts, storages, err := tester.db.StateDiff(from, target)
+		if err != nil {
+			t.Fatalf("Failed to retrieve state diff from %x: %v", from, err)
+		}
+		var (
+			haveAccounts = copyAccounts(tester.snapAccounts[from])
+			haveStorages = copyStorages(tester.snapStorages[from])
+		)
+		for hash, blob := range accounts {
+			if len(blob) == 0 {
+				delete(haveAccounts, hash)
+			} else {
+				haveAccounts[hash] = blob
+			}
+		}
+		for hash, slots := range storages {
+			if haveStorages[hash] == nil {
+				haveStorages[hash] = make(map[common.Hash][]byte)
+			}
+			for slot, blob := range slots {
+				if len(blob) == 0 {
+					delete(haveStorages[hash], slot)
+				} else {
+					haveStorages[hash][slot] = blob
+				}
+			}
+			if len(haveStorages[hash]) == 0 {
+				delete(haveStorages, hash)
+			}
+		}
+		if !reflect.DeepEqual(haveAccounts, tester.accounts) {
+			t.Errorf("Account mismatch after applying diff from %x", from)
+		}
+		if !reflect.DeepEqual(haveStorages, tester.storages) {
+			t.Errorf("Storage mismatch after applying diff from %x", from)
+		}
+	}
+	// Unknown states and descendants of the target must be rejected
+	if _, _, err := tester.db.StateDiff(common.Hash{0x1}, target); err == nil {
+		t.Fatal("Expected error for unknown source state")
+	}
+	if _, _, err := tester.db.StateDiff(target, tester.roots[14]); err == nil {
+		t.Fatal("Expected error for source state above the target")
+	}
+}
@@ -295,6 +295,34 @@ func (tree *layerTree) cap(root common.Hash, layers int) error {
 	return nil
 }
 
+// flush persists the write buffer of the disk layer, replacing it with a new
+// disk layer of the same state. The diff layers on top are linked to the new
+// one.
+func (tree *layerTree) flush() error {
+	tree.lock.Lock()
+	defer tree.lock.Unlock()
+
+	base, err := tree.base.flushBuffer()
+	if err != nil {
+		return err
+	}
+	if base == tree.base {
+		return nil
+	}
+	for _, l := range tree.layers {
+		if diff, ok := l.(*diffLayer); ok {
+			diff.lock.Lock()
+			if diff.parent == layer(tree.base) {
+				diff.parent = base
+			}
+			diff.lock.Unlock()
+		}
+	}
+	tree.layers[base.rootHash()] = base
+	tree.base = base
+	return nil
+}
+
 // bottom returns the bottom-most disk layer in this tree.
 func (tree *layerTree) bottom() *diskLayer {
 	tree.lock.RLock()